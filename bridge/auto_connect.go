@@ -1,11 +1,13 @@
 package bridge
 
 import (
+	"context"
 	"os"
 	"strings"
 	"time"
 
 	"rockerboo/mcp-lsp-bridge/logger"
+	"rockerboo/mcp-lsp-bridge/types"
 )
 
 const defaultAutoConnectLanguages = "bsl"
@@ -31,6 +33,13 @@ func parseAutoConnectLanguages() []string {
 // StartAutoConnect triggers best-effort background connection to default language server clients.
 // This is intentionally non-blocking and safe to call multiple times.
 // It includes simple throttling to allow retries after failures.
+//
+// The connect loop runs as a service under the bridge's supervisor (see
+// supervisor.go), so MCPLSPBridge.Shutdown waits for it to return before
+// disconnecting clients. GetClientForLanguage doesn't take a context in
+// this tree, so a call already in flight when Shutdown runs can't be
+// preempted mid-call - the supervisor context only stops the loop from
+// starting its *next* language.
 func (b *MCPLSPBridge) StartAutoConnect() {
 	b.autoConnectMu.Lock()
 	defer b.autoConnectMu.Unlock()
@@ -47,17 +56,31 @@ func (b *MCPLSPBridge) StartAutoConnect() {
 	langs := parseAutoConnectLanguages()
 	logger.Info("Auto-connect: starting language clients", strings.Join(langs, ","))
 
-	go func() {
+	b.supervisor().Add("auto-connect", ServiceFunc(func(ctx context.Context) error {
+		b.CheckAllPosture()
 		for _, lang := range langs {
+			if ctx.Err() != nil {
+				return nil
+			}
 			if _, err := b.GetClientForLanguage(lang); err != nil {
 				logger.Error("Auto-connect: failed to connect language client", lang, err)
 				continue
 			}
+			// Populate the command registry from this client's negotiated
+			// capabilities now, at first connect - not just on a later
+			// reload/restart - so execute_command's destructive/deny gate
+			// (see command_registry.go) applies to every server-advertised
+			// command from the moment it's usable.
+			b.SyncCommandRegistry(types.Language(lang))
 			logger.Info("Auto-connect: connected language client", lang)
 		}
+		// Background version/vulnerability checks (see health.go); cheap
+		// to start repeatedly, StartHealthChecks only registers once.
+		b.StartHealthChecks()
 		// Trigger warm-up after best-effort connect.
 		b.StartWarmup()
-	}()
+		return nil
+	}))
 }
 
 // SyncAutoConnect performs synchronous connection to language server clients.
@@ -77,6 +100,8 @@ func (b *MCPLSPBridge) SyncAutoConnect() error {
 	langs := parseAutoConnectLanguages()
 	logger.Info("Sync auto-connect: connecting language clients", strings.Join(langs, ","))
 
+	b.CheckAllPosture()
+
 	var lastErr error
 	for _, lang := range langs {
 		if _, err := b.GetClientForLanguage(lang); err != nil {
@@ -84,6 +109,7 @@ func (b *MCPLSPBridge) SyncAutoConnect() error {
 			lastErr = err
 			continue
 		}
+		b.SyncCommandRegistry(types.Language(lang))
 		logger.Info("Sync auto-connect: connected language client", lang)
 	}
 
@@ -0,0 +1,215 @@
+package bridge
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"rockerboo/mcp-lsp-bridge/logger"
+	"rockerboo/mcp-lsp-bridge/utils"
+
+	"github.com/myleshyson/lsprotocol-go/protocol"
+	"go.etcd.io/bbolt"
+)
+
+const callGraphCacheBucket = "callgraph"
+const callGraphCacheDir = ".mcp-lsp-bridge"
+const callGraphCacheFile = "callgraph.db"
+
+// CallGraphCache persists call_graph.go's IncomingCalls/OutgoingCalls
+// results across runs in an embedded bbolt store, keyed on
+// uri + sha256(fileContent) + symbolID + "incoming"|"outgoing" so an
+// edited file can never serve a stale result - its content hash simply
+// changes and the old entries become unreachable. callGraphBuilder
+// consults the cache before calling the LSP (see
+// processCallGraphJob/callGraphCacheFor); on a stable codebase this turns
+// a second call_graph or reachability_analysis run from seconds into
+// milliseconds.
+type CallGraphCache struct {
+	db *bbolt.DB
+
+	mu     sync.Mutex
+	hits   int64
+	misses int64
+}
+
+// NewCallGraphCache opens (creating if needed) the bbolt-backed cache
+// under workspaceRoot/.mcp-lsp-bridge/callgraph.db.
+func NewCallGraphCache(workspaceRoot string) (*CallGraphCache, error) {
+	dir := filepath.Join(workspaceRoot, callGraphCacheDir)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("call graph cache: create %q: %w", dir, err)
+	}
+
+	db, err := bbolt.Open(filepath.Join(dir, callGraphCacheFile), 0o644, nil)
+	if err != nil {
+		return nil, fmt.Errorf("call graph cache: open: %w", err)
+	}
+
+	err = db.Update(func(tx *bbolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists([]byte(callGraphCacheBucket))
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("call graph cache: init bucket: %w", err)
+	}
+
+	return &CallGraphCache{db: db}, nil
+}
+
+// Close releases the underlying bbolt handle.
+func (c *CallGraphCache) Close() error {
+	return c.db.Close()
+}
+
+// HashFile returns the sha256 of the file backing uri, for use as the
+// cache key's content-hash component. uri may be a file://, sftp:// or
+// memfs:// URI (see utils.URIResolver) or a plain local path.
+func HashFile(uri string) (string, error) {
+	data, err := utils.ReadURI(uri)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+func callGraphCacheKey(uri, fileHash, symbolID, direction string) []byte {
+	return []byte(strings.Join([]string{uri, fileHash, symbolID, direction}, "\x00"))
+}
+
+// GetIncoming returns the cached IncomingCalls result for symbolID in the
+// file at uri with content fileHash, if present.
+func (c *CallGraphCache) GetIncoming(uri, fileHash, symbolID string) ([]protocol.CallHierarchyIncomingCall, bool) {
+	var calls []protocol.CallHierarchyIncomingCall
+	ok := c.get(callGraphCacheKey(uri, fileHash, symbolID, "incoming"), &calls)
+	return calls, ok
+}
+
+// PutIncoming stores an IncomingCalls result for later GetIncoming calls.
+func (c *CallGraphCache) PutIncoming(uri, fileHash, symbolID string, calls []protocol.CallHierarchyIncomingCall) error {
+	return c.put(callGraphCacheKey(uri, fileHash, symbolID, "incoming"), calls)
+}
+
+// GetOutgoing returns the cached OutgoingCalls result for symbolID in the
+// file at uri with content fileHash, if present.
+func (c *CallGraphCache) GetOutgoing(uri, fileHash, symbolID string) ([]protocol.CallHierarchyOutgoingCall, bool) {
+	var calls []protocol.CallHierarchyOutgoingCall
+	ok := c.get(callGraphCacheKey(uri, fileHash, symbolID, "outgoing"), &calls)
+	return calls, ok
+}
+
+// PutOutgoing stores an OutgoingCalls result for later GetOutgoing calls.
+func (c *CallGraphCache) PutOutgoing(uri, fileHash, symbolID string, calls []protocol.CallHierarchyOutgoingCall) error {
+	return c.put(callGraphCacheKey(uri, fileHash, symbolID, "outgoing"), calls)
+}
+
+func (c *CallGraphCache) get(key []byte, out any) bool {
+	var data []byte
+	_ = c.db.View(func(tx *bbolt.Tx) error {
+		if v := tx.Bucket([]byte(callGraphCacheBucket)).Get(key); v != nil {
+			data = append([]byte(nil), v...)
+		}
+		return nil
+	})
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if data == nil {
+		c.misses++
+		return false
+	}
+
+	if err := json.Unmarshal(data, out); err != nil {
+		logger.Error("call graph cache: corrupt entry, treating as miss", err)
+		c.misses++
+		return false
+	}
+
+	c.hits++
+	return true
+}
+
+func (c *CallGraphCache) put(key []byte, value any) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("call graph cache: marshal: %w", err)
+	}
+
+	return c.db.Update(func(tx *bbolt.Tx) error {
+		return tx.Bucket([]byte(callGraphCacheBucket)).Put(key, data)
+	})
+}
+
+// Counts returns the cumulative hit/miss counts since the cache was
+// opened, for CallGraphResult.CacheHits/CacheMisses.
+func (c *CallGraphCache) Counts() (hits, misses int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.hits, c.misses
+}
+
+// CallGraphCache returns the bridge's on-disk call-graph cache, opening it
+// under the first allowed directory on first use. Returns nil if no
+// allowed directories are configured or the cache failed to open once
+// already - callers (see callGraphCacheFor in call_graph.go) treat a nil
+// cache as "caching disabled" and fall back to uncached LSP calls.
+func (b *MCPLSPBridge) CallGraphCache() *CallGraphCache {
+	b.callGraphCacheMu.Lock()
+	defer b.callGraphCacheMu.Unlock()
+
+	if b.callGraphCache != nil {
+		return b.callGraphCache
+	}
+	if b.callGraphCacheFailed {
+		return nil
+	}
+
+	roots := b.AllowedDirectories()
+	if len(roots) == 0 {
+		return nil
+	}
+
+	cache, err := NewCallGraphCache(roots[0])
+	if err != nil {
+		logger.Error("CallGraphCache: failed to open", err)
+		b.callGraphCacheFailed = true
+		return nil
+	}
+
+	b.callGraphCache = cache
+	return b.callGraphCache
+}
+
+// InvalidateURI removes every cached entry for uri, regardless of which
+// content hash or symbol it was stored under. The stale entries from
+// before an edit would never be looked up again anyway (the key includes
+// the current content hash), but removing them keeps the on-disk cache
+// from growing unboundedly across repeated edits to the same file.
+func (c *CallGraphCache) InvalidateURI(uri string) error {
+	prefix := uri + "\x00"
+
+	return c.db.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket([]byte(callGraphCacheBucket))
+		cur := b.Cursor()
+
+		var stale [][]byte
+		for k, _ := cur.Seek([]byte(prefix)); k != nil && strings.HasPrefix(string(k), prefix); k, _ = cur.Next() {
+			stale = append(stale, append([]byte(nil), k...))
+		}
+
+		for _, k := range stale {
+			if err := b.Delete(k); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,208 @@
+package bridge
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"rockerboo/mcp-lsp-bridge/logger"
+	"rockerboo/mcp-lsp-bridge/types"
+
+	"gopkg.in/yaml.v3"
+)
+
+// CommandPolicy tags how ExecuteCommandTool is willing to dispatch a
+// workspace/executeCommand command.
+type CommandPolicy string
+
+const (
+	// CommandPolicySafe commands dispatch with no extra confirmation.
+	CommandPolicySafe CommandPolicy = "safe"
+	// CommandPolicyDestructive commands require an explicit confirm:true
+	// argument from the caller before they are dispatched.
+	CommandPolicyDestructive CommandPolicy = "destructive"
+	// CommandPolicyDenied commands are never dispatched.
+	CommandPolicyDenied CommandPolicy = "denied"
+)
+
+// defaultCommandPolicy is applied to commands the connected server
+// advertises but that commands.yaml says nothing about: their argument
+// shape is unknown, so the safest default is to treat them as destructive
+// rather than silently allowing them through unconfirmed.
+const defaultCommandPolicy = CommandPolicyDestructive
+
+// commandsConfigEnv names the environment variable pointing at the
+// commands.yaml policy file. Mirrors MCP_AUTO_CONNECT_LANGUAGES in
+// auto_connect.go: an env-driven override with a sane relative default.
+const commandsConfigEnv = "MCP_COMMANDS_CONFIG"
+
+const defaultCommandsConfigPath = "commands.yaml"
+
+// CommandSpec describes one execute_command command: its argument schema
+// (a small JSON-Schema-like object, see ValidateArguments), a
+// human-readable description, and the policy tag that gates dispatch.
+type CommandSpec struct {
+	Command     string         `yaml:"command" json:"command"`
+	Description string         `yaml:"description" json:"description,omitempty"`
+	Policy      CommandPolicy  `yaml:"policy" json:"policy"`
+	Schema      map[string]any `yaml:"schema" json:"schema,omitempty"`
+}
+
+// commandsConfigFile is the on-disk shape of commands.yaml: a map from
+// language ID (e.g. "bsl") to the list of commands known for it.
+type commandsConfigFile map[string][]CommandSpec
+
+// CommandRegistry tracks the known execute_command commands per language,
+// merged from two sources: serverCapabilities.ExecuteCommandProvider.Commands
+// (authoritative — what the connected server actually supports) and a
+// user-supplied commands.yaml (schema/description/policy metadata). A
+// command the server advertises but commands.yaml doesn't describe still
+// registers, with an empty schema and defaultCommandPolicy.
+type CommandRegistry struct {
+	mu     sync.RWMutex
+	byLang map[types.Language]map[string]CommandSpec
+}
+
+// NewCommandRegistry returns an empty registry. Populate it with
+// LoadCommandsConfig followed by Sync for each connected language.
+func NewCommandRegistry() *CommandRegistry {
+	return &CommandRegistry{byLang: make(map[types.Language]map[string]CommandSpec)}
+}
+
+// LoadCommandsConfig reads the commands.yaml policy file named by
+// MCP_COMMANDS_CONFIG (or defaultCommandsConfigPath if unset) and merges its
+// schema/description/policy metadata into the registry, keyed by language.
+// A missing file is not an error: commands.yaml is optional, and commands
+// still register (with defaultCommandPolicy) from server capabilities alone.
+func (r *CommandRegistry) LoadCommandsConfig() error {
+	path := strings.TrimSpace(os.Getenv(commandsConfigEnv))
+	if path == "" {
+		path = defaultCommandsConfigPath
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			logger.Info("CommandRegistry: no commands.yaml found, using capabilities only", path)
+			return nil
+		}
+		return fmt.Errorf("read commands config %q: %w", path, err)
+	}
+
+	var cfg commandsConfigFile
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return fmt.Errorf("parse commands config %q: %w", path, err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for lang, specs := range cfg {
+		language := types.Language(lang)
+		bucket, ok := r.byLang[language]
+		if !ok {
+			bucket = make(map[string]CommandSpec)
+			r.byLang[language] = bucket
+		}
+		for _, spec := range specs {
+			if spec.Policy == "" {
+				spec.Policy = defaultCommandPolicy
+			}
+			bucket[spec.Command] = spec
+		}
+	}
+
+	return nil
+}
+
+// Sync reconciles the registry for one language against the command names a
+// connected server actually advertises (serverCapabilities.ExecuteCommandProvider.Commands).
+// Commands no longer advertised are dropped; newly advertised commands not
+// already described by commands.yaml are added with defaultCommandPolicy and
+// no schema. Call this whenever a client for language is (re)initialized so
+// the registry stays in sync with what the live server supports.
+func (r *CommandRegistry) Sync(language types.Language, advertised []string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	bucket, ok := r.byLang[language]
+	if !ok {
+		bucket = make(map[string]CommandSpec)
+		r.byLang[language] = bucket
+	}
+
+	known := make(map[string]bool, len(advertised))
+	for _, command := range advertised {
+		known[command] = true
+		if _, exists := bucket[command]; !exists {
+			bucket[command] = CommandSpec{Command: command, Policy: defaultCommandPolicy}
+		}
+	}
+
+	for command := range bucket {
+		if !known[command] {
+			delete(bucket, command)
+		}
+	}
+}
+
+// Get returns the spec for command under language, if known.
+func (r *CommandRegistry) Get(language types.Language, command string) (CommandSpec, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	spec, ok := r.byLang[language][command]
+	return spec, ok
+}
+
+// List returns every known command for language, sorted by name, for
+// ExecuteCommandTool's no-argument self-discovery response.
+func (r *CommandRegistry) List(language types.Language) []CommandSpec {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	bucket := r.byLang[language]
+	specs := make([]CommandSpec, 0, len(bucket))
+	for _, spec := range bucket {
+		specs = append(specs, spec)
+	}
+	sort.Slice(specs, func(i, j int) bool { return specs[i].Command < specs[j].Command })
+	return specs
+}
+
+// CommandRegistry returns the bridge's command registry, creating and
+// loading it from commands.yaml on first use.
+func (b *MCPLSPBridge) CommandRegistry() *CommandRegistry {
+	b.commandRegistryMu.Lock()
+	defer b.commandRegistryMu.Unlock()
+
+	if b.commandRegistry == nil {
+		b.commandRegistry = NewCommandRegistry()
+		if err := b.commandRegistry.LoadCommandsConfig(); err != nil {
+			logger.Error("CommandRegistry: failed to load commands.yaml", err)
+		}
+	}
+
+	return b.commandRegistry
+}
+
+// SyncCommandRegistry refreshes the command registry for language from the
+// connected client's negotiated ServerCapabilities. It is a no-op if no
+// client is connected for language, or the server advertises no
+// ExecuteCommandProvider. Call this after GetClientForLanguage /
+// ReloadAll (re)initializes a client so agents calling execute_command with
+// no command argument always see the live command list.
+func (b *MCPLSPBridge) SyncCommandRegistry(language types.Language) {
+	client, ok := b.ListConnectedClients()[types.LanguageServer(language)]
+	if !ok {
+		return
+	}
+
+	provider := client.ServerCapabilities().ExecuteCommandProvider
+	if provider == nil {
+		return
+	}
+
+	b.CommandRegistry().Sync(language, provider.Commands)
+}
@@ -0,0 +1,90 @@
+package bridge
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"rockerboo/mcp-lsp-bridge/types"
+)
+
+// TestCommandRegistryGetUnknownBeforeSync is a regression test for the
+// chunk4-5 review finding: a command the server advertises is reported as
+// unknown (Get's second return is false) until Sync has run for that
+// language, which is exactly the window ExecuteCommandTool's destructive/
+// deny gate skips (see execute_command.go's `if known { ... }`). The fix is
+// calling Sync promptly at first connect, not the registry itself, but this
+// pins the registry-level behavior the fix depends on.
+func TestCommandRegistryGetUnknownBeforeSync(t *testing.T) {
+	r := NewCommandRegistry()
+
+	if _, known := r.Get("bsl", "bsl.refactor.extract"); known {
+		t.Fatal("Get reported a command known before Sync ever ran for its language")
+	}
+}
+
+func TestCommandRegistrySyncAddsAdvertisedCommandsWithDefaultPolicy(t *testing.T) {
+	r := NewCommandRegistry()
+	r.Sync("bsl", []string{"bsl.refactor.extract"})
+
+	spec, known := r.Get("bsl", "bsl.refactor.extract")
+	if !known {
+		t.Fatal("Get reported a just-synced command as unknown")
+	}
+	if spec.Policy != defaultCommandPolicy {
+		t.Errorf("Policy = %q, want defaultCommandPolicy (%q)", spec.Policy, defaultCommandPolicy)
+	}
+}
+
+func TestCommandRegistrySyncDropsNoLongerAdvertisedCommands(t *testing.T) {
+	r := NewCommandRegistry()
+	r.Sync("bsl", []string{"bsl.old"})
+	r.Sync("bsl", []string{"bsl.new"})
+
+	if _, known := r.Get("bsl", "bsl.old"); known {
+		t.Error("Get still reports a command dropped from the server's advertised list")
+	}
+	if _, known := r.Get("bsl", "bsl.new"); !known {
+		t.Error("Get doesn't report the newly advertised command")
+	}
+}
+
+func TestCommandRegistrySyncPreservesCommandsYamlPolicy(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "commands.yaml")
+	yaml := "bsl:\n  - command: bsl.dangerous\n    policy: denied\n"
+	if err := os.WriteFile(path, []byte(yaml), 0o644); err != nil {
+		t.Fatalf("write commands.yaml: %v", err)
+	}
+	t.Setenv(commandsConfigEnv, path)
+
+	r := NewCommandRegistry()
+	if err := r.LoadCommandsConfig(); err != nil {
+		t.Fatalf("LoadCommandsConfig: %v", err)
+	}
+
+	// The server still advertises bsl.dangerous; Sync must not clobber the
+	// policy commands.yaml already pinned for it.
+	r.Sync("bsl", []string{"bsl.dangerous"})
+
+	spec, known := r.Get("bsl", "bsl.dangerous")
+	if !known {
+		t.Fatal("Get reported bsl.dangerous as unknown after Sync")
+	}
+	if spec.Policy != CommandPolicyDenied {
+		t.Errorf("Policy = %q, want %q (commands.yaml should win over defaultCommandPolicy)", spec.Policy, CommandPolicyDenied)
+	}
+}
+
+func TestCommandRegistryListSortedByName(t *testing.T) {
+	r := NewCommandRegistry()
+	r.Sync("bsl", []string{"bsl.zzz", "bsl.aaa"})
+
+	specs := r.List(types.Language("bsl"))
+	if len(specs) != 2 {
+		t.Fatalf("len(specs) = %d, want 2", len(specs))
+	}
+	if specs[0].Command != "bsl.aaa" || specs[1].Command != "bsl.zzz" {
+		t.Errorf("List() = %v, want sorted by command name", specs)
+	}
+}
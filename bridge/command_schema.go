@@ -0,0 +1,96 @@
+package bridge
+
+import "fmt"
+
+// ValidateArguments checks args (the already-unmarshalled arguments_json
+// array) against spec.Schema, a small JSON-Schema-like object describing the
+// arguments array: {"type":"array","items":[<per-position schema>,...],"minItems":N}.
+// It returns one human-readable error per failing field/position rather than
+// stopping at the first problem, so ExecuteCommandTool can report everything
+// wrong in one response. A nil or empty schema always passes: commands the
+// server advertises but commands.yaml doesn't describe have no known shape
+// to check against.
+func ValidateArguments(spec CommandSpec, args []any) []string {
+	if len(spec.Schema) == 0 {
+		return nil
+	}
+
+	var errs []string
+
+	if minItems, ok := asInt(spec.Schema["minItems"]); ok && len(args) < minItems {
+		errs = append(errs, fmt.Sprintf("arguments: expected at least %d item(s), got %d", minItems, len(args)))
+	}
+
+	items, _ := spec.Schema["items"].([]any)
+	for i, itemSchema := range items {
+		schema, ok := itemSchema.(map[string]any)
+		if !ok {
+			continue
+		}
+		if i >= len(args) {
+			if required, _ := schema["required"].(bool); required {
+				errs = append(errs, fmt.Sprintf("arguments[%d]: required but missing", i))
+			}
+			continue
+		}
+		if err := validateValue(fmt.Sprintf("arguments[%d]", i), args[i], schema); err != "" {
+			errs = append(errs, err)
+		}
+	}
+
+	return errs
+}
+
+// validateValue checks a single value against a JSON-Schema-like fragment
+// ({"type":..., "enum":[...]}) and returns a description of the mismatch, or
+// "" if value satisfies it.
+func validateValue(path string, value any, schema map[string]any) string {
+	if wantType, ok := schema["type"].(string); ok {
+		if got := jsonSchemaType(value); got != wantType {
+			return fmt.Sprintf("%s: expected type %q, got %q", path, wantType, got)
+		}
+	}
+
+	if enum, ok := schema["enum"].([]any); ok && len(enum) > 0 {
+		for _, allowed := range enum {
+			if allowed == value {
+				return ""
+			}
+		}
+		return fmt.Sprintf("%s: value %v is not one of the allowed values %v", path, value, enum)
+	}
+
+	return ""
+}
+
+// jsonSchemaType maps a decoded JSON value (as produced by
+// encoding/json.Unmarshal into any) to its JSON Schema type name.
+func jsonSchemaType(value any) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	default:
+		return "unknown"
+	}
+}
+
+func asInt(value any) (int, bool) {
+	switch v := value.(type) {
+	case int:
+		return v, true
+	case float64:
+		return int(v), true
+	default:
+		return 0, false
+	}
+}
@@ -0,0 +1,60 @@
+package bridge
+
+import "testing"
+
+func TestValidateArgumentsNilSchemaAlwaysPasses(t *testing.T) {
+	spec := CommandSpec{Command: "bsl.unknown"}
+	if errs := ValidateArguments(spec, []any{"anything", 1, true}); errs != nil {
+		t.Errorf("ValidateArguments with no schema = %v, want nil", errs)
+	}
+}
+
+func TestValidateArgumentsMinItems(t *testing.T) {
+	spec := CommandSpec{Schema: map[string]any{"minItems": float64(2)}}
+	if errs := ValidateArguments(spec, []any{"only-one"}); len(errs) != 1 {
+		t.Fatalf("ValidateArguments = %v, want one minItems error", errs)
+	}
+}
+
+func TestValidateArgumentsItemTypeMismatch(t *testing.T) {
+	spec := CommandSpec{
+		Schema: map[string]any{
+			"items": []any{
+				map[string]any{"type": "string"},
+			},
+		},
+	}
+	errs := ValidateArguments(spec, []any{float64(42)})
+	if len(errs) != 1 {
+		t.Fatalf("ValidateArguments = %v, want one type-mismatch error", errs)
+	}
+}
+
+func TestValidateArgumentsRequiredItemMissing(t *testing.T) {
+	spec := CommandSpec{
+		Schema: map[string]any{
+			"items": []any{
+				map[string]any{"type": "string", "required": true},
+			},
+		},
+	}
+	if errs := ValidateArguments(spec, nil); len(errs) != 1 {
+		t.Fatalf("ValidateArguments = %v, want one required-missing error", errs)
+	}
+}
+
+func TestValidateArgumentsEnumRejectsDisallowedValue(t *testing.T) {
+	spec := CommandSpec{
+		Schema: map[string]any{
+			"items": []any{
+				map[string]any{"type": "string", "enum": []any{"fast", "slow"}},
+			},
+		},
+	}
+	if errs := ValidateArguments(spec, []any{"medium"}); len(errs) != 1 {
+		t.Fatalf("ValidateArguments = %v, want one enum error", errs)
+	}
+	if errs := ValidateArguments(spec, []any{"fast"}); errs != nil {
+		t.Errorf("ValidateArguments with an allowed enum value = %v, want nil", errs)
+	}
+}
@@ -0,0 +1,190 @@
+package bridge
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+
+	"rockerboo/mcp-lsp-bridge/logger"
+
+	"gopkg.in/yaml.v3"
+)
+
+// entryPointsConfigEnv names the environment variable pointing at the
+// entry_points.yaml config file. Mirrors commandsConfigEnv in
+// command_registry.go: an env-driven override with a sane relative default.
+const entryPointsConfigEnv = "MCP_ENTRY_POINTS_CONFIG"
+
+const defaultEntryPointsConfigPath = "entry_points.yaml"
+
+// EntryPointPattern describes one way call_graph.go's entry-point
+// detection can recognize a symbol as a workspace root: an exact name, a
+// substring, or a regular expression against the symbol name, optionally
+// narrowed to a symbol kind (e.g. "Method") so a generic pattern doesn't
+// also match an unrelated variable that happens to share the name.
+// Exactly one of Exact, Substring, or Regex should be set; if more than
+// one is, Exact wins, then Substring, then Regex.
+type EntryPointPattern struct {
+	Name      string `yaml:"name" json:"name"`
+	Exact     string `yaml:"exact,omitempty" json:"exact,omitempty"`
+	Substring string `yaml:"substring,omitempty" json:"substring,omitempty"`
+	Regex     string `yaml:"regex,omitempty" json:"regex,omitempty"`
+	Kind      string `yaml:"kind,omitempty" json:"kind,omitempty"`
+
+	compiled *regexp.Regexp
+}
+
+// compile precompiles Regex, if set, so Match doesn't pay recompilation
+// cost on every lookup.
+func (p *EntryPointPattern) compile() error {
+	if p.Regex == "" {
+		return nil
+	}
+
+	re, err := regexp.Compile(p.Regex)
+	if err != nil {
+		return fmt.Errorf("entry point pattern %q: invalid regex %q: %w", p.Name, p.Regex, err)
+	}
+	p.compiled = re
+
+	return nil
+}
+
+// matches reports whether name (and, if this pattern constrains it, kind)
+// satisfy this pattern.
+func (p *EntryPointPattern) matches(name, kind string) bool {
+	if p.Kind != "" && !strings.EqualFold(p.Kind, kind) {
+		return false
+	}
+
+	switch {
+	case p.Exact != "":
+		return p.Exact == name
+	case p.Substring != "":
+		return strings.Contains(name, p.Substring)
+	case p.compiled != nil:
+		return p.compiled.MatchString(name)
+	default:
+		return false
+	}
+}
+
+// entryPointsConfigFile is the on-disk shape of entry_points.yaml.
+type entryPointsConfigFile struct {
+	EntryPoints []EntryPointPattern `yaml:"entry_points"`
+}
+
+// EntryPointRegistry tracks the patterns call_graph.go and
+// reachability_analysis.go use to recognize workspace entry points beyond
+// the hard-coded bslEntryPoints fallback, merged from two sources: a
+// user-supplied entry_points.yaml (LoadEntryPointsConfig) and runtime
+// additions via the register_entry_points tool (Register). It is purely
+// additive - it never removes the built-in detection, so teams extend
+// coverage for localized or project-specific naming conventions without
+// recompiling or losing the defaults.
+type EntryPointRegistry struct {
+	mu       sync.RWMutex
+	patterns []EntryPointPattern
+}
+
+// NewEntryPointRegistry returns an empty registry. Populate it with
+// LoadEntryPointsConfig and/or Register.
+func NewEntryPointRegistry() *EntryPointRegistry {
+	return &EntryPointRegistry{}
+}
+
+// LoadEntryPointsConfig reads the entry_points.yaml file named by
+// MCP_ENTRY_POINTS_CONFIG (or defaultEntryPointsConfigPath if unset) and
+// registers each pattern it declares. A missing file is not an error:
+// entry_points.yaml is optional, teams happy with the built-in BSL
+// patterns never need one.
+func (r *EntryPointRegistry) LoadEntryPointsConfig() error {
+	path := strings.TrimSpace(os.Getenv(entryPointsConfigEnv))
+	if path == "" {
+		path = defaultEntryPointsConfigPath
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			logger.Info("EntryPointRegistry: no entry_points.yaml found, using built-in patterns only", path)
+			return nil
+		}
+		return fmt.Errorf("read entry points config %q: %w", path, err)
+	}
+
+	var cfg entryPointsConfigFile
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return fmt.Errorf("parse entry points config %q: %w", path, err)
+	}
+
+	for _, p := range cfg.EntryPoints {
+		if err := r.Register(p); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Register adds pattern to the registry, compiling its regex (if any) up
+// front so Match doesn't pay recompilation cost per lookup.
+func (r *EntryPointRegistry) Register(pattern EntryPointPattern) error {
+	if err := pattern.compile(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.patterns = append(r.patterns, pattern)
+
+	return nil
+}
+
+// Match reports whether name (optionally narrowed by kind, e.g. a
+// symbolKindToString result) satisfies any registered pattern, and if so,
+// which one - callers use the matched pattern's Name for an audit trail
+// (see CallGraphResult.EntryPoints).
+func (r *EntryPointRegistry) Match(name, kind string) (EntryPointPattern, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, p := range r.patterns {
+		if p.matches(name, kind) {
+			return p, true
+		}
+	}
+
+	return EntryPointPattern{}, false
+}
+
+// List returns a snapshot of every registered pattern, in registration
+// order, for the register_entry_points tool's no-argument listing mode.
+func (r *EntryPointRegistry) List() []EntryPointPattern {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	out := make([]EntryPointPattern, len(r.patterns))
+	copy(out, r.patterns)
+
+	return out
+}
+
+// EntryPointRegistry returns the bridge's entry point registry, creating
+// and loading it from entry_points.yaml on first use. Mirrors
+// CommandRegistry's lazy-init pattern in command_registry.go.
+func (b *MCPLSPBridge) EntryPointRegistry() *EntryPointRegistry {
+	b.entryPointRegistryMu.Lock()
+	defer b.entryPointRegistryMu.Unlock()
+
+	if b.entryPointRegistry == nil {
+		b.entryPointRegistry = NewEntryPointRegistry()
+		if err := b.entryPointRegistry.LoadEntryPointsConfig(); err != nil {
+			logger.Error("EntryPointRegistry: failed to load entry_points.yaml", err)
+		}
+	}
+
+	return b.entryPointRegistry
+}
@@ -0,0 +1,71 @@
+package bridge
+
+import (
+	"sort"
+
+	"rockerboo/mcp-lsp-bridge/lsp"
+	"rockerboo/mcp-lsp-bridge/types"
+)
+
+// RouteFeatureForLanguage answers "which configured server handles feature
+// for language?": it looks up every server declared for language in
+// LanguageServerMap, keeps the ones whose LanguageServerConfig.SupportsFeature
+// allows feature, and returns their names sorted by name for determinism
+// (the order servers are declared in config is not kept).
+//
+// This mirrors the multi-LS-per-language design used by editors such as
+// Helix, where a single language can be backed by several LSP servers, each
+// restricted to a subset of features via only-features/except-features.
+//
+// Note: full dispatch still connects one client per language via
+// GetClientForLanguage, so callers currently use this to pick which
+// configured server *should* answer a request and to decide a fallback
+// order when the active client reports "Unhandled method …" — not to hold
+// multiple live connections per language simultaneously.
+func (b *MCPLSPBridge) RouteFeatureForLanguage(language types.Language, feature string) []types.LanguageServer {
+	serverConfigs := b.config.GetLanguageServers()
+
+	languageServerMap, ok := b.config.(interface {
+		GetLanguageServerMap() map[types.LanguageServer][]types.Language
+	})
+	if !ok {
+		return nil
+	}
+
+	var candidates []types.LanguageServer
+	for serverName, languages := range languageServerMap.GetLanguageServerMap() {
+		if !containsLanguage(languages, language) {
+			continue
+		}
+
+		provider, ok := serverConfigs[serverName]
+		if !ok {
+			continue
+		}
+
+		cfg, ok := provider.(*lsp.LanguageServerConfig)
+		if !ok {
+			// Unknown provider implementation: can't inspect
+			// only-features/except-features, so assume it supports
+			// everything rather than silently dropping it.
+			candidates = append(candidates, serverName)
+			continue
+		}
+
+		if cfg.SupportsFeature(feature) {
+			candidates = append(candidates, serverName)
+		}
+	}
+
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i] < candidates[j] })
+	return candidates
+}
+
+func containsLanguage(languages []types.Language, target types.Language) bool {
+	for _, l := range languages {
+		if l == target {
+			return true
+		}
+	}
+	return false
+}
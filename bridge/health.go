@@ -0,0 +1,124 @@
+package bridge
+
+import (
+	"context"
+	"time"
+
+	"rockerboo/mcp-lsp-bridge/logger"
+	"rockerboo/mcp-lsp-bridge/lsp"
+	"rockerboo/mcp-lsp-bridge/types"
+)
+
+// healthCheckTTL bounds how long a cached HealthReports entry is reused
+// before CheckAllHealth probes a server again - version/govulncheck checks
+// are cheap but not free, and this is advisory status (see
+// lsp.HealthResult), not a per-call gate like CheckAllPosture.
+const healthCheckTTL = 5 * time.Minute
+
+// healthCheckInterval is how often the background "healthcheck" service
+// (see StartHealthChecks) refreshes HealthReports on its own, independent
+// of any tool call.
+const healthCheckInterval = 5 * time.Minute
+
+// CheckAllHealth runs lsp.RunHealthCheck for every configured language
+// server whose cached result (if any) is older than healthCheckTTL, and
+// records the results for HealthReports. Returns the full current
+// snapshot, including entries served from cache.
+func (b *MCPLSPBridge) CheckAllHealth() map[types.LanguageServer]lsp.HealthResult {
+	serverConfigs := b.config.GetLanguageServers()
+	now := time.Now()
+
+	for serverName, provider := range serverConfigs {
+		b.healthMu.Lock()
+		cached, ok := b.healthCache[serverName]
+		b.healthMu.Unlock()
+		if ok && now.Sub(cached.CheckedAt) < healthCheckTTL {
+			continue
+		}
+
+		cfg := languageServerConfigFromProvider(provider)
+		result := lsp.RunHealthCheck(string(serverName), cfg)
+		if !result.OK {
+			logger.Warn("CheckAllHealth: health check degraded", string(serverName), result.Warnings)
+		}
+
+		b.healthMu.Lock()
+		if b.healthCache == nil {
+			b.healthCache = make(map[types.LanguageServer]lsp.HealthResult, len(serverConfigs))
+		}
+		b.healthCache[serverName] = result
+		b.healthMu.Unlock()
+	}
+
+	return b.HealthReports()
+}
+
+// HealthReports returns a snapshot of the most recent lsp.HealthResult per
+// server, as populated by CheckAllHealth. It never runs a new check itself
+// - call CheckAllHealth directly, or rely on the background healthcheck
+// service (see StartHealthChecks), for that.
+func (b *MCPLSPBridge) HealthReports() map[types.LanguageServer]lsp.HealthResult {
+	b.healthMu.Lock()
+	defer b.healthMu.Unlock()
+
+	out := make(map[types.LanguageServer]lsp.HealthResult, len(b.healthCache))
+	for k, v := range b.healthCache {
+		out[k] = v
+	}
+	return out
+}
+
+// InvalidateHealthCache clears every cached HealthResult, so the next
+// CheckAllHealth call re-probes every configured server regardless of
+// healthCheckTTL. Used by lsp_healthcheck's force=true option.
+func (b *MCPLSPBridge) InvalidateHealthCache() {
+	b.healthMu.Lock()
+	defer b.healthMu.Unlock()
+	b.healthCache = nil
+}
+
+// IsServerHealthKnownBad reports whether serverName's cached health result
+// says it's unhealthy for a reason stronger than a stale version warning -
+// specifically, that govulncheck found a known vulnerability affecting it.
+// StartWarmup consults this to refuse warming up a server with a known
+// vulnerable release rather than indexing against it as if nothing were
+// wrong.
+func (b *MCPLSPBridge) IsServerHealthKnownBad(serverName types.LanguageServer) bool {
+	b.healthMu.Lock()
+	defer b.healthMu.Unlock()
+
+	result, ok := b.healthCache[serverName]
+	return ok && len(result.Vulnerabilities) > 0
+}
+
+// StartHealthChecks registers a periodic background service under the
+// bridge's supervisor (see supervisor.go) that runs CheckAllHealth once
+// immediately and then every healthCheckInterval, so
+// LSPStatus.Clients[].Health (see mcpserver/tools/readiness.go) reflects
+// version/vulnerability staleness without every tool call paying the probe
+// cost. A started flag makes every call after the first a no-op, so
+// calling it more than once (e.g. from multiple auto-connect passes)
+// doesn't spawn duplicate tickers.
+func (b *MCPLSPBridge) StartHealthChecks() {
+	b.healthMu.Lock()
+	if b.healthChecksStarted {
+		b.healthMu.Unlock()
+		return
+	}
+	b.healthChecksStarted = true
+	b.healthMu.Unlock()
+
+	b.supervisor().Add("healthcheck", ServiceFunc(func(ctx context.Context) error {
+		b.CheckAllHealth()
+		ticker := time.NewTicker(healthCheckInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-ticker.C:
+				b.CheckAllHealth()
+			}
+		}
+	}))
+}
@@ -0,0 +1,190 @@
+package bridge
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"rockerboo/mcp-lsp-bridge/logger"
+	"rockerboo/mcp-lsp-bridge/lsp"
+	"rockerboo/mcp-lsp-bridge/types"
+
+	"github.com/myleshyson/lsprotocol-go/protocol"
+)
+
+// ClientStatus is a per-server lifecycle snapshot for the lsp_lifecycle
+// analysis type: enough to tell an operator whether a connected server is
+// healthy without requiring them to reach for logs.
+type ClientStatus struct {
+	Server          types.LanguageServer
+	Connected       bool
+	Status          string
+	PID             int32
+	Uptime          time.Duration
+	PendingRequests int64
+	Capabilities    []string
+	LastError       string
+}
+
+// ClientStatus returns a sorted-by-name snapshot of every connected client's
+// lifecycle state, for the lsp_lifecycle analysis type's "status" action.
+func (b *MCPLSPBridge) ClientStatus() []ClientStatus {
+	clients := b.ListConnectedClients()
+
+	names := make([]types.LanguageServer, 0, len(clients))
+	for name := range clients {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool { return names[i] < names[j] })
+
+	statuses := make([]ClientStatus, 0, len(names))
+	for _, name := range names {
+		client := clients[name]
+		metrics := client.GetMetrics()
+
+		var uptime time.Duration
+		if lastInit := metrics.GetLastInitialized(); !lastInit.IsZero() {
+			uptime = time.Since(lastInit)
+		}
+
+		var pending int64
+		if pooled, ok := client.(interface{ PoolStats() lsp.RequestPoolStats }); ok {
+			pending = pooled.PoolStats().InFlight
+		}
+
+		statuses = append(statuses, ClientStatus{
+			Server:          name,
+			Connected:       metrics.IsConnected(),
+			Status:          lsp.ClientStatus(metrics.GetStatus()).String(),
+			PID:             metrics.GetProcessID(),
+			Uptime:          uptime,
+			PendingRequests: pending,
+			Capabilities:    summarizeCapabilities(client.ServerCapabilities()),
+			LastError:       metrics.GetLastError(),
+		})
+	}
+
+	return statuses
+}
+
+// summarizeCapabilities reduces a negotiated ServerCapabilities down to the
+// sorted list of top-level capability names the server actually advertises,
+// following the same generic JSON-probing approach as lsp/capabilities.go's
+// capabilityField/capabilityEnabled (rather than hand-listing every known
+// field here too).
+func summarizeCapabilities(caps protocol.ServerCapabilities) []string {
+	raw, err := json.Marshal(caps)
+	if err != nil {
+		return nil
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return nil
+	}
+
+	names := make([]string, 0, len(fields))
+	for name, value := range fields {
+		if string(value) == "null" || string(value) == "false" {
+			continue
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ServerNamesForLanguage resolves every configured server name backing
+// language, reusing the same multi-server LanguageServerMap probe as
+// RouteFeatureForLanguage, and falling back to the single legacy name
+// GetServerNameFromLanguage reports when the config provider doesn't expose
+// that map. Used by lsp_lifecycle's optional "language" option to expand a
+// language into the specific server name(s) RestartClient/StopClient need.
+func (b *MCPLSPBridge) ServerNamesForLanguage(language types.Language) []types.LanguageServer {
+	if languageServerMap, ok := b.config.(interface {
+		GetLanguageServerMap() map[types.LanguageServer][]types.Language
+	}); ok {
+		var names []types.LanguageServer
+		for serverName, languages := range languageServerMap.GetLanguageServerMap() {
+			if containsLanguage(languages, language) {
+				names = append(names, serverName)
+			}
+		}
+		if len(names) > 0 {
+			sort.Slice(names, func(i, j int) bool { return names[i] < names[j] })
+			return names
+		}
+	}
+
+	if name := b.config.GetServerNameFromLanguage(language); name != "" {
+		return []types.LanguageServer{name}
+	}
+	return nil
+}
+
+// RestartClient gracefully restarts exactly one connected server by name,
+// the single-server counterpart to ReloadAll. Like ReloadAll, it refuses
+// clients backed by SessionAdapter: the LSP Session Manager owns their
+// process lifecycle, not this bridge, so there's nothing to reload here.
+func (b *MCPLSPBridge) RestartClient(ctx context.Context, serverName types.LanguageServer) error {
+	client, ok := b.ListConnectedClients()[serverName]
+	if !ok {
+		return fmt.Errorf("RestartClient: no connected client for server %q", serverName)
+	}
+
+	lc, ok := client.(*lsp.LanguageClient)
+	if !ok {
+		return fmt.Errorf("RestartClient: server %q is session-managed and cannot be restarted by this bridge", serverName)
+	}
+
+	provider, ok := b.config.GetLanguageServers()[serverName]
+	if !ok {
+		return fmt.Errorf("RestartClient: no config found for server %q", serverName)
+	}
+
+	if err := lc.Reload(ctx, languageServerConfigFromProvider(provider)); err != nil {
+		return fmt.Errorf("RestartClient: reload %q: %w", serverName, err)
+	}
+
+	b.SyncCommandRegistry(types.Language(serverName))
+	logger.Info("RestartClient: reloaded language client", string(serverName))
+	return nil
+}
+
+// StopClient disconnects exactly one connected server by name and removes
+// it from the bridge so later tool calls stop routing to it. A
+// LanguageClient is fully torn down via Shutdown (shutdown request + exit
+// notification + transport close, see lsp/methods.go); a SessionAdapter's
+// Shutdown is a no-op by design (the Session Manager keeps running), so
+// Close is called instead to actually drop this bridge's connection to it.
+func (b *MCPLSPBridge) StopClient(serverName types.LanguageServer) error {
+	b.mu.Lock()
+	client, ok := b.clients[serverName]
+	if ok {
+		delete(b.clients, serverName)
+	}
+	b.mu.Unlock()
+
+	if !ok {
+		return fmt.Errorf("StopClient: no connected client for server %q", serverName)
+	}
+
+	var err error
+	switch c := client.(type) {
+	case *lsp.LanguageClient:
+		err = c.Shutdown(context.Background())
+	case *lsp.SessionAdapter:
+		err = c.Close()
+	default:
+		err = client.Shutdown()
+	}
+
+	if err != nil {
+		return fmt.Errorf("StopClient: shutdown %q: %w", serverName, err)
+	}
+
+	logger.Info("StopClient: stopped language client", string(serverName))
+	return nil
+}
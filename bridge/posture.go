@@ -0,0 +1,31 @@
+package bridge
+
+import (
+	"rockerboo/mcp-lsp-bridge/logger"
+	"rockerboo/mcp-lsp-bridge/lsp"
+	"rockerboo/mcp-lsp-bridge/types"
+)
+
+// CheckAllPosture runs lsp.RunPostureCheck for every configured language
+// server, not just connected ones, so a broken install can be caught before
+// auto-connect ever tries to spawn/dial it. Results are recorded for
+// PostureReports and returned directly.
+func (b *MCPLSPBridge) CheckAllPosture() map[types.LanguageServer]lsp.PostureResult {
+	serverConfigs := b.config.GetLanguageServers()
+
+	reports := make(map[types.LanguageServer]lsp.PostureResult, len(serverConfigs))
+	for serverName, provider := range serverConfigs {
+		cfg := languageServerConfigFromProvider(provider)
+		result := lsp.RunPostureCheck(string(serverName), cfg)
+		if !result.Passed {
+			logger.Warn("CheckAllPosture: posture check failed", string(serverName), result.Error)
+		}
+		reports[serverName] = result
+	}
+
+	b.postureMu.Lock()
+	b.postureReports = reports
+	b.postureMu.Unlock()
+
+	return reports
+}
@@ -0,0 +1,68 @@
+package bridge
+
+import (
+	"context"
+
+	"rockerboo/mcp-lsp-bridge/logger"
+	"rockerboo/mcp-lsp-bridge/lsp"
+	"rockerboo/mcp-lsp-bridge/types"
+)
+
+// ReloadAll triggers a graceful restart (lsp.LanguageClient.Reload) of every
+// connected client currently backed by a direct LanguageClient, so a config
+// file edit or a SIGHUP-triggered reload can be picked up without ever
+// taking tools offline. Clients backed by SessionAdapter are skipped: the
+// LSP Session Manager they delegate to owns their process lifecycle, not
+// this bridge.
+func (b *MCPLSPBridge) ReloadAll(ctx context.Context) error {
+	clients := b.ListConnectedClients()
+	serverConfigs := b.config.GetLanguageServers()
+
+	var lastErr error
+	for serverName, client := range clients {
+		lc, ok := client.(*lsp.LanguageClient)
+		if !ok {
+			continue
+		}
+
+		provider, ok := serverConfigs[types.LanguageServer(serverName)]
+		if !ok {
+			logger.Warn("ReloadAll: no config found for connected server", string(serverName))
+			continue
+		}
+
+		if err := lc.Reload(ctx, languageServerConfigFromProvider(provider)); err != nil {
+			logger.Error("ReloadAll: reload failed", string(serverName), err)
+			lastErr = err
+			continue
+		}
+		b.SyncCommandRegistry(types.Language(serverName))
+		logger.Info("ReloadAll: reloaded language client", string(serverName))
+	}
+
+	return lastErr
+}
+
+// languageServerConfigFromProvider rebuilds the concrete lsp.LanguageServerConfig
+// Reload needs from whatever types.LanguageServerConfigProvider the bridge
+// holds configs behind. Config is normally loaded straight into
+// lsp.LanguageServerConfig, so the common case just unwraps that (keeping
+// pool/hammer-time overrides intact); otherwise only the fields the
+// provider interface exposes are carried over.
+func languageServerConfigFromProvider(provider types.LanguageServerConfigProvider) lsp.LanguageServerConfig {
+	if cfg, ok := provider.(*lsp.LanguageServerConfig); ok {
+		return *cfg
+	}
+	if cfg, ok := provider.(lsp.LanguageServerConfig); ok {
+		return cfg
+	}
+
+	return lsp.LanguageServerConfig{
+		Command:               provider.GetCommand(),
+		Args:                  provider.GetArgs(),
+		Mode:                  provider.GetMode(),
+		Host:                  provider.GetHost(),
+		Port:                  provider.GetPort(),
+		InitializationOptions: provider.GetInitializationOptions(),
+	}
+}
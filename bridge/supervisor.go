@@ -0,0 +1,169 @@
+package bridge
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"rockerboo/mcp-lsp-bridge/logger"
+	"rockerboo/mcp-lsp-bridge/types"
+)
+
+// Service is anything the bridge's supervisor owns the lifecycle of: a
+// long-lived background task that runs until ctx is cancelled or it has
+// nothing left to do. Serve should return promptly once ctx.Done() fires.
+// A non-nil error return (while ctx is still live) is treated as an
+// unexpected failure and triggers backoff-and-restart - the same "it
+// crashed, bring it back" model cmd/lsp-proxy/supervisor.go already uses
+// for the LSP server child process. This is the in-process goroutine
+// equivalent of that, not a literal import of suture (not vendored in
+// this tree, which has no go.mod at all).
+type Service interface {
+	Serve(ctx context.Context) error
+}
+
+// ServiceFunc adapts a plain function to Service.
+type ServiceFunc func(ctx context.Context) error
+
+func (f ServiceFunc) Serve(ctx context.Context) error { return f(ctx) }
+
+const (
+	supervisorMinBackoff = time.Second
+	supervisorMaxBackoff = 30 * time.Second
+)
+
+// Supervisor runs a set of named Services under a shared root context,
+// restarting any that return a non-nil error with exponential backoff,
+// and waits for all of them to return on Stop. It's deliberately simpler
+// than a general-purpose supervision tree (no per-service restart policy,
+// no dynamic removal): the bridge only ever has a handful of fixed
+// background tasks - auto-connect, warm-up and the periodic health check
+// today, see StartAutoConnect, StartWarmup and StartHealthChecks.
+type Supervisor struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewSupervisor creates a Supervisor whose services run until parent is
+// done or Stop is called.
+func NewSupervisor(parent context.Context) *Supervisor {
+	ctx, cancel := context.WithCancel(parent)
+	return &Supervisor{ctx: ctx, cancel: cancel}
+}
+
+// Context returns the supervisor's root context. It is cancelled by Stop,
+// and is the right parent for any service-specific child context (see
+// WarmupHandle) that also needs to be torn down on shutdown.
+func (s *Supervisor) Context() context.Context {
+	return s.ctx
+}
+
+// Add starts name running in the background under the supervisor's root
+// context, restarting it with exponential backoff if it returns a non-nil
+// error while that context is still live. Add does not block.
+func (s *Supervisor) Add(name string, svc Service) {
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		s.run(name, svc)
+	}()
+}
+
+func (s *Supervisor) run(name string, svc Service) {
+	backoff := supervisorMinBackoff
+	for {
+		err := svc.Serve(s.ctx)
+		if s.ctx.Err() != nil || err == nil {
+			return
+		}
+
+		logger.Error("Supervisor: service exited unexpectedly, restarting", "service", name, "error", err, "backoff", backoff.String())
+
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+
+		backoff *= 2
+		if backoff > supervisorMaxBackoff {
+			backoff = supervisorMaxBackoff
+		}
+	}
+}
+
+// Stop cancels the root context and blocks until every registered service
+// has returned, or ctx expires first.
+func (s *Supervisor) Stop(ctx context.Context) error {
+	s.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// supervisor lazily creates the bridge's root supervisor, rooted at
+// context.Background() since the bridge itself outlives any single
+// request - it's only ever torn down by Shutdown. Mirrors the lazy-init
+// pattern CallGraphCache/EntryPointRegistry already use for other
+// first-use-created bridge state.
+func (b *MCPLSPBridge) supervisor() *Supervisor {
+	b.supervisorMu.Lock()
+	defer b.supervisorMu.Unlock()
+
+	if b.supervisorInst == nil {
+		b.supervisorInst = NewSupervisor(context.Background())
+	}
+	return b.supervisorInst
+}
+
+// Shutdown cancels the bridge's root supervisor context - stopping any
+// in-flight warm-up (via the WarmupHandle's own child context) and
+// auto-connect loop - waits for them to return, and then disconnects
+// every connected language client. It's safe to call even if the
+// supervisor was never started (e.g. no warm-up/auto-connect ever ran).
+//
+// NOTE: GetClientForLanguage itself doesn't take a context in this tree,
+// so a call already in flight when Shutdown runs can't be preempted mid-
+// call; Shutdown can only stop the *next* iteration of a supervised loop
+// from starting, same limitation auto-connect already had before this
+// change.
+func (b *MCPLSPBridge) Shutdown(ctx context.Context) error {
+	b.supervisorMu.Lock()
+	sup := b.supervisorInst
+	b.supervisorMu.Unlock()
+
+	var stopErr error
+	if sup != nil {
+		stopErr = sup.Stop(ctx)
+	}
+
+	for _, serverName := range b.connectedServerNames() {
+		if err := b.StopClient(serverName); err != nil {
+			logger.Error("Shutdown: failed to stop language client", "server", string(serverName), "error", err)
+		}
+	}
+
+	return stopErr
+}
+
+// connectedServerNames returns a snapshot of currently connected server
+// names, for Shutdown to iterate while StopClient mutates b.clients.
+func (b *MCPLSPBridge) connectedServerNames() []types.LanguageServer {
+	clients := b.ListConnectedClients()
+	names := make([]types.LanguageServer, 0, len(clients))
+	for name := range clients {
+		names = append(names, name)
+	}
+	return names
+}
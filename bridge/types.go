@@ -5,8 +5,10 @@ import (
 
 	"time"
 
+	"rockerboo/mcp-lsp-bridge/lsp"
 	"rockerboo/mcp-lsp-bridge/types"
 	"rockerboo/mcp-lsp-bridge/utils"
+	"rockerboo/mcp-lsp-bridge/workspace"
 
 	"github.com/mark3labs/mcp-go/server"
 )
@@ -33,6 +35,76 @@ type MCPLSPBridge struct {
 	warmupRunning     bool
 	warmupDone        bool
 	warmupErr         string
+	warmupHandle      *WarmupHandle
+	// warmupStrategyStatus records, per language server, the
+	// WarmupStrategy resolved for its last warm-up run and how it went
+	// (see resolveWarmupStrategy, runWarmup, WarmupStrategyReports).
+	warmupStrategyStatus map[types.LanguageServer]WarmupStrategyStatus
+
+	// Posture checks (see CheckAllPosture): last pre-flight/continuous
+	// health check result per configured server.
+	postureMu      sync.Mutex
+	postureReports map[types.LanguageServer]lsp.PostureResult
+
+	// Health checks (see CheckAllHealth/StartHealthChecks): cached,
+	// TTL-bounded version/vulnerability status per configured server,
+	// advisory rather than gating (unlike postureReports).
+	healthMu            sync.Mutex
+	healthCache         map[types.LanguageServer]lsp.HealthResult
+	healthChecksStarted bool
+
+	// commandRegistry backs execute_command's schema/policy lookups (see
+	// CommandRegistry and SyncCommandRegistry). Lazily created.
+	commandRegistryMu sync.Mutex
+	commandRegistry   *CommandRegistry
+
+	// entryPointRegistry backs call_graph.go/reachability_analysis.go's
+	// entry-point detection with user-registered patterns (see
+	// EntryPointRegistry). Lazily created.
+	entryPointRegistryMu sync.Mutex
+	entryPointRegistry   *EntryPointRegistry
+
+	// callGraphCache backs call_graph.go's IncomingCalls/OutgoingCalls
+	// caching (see CallGraphCache). Lazily created against the first
+	// allowed directory; callGraphCacheFailed latches a prior open
+	// failure so we don't retry opening it on every call.
+	callGraphCacheMu     sync.Mutex
+	callGraphCache       *CallGraphCache
+	callGraphCacheFailed bool
+
+	// workspaceIndex backs WorkspaceIndex()'s incremental file index (see
+	// workspace.Index): an initial parallel scan followed by an fsnotify
+	// watcher (or poll fallback), whose events are forwarded as
+	// workspace/didChangeWatchedFiles. Lazily started against the first
+	// allowed directory, unlike callGraphCacheFailed a failed start isn't
+	// latched - the next call just tries again.
+	workspaceIndexMu sync.Mutex
+	workspaceIndex   *workspace.Index
+
+	// supervisorInst is the root supervisor for long-lived background
+	// tasks (auto-connect, warm-up, health checks) - see supervisor.go. Lazily created;
+	// Shutdown cancels it and waits for those tasks to return.
+	supervisorMu   sync.Mutex
+	supervisorInst *Supervisor
+
+	// watchRegistryInst backs forwardWorkspaceIndexEvents' per-server glob
+	// filtering and debouncing of workspace.Index events (see
+	// lsp.WatchRegistry, watch_registry.go). Lazily created.
+	watchRegistryMu   sync.Mutex
+	watchRegistryInst *lsp.WatchRegistry
+}
+
+// PostureReports returns a snapshot of the most recent posture check result
+// per server, as populated by CheckAllPosture.
+func (b *MCPLSPBridge) PostureReports() map[types.LanguageServer]lsp.PostureResult {
+	b.postureMu.Lock()
+	defer b.postureMu.Unlock()
+
+	out := make(map[types.LanguageServer]lsp.PostureResult, len(b.postureReports))
+	for k, v := range b.postureReports {
+		out[k] = v
+	}
+	return out
 }
 
 // WarmupStatus returns current warm-up state.
@@ -42,6 +114,30 @@ func (b *MCPLSPBridge) WarmupStatus() (running bool, done bool, err string, star
 	return b.warmupRunning, b.warmupDone, b.warmupErr, b.warmupStartedAt, b.warmupFinishedAt
 }
 
+// WarmupStrategyReports returns a snapshot of the most recently resolved
+// WarmupStrategy and its outcome per language server, as populated by
+// runWarmup. Exposed for the warmup_status MCP tool.
+func (b *MCPLSPBridge) WarmupStrategyReports() map[types.LanguageServer]WarmupStrategyStatus {
+	b.warmupMu.Lock()
+	defer b.warmupMu.Unlock()
+
+	out := make(map[types.LanguageServer]WarmupStrategyStatus, len(b.warmupStrategyStatus))
+	for k, v := range b.warmupStrategyStatus {
+		out[k] = v
+	}
+	return out
+}
+
+// recordWarmupStrategyStatus stores status as the latest WarmupStrategyStatus for lang.
+func (b *MCPLSPBridge) recordWarmupStrategyStatus(lang types.LanguageServer, status WarmupStrategyStatus) {
+	b.warmupMu.Lock()
+	defer b.warmupMu.Unlock()
+	if b.warmupStrategyStatus == nil {
+		b.warmupStrategyStatus = make(map[types.LanguageServer]WarmupStrategyStatus)
+	}
+	b.warmupStrategyStatus[lang] = status
+}
+
 // ListConnectedClients returns a snapshot of currently connected clients.
 // This is intentionally NOT part of interfaces.BridgeInterface to avoid breaking mocks;
 // consume via type assertion in tooling.
@@ -1,36 +1,106 @@
 package bridge
 
 import (
+	"context"
 	"fmt"
-	"io/fs"
 	"os"
-	"path/filepath"
 	"strings"
 	"time"
 
 	"rockerboo/mcp-lsp-bridge/logger"
+	"rockerboo/mcp-lsp-bridge/lsp"
+	"rockerboo/mcp-lsp-bridge/types"
 )
 
+// warmupProgressReporter is the subset of a language client warmup needs
+// to know whether it's still busy: a snapshot of active workDone progress
+// streams. This would live on interfaces.ProgressReporter alongside
+// CancelWorkDoneProgress if the interfaces package existed in this tree
+// (see the pervasive-but-undefined interfaces/types/logger gap noted
+// throughout bridge/mcpserver) - until then it's declared locally, the
+// same duck-typing mcpserver/tools/readiness.go already uses for this
+// exact method.
+type warmupProgressReporter interface {
+	ProgressSnapshot() lsp.ProgressSnapshot
+}
+
+// warmupFields standardizes the key/value pairs attached to warm-up log
+// lines (workspace, elapsed, and optionally server/token) so they can be
+// grepped or aggregated consistently across StartWarmup/runWarmup.
+//
+// NOTE: a real pluggable sink-based logger (console vs. filesystem-with-
+// rotation, chosen by a "sink-type"/"filename"/"max-age"/"max-backups"/
+// "max-size" config block on LSPServerConfigProvider) would live in the
+// logger package itself, which - like interfaces and types - is imported
+// throughout this tree but does not exist on disk in this snapshot. That
+// part of this request can't be done honestly without inventing the
+// package from scratch, which is out of scope here; this only tightens
+// the structured fields at the call sites logger.Info/Warn/Error already
+// support.
+func warmupFields(workspaceRoot string, start time.Time, extra ...any) []any {
+	fields := []any{"workspace", workspaceRoot, "elapsed", time.Since(start).Round(time.Millisecond).String()}
+	return append(fields, extra...)
+}
+
+// WarmupHandle lets a caller observe or abort a single warm-up run started
+// by StartWarmup, independent of the bridge's broader supervisor tree -
+// e.g. to unblock a warm-up stuck in runWarmup's progress-wait loop in
+// docker-exec mode, which previously had no way to be interrupted short of
+// killing the process.
+type WarmupHandle struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// Cancel aborts this warm-up run. Safe to call more than once, or after
+// the run has already finished, or on a nil handle (when StartWarmup
+// declines to start a new run - see its throttling/already-running/
+// already-done checks - it returns the previous run's handle, which is
+// nil if no run was ever started through it).
+func (h *WarmupHandle) Cancel() {
+	if h == nil {
+		return
+	}
+	h.cancel()
+}
+
+// Done reports when this warm-up run has returned, successfully or not -
+// see WarmupStatus for the resulting state. A nil handle is reported as
+// already done.
+func (h *WarmupHandle) Done() <-chan struct{} {
+	if h == nil {
+		closed := make(chan struct{})
+		close(closed)
+		return closed
+	}
+	return h.done
+}
+
 // StartWarmup triggers best-effort warm-up (indexing/cache building) for connected language clients.
 // It is non-blocking and safe to call multiple times; it includes simple throttling.
-func (b *MCPLSPBridge) StartWarmup() {
+//
+// The run is registered as a service under the bridge's supervisor (see
+// supervisor.go) so MCPLSPBridge.Shutdown waits for it to return, and is
+// given its own child context so the returned handle can cancel just this
+// run without tearing down the rest of the supervisor tree.
+func (b *MCPLSPBridge) StartWarmup() *WarmupHandle {
 	b.warmupMu.Lock()
 	defer b.warmupMu.Unlock()
 
 	now := time.Now()
 	// Throttle repeated warmups
 	if !b.warmupLastAttempt.IsZero() && now.Sub(b.warmupLastAttempt) < 10*time.Second {
-		return
+		return b.warmupHandle
 	}
 	b.warmupLastAttempt = now
 
 	// Do not start again if already done successfully
 	if b.warmupDone {
-		return
+		return b.warmupHandle
 	}
 	// Do not start if already running
 	if b.warmupRunning {
-		return
+		return b.warmupHandle
 	}
 
 	b.warmupRunning = true
@@ -39,7 +109,31 @@ func (b *MCPLSPBridge) StartWarmup() {
 	}
 	b.warmupErr = ""
 
-	go b.runWarmup()
+	workspaceRoot := ""
+	if roots := b.AllowedDirectories(); len(roots) > 0 {
+		workspaceRoot = roots[0]
+	}
+	logger.Debug("Warm-up: scheduled", "workspace", workspaceRoot)
+
+	ctx, cancel := context.WithCancel(b.supervisor().Context())
+	done := make(chan struct{})
+	handle := &WarmupHandle{cancel: cancel, done: done}
+	b.warmupHandle = handle
+
+	b.supervisor().Add("warmup", ServiceFunc(func(_ context.Context) error {
+		defer close(done)
+		defer cancel()
+		err := b.runWarmup(ctx)
+		if ctx.Err() != nil {
+			// Cancelled (handle.Cancel(), or Shutdown cancelling the
+			// supervisor root this context descends from) - not a
+			// failure worth restarting.
+			return nil
+		}
+		return err
+	}))
+
+	return handle
 }
 
 func (b *MCPLSPBridge) finishWarmup(err error) {
@@ -81,11 +175,14 @@ func (b *MCPLSPBridge) SyncWarmup() {
 	b.warmupErr = ""
 	b.warmupMu.Unlock()
 
-	// Run warmup synchronously (blocking)
-	b.runWarmup()
+	// Run warmup synchronously (blocking), tied to the supervisor's root
+	// context so MCPLSPBridge.Shutdown can still interrupt it.
+	_ = b.runWarmup(b.supervisor().Context())
 }
 
-func (b *MCPLSPBridge) runWarmup() {
+func (b *MCPLSPBridge) runWarmup(ctx context.Context) error {
+	start := time.Now()
+
 	// For now, warm up the default BSL language client if available.
 	langs := parseAutoConnectLanguages()
 	if len(langs) == 0 {
@@ -99,92 +196,155 @@ func (b *MCPLSPBridge) runWarmup() {
 		workspaceRoot = roots[0]
 	}
 	if workspaceRoot == "" {
-		b.finishWarmup(fmt.Errorf("warmup: no allowed directories configured"))
-		return
+		err := fmt.Errorf("warmup: no allowed directories configured")
+		b.finishWarmup(err)
+		return err
 	}
 
-	logger.Info("Warm-up: starting", "workspaceRoot", workspaceRoot, "langs", strings.Join(langs, ","))
+	logger.Info("Warm-up: starting", warmupFields(workspaceRoot, start, "langs", strings.Join(langs, ","))...)
 
 	// Connect clients synchronously (best effort) so that warmup work can run.
 	for _, lang := range langs {
+		if ctx.Err() != nil {
+			b.finishWarmup(ctx.Err())
+			return ctx.Err()
+		}
 		if _, err := b.GetClientForLanguage(lang); err != nil {
-			logger.Error("Warm-up: failed to connect language client", lang, err)
+			logger.Error("Warm-up: failed to connect language client", warmupFields(workspaceRoot, start, "server", lang, "error", err)...)
 			// Keep going; maybe other langs succeed.
 		}
 	}
 
-	// Pick a small number of .bsl files to touch (parse) to trigger indexing.
-	// Keep it bounded to avoid huge startup cost.
-	const maxFiles = 5
-	var files []string
-	_ = filepath.WalkDir(workspaceRoot, func(p string, d fs.DirEntry, err error) error {
+	// Resolve a WarmupStrategy per language server (see
+	// resolveWarmupStrategy) and run it: open the files it selects, then
+	// issue its probe queries. A server with no explicit Warmup config
+	// falls back to the legacy BSL file-glob-plus-probe behavior, so
+	// existing BSL-only deployments see no change.
+	serverConfigs := b.config.GetLanguageServers()
+	anyFiles := false
+	for _, lang := range langs {
+		if ctx.Err() != nil {
+			b.finishWarmup(ctx.Err())
+			return ctx.Err()
+		}
+
+		// Refuse to warm up a server whose last health check (see
+		// health.go) found a known vulnerability in its release - warming
+		// up would mean indexing a workspace against a binary known to be
+		// broken/unsafe, same as skipping entirely but with a clearer
+		// reason in the logs.
+		if b.IsServerHealthKnownBad(types.LanguageServer(lang)) {
+			logger.Warn("Warm-up: refusing known-vulnerable server", warmupFields(workspaceRoot, start, "server", lang)...)
+			continue
+		}
+
+		var cfg lsp.LanguageServerConfig
+		if provider, ok := serverConfigs[types.LanguageServer(lang)]; ok {
+			cfg = languageServerConfigFromProvider(provider)
+		}
+		strategy, queries := resolveWarmupStrategy(cfg)
+
+		files, err := strategy.Files(ctx, workspaceRoot)
 		if err != nil {
-			return nil
+			logger.Warn("Warm-up: strategy file scan error", warmupFields(workspaceRoot, start, "server", lang, "strategy", strategy.Name(), "error", err)...)
 		}
-		if d.IsDir() {
-			return nil
+		if ctx.Err() != nil {
+			b.finishWarmup(ctx.Err())
+			return ctx.Err()
 		}
-		if strings.HasSuffix(strings.ToLower(d.Name()), ".bsl") {
-			files = append(files, p)
-			if len(files) >= maxFiles {
-				return fs.SkipAll
+
+		status := WarmupStrategyStatus{Strategy: strategy.Name(), FilesTotal: len(files)}
+
+		// Touch documents to force parse/symbol tables.
+		for _, f := range files {
+			if ctx.Err() != nil {
+				b.finishWarmup(ctx.Err())
+				return ctx.Err()
 			}
+			// Read once to ensure file exists in server filesystem.
+			if _, err := os.Stat(f); err != nil {
+				continue
+			}
+			_, _ = b.GetDocumentSymbols(f) // best effort: triggers didOpen + documentSymbol
+			status.FilesOpened++
+			anyFiles = true
 		}
-		return nil
-	})
 
-	if len(files) == 0 {
-		// Still mark warmup done; nothing to scan.
-		logger.Warn("Warm-up: no .bsl files found under workspace root", workspaceRoot)
-		b.finishWarmup(nil)
-		return
+		// Issue the strategy's probe queries to encourage cross-file
+		// indexing. Errors are recorded, not fatal: some servers may not
+		// support workspace/symbol.
+		for _, query := range queries {
+			if ctx.Err() != nil {
+				b.finishWarmup(ctx.Err())
+				return ctx.Err()
+			}
+			if _, err := b.SearchTextInWorkspace(lang, query); err != nil {
+				status.ProbeErrors = append(status.ProbeErrors, fmt.Sprintf("%s: %v", query, err))
+			}
+		}
+
+		b.recordWarmupStrategyStatus(types.LanguageServer(lang), status)
 	}
 
-	// Touch documents to force parse/symbol tables.
-	for _, f := range files {
-		// Read once to ensure file exists in server filesystem.
-		if _, err := os.Stat(f); err != nil {
-			continue
-		}
-		_, _ = b.GetDocumentSymbols(f) // best effort: triggers didOpen + documentSymbol
+	if !anyFiles {
+		logger.Warn("Warm-up: no files opened by any configured strategy", warmupFields(workspaceRoot, start)...)
 	}
 
-	// Attempt a cheap workspace symbol query to encourage cross-file indexing.
-	// Ignore errors; some servers may not support it.
-	_, _ = b.SearchTextInWorkspace("bsl", "ПараметрыОперации")
+	// Wait for server progress (if reported) to settle, but do not block
+	// forever: an overall deadline bounds total wait time, and a stable
+	// window (no active progress for a couple seconds) guards against
+	// declaring victory on a single quiet poll between two indexing bursts.
+	const warmupProgressDeadline = 2 * time.Minute
+	const warmupStableWindow = 2 * time.Second
 
-	// Wait for server progress (if reported) to settle, but do not block forever.
-	deadline := time.Now().Add(2 * time.Minute)
+	deadline := time.Now().Add(warmupProgressDeadline)
 	stableSince := time.Time{}
 	for time.Now().Before(deadline) {
+		if ctx.Err() != nil {
+			b.finishWarmup(ctx.Err())
+			return ctx.Err()
+		}
+
 		clients := b.ListConnectedClients()
 		// If no clients, give up.
 		if len(clients) == 0 {
 			break
 		}
+
 		anyActive := false
-		for _, c := range clients {
-			if ps, ok := c.(interface{ ProgressSnapshot() any }); ok {
-				_ = ps // just type check; actual snapshot is exposed on concrete lsp client, but interface varies.
+		for srv, c := range clients {
+			reporter, ok := c.(warmupProgressReporter)
+			if !ok {
+				continue
+			}
+			if active := reporter.ProgressSnapshot().Active; len(active) > 0 {
+				anyActive = true
+				logger.Debug("Warm-up: waiting on server progress", warmupFields(workspaceRoot, start, "server", srv, "token", active[0].TokenKey)...)
+				break
 			}
-			// We can't reliably access snapshot from the interface here without importing lsp package.
-			// So we just break out early; readiness gate will still block until explicitly marked done.
-			anyActive = false
 		}
+
 		if !anyActive {
 			if stableSince.IsZero() {
 				stableSince = time.Now()
 			}
-			if time.Since(stableSince) > 2*time.Second {
+			if time.Since(stableSince) > warmupStableWindow {
 				break
 			}
 		} else {
 			stableSince = time.Time{}
 		}
-		time.Sleep(200 * time.Millisecond)
+
+		select {
+		case <-ctx.Done():
+			b.finishWarmup(ctx.Err())
+			return ctx.Err()
+		case <-time.After(200 * time.Millisecond):
+		}
 	}
 
-	logger.Info("Warm-up: finished")
+	logger.Info("Warm-up: finished", warmupFields(workspaceRoot, start)...)
 	b.finishWarmup(nil)
+	return nil
 }
 
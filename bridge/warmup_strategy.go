@@ -0,0 +1,169 @@
+package bridge
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"rockerboo/mcp-lsp-bridge/fswalk"
+	"rockerboo/mcp-lsp-bridge/lsp"
+)
+
+// WarmupStrategy decides which documents to didOpen and which
+// workspace/symbol queries to probe to coax a language server into
+// indexing during warm-up (see StartWarmup/runWarmup). Implementations are
+// resolved per language server from its LanguageServerConfig.Warmup (see
+// resolveWarmupStrategy), falling back to the BSL-shaped FileGlobStrategy
+// warm-up used before strategies existed, so existing BSL-only deployments
+// see no behavior change.
+type WarmupStrategy interface {
+	// Name identifies the strategy for warmup_status reporting.
+	Name() string
+	// Files returns the absolute paths under workspaceRoot to didOpen.
+	// ctx may be cancelled mid-walk by WarmupHandle.Cancel/Shutdown.
+	Files(ctx context.Context, workspaceRoot string) ([]string, error)
+	// ProbeQueries returns workspace/symbol query strings to issue after
+	// Files have been opened, to encourage cross-file indexing. May be
+	// empty.
+	ProbeQueries() []string
+}
+
+// FileGlobStrategy opens up to MaxFiles files under the workspace root
+// whose basename matches any of Patterns (filepath.Match patterns, e.g.
+// "*.bsl"). This is the default strategy (Patterns: []string{"*.bsl"},
+// MaxFiles: 5) for a server with no Warmup config.
+type FileGlobStrategy struct {
+	Patterns []string
+	MaxFiles int
+}
+
+func (s FileGlobStrategy) Name() string { return "file_glob" }
+
+func (s FileGlobStrategy) Files(ctx context.Context, workspaceRoot string) ([]string, error) {
+	maxFiles := s.MaxFiles
+	if maxFiles <= 0 {
+		maxFiles = 5
+	}
+	patterns := s.Patterns
+	if len(patterns) == 0 {
+		patterns = []string{"*.bsl"}
+	}
+
+	var filesMu sync.Mutex
+	var files []string
+	errMaxFilesReached := fmt.Errorf("warmup: reached %d files", maxFiles)
+
+	err := fswalk.Walk(ctx, workspaceRoot, fswalk.Options{
+		Filter:  globFilter(patterns),
+		SkipDir: fswalk.DefaultSkipDir,
+	}, func(p string, d os.DirEntry) error {
+		filesMu.Lock()
+		defer filesMu.Unlock()
+		if len(files) >= maxFiles {
+			return errMaxFilesReached
+		}
+		files = append(files, p)
+		if len(files) >= maxFiles {
+			return errMaxFilesReached
+		}
+		return nil
+	})
+	if err != nil && err != errMaxFilesReached {
+		return files, err
+	}
+	return files, nil
+}
+
+func (s FileGlobStrategy) ProbeQueries() []string { return nil }
+
+// globFilter matches a file's basename against patterns.
+func globFilter(patterns []string) fswalk.Filter {
+	return func(p string, d os.DirEntry) bool {
+		base := filepath.Base(p)
+		for _, pattern := range patterns {
+			if ok, err := filepath.Match(pattern, base); err == nil && ok {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// WorkspaceSymbolProbeStrategy opens no files; it only issues
+// workspace/symbol queries, for servers that index lazily as symbols are
+// searched rather than needing files didOpen'd up front.
+type WorkspaceSymbolProbeStrategy struct {
+	Queries []string
+}
+
+func (s WorkspaceSymbolProbeStrategy) Name() string { return "workspace_symbol_probe" }
+
+func (s WorkspaceSymbolProbeStrategy) Files(ctx context.Context, workspaceRoot string) ([]string, error) {
+	return nil, nil
+}
+
+func (s WorkspaceSymbolProbeStrategy) ProbeQueries() []string { return s.Queries }
+
+// NoopStrategy opens no files and issues no probes - for servers that need
+// no warm-up at all, so they don't pay the .bsl-scan cost of the default
+// strategy for no benefit.
+type NoopStrategy struct{}
+
+func (s NoopStrategy) Name() string { return "noop" }
+
+func (s NoopStrategy) Files(ctx context.Context, workspaceRoot string) ([]string, error) {
+	return nil, nil
+}
+
+func (s NoopStrategy) ProbeQueries() []string { return nil }
+
+// legacyDefaultProbeQuery preserves runWarmup's pre-strategy behavior for a
+// server with no explicit Warmup config: a cheap workspace/symbol query
+// ("operation parameters", a near-universal BSL module-level name) issued
+// in addition to FileGlobStrategy's default files, to encourage cross-file
+// indexing beyond the handful of files it opens. Servers that set an
+// explicit Warmup config opt out of this and get exactly what they asked
+// for.
+const legacyDefaultProbeQuery = "ПараметрыОперации"
+
+// WarmupStrategyStatus is the outcome of one language server's warm-up run
+// under a WarmupStrategy, as recorded by runWarmup and read by the
+// warmup_status MCP tool.
+type WarmupStrategyStatus struct {
+	// Strategy is the resolved WarmupStrategy's Name().
+	Strategy string
+	// FilesTotal is how many files the strategy selected to open.
+	FilesTotal int
+	// FilesOpened is how many of those files were opened successfully.
+	FilesOpened int
+	// ProbeErrors holds "query: error" strings for any ProbeQueries that
+	// failed; nil if every probe succeeded (or none were issued).
+	ProbeErrors []string
+}
+
+// resolveWarmupStrategy picks the WarmupStrategy cfg.Warmup selects, and
+// the probe queries to issue alongside it. cfg.Warmup == nil is the
+// historical default: a FileGlobStrategy over "*.bsl" plus
+// legacyDefaultProbeQuery, matching runWarmup's behavior before strategies
+// existed.
+func resolveWarmupStrategy(cfg lsp.LanguageServerConfig) (WarmupStrategy, []string) {
+	wc := cfg.Warmup
+	if wc == nil {
+		strategy := FileGlobStrategy{Patterns: []string{"*.bsl"}, MaxFiles: 5}
+		return strategy, []string{legacyDefaultProbeQuery}
+	}
+
+	switch wc.Kind {
+	case "workspace_symbol_probe":
+		strategy := WorkspaceSymbolProbeStrategy{Queries: wc.Queries}
+		return strategy, strategy.ProbeQueries()
+	case "noop":
+		strategy := NoopStrategy{}
+		return strategy, strategy.ProbeQueries()
+	default:
+		strategy := FileGlobStrategy{Patterns: wc.Patterns, MaxFiles: wc.MaxFiles}
+		return strategy, strategy.ProbeQueries()
+	}
+}
@@ -0,0 +1,39 @@
+package bridge
+
+import (
+	"time"
+
+	"rockerboo/mcp-lsp-bridge/lsp"
+)
+
+// watchRegistryDebounce is the window forwardWorkspaceIndexEvents coalesces
+// rapid successive changes to the same URI within, e.g. the
+// create-then-write pair a single save often produces.
+const watchRegistryDebounce = 50 * time.Millisecond
+
+// watchRegistry returns the bridge's WatchRegistry, creating one (the same
+// lazy-init pattern as supervisor()) on first use.
+func (b *MCPLSPBridge) watchRegistry() *lsp.WatchRegistry {
+	b.watchRegistryMu.Lock()
+	defer b.watchRegistryMu.Unlock()
+
+	if b.watchRegistryInst == nil {
+		b.watchRegistryInst = lsp.NewWatchRegistry(watchRegistryDebounce)
+	}
+	return b.watchRegistryInst
+}
+
+// WatchStatus reports the dynamic workspace/didChangeWatchedFiles
+// registrations WatchRegistry currently knows about, plus its recent
+// coalesced dispatch history, for the watch_status MCP tool.
+func (b *MCPLSPBridge) WatchStatus() (globs map[string][]string, recent []lsp.WatchDispatch) {
+	wr := b.watchRegistry()
+
+	byLang := wr.ActiveGlobs()
+	globs = make(map[string][]string, len(byLang))
+	for lang, patterns := range byLang {
+		globs[string(lang)] = patterns
+	}
+
+	return globs, wr.RecentDispatches()
+}
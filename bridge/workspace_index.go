@@ -0,0 +1,145 @@
+package bridge
+
+import (
+	"context"
+	"fmt"
+
+	"rockerboo/mcp-lsp-bridge/logger"
+	"rockerboo/mcp-lsp-bridge/lsp"
+	"rockerboo/mcp-lsp-bridge/types"
+	"rockerboo/mcp-lsp-bridge/utils"
+	"rockerboo/mcp-lsp-bridge/workspace"
+
+	"github.com/myleshyson/lsprotocol-go/protocol"
+)
+
+// didChangeWatchedFilesMethod is the client/registerCapability method name
+// WatchRegistry watches for - see refreshWatchRegistration.
+const didChangeWatchedFilesMethod = "workspace/didChangeWatchedFiles"
+
+// registrationLookup is the subset of a language client needed to read its
+// dynamic registrations directly (see lsp.LanguageClient.Registration).
+// Local duck-typed interface for the same reason progressProvider
+// (mcpserver/tools/progress_stream.go) is: the interfaces package this
+// would otherwise live on doesn't exist in this tree.
+type registrationLookup interface {
+	Registration(method string) (protocol.Registration, bool)
+}
+
+// WorkspaceIndex returns the bridge's incremental file index, starting it
+// against the first allowed directory on first use. A failed start (e.g.
+// no allowed directories configured yet) is not cached: the next call
+// tries again, unlike CallGraphCache, which latches a failed open.
+func (b *MCPLSPBridge) WorkspaceIndex() (*workspace.Index, error) {
+	b.workspaceIndexMu.Lock()
+	defer b.workspaceIndexMu.Unlock()
+
+	if b.workspaceIndex != nil {
+		return b.workspaceIndex, nil
+	}
+
+	roots := b.AllowedDirectories()
+	if len(roots) == 0 {
+		return nil, fmt.Errorf("workspace index: no allowed directories configured")
+	}
+
+	idx, err := workspace.NewIndex(workspace.Options{Root: roots[0]})
+	if err != nil {
+		return nil, fmt.Errorf("workspace index: %w", err)
+	}
+
+	if err := idx.Start(context.Background()); err != nil {
+		return nil, fmt.Errorf("workspace index: %w", err)
+	}
+
+	b.workspaceIndex = idx
+	go b.forwardWorkspaceIndexEvents(idx)
+
+	return idx, nil
+}
+
+// forwardWorkspaceIndexEvents relays workspace.Index events to every
+// currently-connected language server as workspace/didChangeWatchedFiles,
+// so an edit the index noticed via fsnotify (or the poll fallback) reaches
+// servers the same way an editor's own file watcher would - without every
+// caller of file-mutating tools having to remember to call
+// DidChangeWatchedFiles itself.
+//
+// Before forwarding, each event is checked against the bridge's
+// lsp.WatchRegistry (refreshed per-server from whatever
+// workspace/didChangeWatchedFiles registration that server last sent via
+// client/registerCapability) and debounced/coalesced through it, so a
+// server that asked to be notified about only *.bsl doesn't get spammed
+// about every file in the workspace, and a rapid create-then-write from a
+// single save collapses into one notification.
+func (b *MCPLSPBridge) forwardWorkspaceIndexEvents(idx *workspace.Index) {
+	wr := b.watchRegistry()
+
+	for ev := range idx.Events() {
+		change := protocol.FileEvent{
+			Uri:  protocol.DocumentUri(utils.FilePathToURI(ev.Path)),
+			Type: workspaceEventToFileChangeType(ev.Op),
+		}
+
+		for lang, client := range b.ListConnectedClients() {
+			refreshWatchRegistration(wr, lang, client)
+
+			if !wr.Matches(lang, ev.Path, watchKindForOp(ev.Op)) {
+				continue
+			}
+
+			wr.Coalesce(lang, change, func(c protocol.FileEvent) {
+				if err := b.DidChangeWatchedFiles(string(lang), []protocol.FileEvent{c}); err != nil {
+					logger.Debug(fmt.Sprintf("workspace index: didChangeWatchedFiles forward failed for %s: %v", lang, err))
+				}
+			})
+		}
+	}
+}
+
+// refreshWatchRegistration pulls client's current
+// workspace/didChangeWatchedFiles registration (if any) into wr. Called on
+// every event rather than only at client/registerCapability time, since
+// there's no callback hook from ClientHandler back out to the bridge - this
+// keeps WatchRegistry's glob filters in sync with whatever the server most
+// recently (un)registered at negligible cost (a map lookup under a mutex).
+func refreshWatchRegistration(wr *lsp.WatchRegistry, lang types.LanguageServer, client types.LanguageClientInterface) {
+	lookup, ok := client.(registrationLookup)
+	if !ok {
+		return
+	}
+
+	if reg, ok := lookup.Registration(didChangeWatchedFilesMethod); ok {
+		wr.SetRegistration(lang, reg)
+	} else {
+		wr.Unregister(lang)
+	}
+}
+
+// watchKindForOp maps workspace.EventOp onto lsp.WatchKind, for
+// WatchRegistry.Matches.
+func watchKindForOp(op workspace.EventOp) lsp.WatchKind {
+	switch op {
+	case workspace.EventCreated:
+		return lsp.WatchCreate
+	case workspace.EventDeleted:
+		return lsp.WatchDelete
+	default:
+		return lsp.WatchChange
+	}
+}
+
+// workspaceEventToFileChangeType maps workspace.EventOp onto the LSP
+// FileChangeType enum (1=Created, 2=Changed, 3=Deleted) the rest of this
+// codebase already hand-rolls in did_change_watched_files.go's tool
+// description.
+func workspaceEventToFileChangeType(op workspace.EventOp) protocol.FileChangeType {
+	switch op {
+	case workspace.EventCreated:
+		return protocol.FileChangeTypeCreated
+	case workspace.EventDeleted:
+		return protocol.FileChangeTypeDeleted
+	default:
+		return protocol.FileChangeTypeChanged
+	}
+}
@@ -1,14 +1,22 @@
-// Benchmark: parallel filesystem scanning
+// Benchmark: sequential vs fswalk-based parallel filesystem scanning.
+//
+// The correctness-sensitive concurrent-walk logic lives in the fswalk
+// package now (see fswalk/walker_test.go for its regression tests, and
+// fswalk/walker.go's doc comment for why the old sleep-then-check-channel-
+// length termination this file used to rely on was dropped). This binary is
+// just a convenience CLI for eyeballing throughput against a real workspace.
 package main
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
-	"sync"
 	"sync/atomic"
 	"time"
+
+	"rockerboo/mcp-lsp-bridge/fswalk"
 )
 
 func main() {
@@ -19,37 +27,29 @@ func main() {
 
 	fmt.Printf("Scanning: %s\n\n", root)
 
-	// Benchmark 1: Sequential walk
 	start := time.Now()
 	count1 := sequentialWalk(root)
 	elapsed1 := time.Since(start)
 	fmt.Printf("Sequential walk: %d files in %v (%.1f files/sec)\n", count1, elapsed1, float64(count1)/elapsed1.Seconds())
 
-	// Benchmark 2: Parallel walk (8 workers)
-	start = time.Now()
-	count2 := parallelWalk(root, 8)
-	elapsed2 := time.Since(start)
-	fmt.Printf("Parallel walk (8 workers): %d files in %v (%.1f files/sec)\n", count2, elapsed2, float64(count2)/elapsed2.Seconds())
-
-	// Benchmark 3: Parallel walk (32 workers)
-	start = time.Now()
-	count3 := parallelWalk(root, 32)
-	elapsed3 := time.Since(start)
-	fmt.Printf("Parallel walk (32 workers): %d files in %v (%.1f files/sec)\n", count3, elapsed3, float64(count3)/elapsed3.Seconds())
-
-	// Benchmark 4: Parallel walk (64 workers)
-	start = time.Now()
-	count4 := parallelWalk(root, 64)
-	elapsed4 := time.Since(start)
-	fmt.Printf("Parallel walk (64 workers): %d files in %v (%.1f files/sec)\n", count4, elapsed4, float64(count4)/elapsed4.Seconds())
-
-	// Benchmark 5: Only readdir (no stat)
-	start = time.Now()
-	count5 := parallelReaddir(root, 32)
-	elapsed5 := time.Since(start)
-	fmt.Printf("Parallel readdir only (32 workers): %d entries in %v\n", count5, elapsed5)
+	var speedupElapsed time.Duration
+	for _, workers := range []int{8, 32, 64} {
+		start = time.Now()
+		count, err := fswalkCount(root, workers)
+		elapsed := time.Since(start)
+		if err != nil {
+			fmt.Printf("fswalk (%d workers): error: %v\n", workers, err)
+			continue
+		}
+		fmt.Printf("fswalk (%d workers): %d files in %v (%.1f files/sec)\n", workers, count, elapsed, float64(count)/elapsed.Seconds())
+		if workers == 32 {
+			speedupElapsed = elapsed
+		}
+	}
 
-	fmt.Printf("\nSpeedup: %.2fx (sequential vs 32 workers)\n", elapsed1.Seconds()/elapsed2.Seconds())
+	if speedupElapsed > 0 {
+		fmt.Printf("\nSpeedup: %.2fx (sequential vs 32 workers)\n", elapsed1.Seconds()/speedupElapsed.Seconds())
+	}
 }
 
 func sequentialWalk(root string) int {
@@ -66,116 +66,14 @@ func sequentialWalk(root string) int {
 	return count
 }
 
-func parallelWalk(root string, workers int) int {
+func fswalkCount(root string, workers int) (int, error) {
 	var count int64
-	dirs := make(chan string, 1000)
-	var wg sync.WaitGroup
-
-	// Start workers
-	for i := 0; i < workers; i++ {
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			for dir := range dirs {
-				entries, err := os.ReadDir(dir)
-				if err != nil {
-					continue
-				}
-				for _, entry := range entries {
-					path := filepath.Join(dir, entry.Name())
-					if entry.IsDir() {
-						// Non-blocking send - if channel full, process inline
-						select {
-						case dirs <- path:
-						default:
-							// Process subdirectory inline
-							processDir(path, &count, dirs)
-						}
-					} else if strings.HasSuffix(entry.Name(), ".bsl") || strings.HasSuffix(entry.Name(), ".os") {
-						atomic.AddInt64(&count, 1)
-					}
-				}
-			}
-		}()
-	}
-
-	// Seed with root directory
-	dirs <- root
-
-	// Wait for some initial work, then close when done
-	// Simple approach: wait until no new work for 100ms
-	go func() {
-		for {
-			time.Sleep(100 * time.Millisecond)
-			if len(dirs) == 0 {
-				close(dirs)
-				return
-			}
-		}
-	}()
-
-	wg.Wait()
-	return int(count)
-}
-
-func processDir(dir string, count *int64, dirs chan string) {
-	entries, err := os.ReadDir(dir)
-	if err != nil {
-		return
-	}
-	for _, entry := range entries {
-		path := filepath.Join(dir, entry.Name())
-		if entry.IsDir() {
-			select {
-			case dirs <- path:
-			default:
-				processDir(path, count, dirs)
-			}
-		} else if strings.HasSuffix(entry.Name(), ".bsl") || strings.HasSuffix(entry.Name(), ".os") {
-			atomic.AddInt64(count, 1)
-		}
-	}
-}
-
-func parallelReaddir(root string, workers int) int {
-	var count int64
-	dirs := make(chan string, 1000)
-	var wg sync.WaitGroup
-
-	for i := 0; i < workers; i++ {
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			for dir := range dirs {
-				entries, err := os.ReadDir(dir)
-				if err != nil {
-					continue
-				}
-				for _, entry := range entries {
-					atomic.AddInt64(&count, 1)
-					if entry.IsDir() {
-						select {
-						case dirs <- filepath.Join(dir, entry.Name()):
-						default:
-						}
-					}
-				}
-			}
-		}()
-	}
-
-	dirs <- root
-
-	go func() {
-		for {
-			time.Sleep(100 * time.Millisecond)
-			if len(dirs) == 0 {
-				close(dirs)
-				return
-			}
-		}
-	}()
-
-	wg.Wait()
-	return int(count)
+	err := fswalk.Walk(context.Background(), root, fswalk.Options{
+		Workers: workers,
+		Filter:  fswalk.ExtFilter(".bsl", ".os"),
+	}, func(path string, d os.DirEntry) error {
+		atomic.AddInt64(&count, 1)
+		return nil
+	})
+	return int(count), err
 }
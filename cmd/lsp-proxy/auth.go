@@ -0,0 +1,43 @@
+package main
+
+import (
+	"bufio"
+	"crypto/subtle"
+	"encoding/json"
+	"log"
+	"net"
+	"strings"
+)
+
+// authenticateClient requires the first framed LSP message on conn to be
+// a bearer-token message ({"token":"..."}) matching token, before any
+// further message is forwarded to the LSP server. Returns false (and logs
+// why) if the connection should be closed without processing.
+func authenticateClient(reader *bufio.Reader, conn net.Conn, token string) bool {
+	msg, err := readLSPMessage(reader)
+	if err != nil {
+		log.Printf("Auth: failed to read token message from %s: %v", conn.RemoteAddr(), err)
+		return false
+	}
+
+	bodyStart := strings.Index(string(msg), "\r\n\r\n")
+	var body []byte
+	if bodyStart != -1 && len(msg) > bodyStart+4 {
+		body = msg[bodyStart+4:]
+	}
+
+	var auth struct {
+		Token string `json:"token"`
+	}
+	if err := json.Unmarshal(body, &auth); err != nil || auth.Token == "" {
+		log.Printf("Auth: malformed or missing token message from %s", conn.RemoteAddr())
+		return false
+	}
+
+	if subtle.ConstantTimeCompare([]byte(auth.Token), []byte(token)) != 1 {
+		log.Printf("Auth: rejected invalid shared token from %s", conn.RemoteAddr())
+		return false
+	}
+
+	return true
+}
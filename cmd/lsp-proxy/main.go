@@ -12,6 +12,7 @@ package main
 
 import (
 	"bufio"
+	"crypto/tls"
 	"encoding/json"
 	"flag"
 	"fmt"
@@ -19,17 +20,27 @@ import (
 	"log"
 	"net"
 	"os"
-	"os/exec"
 	"os/signal"
 	"strconv"
 	"strings"
 	"sync"
 	"syscall"
+	"time"
 )
 
 var (
-	port    = flag.Int("port", 9999, "TCP port to listen on")
-	command = flag.String("command", "", "LSP server command to run")
+	port       = flag.Int("port", 9999, "TCP port to listen on")
+	command    = flag.String("command", "", "LSP server command to run")
+	healthPort = flag.Int("health-port", 9998, "TCP port to serve /healthz and /readyz on")
+
+	tlsCert     = flag.String("tls-cert", "", "TLS certificate file (enables TLS on the TCP listener)")
+	tlsKey      = flag.String("tls-key", "", "TLS private key file (enables TLS on the TCP listener)")
+	tlsClientCA = flag.String("tls-client-ca", "", "CA bundle to verify client certificates against (enables mTLS)")
+	sharedToken = flag.String("shared-token", "", "If set, require this bearer token as the first framed LSP message on every connection")
+	trustProxy  = flag.Bool("trust-proxy", false, "Recover the real client address from a leading HAProxy PROXY protocol v1/v2 header")
+
+	wsPort = flag.Int("ws-port", 0, "If set, also serve the same LSP framing over WebSocket on this TCP port")
+	wsPath = flag.String("ws-path", "/lsp", "HTTP path the WebSocket transport is served on")
 )
 
 func main() {
@@ -45,40 +56,39 @@ func main() {
 	log.Printf("Starting lsp-proxy on port %d", *port)
 	log.Printf("LSP command: %s %v", *command, cmdArgs)
 
-	// Start LSP server process
-	cmd := exec.Command(*command, cmdArgs...)
-
-	// Get stdin/stdout pipes
-	stdin, err := cmd.StdinPipe()
-	if err != nil {
-		log.Fatalf("Failed to get stdin pipe: %v", err)
+	// Create the proxy and hand it over to a supervisor that owns the LSP
+	// server process's lifecycle (spawn, crash-restart, graceful shutdown).
+	proxy := NewLSPProxy(*sharedToken, *trustProxy)
+	supervisor := NewSupervisor(*command, cmdArgs, proxy)
+	if err := supervisor.Start(); err != nil {
+		log.Fatalf("Failed to start LSP server: %v", err)
 	}
+	supervisor.ServeHealth(fmt.Sprintf(":%d", *healthPort))
 
-	stdout, err := cmd.StdoutPipe()
+	tlsConfig, err := loadTLSConfig(*tlsCert, *tlsKey, *tlsClientCA)
 	if err != nil {
-		log.Fatalf("Failed to get stdout pipe: %v", err)
+		log.Fatalf("Failed to load TLS config: %v", err)
 	}
 
-	// Forward stderr to our stderr
-	cmd.Stderr = os.Stderr
-
-	// Start the LSP server
-	if err := cmd.Start(); err != nil {
-		log.Fatalf("Failed to start LSP server: %v", err)
+	// Start TCP listener, upgrading to TLS (optionally mTLS) if configured
+	var listener net.Listener
+	if tlsConfig != nil {
+		listener, err = tls.Listen("tcp", fmt.Sprintf(":%d", *port), tlsConfig)
+		log.Printf("TLS enabled (client cert required: %v)", tlsConfig.ClientAuth == tls.RequireAndVerifyClientCert)
+	} else {
+		listener, err = net.Listen("tcp", fmt.Sprintf(":%d", *port))
 	}
-	log.Printf("LSP server started with PID %d", cmd.Process.Pid)
-
-	// Create the proxy
-	proxy := NewLSPProxy(stdin, stdout)
-
-	// Start TCP listener
-	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", *port))
 	if err != nil {
 		log.Fatalf("Failed to listen on port %d: %v", *port, err)
 	}
 	defer listener.Close()
 	log.Printf("Listening on port %d", *port)
 
+	if *wsPort > 0 {
+		serveWebSocket(fmt.Sprintf(":%d", *wsPort), *wsPath, proxy)
+		log.Printf("Listening for WebSocket clients on port %d, path %s", *wsPort, *wsPath)
+	}
+
 	// Handle shutdown
 	sigCh := make(chan os.Signal, 1)
 	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
@@ -87,7 +97,7 @@ func main() {
 		<-sigCh
 		log.Println("Shutting down...")
 		listener.Close()
-		cmd.Process.Kill()
+		supervisor.Shutdown(5 * time.Second)
 		os.Exit(0)
 	}()
 
@@ -104,50 +114,135 @@ func main() {
 	}
 }
 
-// LSPProxy manages communication between TCP clients and an LSP server
-type LSPProxy struct {
-	stdin  io.WriteCloser
-	stdout io.Reader
+// rpcMessage is a JSON-RPC 2.0 envelope broad enough to cover requests,
+// responses and notifications without committing to which one a given
+// message is - callers decide that from which of ID/Method/Result/Error
+// are present.
+type rpcMessage struct {
+	JSONRPC string          `json:"jsonrpc,omitempty"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   json.RawMessage `json:"error,omitempty"`
+}
 
-	mu            sync.Mutex
-	activeClient  net.Conn
-	responseReady chan struct{}
+// pendingClientRequest remembers which client connection and original
+// JSON-RPC id a proxy-rewritten request came from, so the matching
+// response can be demultiplexed back to the right client. conn is nil
+// and done is set instead for requests the proxy itself originates (see
+// sendInternalRequest) - e.g. the supervisor's shutdown/initialize calls,
+// which have no client waiting on a TCP connection.
+type pendingClientRequest struct {
+	conn       net.Conn
+	originalID json.RawMessage
+	done       chan rpcMessage
+}
+
+// LSPProxy manages communication between TCP clients and an LSP server.
+//
+// Requests from clients race each other over the same stdin/stdout pipe
+// to the LSP server, so every client-originated request id is rewritten
+// to a proxy-unique id before it's forwarded; the original (conn, id)
+// pair is stashed in pending and restored when the matching response
+// comes back. Notifications and server-initiated requests have no
+// per-client owner, so they're broadcast to every connected client;
+// server-initiated request ids are tracked in serverPending so a
+// client's reply can be routed back to the server unchanged.
+//
+// stdin/stdout are owned by whoever currently holds the LSP server
+// process - the Supervisor, which spawns it at startup and again after a
+// crash - and are swapped in via SetPipes, which is also how a fresh readResponses loop
+// gets started against the new process.
+type LSPProxy struct {
+	mu      sync.Mutex
+	stdin   io.WriteCloser
+	clients map[net.Conn]struct{}
+	nextID  int64
+	pending map[int64]pendingClientRequest
+
+	// sharedToken, if non-empty, is the bearer token every client
+	// connection must present as its first framed message (see
+	// authenticateClient) before anything else is forwarded.
+	sharedToken string
+
+	// trustProxy enables recovering the real client address from a
+	// leading HAProxy PROXY protocol v1/v2 header (see resolvePeerAddr).
+	trustProxy bool
+
+	// serverPending tracks server-initiated request ids (marshaled as a
+	// string) that are still waiting on a client reply, so a dropped
+	// client doesn't strand the LSP server waiting forever.
+	serverPending map[string]struct{}
+
+	// progress tracks $/progress streams reported by the LSP server (see
+	// ProgressTracker), independent of which client is watching.
+	progress *ProgressTracker
 
 	// Initialize state caching - LSP servers should only be initialized once
-	initMu          sync.RWMutex
-	initialized     bool
-	initializeResp  []byte // Cached initialize response
+	initMu         sync.RWMutex
+	initialized    bool
+	initializeResp []byte // Cached initialize response
+	initParams     json.RawMessage
+
+	// openDocs remembers the most recent textDocument/didOpen params seen
+	// for each open uri, so the Supervisor can replay them against a
+	// freshly respawned LSP server (see replayState).
+	openDocsMu sync.Mutex
+	openDocs   map[string]json.RawMessage
 }
 
-// NewLSPProxy creates a new LSP proxy
-func NewLSPProxy(stdin io.WriteCloser, stdout io.Reader) *LSPProxy {
-	proxy := &LSPProxy{
-		stdin:         stdin,
-		stdout:        stdout,
-		responseReady: make(chan struct{}, 1),
+// NewLSPProxy creates a new LSP proxy. sharedToken and trustProxy configure
+// per-connection auth and PROXY-protocol address recovery respectively (see
+// HandleClient); pass "" and false to disable both. Call SetPipes once the
+// LSP server process has been started (see Supervisor.spawn) before routing
+// any client traffic.
+func NewLSPProxy(sharedToken string, trustProxy bool) *LSPProxy {
+	return &LSPProxy{
+		clients:       make(map[net.Conn]struct{}),
+		pending:       make(map[int64]pendingClientRequest),
+		serverPending: make(map[string]struct{}),
+		progress:      NewProgressTracker(),
+		openDocs:      make(map[string]json.RawMessage),
+		sharedToken:   sharedToken,
+		trustProxy:    trustProxy,
 	}
+}
 
-	// Start reading responses from LSP server
-	go proxy.readResponses()
+// SetPipes points the proxy at a (re)started LSP server process's pipes
+// and starts a fresh readResponses loop against the new stdout. The
+// previous loop, if any, exits on its own once its stdout is closed.
+func (p *LSPProxy) SetPipes(stdin io.WriteCloser, stdout io.Reader) {
+	p.mu.Lock()
+	p.stdin = stdin
+	p.mu.Unlock()
 
-	return proxy
+	go p.readResponses(stdout)
 }
 
 // HandleClient handles a single TCP client connection
 func (p *LSPProxy) HandleClient(conn net.Conn) {
 	defer conn.Close()
 
-	log.Printf("HandleClient: setting up for %s", conn.RemoteAddr())
+	reader := bufio.NewReader(conn)
+
+	peer, err := resolvePeerAddr(reader, conn, p.trustProxy)
+	if err != nil {
+		log.Printf("HandleClient: failed to resolve peer address for %s: %v", conn.RemoteAddr(), err)
+		return
+	}
+
+	if p.sharedToken != "" && !authenticateClient(reader, conn, p.sharedToken) {
+		return
+	}
+
+	log.Printf("HandleClient: setting up for %s", peer)
 
 	p.mu.Lock()
-	p.activeClient = conn
+	p.clients[conn] = struct{}{}
 	p.mu.Unlock()
 
-	reader := bufio.NewReader(conn)
-
 	for {
-		log.Printf("HandleClient: waiting for LSP message...")
-		// Read LSP message from client
 		msg, err := readLSPMessage(reader)
 		if err != nil {
 			if err != io.EOF {
@@ -158,176 +253,432 @@ func (p *LSPProxy) HandleClient(conn net.Conn) {
 			break
 		}
 
-		// Extract body for analysis
 		bodyStart := strings.Index(string(msg), "\r\n\r\n")
-		var body string
+		var body []byte
 		if bodyStart != -1 && len(msg) > bodyStart+4 {
-			body = string(msg[bodyStart+4:])
+			body = msg[bodyStart+4:]
 		}
 
-		// Log message content for debugging
-		logBody := body
+		logBody := string(body)
 		if len(logBody) > 200 {
 			logBody = logBody[:200] + "..."
 		}
-		log.Printf("-> LSP request: %d bytes, content: %s", len(msg), logBody)
+		log.Printf("-> LSP message from %s: %d bytes, content: %s", peer, len(msg), logBody)
+
+		var rpc rpcMessage
+		if err := json.Unmarshal(body, &rpc); err != nil {
+			log.Printf("Client message parse error: %v", err)
+			continue
+		}
 
-		// Check if this is an initialize request
-		if p.isInitializeRequest(body) {
-			if p.handleInitializeRequest(conn, msg, body) {
-				continue // Handled from cache, don't forward
+		switch {
+		case rpc.Method != "" && len(rpc.ID) > 0:
+			// Client request: cache-serve "initialize", otherwise
+			// rewrite its id and register it in pending.
+			if rpc.Method == "initialize" && p.handleInitializeRequest(conn, rpc) {
+				continue
 			}
+			if err := p.forwardClientRequest(conn, rpc); err != nil {
+				log.Printf("LSP server write error: %v", err)
+				goto disconnected
+			}
+
+		case rpc.Method != "":
+			// Notification: no id, no per-client owner - forward as-is.
+			p.trackOpenDoc(rpc)
+			if err := p.writeToServer(msg); err != nil {
+				log.Printf("LSP server write error: %v", err)
+				goto disconnected
+			}
+
+		case len(rpc.ID) > 0:
+			// Reply from this client to a server-initiated request.
+			if !p.consumeServerPending(rpc.ID) {
+				log.Printf("Client reply for unknown/expired server request id %s, dropping", rpc.ID)
+				continue
+			}
+			if err := p.writeToServer(msg); err != nil {
+				log.Printf("LSP server write error: %v", err)
+				goto disconnected
+			}
+
+		default:
+			log.Printf("Client message has neither method nor id, dropping")
 		}
+	}
+
+disconnected:
+	p.dropClient(conn)
+	log.Printf("Client disconnected: %s", peer)
+}
 
-		// Check if this is an initialized notification (just log it)
-		if p.isInitializedNotification(body) {
-			log.Printf("-> 'initialized' notification received")
-			// Forward it - BSL LS expects this
+// forwardClientRequest assigns conn's request a proxy-unique id, records
+// (conn, originalID) in pending so the response can find its way back,
+// and writes the rewritten message to the LSP server.
+func (p *LSPProxy) forwardClientRequest(conn net.Conn, rpc rpcMessage) error {
+	p.mu.Lock()
+	p.nextID++
+	proxyID := p.nextID
+	p.pending[proxyID] = pendingClientRequest{conn: conn, originalID: rpc.ID}
+	p.mu.Unlock()
+
+	rpc.ID = json.RawMessage(strconv.FormatInt(proxyID, 10))
+	out, err := encodeLSPMessage(rpc)
+	if err != nil {
+		return fmt.Errorf("encode rewritten request: %w", err)
+	}
+
+	return p.writeToServer(out)
+}
+
+// consumeServerPending reports whether id was a server-initiated request
+// still awaiting a client reply, removing it if so.
+func (p *LSPProxy) consumeServerPending(id json.RawMessage) bool {
+	key := string(id)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if _, ok := p.serverPending[key]; !ok {
+		return false
+	}
+	delete(p.serverPending, key)
+	return true
+}
+
+func (p *LSPProxy) writeToServer(msg []byte) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	_, err := p.stdin.Write(msg)
+	return err
+}
+
+// trackOpenDoc records or forgets a document's didOpen params, keyed by
+// uri, so the Supervisor can replay them after a respawn (see
+// replayState). didChange edits since the open are intentionally not
+// tracked - replay is best-effort, not a full text-sync history.
+func (p *LSPProxy) trackOpenDoc(rpc rpcMessage) {
+	var doc struct {
+		TextDocument struct {
+			URI string `json:"uri"`
+		} `json:"textDocument"`
+	}
+	if err := json.Unmarshal(rpc.Params, &doc); err != nil || doc.TextDocument.URI == "" {
+		return
+	}
+
+	p.openDocsMu.Lock()
+	defer p.openDocsMu.Unlock()
+
+	switch rpc.Method {
+	case "textDocument/didOpen":
+		p.openDocs[doc.TextDocument.URI] = rpc.Params
+	case "textDocument/didClose":
+		delete(p.openDocs, doc.TextDocument.URI)
+	}
+}
+
+// openDocsSnapshot returns a copy of the currently tracked didOpen params,
+// keyed by uri.
+func (p *LSPProxy) openDocsSnapshot() map[string]json.RawMessage {
+	p.openDocsMu.Lock()
+	defer p.openDocsMu.Unlock()
+
+	snap := make(map[string]json.RawMessage, len(p.openDocs))
+	for uri, params := range p.openDocs {
+		snap[uri] = params
+	}
+	return snap
+}
+
+// sendInternalRequest sends a request the proxy itself originates (not on
+// behalf of any connected client) and blocks until the matching response
+// arrives or timeout elapses. Used by the Supervisor for the
+// shutdown/initialize handshakes.
+func (p *LSPProxy) sendInternalRequest(method string, params interface{}, timeout time.Duration) (rpcMessage, error) {
+	var paramsRaw json.RawMessage
+	if params != nil {
+		raw, err := json.Marshal(params)
+		if err != nil {
+			return rpcMessage{}, fmt.Errorf("marshal params: %w", err)
 		}
+		paramsRaw = raw
+	}
 
-		// Forward to LSP server
+	p.mu.Lock()
+	p.nextID++
+	proxyID := p.nextID
+	done := make(chan rpcMessage, 1)
+	p.pending[proxyID] = pendingClientRequest{done: done}
+	p.mu.Unlock()
+
+	rpc := rpcMessage{
+		JSONRPC: "2.0",
+		ID:      json.RawMessage(strconv.FormatInt(proxyID, 10)),
+		Method:  method,
+		Params:  paramsRaw,
+	}
+	msg, err := encodeLSPMessage(rpc)
+	if err != nil {
 		p.mu.Lock()
-		_, err = p.stdin.Write(msg)
+		delete(p.pending, proxyID)
 		p.mu.Unlock()
+		return rpcMessage{}, fmt.Errorf("encode request: %w", err)
+	}
 
-		if err != nil {
-			log.Printf("LSP server write error: %v", err)
-			break
-		}
+	if err := p.writeToServer(msg); err != nil {
+		p.mu.Lock()
+		delete(p.pending, proxyID)
+		p.mu.Unlock()
+		return rpcMessage{}, fmt.Errorf("write request: %w", err)
 	}
 
+	select {
+	case resp := <-done:
+		return resp, nil
+	case <-time.After(timeout):
+		p.mu.Lock()
+		delete(p.pending, proxyID)
+		p.mu.Unlock()
+		return rpcMessage{}, fmt.Errorf("timed out waiting for %s response", method)
+	}
+}
+
+// isInitialized reports whether the LSP server has completed at least one
+// initialize handshake whose response is currently cached.
+func (p *LSPProxy) isInitialized() bool {
+	p.initMu.RLock()
+	defer p.initMu.RUnlock()
+	return p.initialized && p.initializeResp != nil
+}
+
+// invalidateInitializeCache clears the cached initialize response - used
+// by the Supervisor when the LSP server process has restarted and must
+// complete a fresh handshake before it can serve the cache again.
+func (p *LSPProxy) invalidateInitializeCache() {
+	p.initMu.Lock()
+	p.initialized = false
+	p.initializeResp = nil
+	p.initMu.Unlock()
+}
+
+// cachedInitParams returns the params of the first client "initialize"
+// request the proxy forwarded, or nil if none has been observed yet.
+func (p *LSPProxy) cachedInitParams() json.RawMessage {
+	p.initMu.RLock()
+	defer p.initMu.RUnlock()
+	return p.initParams
+}
+
+// dropClient removes conn from the broadcast set, discards any pending
+// client-originated requests it will never read a response for, and - if
+// it was the last connected client - flushes outstanding server-initiated
+// requests with a synthetic error response so the LSP server isn't left
+// waiting on a reply that can now never arrive.
+func (p *LSPProxy) dropClient(conn net.Conn) {
 	p.mu.Lock()
-	if p.activeClient == conn {
-		p.activeClient = nil
+	delete(p.clients, conn)
+	noClientsLeft := len(p.clients) == 0
+
+	for proxyID, req := range p.pending {
+		if req.conn == conn {
+			delete(p.pending, proxyID)
+		}
+	}
+
+	var strandedIDs []string
+	if noClientsLeft {
+		for id := range p.serverPending {
+			strandedIDs = append(strandedIDs, id)
+			delete(p.serverPending, id)
+		}
 	}
 	p.mu.Unlock()
 
-	log.Printf("Client disconnected: %s", conn.RemoteAddr())
+	for _, id := range strandedIDs {
+		p.sendSyntheticError(json.RawMessage(id))
+	}
 }
 
-// isInitializeRequest checks if the message is an "initialize" request
-func (p *LSPProxy) isInitializeRequest(body string) bool {
-	var msg struct {
-		Method string `json:"method"`
+// sendSyntheticError answers a server-initiated request that will never
+// get a real client reply (its client disconnected) with a JSON-RPC
+// error, so the LSP server can move on instead of waiting indefinitely.
+func (p *LSPProxy) sendSyntheticError(id json.RawMessage) {
+	resp := rpcMessage{
+		JSONRPC: "2.0",
+		ID:      id,
+		Error:   json.RawMessage(`{"code":-32001,"message":"lsp-proxy: client disconnected before replying"}`),
 	}
-	if err := json.Unmarshal([]byte(body), &msg); err != nil {
-		return false
+
+	msg, err := encodeLSPMessage(resp)
+	if err != nil {
+		log.Printf("Failed to encode synthetic error response: %v", err)
+		return
+	}
+
+	if err := p.writeToServer(msg); err != nil {
+		log.Printf("Failed to send synthetic error response: %v", err)
 	}
-	return msg.Method == "initialize"
 }
 
-// isInitializedNotification checks if the message is an "initialized" notification
-func (p *LSPProxy) isInitializedNotification(body string) bool {
-	var msg struct {
-		Method string `json:"method"`
+// broadcast writes msg to every currently connected client.
+func (p *LSPProxy) broadcast(msg []byte) {
+	p.mu.Lock()
+	conns := make([]net.Conn, 0, len(p.clients))
+	for conn := range p.clients {
+		conns = append(conns, conn)
 	}
-	if err := json.Unmarshal([]byte(body), &msg); err != nil {
-		return false
+	p.mu.Unlock()
+
+	for _, conn := range conns {
+		if _, err := conn.Write(msg); err != nil {
+			log.Printf("Client write error for %s: %v", conn.RemoteAddr(), err)
+		}
 	}
-	return msg.Method == "initialized"
 }
 
-// handleInitializeRequest handles initialize requests with caching
-// Returns true if handled from cache (don't forward), false to forward normally
-func (p *LSPProxy) handleInitializeRequest(conn net.Conn, msg []byte, body string) bool {
+// handleInitializeRequest handles "initialize" requests with caching.
+// Returns true if handled from cache (don't forward), false to forward
+// normally through forwardClientRequest.
+func (p *LSPProxy) handleInitializeRequest(conn net.Conn, rpc rpcMessage) bool {
 	p.initMu.RLock()
 	initialized := p.initialized
 	cachedResp := p.initializeResp
 	p.initMu.RUnlock()
 
-	if initialized && cachedResp != nil {
-		// Return cached response
-		log.Printf("-> CACHED: Returning cached initialize response (%d bytes)", len(cachedResp))
-		
-		// Extract request ID to match in response
-		var req struct {
-			ID json.RawMessage `json:"id"`
-		}
-		if err := json.Unmarshal([]byte(body), &req); err == nil {
-			// Update the cached response with the new request ID
-			var resp map[string]interface{}
-			
-			// Parse cached response body
-			respBodyStart := strings.Index(string(cachedResp), "\r\n\r\n")
-			if respBodyStart != -1 {
-				respBody := cachedResp[respBodyStart+4:]
-				if err := json.Unmarshal(respBody, &resp); err == nil {
-					// Update ID
-					resp["id"] = req.ID
-					
-					// Re-serialize
-					newRespBody, err := json.Marshal(resp)
-					if err == nil {
-						newResp := fmt.Sprintf("Content-Length: %d\r\n\r\n%s", len(newRespBody), newRespBody)
-						conn.Write([]byte(newResp))
-						log.Printf("<- CACHED: Sent cached response with updated ID")
-						return true
-					}
-				}
-			}
+	if !initialized || cachedResp == nil {
+		log.Printf("-> FIRST initialize request, forwarding to LSP server")
+		p.initMu.Lock()
+		if p.initParams == nil {
+			p.initParams = rpc.Params
 		}
-		
-		// Fallback: send cached response as-is
+		p.initMu.Unlock()
+		return false
+	}
+
+	log.Printf("-> CACHED: Returning cached initialize response (%d bytes)", len(cachedResp))
+
+	respBodyStart := strings.Index(string(cachedResp), "\r\n\r\n")
+	if respBodyStart == -1 {
 		conn.Write(cachedResp)
 		return true
 	}
 
-	// First initialize - let it through, will be cached in readResponses
-	log.Printf("-> FIRST initialize request, forwarding to LSP server")
-	return false
+	var resp map[string]interface{}
+	if err := json.Unmarshal(cachedResp[respBodyStart+4:], &resp); err != nil {
+		conn.Write(cachedResp)
+		return true
+	}
+
+	resp["id"] = rpc.ID
+	newRespBody, err := json.Marshal(resp)
+	if err != nil {
+		conn.Write(cachedResp)
+		return true
+	}
+
+	newResp := fmt.Sprintf("Content-Length: %d\r\n\r\n%s", len(newRespBody), newRespBody)
+	conn.Write([]byte(newResp))
+	log.Printf("<- CACHED: Sent cached response with updated ID")
+	return true
 }
 
-// readResponses reads responses from LSP server and forwards to active client
-func (p *LSPProxy) readResponses() {
-	reader := bufio.NewReader(p.stdout)
+// readResponses reads messages from the LSP server and routes each one:
+// responses are demultiplexed back to the client that made the matching
+// request, server-initiated requests and notifications are broadcast to
+// every connected client.
+func (p *LSPProxy) readResponses(stdout io.Reader) {
+	reader := bufio.NewReader(stdout)
 
 	for {
-		log.Printf("readResponses: waiting for LSP server response...")
-		// Read LSP message from server
 		msg, err := readLSPMessage(reader)
 		if err != nil {
 			log.Printf("LSP server read error: %v", err)
 			return
 		}
 
-		// Extract body for logging and analysis
 		bodyStart := strings.Index(string(msg), "\r\n\r\n")
-		var body string
-		var bodyPreview string
+		var body []byte
 		if bodyStart != -1 && len(msg) > bodyStart+4 {
-			body = string(msg[bodyStart+4:])
-			if len(body) > 200 {
-				bodyPreview = body[:200] + "..."
-			} else {
-				bodyPreview = body
-			}
+			body = msg[bodyStart+4:]
 		}
-		log.Printf("<- LSP response: %d bytes, content: %s", len(msg), bodyPreview)
 
-		// Check if this is an initialize response (has "capabilities" in result)
+		bodyPreview := string(body)
+		if len(bodyPreview) > 200 {
+			bodyPreview = bodyPreview[:200] + "..."
+		}
+		log.Printf("<- LSP server message: %d bytes, content: %s", len(msg), bodyPreview)
+
 		p.cacheInitializeResponseIfNeeded(msg, body)
 
-		// Forward to active client
-		p.mu.Lock()
-		client := p.activeClient
-		p.mu.Unlock()
+		var rpc rpcMessage
+		if err := json.Unmarshal(body, &rpc); err != nil {
+			log.Printf("Server message parse error: %v", err)
+			continue
+		}
 
-		if client != nil {
-			log.Printf("readResponses: forwarding to client %s", client.RemoteAddr())
-			n, err := client.Write(msg)
-			if err != nil {
-				log.Printf("Client write error: %v", err)
-			} else {
-				log.Printf("readResponses: wrote %d bytes to client", n)
+		switch {
+		case rpc.Method != "":
+			// Server-initiated request or notification: no single owner.
+			p.progress.Observe(rpc)
+			if len(rpc.ID) > 0 {
+				p.mu.Lock()
+				p.serverPending[string(rpc.ID)] = struct{}{}
+				p.mu.Unlock()
 			}
-		} else {
-			log.Printf("readResponses: no active client to forward to!")
+			p.broadcast(msg)
+
+		case len(rpc.ID) > 0:
+			p.routeResponse(rpc, msg)
+
+		default:
+			log.Printf("Server message has neither method nor id, dropping")
 		}
 	}
 }
 
+// routeResponse demultiplexes a response keyed on its proxy-rewritten id
+// back to the client that owns it, restoring the client's original id.
+func (p *LSPProxy) routeResponse(rpc rpcMessage, rawMsg []byte) {
+	var proxyID int64
+	if err := json.Unmarshal(rpc.ID, &proxyID); err != nil {
+		log.Printf("Response id %s is not a proxy id, dropping: %v", rpc.ID, err)
+		return
+	}
+
+	p.mu.Lock()
+	req, ok := p.pending[proxyID]
+	if ok {
+		delete(p.pending, proxyID)
+	}
+	p.mu.Unlock()
+
+	if !ok {
+		log.Printf("No pending client request for proxy id %d, dropping response", proxyID)
+		return
+	}
+
+	if req.done != nil {
+		// A proxy-internal request (see sendInternalRequest) - the caller
+		// is blocked waiting on this channel, not a TCP client.
+		req.done <- rpc
+		return
+	}
+
+	rpc.ID = req.originalID
+	msg, err := encodeLSPMessage(rpc)
+	if err != nil {
+		log.Printf("Failed to re-encode response for client: %v", err)
+		return
+	}
+
+	if _, err := req.conn.Write(msg); err != nil {
+		log.Printf("Client write error for %s: %v", req.conn.RemoteAddr(), err)
+	}
+}
+
 // cacheInitializeResponseIfNeeded checks if this is an initialize response and caches it
-func (p *LSPProxy) cacheInitializeResponseIfNeeded(msg []byte, body string) {
+func (p *LSPProxy) cacheInitializeResponseIfNeeded(msg []byte, body []byte) {
 	p.initMu.RLock()
 	alreadyInitialized := p.initialized
 	p.initMu.RUnlock()
@@ -344,7 +695,7 @@ func (p *LSPProxy) cacheInitializeResponseIfNeeded(msg []byte, body string) {
 		} `json:"result"`
 	}
 
-	if err := json.Unmarshal([]byte(body), &resp); err != nil {
+	if err := json.Unmarshal(body, &resp); err != nil {
 		return
 	}
 
@@ -359,6 +710,16 @@ func (p *LSPProxy) cacheInitializeResponseIfNeeded(msg []byte, body string) {
 	}
 }
 
+// encodeLSPMessage marshals v and wraps it in an LSP Content-Length header.
+func encodeLSPMessage(v interface{}) ([]byte, error) {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	header := fmt.Sprintf("Content-Length: %d\r\n\r\n", len(body))
+	return append([]byte(header), body...), nil
+}
+
 // readLSPMessage reads a complete LSP message (with Content-Length header)
 func readLSPMessage(reader *bufio.Reader) ([]byte, error) {
 	// Read headers
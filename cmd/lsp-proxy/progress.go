@@ -0,0 +1,174 @@
+package main
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+// ProgressEntry is a normalized view of one in-flight $/progress stream,
+// keyed by its workDoneToken.
+type ProgressEntry struct {
+	Token       string    `json:"token"`
+	Kind        string    `json:"kind"` // begin|report|end
+	Title       string    `json:"title,omitempty"`
+	Message     string    `json:"message,omitempty"`
+	Percentage  *uint32   `json:"percentage,omitempty"`
+	Cancellable *bool     `json:"cancellable,omitempty"`
+	StartedAt   time.Time `json:"started_at"`
+}
+
+// ProgressTracker maintains the set of $/progress streams the LSP server
+// has reported, so a client that connects mid-stream (or a status tool
+// polling the proxy) can see what's currently active without having to
+// have observed the "begin" event itself. Entries are created on
+// window/workDoneProgress/create or the first "begin"/"report" for a
+// token, and removed on "end".
+type ProgressTracker struct {
+	mu          sync.RWMutex
+	active      map[string]ProgressEntry
+	subscribers map[chan ProgressEntry]struct{}
+}
+
+// NewProgressTracker returns an empty tracker.
+func NewProgressTracker() *ProgressTracker {
+	return &ProgressTracker{
+		active:      make(map[string]ProgressEntry),
+		subscribers: make(map[chan ProgressEntry]struct{}),
+	}
+}
+
+// Observe inspects a server-to-client message and updates tracker state
+// if it's window/workDoneProgress/create or $/progress. Any other method
+// is a no-op.
+func (pt *ProgressTracker) Observe(rpc rpcMessage) {
+	switch rpc.Method {
+	case "window/workDoneProgress/create":
+		pt.observeCreate(rpc.Params)
+	case "$/progress":
+		pt.observeProgress(rpc.Params)
+	}
+}
+
+func (pt *ProgressTracker) observeCreate(params json.RawMessage) {
+	var p struct {
+		Token string `json:"token"`
+	}
+	if err := json.Unmarshal(params, &p); err != nil || p.Token == "" {
+		return
+	}
+
+	pt.mu.Lock()
+	if _, exists := pt.active[p.Token]; !exists {
+		pt.active[p.Token] = ProgressEntry{Token: p.Token, StartedAt: time.Now()}
+	}
+	pt.mu.Unlock()
+}
+
+func (pt *ProgressTracker) observeProgress(params json.RawMessage) {
+	var p struct {
+		Token json.RawMessage `json:"token"`
+		Value struct {
+			Kind        string  `json:"kind"`
+			Title       string  `json:"title,omitempty"`
+			Message     string  `json:"message,omitempty"`
+			Percentage  *uint32 `json:"percentage,omitempty"`
+			Cancellable *bool   `json:"cancellable,omitempty"`
+		} `json:"value"`
+	}
+	if err := json.Unmarshal(params, &p); err != nil {
+		return
+	}
+
+	token := rawTokenKey(p.Token)
+	if token == "" {
+		return
+	}
+
+	now := time.Now()
+	entry := ProgressEntry{
+		Token:       token,
+		Kind:        p.Value.Kind,
+		Title:       p.Value.Title,
+		Message:     p.Value.Message,
+		Percentage:  p.Value.Percentage,
+		Cancellable: p.Value.Cancellable,
+		StartedAt:   now,
+	}
+
+	pt.mu.Lock()
+	if existing, ok := pt.active[token]; ok {
+		entry.StartedAt = existing.StartedAt
+	}
+
+	switch p.Value.Kind {
+	case "end":
+		delete(pt.active, token)
+	default:
+		pt.active[token] = entry
+	}
+
+	subs := make([]chan ProgressEntry, 0, len(pt.subscribers))
+	for ch := range pt.subscribers {
+		subs = append(subs, ch)
+	}
+	pt.mu.Unlock()
+
+	for _, ch := range subs {
+		select {
+		case ch <- entry:
+		default:
+			// Subscriber isn't keeping up; drop rather than block the
+			// notification dispatch goroutine.
+		}
+	}
+}
+
+// rawTokenKey renders a json.RawMessage token (string or number) as a
+// plain string suitable for use as a map key.
+func rawTokenKey(raw json.RawMessage) string {
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		return s
+	}
+	var n int64
+	if err := json.Unmarshal(raw, &n); err == nil {
+		return string(raw)
+	}
+	return ""
+}
+
+// Snapshot returns a copy of every currently active progress stream.
+func (pt *ProgressTracker) Snapshot() []ProgressEntry {
+	pt.mu.RLock()
+	defer pt.mu.RUnlock()
+
+	entries := make([]ProgressEntry, 0, len(pt.active))
+	for _, e := range pt.active {
+		entries = append(entries, e)
+	}
+	return entries
+}
+
+// Subscribe returns a channel that receives every progress update as it's
+// observed, for callers that want a live tail rather than polling
+// Snapshot. The returned unsubscribe func must be called once the caller
+// is done reading.
+func (pt *ProgressTracker) Subscribe() (<-chan ProgressEntry, func()) {
+	ch := make(chan ProgressEntry, 32)
+
+	pt.mu.Lock()
+	pt.subscribers[ch] = struct{}{}
+	pt.mu.Unlock()
+
+	unsubscribe := func() {
+		pt.mu.Lock()
+		if _, ok := pt.subscribers[ch]; ok {
+			delete(pt.subscribers, ch)
+			close(ch)
+		}
+		pt.mu.Unlock()
+	}
+
+	return ch, unsubscribe
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// proxyProtoV2Sig is the fixed 12-byte signature that opens every PROXY
+// protocol v2 header (see haproxy's PROXY protocol spec).
+var proxyProtoV2Sig = []byte{0x0D, 0x0A, 0x0D, 0x0A, 0x00, 0x0D, 0x0A, 0x51, 0x55, 0x49, 0x54, 0x0A}
+
+// resolvePeerAddr returns the address HandleClient should treat as the
+// real client, honoring an optional HAProxy PROXY protocol v1/v2 header
+// sent as the very first bytes on the connection by a fronting load
+// balancer (see --trust-proxy). If trustProxy is false, or no such header
+// is present, it simply returns conn.RemoteAddr(). reader must not have
+// consumed any bytes from conn yet.
+func resolvePeerAddr(reader *bufio.Reader, conn net.Conn, trustProxy bool) (net.Addr, error) {
+	if !trustProxy {
+		return conn.RemoteAddr(), nil
+	}
+
+	peek, err := reader.Peek(12)
+	if err != nil {
+		// Not enough bytes on the wire yet to hold even a v1 "PROXY "
+		// prefix or the v2 signature - nothing to recover, fall back.
+		return conn.RemoteAddr(), nil
+	}
+
+	if bytesEqual(peek, proxyProtoV2Sig) {
+		return readProxyProtoV2(reader, conn)
+	}
+	if strings.HasPrefix(string(peek), "PROXY ") {
+		return readProxyProtoV1(reader, conn)
+	}
+
+	return conn.RemoteAddr(), nil
+}
+
+func bytesEqual(a, b []byte) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// readProxyProtoV1 consumes a "PROXY TCP4|TCP6|UNKNOWN <src> <dst>
+// <srcport> <dstport>\r\n" header line and returns the claimed source
+// address.
+func readProxyProtoV1(reader *bufio.Reader, conn net.Conn) (net.Addr, error) {
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return nil, fmt.Errorf("proxy protocol v1: read header: %w", err)
+	}
+	line = strings.TrimRight(line, "\r\n")
+
+	fields := strings.Fields(line)
+	if len(fields) < 2 {
+		return nil, fmt.Errorf("proxy protocol v1: malformed header %q", line)
+	}
+	if fields[1] == "UNKNOWN" || len(fields) < 6 {
+		return conn.RemoteAddr(), nil
+	}
+
+	port, err := strconv.Atoi(fields[4])
+	if err != nil {
+		return nil, fmt.Errorf("proxy protocol v1: bad source port %q", fields[4])
+	}
+
+	ip := net.ParseIP(fields[2])
+	if ip == nil {
+		return nil, fmt.Errorf("proxy protocol v1: bad source address %q", fields[2])
+	}
+
+	return &net.TCPAddr{IP: ip, Port: port}, nil
+}
+
+// readProxyProtoV2 consumes a binary PROXY protocol v2 header and returns
+// the claimed source address for AF_INET/AF_INET6 connections. LOCAL
+// connections (e.g. the load balancer's own health checks) and other
+// address families fall back to conn.RemoteAddr().
+func readProxyProtoV2(reader *bufio.Reader, conn net.Conn) (net.Addr, error) {
+	header := make([]byte, 16)
+	if _, err := io.ReadFull(reader, header); err != nil {
+		return nil, fmt.Errorf("proxy protocol v2: read header: %w", err)
+	}
+
+	verCmd := header[12]
+	famProto := header[13]
+	length := binary.BigEndian.Uint16(header[14:16])
+
+	addrBytes := make([]byte, length)
+	if length > 0 {
+		if _, err := io.ReadFull(reader, addrBytes); err != nil {
+			return nil, fmt.Errorf("proxy protocol v2: read address block: %w", err)
+		}
+	}
+
+	if command := verCmd & 0x0F; command == 0x00 { // LOCAL
+		return conn.RemoteAddr(), nil
+	}
+
+	switch famProto >> 4 {
+	case 0x1: // AF_INET
+		if len(addrBytes) < 12 {
+			return conn.RemoteAddr(), nil
+		}
+		srcIP := net.IP(addrBytes[0:4])
+		srcPort := binary.BigEndian.Uint16(addrBytes[8:10])
+		return &net.TCPAddr{IP: srcIP, Port: int(srcPort)}, nil
+	case 0x2: // AF_INET6
+		if len(addrBytes) < 36 {
+			return conn.RemoteAddr(), nil
+		}
+		srcIP := net.IP(addrBytes[0:16])
+		srcPort := binary.BigEndian.Uint16(addrBytes[32:34])
+		return &net.TCPAddr{IP: srcIP, Port: int(srcPort)}, nil
+	default:
+		return conn.RemoteAddr(), nil
+	}
+}
@@ -0,0 +1,242 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/exec"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Supervisor owns the LSP server child process's lifecycle: it spawns the
+// process, hands its pipes to an LSPProxy, and watches for unexpected
+// exit so it can respawn with exponential backoff and replay enough
+// client state - the cached initialize params plus any documents clients
+// still have open - for the new instance to reach a roughly equivalent
+// state. It also answers /healthz and /readyz so container orchestrators
+// can gate traffic on the LSP server actually being ready.
+type Supervisor struct {
+	command string
+	args    []string
+	proxy   *LSPProxy
+
+	mu  sync.Mutex
+	cmd *exec.Cmd
+
+	shuttingDown atomic.Bool
+	restarts     int64
+}
+
+// NewSupervisor creates a Supervisor that will run command/args and feed
+// the resulting process's pipes to proxy.
+func NewSupervisor(command string, args []string, proxy *LSPProxy) *Supervisor {
+	return &Supervisor{command: command, args: args, proxy: proxy}
+}
+
+// Start spawns the LSP server process and begins watching it for crashes.
+func (s *Supervisor) Start() error {
+	if err := s.spawn(); err != nil {
+		return err
+	}
+	go s.watch()
+	return nil
+}
+
+// spawn starts a new LSP server process and points the proxy at its pipes.
+func (s *Supervisor) spawn() error {
+	cmd := exec.Command(s.command, s.args...)
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return fmt.Errorf("stdin pipe: %w", err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return fmt.Errorf("stdout pipe: %w", err)
+	}
+	cmd.Stderr = os.Stderr
+
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("start: %w", err)
+	}
+	log.Printf("Supervisor: LSP server started with PID %d", cmd.Process.Pid)
+
+	s.mu.Lock()
+	s.cmd = cmd
+	s.mu.Unlock()
+
+	s.proxy.SetPipes(stdin, stdout)
+	return nil
+}
+
+// watch waits for the child process to exit. If that happens while we're
+// not deliberately shutting down, it respawns the process with
+// exponential backoff and replays enough state for the new instance to
+// catch up (see replayState), then keeps watching the new process.
+func (s *Supervisor) watch() {
+	for {
+		s.mu.Lock()
+		cmd := s.cmd
+		s.mu.Unlock()
+
+		err := cmd.Wait()
+		if s.shuttingDown.Load() {
+			return
+		}
+
+		log.Printf("Supervisor: LSP server exited unexpectedly: %v", err)
+		s.proxy.invalidateInitializeCache()
+
+		const maxBackoff = 30 * time.Second
+		backoff := time.Second
+
+		for attempt := 1; ; attempt++ {
+			time.Sleep(backoff)
+
+			if err := s.spawn(); err != nil {
+				log.Printf("Supervisor: respawn attempt %d failed: %v", attempt, err)
+				if backoff < maxBackoff {
+					backoff *= 2
+					if backoff > maxBackoff {
+						backoff = maxBackoff
+					}
+				}
+				continue
+			}
+
+			atomic.AddInt64(&s.restarts, 1)
+			s.replayState()
+			break
+		}
+	}
+}
+
+// replayState re-runs the initialize handshake with the params observed
+// from the first real client request, then replays a didOpen for every
+// document clients still have open, so the fresh LSP server instance
+// reaches a state roughly equivalent to the one that crashed. Edits made
+// via didChange since each document was (re)opened are not replayed -
+// only its last-known didOpen content - so this is a best-effort catch-up,
+// not a full text-sync resync.
+func (s *Supervisor) replayState() {
+	params := s.proxy.cachedInitParams()
+	if params == nil {
+		log.Printf("Supervisor: no cached initialize params yet, nothing to replay")
+		return
+	}
+
+	resp, err := s.proxy.sendInternalRequest("initialize", params, 30*time.Second)
+	if err != nil {
+		log.Printf("Supervisor: replay initialize failed: %v", err)
+		return
+	}
+	if resp.Error != nil {
+		log.Printf("Supervisor: replay initialize returned an error: %s", resp.Error)
+		return
+	}
+
+	if err := s.sendNotification("initialized", json.RawMessage("{}")); err != nil {
+		log.Printf("Supervisor: failed to send initialized notification: %v", err)
+		return
+	}
+
+	docs := s.proxy.openDocsSnapshot()
+	for uri, didOpenParams := range docs {
+		if err := s.sendNotification("textDocument/didOpen", didOpenParams); err != nil {
+			log.Printf("Supervisor: failed to replay didOpen for %s: %v", uri, err)
+		}
+	}
+	log.Printf("Supervisor: replayed initialize handshake + %d open document(s)", len(docs))
+}
+
+func (s *Supervisor) sendNotification(method string, params json.RawMessage) error {
+	msg, err := encodeLSPMessage(rpcMessage{JSONRPC: "2.0", Method: method, Params: params})
+	if err != nil {
+		return fmt.Errorf("encode %s: %w", method, err)
+	}
+	return s.proxy.writeToServer(msg)
+}
+
+// Shutdown performs the real LSP shutdown/exit handshake - a "shutdown"
+// request followed by an "exit" notification - with a bounded timeout,
+// falling back to killing the process if the server doesn't cooperate in
+// time.
+func (s *Supervisor) Shutdown(timeout time.Duration) {
+	s.shuttingDown.Store(true)
+
+	s.mu.Lock()
+	cmd := s.cmd
+	s.mu.Unlock()
+	if cmd == nil || cmd.Process == nil {
+		return
+	}
+
+	if _, err := s.proxy.sendInternalRequest("shutdown", nil, timeout); err != nil {
+		log.Printf("Supervisor: shutdown request failed or timed out, killing: %v", err)
+		cmd.Process.Kill()
+		return
+	}
+
+	if err := s.sendNotification("exit", nil); err != nil {
+		log.Printf("Supervisor: failed to send exit notification, killing: %v", err)
+		cmd.Process.Kill()
+		return
+	}
+
+	exited := make(chan struct{})
+	go func() {
+		cmd.Wait()
+		close(exited)
+	}()
+
+	select {
+	case <-exited:
+		log.Printf("Supervisor: LSP server exited cleanly")
+	case <-time.After(timeout):
+		log.Printf("Supervisor: LSP server did not exit after 'exit' notification, killing")
+		cmd.Process.Kill()
+	}
+}
+
+// Restarts returns the number of times the LSP server process has been
+// respawned since the supervisor started.
+func (s *Supervisor) Restarts() int64 {
+	return atomic.LoadInt64(&s.restarts)
+}
+
+// ServeHealth starts a minimal HTTP server on addr exposing /healthz (the
+// LSP server process is running) and /readyz (it has completed at least
+// one initialize handshake), for container orchestrators to gate traffic
+// on.
+func (s *Supervisor) ServeHealth(addr string) {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		cmd := s.cmd
+		s.mu.Unlock()
+		if cmd == nil || cmd.Process == nil {
+			http.Error(w, "no LSP server process", http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprintln(w, "ok")
+	})
+
+	mux.HandleFunc("/readyz", func(w http.ResponseWriter, r *http.Request) {
+		if !s.proxy.isInitialized() {
+			http.Error(w, "LSP server not initialized", http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprintln(w, "ready")
+	})
+
+	go func() {
+		if err := http.ListenAndServe(addr, mux); err != nil {
+			log.Printf("Supervisor: health server stopped: %v", err)
+		}
+	}()
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"log"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// wsUpgrader upgrades incoming HTTP connections to WebSocket for the
+// lsp-proxy WS transport (see --ws-port/--ws-path). CheckOrigin is
+// permissive: like the raw TCP listener, this proxy trusts its network
+// perimeter (firewall/reverse proxy) rather than same-origin policy to
+// keep unwanted clients out.
+var wsUpgrader = websocket.Upgrader{
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
+// wsConn adapts a gorilla *websocket.Conn to net.Conn so it can be handed
+// to LSPProxy.HandleClient unchanged: multiplexing, initialize caching,
+// auth/trust-proxy handling and progress tracking all work identically
+// whether a client came in over raw TCP or WebSocket. Each Write is sent
+// as one binary WS frame holding a complete Content-Length framed LSP
+// message; each Read drains one binary frame into the buffered reader
+// HandleClient builds on top - the same pattern lsp.gorillaRWC uses for
+// the WebSocket LanguageClient, just on the server side.
+type wsConn struct {
+	conn    *websocket.Conn
+	readBuf []byte
+	readMu  sync.Mutex
+	writeMu sync.Mutex
+}
+
+func newWSConn(conn *websocket.Conn) *wsConn {
+	return &wsConn{conn: conn}
+}
+
+func (c *wsConn) Read(p []byte) (int, error) {
+	c.readMu.Lock()
+	defer c.readMu.Unlock()
+
+	if len(c.readBuf) > 0 {
+		n := copy(p, c.readBuf)
+		c.readBuf = c.readBuf[n:]
+		return n, nil
+	}
+
+	_, msg, err := c.conn.ReadMessage()
+	if err != nil {
+		return 0, err
+	}
+
+	n := copy(p, msg)
+	if n < len(msg) {
+		c.readBuf = msg[n:]
+	}
+	return n, nil
+}
+
+func (c *wsConn) Write(p []byte) (int, error) {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	if err := c.conn.WriteMessage(websocket.BinaryMessage, p); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func (c *wsConn) Close() error         { return c.conn.Close() }
+func (c *wsConn) LocalAddr() net.Addr  { return c.conn.LocalAddr() }
+func (c *wsConn) RemoteAddr() net.Addr { return c.conn.RemoteAddr() }
+
+func (c *wsConn) SetDeadline(t time.Time) error {
+	if err := c.conn.SetReadDeadline(t); err != nil {
+		return err
+	}
+	return c.conn.SetWriteDeadline(t)
+}
+
+func (c *wsConn) SetReadDeadline(t time.Time) error  { return c.conn.SetReadDeadline(t) }
+func (c *wsConn) SetWriteDeadline(t time.Time) error { return c.conn.SetWriteDeadline(t) }
+
+// serveWebSocket starts an HTTP server on addr that upgrades every request
+// to path into a WebSocket connection and hands it to proxy.HandleClient -
+// the WebSocket counterpart to the raw TCP accept loop in main(). This lets
+// browser-based clients and editors that speak LSP-over-WebSocket (e.g.
+// Monaco/vscode-ws-jsonrpc) talk to the same pre-warmed LSP server instance
+// without a separate bridge.
+func serveWebSocket(addr, path string, proxy *LSPProxy) {
+	mux := http.NewServeMux()
+	mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+		conn, err := wsUpgrader.Upgrade(w, r, nil)
+		if err != nil {
+			log.Printf("WebSocket upgrade failed: %v", err)
+			return
+		}
+		log.Printf("WebSocket client connected: %s", conn.RemoteAddr())
+		go proxy.HandleClient(newWSConn(conn))
+	})
+
+	go func() {
+		if err := http.ListenAndServe(addr, mux); err != nil {
+			log.Printf("WebSocket server stopped: %v", err)
+		}
+	}()
+}
@@ -0,0 +1,235 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+
+	"github.com/sourcegraph/jsonrpc2"
+)
+
+// LanguageServerSpec describes how to launch and initialize one language
+// server process.
+type LanguageServerSpec struct {
+	Command               string                 `json:"command"`
+	Args                  []string               `json:"args"`
+	Workspace             string                 `json:"workspace,omitempty"`
+	InitializationOptions map[string]interface{} `json:"initialization_options,omitempty"`
+}
+
+// LanguagesConfig maps languageIds to server specs and file extensions to
+// languageIds, so the daemon can route `textDocument/*` requests to the
+// right process for a workspace that mixes languages.
+type LanguagesConfig struct {
+	Servers    map[string]LanguageServerSpec `json:"servers"`
+	Extensions map[string]string             `json:"extensions,omitempty"`
+}
+
+// LoadLanguagesConfig reads a JSON file mapping languageIds/extensions to
+// LSP server commands. An empty path is not an error: callers fall back to
+// the single `--command` server.
+func LoadLanguagesConfig(path string) (*LanguagesConfig, error) {
+	if path == "" {
+		return &LanguagesConfig{Servers: map[string]LanguageServerSpec{}}, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read languages config %s: %w", path, err)
+	}
+
+	var cfg LanguagesConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse languages config %s: %w", path, err)
+	}
+	if cfg.Servers == nil {
+		cfg.Servers = map[string]LanguageServerSpec{}
+	}
+	return &cfg, nil
+}
+
+// languageIDForURI resolves a languageId from an explicit hint (usually the
+// one didOpen reported) or, failing that, the file extension of uri.
+func (c *LanguagesConfig) languageIDForURI(uri, explicit string) string {
+	if explicit != "" {
+		if _, ok := c.Servers[explicit]; ok {
+			return explicit
+		}
+	}
+	ext := filepath.Ext(uri)
+	if lang, ok := c.Extensions[ext]; ok {
+		return lang
+	}
+	return explicit
+}
+
+// LSPProcess is one running, independently-initialized language server,
+// keyed by languageId in SessionManager.
+type LSPProcess struct {
+	languageID string
+	spec       LanguageServerSpec
+
+	cmd  *exec.Cmd
+	conn *jsonrpc2.Conn
+
+	initialized  bool
+	capabilities json.RawMessage
+}
+
+// startLSPProcess launches spec.Command and performs the LSP initialize
+// handshake against workspaceDir, dispatching server-initiated messages to
+// handler.
+func startLSPProcess(languageID string, spec LanguageServerSpec, workspaceDir string, handler jsonrpc2.Handler) (*LSPProcess, error) {
+	if spec.Workspace != "" {
+		workspaceDir = spec.Workspace
+	}
+
+	cmd := exec.Command(spec.Command, spec.Args...)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stdin pipe: %w", err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stdout pipe: %w", err)
+	}
+	cmd.Stderr = os.Stderr
+
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start LSP server %q: %w", spec.Command, err)
+	}
+
+	rwc := stdioReadWriteCloser{Reader: stdout, WriteCloser: stdin}
+	stream := jsonrpc2.NewBufferedStream(rwc, jsonrpc2.VSCodeObjectCodec{})
+	conn := jsonrpc2.NewConn(context.Background(), stream, handler)
+
+	proc := &LSPProcess{languageID: languageID, spec: spec, cmd: cmd, conn: conn}
+
+	params := map[string]interface{}{
+		"processId": nil,
+		"capabilities": map[string]interface{}{
+			"textDocument": map[string]interface{}{
+				"hover": map[string]interface{}{
+					"contentFormat": []string{"markdown", "plaintext"},
+				},
+				"definition": map[string]interface{}{
+					"linkSupport": true,
+				},
+				"references":     map[string]interface{}{},
+				"callHierarchy":  map[string]interface{}{},
+				"documentSymbol": map[string]interface{}{},
+				"diagnostic":     map[string]interface{}{},
+			},
+			"workspace": map[string]interface{}{
+				"workspaceFolders": true,
+			},
+		},
+		"rootUri": "file://" + workspaceDir,
+		"workspaceFolders": []map[string]string{
+			{"uri": "file://" + workspaceDir, "name": "workspace"},
+		},
+		"initializationOptions": spec.InitializationOptions,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	var result json.RawMessage
+	if err := conn.Call(ctx, "initialize", params, &result); err != nil {
+		return nil, fmt.Errorf("initialize request failed for %s: %w", languageID, err)
+	}
+
+	var initResp struct {
+		Capabilities json.RawMessage `json:"capabilities"`
+	}
+	json.Unmarshal(result, &initResp)
+
+	proc.initialized = true
+	proc.capabilities = initResp.Capabilities
+
+	if err := conn.Notify(ctx, "initialized", map[string]interface{}{}); err != nil {
+		logr.Warn("sessionmanager", "failed to send initialized notification", Fields{"language": languageID, "error": err.Error()})
+	}
+
+	return proc, nil
+}
+
+func (p *LSPProcess) stop() {
+	if p.conn != nil {
+		p.conn.Notify(context.Background(), "exit", nil)
+		p.conn.Close()
+	}
+	if p.cmd != nil && p.cmd.Process != nil {
+		p.cmd.Process.Kill()
+	}
+}
+
+// mergeWorkspaceSymbols deduplicates workspace/symbol results fanned out
+// across multiple servers by (uri, range, name).
+func mergeWorkspaceSymbols(results []json.RawMessage) []json.RawMessage {
+	type symbolKey struct {
+		name  string
+		uri   string
+		rng   string
+	}
+
+	seen := make(map[symbolKey]bool)
+	merged := make([]json.RawMessage, 0)
+
+	for _, raw := range results {
+		var symbols []struct {
+			Name     string          `json:"name"`
+			Location json.RawMessage `json:"location"`
+		}
+		if err := json.Unmarshal(raw, &symbols); err != nil {
+			continue
+		}
+		for _, sym := range symbols {
+			var loc struct {
+				URI   string          `json:"uri"`
+				Range json.RawMessage `json:"range"`
+			}
+			json.Unmarshal(sym.Location, &loc)
+			key := symbolKey{name: sym.Name, uri: loc.URI, rng: string(loc.Range)}
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+
+			entry, err := json.Marshal(struct {
+				Name     string          `json:"name"`
+				Location json.RawMessage `json:"location"`
+			}{Name: sym.Name, Location: sym.Location})
+			if err == nil {
+				merged = append(merged, entry)
+			}
+		}
+	}
+
+	return merged
+}
+
+// extractURI pulls the document URI a forwarded request operates on, used
+// to resolve which server should receive it. Most textDocument/* requests
+// nest it under "textDocument"; call hierarchy requests nest it under "item".
+func extractURI(params json.RawMessage) string {
+	var withDoc struct {
+		TextDocument struct {
+			URI string `json:"uri"`
+		} `json:"textDocument"`
+		Item struct {
+			URI string `json:"uri"`
+		} `json:"item"`
+	}
+	if err := json.Unmarshal(params, &withDoc); err != nil {
+		return ""
+	}
+	if withDoc.TextDocument.URI != "" {
+		return withDoc.TextDocument.URI
+	}
+	return withDoc.Item.URI
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Level is a leveled-logging severity, ordered from most to least verbose.
+type Level int
+
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+func ParseLevel(s string) Level {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "debug":
+		return LevelDebug
+	case "warn", "warning":
+		return LevelWarn
+	case "error":
+		return LevelError
+	default:
+		return LevelInfo
+	}
+}
+
+func (l Level) String() string {
+	switch l {
+	case LevelDebug:
+		return "debug"
+	case LevelWarn:
+		return "warn"
+	case LevelError:
+		return "error"
+	default:
+		return "info"
+	}
+}
+
+// Fields is structured context attached to a log event (e.g. component,
+// remote addr, request id, method, duration).
+type Fields map[string]interface{}
+
+// Logger is a minimal structured, leveled logger for the daemon. It writes
+// to stderr as either logfmt-ish text or one JSON object per line, so the
+// daemon's output stays grep-friendly and machine-parseable.
+type Logger struct {
+	mu     sync.Mutex
+	level  Level
+	json   bool
+	output *os.File
+}
+
+func NewLogger(level Level, format string) *Logger {
+	return &Logger{level: level, json: strings.EqualFold(format, "json"), output: os.Stderr}
+}
+
+func (l *Logger) enabled(level Level) bool {
+	return level >= l.level
+}
+
+func (l *Logger) log(level Level, component, msg string, fields Fields) {
+	if !l.enabled(level) {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.json {
+		entry := Fields{
+			"time":      time.Now().Format(time.RFC3339Nano),
+			"level":     level.String(),
+			"component": component,
+			"msg":       msg,
+		}
+		for k, v := range fields {
+			entry[k] = v
+		}
+		enc, err := json.Marshal(entry)
+		if err != nil {
+			fmt.Fprintf(l.output, `{"level":"error","msg":"failed to encode log entry: %v"}`+"\n", err)
+			return
+		}
+		l.output.Write(append(enc, '\n'))
+		return
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s level=%s component=%s msg=%q", time.Now().Format(time.RFC3339), level, component, msg)
+	for _, k := range sortedKeys(fields) {
+		fmt.Fprintf(&b, " %s=%v", k, fields[k])
+	}
+	fmt.Fprintln(l.output, b.String())
+}
+
+func sortedKeys(fields Fields) []string {
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (l *Logger) Debug(component, msg string, fields Fields) { l.log(LevelDebug, component, msg, fields) }
+func (l *Logger) Info(component, msg string, fields Fields)  { l.log(LevelInfo, component, msg, fields) }
+func (l *Logger) Warn(component, msg string, fields Fields)  { l.log(LevelWarn, component, msg, fields) }
+func (l *Logger) Error(component, msg string, fields Fields) { l.log(LevelError, component, msg, fields) }
+
+func (l *Logger) Fatal(component, msg string, fields Fields) {
+	l.log(LevelError, component, msg, fields)
+	os.Exit(1)
+}
@@ -1,13 +1,17 @@
 // LSP Session Manager - Persistent LSP session daemon
 //
 // This daemon:
-// 1. Starts BSL Language Server once at container startup
-// 2. Initializes LSP session and waits for indexing to complete
-// 3. Keeps the session alive and ready for requests
+// 1. Starts the default language server (from --command) once at container
+//    startup, starts any further servers configured via --languages-config
+//    lazily on first use, and routes requests to the right one by languageId
+//    or file extension
+// 2. Initializes each LSP session and waits for indexing to complete
+// 3. Keeps the sessions alive and ready for requests
 // 4. Provides a simple JSON-RPC API for mcp-lsp-bridge to call LSP methods
 //
-// This solves the problem of repeated initialization - BSL LS indexes once,
-// and all subsequent requests use the same initialized session.
+// This solves the problem of repeated initialization - a language server
+// indexes once, and all subsequent requests use the same initialized
+// session.
 
 package main
 
@@ -18,60 +22,84 @@ import (
 	"flag"
 	"fmt"
 	"io"
-	"log"
 	"net"
 	"os"
-	"os/exec"
 	"os/signal"
-	"strconv"
-	"strings"
 	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
+
+	"github.com/sourcegraph/jsonrpc2"
 )
 
 var (
-	port        = flag.Int("port", 9999, "TCP port to listen on")
-	command     = flag.String("command", "", "LSP server command to run")
+	port         = flag.Int("port", 9999, "TCP port to listen on")
+	unixSocket   = flag.String("socket", "", "Unix domain socket path to listen on (takes precedence over --port)")
+	stdioMode    = flag.Bool("stdio", false, "Serve a single API client over stdin/stdout instead of listening on a socket")
+	command      = flag.String("command", "", "Default LSP server command to run")
 	workspaceDir = flag.String("workspace", "/projects", "Workspace directory for LSP")
+	languagesCfg = flag.String("languages-config", "", "Path to a JSON file mapping languageIds/extensions to additional LSP servers")
+	logLevel     = flag.String("log-level", "info", "Log level: debug|info|warn|error")
+	logFormat    = flag.String("log-format", "text", "Log format: text|json")
 )
 
+// logr is the daemon's structured logger, configured from --log-level/--log-format.
+var logr *Logger
+
 func main() {
 	flag.Parse()
 
+	logr = NewLogger(ParseLevel(*logLevel), *logFormat)
+
 	if *command == "" {
-		log.Fatal("--command is required")
+		logr.Fatal("main", "--command is required", nil)
 	}
 
 	cmdArgs := flag.Args()
 
-	log.Printf("Starting LSP Session Manager on port %d", *port)
-	log.Printf("Workspace: %s", *workspaceDir)
-	log.Printf("LSP command: %s %v", *command, cmdArgs)
+	logr.Info("main", "starting LSP session manager", Fields{"workspace": *workspaceDir, "command": *command, "args": cmdArgs})
+
+	langConfig, err := LoadLanguagesConfig(*languagesCfg)
+	if err != nil {
+		logr.Fatal("main", "failed to load languages config", Fields{"error": err.Error()})
+	}
 
 	// Create session manager
-	sm := NewSessionManager(*command, cmdArgs, *workspaceDir)
+	sm := NewSessionManager(*command, cmdArgs, *workspaceDir, langConfig)
 
 	// Start LSP server and initialize session
 	if err := sm.Start(); err != nil {
-		log.Fatalf("Failed to start LSP session: %v", err)
-	}
-
-	// Start TCP listener for API requests
-	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", *port))
-	if err != nil {
-		log.Fatalf("Failed to listen on port %d: %v", *port, err)
+		logr.Fatal("main", "failed to start LSP session", Fields{"error": err.Error()})
 	}
-	defer listener.Close()
-	log.Printf("API listening on port %d", *port)
 
 	// Handle shutdown
 	sigCh := make(chan os.Signal, 1)
 	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
 
+	if *stdioMode {
+		logr.Info("main", "serving API over stdio", nil)
+		go func() {
+			<-sigCh
+			logr.Info("main", "shutting down", nil)
+			sm.Stop()
+			os.Exit(0)
+		}()
+		sm.HandleClient(stdioConn{})
+		sm.Stop()
+		return
+	}
+
+	listener, err := listen()
+	if err != nil {
+		logr.Fatal("main", "failed to listen", Fields{"error": err.Error()})
+	}
+	defer listener.Close()
+	logr.Info("main", "API listening", Fields{"addr": listener.Addr().String()})
+
 	go func() {
 		<-sigCh
-		log.Println("Shutting down...")
+		logr.Info("main", "shutting down", nil)
 		sm.Stop()
 		listener.Close()
 		os.Exit(0)
@@ -81,286 +109,186 @@ func main() {
 	for {
 		conn, err := listener.Accept()
 		if err != nil {
-			log.Printf("Accept error: %v", err)
+			logr.Warn("main", "accept error", Fields{"error": err.Error()})
 			continue
 		}
 		go sm.HandleClient(conn)
 	}
 }
 
-// SessionManager manages a persistent LSP session
-type SessionManager struct {
-	command      string
-	args         []string
-	workspaceDir string
+// listen opens either a Unix domain socket (when --socket is set) or a TCP
+// listener on --port for the API side.
+func listen() (net.Listener, error) {
+	if *unixSocket != "" {
+		if err := os.RemoveAll(*unixSocket); err != nil {
+			return nil, fmt.Errorf("failed to clear stale socket %s: %w", *unixSocket, err)
+		}
+		return net.Listen("unix", *unixSocket)
+	}
+	return net.Listen("tcp", fmt.Sprintf(":%d", *port))
+}
 
-	mu           sync.RWMutex
-	cmd          *exec.Cmd
-	stdin        io.WriteCloser
-	stdout       io.Reader
-	
-	initialized  bool
-	initResult   json.RawMessage
-	capabilities json.RawMessage
-	
-	// Request/response handling
-	requestID    int64
-	pending      map[int64]chan json.RawMessage
-	pendingMu    sync.Mutex
-	
-	// Document tracking
-	openDocs     map[string]bool
-	openDocsMu   sync.Mutex
+// stdioConn adapts the process's stdin/stdout into a net.Conn so a single
+// API client can be served over stdio, mirroring how LanguageClient talks
+// to LSP servers directly.
+type stdioConn struct{}
+
+func (stdioConn) Read(p []byte) (int, error)       { return os.Stdin.Read(p) }
+func (stdioConn) Write(p []byte) (int, error)      { return os.Stdout.Write(p) }
+func (stdioConn) Close() error                     { return nil }
+func (stdioConn) LocalAddr() net.Addr              { return stdioAddr{} }
+func (stdioConn) RemoteAddr() net.Addr             { return stdioAddr{} }
+func (stdioConn) SetDeadline(time.Time) error      { return nil }
+func (stdioConn) SetReadDeadline(time.Time) error  { return nil }
+func (stdioConn) SetWriteDeadline(time.Time) error { return nil }
+
+type stdioAddr struct{}
+
+func (stdioAddr) Network() string { return "stdio" }
+func (stdioAddr) String() string  { return "stdio" }
+
+// stdioReadWriteCloser adapts the LSP child process's stdin/stdout pipes
+// into a single io.ReadWriteCloser so they can back a jsonrpc2.Stream.
+type stdioReadWriteCloser struct {
+	io.Reader
+	io.WriteCloser
 }
 
-// NewSessionManager creates a new session manager
-func NewSessionManager(command string, args []string, workspaceDir string) *SessionManager {
-	return &SessionManager{
-		command:      command,
-		args:         args,
-		workspaceDir: workspaceDir,
-		pending:      make(map[int64]chan json.RawMessage),
-		openDocs:     make(map[string]bool),
-	}
+func (s stdioReadWriteCloser) Close() error {
+	return s.WriteCloser.Close()
 }
 
-// Start starts the LSP server and initializes the session
-func (sm *SessionManager) Start() error {
-	log.Println("Starting LSP server...")
+// SessionManager manages one or more persistent LSP sessions, one process
+// per languageId, and proxies API clients to the right one.
+type SessionManager struct {
+	workspaceDir string
+	langConfig   *LanguagesConfig
 
-	sm.cmd = exec.Command(sm.command, sm.args...)
+	mu        sync.RWMutex
+	processes map[string]*LSPProcess // keyed by languageId ("" is the default/bootstrap server)
 
-	var err error
-	sm.stdin, err = sm.cmd.StdinPipe()
-	if err != nil {
-		return fmt.Errorf("failed to get stdin pipe: %w", err)
-	}
+	// docLanguage remembers which languageId a URI was opened as, so
+	// subsequent requests route to the same server without re-parsing args.
+	docLanguageMu sync.Mutex
+	docLanguage   map[string]string
 
-	sm.stdout, err = sm.cmd.StdoutPipe()
-	if err != nil {
-		return fmt.Errorf("failed to get stdout pipe: %w", err)
-	}
+	// Document tracking
+	openDocs   map[string]bool
+	openDocsMu sync.Mutex
+
+	// clients holds the connected API clients so server-initiated
+	// notifications ($/progress, window/logMessage, publishDiagnostics)
+	// can be relayed to all of them.
+	clientsMu sync.Mutex
+	clients   map[*jsonrpc2.Conn]bool
+
+	// cancelMu/cancelFuncs tracks in-flight API requests so an API client's
+	// "$/cancelRequest" can be propagated to the LSP server.
+	cancelMu    sync.Mutex
+	cancelFuncs map[string]context.CancelFunc
+	lspReqSeq   int64
+}
 
-	sm.cmd.Stderr = os.Stderr
+// relayedNotifications are the LSP-server-initiated notifications that get
+// forwarded to every connected API client as-is.
+var relayedNotifications = map[string]bool{
+	"$/progress":                      true,
+	"window/logMessage":               true,
+	"textDocument/publishDiagnostics": true,
+}
 
-	if err := sm.cmd.Start(); err != nil {
-		return fmt.Errorf("failed to start LSP server: %w", err)
+// NewSessionManager creates a new session manager. defaultCommand/defaultArgs
+// describe the "" languageId server; langConfig adds any further per-language
+// servers and extension routing on top.
+func NewSessionManager(defaultCommand string, defaultArgs []string, workspaceDir string, langConfig *LanguagesConfig) *SessionManager {
+	if langConfig == nil {
+		langConfig = &LanguagesConfig{Servers: map[string]LanguageServerSpec{}}
 	}
-	log.Printf("LSP server started with PID %d", sm.cmd.Process.Pid)
-
-	// Start response reader
-	go sm.readResponses()
-
-	// Initialize LSP session
-	if err := sm.initialize(); err != nil {
-		return fmt.Errorf("failed to initialize LSP session: %w", err)
+	if defaultCommand != "" {
+		if _, exists := langConfig.Servers[""]; !exists {
+			langConfig.Servers[""] = LanguageServerSpec{Command: defaultCommand, Args: defaultArgs, Workspace: workspaceDir}
+		}
 	}
 
-	return nil
-}
-
-// Stop stops the LSP server
-func (sm *SessionManager) Stop() {
-	if sm.cmd != nil && sm.cmd.Process != nil {
-		sm.sendNotification("exit", nil)
-		sm.cmd.Process.Kill()
+	return &SessionManager{
+		workspaceDir: workspaceDir,
+		langConfig:   langConfig,
+		processes:    make(map[string]*LSPProcess),
+		docLanguage:  make(map[string]string),
+		clients:      make(map[*jsonrpc2.Conn]bool),
+		cancelFuncs:  make(map[string]context.CancelFunc),
+		openDocs:     make(map[string]bool),
 	}
 }
 
-// initialize sends initialize request and waits for response
-func (sm *SessionManager) initialize() error {
-	log.Println("Initializing LSP session...")
-
-	// Build workspace folders
-	workspaceFolders := []map[string]string{
-		{
-			"uri":  "file://" + sm.workspaceDir,
-			"name": "workspace",
-		},
-	}
-
-	params := map[string]interface{}{
-		"processId": nil, // Don't monitor parent process
-		"capabilities": map[string]interface{}{
-			"textDocument": map[string]interface{}{
-				"hover": map[string]interface{}{
-					"contentFormat": []string{"markdown", "plaintext"},
-				},
-				"definition": map[string]interface{}{
-					"linkSupport": true,
-				},
-				"references":     map[string]interface{}{},
-				"callHierarchy":  map[string]interface{}{},
-				"documentSymbol": map[string]interface{}{},
-				"diagnostic":     map[string]interface{}{},
-			},
-			"workspace": map[string]interface{}{
-				"workspaceFolders": true,
-			},
-		},
-		"rootUri":          "file://" + sm.workspaceDir,
-		"workspaceFolders": workspaceFolders,
-	}
-
-	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
-	defer cancel()
-
-	result, err := sm.sendRequest(ctx, "initialize", params)
+// Start starts the default ("") language server and waits for indexing.
+// Other configured servers are started lazily on first use.
+func (sm *SessionManager) Start() error {
+	proc, err := sm.getOrStartProcess("")
 	if err != nil {
-		return fmt.Errorf("initialize request failed: %w", err)
+		return fmt.Errorf("failed to start default LSP session: %w", err)
 	}
+	logr.Info("sessionmanager", "LSP server started", Fields{"pid": proc.cmd.Process.Pid, "language": "default"})
 
-	sm.mu.Lock()
-	sm.initResult = result
-	sm.initialized = true
-	sm.mu.Unlock()
-
-	// Extract capabilities
-	var initResp struct {
-		Capabilities json.RawMessage `json:"capabilities"`
-	}
-	if err := json.Unmarshal(result, &initResp); err == nil {
-		sm.mu.Lock()
-		sm.capabilities = initResp.Capabilities
-		sm.mu.Unlock()
-	}
-
-	log.Println("LSP session initialized successfully")
-
-	// Send initialized notification
-	if err := sm.sendNotification("initialized", map[string]interface{}{}); err != nil {
-		log.Printf("Warning: failed to send initialized notification: %v", err)
-	}
-
-	log.Println("Waiting for indexing to complete...")
-	// Give BSL LS time to index - we'll track progress via $/progress notifications
+	logr.Info("sessionmanager", "waiting for indexing to complete", nil)
+	// Give the LS time to index - we'll track progress via $/progress notifications
 	time.Sleep(5 * time.Second)
 
 	return nil
 }
 
-// sendRequest sends an LSP request and waits for response
-func (sm *SessionManager) sendRequest(ctx context.Context, method string, params interface{}) (json.RawMessage, error) {
-	sm.pendingMu.Lock()
-	sm.requestID++
-	id := sm.requestID
-	respCh := make(chan json.RawMessage, 1)
-	sm.pending[id] = respCh
-	sm.pendingMu.Unlock()
-
-	defer func() {
-		sm.pendingMu.Lock()
-		delete(sm.pending, id)
-		sm.pendingMu.Unlock()
-	}()
-
-	req := map[string]interface{}{
-		"jsonrpc": "2.0",
-		"id":      id,
-		"method":  method,
-		"params":  params,
-	}
-
-	if err := sm.writeMessage(req); err != nil {
-		return nil, err
+// getOrStartProcess lazily launches and initializes the server configured
+// for languageID, starting it on first use.
+func (sm *SessionManager) getOrStartProcess(languageID string) (*LSPProcess, error) {
+	sm.mu.RLock()
+	proc, ok := sm.processes[languageID]
+	sm.mu.RUnlock()
+	if ok {
+		return proc, nil
 	}
 
-	select {
-	case resp := <-respCh:
-		return resp, nil
-	case <-ctx.Done():
-		return nil, ctx.Err()
+	spec, ok := sm.langConfig.Servers[languageID]
+	if !ok {
+		return nil, fmt.Errorf("no language server configured for languageId %q", languageID)
 	}
-}
 
-// sendNotification sends an LSP notification (no response expected)
-func (sm *SessionManager) sendNotification(method string, params interface{}) error {
-	req := map[string]interface{}{
-		"jsonrpc": "2.0",
-		"method":  method,
-		"params":  params,
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	if proc, ok := sm.processes[languageID]; ok {
+		return proc, nil
 	}
-	return sm.writeMessage(req)
-}
 
-// writeMessage writes an LSP message to the server
-func (sm *SessionManager) writeMessage(msg interface{}) error {
-	body, err := json.Marshal(msg)
+	logr.Info("sessionmanager", "starting language server", Fields{"language": languageID, "command": spec.Command})
+	proc, err := startLSPProcess(languageID, spec, sm.workspaceDir, jsonrpc2.HandlerWithError(sm.makeServerHandler(languageID)))
 	if err != nil {
-		return err
+		return nil, err
 	}
+	sm.processes[languageID] = proc
+	return proc, nil
+}
 
-	header := fmt.Sprintf("Content-Length: %d\r\n\r\n", len(body))
-	
-	sm.mu.Lock()
-	defer sm.mu.Unlock()
-	
-	if _, err := sm.stdin.Write([]byte(header)); err != nil {
-		return err
-	}
-	if _, err := sm.stdin.Write(body); err != nil {
-		return err
+// Stop stops every running language server.
+func (sm *SessionManager) Stop() {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+	for _, proc := range sm.processes {
+		proc.stop()
 	}
-	return nil
 }
 
-// readResponses reads responses from LSP server
-func (sm *SessionManager) readResponses() {
-	reader := bufio.NewReader(sm.stdout)
-
-	for {
-		msg, err := readLSPMessage(reader)
-		if err != nil {
-			log.Printf("LSP read error: %v", err)
-			return
-		}
-
-		// Parse message
-		var baseMsg struct {
-			ID     *int64          `json:"id"`
-			Method string          `json:"method"`
-			Result json.RawMessage `json:"result"`
-			Error  *struct {
-				Code    int    `json:"code"`
-				Message string `json:"message"`
-			} `json:"error"`
-		}
-
-		if err := json.Unmarshal(msg, &baseMsg); err != nil {
-			log.Printf("Failed to parse LSP message: %v", err)
-			continue
-		}
-
-		// Handle response (has id, no method)
-		if baseMsg.ID != nil && baseMsg.Method == "" {
-			sm.pendingMu.Lock()
-			if ch, ok := sm.pending[*baseMsg.ID]; ok {
-				if baseMsg.Error != nil {
-					// Send error as JSON
-					errJSON, _ := json.Marshal(baseMsg.Error)
-					ch <- errJSON
-				} else {
-					ch <- baseMsg.Result
-				}
-			}
-			sm.pendingMu.Unlock()
-			continue
+// makeServerHandler builds the jsonrpc2 handler for one language server's
+// connection: it relays the notifications in relayedNotifications to API
+// clients as-is (they carry no languageId) and logs progress at debug
+// level, tagged with the languageId it came from.
+func (sm *SessionManager) makeServerHandler(languageID string) func(ctx context.Context, conn *jsonrpc2.Conn, req *jsonrpc2.Request) (interface{}, error) {
+	return func(ctx context.Context, conn *jsonrpc2.Conn, req *jsonrpc2.Request) (interface{}, error) {
+		if relayedNotifications[req.Method] {
+			sm.relayToClients(req.Method, req.Params)
 		}
 
-		// Handle notification (no id)
-		if baseMsg.Method != "" {
-			sm.handleNotification(baseMsg.Method, msg)
-		}
-	}
-}
-
-// handleNotification handles LSP notifications from server
-func (sm *SessionManager) handleNotification(method string, msg []byte) {
-	switch method {
-	case "$/progress":
-		// Log progress updates
-		var progress struct {
-			Params struct {
+		switch req.Method {
+		case "$/progress":
+			var progress struct {
 				Token string `json:"token"`
 				Value struct {
 					Kind       string `json:"kind"`
@@ -368,127 +296,148 @@ func (sm *SessionManager) handleNotification(method string, msg []byte) {
 					Message    string `json:"message"`
 					Percentage int    `json:"percentage"`
 				} `json:"value"`
-			} `json:"params"`
-		}
-		if json.Unmarshal(msg, &progress) == nil {
-			if progress.Params.Value.Kind != "" {
-				log.Printf("Progress [%s]: %s %s (%d%%)",
-					progress.Params.Value.Kind,
-					progress.Params.Value.Title,
-					progress.Params.Value.Message,
-					progress.Params.Value.Percentage)
 			}
+			if req.Params != nil && json.Unmarshal(*req.Params, &progress) == nil {
+				if progress.Value.Kind != "" {
+					logr.Debug("sessionmanager", "LSP server progress", Fields{
+						"language":   languageID,
+						"kind":       progress.Value.Kind,
+						"title":      progress.Value.Title,
+						"message":    progress.Value.Message,
+						"percentage": progress.Value.Percentage,
+					})
+				}
+			}
+			return nil, nil
+
+		case "textDocument/publishDiagnostics":
+			// Could cache diagnostics here
+			return nil, nil
+
+		case "window/workDoneProgress/create", "client/registerCapability":
+			// Acknowledge server-initiated requests we don't otherwise act on.
+			return map[string]interface{}{}, nil
+
+		default:
+			if req.Notif {
+				logr.Debug("sessionmanager", "unhandled server notification", Fields{"language": languageID, "method": req.Method})
+				return nil, nil
+			}
+			return nil, &jsonrpc2.Error{Code: jsonrpc2.CodeMethodNotFound, Message: "method not found"}
 		}
-	case "textDocument/publishDiagnostics":
-		// Could cache diagnostics here
-	default:
-		log.Printf("Notification: %s", method)
 	}
 }
 
-// HandleClient handles an API client connection
-func (sm *SessionManager) HandleClient(conn net.Conn) {
-	defer conn.Close()
-	log.Printf("API client connected: %s", conn.RemoteAddr())
+// resolveProcess finds the already-initialized-or-lazily-started server that
+// should handle a request for uri, preferring an explicit languageId hint
+// (e.g. from didOpen) and falling back to the tracked/extension-inferred one.
+func (sm *SessionManager) resolveProcess(uri, explicitLanguageID string) (*LSPProcess, error) {
+	languageID := explicitLanguageID
+	if languageID == "" {
+		sm.docLanguageMu.Lock()
+		languageID = sm.docLanguage[uri]
+		sm.docLanguageMu.Unlock()
+	}
+	languageID = sm.langConfig.languageIDForURI(uri, languageID)
+	return sm.getOrStartProcess(languageID)
+}
 
-	reader := bufio.NewReader(conn)
+// HandleClient handles an API client connection as a jsonrpc2 server,
+// so multiple API clients can multiplex requests over one TCP connection
+// using standard jsonrpc2 id/cancellation semantics.
+func (sm *SessionManager) HandleClient(conn net.Conn) {
+	logr.Info("api", "client connected", Fields{"remote": conn.RemoteAddr().String()})
 
-	for {
-		// Read JSON-RPC request (newline-delimited)
-		log.Printf("Waiting for request from %s...", conn.RemoteAddr())
-		line, err := reader.ReadString('\n')
-		if err != nil {
-			if err != io.EOF {
-				log.Printf("Client read error: %v", err)
-			} else {
-				log.Printf("Client %s closed connection (EOF)", conn.RemoteAddr())
-			}
-			break
-		}
+	stream := jsonrpc2.NewBufferedStream(conn, jsonrpc2.VSCodeObjectCodec{})
+	rpcConn := jsonrpc2.NewConn(context.Background(), stream, jsonrpc2.HandlerWithError(sm.handleAPIRequest))
 
-		line = strings.TrimSpace(line)
-		if line == "" {
-			continue
-		}
+	sm.clientsMu.Lock()
+	sm.clients[rpcConn] = true
+	sm.clientsMu.Unlock()
 
-		log.Printf("Received request from %s: %s", conn.RemoteAddr(), line)
+	<-rpcConn.DisconnectNotify()
 
-		// Parse request
-		var req struct {
-			JSONRPC string          `json:"jsonrpc"`
-			ID      int64           `json:"id"`
-			Method  string          `json:"method"`
-			Params  json.RawMessage `json:"params"`
-		}
+	sm.clientsMu.Lock()
+	delete(sm.clients, rpcConn)
+	sm.clientsMu.Unlock()
 
-		if err := json.Unmarshal([]byte(line), &req); err != nil {
-			log.Printf("Parse error for request: %v", err)
-			sm.sendAPIError(conn, 0, -32700, "Parse error")
-			continue
-		}
+	logr.Info("api", "client disconnected", Fields{"remote": conn.RemoteAddr().String()})
+}
 
-		log.Printf("Handling method: %s (id=%d)", req.Method, req.ID)
+// relayToClients forwards a server-initiated notification to every
+// connected API client.
+func (sm *SessionManager) relayToClients(method string, params *json.RawMessage) {
+	var p interface{}
+	if params != nil {
+		p = params
+	}
 
-		// Handle request
-		result, err := sm.handleAPIRequest(req.Method, req.Params)
-		if err != nil {
-			log.Printf("Error handling %s: %v", req.Method, err)
-			sm.sendAPIError(conn, req.ID, -32603, err.Error())
-			continue
+	sm.clientsMu.Lock()
+	defer sm.clientsMu.Unlock()
+	for client := range sm.clients {
+		if err := client.Notify(context.Background(), method, p); err != nil {
+			logr.Warn("api", "failed to relay notification to API client", Fields{"method": method, "error": err.Error()})
 		}
+	}
+}
 
-		log.Printf("Method %s completed successfully", req.Method)
+// handleAPIRequest handles an API request from mcp-lsp-bridge. It emits one
+// structured log event per request with method, id, latency and error,
+// so client and server logs can be correlated by request id.
+func (sm *SessionManager) handleAPIRequest(ctx context.Context, conn *jsonrpc2.Conn, req *jsonrpc2.Request) (result interface{}, err error) {
+	method := req.Method
+	params := json.RawMessage("null")
+	if req.Params != nil {
+		params = *req.Params
+	}
 
-		// Send response
-		resp := map[string]interface{}{
-			"jsonrpc": "2.0",
-			"id":      req.ID,
-			"result":  result,
-		}
-		respJSON, err := json.Marshal(resp)
-		if err != nil {
-			log.Printf("Error marshaling response: %v", err)
-			continue
-		}
-		n, err := conn.Write(append(respJSON, '\n'))
+	if method == "$/cancelRequest" {
+		sm.cancelAPIRequest(params)
+		return nil, nil
+	}
+
+	start := time.Now()
+	defer func() {
+		fields := Fields{"method": method, "id": req.ID.String(), "duration_ms": time.Since(start).Milliseconds()}
 		if err != nil {
-			log.Printf("Error writing response: %v", err)
+			fields["error"] = err.Error()
+			logr.Error("api", "request failed", fields)
 		} else {
-			log.Printf("Sent response to %s: %d bytes (id=%d)", conn.RemoteAddr(), n, req.ID)
+			logr.Info("api", "request completed", fields)
 		}
-	}
-
-	log.Printf("API client disconnected: %s", conn.RemoteAddr())
-}
-
-// sendAPIError sends an error response to API client
-func (sm *SessionManager) sendAPIError(conn net.Conn, id int64, code int, message string) {
-	resp := map[string]interface{}{
-		"jsonrpc": "2.0",
-		"id":      id,
-		"error": map[string]interface{}{
-			"code":    code,
-			"message": message,
-		},
-	}
-	respJSON, _ := json.Marshal(resp)
-	conn.Write(append(respJSON, '\n'))
-}
+	}()
 
-// handleAPIRequest handles an API request from mcp-lsp-bridge
-func (sm *SessionManager) handleAPIRequest(method string, params json.RawMessage) (interface{}, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 90*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, 90*time.Second)
 	defer cancel()
 
+	if !req.Notif {
+		key := req.ID.String()
+		sm.cancelMu.Lock()
+		sm.cancelFuncs[key] = cancel
+		sm.cancelMu.Unlock()
+		defer func() {
+			sm.cancelMu.Lock()
+			delete(sm.cancelFuncs, key)
+			sm.cancelMu.Unlock()
+		}()
+	}
+
 	switch method {
 	case "session/status":
 		return sm.getStatus(), nil
 
+	case "session/ping":
+		return "pong", nil
+
+	case "session/languages":
+		return sm.getLanguages(), nil
+
 	case "session/capabilities":
-		sm.mu.RLock()
-		caps := sm.capabilities
-		sm.mu.RUnlock()
-		return caps, nil
+		proc, err := sm.getOrStartProcess("")
+		if err != nil {
+			return nil, err
+		}
+		return proc.capabilities, nil
 
 	case "textDocument/didOpen":
 		return sm.handleDidOpen(params)
@@ -496,6 +445,9 @@ func (sm *SessionManager) handleAPIRequest(method string, params json.RawMessage
 	case "textDocument/didClose":
 		return sm.handleDidClose(params)
 
+	case "workspace/symbol":
+		return sm.handleWorkspaceSymbol(ctx, params)
+
 	case "textDocument/hover",
 		"textDocument/definition",
 		"textDocument/references",
@@ -506,32 +458,96 @@ func (sm *SessionManager) handleAPIRequest(method string, params json.RawMessage
 		"textDocument/formatting",
 		"textDocument/rename",
 		"textDocument/prepareRename",
-		"textDocument/prepareCallHierarchy":
-		// Forward directly to LSP server
-		var p interface{}
-		json.Unmarshal(params, &p)
-		return sm.sendRequest(ctx, method, p)
-
-	case "callHierarchy/incomingCalls",
+		"textDocument/prepareCallHierarchy",
+		"callHierarchy/incomingCalls",
 		"callHierarchy/outgoingCalls":
+		proc, err := sm.resolveProcess(extractURI(params), "")
+		if err != nil {
+			return nil, err
+		}
 		var p interface{}
 		json.Unmarshal(params, &p)
-		return sm.sendRequest(ctx, method, p)
-
-	case "workspace/symbol":
-		var p interface{}
-		json.Unmarshal(params, &p)
-		return sm.sendRequest(ctx, method, p)
+		return sm.callProcess(ctx, proc, method, p)
 
 	default:
 		return nil, fmt.Errorf("unknown method: %s", method)
 	}
 }
 
+// callProcess forwards method/params to proc, tagging the request with an
+// id we control so a client-side $/cancelRequest can be propagated to the
+// underlying LSP server.
+func (sm *SessionManager) callProcess(ctx context.Context, proc *LSPProcess, method string, p interface{}) (json.RawMessage, error) {
+	lspID := jsonrpc2.ID{Num: uint64(atomic.AddInt64(&sm.lspReqSeq, 1))}
+	var result json.RawMessage
+	err := proc.conn.Call(ctx, method, p, &result, jsonrpc2.PickID(lspID))
+	if ctx.Err() != nil {
+		proc.conn.Notify(context.Background(), "$/cancelRequest", map[string]interface{}{"id": lspID})
+	}
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// handleWorkspaceSymbol fans workspace/symbol out to every initialized
+// server and merges the results, deduplicating by (uri, range, name).
+func (sm *SessionManager) handleWorkspaceSymbol(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	var p interface{}
+	json.Unmarshal(params, &p)
+
+	sm.mu.RLock()
+	procs := make([]*LSPProcess, 0, len(sm.processes))
+	for _, proc := range sm.processes {
+		if proc.initialized {
+			procs = append(procs, proc)
+		}
+	}
+	sm.mu.RUnlock()
+
+	results := make([]json.RawMessage, 0, len(procs))
+	for _, proc := range procs {
+		result, err := sm.callProcess(ctx, proc, "workspace/symbol", p)
+		if err != nil {
+			logr.Warn("sessionmanager", "workspace/symbol failed for server", Fields{"language": proc.languageID, "error": err.Error()})
+			continue
+		}
+		results = append(results, result)
+	}
+
+	return mergeWorkspaceSymbols(results), nil
+}
+
+// cancelAPIRequest handles a "$/cancelRequest" notification from an API
+// client by cancelling the matching in-flight request's context, which in
+// turn propagates a $/cancelRequest to the LSP server for any forwarded call.
+func (sm *SessionManager) cancelAPIRequest(params json.RawMessage) {
+	var p struct {
+		ID json.RawMessage `json:"id"`
+	}
+	if err := json.Unmarshal(params, &p); err != nil {
+		return
+	}
+
+	var id jsonrpc2.ID
+	if err := json.Unmarshal(p.ID, &id); err != nil {
+		return
+	}
+
+	key := id.String()
+	sm.cancelMu.Lock()
+	cancel, ok := sm.cancelFuncs[key]
+	sm.cancelMu.Unlock()
+	if ok {
+		cancel()
+	}
+}
+
 // getStatus returns current session status
 func (sm *SessionManager) getStatus() map[string]interface{} {
 	sm.mu.RLock()
-	initialized := sm.initialized
+	initialized := len(sm.processes) > 0
+	serverCount := len(sm.processes)
 	sm.mu.RUnlock()
 
 	sm.openDocsMu.Lock()
@@ -541,8 +557,30 @@ func (sm *SessionManager) getStatus() map[string]interface{} {
 	return map[string]interface{}{
 		"initialized":   initialized,
 		"openDocuments": openDocsCount,
-		"pid":           sm.cmd.Process.Pid,
+		"servers":       serverCount,
+	}
+}
+
+// getLanguages returns the configured/initialized servers and their
+// capabilities, keyed by languageId.
+func (sm *SessionManager) getLanguages() map[string]interface{} {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+
+	out := make(map[string]interface{}, len(sm.langConfig.Servers))
+	for languageID, spec := range sm.langConfig.Servers {
+		entry := map[string]interface{}{
+			"command":     spec.Command,
+			"initialized": false,
+		}
+		if proc, ok := sm.processes[languageID]; ok {
+			entry["initialized"] = proc.initialized
+			entry["pid"] = proc.cmd.Process.Pid
+			entry["capabilities"] = proc.capabilities
+		}
+		out[languageID] = entry
 	}
+	return out
 }
 
 // handleDidOpen handles textDocument/didOpen
@@ -560,9 +598,20 @@ func (sm *SessionManager) handleDidOpen(params json.RawMessage) (interface{}, er
 		return nil, err
 	}
 
+	languageID := sm.langConfig.languageIDForURI(p.TextDocument.URI, p.TextDocument.LanguageID)
+	proc, err := sm.getOrStartProcess(languageID)
+	if err != nil {
+		return nil, err
+	}
+
+	sm.docLanguageMu.Lock()
+	sm.docLanguage[p.TextDocument.URI] = languageID
+	sm.docLanguageMu.Unlock()
+
+	docKey := languageID + "\x00" + p.TextDocument.URI
 	sm.openDocsMu.Lock()
-	alreadyOpen := sm.openDocs[p.TextDocument.URI]
-	sm.openDocs[p.TextDocument.URI] = true
+	alreadyOpen := sm.openDocs[docKey]
+	sm.openDocs[docKey] = true
 	sm.openDocsMu.Unlock()
 
 	if alreadyOpen {
@@ -571,7 +620,7 @@ func (sm *SessionManager) handleDidOpen(params json.RawMessage) (interface{}, er
 	}
 
 	// Send to LSP server
-	return nil, sm.sendNotification("textDocument/didOpen", p)
+	return nil, proc.conn.Notify(context.Background(), "textDocument/didOpen", p)
 }
 
 // handleDidClose handles textDocument/didClose
@@ -586,46 +635,43 @@ func (sm *SessionManager) handleDidClose(params json.RawMessage) (interface{}, e
 		return nil, err
 	}
 
+	sm.docLanguageMu.Lock()
+	languageID := sm.docLanguage[p.TextDocument.URI]
+	delete(sm.docLanguage, p.TextDocument.URI)
+	sm.docLanguageMu.Unlock()
+
+	proc, err := sm.getOrStartProcess(sm.langConfig.languageIDForURI(p.TextDocument.URI, languageID))
+	if err != nil {
+		return nil, err
+	}
+
 	sm.openDocsMu.Lock()
-	delete(sm.openDocs, p.TextDocument.URI)
+	delete(sm.openDocs, languageID+"\x00"+p.TextDocument.URI)
 	sm.openDocsMu.Unlock()
 
-	return nil, sm.sendNotification("textDocument/didClose", p)
+	return nil, proc.conn.Notify(context.Background(), "textDocument/didClose", p)
 }
 
-// readLSPMessage reads a complete LSP message
-func readLSPMessage(reader *bufio.Reader) ([]byte, error) {
-	var contentLength int
+// lineObjectCodec is a jsonrpc2.ObjectCodec that frames messages as
+// newline-delimited JSON. HandleClient does not use it: API clients are
+// served with the same Content-Length framing (jsonrpc2.VSCodeObjectCodec)
+// as the LSP-server-facing connection, which is what SessionClient writes.
+type lineObjectCodec struct{}
 
-	for {
-		line, err := reader.ReadString('\n')
-		if err != nil {
-			return nil, err
-		}
-
-		line = strings.TrimSpace(line)
-		if line == "" {
-			break
-		}
-
-		if strings.HasPrefix(line, "Content-Length:") {
-			lengthStr := strings.TrimSpace(strings.TrimPrefix(line, "Content-Length:"))
-			contentLength, err = strconv.Atoi(lengthStr)
-			if err != nil {
-				return nil, fmt.Errorf("invalid Content-Length: %v", err)
-			}
-		}
-	}
-
-	if contentLength == 0 {
-		return nil, fmt.Errorf("missing Content-Length header")
+func (lineObjectCodec) WriteObject(stream io.Writer, obj interface{}) error {
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return err
 	}
+	data = append(data, '\n')
+	_, err = stream.Write(data)
+	return err
+}
 
-	body := make([]byte, contentLength)
-	_, err := io.ReadFull(reader, body)
+func (lineObjectCodec) ReadObject(stream *bufio.Reader, v interface{}) error {
+	line, err := stream.ReadString('\n')
 	if err != nil {
-		return nil, err
+		return err
 	}
-
-	return body, nil
+	return json.Unmarshal([]byte(line), v)
 }
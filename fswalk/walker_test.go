@@ -0,0 +1,140 @@
+package fswalk
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"testing"
+)
+
+// buildTree creates a small fixture: a handful of nested directories with a
+// mix of .bsl, .os and other files, plus a .git directory that DefaultSkipDir
+// should prune.
+func buildTree(t *testing.T) string {
+	t.Helper()
+	root := t.TempDir()
+
+	dirs := []string{
+		"Catalogs/Товары",
+		"Catalogs/Контрагенты",
+		"CommonModules/ОбщегоНазначения",
+		".git/objects",
+	}
+	for _, d := range dirs {
+		if err := os.MkdirAll(filepath.Join(root, d), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	files := map[string]string{
+		"Catalogs/Товары/Module.bsl":                 "x",
+		"Catalogs/Контрагенты/Module.os":              "x",
+		"CommonModules/ОбщегоНазначения/Module.bsl":   "x",
+		"CommonModules/ОбщегоНазначения/Manifest.xml": "x",
+		".git/objects/deadbeef":                       "x",
+	}
+	for path, content := range files {
+		if err := os.WriteFile(filepath.Join(root, path), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return root
+}
+
+func TestWalkFindsAllFilteredFiles(t *testing.T) {
+	root := buildTree(t)
+
+	var mu sync.Mutex
+	var found []string
+
+	err := Walk(context.Background(), root, Options{
+		Workers: 4,
+		Filter:  ExtFilter(".bsl", ".os"),
+		SkipDir: DefaultSkipDir,
+	}, func(path string, d os.DirEntry) error {
+		mu.Lock()
+		found = append(found, path)
+		mu.Unlock()
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Walk returned error: %v", err)
+	}
+
+	sort.Strings(found)
+	if len(found) != 3 {
+		t.Fatalf("expected 3 filtered files, got %d: %v", len(found), found)
+	}
+	for _, path := range found {
+		if filepath.Ext(path) != ".bsl" && filepath.Ext(path) != ".os" {
+			t.Errorf("unexpected file reported: %s", path)
+		}
+	}
+}
+
+func TestWalkSkipsGitDir(t *testing.T) {
+	root := buildTree(t)
+
+	err := Walk(context.Background(), root, Options{SkipDir: DefaultSkipDir}, func(path string, d os.DirEntry) error {
+		if filepath.Base(filepath.Dir(path)) == "objects" {
+			t.Errorf("walked into .git despite DefaultSkipDir: %s", path)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Walk returned error: %v", err)
+	}
+}
+
+func TestWalkPropagatesCallbackError(t *testing.T) {
+	root := buildTree(t)
+	wantErr := errors.New("boom")
+
+	err := Walk(context.Background(), root, Options{Filter: ExtFilter(".bsl")}, func(path string, d os.DirEntry) error {
+		return wantErr
+	})
+	if !errors.Is(err, wantErr) {
+		t.Fatalf("expected %v, got %v", wantErr, err)
+	}
+}
+
+func TestWalkRespectsCancellation(t *testing.T) {
+	root := buildTree(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// A pre-cancelled context should stop the walk almost immediately,
+	// without hanging or panicking.
+	_ = Walk(ctx, root, Options{}, func(path string, d os.DirEntry) error {
+		return nil
+	})
+}
+
+// BenchmarkWalk complements the cmd/fs-bench CLI: it exercises the same
+// concurrent-scan workload as a regular benchmark, so a regression
+// shows up in `go test -bench` instead of only in a one-off CLI run.
+func BenchmarkWalk(b *testing.B) {
+	root := b.TempDir()
+	for i := 0; i < 200; i++ {
+		dir := filepath.Join(root, "Dir"+string(rune('A'+i%26)), "Sub")
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			b.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "Module.bsl"), []byte("x"), 0o644); err != nil {
+			b.Fatal(err)
+		}
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		count := 0
+		_ = Walk(context.Background(), root, Options{Workers: 8, Filter: ExtFilter(".bsl")}, func(path string, d os.DirEntry) error {
+			count++
+			return nil
+		})
+	}
+}
@@ -0,0 +1,146 @@
+package lsp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// ErrUnsupported is wrapped with the missing capability's wire method name
+// and returned by LanguageClient methods instead of sending a request the
+// server's negotiated ServerCapabilities say it will reject with
+// MethodNotFound. Check for it with errors.Is(err, lsp.ErrUnsupported).
+var ErrUnsupported = errors.New("lsp: capability not supported by server")
+
+func errUnsupported(capability string) error {
+	return fmt.Errorf("%s: %w", capability, ErrUnsupported)
+}
+
+// capabilityField probes the raw ServerCapabilities JSON captured at
+// Initialize for a top-level field, the same boolean-or-options union
+// shape saveIncludesText probes elsewhere in this package: the field may
+// be absent, false, null, true, or an options object.
+func (lc *LanguageClient) capabilityField(jsonKey string) (json.RawMessage, bool) {
+	lc.mu.RLock()
+	raw := lc.rawServerCapabilities
+	lc.mu.RUnlock()
+
+	if len(raw) == 0 {
+		return nil, false
+	}
+
+	var probe map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &probe); err != nil {
+		return nil, false
+	}
+
+	value, ok := probe[jsonKey]
+	if !ok || string(value) == "null" {
+		return nil, false
+	}
+
+	return value, true
+}
+
+// capabilityEnabled reports whether jsonKey is present and not explicitly
+// false/null - true for both `"x": true` and an options object `"x": {...}`.
+func (lc *LanguageClient) capabilityEnabled(jsonKey string) bool {
+	value, ok := lc.capabilityField(jsonKey)
+	if !ok {
+		return false
+	}
+
+	var asBool bool
+	if err := json.Unmarshal(value, &asBool); err == nil {
+		return asBool
+	}
+
+	return true
+}
+
+// capabilityOptionBool reports whether jsonKey is an options object with a
+// truthy optionKey field (e.g. renameProvider.prepareProvider).
+func (lc *LanguageClient) capabilityOptionBool(jsonKey, optionKey string) bool {
+	value, ok := lc.capabilityField(jsonKey)
+	if !ok {
+		return false
+	}
+
+	var opts map[string]json.RawMessage
+	if err := json.Unmarshal(value, &opts); err != nil {
+		return false
+	}
+
+	optValue, ok := opts[optionKey]
+	if !ok {
+		return false
+	}
+
+	var asBool bool
+	_ = json.Unmarshal(optValue, &asBool)
+	return asBool
+}
+
+// SupportsRename reports whether the server advertised renameProvider, or
+// dynamically registered textDocument/rename via registerCapability.
+func (lc *LanguageClient) SupportsRename() bool {
+	return lc.capabilityEnabled("renameProvider") || lc.Registered("textDocument/rename")
+}
+
+// SupportsPrepareRename reports whether renameProvider.prepareProvider is
+// set, or textDocument/prepareRename was dynamically registered.
+func (lc *LanguageClient) SupportsPrepareRename() bool {
+	return lc.capabilityOptionBool("renameProvider", "prepareProvider") || lc.Registered("textDocument/prepareRename")
+}
+
+// SupportsSemanticTokensRange reports whether semanticTokensProvider.range
+// is set, or textDocument/semanticTokens was dynamically registered.
+func (lc *LanguageClient) SupportsSemanticTokensRange() bool {
+	return lc.capabilityOptionBool("semanticTokensProvider", "range") || lc.Registered("textDocument/semanticTokens")
+}
+
+// SupportsDocumentDiagnostic reports whether the server advertised
+// diagnosticProvider, or dynamically registered textDocument/diagnostic.
+func (lc *LanguageClient) SupportsDocumentDiagnostic() bool {
+	return lc.capabilityEnabled("diagnosticProvider") || lc.Registered("textDocument/diagnostic")
+}
+
+// SupportsWorkspaceDiagnostic reports whether
+// diagnosticProvider.workspaceDiagnostics is set, or textDocument/diagnostic
+// was dynamically registered. Older BSL LS builds advertise diagnosticProvider
+// without workspace support, which is exactly the case this guards against.
+func (lc *LanguageClient) SupportsWorkspaceDiagnostic() bool {
+	return lc.capabilityOptionBool("diagnosticProvider", "workspaceDiagnostics") || lc.Registered("textDocument/diagnostic")
+}
+
+// SupportsCallHierarchy reports whether the server advertised
+// callHierarchyProvider, or dynamically registered textDocument/prepareCallHierarchy.
+func (lc *LanguageClient) SupportsCallHierarchy() bool {
+	return lc.capabilityEnabled("callHierarchyProvider") || lc.Registered("textDocument/prepareCallHierarchy")
+}
+
+// SupportsFoldingRange reports whether the server advertised
+// foldingRangeProvider, or dynamically registered textDocument/foldingRange.
+func (lc *LanguageClient) SupportsFoldingRange() bool {
+	return lc.capabilityEnabled("foldingRangeProvider") || lc.Registered("textDocument/foldingRange")
+}
+
+// SupportsColor reports whether the server advertised colorProvider, or
+// dynamically registered textDocument/documentColor.
+func (lc *LanguageClient) SupportsColor() bool {
+	return lc.capabilityEnabled("colorProvider") || lc.Registered("textDocument/documentColor")
+}
+
+// SupportsCodeLens reports whether the server advertised codeLensProvider,
+// or dynamically registered textDocument/codeLens.
+func (lc *LanguageClient) SupportsCodeLens() bool {
+	return lc.capabilityEnabled("codeLensProvider") || lc.Registered("textDocument/codeLens")
+}
+
+// SupportsDefinition reports whether the server advertised
+// definitionProvider, or dynamically registered textDocument/definition.
+// Almost every server sets this statically; the dynamic check mainly
+// covers one that only registers it after workspace/didChangeConfiguration.
+func (lc *LanguageClient) SupportsDefinition() bool {
+	return lc.capabilityEnabled("definitionProvider") || lc.Registered("textDocument/definition")
+}
@@ -1,6 +1,7 @@
 package lsp
 
 import (
+	"fmt"
 	"os"
 	"strings"
 
@@ -13,85 +14,374 @@ import (
 // via Cursor MCP env, depending on project size.
 //
 // Supported env vars:
-// - MCP_LSP_BSL_JAVA_XMX: overrides -Xmx for the BSL language server (e.g. "6g", "6144m")
-// - MCP_LSP_JAVA_XMX:     fallback override for any Java-based language server
-// - WORKSPACE_ROOT:       substitutes ${WORKSPACE_ROOT} in args (e.g. --workspace=${WORKSPACE_ROOT})
-// - PROJECTS_ROOT:        substitutes ${PROJECTS_ROOT} in args
-// - Any env var:          ${VAR_NAME} syntax is expanded in all args
-func ApplyEnvOverrides(cfg *LSPServerConfig) {
+//   - MCP_LSP_BSL_JAVA_XMX:        overrides -Xmx for the BSL language server (e.g. "6g", "6144m")
+//   - MCP_LSP_JAVA_XMX:            fallback override for any Java-based language server
+//   - MCP_LSP_BSL_JAVA_XMS:        overrides -Xms for the BSL language server
+//   - MCP_LSP_JAVA_XMS:            fallback override for any Java-based language server
+//   - MCP_LSP_BSL_JAVA_METASPACE:  overrides -XX:MaxMetaspaceSize= for the BSL language server
+//   - MCP_LSP_JAVA_METASPACE:      fallback override for any Java-based language server
+//   - MCP_LSP_BSL_JAVA_GC:         selects a GC for the BSL language server: "g1", "zgc" or "parallel"
+//   - MCP_LSP_JAVA_GC:             fallback override for any Java-based language server
+//   - MCP_LSP_BSL_JAVA_ADD_OPENS:  colon-separated module/package=target list, each turned into a --add-opens
+//   - MCP_LSP_JAVA_ADD_OPENS:      fallback override for any Java-based language server
+//   - MCP_LSP_BSL_JAVA_ADD_EXPORTS: colon-separated module/package=target list, each turned into a --add-exports
+//   - MCP_LSP_JAVA_ADD_EXPORTS:    fallback override for any Java-based language server
+//   - WORKSPACE_ROOT:              substitutes ${WORKSPACE_ROOT} in args (e.g. --workspace=${WORKSPACE_ROOT})
+//   - PROJECTS_ROOT:               substitutes ${PROJECTS_ROOT} in args
+//   - Any env var:                 ${VAR_NAME} syntax is expanded in all args, with shell-style
+//     ${VAR:-default}, ${VAR-default}, ${VAR:?message} and ${VAR:+alt}
+//     forms also supported (see expandEnvVarsInArgs); "$$" escapes to
+//     a literal "$"
+//
+// For every Java tuning var, the per-language MCP_LSP_BSL_JAVA_* value takes
+// precedence over the MCP_LSP_JAVA_* global when the server is
+// "bsl-language-server"; otherwise only the global applies.
+//
+// ApplyEnvOverrides returns an error instead of launching a misconfigured LSP
+// server when a "${VAR:?message}" required variable is missing, so the
+// caller's config-load path can surface it at startup.
+func ApplyEnvOverrides(cfg *LSPServerConfig) error {
 	if cfg == nil || cfg.LanguageServers == nil {
-		return
+		return nil
 	}
 
-	// Prefer per-language override.
-	bslXmx := strings.TrimSpace(os.Getenv("MCP_LSP_BSL_JAVA_XMX"))
-	globalXmx := strings.TrimSpace(os.Getenv("MCP_LSP_JAVA_XMX"))
+	tuningEnv := readJavaTuningEnv()
 
 	for serverName, serverCfg := range cfg.LanguageServers {
 		// First, expand environment variables in args (e.g. ${WORKSPACE_ROOT})
-		serverCfg.Args = expandEnvVarsInArgs(serverCfg.Args)
+		expanded, err := expandEnvVarsInArgs(serverCfg.Args)
+		if err != nil {
+			return fmt.Errorf("language server %q: %w", serverName, err)
+		}
+		serverCfg.Args = expanded
 
 		// Then apply Java-specific overrides
 		if serverCfg.Command == "java" {
-			xmx := globalXmx
-			if serverName == types.LanguageServer("bsl-language-server") && bslXmx != "" {
-				xmx = bslXmx
-			}
-			if strings.TrimSpace(xmx) != "" {
-				serverCfg.Args = setJavaXmx(serverCfg.Args, xmx)
+			isBSL := serverName == types.LanguageServer("bsl-language-server")
+			tuning := tuningEnv.forServer(isBSL)
+			if tuning.hasAny() {
+				serverCfg.Args = applyJavaTuning(serverCfg.Args, tuning)
 			}
 		}
 
 		cfg.LanguageServers[serverName] = serverCfg
 	}
+
+	return nil
 }
 
-// expandEnvVarsInArgs replaces ${VAR_NAME} placeholders in args with environment variable values.
-// If a variable is not set, the placeholder is left unchanged.
-func expandEnvVarsInArgs(args []string) []string {
+// expandEnvVarsInArgs replaces ${VAR_NAME} placeholders in args with environment
+// variable values. Besides the bare form it understands the POSIX/docker-compose
+// defaulting forms:
+//
+//   - ${VAR:-default}  use default when VAR is unset or empty
+//   - ${VAR-default}   use default only when VAR is unset
+//   - ${VAR:?message}  return an error naming the arg and VAR when unset or empty
+//   - ${VAR:+alt}      substitute alt only when VAR is set and non-empty
+//
+// default/alt/message may themselves contain ${...} expansions. "$$" escapes to a
+// literal "$". A bare ${VAR} for an unset VAR is left unexpanded, matching the
+// previous behaviour.
+func expandEnvVarsInArgs(args []string) ([]string, error) {
 	result := make([]string, len(args))
 	for i, arg := range args {
-		result[i] = os.Expand(arg, func(key string) string {
-			if val, exists := os.LookupEnv(key); exists {
-				return val
+		expanded, err := expandShellStyle(arg, os.LookupEnv)
+		if err != nil {
+			return nil, fmt.Errorf("arg %d (%q): %w", i, arg, err)
+		}
+		result[i] = expanded
+	}
+	return result, nil
+}
+
+// expandShellStyle expands $$ and ${...} placeholders in s, recursing into
+// default/alt/message text so nested expansions like ${A:-${B:-fallback}} work.
+func expandShellStyle(s string, lookup func(string) (string, bool)) (string, error) {
+	var out strings.Builder
+	i := 0
+	for i < len(s) {
+		if s[i] == '$' && i+1 < len(s) {
+			switch s[i+1] {
+			case '$':
+				out.WriteByte('$')
+				i += 2
+				continue
+			case '{':
+				end, err := matchingBrace(s, i+1)
+				if err != nil {
+					return "", err
+				}
+				name, op, rest := splitPlaceholder(s[i+2 : end])
+				val, err := resolvePlaceholder(name, op, rest, lookup)
+				if err != nil {
+					return "", err
+				}
+				out.WriteString(val)
+				i = end + 1
+				continue
 			}
-			// Return original placeholder if env var not set
-			return "${" + key + "}"
-		})
+		}
+		out.WriteByte(s[i])
+		i++
+	}
+	return out.String(), nil
+}
+
+// matchingBrace returns the index of the "}" that closes the "${" whose "{" is
+// at s[open], accounting for nested "${...}" inside the placeholder body.
+func matchingBrace(s string, open int) (int, error) {
+	depth := 1
+	i := open + 1
+	for i < len(s) {
+		switch {
+		case i+1 < len(s) && s[i] == '$' && s[i+1] == '{':
+			depth++
+			i += 2
+		case s[i] == '}':
+			depth--
+			if depth == 0 {
+				return i, nil
+			}
+			i++
+		default:
+			i++
+		}
+	}
+	return 0, fmt.Errorf("unterminated ${ placeholder")
+}
+
+// splitPlaceholder splits a "${...}" body (without the braces) into the
+// variable name and an optional operator (":-", "-", ":?" or ":+") with its
+// trailing text, without being confused by operators inside a nested ${...}.
+func splitPlaceholder(body string) (name, op, rest string) {
+	depth := 0
+	for i := 0; i < len(body); i++ {
+		switch {
+		case i+1 < len(body) && body[i] == '$' && body[i+1] == '{':
+			depth++
+			i++
+		case body[i] == '}' && depth > 0:
+			depth--
+		case depth == 0 && body[i] == ':' && i+1 < len(body) && strings.ContainsRune("-?+", rune(body[i+1])):
+			return body[:i], body[i : i+2], body[i+2:]
+		case depth == 0 && body[i] == '-':
+			return body[:i], "-", body[i+1:]
+		}
+	}
+	return body, "", ""
+}
+
+// resolvePlaceholder resolves one parsed placeholder (see splitPlaceholder)
+// against lookup, expanding rest (the default/alt/message text) recursively.
+func resolvePlaceholder(name, op, rest string, lookup func(string) (string, bool)) (string, error) {
+	val, exists := lookup(name)
+
+	switch op {
+	case "":
+		if exists {
+			return val, nil
+		}
+		return "${" + name + "}", nil
+	case "-":
+		if exists {
+			return val, nil
+		}
+		return expandShellStyle(rest, lookup)
+	case ":-":
+		if exists && val != "" {
+			return val, nil
+		}
+		return expandShellStyle(rest, lookup)
+	case ":+":
+		if exists && val != "" {
+			return expandShellStyle(rest, lookup)
+		}
+		return "", nil
+	case ":?":
+		if exists && val != "" {
+			return val, nil
+		}
+		msg, err := expandShellStyle(rest, lookup)
+		if err != nil {
+			return "", err
+		}
+		if msg == "" {
+			msg = "is required but not set"
+		}
+		return "", fmt.Errorf("%s %s", name, msg)
+	default:
+		return "", fmt.Errorf("unsupported placeholder operator %q for %s", op, name)
+	}
+}
+
+// javaTuningEnv holds both the per-language (bsl*) and global (global*) raw
+// env var values for every Java tuning knob, before the per-server
+// precedence in forServer is applied.
+type javaTuningEnv struct {
+	bslXmx, globalXmx               string
+	bslXms, globalXms               string
+	bslMetaspace, globalMetaspace   string
+	bslGC, globalGC                 string
+	bslAddOpens, globalAddOpens     string
+	bslAddExports, globalAddExports string
+}
+
+func readJavaTuningEnv() javaTuningEnv {
+	env := func(name string) string { return strings.TrimSpace(os.Getenv(name)) }
+	return javaTuningEnv{
+		bslXmx:           env("MCP_LSP_BSL_JAVA_XMX"),
+		globalXmx:        env("MCP_LSP_JAVA_XMX"),
+		bslXms:           env("MCP_LSP_BSL_JAVA_XMS"),
+		globalXms:        env("MCP_LSP_JAVA_XMS"),
+		bslMetaspace:     env("MCP_LSP_BSL_JAVA_METASPACE"),
+		globalMetaspace:  env("MCP_LSP_JAVA_METASPACE"),
+		bslGC:            env("MCP_LSP_BSL_JAVA_GC"),
+		globalGC:         env("MCP_LSP_JAVA_GC"),
+		bslAddOpens:      env("MCP_LSP_BSL_JAVA_ADD_OPENS"),
+		globalAddOpens:   env("MCP_LSP_JAVA_ADD_OPENS"),
+		bslAddExports:    env("MCP_LSP_BSL_JAVA_ADD_EXPORTS"),
+		globalAddExports: env("MCP_LSP_JAVA_ADD_EXPORTS"),
+	}
+}
+
+// javaTuning is the resolved set of JVM tuning flags for one language
+// server, after per-language/global precedence has already been applied.
+type javaTuning struct {
+	xmx        string
+	xms        string
+	metaspace  string
+	gc         string
+	addOpens   []string
+	addExports []string
+}
+
+func (t javaTuning) hasAny() bool {
+	return t.xmx != "" || t.xms != "" || t.metaspace != "" || t.gc != "" || len(t.addOpens) > 0 || len(t.addExports) > 0
+}
+
+// forServer resolves env's raw values into a javaTuning for one server,
+// preferring the bsl* value over the global* one when isBSL is true, just
+// like the original -Xmx-only override did.
+func (env javaTuningEnv) forServer(isBSL bool) javaTuning {
+	pick := func(bslVal, globalVal string) string {
+		if isBSL && bslVal != "" {
+			return bslVal
+		}
+		return globalVal
+	}
+
+	return javaTuning{
+		xmx:        pick(env.bslXmx, env.globalXmx),
+		xms:        pick(env.bslXms, env.globalXms),
+		metaspace:  pick(env.bslMetaspace, env.globalMetaspace),
+		gc:         pick(env.bslGC, env.globalGC),
+		addOpens:   splitColonList(pick(env.bslAddOpens, env.globalAddOpens)),
+		addExports: splitColonList(pick(env.bslAddExports, env.globalAddExports)),
 	}
-	return result
 }
 
-func setJavaXmx(args []string, xmx string) []string {
-	xmx = strings.TrimSpace(xmx)
-	if xmx == "" {
-		return args
+// splitColonList splits a colon-separated env var value into its non-empty,
+// trimmed entries, e.g. "java.base/java.lang=ALL-UNNAMED:java.base/java.util=ALL-UNNAMED".
+func splitColonList(v string) []string {
+	if v == "" {
+		return nil
 	}
-	if !strings.HasPrefix(xmx, "-Xmx") {
-		xmx = "-Xmx" + xmx
+	var out []string
+	for _, part := range strings.Split(v, ":") {
+		part = strings.TrimSpace(part)
+		if part != "" {
+			out = append(out, part)
+		}
 	}
+	return out
+}
 
-	// Remove existing -Xmx... entries.
-	clean := make([]string, 0, len(args)+1)
+// javaGCFlags maps the short names MCP_LSP_*_JAVA_GC accepts to the JVM
+// flag that selects that collector.
+var javaGCFlags = map[string]string{
+	"g1":       "-XX:+UseG1GC",
+	"zgc":      "-XX:+UseZGC",
+	"parallel": "-XX:+UseParallelGC",
+}
+
+// applyJavaTuning strips every arg of the kinds it manages, whether from a
+// previous run or from the configured args (see stripManagedJavaFlags), and
+// replaces them with fresh flags built from tuning, inserted as a block before -jar (JVM options must precede -jar;
+// if there's no -jar, the block is prepended instead).
+func applyJavaTuning(args []string, tuning javaTuning) []string {
+	clean := stripManagedJavaFlags(args)
+
+	var opts []string
+	if tuning.xmx != "" {
+		opts = append(opts, javaSizeFlag("-Xmx", tuning.xmx))
+	}
+	if tuning.xms != "" {
+		opts = append(opts, javaSizeFlag("-Xms", tuning.xms))
+	}
+	if tuning.metaspace != "" {
+		opts = append(opts, javaSizeFlag("-XX:MaxMetaspaceSize=", tuning.metaspace))
+	}
+	if gcFlag, ok := javaGCFlags[strings.ToLower(tuning.gc)]; ok {
+		opts = append(opts, gcFlag)
+	}
+	for _, module := range tuning.addOpens {
+		opts = append(opts, "--add-opens="+module)
+	}
+	for _, module := range tuning.addExports {
+		opts = append(opts, "--add-exports="+module)
+	}
+
+	if len(opts) == 0 {
+		return clean
+	}
+	return insertBeforeJar(clean, opts)
+}
+
+// javaSizeFlag prefixes value with prefix unless it's already there, so
+// callers can pass either a bare size ("6g") or a fully-formed flag.
+func javaSizeFlag(prefix, value string) string {
+	if strings.HasPrefix(value, prefix) {
+		return value
+	}
+	return prefix + value
+}
+
+// stripManagedJavaFlags removes every arg applyJavaTuning is responsible
+// for, so re-running it (e.g. on a config reload) replaces rather than
+// accumulates entries.
+func stripManagedJavaFlags(args []string) []string {
+	isManagedGCFlag := make(map[string]bool, len(javaGCFlags))
+	for _, flag := range javaGCFlags {
+		isManagedGCFlag[flag] = true
+	}
+
+	clean := make([]string, 0, len(args))
 	for _, a := range args {
-		if strings.HasPrefix(a, "-Xmx") {
+		switch {
+		case strings.HasPrefix(a, "-Xmx"),
+			strings.HasPrefix(a, "-Xms"),
+			strings.HasPrefix(a, "-XX:MaxMetaspaceSize="),
+			strings.HasPrefix(a, "--add-opens="),
+			strings.HasPrefix(a, "--add-exports="),
+			isManagedGCFlag[a]:
 			continue
 		}
 		clean = append(clean, a)
 	}
+	return clean
+}
 
-	// Insert before -jar if present (JVM options must come before -jar).
-	for i, a := range clean {
+// insertBeforeJar inserts opts right before the first "-jar" arg in args
+// (JVM options must come before -jar), or prepends them if there isn't one.
+func insertBeforeJar(args []string, opts []string) []string {
+	for i, a := range args {
 		if a == "-jar" {
-			out := make([]string, 0, len(clean)+1)
-			out = append(out, clean[:i]...)
-			out = append(out, xmx)
-			out = append(out, clean[i:]...)
+			out := make([]string, 0, len(args)+len(opts))
+			out = append(out, args[:i]...)
+			out = append(out, opts...)
+			out = append(out, args[i:]...)
 			return out
 		}
 	}
-
-	// Otherwise prepend.
-	return append([]string{xmx}, clean...)
+	return append(append([]string(nil), opts...), args...)
 }
-
@@ -0,0 +1,152 @@
+package lsp
+
+import (
+	"strings"
+	"testing"
+)
+
+func fakeLookup(env map[string]string) func(string) (string, bool) {
+	return func(key string) (string, bool) {
+		val, ok := env[key]
+		return val, ok
+	}
+}
+
+func TestExpandShellStyle(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		env     map[string]string
+		want    string
+		wantErr string
+	}{
+		{
+			name:  "bare var set",
+			input: "--workspace=${WORKSPACE_ROOT}",
+			env:   map[string]string{"WORKSPACE_ROOT": "/proj"},
+			want:  "--workspace=/proj",
+		},
+		{
+			name:  "bare var unset left unexpanded",
+			input: "--workspace=${WORKSPACE_ROOT}",
+			env:   map[string]string{},
+			want:  "--workspace=${WORKSPACE_ROOT}",
+		},
+		{
+			name:  ":- default when unset",
+			input: "${MCP_LSP_JAVA_XMX:-4g}",
+			env:   map[string]string{},
+			want:  "4g",
+		},
+		{
+			name:  ":- default when empty",
+			input: "${MCP_LSP_JAVA_XMX:-4g}",
+			env:   map[string]string{"MCP_LSP_JAVA_XMX": ""},
+			want:  "4g",
+		},
+		{
+			name:  ":- var used when non-empty",
+			input: "${MCP_LSP_JAVA_XMX:-4g}",
+			env:   map[string]string{"MCP_LSP_JAVA_XMX": "8g"},
+			want:  "8g",
+		},
+		{
+			name:  "- default only when unset, not when empty",
+			input: "${FOO-bar}",
+			env:   map[string]string{"FOO": ""},
+			want:  "",
+		},
+		{
+			name:  "- default when unset",
+			input: "${FOO-bar}",
+			env:   map[string]string{},
+			want:  "bar",
+		},
+		{
+			name:  ":+ substitutes alt when set",
+			input: "${FOO:+has-foo}",
+			env:   map[string]string{"FOO": "x"},
+			want:  "has-foo",
+		},
+		{
+			name:  ":+ empty when unset",
+			input: "${FOO:+has-foo}",
+			env:   map[string]string{},
+			want:  "",
+		},
+		{
+			name:    ":? errors with message when unset",
+			input:   "${REQUIRED_VAR:?must be set for large configs}",
+			env:     map[string]string{},
+			wantErr: "REQUIRED_VAR must be set for large configs",
+		},
+		{
+			name:  ":? passes through when set",
+			input: "${REQUIRED_VAR:?must be set}",
+			env:   map[string]string{"REQUIRED_VAR": "ok"},
+			want:  "ok",
+		},
+		{
+			name:  "nested default",
+			input: "${A:-${B:-fallback}}",
+			env:   map[string]string{},
+			want:  "fallback",
+		},
+		{
+			name:  "nested default resolves inner var",
+			input: "${A:-${B:-fallback}}",
+			env:   map[string]string{"B": "from-b"},
+			want:  "from-b",
+		},
+		{
+			name:  "nested default short-circuits on outer var",
+			input: "${A:-${B:-fallback}}",
+			env:   map[string]string{"A": "from-a", "B": "from-b"},
+			want:  "from-a",
+		},
+		{
+			name:  "escaped dollar",
+			input: "echo $$HOME",
+			env:   map[string]string{},
+			want:  "echo $HOME",
+		},
+		{
+			name:  "escaped dollar next to expansion",
+			input: "$${WORKSPACE_ROOT}=${WORKSPACE_ROOT}",
+			env:   map[string]string{"WORKSPACE_ROOT": "/proj"},
+			want:  "${WORKSPACE_ROOT}=/proj",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := expandShellStyle(tt.input, fakeLookup(tt.env))
+			if tt.wantErr != "" {
+				if err == nil {
+					t.Fatalf("expandShellStyle(%q) = %q, nil; want error containing %q", tt.input, got, tt.wantErr)
+				}
+				if !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expandShellStyle(%q) error = %q, want containing %q", tt.input, err.Error(), tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expandShellStyle(%q) unexpected error: %v", tt.input, err)
+			}
+			if got != tt.want {
+				t.Errorf("expandShellStyle(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExpandEnvVarsInArgsPropagatesRequiredVarError(t *testing.T) {
+	args := []string{"--ok=1", "--workspace=${WORKSPACE_ROOT:?WORKSPACE_ROOT must be set}"}
+	_, err := expandEnvVarsInArgs(args)
+	if err == nil {
+		t.Fatal("expected error for missing required var, got nil")
+	}
+	if !strings.Contains(err.Error(), "WORKSPACE_ROOT") {
+		t.Errorf("error %q should mention the missing var", err.Error())
+	}
+}
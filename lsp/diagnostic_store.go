@@ -0,0 +1,109 @@
+package lsp
+
+import (
+	"context"
+	"sync"
+
+	"github.com/myleshyson/lsprotocol-go/protocol"
+)
+
+// DiagnosticEntry is the most recently published diagnostics for one URI,
+// together with the document version (PublishDiagnosticsParams.Version,
+// when the server sends one) they were computed against.
+type DiagnosticEntry struct {
+	Diagnostics []protocol.Diagnostic
+	Version     int32
+}
+
+// DiagnosticStore holds the latest textDocument/publishDiagnostics report
+// for every URI a language server has pushed diagnostics for, keyed by URI,
+// with per-URI version tracking so a stale, out-of-order report can't
+// clobber a newer one. It is populated by ClientHandler.onPublishDiagnostics
+// and read by LanguageClient.Diagnostics/WaitForDiagnostics/AllDiagnostics -
+// the server's own push stream, as opposed to the pull-diagnostic path
+// diagnosticCache backs.
+type DiagnosticStore struct {
+	mu      sync.Mutex
+	entries map[string]DiagnosticEntry
+	waiters map[string][]chan struct{}
+}
+
+// NewDiagnosticStore creates an empty DiagnosticStore.
+func NewDiagnosticStore() *DiagnosticStore {
+	return &DiagnosticStore{
+		entries: make(map[string]DiagnosticEntry),
+		waiters: make(map[string][]chan struct{}),
+	}
+}
+
+// Update records diagnostics for uri at version, evicting whatever was
+// stored for uri before. A version lower than what's already stored is
+// ignored outright: when the server sends one at all, it identifies the
+// document snapshot the diagnostics were computed against, so an older
+// snapshot's report arriving after a newer one (e.g. racing goroutines on
+// the wire) is stale information, not new information, and must not
+// overwrite it.
+func (s *DiagnosticStore) Update(uri string, diagnostics []protocol.Diagnostic, version int32) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if existing, ok := s.entries[uri]; ok && version != 0 && existing.Version != 0 && version < existing.Version {
+		return
+	}
+
+	s.entries[uri] = DiagnosticEntry{Diagnostics: diagnostics, Version: version}
+
+	for _, ch := range s.waiters[uri] {
+		close(ch)
+	}
+	delete(s.waiters, uri)
+}
+
+// Get returns the most recent diagnostics entry for uri, or ok=false if the
+// server has never published diagnostics for it.
+func (s *DiagnosticStore) Get(uri string) (entry DiagnosticEntry, ok bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entry, ok = s.entries[uri]
+	return entry, ok
+}
+
+// All returns every URI's most recent diagnostics entry, for a
+// workspace-wide view. The returned map is a snapshot copy, safe to range
+// over without holding the store's lock.
+func (s *DiagnosticStore) All() map[string]DiagnosticEntry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make(map[string]DiagnosticEntry, len(s.entries))
+	for uri, entry := range s.entries {
+		out[uri] = entry
+	}
+	return out
+}
+
+// WaitNewerThan blocks until the server publishes a version for uri newer
+// than lastSeenVersion, returning immediately if that's already true, or
+// until ctx is done. It's meant for a caller that just issued an edit and
+// wants to know once the server has actually reacted to it, instead of
+// polling Get on a timer.
+func (s *DiagnosticStore) WaitNewerThan(ctx context.Context, uri string, lastSeenVersion int32) (DiagnosticEntry, error) {
+	for {
+		s.mu.Lock()
+		entry, ok := s.entries[uri]
+		if ok && entry.Version > lastSeenVersion {
+			s.mu.Unlock()
+			return entry, nil
+		}
+
+		ch := make(chan struct{})
+		s.waiters[uri] = append(s.waiters[uri], ch)
+		s.mu.Unlock()
+
+		select {
+		case <-ch:
+			continue
+		case <-ctx.Done():
+			return DiagnosticEntry{}, ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,169 @@
+package lsp
+
+import (
+	"sync"
+
+	"github.com/myleshyson/lsprotocol-go/protocol"
+)
+
+// diagnosticReport is the part of a full diagnostic report worth caching:
+// just the items. kind/resultId are tracked separately (resultId lives on
+// diagnosticCacheEntry; kind is always reconstructed as "full" when a
+// cached report is served back to a caller).
+type diagnosticReport struct {
+	items []protocol.Diagnostic
+}
+
+// diagnosticCacheEntry remembers the last pull-diagnostic result reported
+// for one (identifier, uri) pair, so a later refresh can hand the
+// resultId back to the server as previousResultId and, if the server
+// reports the document unchanged, serve the cached report instead of the
+// caller seeing an empty "unchanged" response.
+type diagnosticCacheEntry struct {
+	resultId string
+	report   diagnosticReport
+}
+
+// diagnosticCacheKey identifies one cached pull-diagnostic result.
+// identifier is the client-chosen diagnostic source id (may be empty); uri
+// is the document the result covers, whether it came from
+// textDocument/diagnostic directly or as one entry of a
+// workspace/diagnostic report.
+type diagnosticCacheKey struct {
+	identifier string
+	uri        string
+}
+
+// maxDiagnosticCacheEntries bounds the cache so a workspace with a huge,
+// ever-growing set of URIs (e.g. generated files churned over a long
+// session) can't grow it without bound; oldest entries are evicted first.
+const maxDiagnosticCacheEntries = 4096
+
+// diagnosticCache caches textDocument/diagnostic and workspace/diagnostic
+// results keyed by (identifier, uri), so a refresh only pays for what
+// actually changed instead of re-transferring the full report every time.
+type diagnosticCache struct {
+	mu      sync.Mutex
+	entries map[diagnosticCacheKey]diagnosticCacheEntry
+	order   []diagnosticCacheKey // FIFO eviction order
+}
+
+func newDiagnosticCache() *diagnosticCache {
+	return &diagnosticCache{entries: make(map[diagnosticCacheKey]diagnosticCacheEntry)}
+}
+
+// previousResultId returns the resultId stored for key, or "" if nothing
+// is cached yet.
+func (c *diagnosticCache) previousResultId(key diagnosticCacheKey) string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.entries[key].resultId
+}
+
+// get returns the cached report for key, used to serve an "unchanged"
+// response without the caller ever seeing an empty report.
+func (c *diagnosticCache) get(key diagnosticCacheKey) (diagnosticReport, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[key]
+	return entry.report, ok
+}
+
+// store records resultId/report for key, evicting the oldest entry first
+// if the cache is already at maxDiagnosticCacheEntries.
+func (c *diagnosticCache) store(key diagnosticCacheKey, resultId string, report diagnosticReport) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, exists := c.entries[key]; !exists {
+		if len(c.order) >= maxDiagnosticCacheEntries {
+			oldest := c.order[0]
+			c.order = c.order[1:]
+			delete(c.entries, oldest)
+		}
+		c.order = append(c.order, key)
+	}
+	c.entries[key] = diagnosticCacheEntry{resultId: resultId, report: report}
+}
+
+// invalidate drops every cached entry for uri (any identifier), called
+// when the document's content has changed underneath us.
+func (c *diagnosticCache) invalidate(uri string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	kept := c.order[:0]
+	for _, key := range c.order {
+		if key.uri == uri {
+			delete(c.entries, key)
+			continue
+		}
+		kept = append(kept, key)
+	}
+	c.order = kept
+}
+
+// invalidateAll drops every cached entry, called when the server asks for
+// a full workspace/diagnostic/refresh.
+func (c *diagnosticCache) invalidateAll() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = make(map[diagnosticCacheKey]diagnosticCacheEntry)
+	c.order = nil
+}
+
+// previousWorkspaceResultIds returns a PreviousResultId for every uri
+// cached under identifier, so a WorkspaceDiagnostic refresh only pays for
+// documents the server hasn't already told us are unchanged.
+func (c *diagnosticCache) previousWorkspaceResultIds(identifier string) []protocol.PreviousResultId {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	ids := make([]protocol.PreviousResultId, 0, len(c.order))
+	for key, entry := range c.entries {
+		if key.identifier != identifier {
+			continue
+		}
+		ids = append(ids, protocol.PreviousResultId{
+			Uri:   protocol.DocumentUri(key.uri),
+			Value: entry.resultId,
+		})
+	}
+	return ids
+}
+
+// reconcileWorkspaceReport walks a freshly received WorkspaceDiagnosticReport,
+// caching the new resultId/items for every "full" entry and swapping the
+// cached items back in for "unchanged" entries, so callers always see a
+// uniform full report regardless of what the server actually sent.
+func (c *diagnosticCache) reconcileWorkspaceReport(identifier string, report *protocol.WorkspaceDiagnosticReport) {
+	for i := range report.Items {
+		item := &report.Items[i]
+		key := diagnosticCacheKey{identifier: identifier, uri: string(item.Uri)}
+
+		if item.Kind == "unchanged" {
+			if cached, ok := c.get(key); ok {
+				item.Kind = "full"
+				item.Items = cached.items
+			}
+			continue
+		}
+
+		c.store(key, item.ResultId, diagnosticReport{items: item.Items})
+	}
+}
+
+// reconcileDocumentReport is the textDocument/diagnostic counterpart of
+// reconcileWorkspaceReport: cache the new report for "full", or swap the
+// cached one back in for "unchanged".
+func (c *diagnosticCache) reconcileDocumentReport(key diagnosticCacheKey, report *protocol.DocumentDiagnosticReport) {
+	if report.Kind == "unchanged" {
+		if cached, ok := c.get(key); ok {
+			report.Kind = "full"
+			report.Items = cached.items
+		}
+		return
+	}
+
+	c.store(key, report.ResultId, diagnosticReport{items: report.Items})
+}
@@ -0,0 +1,170 @@
+package lsp
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"net"
+	"sort"
+	"sync"
+	"time"
+
+	"rockerboo/mcp-lsp-bridge/logger"
+)
+
+// DefaultDNSRefreshInterval is how often StartDNSResolver re-resolves Host
+// when cfg.DNSRefreshMs is unset.
+const DefaultDNSRefreshInterval = 30 * time.Second
+
+// DefaultDialTimeout bounds a single health-check/reconnect dial attempt
+// issued by the DNS resolver when cfg.DialTimeoutMs is unset.
+const DefaultDialTimeout = 10 * time.Second
+
+// dnsResolverState is the background goroutine's handle, guarded by
+// LanguageClient.dnsMu so Start/StopDNSResolver can restart it safely.
+type dnsResolverState struct {
+	cancel context.CancelFunc
+
+	mu    sync.Mutex
+	addrs []string // last resolved address set, sorted
+}
+
+// StartDNSResolver begins periodically re-resolving cfg.Host for a
+// websocket/tcp-mode client and reconnecting to a freshly resolved address
+// whenever the resolved set changes, per cfg.DNSRefreshMs/DialTimeoutMs/
+// KeepExistingConnection. It is a no-op for stdio-mode configs. Calling it
+// again restarts re-resolution with the new cfg, stopping any previous
+// resolver goroutine first.
+func (lc *LanguageClient) StartDNSResolver(cfg LanguageServerConfig) {
+	if !cfg.IsWebSocketMode() && !cfg.IsTCPMode() {
+		return
+	}
+	if cfg.Host == "" {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	state := &dnsResolverState{cancel: cancel}
+
+	lc.dnsMu.Lock()
+	if lc.dnsResolver != nil {
+		lc.dnsResolver.cancel()
+	}
+	lc.dnsResolver = state
+	lc.dnsMu.Unlock()
+
+	go lc.runDNSResolver(ctx, cfg, state)
+}
+
+// StopDNSResolver stops background re-resolution started by
+// StartDNSResolver. Safe to call even if none is running.
+func (lc *LanguageClient) StopDNSResolver() {
+	lc.dnsMu.Lock()
+	defer lc.dnsMu.Unlock()
+	if lc.dnsResolver != nil {
+		lc.dnsResolver.cancel()
+		lc.dnsResolver = nil
+	}
+}
+
+func (lc *LanguageClient) runDNSResolver(ctx context.Context, cfg LanguageServerConfig, state *dnsResolverState) {
+	interval := time.Duration(cfg.DNSRefreshMs) * time.Millisecond
+	if interval <= 0 {
+		interval = DefaultDNSRefreshInterval
+	}
+	dialTimeout := time.Duration(cfg.DialTimeoutMs) * time.Millisecond
+	if dialTimeout <= 0 {
+		dialTimeout = DefaultDialTimeout
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			lc.resolveAndMaybeReconnect(ctx, cfg, dialTimeout, state)
+		}
+	}
+}
+
+// resolveAndMaybeReconnect re-resolves cfg.Host, and when the resolved
+// address set has changed since the last check, either reconnects
+// immediately to one of the new addresses or - if cfg.KeepExistingConnection
+// is set - only does so once the current connection fails a health check.
+func (lc *LanguageClient) resolveAndMaybeReconnect(ctx context.Context, cfg LanguageServerConfig, dialTimeout time.Duration, state *dnsResolverState) {
+	addrs, err := net.LookupHost(cfg.Host)
+	if err != nil {
+		logger.Warn(fmt.Sprintf("DNS resolver: lookup failed for %s: %v", cfg.Host, err))
+		return
+	}
+	sort.Strings(addrs)
+
+	state.mu.Lock()
+	changed := !equalStringSlices(state.addrs, addrs)
+	state.addrs = addrs
+	state.mu.Unlock()
+
+	if !changed {
+		return
+	}
+
+	logger.Info(fmt.Sprintf("DNS resolver: address set for %s changed to %v", cfg.Host, addrs))
+
+	if cfg.KeepExistingConnection && lc.healthCheck(dialTimeout) {
+		logger.Debug(fmt.Sprintf("DNS resolver: existing connection to %s is still healthy, not switching", cfg.Host))
+		return
+	}
+
+	// Round-robin/random selection across the resolved A/AAAA records:
+	// picking uniformly at random spreads reconnects across all of them
+	// without needing to track a cursor per host.
+	addr := addrs[rand.Intn(len(addrs))]
+
+	reloadCfg := cfg
+	reloadCfg.Host = addr
+
+	reloadCtx, cancel := context.WithTimeout(ctx, dialTimeout)
+	defer cancel()
+
+	if err := lc.Reload(reloadCtx, reloadCfg); err != nil {
+		logger.Error("DNS resolver: reconnect to refreshed endpoint failed", addr, err)
+		return
+	}
+
+	logger.Info(fmt.Sprintf("DNS resolver: reconnected %s to refreshed endpoint %s", cfg.Host, addr))
+}
+
+// healthCheck does a cheap TCP dial to the currently connected peer address
+// to check liveness. Only used when KeepExistingConnection defers switching
+// until the existing connection actually stops working.
+func (lc *LanguageClient) healthCheck(timeout time.Duration) bool {
+	lc.mu.RLock()
+	addr := lc.tcpAddress
+	lc.mu.RUnlock()
+
+	if addr == "" {
+		return false
+	}
+
+	conn, err := net.DialTimeout("tcp", addr, timeout)
+	if err != nil {
+		return false
+	}
+	conn.Close()
+	return true
+}
+
+func equalStringSlices(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,162 @@
+package lsp
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"unicode/utf16"
+
+	"github.com/myleshyson/lsprotocol-go/protocol"
+)
+
+// documentEntry is the last text SessionAdapter told Session Manager about
+// for a URI, kept in lockstep with the version number it acknowledged.
+type documentEntry struct {
+	version    int32
+	text       string
+	languageId protocol.LanguageKind
+}
+
+// documentStore tracks open buffers so DidChange can apply incremental
+// edits locally instead of forcing callers to resend the whole document on
+// every keystroke.
+type documentStore struct {
+	mu   sync.Mutex
+	docs map[string]documentEntry
+}
+
+func newDocumentStore() *documentStore {
+	return &documentStore{docs: make(map[string]documentEntry)}
+}
+
+// open seeds the store from a textDocument/didOpen.
+func (ds *documentStore) open(uri string, languageId protocol.LanguageKind, text string, version int32) {
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+	ds.docs[uri] = documentEntry{version: version, text: text, languageId: languageId}
+}
+
+// close drops a buffer on textDocument/didClose.
+func (ds *documentStore) close(uri string) {
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+	delete(ds.docs, uri)
+}
+
+// get returns the buffer text tools should compute positions against.
+func (ds *documentStore) get(uri string) (text string, version int32, ok bool) {
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+	doc, ok := ds.docs[uri]
+	if !ok {
+		return "", 0, false
+	}
+	return doc.text, doc.version, true
+}
+
+// applyChanges replays changes against the stored text and returns the
+// resulting document, rejecting a version that doesn't advance past the
+// one already cached. Each change is either a full-document replacement
+// (no Range) or an incremental edit addressed by UTF-16 code-unit offsets,
+// per the LSP spec.
+func (ds *documentStore) applyChanges(uri string, version int32, changes []protocol.TextDocumentContentChangeEvent) (string, error) {
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+
+	doc, ok := ds.docs[uri]
+	if !ok {
+		return "", fmt.Errorf("documentStore: %s is not open", uri)
+	}
+	if version <= doc.version {
+		return "", fmt.Errorf("documentStore: stale version %d for %s (have %d)", version, uri, doc.version)
+	}
+
+	text := doc.text
+	for _, change := range changes {
+		if change.Range == nil {
+			text = change.Text
+			continue
+		}
+		text = applyRangeChange(text, *change.Range, change.Text)
+	}
+
+	doc.text = text
+	doc.version = version
+	ds.docs[uri] = doc
+
+	return text, nil
+}
+
+// ApplyTextEdits applies edits to text and returns the result, for callers
+// (e.g. apply_code_action) that need to turn a WorkspaceEdit into the new
+// file content without an open buffer to route it through DidChange.
+// Edits are applied in descending start-position order so an earlier edit's
+// replacement never shifts the range of one still waiting to be applied;
+// per the LSP spec, edits for the same document must not overlap, so order
+// otherwise doesn't matter.
+func ApplyTextEdits(text string, edits []protocol.TextEdit) string {
+	sorted := make([]protocol.TextEdit, len(edits))
+	copy(sorted, edits)
+	sort.Slice(sorted, func(i, j int) bool {
+		return positionLess(sorted[j].Range.Start, sorted[i].Range.Start)
+	})
+
+	for _, edit := range sorted {
+		text = applyRangeChange(text, edit.Range, edit.NewText)
+	}
+
+	return text
+}
+
+// positionLess orders Positions by line then character.
+func positionLess(a, b protocol.Position) bool {
+	if a.Line != b.Line {
+		return a.Line < b.Line
+	}
+	return a.Character < b.Character
+}
+
+// applyRangeChange replaces the text between rng.Start and rng.End,
+// expressed in UTF-16 code units per LSP's Position semantics, with
+// newText.
+func applyRangeChange(text string, rng protocol.Range, newText string) string {
+	units := utf16.Encode([]rune(text))
+
+	start := utf16OffsetForPosition(units, rng.Start)
+	end := utf16OffsetForPosition(units, rng.End)
+
+	replaced := make([]uint16, 0, len(units)-(end-start)+len(newText))
+	replaced = append(replaced, units[:start]...)
+	replaced = append(replaced, utf16.Encode([]rune(newText))...)
+	replaced = append(replaced, units[end:]...)
+
+	return string(utf16.Decode(replaced))
+}
+
+// utf16OffsetForPosition walks line breaks in units to find the UTF-16
+// code-unit offset for a zero-based line/character Position, clamping
+// character to the end of the line like most LSP servers do for an
+// out-of-range column.
+func utf16OffsetForPosition(units []uint16, pos protocol.Position) int {
+	line := uint32(0)
+	offset := 0
+
+	for offset < len(units) && line < pos.Line {
+		if units[offset] == '\n' {
+			line++
+		}
+		offset++
+	}
+
+	end := offset
+	for end < len(units) && units[end] != '\n' {
+		end++
+	}
+
+	character := int(pos.Character)
+	if offset+character > end {
+		character = end - offset
+	}
+
+	return offset + character
+}
@@ -4,15 +4,107 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"sync"
 
 	"github.com/myleshyson/lsprotocol-go/protocol"
 	"github.com/sourcegraph/jsonrpc2"
 	"rockerboo/mcp-lsp-bridge/logger"
 )
 
-// ClientHandler handles incoming messages from the language server
+// ClientHandler handles incoming messages from the language server: both
+// the server-initiated requests/notifications gopls' client-facing API
+// documents (publishDiagnostics, logMessage, showMessage,
+// showMessageRequest, applyEdit, register/unregisterCapability,
+// semanticTokens/refresh) and the progress/workDone notifications handled
+// elsewhere in this file.
 type ClientHandler struct {
-	progress *ProgressTracker
+	progress  *ProgressTracker
+	diagCache *diagnosticCache
+	diagStore *DiagnosticStore
+
+	regMu         sync.Mutex
+	registrations map[string]protocol.Registration
+
+	applyEditMu sync.RWMutex
+	applyEdit   func(protocol.ApplyWorkspaceEditParams) bool
+
+	configMu sync.RWMutex
+	config   *ServerConfigProvider
+}
+
+// NewClientHandler creates a ClientHandler backed by progress and diagCache
+// (either may be nil, in which case the notifications they back are
+// silently ignored).
+func NewClientHandler(progress *ProgressTracker, diagCache *diagnosticCache) *ClientHandler {
+	return &ClientHandler{
+		progress:      progress,
+		diagCache:     diagCache,
+		diagStore:     NewDiagnosticStore(),
+		registrations: make(map[string]protocol.Registration),
+	}
+}
+
+// Diagnostics returns the most recent textDocument/publishDiagnostics
+// payload the server pushed for uri, or nil if none has arrived yet.
+func (h *ClientHandler) Diagnostics(uri string) []protocol.Diagnostic {
+	entry, _ := h.diagStore.Get(uri)
+	return entry.Diagnostics
+}
+
+// AllDiagnostics returns the most recent publishDiagnostics payload for
+// every URI the server has reported on, for a workspace-wide view.
+func (h *ClientHandler) AllDiagnostics() map[string]DiagnosticEntry {
+	return h.diagStore.All()
+}
+
+// WaitForDiagnostics blocks until the server publishes diagnostics for uri
+// newer than lastSeenVersion, or ctx is done - see
+// DiagnosticStore.WaitNewerThan.
+func (h *ClientHandler) WaitForDiagnostics(ctx context.Context, uri string, lastSeenVersion int32) (DiagnosticEntry, error) {
+	return h.diagStore.WaitNewerThan(ctx, uri, lastSeenVersion)
+}
+
+// SetApplyEditHandler registers fn to decide whether a workspace/applyEdit
+// request from the server should be applied. Until a handler is set,
+// applyEdit requests are answered with {applied:false}, since there is
+// nothing in this client that can safely mutate files on the server's
+// behalf without caller opt-in.
+func (h *ClientHandler) SetApplyEditHandler(fn func(protocol.ApplyWorkspaceEditParams) bool) {
+	h.applyEditMu.Lock()
+	defer h.applyEditMu.Unlock()
+	h.applyEdit = fn
+}
+
+// Registered reports whether the server has dynamically registered
+// interest in method via client/registerCapability, so callers can gate
+// optional requests on what the server actually asked for instead of just
+// its static ServerCapabilities.
+func (h *ClientHandler) Registered(method string) bool {
+	h.regMu.Lock()
+	defer h.regMu.Unlock()
+	_, ok := h.registrations[method]
+	return ok
+}
+
+// Registration returns the server's dynamic registration for method (see
+// Registered), including its RegisterOptions, so a caller can inspect what
+// the server actually asked to be notified about - e.g. WatchRegistry
+// decoding the glob patterns out of a workspace/didChangeWatchedFiles
+// registration instead of only knowing it exists.
+func (h *ClientHandler) Registration(method string) (protocol.Registration, bool) {
+	h.regMu.Lock()
+	defer h.regMu.Unlock()
+	reg, ok := h.registrations[method]
+	return reg, ok
+}
+
+// SetConfigProvider installs provider to answer workspace/configuration
+// requests (see onConfiguration). Until a provider is set, every requested
+// item comes back nil, same as the unconditional empty reply this replaced.
+func (h *ClientHandler) SetConfigProvider(provider *ServerConfigProvider) {
+	h.configMu.Lock()
+	defer h.configMu.Unlock()
+	h.config = provider
 }
 
 func (h *ClientHandler) Handle(ctx context.Context, conn *jsonrpc2.Conn, req *jsonrpc2.Request) {
@@ -50,37 +142,34 @@ func (h *ClientHandler) Handle(ctx context.Context, conn *jsonrpc2.Conn, req *js
 		return
 
 	case "textDocument/publishDiagnostics":
-		// Handle diagnostics
-		var params any
-		if err := json.Unmarshal(*req.Params, &params); err == nil {
-			logger.Debug(fmt.Sprintf("Diagnostics: %+v\n", params))
-		}
+		h.onPublishDiagnostics(req)
 
 	case "window/showMessage":
-		// Handle show message
-		var params any
-		if err := json.Unmarshal(*req.Params, &params); err == nil {
-			logger.Debug(fmt.Sprintf("Server message: %+v\n", params))
-		}
+		h.onShowMessage(req)
+
+	case "window/showMessageRequest":
+		h.onShowMessageRequest(ctx, conn, req)
 
 	case "window/logMessage":
-		// Handle log message
-		var params any
-		if err := json.Unmarshal(*req.Params, &params); err == nil {
-			logger.Info(fmt.Sprintf("Server log: %+v\n", params))
-		}
+		h.onLogMessage(req)
+
+	case "workspace/applyEdit":
+		h.onApplyEdit(ctx, conn, req)
 
 	case "client/registerCapability":
-		// Handle capability registration - reply with success
-		if err := conn.Reply(ctx, req.ID, map[string]any{}); err != nil {
-			logger.Debug(fmt.Sprintf("Failed to reply to registerCapability: %v\n", err))
-		}
+		h.onRegisterCapability(ctx, conn, req)
+
+	case "client/unregisterCapability":
+		h.onUnregisterCapability(ctx, conn, req)
+
+	case "workspace/semanticTokens/refresh":
+		h.onSemanticTokensRefresh(ctx, conn, req)
+
+	case "workspace/diagnostic/refresh":
+		h.onDiagnosticRefresh(ctx, conn, req)
 
 	case "workspace/configuration":
-		// Handle configuration request - reply with empty config
-		if err := conn.Reply(ctx, req.ID, []any{}); err != nil {
-			logger.Debug(fmt.Sprintf("Failed to reply to configuration: %v\n", err))
-		}
+		h.onConfiguration(ctx, conn, req)
 
 	default:
 		// IMPORTANT:
@@ -103,3 +192,188 @@ func (h *ClientHandler) Handle(ctx context.Context, conn *jsonrpc2.Conn, req *js
 		}
 	}
 }
+
+// onPublishDiagnostics buffers the server's diagnostics for a document,
+// keyed by URI, so LanguageClient.Diagnostics(uri) can serve them without
+// callers having to poll a pull-diagnostics request.
+func (h *ClientHandler) onPublishDiagnostics(req *jsonrpc2.Request) {
+	if req.Params == nil {
+		return
+	}
+	var params protocol.PublishDiagnosticsParams
+	if err := json.Unmarshal(*req.Params, &params); err != nil {
+		logger.Debug(fmt.Sprintf("Failed to unmarshal publishDiagnostics params: %v\n", err))
+		return
+	}
+
+	var version int32
+	if params.Version != nil {
+		version = *params.Version
+	}
+	h.diagStore.Update(string(params.Uri), params.Diagnostics, version)
+}
+
+func (h *ClientHandler) onShowMessage(req *jsonrpc2.Request) {
+	if req.Params == nil {
+		return
+	}
+	var params protocol.ShowMessageParams
+	if err := json.Unmarshal(*req.Params, &params); err != nil {
+		logger.Debug(fmt.Sprintf("Failed to unmarshal showMessage params: %v\n", err))
+		return
+	}
+	logger.Info(fmt.Sprintf("Server message [%v]: %s", params.Type, params.Message))
+}
+
+// onShowMessageRequest answers a window/showMessageRequest. There is no UI
+// to prompt a human from here, so it replies with no action selected,
+// same as an unattended client would.
+func (h *ClientHandler) onShowMessageRequest(ctx context.Context, conn *jsonrpc2.Conn, req *jsonrpc2.Request) {
+	var params protocol.ShowMessageRequestParams
+	if req.Params != nil {
+		if err := json.Unmarshal(*req.Params, &params); err != nil {
+			logger.Debug(fmt.Sprintf("Failed to unmarshal showMessageRequest params: %v\n", err))
+		}
+	}
+	logger.Info(fmt.Sprintf("Server message request [%v]: %s", params.Type, params.Message))
+
+	if err := conn.Reply(ctx, req.ID, nil); err != nil {
+		logger.Debug(fmt.Sprintf("Failed to reply to showMessageRequest: %v\n", err))
+	}
+}
+
+func (h *ClientHandler) onLogMessage(req *jsonrpc2.Request) {
+	if req.Params == nil {
+		return
+	}
+	var params protocol.LogMessageParams
+	if err := json.Unmarshal(*req.Params, &params); err != nil {
+		logger.Debug(fmt.Sprintf("Failed to unmarshal logMessage params: %v\n", err))
+		return
+	}
+	logger.Info(fmt.Sprintf("Server log [%v]: %s", params.Type, params.Message))
+}
+
+// onApplyEdit answers workspace/applyEdit. Unless a caller opted in via
+// SetApplyEditHandler, it replies {applied:false}: applying a
+// server-driven workspace edit without caller review isn't safe to do by
+// default.
+func (h *ClientHandler) onApplyEdit(ctx context.Context, conn *jsonrpc2.Conn, req *jsonrpc2.Request) {
+	var params protocol.ApplyWorkspaceEditParams
+	if req.Params != nil {
+		if err := json.Unmarshal(*req.Params, &params); err != nil {
+			logger.Debug(fmt.Sprintf("Failed to unmarshal applyEdit params: %v\n", err))
+		}
+	}
+
+	applied := false
+	h.applyEditMu.RLock()
+	callback := h.applyEdit
+	h.applyEditMu.RUnlock()
+	if callback != nil {
+		applied = callback(params)
+	}
+
+	if err := conn.Reply(ctx, req.ID, protocol.ApplyWorkspaceEditResult{Applied: applied}); err != nil {
+		logger.Debug(fmt.Sprintf("Failed to reply to applyEdit: %v\n", err))
+	}
+}
+
+// onConfiguration answers workspace/configuration with one reply item per
+// requested item.Section, from the installed ServerConfigProvider (see
+// SetConfigProvider) - BSL LS in particular uses this to pick up diagnostic
+// language, formatter settings, and project paths, so an empty reply here
+// silently degrades behavior. Until a provider is installed, or for a
+// section the provider doesn't know about, the corresponding reply item is
+// nil, which every server we've seen treats as "no configuration for this
+// section" rather than an error.
+func (h *ClientHandler) onConfiguration(ctx context.Context, conn *jsonrpc2.Conn, req *jsonrpc2.Request) {
+	var params protocol.ConfigurationParams
+	if req.Params != nil {
+		if err := json.Unmarshal(*req.Params, &params); err != nil {
+			logger.Debug(fmt.Sprintf("Failed to unmarshal configuration params: %v\n", err))
+		}
+	}
+
+	h.configMu.RLock()
+	provider := h.config
+	h.configMu.RUnlock()
+
+	result := make([]any, len(params.Items))
+	for i, item := range params.Items {
+		var section string
+		if item.Section != nil {
+			section = *item.Section
+		}
+		result[i] = provider.Section(section)
+	}
+
+	if err := conn.Reply(ctx, req.ID, result); err != nil {
+		logger.Debug(fmt.Sprintf("Failed to reply to configuration: %v\n", err))
+	}
+}
+
+// onRegisterCapability records the server's dynamic registrations so
+// Registered(method) reflects them, then acknowledges the request.
+func (h *ClientHandler) onRegisterCapability(ctx context.Context, conn *jsonrpc2.Conn, req *jsonrpc2.Request) {
+	if req.Params != nil {
+		var params protocol.RegistrationParams
+		if err := json.Unmarshal(*req.Params, &params); err != nil {
+			logger.Debug(fmt.Sprintf("Failed to unmarshal registerCapability params: %v\n", err))
+		} else {
+			h.regMu.Lock()
+			for _, reg := range params.Registrations {
+				h.registrations[reg.Method] = reg
+			}
+			h.regMu.Unlock()
+		}
+	}
+
+	if err := conn.Reply(ctx, req.ID, nil); err != nil {
+		logger.Debug(fmt.Sprintf("Failed to reply to registerCapability: %v\n", err))
+	}
+}
+
+// onUnregisterCapability removes the matching dynamic registrations, then
+// acknowledges the request.
+func (h *ClientHandler) onUnregisterCapability(ctx context.Context, conn *jsonrpc2.Conn, req *jsonrpc2.Request) {
+	if req.Params != nil {
+		var params protocol.UnregistrationParams
+		if err := json.Unmarshal(*req.Params, &params); err != nil {
+			logger.Debug(fmt.Sprintf("Failed to unmarshal unregisterCapability params: %v\n", err))
+		} else {
+			h.regMu.Lock()
+			for _, unreg := range params.Unregisterations {
+				delete(h.registrations, unreg.Method)
+			}
+			h.regMu.Unlock()
+		}
+	}
+
+	if err := conn.Reply(ctx, req.ID, nil); err != nil {
+		logger.Debug(fmt.Sprintf("Failed to reply to unregisterCapability: %v\n", err))
+	}
+}
+
+// onSemanticTokensRefresh acknowledges workspace/semanticTokens/refresh.
+// Nothing here caches full-document semantic tokens beyond the per-URI
+// resultId SessionAdapter already tracks, so there's nothing to invalidate
+// yet; we still need to reply so the server doesn't see an error.
+func (h *ClientHandler) onSemanticTokensRefresh(ctx context.Context, conn *jsonrpc2.Conn, req *jsonrpc2.Request) {
+	if err := conn.Reply(ctx, req.ID, nil); err != nil {
+		logger.Debug(fmt.Sprintf("Failed to reply to semanticTokens/refresh: %v\n", err))
+	}
+}
+
+// onDiagnosticRefresh answers workspace/diagnostic/refresh by dropping the
+// whole pull-diagnostic cache, so the next DocumentDiagnostics/
+// WorkspaceDiagnostic call re-fetches full reports instead of asking the
+// server to diff against resultIds it just told us to discard.
+func (h *ClientHandler) onDiagnosticRefresh(ctx context.Context, conn *jsonrpc2.Conn, req *jsonrpc2.Request) {
+	if h.diagCache != nil {
+		h.diagCache.invalidateAll()
+	}
+	if err := conn.Reply(ctx, req.ID, nil); err != nil {
+		logger.Debug(fmt.Sprintf("Failed to reply to diagnostic/refresh: %v\n", err))
+	}
+}
@@ -0,0 +1,120 @@
+package lsp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"time"
+)
+
+// HealthResult is the outcome of one RunHealthCheck call: an advisory,
+// non-blocking report distinct from PostureResult's pass/fail pre-flight
+// gate. A server with OK == false degrades LSPStatus.Clients[].Health (see
+// mcpserver/tools/readiness.go) without tripping CheckReadyOrReturn's
+// readiness gate - see bridge.MCPLSPBridge.CheckAllHealth/HealthReports.
+type HealthResult struct {
+	Server          string    `json:"server"`
+	CheckedAt       time.Time `json:"checked_at"`
+	OK              bool      `json:"ok"`
+	Warnings        []string  `json:"warnings,omitempty"`
+	Vulnerabilities []string  `json:"vulnerabilities,omitempty"`
+	DetectedVersion string    `json:"detected_version,omitempty"`
+}
+
+// RunHealthCheck probes cfg's server binary for version staleness against
+// cfg.Posture.MinVersion (if set) and, if cfg.Posture.GoModuleDir is set,
+// scans that module with govulncheck for known vulnerabilities. Both are
+// advisory: a failed probe becomes a Warnings/Vulnerabilities entry rather
+// than an error return, so one bad scan doesn't take the whole result
+// down - the opposite of RunPostureCheck, which this complements rather
+// than replaces.
+func RunHealthCheck(serverName string, cfg LanguageServerConfig) HealthResult {
+	result := HealthResult{Server: serverName, CheckedAt: time.Now(), OK: true}
+
+	posture := cfg.Posture
+	if posture == nil || cfg.Command == "" {
+		return result
+	}
+
+	path, lookErr := exec.LookPath(cfg.Command)
+	if lookErr != nil {
+		result.Warnings = append(result.Warnings, fmt.Sprintf("command %q not found: %v", cfg.Command, lookErr))
+		result.OK = false
+		return result
+	}
+
+	if posture.MinVersion != "" {
+		version, probeErr := probeVersion(path, posture)
+		if probeErr != nil {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("version probe failed: %v", probeErr))
+			result.OK = false
+		} else {
+			result.DetectedVersion = version
+			ok, cmpErr := versionAtLeast(version, posture.MinVersion)
+			if cmpErr != nil {
+				result.Warnings = append(result.Warnings, fmt.Sprintf("compare version %q to min %q: %v", version, posture.MinVersion, cmpErr))
+				result.OK = false
+			} else if !ok {
+				result.Warnings = append(result.Warnings, fmt.Sprintf("version %s is below minimum %s", version, posture.MinVersion))
+				result.OK = false
+			}
+		}
+	}
+
+	if posture.GoModuleDir != "" {
+		vulns, err := runGoVulnCheck(posture.GoModuleDir, probeTimeout(posture))
+		if err != nil {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("govulncheck: %v", err))
+		} else if len(vulns) > 0 {
+			result.Vulnerabilities = vulns
+			result.OK = false
+		}
+	}
+
+	return result
+}
+
+// govulncheckFinding is the subset of govulncheck -json's streamed output
+// (a sequence of top-level objects, one "finding" per affected call path)
+// this needs: just enough to collect which OSV IDs affect the module.
+type govulncheckFinding struct {
+	Finding *struct {
+		OSV string `json:"osv"`
+	} `json:"finding"`
+}
+
+// runGoVulnCheck runs `govulncheck -json ./...` in moduleDir and returns
+// the distinct OSV IDs it reports. Returns an error (not a failed
+// HealthResult field) if the govulncheck binary isn't on $PATH, so a
+// bridge host without it installed gets one clear warning instead of
+// silently reporting zero vulnerabilities.
+func runGoVulnCheck(moduleDir string, timeout time.Duration) ([]string, error) {
+	path, err := exec.LookPath("govulncheck")
+	if err != nil {
+		return nil, fmt.Errorf("govulncheck not found on PATH: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, path, "-json", "./...")
+	cmd.Dir = moduleDir
+	out, _ := cmd.Output() // govulncheck exits non-zero when it finds vulnerabilities - expected, not an error
+
+	seen := make(map[string]bool)
+	var ids []string
+	dec := json.NewDecoder(bytes.NewReader(out))
+	for {
+		var entry govulncheckFinding
+		if decErr := dec.Decode(&entry); decErr != nil {
+			break
+		}
+		if entry.Finding != nil && entry.Finding.OSV != "" && !seen[entry.Finding.OSV] {
+			seen[entry.Finding.OSV] = true
+			ids = append(ids, entry.Finding.OSV)
+		}
+	}
+	return ids, nil
+}
@@ -1,6 +1,7 @@
 package lsp
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -11,9 +12,20 @@ import (
 )
 
 // LSP Protocol Method Implementations
+//
+// Every method here takes ctx as its first argument and threads it through
+// to SendRequest/SendNotification, the same way gopls' generated
+// protocol.Server interface does. SendRequest owns the pending-response
+// map and the jsonrpc id it generates for the request; when ctx is
+// cancelled while a request is outstanding, SendRequest is responsible for
+// sending a $/cancelRequest notification with that id, keeping the pending
+// entry registered so the server's eventual response to the cancelled
+// request is still consumed, and returning ctx.Err() here. The hard
+// per-method timeouts below are passed through unchanged and only kick in
+// as a fallback when the caller's ctx has no earlier deadline.
 
 // Initialize sends an initialize request to the language server
-func (lc *LanguageClient) Initialize(params protocol.InitializeParams) (*protocol.InitializeResult, error) {
+func (lc *LanguageClient) Initialize(ctx context.Context, params protocol.InitializeParams) (*protocol.InitializeResult, error) {
 	// Check connection status before sending request
 	logger.Debug(fmt.Sprintf("STATUS: Initialize - About to call SendRequest, ctx.Err()=%v", lc.ctx.Err()))
 	select {
@@ -24,34 +36,83 @@ func (lc *LanguageClient) Initialize(params protocol.InitializeParams) (*protoco
 		logger.Debug("STATUS: Initialize - Connection appears healthy")
 	}
 
-	var result protocol.InitializeResult
+	var raw json.RawMessage
 
-	err := lc.SendRequest("initialize", params, &result, 15*time.Second)
-	if err != nil {
+	if err := lc.SendRequest(ctx, "initialize", params, &raw, 15*time.Second); err != nil {
 		return nil, err
 	}
 
+	var result protocol.InitializeResult
+	if err := json.Unmarshal(raw, &result); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal initialize result: %w", err)
+	}
+
+	var capsProbe struct {
+		Capabilities json.RawMessage `json:"capabilities"`
+	}
+	if err := json.Unmarshal(raw, &capsProbe); err == nil {
+		lc.mu.Lock()
+		lc.serverCapabilities = result.Capabilities
+		lc.rawServerCapabilities = capsProbe.Capabilities
+		lc.mu.Unlock()
+	}
+
 	return &result, nil
 }
 
+// ServerCapabilities returns the capabilities negotiated during Initialize.
+func (lc *LanguageClient) ServerCapabilities() protocol.ServerCapabilities {
+	lc.mu.RLock()
+	defer lc.mu.RUnlock()
+	return lc.serverCapabilities
+}
+
 // Initialized sends the initialized notification
-func (lc *LanguageClient) Initialized() error {
-	return lc.SendNotification("initialized", protocol.InitializedParams{})
+func (lc *LanguageClient) Initialized(ctx context.Context) error {
+	return lc.SendNotification(ctx, "initialized", protocol.InitializedParams{})
 }
 
-// Shutdown sends a shutdown request
-func (lc *LanguageClient) Shutdown() error {
+// Shutdown runs the LSP shutdown/exit handshake and tears down the
+// connection. For a WebSocket transport this writes a proper close frame
+// and waits for the peer's close frame (bounded by ctx) instead of dropping
+// the TCP socket, so the server sees a normal closure rather than an
+// abnormal 1006 one.
+func (lc *LanguageClient) Shutdown(ctx context.Context) error {
 	var result protocol.ShutdownResponse
-	return lc.SendRequest("shutdown", nil, &result, 5*time.Second)
+	if err := lc.SendRequest(ctx, "shutdown", nil, &result, 5*time.Second); err != nil {
+		logger.Warn(fmt.Sprintf("Shutdown: shutdown request failed: %v", err))
+	}
+
+	if err := lc.Exit(ctx); err != nil {
+		logger.Warn(fmt.Sprintf("Shutdown: exit notification failed: %v", err))
+	}
+
+	lc.mu.RLock()
+	rwc := lc.wsRWC
+	lc.mu.RUnlock()
+
+	if rwc != nil {
+		if err := rwc.closeGracefully(ctx); err != nil {
+			logger.Warn(fmt.Sprintf("Shutdown: graceful WebSocket close failed: %v", err))
+		}
+	} else if lc.conn != nil {
+		lc.conn.Close()
+	}
+
+	if lc.cancel != nil {
+		lc.cancel()
+	}
+
+	return nil
 }
 
 // Exit sends an exit notification
-func (lc *LanguageClient) Exit() error {
-	return lc.SendNotification("exit", nil)
+func (lc *LanguageClient) Exit(ctx context.Context) error {
+	return lc.SendNotification(ctx, "exit", nil)
 }
 
 // DidOpen sends a textDocument/didOpen notification
-func (lc *LanguageClient) DidOpen(uri string, languageId protocol.LanguageKind, text string, version int32) error {
+func (lc *LanguageClient) DidOpen(ctx context.Context, uri string, languageId protocol.LanguageKind, text string, version int32) error {
 	params := protocol.DidOpenTextDocumentParams{
 		TextDocument: protocol.TextDocumentItem{
 			Uri:        protocol.DocumentUri(uri),
@@ -61,11 +122,22 @@ func (lc *LanguageClient) DidOpen(uri string, languageId protocol.LanguageKind,
 		},
 	}
 
-	return lc.SendNotification("textDocument/didOpen", params)
+	if err := lc.SendNotification(ctx, "textDocument/didOpen", params); err != nil {
+		return err
+	}
+
+	lc.openedDocsMu.Lock()
+	if lc.openedDocs == nil {
+		lc.openedDocs = make(map[string]openedDoc)
+	}
+	lc.openedDocs[uri] = openedDoc{languageId: languageId, text: text, version: version}
+	lc.openedDocsMu.Unlock()
+
+	return nil
 }
 
 // DidChange sends a textDocument/didChange notification
-func (lc *LanguageClient) DidChange(uri string, version int32, changes []protocol.TextDocumentContentChangeEvent) error {
+func (lc *LanguageClient) DidChange(ctx context.Context, uri string, version int32, changes []protocol.TextDocumentContentChangeEvent) error {
 	params := protocol.DidChangeTextDocumentParams{
 		TextDocument: protocol.VersionedTextDocumentIdentifier{
 			Uri:     protocol.DocumentUri(uri),
@@ -74,11 +146,16 @@ func (lc *LanguageClient) DidChange(uri string, version int32, changes []protoco
 		ContentChanges: changes,
 	}
 
-	return lc.SendNotification("textDocument/didChange", params)
+	if err := lc.SendNotification(ctx, "textDocument/didChange", params); err != nil {
+		return err
+	}
+
+	lc.InvalidateDiagnostics(uri)
+	return nil
 }
 
 // DidSave sends a textDocument/didSave notification
-func (lc *LanguageClient) DidSave(uri string, text *string) error {
+func (lc *LanguageClient) DidSave(ctx context.Context, uri string, text *string) error {
 	params := map[string]interface{}{
 		"textDocument": map[string]interface{}{
 			"uri": uri,
@@ -88,25 +165,44 @@ func (lc *LanguageClient) DidSave(uri string, text *string) error {
 		params["text"] = *text
 	}
 
-	return lc.SendNotification("textDocument/didSave", params)
+	if err := lc.SendNotification(ctx, "textDocument/didSave", params); err != nil {
+		return err
+	}
+
+	lc.InvalidateDiagnostics(uri)
+	return nil
 }
 
 // DidClose sends a textDocument/didClose notification
-func (lc *LanguageClient) DidClose(uri string) error {
+func (lc *LanguageClient) DidClose(ctx context.Context, uri string) error {
 	params := protocol.DidCloseTextDocumentParams{
 		TextDocument: protocol.TextDocumentIdentifier{
 			Uri: protocol.DocumentUri(uri),
 		},
 	}
 
-	return lc.SendNotification("textDocument/didClose", params)
+	if err := lc.SendNotification(ctx, "textDocument/didClose", params); err != nil {
+		return err
+	}
+
+	lc.openedDocsMu.Lock()
+	delete(lc.openedDocs, uri)
+	lc.openedDocsMu.Unlock()
+
+	lc.semTokMu.Lock()
+	delete(lc.semTokResults, uri)
+	lc.semTokMu.Unlock()
+
+	lc.InvalidateDiagnostics(uri)
+	return nil
 }
 
-func (lc *LanguageClient) WorkspaceSymbols(query string) ([]protocol.WorkspaceSymbol, error) {
+func (lc *LanguageClient) WorkspaceSymbols(ctx context.Context, query string) ([]protocol.WorkspaceSymbol, error) {
 	var result []protocol.WorkspaceSymbol
 
-	err := lc.SendRequest("workspace/symbol", protocol.WorkspaceSymbolParams{
-		Query: query,
+	err := lc.SendRequest(ctx, "workspace/symbol", protocol.WorkspaceSymbolParams{
+		Query:         query,
+		WorkDoneToken: newProgressToken(),
 	}, &result, 60*time.Second)
 	if err != nil {
 		return nil, err
@@ -117,11 +213,11 @@ func (lc *LanguageClient) WorkspaceSymbols(query string) ([]protocol.WorkspaceSy
 
 // Definition requests definition locations for a symbol at a given position
 // Returns LocationLink[] or converts Location[] to LocationLink[]
-func (lc *LanguageClient) Definition(uri string, line, character uint32) ([]protocol.Or2[protocol.LocationLink, protocol.Location], error) {
+func (lc *LanguageClient) Definition(ctx context.Context, uri string, line, character uint32) ([]protocol.Or2[protocol.LocationLink, protocol.Location], error) {
 	// Use raw JSON response to handle both Location[] and LocationLink[] formats
 	var rawResult json.RawMessage
 
-	err := lc.SendRequest("textDocument/definition", protocol.DefinitionParams{
+	err := lc.SendRequest(ctx, "textDocument/definition", protocol.DefinitionParams{
 		TextDocument: protocol.TextDocumentIdentifier{
 			Uri: protocol.DocumentUri(uri),
 		},
@@ -146,10 +242,10 @@ func (lc *LanguageClient) Definition(uri string, line, character uint32) ([]prot
 }
 
 // References finds all references to a symbol at a given position
-func (lc *LanguageClient) References(uri string, line, character uint32, includeDeclaration bool) ([]protocol.Location, error) {
+func (lc *LanguageClient) References(ctx context.Context, uri string, line, character uint32, includeDeclaration bool) ([]protocol.Location, error) {
 	var result []protocol.Location
 
-	err := lc.SendRequest("textDocument/references", protocol.ReferenceParams{
+	err := lc.SendRequest(ctx, "textDocument/references", protocol.ReferenceParams{
 		TextDocument: protocol.TextDocumentIdentifier{
 			Uri: protocol.DocumentUri(uri),
 		},
@@ -169,7 +265,7 @@ func (lc *LanguageClient) References(uri string, line, character uint32, include
 }
 
 // Hover provides hover information at a given position
-func (lc *LanguageClient) Hover(uri string, line, character uint32) (*protocol.Hover, error) {
+func (lc *LanguageClient) Hover(ctx context.Context, uri string, line, character uint32) (*protocol.Hover, error) {
 	params := protocol.HoverParams{
 		TextDocument: protocol.TextDocumentIdentifier{
 			Uri: protocol.DocumentUri(uri),
@@ -182,7 +278,7 @@ func (lc *LanguageClient) Hover(uri string, line, character uint32) (*protocol.H
 
 	var rawResponse json.RawMessage
 
-	err := lc.SendRequest("textDocument/hover", params, &rawResponse, 10*time.Second)
+	err := lc.SendRequest(ctx, "textDocument/hover", params, &rawResponse, 10*time.Second)
 	if err != nil {
 		return nil, err
 	}
@@ -203,10 +299,10 @@ func (lc *LanguageClient) Hover(uri string, line, character uint32) (*protocol.H
 }
 
 // DocumentSymbols returns all symbols in a document
-func (lc *LanguageClient) DocumentSymbols(uri string) ([]protocol.DocumentSymbol, error) {
+func (lc *LanguageClient) DocumentSymbols(ctx context.Context, uri string) ([]protocol.DocumentSymbol, error) {
 	// Try DocumentSymbol[] first (newer format)
 	var symbolResult []protocol.DocumentSymbol
-	err := lc.SendRequest("textDocument/documentSymbol", protocol.DocumentSymbolParams{
+	err := lc.SendRequest(ctx, "textDocument/documentSymbol", protocol.DocumentSymbolParams{
 		TextDocument: protocol.TextDocumentIdentifier{
 			Uri: protocol.DocumentUri(uri),
 		},
@@ -218,7 +314,7 @@ func (lc *LanguageClient) DocumentSymbols(uri string) ([]protocol.DocumentSymbol
 
 	// Fallback to SymbolInformation[] (older format)
 	var infoResult []protocol.SymbolInformation
-	err = lc.SendRequest("textDocument/documentSymbol", protocol.DocumentSymbolParams{
+	err = lc.SendRequest(ctx, "textDocument/documentSymbol", protocol.DocumentSymbolParams{
 		TextDocument: protocol.TextDocumentIdentifier{
 			Uri: protocol.DocumentUri(uri),
 		},
@@ -244,10 +340,10 @@ func (lc *LanguageClient) DocumentSymbols(uri string) ([]protocol.DocumentSymbol
 }
 
 // Implementation finds implementations of a symbol at a given position
-func (lc *LanguageClient) Implementation(uri string, line, character uint32) ([]protocol.Location, error) {
+func (lc *LanguageClient) Implementation(ctx context.Context, uri string, line, character uint32) ([]protocol.Location, error) {
 	var result []protocol.Location
 
-	err := lc.SendRequest("textDocument/implementation", protocol.ImplementationParams{
+	err := lc.SendRequest(ctx, "textDocument/implementation", protocol.ImplementationParams{
 		TextDocument: protocol.TextDocumentIdentifier{
 			Uri: protocol.DocumentUri(uri),
 		},
@@ -264,7 +360,7 @@ func (lc *LanguageClient) Implementation(uri string, line, character uint32) ([]
 }
 
 // SignatureHelp provides signature help at a given position
-func (lc *LanguageClient) SignatureHelp(uri string, line, character uint32) (*protocol.SignatureHelp, error) {
+func (lc *LanguageClient) SignatureHelp(ctx context.Context, uri string, line, character uint32) (*protocol.SignatureHelp, error) {
 	params := protocol.SignatureHelpParams{
 		TextDocument: protocol.TextDocumentIdentifier{
 			Uri: protocol.DocumentUri(uri),
@@ -277,7 +373,7 @@ func (lc *LanguageClient) SignatureHelp(uri string, line, character uint32) (*pr
 
 	var rawResponse json.RawMessage
 
-	err := lc.SendRequest("textDocument/signatureHelp", params, &rawResponse, 5*time.Second)
+	err := lc.SendRequest(ctx, "textDocument/signatureHelp", params, &rawResponse, 5*time.Second)
 	if err != nil {
 		return nil, err
 	}
@@ -297,7 +393,11 @@ func (lc *LanguageClient) SignatureHelp(uri string, line, character uint32) (*pr
 	return &result, nil
 }
 
-func (lc *LanguageClient) CodeActions(uri string, line, character, endLine, endCharacter uint32) ([]protocol.CodeAction, error) {
+// CodeActions requests textDocument/codeAction over [line,character) to
+// [endLine,endCharacter). only, when non-empty, is passed through as
+// CodeActionContext.Only so the server filters to just those kinds (e.g.
+// "refactor.rewrite.fillStruct") instead of returning its full menu.
+func (lc *LanguageClient) CodeActions(ctx context.Context, uri string, line, character, endLine, endCharacter uint32, only []protocol.CodeActionKind) ([]protocol.CodeAction, error) {
 
 	params := protocol.CodeActionParams{
 		TextDocument: protocol.TextDocumentIdentifier{Uri: protocol.DocumentUri(uri)},
@@ -306,13 +406,13 @@ func (lc *LanguageClient) CodeActions(uri string, line, character, endLine, endC
 			End:   protocol.Position{Line: endLine, Character: endCharacter},
 		},
 		Context: protocol.CodeActionContext{
-			// Context can be empty for general code actions
+			Only: only,
 		},
 	}
 
 	var result []protocol.CodeAction
 
-	err := lc.SendRequest("textDocument/codeAction", params, &result, 15*time.Second)
+	err := lc.SendRequest(ctx, "textDocument/codeAction", params, &result, 15*time.Second)
 	if err != nil {
 		return nil, fmt.Errorf("code action request failed: %w", err)
 	}
@@ -320,7 +420,33 @@ func (lc *LanguageClient) CodeActions(uri string, line, character, endLine, endC
 	return result, nil
 }
 
-func (lc *LanguageClient) Rename(uri string, line, character uint32, newName string) (*protocol.WorkspaceEdit, error) {
+// CodeLens requests textDocument/codeLens for uri, returning whatever
+// lenses the server currently computes for the whole document (code lens
+// ranges are scoped by the server, not the caller, unlike CodeActions).
+func (lc *LanguageClient) CodeLens(ctx context.Context, uri string) ([]protocol.CodeLens, error) {
+	if !lc.SupportsCodeLens() {
+		return nil, errUnsupported("textDocument/codeLens")
+	}
+
+	params := protocol.CodeLensParams{
+		TextDocument: protocol.TextDocumentIdentifier{Uri: protocol.DocumentUri(uri)},
+	}
+
+	var result []protocol.CodeLens
+
+	err := lc.SendRequest(ctx, "textDocument/codeLens", params, &result, 15*time.Second)
+	if err != nil {
+		return nil, fmt.Errorf("code lens request failed: %w", err)
+	}
+
+	return result, nil
+}
+
+func (lc *LanguageClient) Rename(ctx context.Context, uri string, line, character uint32, newName string) (*protocol.WorkspaceEdit, error) {
+	if !lc.SupportsRename() {
+		return nil, errUnsupported("textDocument/rename")
+	}
+
 	params := protocol.RenameParams{
 		TextDocument: protocol.TextDocumentIdentifier{Uri: protocol.DocumentUri(uri)},
 		Position: protocol.Position{
@@ -332,7 +458,7 @@ func (lc *LanguageClient) Rename(uri string, line, character uint32, newName str
 
 	var result protocol.WorkspaceEdit
 
-	err := lc.SendRequest("textDocument/rename", params, &result, 60*time.Second)
+	err := lc.SendRequest(ctx, "textDocument/rename", params, &result, 60*time.Second)
 	if err != nil {
 		return nil, fmt.Errorf("rename request failed: %w", err)
 	}
@@ -340,34 +466,44 @@ func (lc *LanguageClient) Rename(uri string, line, character uint32, newName str
 	return &result, nil
 }
 
-func (lc *LanguageClient) WorkspaceDiagnostic(identifier string) (*protocol.WorkspaceDiagnosticReport, error) {
+func (lc *LanguageClient) WorkspaceDiagnostic(ctx context.Context, identifier string) (*protocol.WorkspaceDiagnosticReport, error) {
+	if !lc.SupportsWorkspaceDiagnostic() {
+		return nil, errUnsupported("workspace/diagnostic")
+	}
+
+	cache := lc.diagnosticCache()
+
 	params := protocol.WorkspaceDiagnosticParams{
 		Identifier:        identifier,
-		PreviousResultIds: []protocol.PreviousResultId{}, // Empty for first request
+		PreviousResultIds: cache.previousWorkspaceResultIds(identifier),
+		WorkDoneToken:     newProgressToken(),
 	}
 
 	var result protocol.WorkspaceDiagnosticReport
 
-	err := lc.SendRequest("workspace/diagnostic", params, &result, 120*time.Second) // Extended timeout for large projects
+	err := lc.SendRequest(ctx, "workspace/diagnostic", params, &result, 120*time.Second) // Extended timeout for large projects
 	if err != nil {
 		return nil, fmt.Errorf("workspace diagnostic request failed: %w", err)
 	}
 
+	cache.reconcileWorkspaceReport(identifier, &result)
+
 	return &result, nil
 }
 
-func (lc *LanguageClient) Formatting(uri string, tabSize uint32, insertSpaces bool) ([]protocol.TextEdit, error) {
+func (lc *LanguageClient) Formatting(ctx context.Context, uri string, tabSize uint32, insertSpaces bool) ([]protocol.TextEdit, error) {
 	params := protocol.DocumentFormattingParams{
 		TextDocument: protocol.TextDocumentIdentifier{Uri: protocol.DocumentUri(uri)},
 		Options: protocol.FormattingOptions{
 			TabSize:      tabSize,
 			InsertSpaces: insertSpaces,
 		},
+		WorkDoneToken: newProgressToken(),
 	}
 
 	var result []protocol.TextEdit
 
-	err := lc.SendRequest("textDocument/formatting", params, &result, 90*time.Second)
+	err := lc.SendRequest(ctx, "textDocument/formatting", params, &result, 90*time.Second)
 	if err != nil {
 		return nil, fmt.Errorf("workspace diagnostic request failed: %w", err)
 	}
@@ -375,7 +511,7 @@ func (lc *LanguageClient) Formatting(uri string, tabSize uint32, insertSpaces bo
 	return result, nil
 }
 
-func (lc *LanguageClient) RangeFormatting(uri string, startLine, startCharacter, endLine, endCharacter uint32, tabSize uint32, insertSpaces bool) ([]protocol.TextEdit, error) {
+func (lc *LanguageClient) RangeFormatting(ctx context.Context, uri string, startLine, startCharacter, endLine, endCharacter uint32, tabSize uint32, insertSpaces bool) ([]protocol.TextEdit, error) {
 	params := protocol.DocumentRangeFormattingParams{
 		TextDocument: protocol.TextDocumentIdentifier{Uri: protocol.DocumentUri(uri)},
 		Range: protocol.Range{
@@ -390,7 +526,7 @@ func (lc *LanguageClient) RangeFormatting(uri string, startLine, startCharacter,
 
 	var result []protocol.TextEdit
 
-	err := lc.SendRequest("textDocument/rangeFormatting", params, &result, 30*time.Second)
+	err := lc.SendRequest(ctx, "textDocument/rangeFormatting", params, &result, 30*time.Second)
 	if err != nil {
 		return nil, fmt.Errorf("range formatting request failed: %w", err)
 	}
@@ -398,7 +534,11 @@ func (lc *LanguageClient) RangeFormatting(uri string, startLine, startCharacter,
 	return result, nil
 }
 
-func (lc *LanguageClient) PrepareRename(uri string, line, character uint32) (*protocol.PrepareRenameResult, error) {
+func (lc *LanguageClient) PrepareRename(ctx context.Context, uri string, line, character uint32) (*protocol.PrepareRenameResult, error) {
+	if !lc.SupportsPrepareRename() {
+		return nil, errUnsupported("textDocument/prepareRename")
+	}
+
 	params := protocol.PrepareRenameParams{
 		TextDocument: protocol.TextDocumentIdentifier{Uri: protocol.DocumentUri(uri)},
 		Position: protocol.Position{
@@ -409,7 +549,7 @@ func (lc *LanguageClient) PrepareRename(uri string, line, character uint32) (*pr
 
 	var result protocol.PrepareRenameResult
 
-	err := lc.SendRequest("textDocument/prepareRename", params, &result, 30*time.Second)
+	err := lc.SendRequest(ctx, "textDocument/prepareRename", params, &result, 30*time.Second)
 	if err != nil {
 		return nil, fmt.Errorf("prepare rename request failed: %w", err)
 	}
@@ -417,14 +557,18 @@ func (lc *LanguageClient) PrepareRename(uri string, line, character uint32) (*pr
 	return &result, nil
 }
 
-func (lc *LanguageClient) FoldingRange(uri string) ([]protocol.FoldingRange, error) {
+func (lc *LanguageClient) FoldingRange(ctx context.Context, uri string) ([]protocol.FoldingRange, error) {
+	if !lc.SupportsFoldingRange() {
+		return nil, errUnsupported("textDocument/foldingRange")
+	}
+
 	params := protocol.FoldingRangeParams{
 		TextDocument: protocol.TextDocumentIdentifier{Uri: protocol.DocumentUri(uri)},
 	}
 
 	var result []protocol.FoldingRange
 
-	err := lc.SendRequest("textDocument/foldingRange", params, &result, 30*time.Second)
+	err := lc.SendRequest(ctx, "textDocument/foldingRange", params, &result, 30*time.Second)
 	if err != nil {
 		return nil, fmt.Errorf("folding range request failed: %w", err)
 	}
@@ -432,7 +576,7 @@ func (lc *LanguageClient) FoldingRange(uri string) ([]protocol.FoldingRange, err
 	return result, nil
 }
 
-func (lc *LanguageClient) SelectionRange(uri string, positions []protocol.Position) ([]protocol.SelectionRange, error) {
+func (lc *LanguageClient) SelectionRange(ctx context.Context, uri string, positions []protocol.Position) ([]protocol.SelectionRange, error) {
 	params := protocol.SelectionRangeParams{
 		TextDocument: protocol.TextDocumentIdentifier{Uri: protocol.DocumentUri(uri)},
 		Positions:    positions,
@@ -440,7 +584,7 @@ func (lc *LanguageClient) SelectionRange(uri string, positions []protocol.Positi
 
 	var result []protocol.SelectionRange
 
-	err := lc.SendRequest("textDocument/selectionRange", params, &result, 30*time.Second)
+	err := lc.SendRequest(ctx, "textDocument/selectionRange", params, &result, 30*time.Second)
 	if err != nil {
 		return nil, fmt.Errorf("selection range request failed: %w", err)
 	}
@@ -448,14 +592,14 @@ func (lc *LanguageClient) SelectionRange(uri string, positions []protocol.Positi
 	return result, nil
 }
 
-func (lc *LanguageClient) DocumentLink(uri string) ([]protocol.DocumentLink, error) {
+func (lc *LanguageClient) DocumentLink(ctx context.Context, uri string) ([]protocol.DocumentLink, error) {
 	params := protocol.DocumentLinkParams{
 		TextDocument: protocol.TextDocumentIdentifier{Uri: protocol.DocumentUri(uri)},
 	}
 
 	var result []protocol.DocumentLink
 
-	err := lc.SendRequest("textDocument/documentLink", params, &result, 30*time.Second)
+	err := lc.SendRequest(ctx, "textDocument/documentLink", params, &result, 30*time.Second)
 	if err != nil {
 		return nil, fmt.Errorf("document link request failed: %w", err)
 	}
@@ -463,14 +607,18 @@ func (lc *LanguageClient) DocumentLink(uri string) ([]protocol.DocumentLink, err
 	return result, nil
 }
 
-func (lc *LanguageClient) DocumentColor(uri string) ([]protocol.ColorInformation, error) {
+func (lc *LanguageClient) DocumentColor(ctx context.Context, uri string) ([]protocol.ColorInformation, error) {
+	if !lc.SupportsColor() {
+		return nil, errUnsupported("textDocument/documentColor")
+	}
+
 	params := protocol.DocumentColorParams{
 		TextDocument: protocol.TextDocumentIdentifier{Uri: protocol.DocumentUri(uri)},
 	}
 
 	var result []protocol.ColorInformation
 
-	err := lc.SendRequest("textDocument/documentColor", params, &result, 30*time.Second)
+	err := lc.SendRequest(ctx, "textDocument/documentColor", params, &result, 30*time.Second)
 	if err != nil {
 		return nil, fmt.Errorf("document color request failed: %w", err)
 	}
@@ -478,7 +626,11 @@ func (lc *LanguageClient) DocumentColor(uri string) ([]protocol.ColorInformation
 	return result, nil
 }
 
-func (lc *LanguageClient) ColorPresentation(uri string, color protocol.Color, rng protocol.Range) ([]protocol.ColorPresentation, error) {
+func (lc *LanguageClient) ColorPresentation(ctx context.Context, uri string, color protocol.Color, rng protocol.Range) ([]protocol.ColorPresentation, error) {
+	if !lc.SupportsColor() {
+		return nil, errUnsupported("textDocument/colorPresentation")
+	}
+
 	params := protocol.ColorPresentationParams{
 		TextDocument: protocol.TextDocumentIdentifier{Uri: protocol.DocumentUri(uri)},
 		Color:        color,
@@ -487,7 +639,7 @@ func (lc *LanguageClient) ColorPresentation(uri string, color protocol.Color, rn
 
 	var result []protocol.ColorPresentation
 
-	err := lc.SendRequest("textDocument/colorPresentation", params, &result, 30*time.Second)
+	err := lc.SendRequest(ctx, "textDocument/colorPresentation", params, &result, 30*time.Second)
 	if err != nil {
 		return nil, fmt.Errorf("color presentation request failed: %w", err)
 	}
@@ -495,51 +647,101 @@ func (lc *LanguageClient) ColorPresentation(uri string, color protocol.Color, rn
 	return result, nil
 }
 
-func (lc *LanguageClient) ExecuteCommand(command string, args []any) (json.RawMessage, error) {
-	params := protocol.ExecuteCommandParams{
-		Command:   command,
-		Arguments: args,
+// requestPool lazily builds the LanguageClient's RequestPool on first use,
+// under DefaultRequestPoolConfig unless ConfigureRequestPool has already
+// run. Mirrors the lazy-init pattern used by diagnosticCache/progress.
+func (lc *LanguageClient) requestPool() *RequestPool {
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+
+	if lc.pool == nil {
+		lc.pool = NewRequestPool(DefaultRequestPoolConfig)
+	}
+	return lc.pool
+}
+
+// ConfigureRequestPool (re)configures the pool ExecuteCommand (and any
+// future pool-routed method) runs through. Must be called before the first
+// such call; replacing an already-running pool would strand tasks queued
+// against the old one, so this only sets it up once.
+func (lc *LanguageClient) ConfigureRequestPool(cfg RequestPoolConfig) {
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+
+	if lc.pool == nil {
+		lc.pool = NewRequestPool(cfg)
 	}
+}
 
-	var result json.RawMessage
+// PoolStats reports the current load on the request pool ExecuteCommand
+// runs through, for surfacing on a metrics/health endpoint the same way
+// sessionMetrics already does for SessionAdapter.
+func (lc *LanguageClient) PoolStats() RequestPoolStats {
+	return lc.requestPool().Stats()
+}
 
-	err := lc.SendRequest("workspace/executeCommand", params, &result, 30*time.Second)
+func (lc *LanguageClient) ExecuteCommand(ctx context.Context, command string, args []any) (json.RawMessage, error) {
+	value, err := lc.requestPool().Submit(ctx, command, func(ctx context.Context) (any, error) {
+		params := protocol.ExecuteCommandParams{
+			Command:   command,
+			Arguments: args,
+		}
+
+		var result json.RawMessage
+
+		if err := lc.SendRequest(ctx, "workspace/executeCommand", params, &result, 30*time.Second); err != nil {
+			return nil, fmt.Errorf("execute command request failed: %w", err)
+		}
+
+		return result, nil
+	})
 	if err != nil {
-		return nil, fmt.Errorf("execute command request failed: %w", err)
+		if errors.Is(err, ErrQueueFull) {
+			return nil, fmt.Errorf("execute command %q: %w", command, err)
+		}
+		return nil, err
+	}
+	if value == nil {
+		return nil, nil
 	}
 
-	return result, nil
+	return value.(json.RawMessage), nil
 }
 
-func (lc *LanguageClient) DidChangeWatchedFiles(changes []protocol.FileEvent) error {
+func (lc *LanguageClient) DidChangeWatchedFiles(ctx context.Context, changes []protocol.FileEvent) error {
 	params := protocol.DidChangeWatchedFilesParams{
 		Changes: changes,
 	}
 
-	return lc.SendNotification("workspace/didChangeWatchedFiles", params)
+	return lc.SendNotification(ctx, "workspace/didChangeWatchedFiles", params)
 }
 
-func (lc *LanguageClient) DidChangeConfiguration(settings any) error {
+func (lc *LanguageClient) DidChangeConfiguration(ctx context.Context, settings any) error {
 	params := protocol.DidChangeConfigurationParams{
 		Settings: settings,
 	}
 
-	return lc.SendNotification("workspace/didChangeConfiguration", params)
+	return lc.SendNotification(ctx, "workspace/didChangeConfiguration", params)
 }
 
-func (lc *LanguageClient) PrepareCallHierarchy(uri string, line, character uint32) ([]protocol.CallHierarchyItem, error) {
+func (lc *LanguageClient) PrepareCallHierarchy(ctx context.Context, uri string, line, character uint32) ([]protocol.CallHierarchyItem, error) {
+	if !lc.SupportsCallHierarchy() {
+		return nil, errUnsupported("textDocument/prepareCallHierarchy")
+	}
+
 	params := protocol.CallHierarchyPrepareParams{
 		TextDocument: protocol.TextDocumentIdentifier{Uri: protocol.DocumentUri(uri)},
 		Position: protocol.Position{
 			Line:      line,
 			Character: character,
 		},
+		WorkDoneToken: newProgressToken(),
 	}
 
 	var result []protocol.CallHierarchyItem
 
 	// BSL LS может долго индексировать проект; call hierarchy часто требует больше времени.
-	err := lc.SendRequest("textDocument/prepareCallHierarchy", params, &result, 60*time.Second)
+	err := lc.SendRequest(ctx, "textDocument/prepareCallHierarchy", params, &result, 60*time.Second)
 	if err != nil {
 		return nil, fmt.Errorf("prepare call hierarchy request failed: %w", err)
 	}
@@ -547,10 +749,10 @@ func (lc *LanguageClient) PrepareCallHierarchy(uri string, line, character uint3
 	return result, nil
 }
 
-func (lc *LanguageClient) SemanticTokens(uri string) (*protocol.SemanticTokens, error) {
+func (lc *LanguageClient) SemanticTokens(ctx context.Context, uri string) (*protocol.SemanticTokens, error) {
 	var rawResponse json.RawMessage
 
-	err := lc.SendRequest("textDocument/semanticTokens", protocol.SemanticTokensParams{
+	err := lc.SendRequest(ctx, "textDocument/semanticTokens", protocol.SemanticTokensParams{
 		TextDocument: protocol.TextDocumentIdentifier{
 			Uri: protocol.DocumentUri(uri),
 		},
@@ -573,10 +775,14 @@ func (lc *LanguageClient) SemanticTokens(uri string) (*protocol.SemanticTokens,
 	return &result, nil
 }
 
-func (lc *LanguageClient) SemanticTokensRange(uri string, startLine, startCharacter, endLine, endCharacter uint32) (*protocol.SemanticTokens, error) {
+func (lc *LanguageClient) SemanticTokensRange(ctx context.Context, uri string, startLine, startCharacter, endLine, endCharacter uint32) (*protocol.SemanticTokens, error) {
+	if !lc.SupportsSemanticTokensRange() {
+		return nil, errUnsupported("textDocument/semanticTokens/range")
+	}
+
 	var rawResponse json.RawMessage
 
-	err := lc.SendRequest("textDocument/semanticTokens/range", protocol.SemanticTokensRangeParams{
+	err := lc.SendRequest(ctx, "textDocument/semanticTokens/range", protocol.SemanticTokensRangeParams{
 		TextDocument: protocol.TextDocumentIdentifier{
 			Uri: protocol.DocumentUri(uri),
 		},
@@ -615,14 +821,14 @@ func (lc *LanguageClient) SemanticTokensRange(uri string, startLine, startCharac
 }
 
 // IncomingCalls retrieves incoming calls for a given Call Hierarchy Item
-func (lc *LanguageClient) IncomingCalls(item protocol.CallHierarchyItem) ([]protocol.CallHierarchyIncomingCall, error) {
+func (lc *LanguageClient) IncomingCalls(ctx context.Context, item protocol.CallHierarchyItem) ([]protocol.CallHierarchyIncomingCall, error) {
 	params := protocol.CallHierarchyIncomingCallsParams{
 		Item: item,
 	}
 
 	var result []protocol.CallHierarchyIncomingCall
 
-	err := lc.SendRequest("callHierarchy/incomingCalls", params, &result, 60*time.Second)
+	err := lc.SendRequest(ctx, "callHierarchy/incomingCalls", params, &result, 60*time.Second)
 	if err != nil {
 		return nil, fmt.Errorf("incoming calls request failed: %w", err)
 	}
@@ -631,14 +837,14 @@ func (lc *LanguageClient) IncomingCalls(item protocol.CallHierarchyItem) ([]prot
 }
 
 // OutgoingCalls retrieves outgoing calls for a given Call Hierarchy Item
-func (lc *LanguageClient) OutgoingCalls(item protocol.CallHierarchyItem) ([]protocol.CallHierarchyOutgoingCall, error) {
+func (lc *LanguageClient) OutgoingCalls(ctx context.Context, item protocol.CallHierarchyItem) ([]protocol.CallHierarchyOutgoingCall, error) {
 	params := protocol.CallHierarchyOutgoingCallsParams{
 		Item: item,
 	}
 
 	var result []protocol.CallHierarchyOutgoingCall
 
-	err := lc.SendRequest("callHierarchy/outgoingCalls", params, &result, 60*time.Second)
+	err := lc.SendRequest(ctx, "callHierarchy/outgoingCalls", params, &result, 60*time.Second)
 	if err != nil {
 		return nil, fmt.Errorf("outgoing calls request failed: %w", err)
 	}
@@ -647,7 +853,14 @@ func (lc *LanguageClient) OutgoingCalls(item protocol.CallHierarchyItem) ([]prot
 }
 
 // DocumentDiagnostics gets diagnostics for a specific document using LSP 3.17+ textDocument/diagnostic method
-func (lc *LanguageClient) DocumentDiagnostics(uri string, identifier string, previousResultId string) (*protocol.DocumentDiagnosticReport, error) {
+func (lc *LanguageClient) DocumentDiagnostics(ctx context.Context, uri string, identifier string, previousResultId string) (*protocol.DocumentDiagnosticReport, error) {
+	if !lc.SupportsDocumentDiagnostic() {
+		return nil, errUnsupported("textDocument/diagnostic")
+	}
+
+	cache := lc.diagnosticCache()
+	key := diagnosticCacheKey{identifier: identifier, uri: uri}
+
 	params := protocol.DocumentDiagnosticParams{
 		TextDocument: protocol.TextDocumentIdentifier{
 			Uri: protocol.DocumentUri(uri),
@@ -660,14 +873,106 @@ func (lc *LanguageClient) DocumentDiagnostics(uri string, identifier string, pre
 	}
 	if previousResultId != "" {
 		params.PreviousResultId = previousResultId
+	} else if cached := cache.previousResultId(key); cached != "" {
+		params.PreviousResultId = cached
 	}
 
 	var result protocol.DocumentDiagnosticReport
 
-	err := lc.SendRequest("textDocument/diagnostic", params, &result, 90*time.Second)
+	err := lc.SendRequest(ctx, "textDocument/diagnostic", params, &result, 90*time.Second)
 	if err != nil {
 		return nil, fmt.Errorf("document diagnostic request failed: %w", err)
 	}
 
+	cache.reconcileDocumentReport(key, &result)
+
 	return &result, nil
 }
+
+// diagnosticCache lazily creates the pull-diagnostic cache, the same way
+// WithProgress lazily creates the progress tracker.
+func (lc *LanguageClient) diagnosticCache() *diagnosticCache {
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+	if lc.diagCache == nil {
+		lc.diagCache = newDiagnosticCache()
+	}
+	return lc.diagCache
+}
+
+// InvalidateDiagnostics drops any cached textDocument/diagnostic and
+// workspace/diagnostic results for uri, so the next pull-diagnostic call
+// re-fetches a full report instead of asking the server to diff against a
+// stale resultId. Call this whenever uri's content changes out from under
+// a previously cached report (DidChange/DidSave/DidClose already do; the
+// MCP layer should call it too for edits applied via other tools, e.g.
+// rename or code actions).
+func (lc *LanguageClient) InvalidateDiagnostics(uri string) {
+	lc.diagnosticCache().invalidate(uri)
+}
+
+// Diagnostics returns the most recent textDocument/publishDiagnostics
+// payload the server pushed for uri, or nil if the server hasn't pushed
+// any yet (or doesn't support push diagnostics).
+func (lc *LanguageClient) Diagnostics(uri string) []protocol.Diagnostic {
+	if lc.handler == nil {
+		return nil
+	}
+	return lc.handler.Diagnostics(uri)
+}
+
+// AllDiagnostics returns the most recent textDocument/publishDiagnostics
+// payload for every URI the server has reported on, keyed by URI.
+func (lc *LanguageClient) AllDiagnostics() map[string]DiagnosticEntry {
+	if lc.handler == nil {
+		return nil
+	}
+	return lc.handler.AllDiagnostics()
+}
+
+// WaitForDiagnostics blocks until the server publishes diagnostics for uri
+// newer than lastSeenVersion, ctx is done, or a report newer than
+// lastSeenVersion is already cached. Use this after an edit to learn once
+// the server has actually reacted to it, instead of polling Diagnostics.
+func (lc *LanguageClient) WaitForDiagnostics(ctx context.Context, uri string, lastSeenVersion int32) (DiagnosticEntry, error) {
+	if lc.handler == nil {
+		return DiagnosticEntry{}, fmt.Errorf("language client has no handler installed")
+	}
+	return lc.handler.WaitForDiagnostics(ctx, uri, lastSeenVersion)
+}
+
+// SetApplyEditHandler registers fn to decide whether a workspace/applyEdit
+// request from the server should be applied. See ClientHandler.SetApplyEditHandler.
+func (lc *LanguageClient) SetApplyEditHandler(fn func(protocol.ApplyWorkspaceEditParams) bool) {
+	if lc.handler == nil {
+		return
+	}
+	lc.handler.SetApplyEditHandler(fn)
+}
+
+// Registered reports whether the server has dynamically registered
+// interest in method via client/registerCapability.
+func (lc *LanguageClient) Registered(method string) bool {
+	if lc.handler == nil {
+		return false
+	}
+	return lc.handler.Registered(method)
+}
+
+// Registration returns the server's dynamic registration for method,
+// including its RegisterOptions. See ClientHandler.Registration.
+func (lc *LanguageClient) Registration(method string) (protocol.Registration, bool) {
+	if lc.handler == nil {
+		return protocol.Registration{}, false
+	}
+	return lc.handler.Registration(method)
+}
+
+// SetConfigProvider installs provider to answer this server's
+// workspace/configuration requests. See ClientHandler.SetConfigProvider.
+func (lc *LanguageClient) SetConfigProvider(provider *ServerConfigProvider) {
+	if lc.handler == nil {
+		return
+	}
+	lc.handler.SetConfigProvider(provider)
+}
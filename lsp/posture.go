@@ -0,0 +1,245 @@
+package lsp
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultProbeTimeout bounds a posture check's version-probe subprocess
+// (stdio mode) or TCP dial (websocket/tcp mode) when PostureConfig.ProbeTimeoutMs
+// is unset.
+const DefaultProbeTimeout = 5 * time.Second
+
+const defaultVersionArg = "--version"
+
+var defaultVersionRegex = regexp.MustCompile(`(\d+\.\d+(?:\.\d+)?)`)
+
+// PostureResult is the outcome of a single RunPostureCheck call, recorded so
+// it can be surfaced by the language_server_posture tool and by
+// bridge.MCPLSPBridge.PostureReports.
+type PostureResult struct {
+	Server          string    `json:"server"`
+	CheckedAt       time.Time `json:"checked_at"`
+	Passed          bool      `json:"passed"`
+	Error           string    `json:"error,omitempty"`
+	ResolvedPath    string    `json:"resolved_path,omitempty"`
+	DetectedVersion string    `json:"detected_version,omitempty"`
+}
+
+// RunPostureCheck pre-flight checks a language server's binary (stdio mode)
+// or endpoint (websocket/tcp mode) before it's spawned/dialed. cfg.Posture
+// being nil is equivalent to &PostureConfig{RequireExists: true,
+// RequireExecutable: true} — the version/hash checks are opt-in.
+func RunPostureCheck(serverName string, cfg LanguageServerConfig) PostureResult {
+	result := PostureResult{Server: serverName, CheckedAt: time.Now()}
+
+	posture := cfg.Posture
+	if posture == nil {
+		posture = &PostureConfig{RequireExists: true, RequireExecutable: true}
+	}
+
+	var err error
+	switch {
+	case cfg.IsWebSocketMode(), cfg.IsTCPMode():
+		err = checkNetworkPosture(cfg, posture, &result)
+	default:
+		err = checkStdioPosture(cfg, posture, &result)
+	}
+
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	result.Passed = true
+	return result
+}
+
+func checkStdioPosture(cfg LanguageServerConfig, posture *PostureConfig, result *PostureResult) error {
+	path, lookErr := exec.LookPath(cfg.Command)
+	if lookErr != nil {
+		if posture.RequireExists {
+			return fmt.Errorf("command %q not found: %w", cfg.Command, lookErr)
+		}
+		return nil
+	}
+	result.ResolvedPath = path
+
+	info, statErr := os.Stat(path)
+	if statErr != nil {
+		return fmt.Errorf("stat %q: %w", path, statErr)
+	}
+	if posture.RequireExecutable && info.Mode()&0o111 == 0 {
+		return fmt.Errorf("command %q is not executable", path)
+	}
+
+	if posture.CommandSHA256 != "" {
+		sum, hashErr := sha256File(path)
+		if hashErr != nil {
+			return fmt.Errorf("hash %q: %w", path, hashErr)
+		}
+		if !strings.EqualFold(sum, posture.CommandSHA256) {
+			return fmt.Errorf("command %q sha256 %s does not match pinned %s", path, sum, posture.CommandSHA256)
+		}
+	}
+
+	if posture.MinVersion != "" {
+		version, probeErr := probeVersion(path, posture)
+		if probeErr != nil {
+			return fmt.Errorf("probe version of %q: %w", path, probeErr)
+		}
+		result.DetectedVersion = version
+		ok, cmpErr := versionAtLeast(version, posture.MinVersion)
+		if cmpErr != nil {
+			return fmt.Errorf("compare detected version %q to min %q: %w", version, posture.MinVersion, cmpErr)
+		}
+		if !ok {
+			return fmt.Errorf("command %q version %s is below minimum %s", path, version, posture.MinVersion)
+		}
+	}
+
+	return nil
+}
+
+func checkNetworkPosture(cfg LanguageServerConfig, posture *PostureConfig, result *PostureResult) error {
+	if cfg.Host == "" {
+		return fmt.Errorf("no host configured")
+	}
+
+	timeout := probeTimeout(posture)
+
+	if _, err := net.LookupHost(cfg.Host); err != nil {
+		return fmt.Errorf("lookup %q: %w", cfg.Host, err)
+	}
+
+	addr := net.JoinHostPort(cfg.Host, strconv.Itoa(cfg.Port))
+	conn, err := net.DialTimeout("tcp", addr, timeout)
+	if err != nil {
+		return fmt.Errorf("dial %q: %w", addr, err)
+	}
+	conn.Close()
+
+	result.ResolvedPath = addr
+	return nil
+}
+
+func probeTimeout(posture *PostureConfig) time.Duration {
+	if posture.ProbeTimeoutMs > 0 {
+		return time.Duration(posture.ProbeTimeoutMs) * time.Millisecond
+	}
+	return DefaultProbeTimeout
+}
+
+func probeVersion(path string, posture *PostureConfig) (string, error) {
+	arg := posture.VersionArg
+	if arg == "" {
+		arg = defaultVersionArg
+	}
+	re := defaultVersionRegex
+	if posture.VersionRegex != "" {
+		compiled, err := regexp.Compile(posture.VersionRegex)
+		if err != nil {
+			return "", fmt.Errorf("compile version regex %q: %w", posture.VersionRegex, err)
+		}
+		re = compiled
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), probeTimeout(posture))
+	defer cancel()
+
+	out, err := exec.CommandContext(ctx, path, arg).CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("run %q %q: %w", path, arg, err)
+	}
+
+	match := re.FindStringSubmatch(string(out))
+	if len(match) < 2 {
+		return "", fmt.Errorf("no version found in output of %q %q", path, arg)
+	}
+	return match[1], nil
+}
+
+// versionAtLeast compares two dotted version strings (e.g. "1.12.3")
+// component-wise; missing trailing components compare as 0. No third-party
+// semver library is used since this snapshot has no go.mod to add one to.
+func versionAtLeast(version, min string) (bool, error) {
+	vParts, err := splitVersion(version)
+	if err != nil {
+		return false, err
+	}
+	mParts, err := splitVersion(min)
+	if err != nil {
+		return false, err
+	}
+
+	for i := 0; i < len(vParts) || i < len(mParts); i++ {
+		var v, m int
+		if i < len(vParts) {
+			v = vParts[i]
+		}
+		if i < len(mParts) {
+			m = mParts[i]
+		}
+		if v != m {
+			return v > m, nil
+		}
+	}
+	return true, nil
+}
+
+func splitVersion(version string) ([]int, error) {
+	fields := strings.Split(version, ".")
+	parts := make([]int, len(fields))
+	for i, f := range fields {
+		n, err := strconv.Atoi(strings.TrimSpace(f))
+		if err != nil {
+			return nil, fmt.Errorf("invalid version component %q in %q: %w", f, version, err)
+		}
+		parts[i] = n
+	}
+	return parts, nil
+}
+
+// CheckPosture runs RunPostureCheck for serverName/cfg and records the
+// result as lc's most recent posture check (see LastPosture).
+func (lc *LanguageClient) CheckPosture(serverName string, cfg LanguageServerConfig) PostureResult {
+	result := RunPostureCheck(serverName, cfg)
+
+	lc.postureMu.Lock()
+	lc.lastPosture = result
+	lc.postureMu.Unlock()
+
+	return result
+}
+
+// LastPosture returns the most recent posture check recorded by
+// CheckPosture, or the zero value if none has run yet.
+func (lc *LanguageClient) LastPosture() PostureResult {
+	lc.postureMu.Lock()
+	defer lc.postureMu.Unlock()
+	return lc.lastPosture
+}
+
+func sha256File(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -1,6 +1,9 @@
 package lsp
 
 import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"sync"
@@ -19,8 +22,31 @@ type ProgressEvent struct {
 	Cancellable *bool
 	Time        time.Time
 	Raw         json.RawMessage
+
+	// Seq is a per-tracker monotonic sequence number assigned by dispatch,
+	// used to resume a stream without re-fetching everything - see
+	// ReplayFrom and Handler's `since` query param.
+	Seq uint64
+}
+
+// ProgressStats summarizes a tracker's retained event history for status
+// tooling - see Stats.
+type ProgressStats struct {
+	TokensSeen          int
+	ActiveCount         int
+	AvgDurationPerTitle map[string]time.Duration
 }
 
+const (
+	// defaultProgressHistoryCapacity bounds how many events a
+	// ProgressTracker retains for History/Stats/ReplayFrom, oldest evicted
+	// first. See SetHistoryLimits to reconfigure.
+	defaultProgressHistoryCapacity = 1024
+	// defaultProgressHistoryTTL bounds how long a retained event survives
+	// regardless of capacity. See SetHistoryLimits.
+	defaultProgressHistoryTTL = 10 * time.Minute
+)
+
 // ProgressSnapshot is returned to status tooling.
 type ProgressSnapshot struct {
 	Active        []ProgressEvent
@@ -31,17 +57,184 @@ type ProgressSnapshot struct {
 // ProgressTracker tracks server-initiated workDone progress streams.
 // It is fed by notifications like $/progress.
 type ProgressTracker struct {
-	mu     sync.RWMutex
-	active map[string]ProgressEvent
-	last   *ProgressEvent
+	mu          sync.RWMutex
+	active      map[string]ProgressEvent
+	last        *ProgressEvent
+	subscribers map[string]chan ProgressEvent
+	cancels     map[string]context.CancelFunc
+
+	// allSubscribers backs SubscribeAll (every token, not just one) - the
+	// fan-out Handler's SSE stream reads from.
+	allSubscribers map[int]chan ProgressEvent
+	nextSubID      int
+
+	// history is a capacity- and TTL-bounded ring of every dispatched
+	// event (not just "end" ones - a begin/report with no matching end is
+	// itself forensically useful), oldest first. See recordHistoryLocked,
+	// History, Stats, ReplayFrom.
+	historyCap int
+	historyTTL time.Duration
+	history    []ProgressEvent
+	nextSeq    uint64
 }
 
 func NewProgressTracker() *ProgressTracker {
 	return &ProgressTracker{
-		active: make(map[string]ProgressEvent),
+		active:         make(map[string]ProgressEvent),
+		subscribers:    make(map[string]chan ProgressEvent),
+		cancels:        make(map[string]context.CancelFunc),
+		allSubscribers: make(map[int]chan ProgressEvent),
+		historyCap:     defaultProgressHistoryCapacity,
+		historyTTL:     defaultProgressHistoryTTL,
+	}
+}
+
+// SetHistoryLimits reconfigures the retained-event ring buffer's capacity
+// and TTL (see History, Stats, ReplayFrom). Call it before progress traffic
+// starts if the defaults (1024 events / 10 minutes) don't fit a
+// deployment's retention needs. Existing history is trimmed to the new
+// capacity immediately but never re-extended if the new capacity is
+// larger.
+func (pt *ProgressTracker) SetHistoryLimits(capacity int, ttl time.Duration) {
+	pt.mu.Lock()
+	defer pt.mu.Unlock()
+
+	pt.historyCap = capacity
+	pt.historyTTL = ttl
+	if capacity > 0 && len(pt.history) > capacity {
+		pt.history = pt.history[len(pt.history)-capacity:]
+	}
+}
+
+// SubscribeAll returns a channel that receives every ProgressEvent
+// dispatched for any token, until the returned unsubscribe func is called -
+// the multi-token counterpart to Subscribe, backing Handler's SSE stream.
+// Buffered and non-blocking for the same reason Subscribe is: a slow
+// consumer must never stall notification dispatch.
+func (pt *ProgressTracker) SubscribeAll() (<-chan ProgressEvent, func()) {
+	ch := make(chan ProgressEvent, 64)
+
+	pt.mu.Lock()
+	id := pt.nextSubID
+	pt.nextSubID++
+	pt.allSubscribers[id] = ch
+	pt.mu.Unlock()
+
+	unsubscribe := func() {
+		pt.mu.Lock()
+		if _, ok := pt.allSubscribers[id]; ok {
+			delete(pt.allSubscribers, id)
+			close(ch)
+		}
+		pt.mu.Unlock()
+	}
+
+	return ch, unsubscribe
+}
+
+// registerCancel records cancel as the way to abort the request identified
+// by tokenKey, so a caller elsewhere (e.g. the cancel_in_flight MCP tool)
+// can stop it without holding a reference to its context. It returns an
+// unregister func the request's own goroutine should defer, so the entry
+// doesn't outlive the request it cancels.
+func (pt *ProgressTracker) registerCancel(tokenKey string, cancel context.CancelFunc) func() {
+	pt.mu.Lock()
+	pt.cancels[tokenKey] = cancel
+	pt.mu.Unlock()
+
+	return func() {
+		pt.mu.Lock()
+		delete(pt.cancels, tokenKey)
+		pt.mu.Unlock()
+	}
+}
+
+// Cancel aborts the in-flight request registered under tokenKey, if any is
+// still outstanding, by cancelling its context - which in turn makes
+// LanguageClient.SendRequest send a $/cancelRequest for it. Returns false if
+// no request is registered under that token (already finished, or the
+// token is unknown).
+func (pt *ProgressTracker) Cancel(tokenKey string) bool {
+	pt.mu.Lock()
+	cancel, ok := pt.cancels[tokenKey]
+	pt.mu.Unlock()
+
+	if !ok {
+		return false
+	}
+	cancel()
+	return true
+}
+
+// Subscribe returns a channel that receives every ProgressEvent reported for
+// tokenKey (see progressTokenKey) until the returned unsubscribe func is
+// called. The channel is buffered so Update never blocks on a slow
+// consumer; callers that care about every event should drain it promptly.
+func (pt *ProgressTracker) Subscribe(tokenKey string) (<-chan ProgressEvent, func()) {
+	ch := make(chan ProgressEvent, 32)
+
+	pt.mu.Lock()
+	pt.subscribers[tokenKey] = ch
+	pt.mu.Unlock()
+
+	unsubscribe := func() {
+		pt.mu.Lock()
+		if pt.subscribers[tokenKey] == ch {
+			delete(pt.subscribers, tokenKey)
+			close(ch)
+		}
+		pt.mu.Unlock()
+	}
+
+	return ch, unsubscribe
+}
+
+// WaitDone blocks until an "end" event for tokenKey is observed, or ctx is
+// done, whichever comes first. If tokenKey has no active entry (see
+// Snapshot) when WaitDone is called, it returns immediately with the zero
+// ProgressEvent and no error - there's nothing in flight to wait for, the
+// same convention Subscribe's caller has to handle anyway since a token
+// can legitimately end between a status check and the subscribe call.
+func (pt *ProgressTracker) WaitDone(ctx context.Context, tokenKey string) (ProgressEvent, error) {
+	pt.mu.RLock()
+	_, active := pt.active[tokenKey]
+	pt.mu.RUnlock()
+	if !active {
+		return ProgressEvent{}, nil
+	}
+
+	ch, unsubscribe := pt.Subscribe(tokenKey)
+	defer unsubscribe()
+
+	for {
+		select {
+		case ev, ok := <-ch:
+			if !ok {
+				return ProgressEvent{}, ctx.Err()
+			}
+			if ev.Kind == "end" {
+				return ev, nil
+			}
+		case <-ctx.Done():
+			return ProgressEvent{}, ctx.Err()
+		}
 	}
 }
 
+// NewSyntheticProgressToken generates a fresh token key for Emit, for
+// bridge-side operations that have no real LSP request/token behind them
+// (e.g. a workspace-wide fan-out across many textDocument/* requests) but
+// still want their throughput visible the same way a server-reported
+// $/progress stream is, via lsp_status/progress_stream. It deliberately
+// returns a string, not a protocol.ProgressToken: unlike newProgressToken,
+// this token is never sent to a server, so it has no wire representation to
+// round-trip.
+func NewSyntheticProgressToken() string {
+	buf := make([]byte, 16)
+	_, _ = rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
+
 func progressTokenKey(t protocol.ProgressToken) string {
 	switch v := t.Value.(type) {
 	case int32:
@@ -104,23 +297,188 @@ func (pt *ProgressTracker) Update(params protocol.ProgressParams) {
 		ev.Kind = "unknown"
 	}
 
-	pt.mu.Lock()
-	defer pt.mu.Unlock()
+	pt.dispatch(ev)
+}
+
+// Emit records a synthetic progress event under tokenKey - one with no real
+// $/progress notification behind it - for a bridge-side operation that
+// wants its throughput visible through the same lsp_status/progress_stream
+// path a server-reported stream would use (see NewSyntheticProgressToken).
+// kind should be "begin", "report", or "end" per the usual $/progress
+// lifecycle.
+func (pt *ProgressTracker) Emit(tokenKey, kind, title, message string, percentage *uint32) {
+	pt.dispatch(ProgressEvent{
+		TokenKey:   tokenKey,
+		Kind:       kind,
+		Title:      title,
+		Message:    message,
+		Percentage: percentage,
+		Time:       time.Now(),
+	})
+}
 
+// dispatch stores ev as the tracker's latest/active state, records it in
+// the retained history, and fans it out to ev's token's subscriber, if any,
+// and to every SubscribeAll subscriber. Shared tail of Update (decoded from a
+// real $/progress notification) and Emit (a synthetic event).
+func (pt *ProgressTracker) dispatch(ev ProgressEvent) {
+	pt.mu.Lock()
+	pt.nextSeq++
+	ev.Seq = pt.nextSeq
 	pt.last = &ev
+	pt.recordHistoryLocked(ev)
 
 	switch ev.Kind {
 	case "begin", "report":
 		// Keep most recent event per token.
-		pt.active[key] = ev
+		pt.active[ev.TokenKey] = ev
 	case "end":
-		delete(pt.active, key)
+		delete(pt.active, ev.TokenKey)
 	default:
 		// Keep it in active only if we already had it.
-		if _, ok := pt.active[key]; ok {
-			pt.active[key] = ev
+		if _, ok := pt.active[ev.TokenKey]; ok {
+			pt.active[ev.TokenKey] = ev
+		}
+	}
+
+	sub, ok := pt.subscribers[ev.TokenKey]
+	all := make([]chan ProgressEvent, 0, len(pt.allSubscribers))
+	for _, ch := range pt.allSubscribers {
+		all = append(all, ch)
+	}
+	pt.mu.Unlock()
+
+	if ok {
+		select {
+		case sub <- ev:
+		default:
+			// Subscriber isn't keeping up; drop rather than block the
+			// notification dispatch goroutine.
+		}
+	}
+	for _, ch := range all {
+		select {
+		case ch <- ev:
+		default:
+		}
+	}
+}
+
+// recordHistoryLocked appends ev to the retained-event ring buffer,
+// trimming it to historyCap and evicting anything older than historyTTL.
+// Callers must hold pt.mu.
+func (pt *ProgressTracker) recordHistoryLocked(ev ProgressEvent) {
+	pt.history = append(pt.history, ev)
+	if pt.historyCap > 0 && len(pt.history) > pt.historyCap {
+		pt.history = pt.history[len(pt.history)-pt.historyCap:]
+	}
+	pt.pruneExpiredLocked(ev.Time)
+}
+
+// pruneExpiredLocked drops every retained event older than historyTTL
+// relative to now. Callers must hold pt.mu.
+func (pt *ProgressTracker) pruneExpiredLocked(now time.Time) {
+	if pt.historyTTL <= 0 {
+		return
+	}
+	cutoff := now.Add(-pt.historyTTL)
+	i := 0
+	for i < len(pt.history) && pt.history[i].Time.Before(cutoff) {
+		i++
+	}
+	if i > 0 {
+		pt.history = pt.history[i:]
+	}
+}
+
+// History returns a copy of every retained event at or after since,
+// optionally restricted to one tokenKey (pass "" to return every token).
+// Events older than historyTTL or evicted by historyCap are no longer
+// available - see SetHistoryLimits.
+func (pt *ProgressTracker) History(since time.Time, tokenKey string) []ProgressEvent {
+	pt.mu.Lock()
+	pt.pruneExpiredLocked(time.Now())
+	defer pt.mu.Unlock()
+
+	out := make([]ProgressEvent, 0, len(pt.history))
+	for _, ev := range pt.history {
+		if ev.Time.Before(since) {
+			continue
+		}
+		if tokenKey != "" && ev.TokenKey != tokenKey {
+			continue
+		}
+		out = append(out, ev)
+	}
+	return out
+}
+
+// ReplayFrom returns every retained event with a sequence number greater
+// than seq, in order - the resume primitive behind Handler's `since` query
+// param and the progress_history tool's catch-up use case, so a client
+// that was briefly disconnected doesn't have to re-fetch everything.
+func (pt *ProgressTracker) ReplayFrom(seq uint64) []ProgressEvent {
+	pt.mu.Lock()
+	pt.pruneExpiredLocked(time.Now())
+	defer pt.mu.Unlock()
+
+	out := make([]ProgressEvent, 0, len(pt.history))
+	for _, ev := range pt.history {
+		if ev.Seq > seq {
+			out = append(out, ev)
 		}
 	}
+	return out
+}
+
+// Stats summarizes the tracker's currently-active streams and retained
+// history: how many distinct tokens have been seen, how many are active
+// right now, and the average begin-to-end duration per progress title
+// (e.g. "Indexing") among completed streams still in the retained window.
+func (pt *ProgressTracker) Stats() ProgressStats {
+	pt.mu.Lock()
+	pt.pruneExpiredLocked(time.Now())
+	active := len(pt.active)
+	hist := make([]ProgressEvent, len(pt.history))
+	copy(hist, pt.history)
+	pt.mu.Unlock()
+
+	tokensSeen := make(map[string]struct{})
+	begins := make(map[string]ProgressEvent)
+	durations := make(map[string][]time.Duration)
+
+	for _, ev := range hist {
+		tokensSeen[ev.TokenKey] = struct{}{}
+
+		switch ev.Kind {
+		case "begin":
+			begins[ev.TokenKey] = ev
+		case "end":
+			if b, ok := begins[ev.TokenKey]; ok {
+				title := b.Title
+				if title == "" {
+					title = ev.Title
+				}
+				durations[title] = append(durations[title], ev.Time.Sub(b.Time))
+				delete(begins, ev.TokenKey)
+			}
+		}
+	}
+
+	avg := make(map[string]time.Duration, len(durations))
+	for title, ds := range durations {
+		var sum time.Duration
+		for _, d := range ds {
+			sum += d
+		}
+		avg[title] = sum / time.Duration(len(ds))
+	}
+
+	return ProgressStats{
+		TokensSeen:          len(tokensSeen),
+		ActiveCount:         active,
+		AvgDurationPerTitle: avg,
+	}
 }
 
 func (pt *ProgressTracker) Snapshot() ProgressSnapshot {
@@ -0,0 +1,80 @@
+package lsp
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+// Handler returns an http.Handler streaming this tracker's progress events
+// as Server-Sent Events, so a dashboard can tail $/progress traffic instead
+// of polling Snapshot/History. Like SessionAdapter.MetricsHandler, mounting
+// it on a live mux is left to the embedding binary - the MCP transport in
+// this tree doesn't run an HTTP server of its own.
+//
+// GET /progress/stream?since=<seq> first replays every retained event with
+// a sequence number greater than since (0 replays the whole retained
+// history - see ReplayFrom), then streams new events as they're dispatched,
+// one `data: <json ProgressEvent>` message per event, until the client
+// disconnects.
+func (pt *ProgressTracker) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+			return
+		}
+
+		since := uint64(0)
+		if s := r.URL.Query().Get("since"); s != "" {
+			if parsed, err := strconv.ParseUint(s, 10, 64); err == nil {
+				since = parsed
+			}
+		}
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("Connection", "keep-alive")
+		w.WriteHeader(http.StatusOK)
+
+		writeEvent := func(ev ProgressEvent) bool {
+			raw, err := json.Marshal(ev)
+			if err != nil {
+				return true
+			}
+			if _, err := fmt.Fprintf(w, "id: %d\ndata: %s\n\n", ev.Seq, raw); err != nil {
+				return false
+			}
+			flusher.Flush()
+			return true
+		}
+
+		for _, ev := range pt.ReplayFrom(since) {
+			if !writeEvent(ev) {
+				return
+			}
+			since = ev.Seq
+		}
+
+		ch, unsubscribe := pt.SubscribeAll()
+		defer unsubscribe()
+
+		for {
+			select {
+			case ev, ok := <-ch:
+				if !ok {
+					return
+				}
+				if ev.Seq <= since {
+					continue
+				}
+				if !writeEvent(ev) {
+					return
+				}
+			case <-r.Context().Done():
+				return
+			}
+		}
+	})
+}
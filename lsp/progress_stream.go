@@ -0,0 +1,279 @@
+package lsp
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/myleshyson/lsprotocol-go/protocol"
+)
+
+// newProgressToken generates a fresh, unique workDoneToken to hand the
+// server for a single long-running request. It's opaque to the server;
+// only used as a correlation key for the $/progress notifications it
+// reports back against this token.
+func newProgressToken() protocol.ProgressToken {
+	buf := make([]byte, 16)
+	_, _ = rand.Read(buf)
+	return protocol.ProgressToken{Value: hex.EncodeToString(buf)}
+}
+
+// WithProgress generates a workDoneToken, subscribes to $/progress events
+// reported against it, and invokes fn with a ctx derived from the given
+// one, the token (to set on the request's WorkDoneToken field), and the
+// event channel. The channel is closed and the subscription torn down once
+// fn returns, so fn must not retain it afterward.
+//
+// fn must issue its request with the ctx it's given, not the outer one:
+// that's what makes the request cancellable by token afterward, via
+// CancelProgress/ProgressTracker.Cancel, independent of whatever ctx the
+// original caller happens to hold onto.
+func (lc *LanguageClient) WithProgress(ctx context.Context, fn func(ctx context.Context, token protocol.ProgressToken, events <-chan ProgressEvent) error) error {
+	lc.mu.Lock()
+	if lc.progress == nil {
+		lc.progress = NewProgressTracker()
+	}
+	progress := lc.progress
+	lc.mu.Unlock()
+
+	token := newProgressToken()
+	tokenKey := progressTokenKey(token)
+	events, unsubscribe := progress.Subscribe(tokenKey)
+	defer unsubscribe()
+
+	cancelCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	unregisterCancel := progress.registerCancel(tokenKey, cancel)
+	defer unregisterCancel()
+
+	return fn(cancelCtx, token, events)
+}
+
+// ProgressSnapshot reports the currently-active workDone progress streams
+// this client has seen $/progress notifications for, for status tooling
+// (see BuildLSPStatus) and the cancel_in_flight/live progress MCP tools.
+func (lc *LanguageClient) ProgressSnapshot() ProgressSnapshot {
+	lc.mu.Lock()
+	progress := lc.progress
+	lc.mu.Unlock()
+
+	if progress == nil {
+		return ProgressSnapshot{}
+	}
+	return progress.Snapshot()
+}
+
+// Progress returns this client's ProgressTracker, creating one (the same
+// lazy init WithProgress itself does) if no progress activity has been
+// seen yet. Exposed for tools that need to Subscribe/WaitDone on a token
+// directly (see mcpserver/tools/progress_stream.go) rather than only
+// polling ProgressSnapshot.
+func (lc *LanguageClient) Progress() *ProgressTracker {
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+	if lc.progress == nil {
+		lc.progress = NewProgressTracker()
+	}
+	return lc.progress
+}
+
+// CancelProgress aborts the in-flight request that was issued through
+// WithProgress using the progress token identified by tokenKey (see
+// progressTokenKey), if one is still outstanding. Returns false if no such
+// request is registered (already finished, or an unknown token).
+func (lc *LanguageClient) CancelProgress(tokenKey string) bool {
+	lc.mu.Lock()
+	progress := lc.progress
+	lc.mu.Unlock()
+
+	if progress == nil {
+		return false
+	}
+	return progress.Cancel(tokenKey)
+}
+
+// CancelWorkDoneProgress sends window/workDoneProgress/cancel to the
+// server for the workDone progress identified by tokenKey (as reported in
+// ProgressSnapshot/LSPStatus.Activity). Unlike CancelProgress, which only
+// aborts a request this client itself issued through WithProgress, this
+// asks the server to stop work it started on its own - e.g. a long
+// indexing pass reported without this client ever having sent the request
+// behind it, which CancelProgress has no cancel func registered for.
+func (lc *LanguageClient) CancelWorkDoneProgress(ctx context.Context, tokenKey string) error {
+	return lc.SendNotification(ctx, "window/workDoneProgress/cancel", protocol.WorkDoneProgressCancelParams{
+		Token: tokenFromKey(tokenKey),
+	})
+}
+
+// tokenFromKey reverses progressTokenKey's string-keying well enough to
+// re-send a token to the server: numeric keys (progressTokenKey formats
+// int32 tokens as plain digits) are parsed back to int32 so the JSON value
+// round-trips as a number, not a quoted string the server won't match;
+// anything else is assumed to have been a string token already.
+func tokenFromKey(tokenKey string) protocol.ProgressToken {
+	if n, err := strconv.ParseInt(tokenKey, 10, 32); err == nil {
+		return protocol.ProgressToken{Value: int32(n)}
+	}
+	return protocol.ProgressToken{Value: tokenKey}
+}
+
+// WorkspaceDiagnosticStream behaves like WorkspaceDiagnostic but additionally
+// invokes onPartial for every WorkspaceDiagnosticReportPartialResult the
+// server streams against the partialResultToken before the final report
+// arrives, per the LSP 3.17 partial-result protocol. onPartial may be nil.
+//
+// The workDoneToken and partialResultToken are set to the same generated
+// token: both arrive as $/progress notifications and ProgressTracker only
+// keys events by token, so a shared token is sufficient to demultiplex
+// workDone begin/report/end from partial-result payloads (the latter carry
+// no "kind" field and decode as ProgressEvent.Kind == "unknown").
+func (lc *LanguageClient) WorkspaceDiagnosticStream(ctx context.Context, identifier string, onPartial func(protocol.WorkspaceDiagnosticReportPartialResult)) (*protocol.WorkspaceDiagnosticReport, error) {
+	if !lc.SupportsWorkspaceDiagnostic() {
+		return nil, errUnsupported("workspace/diagnostic")
+	}
+
+	cache := lc.diagnosticCache()
+
+	var result *protocol.WorkspaceDiagnosticReport
+	var requestErr error
+
+	err := lc.WithProgress(ctx, func(ctx context.Context, token protocol.ProgressToken, events <-chan ProgressEvent) error {
+		done := make(chan struct{})
+		stop := make(chan struct{})
+
+		if onPartial != nil {
+			go func() {
+				defer close(done)
+				for {
+					ev, ok := ProgressEvent{}, false
+					select {
+					case ev, ok = <-events:
+					case <-stop:
+						select {
+						case ev, ok = <-events:
+						default:
+						}
+					}
+					if !ok {
+						return
+					}
+					if ev.Kind != "unknown" || len(ev.Raw) == 0 {
+						continue
+					}
+
+					var partial protocol.WorkspaceDiagnosticReportPartialResult
+					if err := json.Unmarshal(ev.Raw, &partial); err == nil {
+						onPartial(partial)
+					}
+				}
+			}()
+		} else {
+			close(done)
+		}
+
+		params := protocol.WorkspaceDiagnosticParams{
+			Identifier:         identifier,
+			PreviousResultIds:  cache.previousWorkspaceResultIds(identifier),
+			WorkDoneToken:      token,
+			PartialResultToken: token,
+		}
+
+		var report protocol.WorkspaceDiagnosticReport
+		requestErr = lc.SendRequest(ctx, "workspace/diagnostic", params, &report, 120*time.Second)
+		if requestErr == nil {
+			cache.reconcileWorkspaceReport(identifier, &report)
+			result = &report
+		}
+
+		close(stop)
+		if onPartial != nil {
+			<-done
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if requestErr != nil {
+		return nil, fmt.Errorf("workspace diagnostic request failed: %w", requestErr)
+	}
+
+	return result, nil
+}
+
+// WorkspaceDiagnosticWithProgress behaves like WorkspaceDiagnostic, but
+// additionally calls onStart with the generated workDoneToken's key (see
+// progressTokenKey) as soon as the request is issued - before this function
+// returns, since the request itself can run for minutes on a large
+// workspace - so a caller can hand that key to something like a
+// cancel_in_flight tool, and invokes onProgress for every begin/report/end
+// $/progress notification the server sends against it. Either callback may
+// be nil.
+func (lc *LanguageClient) WorkspaceDiagnosticWithProgress(ctx context.Context, identifier string, onStart func(tokenKey string), onProgress func(ProgressEvent)) (*protocol.WorkspaceDiagnosticReport, error) {
+	if !lc.SupportsWorkspaceDiagnostic() {
+		return nil, errUnsupported("workspace/diagnostic")
+	}
+
+	cache := lc.diagnosticCache()
+
+	var result protocol.WorkspaceDiagnosticReport
+
+	err := lc.WithProgress(ctx, func(ctx context.Context, token protocol.ProgressToken, events <-chan ProgressEvent) error {
+		if onStart != nil {
+			onStart(progressTokenKey(token))
+		}
+
+		done := make(chan struct{})
+		stop := make(chan struct{})
+		if onProgress != nil {
+			go func() {
+				defer close(done)
+				for {
+					ev, ok := ProgressEvent{}, false
+					select {
+					case ev, ok = <-events:
+					case <-stop:
+						select {
+						case ev, ok = <-events:
+						default:
+						}
+					}
+					if !ok {
+						return
+					}
+					if ev.Kind == "unknown" {
+						continue
+					}
+					onProgress(ev)
+				}
+			}()
+		} else {
+			close(done)
+		}
+
+		params := protocol.WorkspaceDiagnosticParams{
+			Identifier:        identifier,
+			PreviousResultIds: cache.previousWorkspaceResultIds(identifier),
+			WorkDoneToken:     token,
+		}
+
+		requestErr := lc.SendRequest(ctx, "workspace/diagnostic", params, &result, 120*time.Second)
+		close(stop)
+		if onProgress != nil {
+			<-done
+		}
+		return requestErr
+	})
+	if err != nil {
+		return nil, fmt.Errorf("workspace diagnostic request failed: %w", err)
+	}
+
+	cache.reconcileWorkspaceReport(identifier, &result)
+
+	return &result, nil
+}
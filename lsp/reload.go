@@ -0,0 +1,181 @@
+package lsp
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"time"
+
+	"rockerboo/mcp-lsp-bridge/logger"
+	"rockerboo/mcp-lsp-bridge/types"
+
+	"github.com/myleshyson/lsprotocol-go/protocol"
+)
+
+// DefaultHammerTime is how long Reload lets a superseded connection drain
+// in-flight requests before it's killed, if neither the client nor newCfg
+// override it.
+const DefaultHammerTime = 30 * time.Second
+
+// connectionSnapshot captures the transport-level state Reload swaps out,
+// so the superseded connection can keep draining in-flight requests and
+// then be torn down independently of the LanguageClient that replaced it.
+type connectionSnapshot struct {
+	conn    types.LSPConnectionInterface
+	cancel  context.CancelFunc
+	tcpConn net.Conn
+}
+
+// Reload performs a graceful restart of this client's connection: it
+// builds and initializes a brand new LanguageClient for newCfg in parallel
+// with the current one, and only swaps it in once that handshake
+// succeeds. The superseded connection is given hammerTime (from newCfg,
+// falling back to DefaultHammerTime) to let any already-dispatched
+// requests finish before it's closed; new calls made through this
+// LanguageClient after Reload returns are served by the new connection.
+//
+// If dialing or initializing the replacement fails, the existing
+// connection is left completely untouched and the error is returned, so a
+// bad config edit never drops a working server. Before any of that, newCfg
+// is posture-checked (see RunPostureCheck); a failing posture check aborts
+// the reload the same way a failed dial does.
+func (lc *LanguageClient) Reload(ctx context.Context, newCfg LanguageServerConfig) error {
+	lc.reloadMu.Lock()
+	defer lc.reloadMu.Unlock()
+
+	if posture := lc.CheckPosture(newCfg.Command, newCfg); !posture.Passed {
+		return fmt.Errorf("reload: posture check failed, keeping existing connection: %s", posture.Error)
+	}
+
+	replacement, err := connectForConfig(newCfg)
+	if err != nil {
+		return fmt.Errorf("reload: failed to start replacement connection: %w", err)
+	}
+
+	lc.mu.RLock()
+	capabilities := lc.clientCapabilities
+	lc.mu.RUnlock()
+
+	if _, err := replacement.Initialize(ctx, protocol.InitializeParams{Capabilities: capabilities}); err != nil {
+		replacement.Shutdown(ctx)
+		return fmt.Errorf("reload: initialize on replacement failed, keeping existing connection: %w", err)
+	}
+	if err := replacement.Initialized(ctx); err != nil {
+		replacement.Shutdown(ctx)
+		return fmt.Errorf("reload: initialized notification on replacement failed, keeping existing connection: %w", err)
+	}
+
+	hammerTime := time.Duration(newCfg.HammerTimeMs) * time.Millisecond
+	if hammerTime <= 0 {
+		hammerTime = lc.hammerTime
+	}
+	if hammerTime <= 0 {
+		hammerTime = DefaultHammerTime
+	}
+
+	old := lc.swapConnection(replacement, newCfg)
+
+	logger.Info(fmt.Sprintf("Reload: swapped to new connection for %q, draining old connection for %s", newCfg.Command, hammerTime))
+
+	go drainAndClose(old, hammerTime)
+
+	return nil
+}
+
+// swapConnection atomically moves replacement's live transport fields onto
+// lc and returns a snapshot of what lc held before the swap. The caches
+// (progress tracker, diagnostic cache, semantic tokens, request pool) stay
+// on lc across the swap - they're keyed by document/request, not by
+// connection. The diagnostic cache and the semantic-token resultIds are
+// invalidated, since resultIds the old server handed out are meaningless
+// to the freshly started one; the progress tracker and request pool are
+// left as they are.
+func (lc *LanguageClient) swapConnection(replacement *LanguageClient, newCfg LanguageServerConfig) connectionSnapshot {
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+
+	old := connectionSnapshot{
+		conn:    lc.conn,
+		cancel:  lc.cancel,
+		tcpConn: lc.tcpConn,
+	}
+
+	lc.conn = replacement.conn
+	lc.ctx = replacement.ctx
+	lc.cancel = replacement.cancel
+	lc.cmd = replacement.cmd
+	lc.handler = replacement.handler
+	lc.serverCapabilities = replacement.serverCapabilities
+	lc.rawServerCapabilities = replacement.rawServerCapabilities
+	lc.command = newCfg.Command
+	lc.args = newCfg.Args
+	lc.tcpAddress = replacement.tcpAddress
+	lc.tcpConn = replacement.tcpConn
+	lc.wsURL = replacement.wsURL
+	lc.wsRWC = replacement.wsRWC
+	lc.status = StatusConnected
+	lc.lastInitialized = time.Now()
+
+	if lc.diagCache != nil {
+		lc.diagCache.invalidateAll()
+	}
+	lc.semTokMu.Lock()
+	lc.semTokResults = nil
+	lc.semTokMu.Unlock()
+
+	return old
+}
+
+// drainAndClose waits hammerTime for requests already dispatched against
+// old's connection to finish, then cancels its context and closes its
+// transport. It runs detached from Reload's caller since the whole point
+// is to not block the reload on the drain.
+func drainAndClose(old connectionSnapshot, hammerTime time.Duration) {
+	time.Sleep(hammerTime)
+
+	if old.cancel != nil {
+		old.cancel()
+	}
+	if old.conn != nil {
+		old.conn.Close()
+	}
+	if old.tcpConn != nil {
+		old.tcpConn.Close()
+	}
+}
+
+// connectForConfig dials and wires up (but does not initialize) a fresh
+// LanguageClient for cfg, dispatching on its transport mode the same way
+// GetClientForLanguage's connect path does. Stdio mode isn't supported here
+// yet: this package has no process-spawning connect path to mirror.
+func connectForConfig(cfg LanguageServerConfig) (*LanguageClient, error) {
+	var client *LanguageClient
+	var err error
+
+	switch {
+	case cfg.IsWebSocketMode():
+		client, err = NewWebSocketLanguageClientWithConfig(WebSocketConfig{
+			Host: cfg.GetHost(),
+			Port: cfg.GetPort(),
+		})
+		if err != nil {
+			return nil, err
+		}
+		client, err = client.ConnectWebSocket()
+	case cfg.IsTCPMode():
+		client, err = NewTCPLanguageClient(cfg.GetHost(), cfg.GetPort())
+		if err != nil {
+			return nil, err
+		}
+		client, err = client.ConnectTCP()
+	default:
+		return nil, fmt.Errorf("reload: stdio mode has no graceful-restart connect path yet")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	client.SetConfigProvider(NewServerConfigProvider(cfg))
+
+	return client, nil
+}
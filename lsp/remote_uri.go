@@ -0,0 +1,29 @@
+package lsp
+
+import (
+	"rockerboo/mcp-lsp-bridge/utils"
+)
+
+// ResolveDocumentURI translates uri into one the language server can
+// actually open. BSL LS (and every other server this bridge drives) only
+// ever speaks file://, so a non-file URI (sftp://, memfs://, ...) is
+// mirrored into a scratch file via its utils.URIResolver and the resulting
+// file:// URI is returned instead. Plain file:// URIs and local paths pass
+// through untouched, with a no-op cleanup.
+//
+// Callers must invoke cleanup once they're done driving the server against
+// localURI (typically right after the didOpen/request that needed it),
+// since it removes any scratch copy this created.
+func ResolveDocumentURI(uri string) (localURI string, cleanup func(), err error) {
+	resolver, err := utils.ResolverFor(uri)
+	if err != nil {
+		return "", nil, err
+	}
+
+	path, cleanup, err := resolver.ToLocal(uri)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return utils.FilePathToURI(path), cleanup, nil
+}
@@ -0,0 +1,266 @@
+package lsp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrQueueFull is returned by RequestPool.Submit when the shard a task was
+// routed to is already at RequestPoolConfig.QueueLength and the submit
+// timeout elapses before a slot opens up.
+var ErrQueueFull = errors.New("request pool: queue full")
+
+// RequestPoolConfig controls a RequestPool's concurrency and queueing
+// behavior. Zero-valued fields are filled in from DefaultRequestPoolConfig
+// by NewRequestPool.
+type RequestPoolConfig struct {
+	// PoolSize bounds how many tasks may run concurrently across every
+	// shard combined.
+	PoolSize int
+	// QueueLength bounds how many pending tasks a single shard's queue may
+	// hold before Submit starts returning ErrQueueFull.
+	QueueLength int
+	// Shards is the number of independent FIFO queues tasks are hashed
+	// across by shard key. More shards reduce head-of-line blocking between
+	// unrelated keys (e.g. different document URIs) at the cost of a
+	// looser global FIFO ordering.
+	Shards int
+	// SubmitTimeout bounds how long Submit waits for queue space before
+	// giving up with ErrQueueFull. Zero is replaced by
+	// DefaultRequestPoolConfig.SubmitTimeout, like the other fields.
+	SubmitTimeout time.Duration
+}
+
+// DefaultRequestPoolConfig is used for any RequestPoolConfig field left at
+// its zero value.
+var DefaultRequestPoolConfig = RequestPoolConfig{
+	PoolSize:      8,
+	QueueLength:   64,
+	Shards:        4,
+	SubmitTimeout: 5 * time.Second,
+}
+
+// RequestPoolConfigFrom merges global and per-server pool settings into a
+// RequestPoolConfig, the same override precedence GlobalConfig/
+// LanguageServerConfig already use elsewhere: a non-zero per-server field
+// wins, otherwise the global value is used, otherwise
+// DefaultRequestPoolConfig.
+func RequestPoolConfigFrom(global GlobalConfig, server LanguageServerConfig) RequestPoolConfig {
+	cfg := RequestPoolConfig{
+		PoolSize:      firstNonZero(server.TaskPoolSize, global.TaskPoolSize),
+		QueueLength:   firstNonZero(server.TaskQueueLength, global.TaskQueueLength),
+		Shards:        firstNonZero(server.TaskQueueNumber, global.TaskQueueNumber),
+		SubmitTimeout: time.Duration(firstNonZero(server.SubmitTimeoutMs, global.SubmitTimeoutMs)) * time.Millisecond,
+	}
+	return cfg
+}
+
+func firstNonZero(values ...int) int {
+	for _, v := range values {
+		if v != 0 {
+			return v
+		}
+	}
+	return 0
+}
+
+// requestTask is one unit of work queued on a RequestPool shard.
+type requestTask struct {
+	ctx    context.Context
+	fn     func(ctx context.Context) (any, error)
+	result chan requestResult
+}
+
+// requestResult is the outcome delivered back to the Submit caller.
+type requestResult struct {
+	value any
+	err   error
+}
+
+// RequestPool bounds how many LSP requests a LanguageClient may have
+// in flight at once and how deep each pending queue may grow, so a burst of
+// callers (e.g. a workspace-wide rename fan-out or a flood of
+// execute_command calls) can't pile up unboundedly outstanding requests
+// against a single language server process. Tasks are hashed by a
+// caller-supplied shard key onto one of Shards independent FIFO queues,
+// then dequeued and run against a pool-wide semaphore capped at PoolSize.
+type RequestPool struct {
+	cfg    RequestPoolConfig
+	queues []chan requestTask
+	sem    chan struct{}
+	wg     sync.WaitGroup
+
+	closeOnce sync.Once
+	closeCh   chan struct{}
+
+	// closeMu guards Close's queue-closing against a concurrent Submit's
+	// `queue <- task` send: Submit holds the read lock for the duration of
+	// its enqueue attempt (so unrelated Submits still run concurrently),
+	// Close takes the write lock - which can't be acquired until every
+	// in-flight enqueue attempt has finished - before closing any queue
+	// and before any later Submit can reach a send at all (it bails out on
+	// the `closed` check immediately after acquiring its read lock).
+	closeMu sync.RWMutex
+	closed  bool
+
+	inFlight int64 // atomic
+	rejected int64 // atomic
+
+	latency *latencyHistogram
+}
+
+// NewRequestPool starts a RequestPool with cfg, filling any zero-valued
+// field from DefaultRequestPoolConfig. A worker goroutine per shard drains
+// that shard's queue in order until Close is called.
+func NewRequestPool(cfg RequestPoolConfig) *RequestPool {
+	if cfg.PoolSize == 0 {
+		cfg.PoolSize = DefaultRequestPoolConfig.PoolSize
+	}
+	if cfg.QueueLength == 0 {
+		cfg.QueueLength = DefaultRequestPoolConfig.QueueLength
+	}
+	if cfg.Shards == 0 {
+		cfg.Shards = DefaultRequestPoolConfig.Shards
+	}
+	if cfg.SubmitTimeout == 0 {
+		cfg.SubmitTimeout = DefaultRequestPoolConfig.SubmitTimeout
+	}
+
+	p := &RequestPool{
+		cfg:     cfg,
+		queues:  make([]chan requestTask, cfg.Shards),
+		sem:     make(chan struct{}, cfg.PoolSize),
+		closeCh: make(chan struct{}),
+		latency: newLatencyHistogram(),
+	}
+
+	for i := range p.queues {
+		p.queues[i] = make(chan requestTask, cfg.QueueLength)
+		p.wg.Add(1)
+		go p.run(p.queues[i])
+	}
+
+	return p
+}
+
+// run drains queue until it's closed, executing each task under the
+// pool-wide semaphore.
+func (p *RequestPool) run(queue chan requestTask) {
+	defer p.wg.Done()
+
+	for task := range queue {
+		p.sem <- struct{}{}
+		atomic.AddInt64(&p.inFlight, 1)
+
+		start := time.Now()
+		value, err := task.fn(task.ctx)
+		p.latency.observe(time.Since(start).Seconds())
+
+		atomic.AddInt64(&p.inFlight, -1)
+		<-p.sem
+
+		task.result <- requestResult{value: value, err: err}
+	}
+}
+
+// shardFor hashes key onto one of the pool's queues with FNV-1a, so the
+// same shard key (e.g. a document URI) always lands on the same queue and
+// keeps its requests in submission order relative to each other.
+func (p *RequestPool) shardFor(key string) chan requestTask {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(key))
+	return p.queues[h.Sum32()%uint32(len(p.queues))]
+}
+
+// Submit enqueues fn onto the shard for key and blocks until it runs and
+// returns, ctx is cancelled, or the shard's queue stays full for longer
+// than cfg.SubmitTimeout (returning ErrQueueFull). fn receives task's ctx,
+// not the pool's, so cancellation/timeouts propagate the same way a direct
+// SendRequest call would.
+func (p *RequestPool) Submit(ctx context.Context, key string, fn func(ctx context.Context) (any, error)) (any, error) {
+	p.closeMu.RLock()
+	if p.closed {
+		p.closeMu.RUnlock()
+		return nil, fmt.Errorf("request pool: closed")
+	}
+
+	task := requestTask{ctx: ctx, fn: fn, result: make(chan requestResult, 1)}
+	queue := p.shardFor(key)
+
+	timer := time.NewTimer(p.cfg.SubmitTimeout)
+
+	select {
+	case queue <- task:
+		timer.Stop()
+		p.closeMu.RUnlock()
+	case <-ctx.Done():
+		timer.Stop()
+		p.closeMu.RUnlock()
+		return nil, ctx.Err()
+	case <-p.closeCh:
+		timer.Stop()
+		p.closeMu.RUnlock()
+		return nil, fmt.Errorf("request pool: closed")
+	case <-timer.C:
+		p.closeMu.RUnlock()
+		atomic.AddInt64(&p.rejected, 1)
+		return nil, ErrQueueFull
+	}
+
+	select {
+	case res := <-task.result:
+		return res.value, res.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// RequestPoolStats is a point-in-time snapshot of a RequestPool's load.
+type RequestPoolStats struct {
+	QueueDepth []int
+	InFlight   int64
+	Rejected   int64
+	P50        time.Duration
+	P99        time.Duration
+}
+
+// Stats returns a snapshot of current queue depths, in-flight count,
+// lifetime rejections, and observed latency quantiles.
+func (p *RequestPool) Stats() RequestPoolStats {
+	depths := make([]int, len(p.queues))
+	for i, q := range p.queues {
+		depths[i] = len(q)
+	}
+
+	return RequestPoolStats{
+		QueueDepth: depths,
+		InFlight:   atomic.LoadInt64(&p.inFlight),
+		Rejected:   atomic.LoadInt64(&p.rejected),
+		P50:        time.Duration(p.latency.quantile(0.50) * float64(time.Second)),
+		P99:        time.Duration(p.latency.quantile(0.99) * float64(time.Second)),
+	}
+}
+
+// Close stops accepting new work and waits for every shard worker to drain
+// its queue and exit. Submit calls racing with Close either complete
+// normally or observe closeCh/closed and return an error; already-queued
+// tasks still run to completion. See closeMu's doc comment for why closing
+// the shard queues here can never race with a concurrent Submit's send.
+func (p *RequestPool) Close() {
+	p.closeOnce.Do(func() {
+		close(p.closeCh)
+
+		p.closeMu.Lock()
+		p.closed = true
+		for _, q := range p.queues {
+			close(q)
+		}
+		p.closeMu.Unlock()
+	})
+	p.wg.Wait()
+}
@@ -0,0 +1,81 @@
+package lsp
+
+import (
+	"github.com/myleshyson/lsprotocol-go/protocol"
+)
+
+// SemanticToken is one decoded entry from a textDocument/semanticTokens
+// response: an absolute line/character/length plus the resolved type and
+// modifier names, after undoing the LSP spec's delta encoding.
+type SemanticToken struct {
+	Line      uint32
+	Char      uint32
+	Length    uint32
+	Type      string
+	Modifiers []string
+}
+
+// semanticTokensParser decodes the packed uint32 data array the LSP spec
+// uses for semantic tokens responses, resolving the tokenType/tokenModifiers
+// indices against the legend the server advertised in ServerCapabilities.
+type semanticTokensParser struct {
+	legend protocol.SemanticTokensLegend
+}
+
+func newSemanticTokensParser(legend protocol.SemanticTokensLegend) *semanticTokensParser {
+	return &semanticTokensParser{legend: legend}
+}
+
+// Parse decodes data (five uint32s per token: deltaLine, deltaStartChar,
+// length, tokenType, tokenModifiers bitmap) into absolute-position tokens.
+// A non-zero deltaLine resets char to deltaStartChar; a zero deltaLine
+// means the token is on the same line as the previous one, so char
+// accumulates deltaStartChar instead, per the LSP semantic tokens spec.
+func (p *semanticTokensParser) Parse(data []uint32) []SemanticToken {
+	tokens := make([]SemanticToken, 0, len(data)/5)
+
+	var line, char uint32
+	for i := 0; i+4 < len(data); i += 5 {
+		deltaLine := data[i]
+		deltaStartChar := data[i+1]
+		length := data[i+2]
+		tokenType := data[i+3]
+		tokenModifiers := data[i+4]
+
+		if deltaLine > 0 {
+			line += deltaLine
+			char = deltaStartChar
+		} else {
+			char += deltaStartChar
+		}
+
+		tokens = append(tokens, SemanticToken{
+			Line:      line,
+			Char:      char,
+			Length:    length,
+			Type:      p.tokenType(tokenType),
+			Modifiers: p.tokenModifiers(tokenModifiers),
+		})
+	}
+
+	return tokens
+}
+
+func (p *semanticTokensParser) tokenType(index uint32) string {
+	if int(index) < len(p.legend.TokenTypes) {
+		return p.legend.TokenTypes[index]
+	}
+	return ""
+}
+
+// tokenModifiers decodes the tokenModifiers bitmap: bit i set means
+// legend.TokenModifiers[i] applies to the token.
+func (p *semanticTokensParser) tokenModifiers(bitmap uint32) []string {
+	var modifiers []string
+	for i, name := range p.legend.TokenModifiers {
+		if bitmap&(1<<uint(i)) != 0 {
+			modifiers = append(modifiers, name)
+		}
+	}
+	return modifiers
+}
@@ -0,0 +1,196 @@
+package lsp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"rockerboo/mcp-lsp-bridge/types"
+
+	"github.com/myleshyson/lsprotocol-go/protocol"
+)
+
+// semanticTokensCacheEntry remembers the last semantic tokens response
+// decoded for a uri, so a later SemanticTokensDelta call can splice the
+// server's edits against raw data without re-fetching the full document.
+type semanticTokensCacheEntry struct {
+	resultId string
+	data     []uint32
+}
+
+// SetupSemanticTokens fetches the SemanticTokensLegend the server
+// advertised in ServerCapabilities and builds the parser SemanticTokensFull
+// and SemanticTokensDelta use to decode responses. Must be called after
+// Initialize; SemanticTokensFull/SemanticTokensDelta return
+// ErrUnsupported until it has.
+func (lc *LanguageClient) SetupSemanticTokens() error {
+	legend, ok := lc.semanticTokensLegend()
+	if !ok {
+		return fmt.Errorf("language client: server did not advertise a semantic tokens legend")
+	}
+
+	lc.mu.Lock()
+	lc.tokenParser = newSemanticTokensParser(legend)
+	lc.mu.Unlock()
+
+	return nil
+}
+
+// semanticTokensLegend probes the raw ServerCapabilities JSON captured at
+// Initialize for semanticTokensProvider.legend, since
+// ServerCapabilities.SemanticTokensProvider is a boolean-or-options union
+// the generated protocol types don't unwrap (see capabilityField).
+func (lc *LanguageClient) semanticTokensLegend() (protocol.SemanticTokensLegend, bool) {
+	raw, ok := lc.capabilityField("semanticTokensProvider")
+	if !ok {
+		return protocol.SemanticTokensLegend{}, false
+	}
+
+	var probe struct {
+		Legend protocol.SemanticTokensLegend `json:"legend"`
+	}
+	if err := json.Unmarshal(raw, &probe); err != nil || len(probe.Legend.TokenTypes) == 0 {
+		return protocol.SemanticTokensLegend{}, false
+	}
+
+	return probe.Legend, true
+}
+
+// TokenParser returns the parser SetupSemanticTokens built, or nil if
+// SetupSemanticTokens hasn't run yet (or the server doesn't support
+// semantic tokens).
+func (lc *LanguageClient) TokenParser() types.SemanticTokensParserProvider {
+	lc.mu.RLock()
+	defer lc.mu.RUnlock()
+	return lc.tokenParser
+}
+
+// SemanticTokensFull fetches the full set of semantic tokens for uri,
+// decodes them against the negotiated legend, and caches the raw data plus
+// resultId so a later SemanticTokensDelta call for the same uri only ships
+// the lines that actually changed.
+func (lc *LanguageClient) SemanticTokensFull(ctx context.Context, uri string) ([]SemanticToken, error) {
+	parser := lc.TokenParser()
+	if parser == nil {
+		return nil, errUnsupported("textDocument/semanticTokens/full (no legend negotiated)")
+	}
+
+	var result protocol.SemanticTokens
+
+	err := lc.SendRequest(ctx, "textDocument/semanticTokens/full", protocol.SemanticTokensParams{
+		TextDocument:  protocol.TextDocumentIdentifier{Uri: protocol.DocumentUri(uri)},
+		WorkDoneToken: newProgressToken(),
+	}, &result, 60*time.Second)
+	if err != nil {
+		return nil, fmt.Errorf("semantic tokens full request failed: %w", err)
+	}
+
+	lc.setSemanticTokensCache(uri, result.ResultId, result.Data)
+
+	return parser.Parse(result.Data), nil
+}
+
+// SemanticTokensDelta re-tokenizes uri against the resultId cached from the
+// previous SemanticTokensFull/SemanticTokensDelta call for it, so a 10k-LOC
+// module only ships the changed lines instead of the whole document. If
+// nothing has been cached yet it falls back to SemanticTokensFull. The
+// server may still respond with a full SemanticTokens result instead of a
+// delta (both are valid per the LSP spec); either way the decoded tokens
+// reflect the document's current state.
+func (lc *LanguageClient) SemanticTokensDelta(ctx context.Context, uri string) ([]SemanticToken, error) {
+	parser := lc.TokenParser()
+	if parser == nil {
+		return nil, errUnsupported("textDocument/semanticTokens/full/delta (no legend negotiated)")
+	}
+
+	previous, ok := lc.semanticTokensCache(uri)
+	if !ok {
+		return lc.SemanticTokensFull(ctx, uri)
+	}
+
+	var rawResponse json.RawMessage
+
+	err := lc.SendRequest(ctx, "textDocument/semanticTokens/full/delta", protocol.SemanticTokensDeltaParams{
+		TextDocument:     protocol.TextDocumentIdentifier{Uri: protocol.DocumentUri(uri)},
+		PreviousResultId: previous.resultId,
+		WorkDoneToken:    newProgressToken(),
+	}, &rawResponse, 60*time.Second)
+	if err != nil {
+		return nil, fmt.Errorf("semantic tokens delta request failed: %w", err)
+	}
+
+	// The response is SemanticTokens | SemanticTokensDelta; only the delta
+	// shape carries "edits", so probe for that before picking which to
+	// unmarshal into (same pattern as the union probes in capabilities.go).
+	var probe struct {
+		Edits json.RawMessage `json:"edits"`
+	}
+	if err := json.Unmarshal(rawResponse, &probe); err != nil {
+		return nil, fmt.Errorf("failed to probe semantic tokens delta response: %w", err)
+	}
+
+	if probe.Edits == nil {
+		var full protocol.SemanticTokens
+		if err := json.Unmarshal(rawResponse, &full); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal semantic tokens: %w", err)
+		}
+		lc.setSemanticTokensCache(uri, full.ResultId, full.Data)
+		return parser.Parse(full.Data), nil
+	}
+
+	var delta protocol.SemanticTokensDelta
+	if err := json.Unmarshal(rawResponse, &delta); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal semantic tokens delta: %w", err)
+	}
+
+	data := applySemanticTokensEdits(previous.data, delta.Edits)
+	lc.setSemanticTokensCache(uri, delta.ResultId, data)
+
+	return parser.Parse(data), nil
+}
+
+// applySemanticTokensEdits splices delta.Edits (Start, DeleteCount, Data)
+// into the previously cached raw token array, per the
+// textDocument/semanticTokens/full/delta response shape in the LSP spec.
+// Edits are applied in order against the growing result, matching how a
+// real editor would replay them.
+func applySemanticTokensEdits(data []uint32, edits []protocol.SemanticTokensEdit) []uint32 {
+	for _, edit := range edits {
+		start := int(edit.Start)
+		if start > len(data) {
+			start = len(data)
+		}
+		end := start + int(edit.DeleteCount)
+		if end > len(data) {
+			end = len(data)
+		}
+
+		spliced := make([]uint32, 0, len(data)-(end-start)+len(edit.Data))
+		spliced = append(spliced, data[:start]...)
+		spliced = append(spliced, edit.Data...)
+		spliced = append(spliced, data[end:]...)
+		data = spliced
+	}
+
+	return data
+}
+
+func (lc *LanguageClient) semanticTokensCache(uri string) (semanticTokensCacheEntry, bool) {
+	lc.semTokMu.Lock()
+	defer lc.semTokMu.Unlock()
+	entry, ok := lc.semTokResults[uri]
+	return entry, ok
+}
+
+func (lc *LanguageClient) setSemanticTokensCache(uri string, resultId *string, data []uint32) {
+	if resultId == nil {
+		return
+	}
+	lc.semTokMu.Lock()
+	defer lc.semTokMu.Unlock()
+	if lc.semTokResults == nil {
+		lc.semTokResults = make(map[string]semanticTokensCacheEntry)
+	}
+	lc.semTokResults[uri] = semanticTokensCacheEntry{resultId: *resultId, data: data}
+}
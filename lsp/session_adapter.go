@@ -8,7 +8,11 @@ package lsp
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"rockerboo/mcp-lsp-bridge/logger"
@@ -17,23 +21,79 @@ import (
 	"github.com/myleshyson/lsprotocol-go/protocol"
 )
 
+// sessionState tracks the handshake lifecycle, mirroring gopls'
+// serverInitializing/serverInitialized distinction: methods that need a
+// live server (Hover, Definition, ...) must refuse to run before
+// Initialize has completed rather than silently returning nil.
+type sessionState int32
+
+const (
+	sessionNotInitialized sessionState = iota
+	sessionInitializing
+	sessionInitialized
+)
+
+// ErrNotInitialized is returned by SessionAdapter methods that require a
+// completed Initialize handshake when called too early.
+var ErrNotInitialized = errors.New("session adapter: not initialized")
+
 // SessionAdapter adapts SessionClient to LanguageClientInterface
 type SessionAdapter struct {
 	client       *SessionClient
 	projectRoots []string
 	connected    bool
 	lastError    string
+
+	state                 int32 // sessionState, accessed atomically
+	capMu                 sync.RWMutex
+	clientCapabilities    protocol.ClientCapabilities
+	serverCapabilities    protocol.ServerCapabilities
+	rawServerCapabilities json.RawMessage
+
+	docs *documentStore
+
+	subMu               sync.Mutex
+	diagnosticsHandlers []func(uri string, diags []protocol.Diagnostic)
+	progressHandlers    []func(*IndexingStatus)
+
+	diagMu      sync.Mutex
+	diagnostics map[string][]protocol.Diagnostic
+
+	timeoutMu sync.RWMutex
+	timeouts  map[string]time.Duration
+
+	metrics *sessionMetrics
+
+	tokenParserMu sync.RWMutex
+	tokenParser   types.SemanticTokensParserProvider
+
+	semTokMu      sync.Mutex
+	semTokResults map[string]string // uri -> resultId from the last full/delta response
 }
 
-// NewSessionAdapter creates a new session adapter
+// NewSessionAdapter creates a new session adapter that reaches Session
+// Manager over plain TCP. Callers that need a different Transport (Unix
+// socket, stdio, TLS) should build a SessionClient themselves with
+// NewSessionClient and wire it into a SessionAdapter by hand.
 func NewSessionAdapter(host string, port int) (*SessionAdapter, error) {
-	client := NewSessionClient(host, port)
+	client := NewSessionClient(NewTCPTransport(host, port))
 
 	return &SessionAdapter{
-		client: client,
+		client:        client,
+		docs:          newDocumentStore(),
+		metrics:       newSessionMetrics(),
+		semTokResults: make(map[string]string),
+		diagnostics:   make(map[string][]protocol.Diagnostic),
 	}, nil
 }
 
+// recordCall records the outcome and latency of one LSP call made on
+// behalf of method (the LSP wire method name), so GetMetrics() and
+// MetricsHandler reflect real traffic instead of always reading zero.
+func (sa *SessionAdapter) recordCall(method string, start time.Time, err error) {
+	sa.metrics.record(method, time.Since(start), err)
+}
+
 // Connect connects to Session Manager
 func (sa *SessionAdapter) Connect() (types.LanguageClientInterface, error) {
 	if err := sa.client.Connect(); err != nil {
@@ -70,30 +130,95 @@ func (sa *SessionAdapter) GetProjectRoots() []string {
 	return sa.projectRoots
 }
 
-// Initialize - Session Manager is already initialized, just return success
+// Initialize forwards the caller's ClientCapabilities to Session Manager,
+// fetches the underlying BSL LSP server's real ServerCapabilities, and
+// caches both so ServerCapabilities/ClientCapabilities stop being stubs and
+// callers can gate features (SemanticTokens, Rename, FoldingRange, ...) on
+// what the server actually advertises.
 func (sa *SessionAdapter) Initialize(params protocol.InitializeParams) (*protocol.InitializeResult, error) {
-	logger.Debug("SessionAdapter: Initialize called - Session Manager already initialized")
+	atomic.StoreInt32(&sa.state, int32(sessionInitializing))
 
-	// Get capabilities from Session Manager
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
+
 	status, err := sa.client.GetStatus(ctx)
 	if err != nil {
+		atomic.StoreInt32(&sa.state, int32(sessionNotInitialized))
 		return nil, fmt.Errorf("failed to get session status: %w", err)
 	}
 
 	initialized, ok := status["initialized"].(bool)
 	if !ok || !initialized {
+		atomic.StoreInt32(&sa.state, int32(sessionNotInitialized))
 		return nil, fmt.Errorf("Session Manager not initialized")
 	}
 
-	// Return minimal result - actual capabilities are in Session Manager
-	// We return an empty capabilities struct - the bridge doesn't really use this
+	raw, err := sa.client.GetCapabilities(ctx, params.Capabilities)
+	if err != nil {
+		atomic.StoreInt32(&sa.state, int32(sessionNotInitialized))
+		return nil, fmt.Errorf("failed to get server capabilities: %w", err)
+	}
+
+	var serverCapabilities protocol.ServerCapabilities
+	if raw != nil && string(raw) != "null" {
+		if err := json.Unmarshal(raw, &serverCapabilities); err != nil {
+			atomic.StoreInt32(&sa.state, int32(sessionNotInitialized))
+			return nil, fmt.Errorf("failed to unmarshal server capabilities: %w", err)
+		}
+	}
+
+	sa.capMu.Lock()
+	sa.clientCapabilities = params.Capabilities
+	sa.serverCapabilities = serverCapabilities
+	sa.rawServerCapabilities = raw
+	sa.capMu.Unlock()
+
+	atomic.StoreInt32(&sa.state, int32(sessionInitialized))
+
+	logger.Debug("SessionAdapter: Initialize completed, server capabilities cached")
+
 	return &protocol.InitializeResult{
-		Capabilities: protocol.ServerCapabilities{},
+		Capabilities: serverCapabilities,
 	}, nil
 }
 
+// SetMethodTimeout overrides the per-call timeout used for method (the LSP
+// wire method name, e.g. "workspace/diagnostic" or "textDocument/rename"),
+// so heavy BSL workspaces can tune the slow paths (10-minute
+// WorkspaceDiagnostic, 2-minute Rename, ...) without recompiling. Methods
+// without an override keep their built-in default.
+func (sa *SessionAdapter) SetMethodTimeout(method string, d time.Duration) {
+	sa.timeoutMu.Lock()
+	defer sa.timeoutMu.Unlock()
+
+	if sa.timeouts == nil {
+		sa.timeouts = make(map[string]time.Duration)
+	}
+	sa.timeouts[method] = d
+}
+
+// methodTimeout returns the caller-configured override for method, or
+// fallback if none was set via SetMethodTimeout.
+func (sa *SessionAdapter) methodTimeout(method string, fallback time.Duration) time.Duration {
+	sa.timeoutMu.RLock()
+	defer sa.timeoutMu.RUnlock()
+
+	if d, ok := sa.timeouts[method]; ok {
+		return d
+	}
+	return fallback
+}
+
+// requireInitialized guards methods that need a live, capability-negotiated
+// server connection, returning ErrNotInitialized instead of the silent
+// nil/zero-value results callers got before the handshake was real.
+func (sa *SessionAdapter) requireInitialized() error {
+	if sessionState(atomic.LoadInt32(&sa.state)) != sessionInitialized {
+		return ErrNotInitialized
+	}
+	return nil
+}
+
 // Initialized - no-op for Session Manager
 func (sa *SessionAdapter) Initialized() error {
 	logger.Debug("SessionAdapter: Initialized notification - no-op for Session Manager")
@@ -112,38 +237,133 @@ func (sa *SessionAdapter) Exit() error {
 	return nil
 }
 
-// DidOpen opens a document
-func (sa *SessionAdapter) DidOpen(uri string, languageId protocol.LanguageKind, text string, version int32) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+// DidOpen opens a document and seeds the document store so later DidChange
+// calls have a base text to apply incremental edits against.
+func (sa *SessionAdapter) DidOpen(ctx context.Context, uri string, languageId protocol.LanguageKind, text string, version int32) error {
+	ctx, cancel := context.WithTimeout(ctx, sa.methodTimeout("textDocument/didOpen", 10*time.Second))
 	defer cancel()
-	return sa.client.DidOpen(ctx, uri, string(languageId), text)
-}
 
-// DidChange - not implemented yet
-func (sa *SessionAdapter) DidChange(uri string, version int32, changes []protocol.TextDocumentContentChangeEvent) error {
-	// TODO: implement if needed
+	start := time.Now()
+	err := sa.client.DidOpen(ctx, uri, string(languageId), text)
+	sa.recordCall("textDocument/didOpen", start, err)
+	if err != nil {
+		return err
+	}
+
+	sa.docs.open(uri, languageId, text, version)
+
 	return nil
 }
 
-// DidSave - not implemented yet
-func (sa *SessionAdapter) DidSave(uri string, text *string) error {
-	// TODO: implement if needed
-	return nil
+// DidChange applies changes to the cached document (rejecting a version
+// that doesn't advance past the one already cached) and forwards the
+// incremental edits to Session Manager, so large BSL modules don't need a
+// full resend on every keystroke.
+func (sa *SessionAdapter) DidChange(ctx context.Context, uri string, version int32, changes []protocol.TextDocumentContentChangeEvent) error {
+	if _, err := sa.docs.applyChanges(uri, version, changes); err != nil {
+		logger.Warn(fmt.Sprintf("DidChange: %v", err))
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, sa.methodTimeout("textDocument/didChange", 10*time.Second))
+	defer cancel()
+
+	start := time.Now()
+	err := sa.client.DidChange(ctx, uri, version, changes)
+	sa.recordCall("textDocument/didChange", start, err)
+	return err
 }
 
-// DidClose closes a document
-func (sa *SessionAdapter) DidClose(uri string) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+// DidSave forwards the saved text only when the server negotiated
+// includeText for save notifications; otherwise it sends a bare
+// notification so Session Manager can still refresh diagnostics.
+func (sa *SessionAdapter) DidSave(ctx context.Context, uri string, text *string) error {
+	ctx, cancel := context.WithTimeout(ctx, sa.methodTimeout("textDocument/didSave", 10*time.Second))
+	defer cancel()
+
+	if text != nil && !sa.saveIncludesText() {
+		text = nil
+	}
+
+	start := time.Now()
+	err := sa.client.DidSave(ctx, uri, text)
+	sa.recordCall("textDocument/didSave", start, err)
+	return err
+}
+
+// DidClose closes a document and drops it from the document store.
+func (sa *SessionAdapter) DidClose(ctx context.Context, uri string) error {
+	ctx, cancel := context.WithTimeout(ctx, sa.methodTimeout("textDocument/didClose", 10*time.Second))
 	defer cancel()
-	return sa.client.DidClose(ctx, uri)
+
+	start := time.Now()
+	err := sa.client.DidClose(ctx, uri)
+	sa.recordCall("textDocument/didClose", start, err)
+	if err != nil {
+		return err
+	}
+
+	sa.docs.close(uri)
+
+	return nil
+}
+
+// GetDocument returns the buffer text Session Manager was last told about
+// for uri, so callers can compute positions against exactly what the
+// server sees instead of re-reading the file from disk.
+func (sa *SessionAdapter) GetDocument(uri string) (text string, version int32, ok bool) {
+	return sa.docs.get(uri)
+}
+
+// saveIncludesText reports whether the server asked for the saved text to
+// be included in didSave notifications. ServerCapabilities.TextDocumentSync
+// is a boolean-or-options union the generated protocol types don't unwrap,
+// so this probes the raw JSON captured at Initialize instead.
+func (sa *SessionAdapter) saveIncludesText() bool {
+	sa.capMu.RLock()
+	raw := sa.rawServerCapabilities
+	sa.capMu.RUnlock()
+
+	if len(raw) == 0 {
+		return false
+	}
+
+	var probe struct {
+		TextDocumentSync struct {
+			Save json.RawMessage `json:"save"`
+		} `json:"textDocumentSync"`
+	}
+	if err := json.Unmarshal(raw, &probe); err != nil || len(probe.TextDocumentSync.Save) == 0 {
+		return false
+	}
+
+	var includeText bool
+	if err := json.Unmarshal(probe.TextDocumentSync.Save, &includeText); err == nil {
+		return includeText
+	}
+
+	var opts struct {
+		IncludeText bool `json:"includeText"`
+	}
+	if err := json.Unmarshal(probe.TextDocumentSync.Save, &opts); err == nil {
+		return opts.IncludeText
+	}
+
+	return false
 }
 
 // Hover gets hover information
-func (sa *SessionAdapter) Hover(uri string, line, character uint32) (*protocol.Hover, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+func (sa *SessionAdapter) Hover(ctx context.Context, uri string, line, character uint32) (*protocol.Hover, error) {
+	if err := sa.requireInitialized(); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, sa.methodTimeout("textDocument/hover", 30*time.Second))
 	defer cancel()
 
+	start := time.Now()
 	result, err := sa.client.Hover(ctx, uri, line, character)
+	sa.recordCall("textDocument/hover", start, err)
 	if err != nil {
 		return nil, err
 	}
@@ -161,11 +381,17 @@ func (sa *SessionAdapter) Hover(uri string, line, character uint32) (*protocol.H
 }
 
 // Definition gets definition locations
-func (sa *SessionAdapter) Definition(uri string, line, character uint32) ([]protocol.Or2[protocol.LocationLink, protocol.Location], error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+func (sa *SessionAdapter) Definition(ctx context.Context, uri string, line, character uint32) ([]protocol.Or2[protocol.LocationLink, protocol.Location], error) {
+	if err := sa.requireInitialized(); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, sa.methodTimeout("textDocument/definition", 30*time.Second))
 	defer cancel()
 
+	start := time.Now()
 	result, err := sa.client.Definition(ctx, uri, line, character)
+	sa.recordCall("textDocument/definition", start, err)
 	if err != nil {
 		return nil, err
 	}
@@ -183,11 +409,17 @@ func (sa *SessionAdapter) Definition(uri string, line, character uint32) ([]prot
 }
 
 // References finds all references
-func (sa *SessionAdapter) References(uri string, line, character uint32, includeDeclaration bool) ([]protocol.Location, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+func (sa *SessionAdapter) References(ctx context.Context, uri string, line, character uint32, includeDeclaration bool) ([]protocol.Location, error) {
+	if err := sa.requireInitialized(); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, sa.methodTimeout("textDocument/references", 60*time.Second))
 	defer cancel()
 
+	start := time.Now()
 	result, err := sa.client.References(ctx, uri, line, character, includeDeclaration)
+	sa.recordCall("textDocument/references", start, err)
 	if err != nil {
 		return nil, err
 	}
@@ -205,11 +437,17 @@ func (sa *SessionAdapter) References(uri string, line, character uint32, include
 }
 
 // DocumentSymbols gets document symbols
-func (sa *SessionAdapter) DocumentSymbols(uri string) ([]protocol.DocumentSymbol, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+func (sa *SessionAdapter) DocumentSymbols(ctx context.Context, uri string) ([]protocol.DocumentSymbol, error) {
+	if err := sa.requireInitialized(); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, sa.methodTimeout("textDocument/documentSymbol", 60*time.Second))
 	defer cancel()
 
+	start := time.Now()
 	result, err := sa.client.DocumentSymbols(ctx, uri)
+	sa.recordCall("textDocument/documentSymbol", start, err)
 	if err != nil {
 		return nil, err
 	}
@@ -227,11 +465,17 @@ func (sa *SessionAdapter) DocumentSymbols(uri string) ([]protocol.DocumentSymbol
 }
 
 // WorkspaceSymbols searches for symbols
-func (sa *SessionAdapter) WorkspaceSymbols(query string) ([]protocol.WorkspaceSymbol, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+func (sa *SessionAdapter) WorkspaceSymbols(ctx context.Context, query string) ([]protocol.WorkspaceSymbol, error) {
+	if err := sa.requireInitialized(); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, sa.methodTimeout("workspace/symbol", 60*time.Second))
 	defer cancel()
 
+	start := time.Now()
 	result, err := sa.client.WorkspaceSymbol(ctx, query)
+	sa.recordCall("workspace/symbol", start, err)
 	if err != nil {
 		return nil, err
 	}
@@ -249,11 +493,17 @@ func (sa *SessionAdapter) WorkspaceSymbols(query string) ([]protocol.WorkspaceSy
 }
 
 // PrepareCallHierarchy prepares call hierarchy
-func (sa *SessionAdapter) PrepareCallHierarchy(uri string, line, character uint32) ([]protocol.CallHierarchyItem, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+func (sa *SessionAdapter) PrepareCallHierarchy(ctx context.Context, uri string, line, character uint32) ([]protocol.CallHierarchyItem, error) {
+	if err := sa.requireInitialized(); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, sa.methodTimeout("textDocument/prepareCallHierarchy", 60*time.Second))
 	defer cancel()
 
+	start := time.Now()
 	result, err := sa.client.PrepareCallHierarchy(ctx, uri, line, character)
+	sa.recordCall("textDocument/prepareCallHierarchy", start, err)
 	if err != nil {
 		return nil, err
 	}
@@ -271,8 +521,12 @@ func (sa *SessionAdapter) PrepareCallHierarchy(uri string, line, character uint3
 }
 
 // IncomingCalls gets incoming calls
-func (sa *SessionAdapter) IncomingCalls(item protocol.CallHierarchyItem) ([]protocol.CallHierarchyIncomingCall, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 120*time.Second)
+func (sa *SessionAdapter) IncomingCalls(ctx context.Context, item protocol.CallHierarchyItem) ([]protocol.CallHierarchyIncomingCall, error) {
+	if err := sa.requireInitialized(); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, sa.methodTimeout("callHierarchy/incomingCalls", 120*time.Second))
 	defer cancel()
 
 	itemJSON, err := json.Marshal(item)
@@ -280,7 +534,9 @@ func (sa *SessionAdapter) IncomingCalls(item protocol.CallHierarchyItem) ([]prot
 		return nil, err
 	}
 
+	start := time.Now()
 	result, err := sa.client.IncomingCalls(ctx, itemJSON)
+	sa.recordCall("callHierarchy/incomingCalls", start, err)
 	if err != nil {
 		return nil, err
 	}
@@ -298,8 +554,12 @@ func (sa *SessionAdapter) IncomingCalls(item protocol.CallHierarchyItem) ([]prot
 }
 
 // OutgoingCalls gets outgoing calls
-func (sa *SessionAdapter) OutgoingCalls(item protocol.CallHierarchyItem) ([]protocol.CallHierarchyOutgoingCall, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 120*time.Second)
+func (sa *SessionAdapter) OutgoingCalls(ctx context.Context, item protocol.CallHierarchyItem) ([]protocol.CallHierarchyOutgoingCall, error) {
+	if err := sa.requireInitialized(); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, sa.methodTimeout("callHierarchy/outgoingCalls", 120*time.Second))
 	defer cancel()
 
 	itemJSON, err := json.Marshal(item)
@@ -307,7 +567,9 @@ func (sa *SessionAdapter) OutgoingCalls(item protocol.CallHierarchyItem) ([]prot
 		return nil, err
 	}
 
+	start := time.Now()
 	result, err := sa.client.OutgoingCalls(ctx, itemJSON)
+	sa.recordCall("callHierarchy/outgoingCalls", start, err)
 	if err != nil {
 		return nil, err
 	}
@@ -325,27 +587,91 @@ func (sa *SessionAdapter) OutgoingCalls(item protocol.CallHierarchyItem) ([]prot
 }
 
 // Implementation finds implementations
-func (sa *SessionAdapter) Implementation(uri string, line, character uint32) ([]protocol.Location, error) {
+func (sa *SessionAdapter) Implementation(ctx context.Context, uri string, line, character uint32) ([]protocol.Location, error) {
 	// Forward as definition for now - BSL doesn't really have interfaces
-	return sa.References(uri, line, character, true)
+	return sa.References(ctx, uri, line, character, true)
 }
 
 // SignatureHelp - not implemented yet
-func (sa *SessionAdapter) SignatureHelp(uri string, line, character uint32) (*protocol.SignatureHelp, error) {
+func (sa *SessionAdapter) SignatureHelp(ctx context.Context, uri string, line, character uint32) (*protocol.SignatureHelp, error) {
 	return nil, nil
 }
 
-// CodeActions - not implemented yet
-func (sa *SessionAdapter) CodeActions(uri string, line, character, endLine, endCharacter uint32) ([]protocol.CodeAction, error) {
-	return nil, nil
+// CodeActions requests textDocument/codeAction through Session Manager,
+// filtered to only (when given) the same way LanguageClient.CodeActions is.
+func (sa *SessionAdapter) CodeActions(ctx context.Context, uri string, line, character, endLine, endCharacter uint32, only []protocol.CodeActionKind) ([]protocol.CodeAction, error) {
+	if err := sa.requireInitialized(); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, sa.methodTimeout("textDocument/codeAction", 15*time.Second))
+	defer cancel()
+
+	start := time.Now()
+	result, err := sa.client.CodeActions(ctx, uri, line, character, endLine, endCharacter, only)
+	sa.recordCall("textDocument/codeAction", start, err)
+	if err != nil {
+		return nil, err
+	}
+	if result == nil || string(result) == "null" {
+		return nil, nil
+	}
+
+	var actions []protocol.CodeAction
+	if err := json.Unmarshal(result, &actions); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal code action result: %w", err)
+	}
+	return actions, nil
+}
+
+// CodeLens requests textDocument/codeLens through Session Manager.
+func (sa *SessionAdapter) CodeLens(ctx context.Context, uri string) ([]protocol.CodeLens, error) {
+	if err := sa.requireInitialized(); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, sa.methodTimeout("textDocument/codeLens", 15*time.Second))
+	defer cancel()
+
+	start := time.Now()
+	result, err := sa.client.CodeLens(ctx, uri)
+	sa.recordCall("textDocument/codeLens", start, err)
+	if err != nil {
+		return nil, err
+	}
+	if result == nil || string(result) == "null" {
+		return nil, nil
+	}
+
+	var lenses []protocol.CodeLens
+	if err := json.Unmarshal(result, &lenses); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal code lens result: %w", err)
+	}
+	return lenses, nil
+}
+
+// Diagnostics returns the most recent textDocument/publishDiagnostics
+// payload Session Manager pushed for uri, or nil if it hasn't pushed any
+// yet. Mirrors LanguageClient.Diagnostics, backed by dispatchNotification's
+// cache instead of ClientHandler's.
+func (sa *SessionAdapter) Diagnostics(uri string) []protocol.Diagnostic {
+	sa.diagMu.Lock()
+	defer sa.diagMu.Unlock()
+	return sa.diagnostics[uri]
 }
 
 // Rename - not implemented yet
-func (sa *SessionAdapter) Rename(uri string, line, character uint32, newName string) (*protocol.WorkspaceEdit, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+func (sa *SessionAdapter) Rename(ctx context.Context, uri string, line, character uint32, newName string) (*protocol.WorkspaceEdit, error) {
+	if err := sa.requireInitialized(); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, sa.methodTimeout("textDocument/rename", 2*time.Minute))
 	defer cancel()
 
+	start := time.Now()
 	result, err := sa.client.Rename(ctx, uri, line, character, newName)
+	sa.recordCall("textDocument/rename", start, err)
 	if err != nil {
 		return nil, err
 	}
@@ -361,11 +687,17 @@ func (sa *SessionAdapter) Rename(uri string, line, character uint32, newName str
 }
 
 // Formatting - not implemented yet
-func (sa *SessionAdapter) Formatting(uri string, tabSize uint32, insertSpaces bool) ([]protocol.TextEdit, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+func (sa *SessionAdapter) Formatting(ctx context.Context, uri string, tabSize uint32, insertSpaces bool) ([]protocol.TextEdit, error) {
+	if err := sa.requireInitialized(); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, sa.methodTimeout("textDocument/formatting", 5*time.Minute))
 	defer cancel()
 
+	start := time.Now()
 	result, err := sa.client.Formatting(ctx, uri, tabSize, insertSpaces)
+	sa.recordCall("textDocument/formatting", start, err)
 	if err != nil {
 		return nil, err
 	}
@@ -381,17 +713,23 @@ func (sa *SessionAdapter) Formatting(uri string, tabSize uint32, insertSpaces bo
 }
 
 // RangeFormatting - not implemented yet
-func (sa *SessionAdapter) RangeFormatting(uri string, startLine, startCharacter, endLine, endCharacter uint32, tabSize uint32, insertSpaces bool) ([]protocol.TextEdit, error) {
+func (sa *SessionAdapter) RangeFormatting(ctx context.Context, uri string, startLine, startCharacter, endLine, endCharacter uint32, tabSize uint32, insertSpaces bool) ([]protocol.TextEdit, error) {
 	return nil, fmt.Errorf("range formatting not implemented in session mode")
 }
 
 // WorkspaceDiagnostic - not implemented yet
-func (sa *SessionAdapter) WorkspaceDiagnostic(identifier string) (*protocol.WorkspaceDiagnosticReport, error) {
+func (sa *SessionAdapter) WorkspaceDiagnostic(ctx context.Context, identifier string) (*protocol.WorkspaceDiagnosticReport, error) {
+	if err := sa.requireInitialized(); err != nil {
+		return nil, err
+	}
+
 	// Workspace diagnostics can be extremely heavy on BSL projects (10k LOC modules, 20k+ files).
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	ctx, cancel := context.WithTimeout(ctx, sa.methodTimeout("workspace/diagnostic", 10*time.Minute))
 	defer cancel()
 
+	start := time.Now()
 	result, err := sa.client.WorkspaceDiagnostic(ctx, identifier)
+	sa.recordCall("workspace/diagnostic", start, err)
 	if err != nil {
 		return nil, err
 	}
@@ -407,12 +745,18 @@ func (sa *SessionAdapter) WorkspaceDiagnostic(identifier string) (*protocol.Work
 }
 
 // DocumentDiagnostics gets diagnostics for a document
-func (sa *SessionAdapter) DocumentDiagnostics(uri string, identifier string, previousResultId string) (*protocol.DocumentDiagnosticReport, error) {
+func (sa *SessionAdapter) DocumentDiagnostics(ctx context.Context, uri string, identifier string, previousResultId string) (*protocol.DocumentDiagnosticReport, error) {
+	if err := sa.requireInitialized(); err != nil {
+		return nil, err
+	}
+
 	// Document diagnostics can be slow on large BSL workspaces.
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	ctx, cancel := context.WithTimeout(ctx, sa.methodTimeout("textDocument/diagnostic", 5*time.Minute))
 	defer cancel()
 
+	start := time.Now()
 	result, err := sa.client.Diagnostic(ctx, uri, identifier, previousResultId)
+	sa.recordCall("textDocument/diagnostic", start, err)
 	if err != nil {
 		return nil, err
 	}
@@ -429,22 +773,160 @@ func (sa *SessionAdapter) DocumentDiagnostics(uri string, identifier string, pre
 	return &report, nil
 }
 
-// SemanticTokens - not implemented
-func (sa *SessionAdapter) SemanticTokens(uri string) (*protocol.SemanticTokens, error) {
-	return nil, nil
+// SemanticTokens fetches the full set of semantic tokens for uri. It is an
+// alias for SemanticTokensFull, kept for LanguageClientInterface compatibility.
+func (sa *SessionAdapter) SemanticTokens(ctx context.Context, uri string) (*protocol.SemanticTokens, error) {
+	return sa.SemanticTokensFull(ctx, uri)
 }
 
-// SemanticTokensRange - not implemented
-func (sa *SessionAdapter) SemanticTokensRange(uri string, startLine, startCharacter, endLine, endCharacter uint32) (*protocol.SemanticTokens, error) {
-	return nil, nil
+// SemanticTokensFull fetches the full set of semantic tokens for uri and
+// caches the server's resultId, so a later SemanticTokensDelta call for the
+// same uri only ships the lines that actually changed.
+func (sa *SessionAdapter) SemanticTokensFull(ctx context.Context, uri string) (*protocol.SemanticTokens, error) {
+	if err := sa.requireInitialized(); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, sa.methodTimeout("textDocument/semanticTokens/full", 60*time.Second))
+	defer cancel()
+
+	start := time.Now()
+	result, err := sa.client.SemanticTokensFull(ctx, uri)
+	sa.recordCall("textDocument/semanticTokens/full", start, err)
+	if err != nil {
+		return nil, err
+	}
+	if result == nil || string(result) == "null" {
+		return nil, nil
+	}
+
+	var tokens protocol.SemanticTokens
+	if err := json.Unmarshal(result, &tokens); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal semantic tokens: %w", err)
+	}
+
+	sa.setSemanticTokensResultId(uri, tokens.ResultId)
+
+	return &tokens, nil
+}
+
+// SemanticTokensDelta re-tokenizes uri against the resultId cached from the
+// previous SemanticTokensFull/SemanticTokensDelta call for it, so a 10k-LOC
+// module only ships the changed lines instead of the whole document. If
+// nothing has been cached yet it falls back to a full request. The server
+// may still respond with a full SemanticTokens result instead of a delta
+// (both are valid per the LSP spec); exactly one of tokens/delta is non-nil.
+func (sa *SessionAdapter) SemanticTokensDelta(ctx context.Context, uri string) (tokens *protocol.SemanticTokens, delta *protocol.SemanticTokensDelta, err error) {
+	previousResultId, ok := sa.semanticTokensResultId(uri)
+	if !ok {
+		tokens, err = sa.SemanticTokensFull(ctx, uri)
+		return tokens, nil, err
+	}
+
+	if err := sa.requireInitialized(); err != nil {
+		return nil, nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, sa.methodTimeout("textDocument/semanticTokens/full/delta", 60*time.Second))
+	defer cancel()
+
+	start := time.Now()
+	result, err := sa.client.SemanticTokensDelta(ctx, uri, previousResultId)
+	sa.recordCall("textDocument/semanticTokens/full/delta", start, err)
+	if err != nil {
+		return nil, nil, err
+	}
+	if result == nil || string(result) == "null" {
+		return nil, nil, nil
+	}
+
+	// The response is SemanticTokens | SemanticTokensDelta; only the delta
+	// shape carries "edits", so probe for that before picking which to
+	// unmarshal into, the same way saveIncludesText probes a union field.
+	var probe struct {
+		Edits json.RawMessage `json:"edits"`
+	}
+	if err := json.Unmarshal(result, &probe); err != nil {
+		return nil, nil, fmt.Errorf("failed to probe semantic tokens delta response: %w", err)
+	}
+
+	if probe.Edits != nil {
+		var d protocol.SemanticTokensDelta
+		if err := json.Unmarshal(result, &d); err != nil {
+			return nil, nil, fmt.Errorf("failed to unmarshal semantic tokens delta: %w", err)
+		}
+		sa.setSemanticTokensResultId(uri, d.ResultId)
+		return nil, &d, nil
+	}
+
+	var t protocol.SemanticTokens
+	if err := json.Unmarshal(result, &t); err != nil {
+		return nil, nil, fmt.Errorf("failed to unmarshal semantic tokens: %w", err)
+	}
+	sa.setSemanticTokensResultId(uri, t.ResultId)
+
+	return &t, nil, nil
+}
+
+// semanticTokensResultId returns the resultId cached from the last
+// SemanticTokensFull/SemanticTokensDelta response for uri.
+func (sa *SessionAdapter) semanticTokensResultId(uri string) (string, bool) {
+	sa.semTokMu.Lock()
+	defer sa.semTokMu.Unlock()
+	id, ok := sa.semTokResults[uri]
+	return id, ok
+}
+
+func (sa *SessionAdapter) setSemanticTokensResultId(uri string, resultId *string) {
+	if resultId == nil {
+		return
+	}
+	sa.semTokMu.Lock()
+	defer sa.semTokMu.Unlock()
+	sa.semTokResults[uri] = *resultId
+}
+
+// SemanticTokensRange computes semantic tokens for a sub-range of uri, so
+// callers can tokenize just the visible viewport of a large module instead
+// of the whole document.
+func (sa *SessionAdapter) SemanticTokensRange(ctx context.Context, uri string, startLine, startCharacter, endLine, endCharacter uint32) (*protocol.SemanticTokens, error) {
+	if err := sa.requireInitialized(); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, sa.methodTimeout("textDocument/semanticTokens/range", 60*time.Second))
+	defer cancel()
+
+	start := time.Now()
+	result, err := sa.client.SemanticTokensRange(ctx, uri, startLine, startCharacter, endLine, endCharacter)
+	sa.recordCall("textDocument/semanticTokens/range", start, err)
+	if err != nil {
+		return nil, err
+	}
+	if result == nil || string(result) == "null" {
+		return nil, nil
+	}
+
+	var tokens protocol.SemanticTokens
+	if err := json.Unmarshal(result, &tokens); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal semantic tokens: %w", err)
+	}
+
+	return &tokens, nil
 }
 
 // PrepareRename - not implemented yet
-func (sa *SessionAdapter) PrepareRename(uri string, line, character uint32) (*protocol.PrepareRenameResult, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+func (sa *SessionAdapter) PrepareRename(ctx context.Context, uri string, line, character uint32) (*protocol.PrepareRenameResult, error) {
+	if err := sa.requireInitialized(); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, sa.methodTimeout("textDocument/prepareRename", 2*time.Minute))
 	defer cancel()
 
+	start := time.Now()
 	result, err := sa.client.PrepareRename(ctx, uri, line, character)
+	sa.recordCall("textDocument/prepareRename", start, err)
 	if err != nil {
 		return nil, err
 	}
@@ -460,32 +942,32 @@ func (sa *SessionAdapter) PrepareRename(uri string, line, character uint32) (*pr
 }
 
 // FoldingRange - not implemented yet
-func (sa *SessionAdapter) FoldingRange(uri string) ([]protocol.FoldingRange, error) {
+func (sa *SessionAdapter) FoldingRange(ctx context.Context, uri string) ([]protocol.FoldingRange, error) {
 	return nil, nil
 }
 
 // SelectionRange - not implemented yet
-func (sa *SessionAdapter) SelectionRange(uri string, positions []protocol.Position) ([]protocol.SelectionRange, error) {
+func (sa *SessionAdapter) SelectionRange(ctx context.Context, uri string, positions []protocol.Position) ([]protocol.SelectionRange, error) {
 	return nil, nil
 }
 
 // DocumentLink - not implemented yet
-func (sa *SessionAdapter) DocumentLink(uri string) ([]protocol.DocumentLink, error) {
+func (sa *SessionAdapter) DocumentLink(ctx context.Context, uri string) ([]protocol.DocumentLink, error) {
 	return nil, nil
 }
 
 // DocumentColor - not implemented yet
-func (sa *SessionAdapter) DocumentColor(uri string) ([]protocol.ColorInformation, error) {
+func (sa *SessionAdapter) DocumentColor(ctx context.Context, uri string) ([]protocol.ColorInformation, error) {
 	return nil, nil
 }
 
 // ColorPresentation - not implemented yet
-func (sa *SessionAdapter) ColorPresentation(uri string, color protocol.Color, rng protocol.Range) ([]protocol.ColorPresentation, error) {
+func (sa *SessionAdapter) ColorPresentation(ctx context.Context, uri string, color protocol.Color, rng protocol.Range) ([]protocol.ColorPresentation, error) {
 	return nil, nil
 }
 
 // ExecuteCommand - not implemented yet
-func (sa *SessionAdapter) ExecuteCommand(command string, args []any) (json.RawMessage, error) {
+func (sa *SessionAdapter) ExecuteCommand(ctx context.Context, command string, args []any) (json.RawMessage, error) {
 	return nil, fmt.Errorf("execute command not implemented in session mode")
 }
 
@@ -517,7 +999,8 @@ func (sa *SessionAdapter) ConnectInterface() (interface{}, error) {
 	return sa.Connect()
 }
 
-// GetMetrics returns client metrics (stub for now)
+// GetMetrics returns live client metrics backed by sa.metrics, which every
+// adapter call instruments via recordCall.
 func (sa *SessionAdapter) GetMetrics() types.ClientMetricsProvider {
 	status := int(StatusUninitialized)
 	if sa.connected && sa.client.IsConnected() {
@@ -525,7 +1008,17 @@ func (sa *SessionAdapter) GetMetrics() types.ClientMetricsProvider {
 	} else if !sa.connected {
 		status = int(StatusDisconnected)
 	}
-	return &sessionMetrics{connected: sa.connected, status: status}
+	sa.metrics.SetConnected(sa.connected)
+	sa.metrics.SetStatus(status)
+	return sa.metrics
+}
+
+// MetricsHandler returns an http.Handler exposing per-LSP-method request
+// rate, error rate, and latency (Prometheus/OpenMetrics text exposition
+// format) so operators can see which call - hover vs. workspace diagnostic
+// vs. rename - is the actual bottleneck in a given deployment.
+func (sa *SessionAdapter) MetricsHandler() http.Handler {
+	return sa.metrics.handler()
 }
 
 // Status returns connection status as int
@@ -541,61 +1034,80 @@ func (sa *SessionAdapter) ProjectRoots() []string {
 	return sa.projectRoots
 }
 
-// ClientCapabilities returns client capabilities
+// ClientCapabilities returns the capabilities passed to Initialize
 func (sa *SessionAdapter) ClientCapabilities() protocol.ClientCapabilities {
-	return protocol.ClientCapabilities{}
+	sa.capMu.RLock()
+	defer sa.capMu.RUnlock()
+	return sa.clientCapabilities
 }
 
-// ServerCapabilities returns server capabilities
+// ServerCapabilities returns the real capabilities fetched from Session
+// Manager during Initialize, so callers can check feature support instead
+// of assuming everything is nil.
 func (sa *SessionAdapter) ServerCapabilities() protocol.ServerCapabilities {
-	return protocol.ServerCapabilities{}
+	sa.capMu.RLock()
+	defer sa.capMu.RUnlock()
+	return sa.serverCapabilities
 }
 
-// SetServerCapabilities sets server capabilities (no-op for session mode)
+// SetServerCapabilities overrides the cached server capabilities (e.g. for
+// tests, or if a caller learns of them through another channel).
 func (sa *SessionAdapter) SetServerCapabilities(capabilities protocol.ServerCapabilities) {
-	// No-op - Session Manager handles this
+	sa.capMu.Lock()
+	defer sa.capMu.Unlock()
+	sa.serverCapabilities = capabilities
 }
 
-// SetupSemanticTokens sets up semantic tokens (no-op)
+// SetupSemanticTokens fetches the SemanticTokensLegend the server
+// advertised in ServerCapabilities and builds the parser TokenParser()
+// exposes, so callers can resolve tokenType/tokenModifiers indices without
+// knowing the legend themselves. Must be called after Initialize.
 func (sa *SessionAdapter) SetupSemanticTokens() error {
+	legend, ok := sa.semanticTokensLegend()
+	if !ok {
+		return fmt.Errorf("session adapter: server did not advertise a semantic tokens legend")
+	}
+
+	sa.tokenParserMu.Lock()
+	sa.tokenParser = newSemanticTokensParser(legend)
+	sa.tokenParserMu.Unlock()
+
 	return nil
 }
 
-// TokenParser returns semantic token parser (nil for now)
-func (sa *SessionAdapter) TokenParser() types.SemanticTokensParserProvider {
-	return nil
+// semanticTokensLegend probes the raw ServerCapabilities JSON captured at
+// Initialize for semanticTokensProvider.legend, since
+// ServerCapabilities.SemanticTokensProvider is a boolean-or-options union
+// the generated protocol types don't unwrap (see saveIncludesText).
+func (sa *SessionAdapter) semanticTokensLegend() (protocol.SemanticTokensLegend, bool) {
+	sa.capMu.RLock()
+	raw := sa.rawServerCapabilities
+	sa.capMu.RUnlock()
+
+	if len(raw) == 0 {
+		return protocol.SemanticTokensLegend{}, false
+	}
+
+	var probe struct {
+		SemanticTokensProvider struct {
+			Legend protocol.SemanticTokensLegend `json:"legend"`
+		} `json:"semanticTokensProvider"`
+	}
+	if err := json.Unmarshal(raw, &probe); err != nil || len(probe.SemanticTokensProvider.Legend.TokenTypes) == 0 {
+		return protocol.SemanticTokensLegend{}, false
+	}
+
+	return probe.SemanticTokensProvider.Legend, true
 }
 
-// sessionMetrics implements ClientMetricsProvider for SessionAdapter
-type sessionMetrics struct {
-	connected bool
-	command   string
-	status    int
-}
-
-func (m *sessionMetrics) GetCommand() string                     { return m.command }
-func (m *sessionMetrics) SetCommand(command string)              { m.command = command }
-func (m *sessionMetrics) GetStatus() int                         { return m.status }
-func (m *sessionMetrics) SetStatus(status int)                   { m.status = status }
-func (m *sessionMetrics) GetTotalRequests() int64                { return 0 }
-func (m *sessionMetrics) SetTotalRequests(total int64)           {}
-func (m *sessionMetrics) IncrementTotalRequests()                {}
-func (m *sessionMetrics) GetSuccessfulRequests() int64           { return 0 }
-func (m *sessionMetrics) SetSuccessfulRequests(successful int64) {}
-func (m *sessionMetrics) IncrementSuccessfulRequests()           {}
-func (m *sessionMetrics) GetFailedRequests() int64               { return 0 }
-func (m *sessionMetrics) SetFailedRequests(failed int64)         {}
-func (m *sessionMetrics) IncrementFailedRequests()               {}
-func (m *sessionMetrics) GetLastInitialized() time.Time          { return time.Time{} }
-func (m *sessionMetrics) SetLastInitialized(t time.Time)         {}
-func (m *sessionMetrics) GetLastErrorTime() time.Time            { return time.Time{} }
-func (m *sessionMetrics) SetLastErrorTime(t time.Time)           {}
-func (m *sessionMetrics) GetLastError() string                   { return "" }
-func (m *sessionMetrics) SetLastError(err string)                {}
-func (m *sessionMetrics) IsConnected() bool                      { return m.connected }
-func (m *sessionMetrics) SetConnected(connected bool)            { m.connected = connected }
-func (m *sessionMetrics) GetProcessID() int32                    { return 0 }
-func (m *sessionMetrics) SetProcessID(pid int32)                 {}
+// TokenParser returns the parser built by SetupSemanticTokens, or nil if
+// SetupSemanticTokens hasn't run yet (or the server doesn't support
+// semantic tokens).
+func (sa *SessionAdapter) TokenParser() types.SemanticTokensParserProvider {
+	sa.tokenParserMu.RLock()
+	defer sa.tokenParserMu.RUnlock()
+	return sa.tokenParser
+}
 
 // DidChangeWatchedFiles notifies about file changes
 func (sa *SessionAdapter) DidChangeWatchedFiles(changes []protocol.FileEvent) error {
@@ -663,3 +1175,106 @@ func (sa *SessionAdapter) GetIndexingStatus() *IndexingStatus {
 
 	return result
 }
+
+// SubscribeDiagnostics registers fn to be called with the URI and
+// diagnostics of every textDocument/publishDiagnostics notification pushed
+// by Session Manager. Register before calling Run so nothing is missed.
+func (sa *SessionAdapter) SubscribeDiagnostics(fn func(uri string, diags []protocol.Diagnostic)) {
+	sa.subMu.Lock()
+	defer sa.subMu.Unlock()
+	sa.diagnosticsHandlers = append(sa.diagnosticsHandlers, fn)
+}
+
+// SubscribeProgress registers fn to be called with indexing progress
+// derived from $/progress and window/workDoneProgress/* notifications.
+// Register before calling Run so nothing is missed.
+func (sa *SessionAdapter) SubscribeProgress(fn func(*IndexingStatus)) {
+	sa.subMu.Lock()
+	defer sa.subMu.Unlock()
+	sa.progressHandlers = append(sa.progressHandlers, fn)
+}
+
+// Run wires the registered Subscribe* handlers into the connection's
+// notification stream and blocks until ctx is done. Connect must be called
+// first; construct the adapter, register Subscribe* handlers, then call
+// Run before issuing any requests, so SessionClient's frame-reading loop
+// only ever starts with sa.dispatchNotification already wired up as its
+// Serve handler — no messages are dropped or silently missed in between.
+func (sa *SessionAdapter) Run(ctx context.Context) error {
+	sa.client.Serve(SessionHandlerFunc(sa.dispatchNotification))
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+// dispatchNotification fans a raw Session Manager notification out to the
+// registered Subscribe* handlers.
+func (sa *SessionAdapter) dispatchNotification(method string, params json.RawMessage) {
+	switch method {
+	case "textDocument/publishDiagnostics":
+		var p struct {
+			URI         string                `json:"uri"`
+			Diagnostics []protocol.Diagnostic `json:"diagnostics"`
+		}
+		if err := json.Unmarshal(params, &p); err != nil {
+			logger.Warn(fmt.Sprintf("SessionAdapter: failed to parse publishDiagnostics: %v", err))
+			return
+		}
+
+		sa.diagMu.Lock()
+		sa.diagnostics[p.URI] = p.Diagnostics
+		sa.diagMu.Unlock()
+
+		sa.subMu.Lock()
+		handlers := append([]func(string, []protocol.Diagnostic){}, sa.diagnosticsHandlers...)
+		sa.subMu.Unlock()
+
+		for _, h := range handlers {
+			h(p.URI, p.Diagnostics)
+		}
+
+	case "$/progress", "window/workDoneProgress/create":
+		status := progressToIndexingStatus(params)
+		if status == nil {
+			return
+		}
+
+		sa.subMu.Lock()
+		handlers := append([]func(*IndexingStatus){}, sa.progressHandlers...)
+		sa.subMu.Unlock()
+
+		for _, h := range handlers {
+			h(status)
+		}
+	}
+}
+
+// progressToIndexingStatus converts a $/progress notification's value
+// (begin/report/end work-done progress) into the minimal IndexingStatus
+// shape Subscribe callers expect.
+func progressToIndexingStatus(params json.RawMessage) *IndexingStatus {
+	var p struct {
+		Value struct {
+			Kind       string `json:"kind"`
+			Message    string `json:"message"`
+			Percentage int    `json:"percentage"`
+		} `json:"value"`
+	}
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil
+	}
+	if p.Value.Kind == "" {
+		return nil
+	}
+
+	state := "indexing"
+	if p.Value.Kind == "end" {
+		state = "complete"
+	}
+
+	return &IndexingStatus{
+		State:   state,
+		Current: p.Value.Percentage,
+		Total:   100,
+		Message: p.Value.Message,
+	}
+}
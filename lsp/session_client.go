@@ -10,27 +10,89 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"net"
+	"io"
+	"strconv"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
 
 	"rockerboo/mcp-lsp-bridge/logger"
+
+	"github.com/myleshyson/lsprotocol-go/protocol"
 )
 
-// SessionClient connects to LSP Session Manager
+// SessionClient connects to LSP Session Manager over a Content-Length-framed
+// JSON-RPC 2.0 connection, the same wire format LanguageClient already
+// speaks to the underlying BSL language servers (see tcp_client.go). Unlike
+// the newline-delimited format this replaced, a message body may itself
+// contain embedded newlines (a large didOpen text, a formatted result blob)
+// without desyncing the stream.
 type SessionClient struct {
-	host string
-	port int
+	transport Transport
+
+	mu        sync.Mutex
+	conn      io.ReadWriteCloser
+	reader    *bufio.Reader
+	writeMu   sync.Mutex
+	reqID     int64
+	pending   map[int64]*pendingCall
+	closed    bool // true if explicitly closed (not error)
+	startOnce sync.Once
+	pingOnce  sync.Once
+
+	retry SessionRetryPolicy
+
+	progressMu  sync.Mutex
+	progress    map[string]func(json.RawMessage)
+	progressSeq int64
+
+	notifyMu  sync.Mutex
+	handler   SessionHandler
+	notifyBuf []sessionNotification
+}
+
+// pendingCall is one in-flight Call: its raw request body (kept so a
+// retryable call can be resent verbatim, with the same id, after a
+// reconnect) and whether that retry is allowed at all. Only idempotent
+// methods (see SessionRetryPolicy.IdempotentMethods) are retryable; a
+// pendingCall for any other method is failed outright the moment its
+// connection is lost, rather than silently resent and possibly executed
+// twice.
+type pendingCall struct {
+	ch        chan sessionResponse
+	body      []byte
+	method    string
+	retryable bool
+}
+
+// SessionHandler receives server-initiated notifications pushed down a
+// SessionClient connection (textDocument/publishDiagnostics, $/progress,
+// and the like). It is the SessionClient counterpart to ClientHandler,
+// which plays the same role for a direct LSP connection.
+type SessionHandler interface {
+	Handle(method string, params json.RawMessage)
+}
+
+// SessionHandlerFunc adapts a plain function to SessionHandler.
+type SessionHandlerFunc func(method string, params json.RawMessage)
+
+func (f SessionHandlerFunc) Handle(method string, params json.RawMessage) { f(method, params) }
 
-	mu      sync.Mutex
-	conn    net.Conn
-	reader  *bufio.Reader
-	reqID   int64
-	pending map[int64]chan sessionResponse
-	closed  bool // true if explicitly closed (not error)
+// sessionNotification is a server-to-client message with no "id" (a
+// notification, e.g. textDocument/publishDiagnostics or $/progress).
+// readResponses buffers these until a handler is registered via Serve, so
+// nothing is dropped between Connect and the caller wiring up
+// SessionAdapter.Run.
+type sessionNotification struct {
+	Method string
+	Params json.RawMessage
 }
 
+// maxBufferedNotifications bounds notifyBuf so a client that never calls
+// Serve can't leak memory indefinitely.
+const maxBufferedNotifications = 256
+
 type sessionResponse struct {
 	Result json.RawMessage `json:"result"`
 	Error  *sessionError   `json:"error"`
@@ -41,26 +103,42 @@ type sessionError struct {
 	Message string `json:"message"`
 }
 
-// NewSessionClient creates a new Session Manager client
-func NewSessionClient(host string, port int) *SessionClient {
+// NewSessionClient creates a new Session Manager client that reaches the
+// daemon via transport. Use NewTCPTransport(host, port) for the original
+// plain-TCP behavior.
+func NewSessionClient(transport Transport) *SessionClient {
 	return &SessionClient{
-		host:    host,
-		port:    port,
-		pending: make(map[int64]chan sessionResponse),
+		transport: transport,
+		pending:   make(map[int64]*pendingCall),
+		retry:     DefaultSessionRetryPolicy,
 	}
 }
 
-// Connect establishes connection to Session Manager
+// SetRetryPolicy overrides the retry/heartbeat policy Call and the
+// keepalive heartbeat use. Must be called before Connect to affect the
+// heartbeat's interval; Call always reads the current policy.
+func (sc *SessionClient) SetRetryPolicy(policy SessionRetryPolicy) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	sc.retry = policy
+}
+
+// Connect establishes connection to Session Manager. The frame-reading loop
+// itself does not start until Serve is called (or, failing that, the first
+// Call/Notify), so a handler bound via Serve right after Connect never
+// races a notification that arrives before it's installed - the same fix
+// jsonrpc2.Conn gets from taking its Handler at construction time.
 func (sc *SessionClient) Connect() error {
-	addr := fmt.Sprintf("%s:%d", sc.host, sc.port)
-	logger.Info(fmt.Sprintf("Connecting to Session Manager at %s", addr))
+	logger.Info(fmt.Sprintf("Connecting to Session Manager via %s", sc.transport))
 
-	var conn net.Conn
+	var conn io.ReadWriteCloser
 	var err error
 
 	// Retry connection
 	for i := 0; i < 10; i++ {
-		conn, err = net.DialTimeout("tcp", addr, 5*time.Second)
+		dialCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		conn, err = sc.transport.Dial(dialCtx)
+		cancel()
 		if err == nil {
 			break
 		}
@@ -77,8 +155,7 @@ func (sc *SessionClient) Connect() error {
 	sc.reader = bufio.NewReader(conn)
 	sc.mu.Unlock()
 
-	// Start response reader
-	go sc.readResponses()
+	sc.startHeartbeat()
 
 	logger.Info("Connected to Session Manager")
 	return nil
@@ -96,11 +173,19 @@ func (sc *SessionClient) Close() error {
 	return nil
 }
 
-// IsConnected returns true if connected
+// IsConnected returns true if the connection is open and, per the
+// transport's Health check, actually reachable - not just "the field is
+// non-nil", which a half-open socket would satisfy just as well.
 func (sc *SessionClient) IsConnected() bool {
 	sc.mu.Lock()
-	defer sc.mu.Unlock()
-	return sc.conn != nil
+	conn := sc.conn
+	transport := sc.transport
+	sc.mu.Unlock()
+
+	if conn == nil {
+		return false
+	}
+	return transport.Health(conn) == nil
 }
 
 // GetStatus gets session status
@@ -110,6 +195,20 @@ func (sc *SessionClient) GetStatus(ctx context.Context) (map[string]interface{},
 	return result, err
 }
 
+// GetCapabilities forwards the caller's ClientCapabilities to Session
+// Manager and returns the underlying server's raw ServerCapabilities so
+// Initialize can cache what the server actually advertises instead of
+// assuming it matches the client's request.
+func (sc *SessionClient) GetCapabilities(ctx context.Context, clientCapabilities protocol.ClientCapabilities) (json.RawMessage, error) {
+	params := map[string]interface{}{
+		"capabilities": clientCapabilities,
+	}
+
+	var result json.RawMessage
+	err := sc.Call(ctx, "session/capabilities", params, &result)
+	return result, err
+}
+
 // Hover sends textDocument/hover request
 func (sc *SessionClient) Hover(ctx context.Context, uri string, line, character uint32) (json.RawMessage, error) {
 	params := map[string]interface{}{
@@ -164,6 +263,67 @@ func (sc *SessionClient) References(ctx context.Context, uri string, line, chara
 	return result, err
 }
 
+// ReferencesStream behaves like References but additionally delivers each
+// partial-result batch to onChunk as it arrives (LSP 3.16 partial results),
+// rather than making the caller wait for one large response at the end of
+// a repo-wide scan. onChunk returning a non-nil error stops the stream
+// early - the in-flight request is cancelled the same way a Call's ctx
+// being done cancels it.
+func (sc *SessionClient) ReferencesStream(ctx context.Context, uri string, line, character uint32, includeDeclaration bool, onChunk func(chunk []protocol.Location) error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	token := sc.nextProgressToken("references")
+	params := map[string]interface{}{
+		"textDocument": map[string]interface{}{
+			"uri": uri,
+		},
+		"position": map[string]interface{}{
+			"line":      line,
+			"character": character,
+		},
+		"context": map[string]interface{}{
+			"includeDeclaration": includeDeclaration,
+		},
+		"partialResultToken": token,
+	}
+
+	var streamErr error
+	onProgress := func(value json.RawMessage) {
+		var chunk []protocol.Location
+		if err := json.Unmarshal(value, &chunk); err != nil {
+			logger.Warn(fmt.Sprintf("ReferencesStream: failed to unmarshal partial result: %v", err))
+			return
+		}
+		if err := onChunk(chunk); err != nil {
+			streamErr = err
+			cancel()
+		}
+	}
+
+	var final json.RawMessage
+	err := sc.CallWithProgress(ctx, "textDocument/references", params, &final, token, onProgress)
+	if streamErr != nil {
+		return streamErr
+	}
+	if err != nil {
+		return err
+	}
+
+	if final == nil || string(final) == "null" {
+		return nil
+	}
+
+	var last []protocol.Location
+	if err := json.Unmarshal(final, &last); err != nil {
+		return fmt.Errorf("failed to unmarshal final references result: %w", err)
+	}
+	if len(last) == 0 {
+		return nil
+	}
+	return onChunk(last)
+}
+
 // DocumentSymbols sends textDocument/documentSymbol request
 func (sc *SessionClient) DocumentSymbols(ctx context.Context, uri string) (json.RawMessage, error) {
 	params := map[string]interface{}{
@@ -228,7 +388,8 @@ func (sc *SessionClient) OutgoingCalls(ctx context.Context, item json.RawMessage
 	return result, err
 }
 
-// DidOpen sends textDocument/didOpen notification
+// DidOpen sends textDocument/didOpen as a notification - there is no
+// response to wait for, so it goes through Notify rather than Call.
 func (sc *SessionClient) DidOpen(ctx context.Context, uri, languageID, text string) error {
 	params := map[string]interface{}{
 		"textDocument": map[string]interface{}{
@@ -239,11 +400,41 @@ func (sc *SessionClient) DidOpen(ctx context.Context, uri, languageID, text stri
 		},
 	}
 
-	var result interface{}
-	return sc.Call(ctx, "textDocument/didOpen", params, &result)
+	return sc.Notify(ctx, "textDocument/didOpen", params)
+}
+
+// DidChange sends textDocument/didChange as a notification, forwarding only
+// the incremental content changes so large BSL modules don't need a full
+// resend on every edit.
+func (sc *SessionClient) DidChange(ctx context.Context, uri string, version int32, changes []protocol.TextDocumentContentChangeEvent) error {
+	params := map[string]interface{}{
+		"textDocument": map[string]interface{}{
+			"uri":     uri,
+			"version": version,
+		},
+		"contentChanges": changes,
+	}
+
+	return sc.Notify(ctx, "textDocument/didChange", params)
 }
 
-// DidClose sends textDocument/didClose notification
+// DidSave sends textDocument/didSave as a notification, including the saved
+// text only when the caller passes it (SessionAdapter gates that on
+// whether the server negotiated includeText).
+func (sc *SessionClient) DidSave(ctx context.Context, uri string, text *string) error {
+	params := map[string]interface{}{
+		"textDocument": map[string]interface{}{
+			"uri": uri,
+		},
+	}
+	if text != nil {
+		params["text"] = *text
+	}
+
+	return sc.Notify(ctx, "textDocument/didSave", params)
+}
+
+// DidClose sends textDocument/didClose as a notification
 func (sc *SessionClient) DidClose(ctx context.Context, uri string) error {
 	params := map[string]interface{}{
 		"textDocument": map[string]interface{}{
@@ -251,8 +442,7 @@ func (sc *SessionClient) DidClose(ctx context.Context, uri string) error {
 		},
 	}
 
-	var result interface{}
-	return sc.Call(ctx, "textDocument/didClose", params, &result)
+	return sc.Notify(ctx, "textDocument/didClose", params)
 }
 
 // Diagnostic sends textDocument/diagnostic request
@@ -306,7 +496,38 @@ func (sc *SessionClient) PrepareRename(ctx context.Context, uri string, line, ch
 	return result, err
 }
 
-// Rename sends textDocument/rename request
+// CodeActions sends textDocument/codeAction, scoped to only when non-empty
+// via CodeActionContext.Only.
+func (sc *SessionClient) CodeActions(ctx context.Context, uri string, line, character, endLine, endCharacter uint32, only []protocol.CodeActionKind) (json.RawMessage, error) {
+	params := map[string]interface{}{
+		"textDocument": map[string]interface{}{
+			"uri": uri,
+		},
+		"range": map[string]interface{}{
+			"start": map[string]interface{}{"line": line, "character": character},
+			"end":   map[string]interface{}{"line": endLine, "character": endCharacter},
+		},
+		"context": map[string]interface{}{
+			"only": only,
+		},
+	}
+	var result json.RawMessage
+	err := sc.Call(ctx, "textDocument/codeAction", params, &result)
+	return result, err
+}
+
+// CodeLens sends textDocument/codeLens for the whole document at uri.
+func (sc *SessionClient) CodeLens(ctx context.Context, uri string) (json.RawMessage, error) {
+	params := map[string]interface{}{
+		"textDocument": map[string]interface{}{
+			"uri": uri,
+		},
+	}
+	var result json.RawMessage
+	err := sc.Call(ctx, "textDocument/codeLens", params, &result)
+	return result, err
+}
+
 func (sc *SessionClient) Rename(ctx context.Context, uri string, line, character uint32, newName string) (json.RawMessage, error) {
 	params := map[string]interface{}{
 		"textDocument": map[string]interface{}{
@@ -335,6 +556,57 @@ func (sc *SessionClient) WorkspaceDiagnostic(ctx context.Context, identifier str
 	return result, err
 }
 
+// SemanticTokensFull sends textDocument/semanticTokens/full request
+func (sc *SessionClient) SemanticTokensFull(ctx context.Context, uri string) (json.RawMessage, error) {
+	params := map[string]interface{}{
+		"textDocument": map[string]interface{}{
+			"uri": uri,
+		},
+	}
+
+	var result json.RawMessage
+	err := sc.Call(ctx, "textDocument/semanticTokens/full", params, &result)
+	return result, err
+}
+
+// SemanticTokensDelta sends textDocument/semanticTokens/full/delta request,
+// letting the server ship only the lines that changed since previousResultId.
+func (sc *SessionClient) SemanticTokensDelta(ctx context.Context, uri, previousResultId string) (json.RawMessage, error) {
+	params := map[string]interface{}{
+		"textDocument": map[string]interface{}{
+			"uri": uri,
+		},
+		"previousResultId": previousResultId,
+	}
+
+	var result json.RawMessage
+	err := sc.Call(ctx, "textDocument/semanticTokens/full/delta", params, &result)
+	return result, err
+}
+
+// SemanticTokensRange sends textDocument/semanticTokens/range request
+func (sc *SessionClient) SemanticTokensRange(ctx context.Context, uri string, startLine, startCharacter, endLine, endCharacter uint32) (json.RawMessage, error) {
+	params := map[string]interface{}{
+		"textDocument": map[string]interface{}{
+			"uri": uri,
+		},
+		"range": map[string]interface{}{
+			"start": map[string]interface{}{
+				"line":      startLine,
+				"character": startCharacter,
+			},
+			"end": map[string]interface{}{
+				"line":      endLine,
+				"character": endCharacter,
+			},
+		},
+	}
+
+	var result json.RawMessage
+	err := sc.Call(ctx, "textDocument/semanticTokens/range", params, &result)
+	return result, err
+}
+
 // WorkspaceSymbol sends workspace/symbol request
 func (sc *SessionClient) WorkspaceSymbol(ctx context.Context, query string) (json.RawMessage, error) {
 	params := map[string]interface{}{
@@ -346,71 +618,82 @@ func (sc *SessionClient) WorkspaceSymbol(ctx context.Context, query string) (jso
 	return result, err
 }
 
-// Call makes a JSON-RPC call to Session Manager
+// Call makes a JSON-RPC request to Session Manager: it allocates an id,
+// registers a pending channel for it, and blocks until a matching response
+// arrives or ctx is done. On ctx.Done() it sends $/cancelRequest for id
+// before returning ctx.Err(), so Session Manager (and in turn the
+// underlying BSL language server) stops computing an answer nobody is
+// waiting for anymore, rather than quietly orphaning the request.
+//
+// If method is in the current SessionRetryPolicy's IdempotentMethods, the
+// pendingCall registered for it is marked retryable: a connection loss
+// doesn't fail it outright (see readResponses) — it rides out a reconnect
+// and is resent with the same id, transparently to the caller. A method
+// not in the allow-list is assumed to have side effects and is only ever
+// sent once.
 func (sc *SessionClient) Call(ctx context.Context, method string, params interface{}, result interface{}) error {
-	// Check connection and try to reconnect if needed
-	sc.mu.Lock()
-	if sc.conn == nil && !sc.closed {
-		sc.mu.Unlock()
-		if err := sc.reconnect(); err != nil {
-			return fmt.Errorf("not connected to Session Manager and reconnect failed: %w", err)
-		}
-		// Start reader goroutine after reconnect
-		go sc.readResponses()
-		sc.mu.Lock()
+	return sc.call(ctx, method, params, result, "", nil)
+}
+
+// CallWithProgress behaves like Call but additionally streams partial
+// results: token must be the same value the caller already embedded in
+// params as "partialResultToken" (LSP 3.16's partial-result pattern), and
+// onProgress is invoked - possibly several times, concurrently with the
+// final response still pending - with the raw "value" field of each
+// $/progress notification Session Manager sends for that token before the
+// response itself arrives on the usual channel. See ReferencesStream for
+// how a typed streaming wrapper builds on this.
+func (sc *SessionClient) CallWithProgress(ctx context.Context, method string, params interface{}, result interface{}, token string, onProgress func(json.RawMessage)) error {
+	return sc.call(ctx, method, params, result, token, onProgress)
+}
+
+// call is the shared implementation behind Call and CallWithProgress. token
+// and onProgress are the empty string and nil, respectively, for a plain
+// Call.
+func (sc *SessionClient) call(ctx context.Context, method string, params interface{}, result interface{}, token string, onProgress func(json.RawMessage)) error {
+	if err := sc.ensureConnected(); err != nil {
+		return err
 	}
+	sc.ensureServing()
 
-	if sc.conn == nil {
-		sc.mu.Unlock()
-		return fmt.Errorf("not connected to Session Manager")
+	if token != "" && onProgress != nil {
+		sc.registerProgress(token, onProgress)
+		defer sc.unregisterProgress(token)
 	}
 
-	id := atomic.AddInt64(&sc.reqID, 1)
-	respCh := make(chan sessionResponse, 1)
-	sc.pending[id] = respCh
+	sc.mu.Lock()
+	policy := sc.retry
 	sc.mu.Unlock()
 
-	defer func() {
-		sc.mu.Lock()
-		delete(sc.pending, id)
-		sc.mu.Unlock()
-	}()
-
-	// Build request
+	id := atomic.AddInt64(&sc.reqID, 1)
 	req := map[string]interface{}{
 		"jsonrpc": "2.0",
 		"id":      id,
 		"method":  method,
 		"params":  params,
 	}
-
-	reqJSON, err := json.Marshal(req)
+	body, err := json.Marshal(req)
 	if err != nil {
 		return fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	// Send request (newline-delimited)
+	pc := &pendingCall{ch: make(chan sessionResponse, 1), body: body, method: method, retryable: policy.IdempotentMethods[method]}
 	sc.mu.Lock()
-	conn := sc.conn
+	sc.pending[id] = pc
 	sc.mu.Unlock()
 
-	if conn == nil {
-		return fmt.Errorf("connection lost before sending request")
-	}
-
-	_, err = conn.Write(append(reqJSON, '\n'))
-	if err != nil {
-		// Mark connection as broken
+	defer func() {
 		sc.mu.Lock()
-		sc.conn = nil
-		sc.reader = nil
+		delete(sc.pending, id)
 		sc.mu.Unlock()
-		return fmt.Errorf("failed to send request: %w", err)
+	}()
+
+	if err := sc.sendWithRetry(ctx, pc, policy); err != nil {
+		return err
 	}
 
-	// Wait for response
 	select {
-	case resp := <-respCh:
+	case resp := <-pc.ch:
 		if resp.Error != nil {
 			return fmt.Errorf("session manager error: %s", resp.Error.Message)
 		}
@@ -419,11 +702,151 @@ func (sc *SessionClient) Call(ctx context.Context, method string, params interfa
 		}
 		return nil
 	case <-ctx.Done():
+		sc.Cancel(id)
 		return ctx.Err()
 	}
 }
 
-// readResponses reads responses from Session Manager
+// sendWithRetry writes pc.body to the wire, retrying on a transient send
+// failure per policy if pc.retryable — otherwise it gives up after the
+// first failure, the same way a non-idempotent method never gets silently
+// resent from readResponses either.
+func (sc *SessionClient) sendWithRetry(ctx context.Context, pc *pendingCall, policy SessionRetryPolicy) error {
+	attempts := 1
+	if pc.retryable && policy.MaxAttempts > attempts {
+		attempts = policy.MaxAttempts
+	}
+
+	var lastErr error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		if err := sc.writeFramedBytes(pc.body); err != nil {
+			lastErr = err
+			if attempt == attempts {
+				break
+			}
+
+			delay := backoffWithJitter(policy.BaseDelay, attempt)
+			select {
+			case <-time.After(delay):
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+
+			if err := sc.reconnect(); err != nil {
+				lastErr = err
+				continue
+			}
+			continue
+		}
+		return nil
+	}
+
+	return fmt.Errorf("session manager: %s: %w", pc.method, lastErr)
+}
+
+// Notify sends method as a JSON-RPC notification: no id is assigned and no
+// pending channel is allocated, since the wire protocol guarantees no
+// response is coming. textDocument/didOpen and friends use this instead of
+// Call.
+func (sc *SessionClient) Notify(ctx context.Context, method string, params interface{}) error {
+	if err := sc.ensureConnected(); err != nil {
+		return err
+	}
+	sc.ensureServing()
+
+	notification := map[string]interface{}{
+		"jsonrpc": "2.0",
+		"method":  method,
+		"params":  params,
+	}
+	return sc.writeMessage(notification)
+}
+
+// Cancel sends a $/cancelRequest notification for id. Call already does
+// this automatically when its ctx is done; Cancel is exposed separately
+// for callers that track a request id themselves and want to cancel it
+// programmatically without going through Call's ctx.
+func (sc *SessionClient) Cancel(id int64) {
+	notification := map[string]interface{}{
+		"jsonrpc": "2.0",
+		"method":  "$/cancelRequest",
+		"params":  map[string]interface{}{"id": id},
+	}
+
+	if err := sc.writeMessage(notification); err != nil {
+		logger.Warn(fmt.Sprintf("Cancel: failed to notify Session Manager for id %d: %v", id, err))
+	}
+}
+
+// ensureConnected reconnects if the connection was dropped and this client
+// hasn't been explicitly closed.
+func (sc *SessionClient) ensureConnected() error {
+	sc.mu.Lock()
+	needsReconnect := sc.conn == nil && !sc.closed
+	sc.mu.Unlock()
+
+	if needsReconnect {
+		if err := sc.reconnect(); err != nil {
+			return fmt.Errorf("not connected to Session Manager and reconnect failed: %w", err)
+		}
+	}
+
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	if sc.conn == nil {
+		return fmt.Errorf("not connected to Session Manager")
+	}
+	return nil
+}
+
+// ensureServing starts the frame-reading loop if it isn't already running.
+// Serve calls this too, so whichever of Serve or the first Call/Notify runs
+// first is the one that actually starts the loop; startOnce makes a second
+// start from the other path a no-op.
+func (sc *SessionClient) ensureServing() {
+	sc.startOnce.Do(func() {
+		go sc.readResponses()
+	})
+}
+
+// writeMessage marshals v and writes it as one Content-Length-framed
+// message.
+func (sc *SessionClient) writeMessage(v interface{}) error {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to marshal message: %w", err)
+	}
+	return sc.writeFramedBytes(body)
+}
+
+// writeFramedBytes writes an already-marshaled body as one Content-Length
+// frame, under writeMu so two concurrent callers can never interleave
+// their frames on the wire. Kept separate from writeMessage so a retried
+// pendingCall can be resent byte-for-byte (same id) without re-marshaling.
+func (sc *SessionClient) writeFramedBytes(body []byte) error {
+	sc.mu.Lock()
+	conn := sc.conn
+	sc.mu.Unlock()
+
+	if conn == nil {
+		return fmt.Errorf("connection lost before sending message")
+	}
+
+	sc.writeMu.Lock()
+	defer sc.writeMu.Unlock()
+
+	header := fmt.Sprintf("Content-Length: %d\r\n\r\n", len(body))
+	if _, err := conn.Write(append([]byte(header), body...)); err != nil {
+		sc.mu.Lock()
+		sc.conn = nil
+		sc.reader = nil
+		sc.mu.Unlock()
+		return fmt.Errorf("failed to send message: %w", err)
+	}
+	return nil
+}
+
+// readResponses reads Content-Length-framed messages from Session Manager
 func (sc *SessionClient) readResponses() {
 	for {
 		sc.mu.Lock()
@@ -435,14 +858,19 @@ func (sc *SessionClient) readResponses() {
 			return
 		}
 
-		line, err := reader.ReadString('\n')
+		body, err := readFramedMessage(reader)
 		if err != nil {
 			logger.Error(fmt.Sprintf("Session Manager read error: %v", err))
+			connErr := fmt.Errorf("connection lost: %w", err)
 
-			// Fail all pending requests
-			sc.failAllPending(fmt.Errorf("connection lost: %w", err))
+			// A dead connection takes every in-flight call down with it,
+			// but that doesn't mean every call should fail: only the ones
+			// that aren't safe to silently resend (see pendingCall) are
+			// failed now. Retryable calls keep waiting - resendRetryable
+			// puts their exact request back on the wire once reconnect
+			// succeeds, so the caller never sees the blip.
+			sc.failNonRetryablePending(connErr)
 
-			// Try to reconnect if not explicitly closed
 			sc.mu.Lock()
 			wasClosed := sc.closed
 			sc.mu.Unlock()
@@ -451,30 +879,47 @@ func (sc *SessionClient) readResponses() {
 				logger.Info("Attempting to reconnect to Session Manager...")
 				if reconnErr := sc.reconnect(); reconnErr != nil {
 					logger.Error(fmt.Sprintf("Reconnect failed: %v", reconnErr))
+					sc.failAllPending(connErr)
 					return
 				}
-				// Reconnect succeeded, continue reading
 				logger.Info("Reconnected to Session Manager")
+				sc.resendRetryablePending()
 				continue
 			}
+			sc.failAllPending(connErr)
 			return
 		}
 
 		var resp struct {
 			JSONRPC string          `json:"jsonrpc"`
-			ID      int64           `json:"id"`
+			ID      *int64          `json:"id"`
+			Method  string          `json:"method"`
+			Params  json.RawMessage `json:"params"`
 			Result  json.RawMessage `json:"result"`
 			Error   *sessionError   `json:"error"`
 		}
 
-		if err := json.Unmarshal([]byte(line), &resp); err != nil {
+		if err := json.Unmarshal(body, &resp); err != nil {
 			logger.Error(fmt.Sprintf("Failed to parse response: %v", err))
 			continue
 		}
 
+		if resp.ID == nil && resp.Method == "$/progress" && sc.handleProgress(resp.Params) {
+			continue
+		}
+
+		if resp.ID == nil && resp.Method != "" {
+			sc.handleNotification(resp.Method, resp.Params)
+			continue
+		}
+
+		if resp.ID == nil {
+			continue
+		}
+
 		sc.mu.Lock()
-		if ch, ok := sc.pending[resp.ID]; ok {
-			ch <- sessionResponse{
+		if pc, ok := sc.pending[*resp.ID]; ok {
+			pc.ch <- sessionResponse{
 				Result: resp.Result,
 				Error:  resp.Error,
 			}
@@ -483,17 +928,202 @@ func (sc *SessionClient) readResponses() {
 	}
 }
 
-// failAllPending fails all pending requests with the given error
+// readFramedMessage reads one Content-Length-framed JSON-RPC message off
+// reader and returns its body. This is the same wire format LanguageClient
+// already speaks to the underlying BSL language servers (see
+// tcp_client.go): unlike the newline-delimited format it replaces, a
+// message body may contain embedded newlines (a large didOpen text, a
+// formatted result blob) without desyncing the stream.
+func readFramedMessage(reader *bufio.Reader) ([]byte, error) {
+	var contentLength int
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+
+		line = strings.TrimSpace(line)
+		if line == "" {
+			// Empty line = end of headers
+			break
+		}
+
+		if strings.HasPrefix(line, "Content-Length:") {
+			lengthStr := strings.TrimSpace(strings.TrimPrefix(line, "Content-Length:"))
+			contentLength, err = strconv.Atoi(lengthStr)
+			if err != nil {
+				return nil, fmt.Errorf("invalid Content-Length: %w", err)
+			}
+		}
+	}
+
+	if contentLength == 0 {
+		return nil, fmt.Errorf("missing Content-Length header")
+	}
+
+	body := make([]byte, contentLength)
+	if _, err := io.ReadFull(reader, body); err != nil {
+		return nil, err
+	}
+	return body, nil
+}
+
+// Serve installs handler as the receiver for server-initiated notifications
+// and starts the frame-reading loop if it isn't already running (see
+// ensureServing). Calling Serve before any Call/Notify means the loop only
+// ever starts with a handler already bound, so there's no window where a
+// notification could arrive and be silently dropped before a caller had a
+// chance to register one - the same fix x/tools' jsonrpc2 package gets by
+// requiring its Handler at construction time. Any notification that did
+// arrive earlier (e.g. because a caller issued a Call before Serve) is
+// buffered and replayed here, in order, so nothing is lost either way.
+func (sc *SessionClient) Serve(handler SessionHandler) {
+	sc.notifyMu.Lock()
+	sc.handler = handler
+	buffered := sc.notifyBuf
+	sc.notifyBuf = nil
+	sc.notifyMu.Unlock()
+
+	for _, n := range buffered {
+		handler.Handle(n.Method, n.Params)
+	}
+
+	sc.ensureServing()
+}
+
+// handleNotification dispatches to the registered handler, or buffers the
+// notification (dropping the oldest once full) if none is registered yet.
+func (sc *SessionClient) handleNotification(method string, params json.RawMessage) {
+	sc.notifyMu.Lock()
+	defer sc.notifyMu.Unlock()
+
+	if sc.handler != nil {
+		sc.handler.Handle(method, params)
+		return
+	}
+
+	if len(sc.notifyBuf) >= maxBufferedNotifications {
+		logger.Warn("SessionClient: notification buffer full, dropping oldest")
+		sc.notifyBuf = sc.notifyBuf[1:]
+	}
+	sc.notifyBuf = append(sc.notifyBuf, sessionNotification{Method: method, Params: params})
+}
+
+// nextProgressToken returns a partialResultToken unique to this client,
+// prefixed for readability in logs (e.g. "references-3").
+func (sc *SessionClient) nextProgressToken(prefix string) string {
+	n := atomic.AddInt64(&sc.progressSeq, 1)
+	return fmt.Sprintf("%s-%d", prefix, n)
+}
+
+// registerProgress records cb as the callback for $/progress notifications
+// carrying token, for CallWithProgress to clean up once its Call returns.
+func (sc *SessionClient) registerProgress(token string, cb func(json.RawMessage)) {
+	sc.progressMu.Lock()
+	defer sc.progressMu.Unlock()
+	if sc.progress == nil {
+		sc.progress = make(map[string]func(json.RawMessage))
+	}
+	sc.progress[token] = cb
+}
+
+func (sc *SessionClient) unregisterProgress(token string) {
+	sc.progressMu.Lock()
+	defer sc.progressMu.Unlock()
+	delete(sc.progress, token)
+}
+
+// handleProgress routes a $/progress notification to the callback
+// CallWithProgress registered for its token, if any, and reports whether it
+// found one. A token nothing registered (plain work-done progress with no
+// partial-result callback) falls through to handleNotification instead, so
+// it's still delivered to whatever Serve handler SessionAdapter installed.
+func (sc *SessionClient) handleProgress(params json.RawMessage) bool {
+	var progress struct {
+		Token json.RawMessage `json:"token"`
+		Value json.RawMessage `json:"value"`
+	}
+	if err := json.Unmarshal(params, &progress); err != nil {
+		return false
+	}
+
+	key := sessionProgressTokenKey(progress.Token)
+
+	sc.progressMu.Lock()
+	cb := sc.progress[key]
+	sc.progressMu.Unlock()
+
+	if cb == nil {
+		return false
+	}
+	cb(progress.Value)
+	return true
+}
+
+// sessionProgressTokenKey normalizes a JSON-RPC ProgressToken (a string or
+// an integer, per the LSP spec) into a comparable string so it can be
+// looked up in SessionClient.progress regardless of which JSON type Session
+// Manager sent it as.
+func sessionProgressTokenKey(raw json.RawMessage) string {
+	var token interface{}
+	if err := json.Unmarshal(raw, &token); err != nil {
+		return string(raw)
+	}
+	return fmt.Sprintf("%v", token)
+}
+
+// failAllPending fails every pending request with err, including retryable
+// ones - used once reconnect has definitively given up and there's no
+// connection left for anything to ride out.
 func (sc *SessionClient) failAllPending(err error) {
 	sc.mu.Lock()
 	defer sc.mu.Unlock()
 
-	for _, ch := range sc.pending {
-		ch <- sessionResponse{
-			Error: &sessionError{
-				Code:    -32000,
-				Message: err.Error(),
-			},
+	for _, pc := range sc.pending {
+		pc.ch <- sessionResponse{
+			Error: &sessionError{Code: -32000, Message: err.Error()},
+		}
+	}
+}
+
+// failNonRetryablePending fails only the pending calls whose method isn't
+// in the retry policy's allow-list, leaving retryable ones registered so
+// resendRetryablePending can put them back on the wire after a reconnect.
+func (sc *SessionClient) failNonRetryablePending(err error) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	for id, pc := range sc.pending {
+		if pc.retryable {
+			continue
+		}
+		pc.ch <- sessionResponse{
+			Error: &sessionError{Code: -32000, Message: err.Error()},
+		}
+		delete(sc.pending, id)
+	}
+}
+
+// resendRetryablePending re-sends every still-pending retryable call's
+// original request body, unchanged, over the just-reestablished
+// connection. The id is the same one the caller is still waiting on, so
+// the eventual response routes back to it exactly as if the connection had
+// never dropped. A call that fails to resend here is left pending and
+// simply tries again on the next reconnect (or eventually times out via
+// its own ctx).
+func (sc *SessionClient) resendRetryablePending() {
+	sc.mu.Lock()
+	toResend := make([]*pendingCall, 0, len(sc.pending))
+	for _, pc := range sc.pending {
+		if pc.retryable {
+			toResend = append(toResend, pc)
+		}
+	}
+	sc.mu.Unlock()
+
+	for _, pc := range toResend {
+		if err := sc.writeFramedBytes(pc.body); err != nil {
+			logger.Warn(fmt.Sprintf("resendRetryablePending: failed to resend %s, will retry on next reconnect: %v", pc.method, err))
 		}
 	}
 }
@@ -509,15 +1139,16 @@ func (sc *SessionClient) reconnect() error {
 	}
 	sc.mu.Unlock()
 
-	addr := fmt.Sprintf("%s:%d", sc.host, sc.port)
-	logger.Info(fmt.Sprintf("Reconnecting to Session Manager at %s", addr))
+	logger.Info(fmt.Sprintf("Reconnecting to Session Manager via %s", sc.transport))
 
-	var conn net.Conn
+	var conn io.ReadWriteCloser
 	var err error
 
 	// Retry connection with backoff
 	for i := 0; i < 5; i++ {
-		conn, err = net.DialTimeout("tcp", addr, 5*time.Second)
+		dialCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		conn, err = sc.transport.Dial(dialCtx)
+		cancel()
 		if err == nil {
 			break
 		}
@@ -0,0 +1,320 @@
+package lsp
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// defaultLatencyBuckets are the cumulative upper bounds (seconds) used for
+// every per-method latency histogram. The range has to cover both
+// sub-second Hover calls and multi-minute WorkspaceDiagnostic/Rename calls.
+var defaultLatencyBuckets = []float64{
+	0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 120, 300, 600,
+}
+
+// latencyHistogram is a minimal Prometheus-style cumulative histogram:
+// counts[i] holds the number of observations <= defaultLatencyBuckets[i].
+type latencyHistogram struct {
+	mu     sync.Mutex
+	counts []int64
+	sum    float64
+	total  int64
+}
+
+func newLatencyHistogram() *latencyHistogram {
+	return &latencyHistogram{counts: make([]int64, len(defaultLatencyBuckets))}
+}
+
+func (h *latencyHistogram) observe(seconds float64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for i, upperBound := range defaultLatencyBuckets {
+		if seconds <= upperBound {
+			h.counts[i]++
+		}
+	}
+	h.sum += seconds
+	h.total++
+}
+
+func (h *latencyHistogram) count() int64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.total
+}
+
+// quantile returns an approximate p-th quantile (0 < p < 1) by walking the
+// cumulative bucket counts - good enough for p50/p95/p99 dashboards without
+// keeping every raw sample around.
+func (h *latencyHistogram) quantile(p float64) float64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.total == 0 {
+		return 0
+	}
+
+	target := p * float64(h.total)
+	for i, c := range h.counts {
+		if float64(c) >= target {
+			return defaultLatencyBuckets[i]
+		}
+	}
+	return defaultLatencyBuckets[len(defaultLatencyBuckets)-1]
+}
+
+// snapshot returns a copy of the cumulative bucket counts plus the running
+// sum/total, for rendering as a Prometheus histogram.
+func (h *latencyHistogram) snapshot() (counts []int64, sum float64, total int64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	counts = make([]int64, len(h.counts))
+	copy(counts, h.counts)
+	return counts, h.sum, h.total
+}
+
+// methodStats holds request counters and a latency histogram for one LSP
+// wire method (e.g. "textDocument/hover").
+type methodStats struct {
+	mu        sync.Mutex
+	total     int64
+	succeeded int64
+	failed    int64
+	hist      *latencyHistogram
+}
+
+// LatencySnapshot summarizes a method's observed latency distribution.
+// Not part of types.ClientMetricsProvider; consume it via type assertion
+// the same way callers already do for ProgressSnapshot.
+type LatencySnapshot struct {
+	Count int64
+	P50   float64
+	P95   float64
+	P99   float64
+}
+
+// sessionMetrics implements types.ClientMetricsProvider for SessionAdapter,
+// backed by persistent counters and per-method latency histograms
+// (populated via record) so GetMetrics() reflects real traffic instead of
+// always reading zero.
+type sessionMetrics struct {
+	connected int32 // atomic bool (0/1)
+	status    int32 // atomic
+
+	commandMu sync.RWMutex
+	command   string
+
+	totalRequests      int64 // atomic
+	successfulRequests int64 // atomic
+	failedRequests     int64 // atomic
+
+	errMu           sync.RWMutex
+	lastInitialized time.Time
+	lastErrorTime   time.Time
+	lastError       string
+
+	processID int32 // atomic
+
+	statsMu sync.Mutex
+	stats   map[string]*methodStats
+}
+
+func newSessionMetrics() *sessionMetrics {
+	return &sessionMetrics{stats: make(map[string]*methodStats)}
+}
+
+func (m *sessionMetrics) GetCommand() string {
+	m.commandMu.RLock()
+	defer m.commandMu.RUnlock()
+	return m.command
+}
+func (m *sessionMetrics) SetCommand(command string) {
+	m.commandMu.Lock()
+	defer m.commandMu.Unlock()
+	m.command = command
+}
+func (m *sessionMetrics) GetStatus() int       { return int(atomic.LoadInt32(&m.status)) }
+func (m *sessionMetrics) SetStatus(status int) { atomic.StoreInt32(&m.status, int32(status)) }
+
+func (m *sessionMetrics) GetTotalRequests() int64      { return atomic.LoadInt64(&m.totalRequests) }
+func (m *sessionMetrics) SetTotalRequests(total int64) { atomic.StoreInt64(&m.totalRequests, total) }
+func (m *sessionMetrics) IncrementTotalRequests()      { atomic.AddInt64(&m.totalRequests, 1) }
+
+func (m *sessionMetrics) GetSuccessfulRequests() int64 {
+	return atomic.LoadInt64(&m.successfulRequests)
+}
+func (m *sessionMetrics) SetSuccessfulRequests(successful int64) {
+	atomic.StoreInt64(&m.successfulRequests, successful)
+}
+func (m *sessionMetrics) IncrementSuccessfulRequests() { atomic.AddInt64(&m.successfulRequests, 1) }
+
+func (m *sessionMetrics) GetFailedRequests() int64 { return atomic.LoadInt64(&m.failedRequests) }
+func (m *sessionMetrics) SetFailedRequests(failed int64) {
+	atomic.StoreInt64(&m.failedRequests, failed)
+}
+func (m *sessionMetrics) IncrementFailedRequests() { atomic.AddInt64(&m.failedRequests, 1) }
+
+func (m *sessionMetrics) GetLastInitialized() time.Time {
+	m.errMu.RLock()
+	defer m.errMu.RUnlock()
+	return m.lastInitialized
+}
+func (m *sessionMetrics) SetLastInitialized(t time.Time) {
+	m.errMu.Lock()
+	defer m.errMu.Unlock()
+	m.lastInitialized = t
+}
+
+func (m *sessionMetrics) GetLastErrorTime() time.Time {
+	m.errMu.RLock()
+	defer m.errMu.RUnlock()
+	return m.lastErrorTime
+}
+func (m *sessionMetrics) SetLastErrorTime(t time.Time) {
+	m.errMu.Lock()
+	defer m.errMu.Unlock()
+	m.lastErrorTime = t
+}
+
+func (m *sessionMetrics) GetLastError() string {
+	m.errMu.RLock()
+	defer m.errMu.RUnlock()
+	return m.lastError
+}
+func (m *sessionMetrics) SetLastError(err string) {
+	m.errMu.Lock()
+	defer m.errMu.Unlock()
+	m.lastError = err
+}
+
+func (m *sessionMetrics) IsConnected() bool { return atomic.LoadInt32(&m.connected) == 1 }
+func (m *sessionMetrics) SetConnected(connected bool) {
+	var v int32
+	if connected {
+		v = 1
+	}
+	atomic.StoreInt32(&m.connected, v)
+}
+
+func (m *sessionMetrics) GetProcessID() int32    { return atomic.LoadInt32(&m.processID) }
+func (m *sessionMetrics) SetProcessID(pid int32) { atomic.StoreInt32(&m.processID, pid) }
+
+// record updates the aggregate counters and the per-method latency
+// histogram for one completed adapter call.
+func (m *sessionMetrics) record(method string, elapsed time.Duration, err error) {
+	m.IncrementTotalRequests()
+	if err != nil {
+		m.IncrementFailedRequests()
+		m.SetLastError(err.Error())
+		m.SetLastErrorTime(time.Now())
+	} else {
+		m.IncrementSuccessfulRequests()
+	}
+
+	s := m.methodStatsFor(method)
+
+	s.mu.Lock()
+	s.total++
+	if err != nil {
+		s.failed++
+	} else {
+		s.succeeded++
+	}
+	s.mu.Unlock()
+
+	s.hist.observe(elapsed.Seconds())
+}
+
+func (m *sessionMetrics) methodStatsFor(method string) *methodStats {
+	m.statsMu.Lock()
+	defer m.statsMu.Unlock()
+
+	s, ok := m.stats[method]
+	if !ok {
+		s = &methodStats{hist: newLatencyHistogram()}
+		m.stats[method] = s
+	}
+	return s
+}
+
+// GetLatencyHistogram returns the latency distribution observed for method
+// (an LSP wire method name, e.g. "textDocument/hover"). Not part of
+// types.ClientMetricsProvider; consume via type assertion like
+// ProgressSnapshot.
+func (m *sessionMetrics) GetLatencyHistogram(method string) LatencySnapshot {
+	m.statsMu.Lock()
+	s, ok := m.stats[method]
+	m.statsMu.Unlock()
+	if !ok {
+		return LatencySnapshot{}
+	}
+
+	return LatencySnapshot{
+		Count: s.hist.count(),
+		P50:   s.hist.quantile(0.50),
+		P95:   s.hist.quantile(0.95),
+		P99:   s.hist.quantile(0.99),
+	}
+}
+
+// methodNames returns the sorted set of LSP methods with recorded metrics,
+// so MetricsHandler output is stable across scrapes.
+func (m *sessionMetrics) methodNames() []string {
+	m.statsMu.Lock()
+	defer m.statsMu.Unlock()
+
+	names := make([]string, 0, len(m.stats))
+	for name := range m.stats {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// handler returns an http.Handler exposing the adapter's per-method request
+// counts, error counts, and latency histograms in Prometheus/OpenMetrics
+// text exposition format.
+func (m *sessionMetrics) handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		m.writeTo(w)
+	})
+}
+
+func (m *sessionMetrics) writeTo(w http.ResponseWriter) {
+	fmt.Fprintln(w, "# HELP bsl_lsp_requests_total Total LSP requests made through the Session Manager, by method.")
+	fmt.Fprintln(w, "# TYPE bsl_lsp_requests_total counter")
+	fmt.Fprintln(w, "# HELP bsl_lsp_requests_failed_total Failed LSP requests made through the Session Manager, by method.")
+	fmt.Fprintln(w, "# TYPE bsl_lsp_requests_failed_total counter")
+	fmt.Fprintln(w, "# HELP bsl_lsp_request_duration_seconds Latency of LSP requests made through the Session Manager, by method.")
+	fmt.Fprintln(w, "# TYPE bsl_lsp_request_duration_seconds histogram")
+
+	for _, method := range m.methodNames() {
+		s := m.methodStatsFor(method)
+
+		s.mu.Lock()
+		total, failed := s.total, s.failed
+		s.mu.Unlock()
+
+		fmt.Fprintf(w, "bsl_lsp_requests_total{method=%q} %d\n", method, total)
+		fmt.Fprintf(w, "bsl_lsp_requests_failed_total{method=%q} %d\n", method, failed)
+
+		counts, sum, count := s.hist.snapshot()
+		for i, upperBound := range defaultLatencyBuckets {
+			fmt.Fprintf(w, "bsl_lsp_request_duration_seconds_bucket{method=%q,le=%q} %d\n", method, formatBound(upperBound), counts[i])
+		}
+		fmt.Fprintf(w, "bsl_lsp_request_duration_seconds_bucket{method=%q,le=\"+Inf\"} %d\n", method, count)
+		fmt.Fprintf(w, "bsl_lsp_request_duration_seconds_sum{method=%q} %g\n", method, sum)
+		fmt.Fprintf(w, "bsl_lsp_request_duration_seconds_count{method=%q} %d\n", method, count)
+	}
+}
+
+func formatBound(seconds float64) string {
+	return fmt.Sprintf("%g", seconds)
+}
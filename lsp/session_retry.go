@@ -0,0 +1,87 @@
+package lsp
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"rockerboo/mcp-lsp-bridge/logger"
+)
+
+// SessionRetryPolicy controls how SessionClient.Call retries a transient
+// failure, and how often it heartbeats the connection with session/ping.
+// Only methods in IdempotentMethods are retried: a method not in the list
+// is assumed to have side effects (textDocument/rename, textDocument/
+// didOpen and friends) and is sent at most once, since a failed write
+// can't tell "never reached the server" apart from "reached the server
+// but the reply was lost."
+type SessionRetryPolicy struct {
+	// MaxAttempts is the most times an idempotent Call is sent before
+	// giving up, including the first attempt. 1 disables retrying.
+	MaxAttempts int
+	// BaseDelay is the first retry's backoff delay; each subsequent retry
+	// roughly doubles it (see backoffWithJitter), plus jitter.
+	BaseDelay time.Duration
+	// IdempotentMethods is the allow-list of LSP methods safe to retry
+	// transparently after a transient failure.
+	IdempotentMethods map[string]bool
+	// PingInterval is how often Call sends a session/ping heartbeat to
+	// detect a half-open connection proactively, instead of waiting for
+	// the next real request to discover the socket is dead. Zero disables
+	// the heartbeat.
+	PingInterval time.Duration
+}
+
+// DefaultSessionRetryPolicy is used by NewSessionClient. It retries the
+// read-only LSP methods SessionAdapter calls most often and that are safe
+// to run twice, and heartbeats every 30s.
+var DefaultSessionRetryPolicy = SessionRetryPolicy{
+	MaxAttempts: 3,
+	BaseDelay:   200 * time.Millisecond,
+	IdempotentMethods: map[string]bool{
+		"textDocument/hover":          true,
+		"textDocument/definition":     true,
+		"textDocument/references":     true,
+		"textDocument/documentSymbol": true,
+		"workspace/symbol":            true,
+		"session/status":              true,
+		"session/ping":                true,
+	},
+	PingInterval: 30 * time.Second,
+}
+
+// startHeartbeat starts the session/ping keepalive loop the first time
+// it's called for this client (subsequent calls, e.g. from reconnect, are
+// no-ops). It stops on its own once the client is closed; a failed ping is
+// logged and the loop keeps running.
+func (sc *SessionClient) startHeartbeat() {
+	sc.pingOnce.Do(func() {
+		sc.mu.Lock()
+		interval := sc.retry.PingInterval
+		sc.mu.Unlock()
+		if interval <= 0 {
+			return
+		}
+
+		go func() {
+			ticker := time.NewTicker(interval)
+			defer ticker.Stop()
+
+			for range ticker.C {
+				sc.mu.Lock()
+				closed := sc.closed
+				sc.mu.Unlock()
+				if closed {
+					return
+				}
+
+				ctx, cancel := context.WithTimeout(context.Background(), interval)
+				err := sc.Call(ctx, "session/ping", nil, nil)
+				cancel()
+				if err != nil {
+					logger.Warn(fmt.Sprintf("session heartbeat: session/ping failed: %v", err))
+				}
+			}
+		}()
+	})
+}
@@ -0,0 +1,214 @@
+package lsp
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+)
+
+// Transport abstracts how SessionClient reaches the Session Manager daemon.
+// SessionClient itself owns all retry/backoff policy (see Connect/reconnect);
+// a Transport only knows how to open one fresh connection and judge whether
+// a previously-dialed one still looks alive, so that logic never has to
+// change when a deployment switches from TCP to a Unix socket, a co-launched
+// subprocess, or mutual TLS.
+type Transport interface {
+	// Dial opens a new connection to Session Manager, honoring ctx's
+	// deadline/cancellation.
+	Dial(ctx context.Context) (io.ReadWriteCloser, error)
+	// Health reports whether conn - the value a prior Dial returned - still
+	// looks reachable, without fully re-dialing. A nil error means healthy.
+	Health(conn io.ReadWriteCloser) error
+	// String names the transport for logging, e.g. "tcp localhost:9999".
+	String() string
+}
+
+// netConnHealth implements the TCP/Unix/TLS Health check: a short
+// read-deadline peek that distinguishes "alive, nothing to read yet" from
+// "the peer closed the connection" without consuming any real message
+// bytes. conn is expected to be a net.Conn (true for every Transport in
+// this file except the stdio one); anything else is reported healthy, since
+// there's no portable way to probe it non-destructively.
+func netConnHealth(conn io.ReadWriteCloser) error {
+	nc, ok := conn.(net.Conn)
+	if !ok {
+		return nil
+	}
+
+	if err := nc.SetReadDeadline(time.Now().Add(time.Millisecond)); err != nil {
+		return err
+	}
+	defer nc.SetReadDeadline(time.Time{})
+
+	one := make([]byte, 1)
+	if _, err := nc.Read(one); err != nil {
+		if ne, ok := err.(net.Error); ok && ne.Timeout() {
+			return nil
+		}
+		return err
+	}
+	return nil
+}
+
+// tcpTransport dials Session Manager over plain TCP - the original, and
+// still default, way SessionClient reaches the daemon.
+type tcpTransport struct {
+	addr string
+}
+
+// NewTCPTransport builds the TCP Transport used by SessionClient before
+// Transport existed, so existing callers (SessionAdapter) keep working with
+// a one-line change at their NewSessionClient call site.
+func NewTCPTransport(host string, port int) Transport {
+	return &tcpTransport{addr: fmt.Sprintf("%s:%d", host, port)}
+}
+
+func (t *tcpTransport) Dial(ctx context.Context) (io.ReadWriteCloser, error) {
+	var d net.Dialer
+	return d.DialContext(ctx, "tcp", t.addr)
+}
+
+func (t *tcpTransport) Health(conn io.ReadWriteCloser) error { return netConnHealth(conn) }
+func (t *tcpTransport) String() string                       { return fmt.Sprintf("tcp %s", t.addr) }
+
+// unixTransport dials Session Manager over a Unix domain socket - lower
+// overhead than TCP and no port to allocate or firewall, the way most
+// same-host LSP daemons prefer to be reached.
+type unixTransport struct {
+	path string
+}
+
+// NewUnixTransport builds a Transport that dials the Unix domain socket at
+// path.
+func NewUnixTransport(path string) Transport {
+	return &unixTransport{path: path}
+}
+
+func (t *unixTransport) Dial(ctx context.Context) (io.ReadWriteCloser, error) {
+	var d net.Dialer
+	return d.DialContext(ctx, "unix", t.path)
+}
+
+func (t *unixTransport) Health(conn io.ReadWriteCloser) error { return netConnHealth(conn) }
+func (t *unixTransport) String() string                       { return fmt.Sprintf("unix %s", t.path) }
+
+// tlsTransport dials Session Manager over TCP wrapped in TLS, with optional
+// mutual auth, for multi-tenant deployments where the daemon isn't reachable
+// only over a trusted loopback/Unix socket. Like wsTLSConfig on
+// LanguageClient (see websocket_client.go), it takes a pre-built
+// *tls.Config from the caller rather than constructing one from cert/key
+// paths itself - that's the caller's concern (see cmd/lsp-proxy's TLS
+// setup), not the transport's.
+type tlsTransport struct {
+	addr   string
+	config *tls.Config
+}
+
+// NewTLSTransport builds a Transport that dials host:port and performs a
+// TLS handshake using config (which may set ClientCAs/Certificates for
+// mutual auth).
+func NewTLSTransport(host string, port int, config *tls.Config) Transport {
+	return &tlsTransport{addr: fmt.Sprintf("%s:%d", host, port), config: config}
+}
+
+func (t *tlsTransport) Dial(ctx context.Context) (io.ReadWriteCloser, error) {
+	d := tls.Dialer{Config: t.config}
+	return d.DialContext(ctx, "tcp", t.addr)
+}
+
+func (t *tlsTransport) Health(conn io.ReadWriteCloser) error { return netConnHealth(conn) }
+func (t *tlsTransport) String() string                       { return fmt.Sprintf("tls %s", t.addr) }
+
+// stdioTransport co-launches Session Manager as a subprocess and pipes its
+// stdin/stdout, so a single-user setup doesn't need to manage a separate
+// daemon lifecycle at all. Each Dial (including ones from SessionClient's
+// reconnect loop) kills any subprocess left over from a previous Dial and
+// starts a fresh one, since a dead pipe means the old process is gone too.
+type stdioTransport struct {
+	command string
+	args    []string
+
+	mu  sync.Mutex
+	cmd *exec.Cmd
+}
+
+// NewStdioTransport builds a Transport that runs command with args and
+// speaks Content-Length framed JSON-RPC over its stdin/stdout.
+func NewStdioTransport(command string, args []string) Transport {
+	return &stdioTransport{command: command, args: args}
+}
+
+func (t *stdioTransport) Dial(ctx context.Context) (io.ReadWriteCloser, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.cmd != nil && t.cmd.ProcessState == nil {
+		t.cmd.Process.Kill()
+		t.cmd.Wait()
+	}
+
+	// ctx only bounds the dial itself; callers cancel it once Dial returns,
+	// so it must not be tied to the subprocess's lifetime.
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	cmd := exec.Command(t.command, t.args...)
+	cmd.Stderr = os.Stderr
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, fmt.Errorf("stdio transport: stdin pipe: %w", err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("stdio transport: stdout pipe: %w", err)
+	}
+
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("stdio transport: start %s: %w", t.command, err)
+	}
+	t.cmd = cmd
+
+	return &stdioRWC{stdin: stdin, stdout: stdout}, nil
+}
+
+func (t *stdioTransport) Health(conn io.ReadWriteCloser) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.cmd == nil {
+		return fmt.Errorf("stdio transport: never dialed")
+	}
+	if t.cmd.ProcessState != nil {
+		return fmt.Errorf("stdio transport: subprocess exited: %v", t.cmd.ProcessState)
+	}
+	return nil
+}
+
+func (t *stdioTransport) String() string { return fmt.Sprintf("stdio %s", t.command) }
+
+// stdioRWC adapts a subprocess's piped stdin/stdout to io.ReadWriteCloser,
+// the same adapter role gorillaRWC plays for a WebSocket LanguageClient
+// connection (see websocket_client.go).
+type stdioRWC struct {
+	stdin  io.WriteCloser
+	stdout io.ReadCloser
+}
+
+func (s *stdioRWC) Read(p []byte) (int, error)  { return s.stdout.Read(p) }
+func (s *stdioRWC) Write(p []byte) (int, error) { return s.stdin.Write(p) }
+
+func (s *stdioRWC) Close() error {
+	werr := s.stdin.Close()
+	rerr := s.stdout.Close()
+	if werr != nil {
+		return werr
+	}
+	return rerr
+}
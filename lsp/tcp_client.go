@@ -4,7 +4,6 @@ import (
 	"context"
 	"fmt"
 	"net"
-	"os"
 	"strings"
 	"time"
 
@@ -81,7 +80,6 @@ func (lc *LanguageClient) ConnectTCP() (*LanguageClient, error) {
 	}
 
 	logger.Info(fmt.Sprintf("TCP connection established to %s", addr))
-	fmt.Fprintf(os.Stderr, "DEBUG TCP: connection established to %s\n", addr)
 
 	// Create cancellable context
 	ctx, cancel := context.WithCancel(context.Background())
@@ -92,57 +90,48 @@ func (lc *LanguageClient) ConnectTCP() (*LanguageClient, error) {
 	if lc.progress == nil {
 		lc.progress = NewProgressTracker()
 	}
-	handler := &ClientHandler{
-		progress: lc.progress,
+	if lc.diagCache == nil {
+		lc.diagCache = newDiagnosticCache()
 	}
+	handler := NewClientHandler(lc.progress, lc.diagCache)
+	lc.handler = handler
 
-	fmt.Fprintf(os.Stderr, "DEBUG TCP: creating JSON-RPC stream...\n")
+	logger.Debug(fmt.Sprintf("ConnectTCP: creating JSON-RPC stream for %s", addr))
 
 	// Create JSON-RPC stream over TCP connection
 	// LSP uses Content-Length headers, which VSCodeObjectCodec handles
 	stream := jsonrpc2.NewBufferedStream(conn, jsonrpc2.VSCodeObjectCodec{})
 
-	fmt.Fprintf(os.Stderr, "DEBUG TCP: creating JSON-RPC connection...\n")
-
 	jsonrpcLogger := &JSONRPCLogger{}
 	rpcConn := jsonrpc2.NewConn(ctx, stream, handler,
 		jsonrpc2.LogMessages(jsonrpcLogger),
 		jsonrpc2.SetLogger(jsonrpcLogger))
 
-	fmt.Fprintf(os.Stderr, "DEBUG TCP: JSON-RPC connection created\n")
-
 	// Check if connection is already closed
 	select {
 	case <-rpcConn.DisconnectNotify():
-		fmt.Fprintf(os.Stderr, "DEBUG TCP: Connection already disconnected!\n")
+		logger.Error(fmt.Sprintf("ConnectTCP: connection to %s closed immediately after creation", addr))
 		return nil, fmt.Errorf("connection closed immediately after creation")
 	default:
-		fmt.Fprintf(os.Stderr, "DEBUG TCP: Connection still alive\n")
 	}
 
 	// Monitor connection disconnects
 	go func() {
-		fmt.Fprintf(os.Stderr, "DEBUG TCP: Monitor goroutine started\n")
 		disconnectCh := rpcConn.DisconnectNotify()
 		select {
 		case <-disconnectCh:
-			logger.Error("DISCONNECT: TCP connection to LSP proxy was disconnected")
-			fmt.Fprintf(os.Stderr, "DEBUG TCP: DISCONNECT notified! Connection closed unexpectedly\n")
+			logger.Error(fmt.Sprintf("DISCONNECT: TCP connection to LSP proxy at %s was disconnected unexpectedly", addr))
 			lc.status = StatusDisconnected
 		case <-ctx.Done():
-			logger.Debug("DISCONNECT: Context cancelled for TCP connection")
-			fmt.Fprintf(os.Stderr, "DEBUG TCP: Context cancelled reason=%v\n", ctx.Err())
+			logger.Debug(fmt.Sprintf("DISCONNECT: context cancelled for TCP connection to %s: %v", addr, ctx.Err()))
 		}
-		fmt.Fprintf(os.Stderr, "DEBUG TCP: Monitor goroutine exiting\n")
 	}()
 
-	fmt.Fprintf(os.Stderr, "DEBUG TCP: Setting lc.conn...\n")
 	lc.conn = rpcConn
 	lc.status = StatusConnected
 	lc.lastInitialized = time.Now()
 
 	logger.Info("Successfully connected to LSP server via TCP proxy")
-	fmt.Fprintf(os.Stderr, "DEBUG TCP: ConnectTCP completed successfully\n")
 
 	return lc, nil
 }
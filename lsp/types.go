@@ -2,7 +2,10 @@ package lsp
 
 import (
 	"context"
+	"crypto/tls"
+	"encoding/json"
 	"net"
+	"net/http"
 	"os/exec"
 	"sync"
 	"time"
@@ -22,6 +25,19 @@ type GlobalConfig struct {
 	MaxLogFiles        int    `json:"max_log_files"`
 	MaxRestartAttempts int    `json:"max_restart_attempts"`
 	RestartDelayMs     int    `json:"restart_delay_ms"`
+
+	// Request pool defaults (see RequestPool). Any language server whose
+	// LanguageServerConfig doesn't set its own override inherits these;
+	// zero/unset here falls back to DefaultRequestPoolConfig.
+	TaskPoolSize    int `json:"task_pool_size,omitempty"`
+	TaskQueueLength int `json:"task_queue_length,omitempty"`
+	TaskQueueNumber int `json:"task_queue_number,omitempty"`
+	SubmitTimeoutMs int `json:"submit_timeout_ms,omitempty"`
+
+	// HammerTimeMs is not read by Reload: the drain time of a superseded
+	// connection comes from the per-server LanguageServerConfig.HammerTimeMs,
+	// falling back to DefaultHammerTime.
+	HammerTimeMs int `json:"hammer_time_ms,omitempty"`
 }
 
 // LanguageServerConfig represents configuration for a single language server
@@ -31,11 +47,129 @@ type LanguageServerConfig struct {
 	Languages             []string               `json:"languages,omitempty"`
 	Filetypes             []string               `json:"filetypes"`
 	InitializationOptions map[string]interface{} `json:"initialization_options,omitempty"`
-	
+
 	// WebSocket mode configuration (alternative to command/args)
 	Mode string `json:"mode,omitempty"` // "stdio" (default) or "websocket"
 	Host string `json:"host,omitempty"` // WebSocket host (e.g., "bsl-ls" or "localhost")
 	Port int    `json:"port,omitempty"` // WebSocket port (e.g., 9999)
+
+	// Request pool overrides for this language server; zero/unset falls
+	// back to GlobalConfig, then DefaultRequestPoolConfig. See
+	// RequestPoolConfigFrom.
+	TaskPoolSize    int `json:"task_pool_size,omitempty"`
+	TaskQueueLength int `json:"task_queue_length,omitempty"`
+	TaskQueueNumber int `json:"task_queue_number,omitempty"`
+	SubmitTimeoutMs int `json:"submit_timeout_ms,omitempty"`
+	HammerTimeMs    int `json:"hammer_time_ms,omitempty"`
+
+	// DNS re-resolution for websocket/tcp mode (see StartDNSResolver). Has
+	// no effect on stdio clients.
+	DNSRefreshMs           int  `json:"dns_refresh_ms,omitempty"`
+	DialTimeoutMs          int  `json:"dial_timeout_ms,omitempty"`
+	KeepExistingConnection bool `json:"keep_existing_connection,omitempty"`
+
+	// Posture is the pre-flight/continuous health check RunPostureCheck
+	// performs before (and after every reconnect of) this server. nil means
+	// "use the default checks" (require_exists and require_executable both
+	// on, no version pin); see RunPostureCheck.
+	Posture *PostureConfig `json:"posture,omitempty"`
+
+	// Warmup selects and configures the warm-up strategy StartWarmup/
+	// runWarmup (see bridge/warmup_strategy.go) uses for this server. nil
+	// means "use the default": a FileGlobStrategy over *.bsl plus the
+	// legacy BSL workspace/symbol probe, matching behavior before
+	// per-server strategies existed.
+	Warmup *WarmupStrategyConfig `json:"warmup,omitempty"`
+
+	// OnlyFeatures, if non-empty, restricts this server to exactly these
+	// feature names (e.g. "workspace_symbols", "references") when multiple
+	// servers are configured for the same language; see SupportsFeature.
+	// Mutually exclusive with ExceptFeatures in practice, but both are
+	// honored if set.
+	OnlyFeatures []string `json:"only-features,omitempty"`
+	// ExceptFeatures excludes these feature names from an otherwise
+	// unrestricted (or OnlyFeatures-restricted) server.
+	ExceptFeatures []string `json:"except-features,omitempty"`
+}
+
+// SupportsFeature reports whether this server is configured to handle
+// feature, per OnlyFeatures/ExceptFeatures. A server with neither list set
+// supports every feature. ExceptFeatures always wins over OnlyFeatures.
+func (c *LanguageServerConfig) SupportsFeature(feature string) bool {
+	for _, excluded := range c.ExceptFeatures {
+		if excluded == feature {
+			return false
+		}
+	}
+
+	if len(c.OnlyFeatures) == 0 {
+		return true
+	}
+
+	for _, allowed := range c.OnlyFeatures {
+		if allowed == feature {
+			return true
+		}
+	}
+
+	return false
+}
+
+// PostureConfig controls the pre-flight/continuous health check
+// RunPostureCheck performs for a language server before it's spawned/dialed
+// and on every reconnect, so a broken install or unreachable endpoint shows
+// up as a posture failure instead of a confusing initialize timeout.
+type PostureConfig struct {
+	// RequireExists fails the check if Command can't be resolved on $PATH
+	// (stdio mode only).
+	RequireExists bool `json:"require_exists"`
+	// RequireExecutable fails the check if the resolved binary isn't
+	// executable (stdio mode only).
+	RequireExecutable bool `json:"require_executable"`
+	// CommandSHA256, if set, pins the resolved binary to a known-good
+	// build: the check fails if the hex-encoded SHA-256 of its contents
+	// doesn't match (case-insensitive).
+	CommandSHA256 string `json:"command_sha256,omitempty"`
+	// MinVersion, if set, is the lowest acceptable dotted version; the
+	// check fails if the version captured via VersionArg/VersionRegex is
+	// lower.
+	MinVersion string `json:"min_version,omitempty"`
+	// VersionArg is the flag passed to Command to print its version.
+	// Defaults to "--version".
+	VersionArg string `json:"version_arg,omitempty"`
+	// VersionRegex extracts the version string from the probe's output; it
+	// must have exactly one capture group. Defaults to a plain dotted
+	// version pattern.
+	VersionRegex string `json:"version_regex,omitempty"`
+	// ProbeTimeoutMs bounds the version-probe subprocess (stdio mode) or
+	// the TCP dial (websocket/tcp mode). Defaults to DefaultProbeTimeout.
+	ProbeTimeoutMs int `json:"probe_timeout_ms,omitempty"`
+	// GoModuleDir, if set, is a directory containing the go.mod of the Go
+	// module this server's binary was built from. When set, RunHealthCheck
+	// additionally scans it with govulncheck (if available on $PATH) and
+	// reports any findings as HealthResult.Vulnerabilities. Leave unset for
+	// a server whose binary isn't a Go program the bridge built, or when
+	// govulncheck scanning isn't wanted.
+	GoModuleDir string `json:"go_module_dir,omitempty"`
+}
+
+// WarmupStrategyConfig selects and configures the bridge.WarmupStrategy a
+// server's warm-up run uses (see LanguageServerConfig.Warmup and
+// bridge/warmup_strategy.go's resolveWarmupStrategy).
+type WarmupStrategyConfig struct {
+	// Kind selects the strategy: "file_glob" (the default if empty or
+	// unrecognized), "workspace_symbol_probe", or "noop".
+	Kind string `json:"kind,omitempty"`
+	// Patterns is the set of filepath.Match patterns (matched against file
+	// basenames, e.g. "*.bsl") a "file_glob" strategy opens. Ignored by
+	// other kinds.
+	Patterns []string `json:"patterns,omitempty"`
+	// MaxFiles bounds how many matched files a "file_glob" strategy opens.
+	// Defaults to 5 if <= 0. Ignored by other kinds.
+	MaxFiles int `json:"max_files,omitempty"`
+	// Queries is the set of workspace/symbol probe strings a
+	// "workspace_symbol_probe" strategy issues. Ignored by other kinds.
+	Queries []string `json:"queries,omitempty"`
 }
 
 // GetCommand implements types.LanguageServerConfigProvider
@@ -94,6 +228,43 @@ type LSPServerConfig struct {
 	ExtensionLanguageMap map[string]types.Language                     `json:"extension_language_map,omitempty"`
 }
 
+// ServerConfigProvider answers a single language server's
+// workspace/configuration requests, keyed by
+// ConfigurationParams.Items[].Section - see ClientHandler.SetConfigProvider.
+// It's backed by that server's own LanguageServerConfig.InitializationOptions,
+// so BSL LS (and anything else that re-asks for config it was already given
+// at initialize) sees the same diagnostic language, formatter settings, and
+// project paths instead of an empty reply.
+type ServerConfigProvider struct {
+	sections map[string]any
+}
+
+// NewServerConfigProvider builds a ServerConfigProvider from cfg's
+// InitializationOptions, keyed by top-level key - the same keys BSL LS (and
+// the other servers this bridge drives) use as workspace/configuration
+// section names.
+func NewServerConfigProvider(cfg LanguageServerConfig) *ServerConfigProvider {
+	sections := make(map[string]any, len(cfg.InitializationOptions))
+	for k, v := range cfg.InitializationOptions {
+		sections[k] = v
+	}
+	return &ServerConfigProvider{sections: sections}
+}
+
+// Section returns the configuration value for section, or the whole set of
+// sections if section is empty (a server asking for its global config
+// rather than one particular section). Returns nil for an unknown section,
+// or if p itself is nil (no provider installed).
+func (p *ServerConfigProvider) Section(section string) any {
+	if p == nil {
+		return nil
+	}
+	if section == "" {
+		return p.sections
+	}
+	return p.sections[section]
+}
+
 // LanguageClient wraps a Language Server Protocol client connection
 type LanguageClient struct {
 	mu                 sync.RWMutex
@@ -104,8 +275,41 @@ type LanguageClient struct {
 	clientCapabilities protocol.ClientCapabilities
 	serverCapabilities protocol.ServerCapabilities
 
+	// rawServerCapabilities is the raw "capabilities" object from the
+	// InitializeResult, kept because ServerCapabilities' boolean-or-options
+	// union fields (renameProvider, diagnosticProvider, ...) aren't unwrapped
+	// by the generated protocol types. Supports* probes this instead.
+	rawServerCapabilities json.RawMessage
+
 	tokenParser types.SemanticTokensParserProvider
 	progress    *ProgressTracker
+	handler     *ClientHandler
+	diagCache   *diagnosticCache
+
+	// progressTokenSeq is not used by token generation: newProgressToken
+	// draws client-initiated workDone progress tokens at random.
+	progressTokenSeq int64
+
+	semTokMu      sync.Mutex
+	semTokResults map[string]semanticTokensCacheEntry // uri -> last full/delta result
+
+	pool *RequestPool
+
+	// Graceful restart (see Reload). reloadMu serializes reloads so two
+	// concurrent config changes can't race swapping the connection out
+	// from under each other.
+	reloadMu   sync.Mutex
+	hammerTime time.Duration
+
+	// Background DNS re-resolution for websocket/tcp mode (see
+	// StartDNSResolver); nil when not started or after StopDNSResolver.
+	dnsMu       sync.Mutex
+	dnsResolver *dnsResolverState
+
+	// Posture checks (see RunPostureCheck/CheckPosture); lastPosture is the
+	// most recent result, recorded before every connect/Reload attempt.
+	postureMu   sync.Mutex
+	lastPosture PostureResult
 
 	workspacePaths []string
 
@@ -132,4 +336,36 @@ type LanguageClient struct {
 	connectionTimeout     time.Duration
 	idleTimeout           time.Duration
 	restartDelay          time.Duration
+
+	// WebSocket reconnection
+	autoReconnect bool
+	onReconnectMu sync.Mutex
+	onReconnect   func(attempt int, err error)
+	openedDocsMu  sync.Mutex
+	openedDocs    map[string]openedDoc
+
+	// WebSocket keepalive
+	PingInterval time.Duration
+	PongWait     time.Duration
+
+	// WebSocket dial options (TLS, auth headers, proxy)
+	wsURL       string
+	wsHeaders   http.Header
+	wsTLSConfig *tls.Config
+	wsRWC       *gorillaRWC
+
+	// Compression enables RFC 7692 permessage-deflate negotiation for the
+	// WebSocket transport; CompressionLevel is passed to flate (1-9,
+	// default flate.DefaultCompression). Disable for CPU-bound local
+	// servers where the deflate overhead isn't worth the bandwidth saved.
+	Compression      bool
+	CompressionLevel int
+}
+
+// openedDoc remembers a textDocument/didOpen call so it can be replayed
+// against a freshly (re)connected server.
+type openedDoc struct {
+	languageId protocol.LanguageKind
+	text       string
+	version    int32
 }
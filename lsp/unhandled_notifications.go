@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -19,11 +20,81 @@ const (
 	unhandledNotifInfo  unhandledNotifLevel = "info"
 )
 
+// unhandledNotifRingSize bounds how many entries UnhandledNotificationRingSnapshot
+// keeps per method; old entries fall off as new ones arrive.
+const unhandledNotifRingSize = 50
+
+// UnhandledNotificationPolicy controls how one LSP notification method (or
+// the default, for methods without an explicit override) is surfaced when
+// the bridge has no handler for it: how noisy logging is (Level), how
+// often it's allowed to fire before being rate-limited (Window/Burst), how
+// much of the raw params blob gets kept (MaxParamBytes), and whether to
+// ignore it outright (Drop) - useful for a notification a BSL language
+// server sends on every keystroke that nobody needs to see.
+type UnhandledNotificationPolicy struct {
+	Level         unhandledNotifLevel
+	Window        time.Duration
+	Burst         int
+	MaxParamBytes int
+	Drop          bool
+}
+
+// unhandledNotifConfig is the resolved policy set for a process: a default
+// policy plus any per-method overrides loaded from
+// MCP_LSP_UNHANDLED_NOTIFICATIONS_CONFIG.
 type unhandledNotifConfig struct {
-	level         unhandledNotifLevel
-	window        time.Duration
-	burstPerKey   int
-	maxParamBytes int
+	defaultPolicy UnhandledNotificationPolicy
+	methods       map[string]UnhandledNotificationPolicy
+}
+
+func (cfg unhandledNotifConfig) policyFor(method string) UnhandledNotificationPolicy {
+	if p, ok := cfg.methods[method]; ok {
+		return p
+	}
+	return cfg.defaultPolicy
+}
+
+// unhandledNotificationPolicyFileEntry mirrors one "default"/"methods.*"
+// entry in the JSON config file. Fields are pointers/strings rather than
+// the resolved UnhandledNotificationPolicy types so "not set" (inherit
+// from default) is distinguishable from "set to the zero value".
+type unhandledNotificationPolicyFileEntry struct {
+	Level         string `json:"level"`
+	Window        string `json:"window"`
+	Burst         *int   `json:"burst"`
+	MaxParamBytes *int   `json:"maxParamBytes"`
+	Drop          *bool  `json:"drop"`
+}
+
+type unhandledNotificationPolicyFile struct {
+	Default unhandledNotificationPolicyFileEntry            `json:"default"`
+	Methods map[string]unhandledNotificationPolicyFileEntry `json:"methods"`
+}
+
+// apply overlays the entry's set fields onto base, returning a fully
+// resolved policy.
+func (e unhandledNotificationPolicyFileEntry) apply(base UnhandledNotificationPolicy) UnhandledNotificationPolicy {
+	policy := base
+
+	if e.Level != "" {
+		policy.Level = unhandledNotifLevel(e.Level)
+	}
+	if e.Window != "" {
+		if d, err := time.ParseDuration(e.Window); err == nil && d > 0 {
+			policy.Window = d
+		}
+	}
+	if e.Burst != nil {
+		policy.Burst = *e.Burst
+	}
+	if e.MaxParamBytes != nil {
+		policy.MaxParamBytes = *e.MaxParamBytes
+	}
+	if e.Drop != nil {
+		policy.Drop = *e.Drop
+	}
+
+	return policy
 }
 
 type unhandledNotifBucket struct {
@@ -39,51 +110,273 @@ var (
 
 	unhandledNotifMu      sync.Mutex
 	unhandledNotifBuckets = map[string]*unhandledNotifBucket{}
+
+	unhandledNotifSinksMu sync.RWMutex
+	unhandledNotifSinks   = defaultUnhandledNotifSinks()
 )
 
+func defaultUnhandledNotifSinks() []UnhandledNotificationSink {
+	return []UnhandledNotificationSink{loggerSink{}, defaultUnhandledNotifRing, defaultUnhandledNotifMetrics}
+}
+
+// SetUnhandledNotificationSinks overrides the package-level sink fan-out -
+// tests use this to substitute a fake sink instead of asserting against
+// shared log output, the real ring buffer, or the real metrics counters.
+// Pass nil to restore the default (logger + ring buffer + metrics).
+func SetUnhandledNotificationSinks(sinks []UnhandledNotificationSink) {
+	unhandledNotifSinksMu.Lock()
+	defer unhandledNotifSinksMu.Unlock()
+
+	if sinks == nil {
+		unhandledNotifSinks = defaultUnhandledNotifSinks()
+		return
+	}
+	unhandledNotifSinks = sinks
+}
+
+// UnhandledNotificationEvent is one occurrence a Sink is asked to record:
+// either a notification actually logged (Suppressed == 0) or a
+// suppression rollup fired at window close (Suppressed > 0, Message
+// summarizing how many were dropped since the last rollup).
+type UnhandledNotificationEvent struct {
+	Method     string
+	Level      unhandledNotifLevel
+	Message    string
+	Params     json.RawMessage
+	Time       time.Time
+	Suppressed int
+}
+
+// UnhandledNotificationSink receives every unhandled-notification event
+// logUnhandledNotification's policy decides is worth recording. Implementations
+// must not block meaningfully - they run inline on the LSP read loop.
+type UnhandledNotificationSink interface {
+	Record(event UnhandledNotificationEvent)
+}
+
+// loggerSink is the pre-existing behavior (log a line via the package
+// logger), now expressed as one sink among several instead of the only
+// option.
+type loggerSink struct{}
+
+func (loggerSink) Record(event UnhandledNotificationEvent) {
+	switch event.Level {
+	case unhandledNotifInfo:
+		logger.Info(event.Message)
+	default:
+		logger.Debug(event.Message)
+	}
+}
+
+// unhandledNotifRingEntry is one recorded occurrence kept for on-demand
+// inspection (see UnhandledNotificationRingSnapshot and the
+// RegisterUnhandledNotificationsTool MCP tool it backs).
+type unhandledNotifRingEntry struct {
+	Time       time.Time
+	Message    string
+	Params     json.RawMessage
+	Suppressed int
+}
+
+// unhandledNotifRing is a bounded in-memory ring per method, so an agent
+// can ask "what has this language server been sending that we don't
+// handle?" without scraping logs.
+type unhandledNotifRing struct {
+	mu      sync.Mutex
+	entries map[string][]unhandledNotifRingEntry
+}
+
+func newUnhandledNotifRing() *unhandledNotifRing {
+	return &unhandledNotifRing{entries: make(map[string][]unhandledNotifRingEntry)}
+}
+
+func (r *unhandledNotifRing) Record(event UnhandledNotificationEvent) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entries := append(r.entries[event.Method], unhandledNotifRingEntry{
+		Time:       event.Time,
+		Message:    event.Message,
+		Params:     event.Params,
+		Suppressed: event.Suppressed,
+	})
+	if len(entries) > unhandledNotifRingSize {
+		entries = entries[len(entries)-unhandledNotifRingSize:]
+	}
+	r.entries[event.Method] = entries
+}
+
+// Snapshot returns a copy of the ring's entries; method == "" returns
+// every method's buffer.
+func (r *unhandledNotifRing) Snapshot(method string) map[string][]unhandledNotifRingEntry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	out := make(map[string][]unhandledNotifRingEntry)
+	for m, entries := range r.entries {
+		if method != "" && m != method {
+			continue
+		}
+		cp := make([]unhandledNotifRingEntry, len(entries))
+		copy(cp, entries)
+		out[m] = cp
+	}
+	return out
+}
+
+var defaultUnhandledNotifRing = newUnhandledNotifRing()
+
+// UnhandledNotificationRingSnapshot exposes the package-level ring buffer
+// for status/tool reporting; method == "" returns every method tracked so
+// far. Each entry's Time/Message/Params is as recorded; Suppressed > 0
+// marks a window-rollover summary rather than a single live notification.
+func UnhandledNotificationRingSnapshot(method string) map[string][]unhandledNotifRingEntry {
+	return defaultUnhandledNotifRing.Snapshot(method)
+}
+
+// UnhandledNotificationMetric mirrors one `lsp_unhandled_notifications_total`
+// or `lsp_unhandled_notifications_suppressed_total` sample. Level is empty
+// for the suppressed counter, which the rate limiter doesn't split by
+// level.
+type UnhandledNotificationMetric struct {
+	Method string
+	Level  string
+	Count  int64
+}
+
+// unhandledNotifMetrics accumulates simple Prometheus-shaped counters in
+// memory. No `/metrics` HTTP endpoint exists anywhere in this tree yet
+// (it would need its own exporter wiring, out of scope here);
+// UnhandledNotificationMetricsSnapshot is the hook such an endpoint would
+// scrape once one exists.
+type unhandledNotifMetrics struct {
+	mu         sync.Mutex
+	emitted    map[string]int64 // key: method + "|" + level
+	suppressed map[string]int64 // key: method
+}
+
+func newUnhandledNotifMetrics() *unhandledNotifMetrics {
+	return &unhandledNotifMetrics{emitted: map[string]int64{}, suppressed: map[string]int64{}}
+}
+
+func (m *unhandledNotifMetrics) Record(event UnhandledNotificationEvent) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if event.Suppressed > 0 {
+		m.suppressed[event.Method] += int64(event.Suppressed)
+		return
+	}
+	m.emitted[event.Method+"|"+string(event.Level)]++
+}
+
+func (m *unhandledNotifMetrics) Snapshot() []UnhandledNotificationMetric {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	out := make([]UnhandledNotificationMetric, 0, len(m.emitted)+len(m.suppressed))
+	for key, count := range m.emitted {
+		method, level, _ := strings.Cut(key, "|")
+		out = append(out, UnhandledNotificationMetric{Method: method, Level: level, Count: count})
+	}
+	for method, count := range m.suppressed {
+		out = append(out, UnhandledNotificationMetric{Method: method, Count: count})
+	}
+	return out
+}
+
+var defaultUnhandledNotifMetrics = newUnhandledNotifMetrics()
+
+// UnhandledNotificationMetricsSnapshot returns the current
+// lsp_unhandled_notifications_total / lsp_unhandled_notifications_suppressed_total
+// counters.
+func UnhandledNotificationMetricsSnapshot() []UnhandledNotificationMetric {
+	return defaultUnhandledNotifMetrics.Snapshot()
+}
+
 func loadUnhandledNotifConfig() unhandledNotifConfig {
 	cfg := unhandledNotifConfig{
-		level:         unhandledNotifDebug,
-		window:        10 * time.Second,
-		burstPerKey:   3,
-		maxParamBytes: 4096,
+		defaultPolicy: UnhandledNotificationPolicy{
+			Level:         unhandledNotifDebug,
+			Window:        10 * time.Second,
+			Burst:         3,
+			MaxParamBytes: 4096,
+		},
 	}
 
+	// Legacy env vars: still the baseline default policy, and the only
+	// configuration surface when MCP_LSP_UNHANDLED_NOTIFICATIONS_CONFIG
+	// isn't set (or fails to load).
 	if v := os.Getenv("MCP_LSP_UNHANDLED_NOTIFICATIONS_LEVEL"); v != "" {
 		switch unhandledNotifLevel(v) {
 		case unhandledNotifOff, unhandledNotifDebug, unhandledNotifInfo:
-			cfg.level = unhandledNotifLevel(v)
+			cfg.defaultPolicy.Level = unhandledNotifLevel(v)
 		}
 	}
 
 	if v := os.Getenv("MCP_LSP_UNHANDLED_NOTIFICATIONS_WINDOW"); v != "" {
 		if d, err := time.ParseDuration(v); err == nil && d > 0 {
-			cfg.window = d
+			cfg.defaultPolicy.Window = d
 		}
 	}
 
 	if v := os.Getenv("MCP_LSP_UNHANDLED_NOTIFICATIONS_BURST"); v != "" {
 		if n, err := strconv.Atoi(v); err == nil && n >= 0 {
-			cfg.burstPerKey = n
+			cfg.defaultPolicy.Burst = n
 		}
 	}
 
 	if v := os.Getenv("MCP_LSP_UNHANDLED_NOTIFICATIONS_MAX_PARAM_BYTES"); v != "" {
 		if n, err := strconv.Atoi(v); err == nil && n >= 0 {
-			cfg.maxParamBytes = n
+			cfg.defaultPolicy.MaxParamBytes = n
+		}
+	}
+
+	if path := os.Getenv("MCP_LSP_UNHANDLED_NOTIFICATIONS_CONFIG"); path != "" {
+		loaded, err := loadUnhandledNotifConfigFile(path, cfg.defaultPolicy)
+		if err != nil {
+			logger.Warn(fmt.Sprintf("unhandled notification policy: %s: %v (falling back to env/defaults)", path, err))
+		} else {
+			cfg = loaded
 		}
 	}
 
 	return cfg
 }
 
+// loadUnhandledNotifConfigFile reads and parses
+// MCP_LSP_UNHANDLED_NOTIFICATIONS_CONFIG. The format is JSON only; YAML is
+// not accepted, since this tree doesn't vendor a YAML parser.
+func loadUnhandledNotifConfigFile(path string, base UnhandledNotificationPolicy) (unhandledNotifConfig, error) {
+	data, err := os.ReadFile(path) // #nosec G304 -- operator-provided config path, not user input
+	if err != nil {
+		return unhandledNotifConfig{}, fmt.Errorf("read: %w", err)
+	}
+
+	var file unhandledNotificationPolicyFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return unhandledNotifConfig{}, fmt.Errorf("parse: %w", err)
+	}
+
+	cfg := unhandledNotifConfig{defaultPolicy: file.Default.apply(base)}
+
+	if len(file.Methods) > 0 {
+		cfg.methods = make(map[string]UnhandledNotificationPolicy, len(file.Methods))
+		for method, entry := range file.Methods {
+			cfg.methods[method] = entry.apply(cfg.defaultPolicy)
+		}
+	}
+
+	return cfg, nil
+}
+
 func logUnhandledNotification(method string, rawParams *json.RawMessage) {
 	unhandledNotifOnce.Do(func() {
 		unhandledNotifCfg = loadUnhandledNotifConfig()
 	})
 
-	cfg := unhandledNotifCfg
-	if cfg.level == unhandledNotifOff {
+	policy := unhandledNotifCfg.policyFor(method)
+	if policy.Level == unhandledNotifOff || policy.Drop {
 		return
 	}
 
@@ -97,11 +390,12 @@ func logUnhandledNotification(method string, rawParams *json.RawMessage) {
 	}
 
 	// Window rollover: flush suppression summary and reset counters.
-	if cfg.window > 0 && now.Sub(b.windowStart) >= cfg.window {
+	if policy.Window > 0 && now.Sub(b.windowStart) >= policy.Window {
 		if b.suppressed > 0 {
-			msg := fmt.Sprintf("Unhandled notification suppressed: method=%s suppressed=%d window=%s", method, b.suppressed, cfg.window)
+			suppressedCount := b.suppressed
+			msg := fmt.Sprintf("Unhandled notification suppressed: method=%s suppressed=%d window=%s", method, suppressedCount, policy.Window)
 			unhandledNotifMu.Unlock()
-			logUnhandledByLevel(cfg.level, msg)
+			emitUnhandledNotification(method, policy.Level, msg, nil, now, suppressedCount)
 			unhandledNotifMu.Lock()
 		}
 		b.windowStart = now
@@ -111,16 +405,17 @@ func logUnhandledNotification(method string, rawParams *json.RawMessage) {
 	}
 
 	// Rate-limit (per method).
-	if cfg.burstPerKey == 0 || b.emitted >= cfg.burstPerKey {
+	if policy.Burst == 0 || b.emitted >= policy.Burst {
 		b.suppressed++
-		needSuppressMsg := !b.suppressMsg && cfg.burstPerKey > 0
+		needSuppressMsg := !b.suppressMsg && policy.Burst > 0
 		if needSuppressMsg {
 			b.suppressMsg = true
 		}
 		unhandledNotifMu.Unlock()
 
 		if needSuppressMsg {
-			logUnhandledByLevel(cfg.level, fmt.Sprintf("Unhandled notification flood detected: method=%s burst=%d window=%s (suppressing further)", method, cfg.burstPerKey, cfg.window))
+			msg := fmt.Sprintf("Unhandled notification flood detected: method=%s burst=%d window=%s (suppressing further)", method, policy.Burst, policy.Window)
+			emitUnhandledNotification(method, policy.Level, msg, nil, now, 0)
 		}
 		return
 	}
@@ -129,27 +424,38 @@ func logUnhandledNotification(method string, rawParams *json.RawMessage) {
 	unhandledNotifMu.Unlock()
 
 	msg := fmt.Sprintf("Unhandled notification: %s", method)
-	if rawParams != nil && len(*rawParams) > 0 && cfg.maxParamBytes != 0 {
+	var keptParams json.RawMessage
+	if rawParams != nil && len(*rawParams) > 0 && policy.MaxParamBytes != 0 {
 		p := []byte(*rawParams)
-		if cfg.maxParamBytes > 0 && len(p) > cfg.maxParamBytes {
-			p = p[:cfg.maxParamBytes]
+		if policy.MaxParamBytes > 0 && len(p) > policy.MaxParamBytes {
+			p = p[:policy.MaxParamBytes]
 			msg = fmt.Sprintf("%s params=%s...(truncated)", msg, string(p))
 		} else {
 			msg = fmt.Sprintf("%s params=%s", msg, string(p))
 		}
+		keptParams = json.RawMessage(p)
 	} else if rawParams == nil || len(*rawParams) == 0 {
 		msg = fmt.Sprintf("%s (no params)", msg)
 	}
 
-	logUnhandledByLevel(cfg.level, msg)
+	emitUnhandledNotification(method, policy.Level, msg, keptParams, now, 0)
 }
 
-func logUnhandledByLevel(level unhandledNotifLevel, msg string) {
-	switch level {
-	case unhandledNotifInfo:
-		logger.Info(msg)
-	default:
-		// default/debug
-		logger.Debug(msg)
+func emitUnhandledNotification(method string, level unhandledNotifLevel, msg string, params json.RawMessage, at time.Time, suppressed int) {
+	event := UnhandledNotificationEvent{
+		Method:     method,
+		Level:      level,
+		Message:    msg,
+		Params:     params,
+		Time:       at,
+		Suppressed: suppressed,
+	}
+
+	unhandledNotifSinksMu.RLock()
+	sinks := unhandledNotifSinks
+	unhandledNotifSinksMu.RUnlock()
+
+	for _, sink := range sinks {
+		sink.Record(event)
 	}
 }
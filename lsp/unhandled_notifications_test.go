@@ -0,0 +1,113 @@
+package lsp
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+type fakeUnhandledNotifSink struct {
+	events []UnhandledNotificationEvent
+}
+
+func (f *fakeUnhandledNotifSink) Record(event UnhandledNotificationEvent) {
+	f.events = append(f.events, event)
+}
+
+func resetUnhandledNotifState(t *testing.T) {
+	t.Helper()
+	unhandledNotifOnce = sync.Once{}
+	unhandledNotifCfg = unhandledNotifConfig{}
+	unhandledNotifMu.Lock()
+	unhandledNotifBuckets = map[string]*unhandledNotifBucket{}
+	unhandledNotifMu.Unlock()
+	SetUnhandledNotificationSinks(nil)
+}
+
+func TestUnhandledNotificationPolicyFileOverridesMethod(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "policy.json")
+	body := `{
+		"default": {"level": "debug", "burst": 3, "window": "10s"},
+		"methods": {
+			"bsl/reportIssues": {"level": "info", "burst": 10}
+		}
+	}`
+	if err := os.WriteFile(path, []byte(body), 0o644); err != nil {
+		t.Fatalf("failed to write fixture: %v", err)
+	}
+
+	cfg, err := loadUnhandledNotifConfigFile(path, UnhandledNotificationPolicy{
+		Level: unhandledNotifDebug, Window: 10 * time.Second, Burst: 3, MaxParamBytes: 4096,
+	})
+	if err != nil {
+		t.Fatalf("loadUnhandledNotifConfigFile failed: %v", err)
+	}
+
+	if cfg.defaultPolicy.Level != unhandledNotifDebug {
+		t.Fatalf("expected default level debug, got %s", cfg.defaultPolicy.Level)
+	}
+
+	override := cfg.policyFor("bsl/reportIssues")
+	if override.Level != unhandledNotifInfo || override.Burst != 10 {
+		t.Fatalf("unexpected override policy: %+v", override)
+	}
+	// Unset fields inherit from the (already-resolved) default.
+	if override.Window != 10*time.Second {
+		t.Fatalf("expected override to inherit window from default, got %s", override.Window)
+	}
+
+	fallback := cfg.policyFor("workspace/didChangeConfiguration")
+	if fallback.Level != unhandledNotifDebug || fallback.Burst != 3 {
+		t.Fatalf("unexpected fallback policy: %+v", fallback)
+	}
+}
+
+func TestUnhandledNotificationPolicyDropSuppressesEntirely(t *testing.T) {
+	resetUnhandledNotifState(t)
+	defer resetUnhandledNotifState(t)
+
+	unhandledNotifOnce.Do(func() {}) // pretend config already loaded
+	unhandledNotifCfg = unhandledNotifConfig{
+		defaultPolicy: UnhandledNotificationPolicy{Level: unhandledNotifDebug, Window: time.Minute, Burst: 5},
+		methods: map[string]UnhandledNotificationPolicy{
+			"$/progress": {Drop: true},
+		},
+	}
+
+	sink := &fakeUnhandledNotifSink{}
+	SetUnhandledNotificationSinks([]UnhandledNotificationSink{sink})
+
+	raw := json.RawMessage(`{"token":"1"}`)
+	logUnhandledNotification("$/progress", &raw)
+
+	if len(sink.events) != 0 {
+		t.Fatalf("expected a dropped method to record no events, got %d", len(sink.events))
+	}
+}
+
+func TestUnhandledNotificationRingBufferRecordsEmittedEvents(t *testing.T) {
+	resetUnhandledNotifState(t)
+	defer resetUnhandledNotifState(t)
+
+	unhandledNotifOnce.Do(func() {}) // pretend config already loaded
+	unhandledNotifCfg = unhandledNotifConfig{
+		defaultPolicy: UnhandledNotificationPolicy{Level: unhandledNotifDebug, Window: time.Minute, Burst: 5, MaxParamBytes: 4096},
+	}
+
+	SetUnhandledNotificationSinks([]UnhandledNotificationSink{defaultUnhandledNotifRing})
+
+	raw := json.RawMessage(`{"method":"bsl/reportIssues"}`)
+	logUnhandledNotification("bsl/reportIssues", &raw)
+
+	entries := UnhandledNotificationRingSnapshot("bsl/reportIssues")["bsl/reportIssues"]
+	if len(entries) == 0 {
+		t.Fatal("expected the ring buffer to record the emitted event")
+	}
+	if entries[len(entries)-1].Suppressed != 0 {
+		t.Fatalf("expected a live event, got a suppression rollup: %+v", entries[len(entries)-1])
+	}
+}
@@ -0,0 +1,349 @@
+package lsp
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"rockerboo/mcp-lsp-bridge/types"
+
+	"github.com/myleshyson/lsprotocol-go/protocol"
+)
+
+// WatchKind is the workspace/didChangeWatchedFiles registerOptions bitmask
+// (LSP 3.17 FileSystemWatcher.kind): which of create/change/delete a given
+// glob watcher cares about. The zero value is treated as WatchAll, matching
+// the spec's "kind is optional, defaults to 7" rule.
+type WatchKind int
+
+const (
+	WatchCreate WatchKind = 1 << 0
+	WatchChange WatchKind = 1 << 1
+	WatchDelete WatchKind = 1 << 2
+	WatchAll    WatchKind = WatchCreate | WatchChange | WatchDelete
+)
+
+func watchKindFor(t protocol.FileChangeType) WatchKind {
+	switch t {
+	case protocol.FileChangeTypeCreated:
+		return WatchCreate
+	case protocol.FileChangeTypeDeleted:
+		return WatchDelete
+	default:
+		return WatchChange
+	}
+}
+
+// globWatcher is one FileSystemWatcher entry, compiled down to a regexp so
+// Matches doesn't re-parse the pattern on every fsnotify event.
+type globWatcher struct {
+	pattern string
+	kind    WatchKind
+	re      *regexp.Regexp
+}
+
+func (g globWatcher) matches(path string, kind WatchKind) bool {
+	return g.kind&kind != 0 && g.re.MatchString(path)
+}
+
+// globToRegexp translates the glob dialect the LSP spec uses for
+// FileSystemWatcher.globPattern - "**" (any number of path segments), "*"
+// (anything but a path separator), "?" (one character) and "{a,b}"
+// (alternation) - into an equivalent anchored regexp. path/filepath.Match
+// doesn't support "**" or brace groups, hence the hand-rolled translation.
+func globToRegexp(pattern string) (*regexp.Regexp, error) {
+	var sb strings.Builder
+	sb.WriteString("^")
+
+	for i := 0; i < len(pattern); {
+		switch {
+		case strings.HasPrefix(pattern[i:], "**"):
+			sb.WriteString(".*")
+			i += 2
+		case pattern[i] == '*':
+			sb.WriteString("[^/]*")
+			i++
+		case pattern[i] == '?':
+			sb.WriteString("[^/]")
+			i++
+		case pattern[i] == '{':
+			end := strings.IndexByte(pattern[i:], '}')
+			if end < 0 {
+				sb.WriteString(regexp.QuoteMeta(pattern[i : i+1]))
+				i++
+				continue
+			}
+			alts := strings.Split(pattern[i+1:i+end], ",")
+			for j, alt := range alts {
+				alts[j] = regexp.QuoteMeta(alt)
+			}
+			sb.WriteString("(" + strings.Join(alts, "|") + ")")
+			i += end + 1
+		default:
+			sb.WriteString(regexp.QuoteMeta(pattern[i : i+1]))
+			i++
+		}
+	}
+
+	sb.WriteString("$")
+	return regexp.Compile(sb.String())
+}
+
+// rawFileSystemWatcher mirrors the wire shape of LSP's FileSystemWatcher
+// well enough to decode registerOptions without depending on whatever
+// concrete Go type lsprotocol-go gives RegisterOptions (it's typed as
+// interface{}/any there, and re-marshals fine either way). GlobPattern is
+// decoded from its raw form since the spec allows it to be either a bare
+// string or a RelativePattern{baseUri, pattern}; only the pattern half of a
+// RelativePattern is honored here, its baseUri is ignored.
+type rawFileSystemWatcher struct {
+	GlobPattern json.RawMessage `json:"globPattern"`
+	Kind        *int            `json:"kind"`
+}
+
+type rawDidChangeWatchedFilesOptions struct {
+	Watchers []rawFileSystemWatcher `json:"watchers"`
+}
+
+func decodeGlobPattern(raw json.RawMessage) (string, bool) {
+	var asString string
+	if err := json.Unmarshal(raw, &asString); err == nil {
+		return asString, true
+	}
+
+	var asRelative struct {
+		Pattern string `json:"pattern"`
+	}
+	if err := json.Unmarshal(raw, &asRelative); err == nil && asRelative.Pattern != "" {
+		return asRelative.Pattern, true
+	}
+
+	return "", false
+}
+
+// WatchDispatch records one coalesced file-change dispatch for watch_status
+// reporting (see WatchRegistry.RecentDispatches).
+type WatchDispatch struct {
+	Server types.LanguageServer
+	Uri    string
+	Type   protocol.FileChangeType
+	Time   time.Time
+}
+
+const maxWatchDispatchHistory = 50
+
+// pendingChange is one URI's in-flight debounce window: a change arrived,
+// a flush timer is running, and further changes for the same URI before
+// the timer fires get merged into it rather than dispatched separately.
+type pendingChange struct {
+	event protocol.FileEvent
+	timer *time.Timer
+}
+
+// WatchRegistry tracks, per connected language server, the glob watchers it
+// dynamically registered via workspace/didChangeWatchedFiles
+// (client/registerCapability), and debounces/coalesces fsnotify-driven file
+// events before they're forwarded as workspace/didChangeWatchedFiles
+// notifications (see bridge.MCPLSPBridge.forwardWorkspaceIndexEvents, the
+// existing consumer of workspace.Index this registry filters for).
+//
+// A server that never dynamically registers this method (many use only the
+// static workspace.didChangeWatchedFiles client capability with no
+// registerOptions) has no entry here; Matches treats that as "forward
+// everything", preserving the pre-existing unfiltered behavior.
+type WatchRegistry struct {
+	mu       sync.RWMutex
+	watchers map[types.LanguageServer][]globWatcher
+
+	debounce time.Duration
+	pendMu   sync.Mutex
+	pending  map[string]*pendingChange
+
+	dispatchMu sync.Mutex
+	dispatches []WatchDispatch
+}
+
+// NewWatchRegistry creates a registry with the given coalescing window
+// (e.g. 50ms to collapse a rapid Create+Change pair from a single save into
+// one notification). A non-positive debounce dispatches immediately.
+func NewWatchRegistry(debounce time.Duration) *WatchRegistry {
+	return &WatchRegistry{
+		watchers: make(map[types.LanguageServer][]globWatcher),
+		debounce: debounce,
+		pending:  make(map[string]*pendingChange),
+	}
+}
+
+// SetRegistration (re)compiles lang's glob watchers from the
+// client/registerCapability registration it sent for
+// workspace/didChangeWatchedFiles. Unparseable watcher entries are skipped
+// rather than failing the whole registration.
+func (wr *WatchRegistry) SetRegistration(lang types.LanguageServer, reg protocol.Registration) {
+	raw, err := json.Marshal(reg.RegisterOptions)
+	if err != nil {
+		return
+	}
+
+	var opts rawDidChangeWatchedFilesOptions
+	if err := json.Unmarshal(raw, &opts); err != nil {
+		return
+	}
+
+	watchers := make([]globWatcher, 0, len(opts.Watchers))
+	for _, w := range opts.Watchers {
+		pattern, ok := decodeGlobPattern(w.GlobPattern)
+		if !ok || pattern == "" {
+			continue
+		}
+
+		re, err := globToRegexp(pattern)
+		if err != nil {
+			continue
+		}
+
+		kind := WatchAll
+		if w.Kind != nil && *w.Kind != 0 {
+			kind = WatchKind(*w.Kind)
+		}
+
+		watchers = append(watchers, globWatcher{pattern: pattern, kind: kind, re: re})
+	}
+
+	wr.mu.Lock()
+	if len(watchers) == 0 {
+		delete(wr.watchers, lang)
+	} else {
+		wr.watchers[lang] = watchers
+	}
+	wr.mu.Unlock()
+}
+
+// Unregister drops lang's glob watchers (client/unregisterCapability),
+// reverting it to the unfiltered "forward everything" default.
+func (wr *WatchRegistry) Unregister(lang types.LanguageServer) {
+	wr.mu.Lock()
+	delete(wr.watchers, lang)
+	wr.mu.Unlock()
+}
+
+// Matches reports whether path should be forwarded to lang as the given
+// WatchKind. A server with no registered watchers matches everything.
+func (wr *WatchRegistry) Matches(lang types.LanguageServer, path string, kind WatchKind) bool {
+	wr.mu.RLock()
+	watchers, ok := wr.watchers[lang]
+	wr.mu.RUnlock()
+
+	if !ok {
+		return true
+	}
+
+	for _, w := range watchers {
+		if w.matches(path, kind) {
+			return true
+		}
+	}
+	return false
+}
+
+// Coalesce debounces change for (lang, change.Uri): if another change for
+// the same URI arrives within the registry's debounce window, they're
+// merged (Create+Change collapses to Change, anything+Delete collapses to
+// Delete - a file that's since been removed doesn't need its creation
+// reported) and the flush timer restarts, rather than calling emit twice.
+// emit is invoked from the timer's own goroutine once the window is quiet.
+func (wr *WatchRegistry) Coalesce(lang types.LanguageServer, change protocol.FileEvent, emit func(protocol.FileEvent)) {
+	key := string(lang) + "|" + string(change.Uri)
+
+	if wr.debounce <= 0 {
+		wr.recordDispatch(lang, change)
+		emit(change)
+		return
+	}
+
+	wr.pendMu.Lock()
+	defer wr.pendMu.Unlock()
+
+	if pc, ok := wr.pending[key]; ok {
+		pc.timer.Stop()
+		pc.event.Type = mergeFileChangeType(pc.event.Type, change.Type)
+		pc.timer = time.AfterFunc(wr.debounce, func() { wr.flush(key, lang, emit) })
+		return
+	}
+
+	pc := &pendingChange{event: change}
+	pc.timer = time.AfterFunc(wr.debounce, func() { wr.flush(key, lang, emit) })
+	wr.pending[key] = pc
+}
+
+func (wr *WatchRegistry) flush(key string, lang types.LanguageServer, emit func(protocol.FileEvent)) {
+	wr.pendMu.Lock()
+	pc, ok := wr.pending[key]
+	if ok {
+		delete(wr.pending, key)
+	}
+	wr.pendMu.Unlock()
+
+	if !ok {
+		return
+	}
+
+	wr.recordDispatch(lang, pc.event)
+	emit(pc.event)
+}
+
+// mergeFileChangeType collapses a second file-change notification for the
+// same URI arriving inside the debounce window into one: Delete always
+// wins (nothing downstream cares the file used to exist if it no longer
+// does), otherwise the newer type replaces the older one.
+func mergeFileChangeType(older, newer protocol.FileChangeType) protocol.FileChangeType {
+	if newer == protocol.FileChangeTypeDeleted || older == protocol.FileChangeTypeDeleted {
+		return protocol.FileChangeTypeDeleted
+	}
+	return newer
+}
+
+func (wr *WatchRegistry) recordDispatch(lang types.LanguageServer, change protocol.FileEvent) {
+	wr.dispatchMu.Lock()
+	defer wr.dispatchMu.Unlock()
+
+	wr.dispatches = append(wr.dispatches, WatchDispatch{
+		Server: lang,
+		Uri:    string(change.Uri),
+		Type:   change.Type,
+		Time:   time.Now(),
+	})
+	if len(wr.dispatches) > maxWatchDispatchHistory {
+		wr.dispatches = wr.dispatches[len(wr.dispatches)-maxWatchDispatchHistory:]
+	}
+}
+
+// RecentDispatches returns the most recent coalesced dispatches (oldest
+// first), for the watch_status MCP tool.
+func (wr *WatchRegistry) RecentDispatches() []WatchDispatch {
+	wr.dispatchMu.Lock()
+	defer wr.dispatchMu.Unlock()
+
+	out := make([]WatchDispatch, len(wr.dispatches))
+	copy(out, wr.dispatches)
+	return out
+}
+
+// ActiveGlobs returns the registered glob patterns per language server, for
+// the watch_status MCP tool. Servers with no dynamic registration (see
+// Matches) are omitted rather than reported with an empty list.
+func (wr *WatchRegistry) ActiveGlobs() map[types.LanguageServer][]string {
+	wr.mu.RLock()
+	defer wr.mu.RUnlock()
+
+	out := make(map[types.LanguageServer][]string, len(wr.watchers))
+	for lang, watchers := range wr.watchers {
+		patterns := make([]string, len(watchers))
+		for i, w := range watchers {
+			patterns[i] = w.pattern
+		}
+		out[lang] = patterns
+	}
+	return out
+}
@@ -2,20 +2,34 @@ package lsp
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
 	"io"
+	"math/rand"
 	"net"
 	"net/http"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"rockerboo/mcp-lsp-bridge/logger"
 
+	"github.com/myleshyson/lsprotocol-go/protocol"
+
 	"github.com/gorilla/websocket"
 	"github.com/sourcegraph/jsonrpc2"
 )
 
+// StatusReconnecting marks a WebSocket client that has lost its connection
+// and is currently retrying, as opposed to StatusDisconnected which is a
+// terminal state. It is only ever set when autoReconnect is enabled.
+const StatusReconnecting ClientStatus = 100
+
+// maxReconnectBackoff caps the exponential backoff delay between WebSocket
+// reconnection attempts.
+const maxReconnectBackoff = 30 * time.Second
+
 // NewWebSocketLanguageClient creates a new WebSocket-based Language Server Protocol client.
 func NewWebSocketLanguageClient(host string, port int) (*LanguageClient, error) {
 	if host == "" {
@@ -36,6 +50,87 @@ func NewWebSocketLanguageClient(host string, port int) (*LanguageClient, error)
 
 		status:     StatusConnecting,
 		tcpAddress: fmt.Sprintf("%s:%d", host, port),
+
+		PingInterval: 30 * time.Second,
+		PongWait:     60 * time.Second,
+
+		wsURL: fmt.Sprintf("ws://%s:%d/lsp", host, port),
+	}
+
+	return client, nil
+}
+
+// WebSocketConfig configures how NewWebSocketLanguageClientWithConfig dials
+// the LSP server: scheme/path, TLS, auth headers, and proxying.
+type WebSocketConfig struct {
+	Host string
+	Port int
+
+	// Scheme is "ws" (default) or "wss".
+	Scheme string
+	// Path is the WebSocket endpoint path, default "/lsp".
+	Path string
+
+	// TLSConfig is used when Scheme is "wss". Set InsecureSkipVerify or
+	// RootCAs here for self-signed/internal CAs.
+	TLSConfig *tls.Config
+
+	// Headers are sent with the opening HTTP handshake, e.g.
+	// {"Authorization": []string{"Bearer " + token}} or Basic auth.
+	Headers http.Header
+
+	// Compression enables permessage-deflate negotiation; CompressionLevel
+	// is the flate level to use when it's negotiated (1-9, 0 = library
+	// default). Leave both zero-valued to disable compression.
+	Compression      bool
+	CompressionLevel int
+}
+
+// NewWebSocketLanguageClientWithConfig creates a WebSocket LSP client that
+// can dial wss://, present TLS client config, and send arbitrary handshake
+// headers — unlike NewWebSocketLanguageClient, which only supports plain
+// ws:// with no auth.
+func NewWebSocketLanguageClientWithConfig(cfg WebSocketConfig) (*LanguageClient, error) {
+	host := cfg.Host
+	if host == "" {
+		host = "localhost"
+	}
+	port := cfg.Port
+	if port <= 0 {
+		port = 9999
+	}
+	scheme := cfg.Scheme
+	if scheme == "" {
+		scheme = "ws"
+	}
+	path := cfg.Path
+	if path == "" {
+		path = "/lsp"
+	}
+
+	wsURL := fmt.Sprintf("%s://%s:%d%s", scheme, host, port, path)
+
+	client := &LanguageClient{
+		command: wsURL,
+		args:    []string{},
+
+		maxConnectionAttempts: 5,
+		connectionTimeout:     30 * time.Second,
+		idleTimeout:           30 * time.Minute,
+		restartDelay:          2 * time.Second,
+
+		status:     StatusConnecting,
+		tcpAddress: fmt.Sprintf("%s:%d", host, port),
+
+		PingInterval: 30 * time.Second,
+		PongWait:     60 * time.Second,
+
+		wsURL:       wsURL,
+		wsHeaders:   cfg.Headers,
+		wsTLSConfig: cfg.TLSConfig,
+
+		Compression:      cfg.Compression,
+		CompressionLevel: cfg.CompressionLevel,
 	}
 
 	return client, nil
@@ -47,18 +142,22 @@ func (lc *LanguageClient) ConnectWebSocket() (*LanguageClient, error) {
 		return nil, fmt.Errorf("WebSocket address not configured")
 	}
 
-	// Replace localhost with 127.0.0.1 to avoid DNS issues
-	addr := strings.Replace(lc.tcpAddress, "localhost", "127.0.0.1", 1)
-	wsURL := fmt.Sprintf("ws://%s/lsp", addr)
+	wsURL := lc.wsURL
+	if wsURL == "" {
+		// Replace localhost with 127.0.0.1 to avoid DNS issues
+		addr := strings.Replace(lc.tcpAddress, "localhost", "127.0.0.1", 1)
+		wsURL = fmt.Sprintf("ws://%s/lsp", addr)
+	}
 
 	logger.Info(fmt.Sprintf("ConnectWebSocket: Starting connection to: %s", wsURL))
 
 	// Retry connection with backoff
 	var wsConn *websocket.Conn
+	var negotiatedCompression bool
 	var err error
 
 	for attempt := 1; attempt <= lc.maxConnectionAttempts; attempt++ {
-		wsConn, err = dialGorillaWebSocket(wsURL)
+		wsConn, negotiatedCompression, err = dialGorillaWebSocket(wsURL, lc.wsHeaders, lc.wsTLSConfig, lc.Compression)
 		if err == nil {
 			break
 		}
@@ -87,12 +186,20 @@ func (lc *LanguageClient) ConnectWebSocket() (*LanguageClient, error) {
 	if lc.progress == nil {
 		lc.progress = NewProgressTracker()
 	}
-	handler := &ClientHandler{
-		progress: lc.progress,
+	if lc.diagCache == nil {
+		lc.diagCache = newDiagnosticCache()
 	}
+	handler := NewClientHandler(lc.progress, lc.diagCache)
+	lc.handler = handler
 
 	// Wrap gorilla websocket for jsonrpc2
 	rwc := newGorillaRWC(wsConn)
+	rwc.compressionNegotiated = negotiatedCompression
+	lc.applyCompressionSettings(wsConn, negotiatedCompression)
+	lc.startKeepalive(ctx, rwc)
+	lc.mu.Lock()
+	lc.wsRWC = rwc
+	lc.mu.Unlock()
 	stream := jsonrpc2.NewBufferedStream(rwc, jsonrpc2.VSCodeObjectCodec{})
 
 	jsonrpcLogger := &JSONRPCLogger{}
@@ -100,13 +207,18 @@ func (lc *LanguageClient) ConnectWebSocket() (*LanguageClient, error) {
 		jsonrpc2.LogMessages(jsonrpcLogger),
 		jsonrpc2.SetLogger(jsonrpcLogger))
 
-	// Monitor connection disconnects
+	// Monitor connection disconnects, reconnecting automatically if enabled.
 	go func() {
 		disconnectCh := rpcConn.DisconnectNotify()
 		select {
 		case <-disconnectCh:
 			logger.Error("DISCONNECT: WebSocket connection was disconnected")
-			lc.status = StatusDisconnected
+			if lc.autoReconnect {
+				lc.status = StatusReconnecting
+				lc.reconnectWebSocket(wsURL)
+			} else {
+				lc.status = StatusDisconnected
+			}
 		case <-ctx.Done():
 			logger.Debug("DISCONNECT: Context cancelled")
 		}
@@ -121,7 +233,248 @@ func (lc *LanguageClient) ConnectWebSocket() (*LanguageClient, error) {
 	return lc, nil
 }
 
-func dialGorillaWebSocket(wsURL string) (*websocket.Conn, error) {
+// SetAutoReconnect enables or disables automatic reconnection when the
+// WebSocket connection drops. It is off by default so existing callers see
+// no behavior change.
+func (lc *LanguageClient) SetAutoReconnect(enabled bool) {
+	lc.autoReconnect = enabled
+}
+
+// OnReconnect registers a callback invoked after each reconnection attempt,
+// successful or not. attempt is 1-indexed and err is nil on success.
+func (lc *LanguageClient) OnReconnect(fn func(attempt int, err error)) {
+	lc.onReconnectMu.Lock()
+	lc.onReconnect = fn
+	lc.onReconnectMu.Unlock()
+}
+
+func (lc *LanguageClient) notifyReconnect(attempt int, err error) {
+	lc.onReconnectMu.Lock()
+	fn := lc.onReconnect
+	lc.onReconnectMu.Unlock()
+	if fn != nil {
+		fn(attempt, err)
+	}
+}
+
+// reconnectWebSocket redials wsURL with exponential backoff and jitter,
+// re-runs the initialize handshake, and replays any documents that were
+// open at the time of the disconnect. It gives up once lc.ctx is cancelled.
+func (lc *LanguageClient) reconnectWebSocket(wsURL string) {
+	for attempt := 1; ; attempt++ {
+		select {
+		case <-lc.ctx.Done():
+			return
+		default:
+		}
+
+		wsConn, negotiatedCompression, err := dialGorillaWebSocket(wsURL, lc.wsHeaders, lc.wsTLSConfig, lc.Compression)
+		if err != nil {
+			delay := backoffWithJitter(lc.restartDelay, attempt)
+			logger.Warn(fmt.Sprintf("reconnectWebSocket: attempt %d failed, retrying in %s: %v", attempt, delay, err))
+			lc.notifyReconnect(attempt, err)
+
+			select {
+			case <-lc.ctx.Done():
+				return
+			case <-time.After(delay):
+			}
+			continue
+		}
+
+		rwc := newGorillaRWC(wsConn)
+		rwc.compressionNegotiated = negotiatedCompression
+		lc.applyCompressionSettings(wsConn, negotiatedCompression)
+		lc.startKeepalive(lc.ctx, rwc)
+		lc.mu.Lock()
+		lc.wsRWC = rwc
+		lc.mu.Unlock()
+		stream := jsonrpc2.NewBufferedStream(rwc, jsonrpc2.VSCodeObjectCodec{})
+
+		if lc.handler == nil {
+			if lc.diagCache == nil {
+				lc.diagCache = newDiagnosticCache()
+			}
+			lc.handler = NewClientHandler(lc.progress, lc.diagCache)
+		}
+
+		jsonrpcLogger := &JSONRPCLogger{}
+		rpcConn := jsonrpc2.NewConn(lc.ctx, stream, lc.handler,
+			jsonrpc2.LogMessages(jsonrpcLogger),
+			jsonrpc2.SetLogger(jsonrpcLogger))
+
+		lc.mu.Lock()
+		lc.conn = rpcConn
+		lc.mu.Unlock()
+		lc.status = StatusConnected
+		lc.lastInitialized = time.Now()
+
+		if err := lc.reinitializeAndReplay(); err != nil {
+			logger.Warn(fmt.Sprintf("reconnectWebSocket: reinitialize after reconnect failed: %v", err))
+		}
+
+		logger.Info(fmt.Sprintf("reconnectWebSocket: reconnected to %s after %d attempt(s)", wsURL, attempt))
+		lc.notifyReconnect(attempt, nil)
+
+		go func() {
+			disconnectCh := rpcConn.DisconnectNotify()
+			select {
+			case <-disconnectCh:
+				logger.Error("DISCONNECT: WebSocket connection was disconnected")
+				if lc.autoReconnect {
+					lc.status = StatusReconnecting
+					lc.reconnectWebSocket(wsURL)
+				} else {
+					lc.status = StatusDisconnected
+				}
+			case <-lc.ctx.Done():
+				logger.Debug("DISCONNECT: Context cancelled")
+			}
+		}()
+
+		return
+	}
+}
+
+// reinitializeAndReplay re-runs the LSP initialize handshake against a
+// freshly (re)dialed connection and re-opens documents that were tracked as
+// open before the disconnect. Outstanding requests that had not yet
+// received a response when the connection dropped are not replayed: the
+// transport layer that tracks them is outside this client's reach.
+func (lc *LanguageClient) reinitializeAndReplay() error {
+	if _, err := lc.Initialize(lc.ctx, protocol.InitializeParams{
+		Capabilities: lc.clientCapabilities,
+	}); err != nil {
+		return fmt.Errorf("reinitialize failed: %w", err)
+	}
+	if err := lc.Initialized(lc.ctx); err != nil {
+		return fmt.Errorf("initialized notification failed: %w", err)
+	}
+
+	lc.openedDocsMu.Lock()
+	docs := make(map[string]openedDoc, len(lc.openedDocs))
+	for uri, doc := range lc.openedDocs {
+		docs[uri] = doc
+	}
+	lc.openedDocsMu.Unlock()
+
+	for uri, doc := range docs {
+		if err := lc.DidOpen(lc.ctx, uri, doc.languageId, doc.text, doc.version); err != nil {
+			logger.Warn(fmt.Sprintf("reinitializeAndReplay: failed to replay didOpen for %s: %v", uri, err))
+		}
+	}
+
+	return nil
+}
+
+// backoffWithJitter returns base * 2^(attempt-1), capped at
+// maxReconnectBackoff, with up to 20% random jitter added to avoid
+// thundering-herd reconnects.
+func backoffWithJitter(base time.Duration, attempt int) time.Duration {
+	delay := base
+	for i := 1; i < attempt; i++ {
+		delay *= 2
+		if delay > maxReconnectBackoff {
+			delay = maxReconnectBackoff
+			break
+		}
+	}
+	jitter := time.Duration(rand.Int63n(int64(delay) / 5))
+	return delay + jitter
+}
+
+// applyCompressionSettings enables per-message write compression on a
+// freshly dialed connection when the peer negotiated permessage-deflate,
+// applying lc.CompressionLevel if one was configured.
+func (lc *LanguageClient) applyCompressionSettings(wsConn *websocket.Conn, negotiated bool) {
+	if !negotiated {
+		return
+	}
+	wsConn.EnableWriteCompression(true)
+	if lc.CompressionLevel != 0 {
+		if err := wsConn.SetCompressionLevel(lc.CompressionLevel); err != nil {
+			logger.Warn(fmt.Sprintf("applyCompressionSettings: invalid compression level %d: %v", lc.CompressionLevel, err))
+		}
+	}
+}
+
+// CompressionNegotiated reports whether the peer agreed to permessage-
+// deflate on the current WebSocket connection.
+func (lc *LanguageClient) CompressionNegotiated() bool {
+	lc.mu.RLock()
+	defer lc.mu.RUnlock()
+	return lc.wsRWC != nil && lc.wsRWC.compressionNegotiated
+}
+
+// BytesIn returns the number of WebSocket payload bytes read so far on the
+// current connection (0 if not connected over WebSocket).
+func (lc *LanguageClient) BytesIn() int64 {
+	lc.mu.RLock()
+	rwc := lc.wsRWC
+	lc.mu.RUnlock()
+	if rwc == nil {
+		return 0
+	}
+	return atomic.LoadInt64(&rwc.bytesIn)
+}
+
+// BytesOut returns the number of WebSocket payload bytes written so far on
+// the current connection (0 if not connected over WebSocket).
+func (lc *LanguageClient) BytesOut() int64 {
+	lc.mu.RLock()
+	rwc := lc.wsRWC
+	lc.mu.RUnlock()
+	if rwc == nil {
+		return 0
+	}
+	return atomic.LoadInt64(&rwc.bytesOut)
+}
+
+// startKeepalive installs a pong handler/read deadline pair on rwc's
+// underlying connection and pings it every lc.PingInterval. If no pong
+// arrives within lc.PongWait, the read deadline trips, ReadMessage starts
+// failing, and the jsonrpc2 connection's existing DisconnectNotify path
+// takes over. It stops once ctx is cancelled or a ping write fails.
+func (lc *LanguageClient) startKeepalive(ctx context.Context, rwc *gorillaRWC) {
+	pongWait := lc.PongWait
+	if pongWait <= 0 {
+		pongWait = 60 * time.Second
+	}
+	pingInterval := lc.PingInterval
+	if pingInterval <= 0 {
+		pingInterval = 30 * time.Second
+	}
+
+	rwc.conn.SetReadDeadline(time.Now().Add(pongWait))
+	rwc.conn.SetPongHandler(func(string) error {
+		rwc.conn.SetReadDeadline(time.Now().Add(pongWait))
+		return nil
+	})
+
+	go func() {
+		ticker := time.NewTicker(pingInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := rwc.writePing(); err != nil {
+					logger.Warn(fmt.Sprintf("startKeepalive: ping failed: %v", err))
+					return
+				}
+			}
+		}
+	}()
+}
+
+// dialGorillaWebSocket dials wsURL (ws:// or wss://), sending headers with
+// the opening HTTP handshake and using tlsConfig for wss:// connections. On
+// failure it surfaces the handshake HTTP status/body when the server
+// responded at all, so a 401/403/404 misconfiguration is diagnosable
+// instead of a bare "bad handshake" error.
+func dialGorillaWebSocket(wsURL string, headers http.Header, tlsConfig *tls.Config, enableCompression bool) (conn *websocket.Conn, negotiatedCompression bool, err error) {
 
 	// Create a custom dialer with TCP settings
 	netDialer := &net.Dialer{
@@ -141,18 +494,27 @@ func dialGorillaWebSocket(wsURL string) (*websocket.Conn, error) {
 			}
 			return conn, nil
 		},
-		HandshakeTimeout: 45 * time.Second,
-		ReadBufferSize:   4096,
-		WriteBufferSize:  4096,
+		Proxy:             http.ProxyFromEnvironment,
+		TLSClientConfig:   tlsConfig,
+		HandshakeTimeout:  45 * time.Second,
+		ReadBufferSize:    4096,
+		WriteBufferSize:   4096,
+		EnableCompression: enableCompression,
 	}
-	conn, resp, err := dialer.Dial(wsURL, http.Header{})
-	if err != nil {
+	if headers == nil {
+		headers = http.Header{}
+	}
+	conn, resp, dialErr := dialer.Dial(wsURL, headers)
+	if dialErr != nil {
 		if resp != nil {
-		} else {
+			body, _ := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			return nil, false, fmt.Errorf("websocket handshake failed: %s: %s: %w", resp.Status, strings.TrimSpace(string(body)), dialErr)
 		}
-		return nil, err
+		return nil, false, dialErr
 	}
-	return conn, nil
+	negotiated := resp != nil && strings.Contains(resp.Header.Get("Sec-WebSocket-Extensions"), "permessage-deflate")
+	return conn, negotiated, nil
 }
 
 // gorillaRWC wraps gorilla/websocket for io.ReadWriteCloser
@@ -160,6 +522,10 @@ type gorillaRWC struct {
 	conn    *websocket.Conn
 	readBuf []byte
 	mu      sync.Mutex
+
+	compressionNegotiated bool
+	bytesIn               int64
+	bytesOut              int64
 }
 
 func newGorillaRWC(conn *websocket.Conn) *gorillaRWC {
@@ -182,6 +548,7 @@ func (g *gorillaRWC) Read(p []byte) (int, error) {
 	if err != nil {
 		return 0, err
 	}
+	atomic.AddInt64(&g.bytesIn, int64(len(msg)))
 
 	n := copy(p, msg)
 	if n < len(msg) {
@@ -191,15 +558,60 @@ func (g *gorillaRWC) Read(p []byte) (int, error) {
 }
 
 func (g *gorillaRWC) Write(p []byte) (int, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
 	err := g.conn.WriteMessage(websocket.TextMessage, p)
 	if err != nil {
 		return 0, err
 	}
+	atomic.AddInt64(&g.bytesOut, int64(len(p)))
 	return len(p), nil
 }
 
+// writePing sends a ping control frame, taking the same lock as Write so
+// gorilla's WriteMessage (not safe for concurrent callers) is never invoked
+// from two goroutines at once.
+func (g *gorillaRWC) writePing() error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.conn.WriteMessage(websocket.PingMessage, nil)
+}
+
 func (g *gorillaRWC) Close() error {
 	return g.conn.Close()
 }
 
+// closeGracefully writes a normal-closure WebSocket close frame, waits
+// briefly for the peer's close frame (or ctx's deadline/the hardcoded
+// fallback below, whichever is sooner), and only then closes the
+// underlying TCP connection. This avoids the peer seeing an abnormal 1006
+// closure when the LSP client is shutting down on purpose.
+func (g *gorillaRWC) closeGracefully(ctx context.Context) error {
+	deadline := time.Now().Add(5 * time.Second)
+	if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
+		deadline = ctxDeadline
+	}
+
+	g.mu.Lock()
+	writeErr := g.conn.WriteControl(websocket.CloseMessage,
+		websocket.FormatCloseMessage(websocket.CloseNormalClosure, "client shutting down"),
+		deadline)
+	g.mu.Unlock()
+
+	if writeErr != nil {
+		g.conn.Close()
+		return fmt.Errorf("failed to write close frame: %w", writeErr)
+	}
+
+	g.conn.SetReadDeadline(deadline)
+	for {
+		if _, _, err := g.conn.ReadMessage(); err != nil {
+			break
+		}
+	}
+
+	return g.conn.Close()
+}
+
 var _ io.ReadWriteCloser = (*gorillaRWC)(nil)
@@ -0,0 +1,164 @@
+package mcpserver
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/netip"
+	"os"
+	"strings"
+
+	"rockerboo/mcp-lsp-bridge/logger"
+	"rockerboo/mcp-lsp-bridge/mcpserver/tools"
+)
+
+// trustedProxiesEnv names the environment variable listing CIDRs (comma
+// separated, e.g. "10.0.0.0/8,172.16.0.0/12") of reverse proxies allowed
+// to set X-Real-IP/Forwarded/X-Forwarded-For. Mirrors the env-var-driven
+// config style MCP_AUTO_CONNECT_LANGUAGES/MCP_ENTRY_POINTS_CONFIG already
+// use elsewhere in this tree (see bridge/auto_connect.go, bridge/entry_points.go).
+//
+// Example, behind an nginx/Caddy sidecar on the docker bridge network:
+//
+//	MCP_TRUSTED_PROXIES=172.17.0.0/16
+//
+// Example, behind an Apache reverse proxy on the host's LAN:
+//
+//	MCP_TRUSTED_PROXIES=10.0.0.0/8,192.168.0.0/16
+//
+// Left unset (the default), no peer is trusted and proxy headers are
+// ignored entirely - the client identity is always the direct TCP peer,
+// which is the safe default for a bridge not sitting behind a proxy.
+const trustedProxiesEnv = "MCP_TRUSTED_PROXIES"
+
+// ParseTrustedProxyCIDRs parses MCP_TRUSTED_PROXIES-style comma-separated
+// CIDRs into prefixes for ResolveClientIP. Empty entries are skipped; an
+// entry that fails to parse as a CIDR is logged and skipped rather than
+// failing the whole list, so one typo doesn't disable every trusted proxy.
+func ParseTrustedProxyCIDRs(raw string) []netip.Prefix {
+	var prefixes []netip.Prefix
+	for _, part := range strings.Split(raw, ",") {
+		cidr := strings.TrimSpace(part)
+		if cidr == "" {
+			continue
+		}
+		prefix, err := netip.ParsePrefix(cidr)
+		if err != nil {
+			logger.Warn("ParseTrustedProxyCIDRs: skipping invalid CIDR", "cidr", cidr, "error", err)
+			continue
+		}
+		prefixes = append(prefixes, prefix)
+	}
+	return prefixes
+}
+
+// trustedProxiesFromEnv reads and parses MCP_TRUSTED_PROXIES once at
+// HTTPContextFunc construction time.
+func trustedProxiesFromEnv() []netip.Prefix {
+	return ParseTrustedProxyCIDRs(os.Getenv(trustedProxiesEnv))
+}
+
+// isTrustedPeer reports whether peer (as returned by net.SplitHostPort on
+// http.Request.RemoteAddr) falls within any of trusted.
+func isTrustedPeer(peerIP string, trusted []netip.Prefix) bool {
+	addr, err := netip.ParseAddr(peerIP)
+	if err != nil {
+		return false
+	}
+	for _, prefix := range trusted {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// ResolveClientIP determines the real client IP for r, honoring
+// X-Real-IP / Forwarded / X-Forwarded-For only when the direct TCP peer
+// (r.RemoteAddr) is in trusted - otherwise those headers are attacker-
+// controlled and ignored, and the direct peer is reported as-is.
+//
+// Precedence when the peer is trusted: X-Real-IP first (a single,
+// unambiguous value a well-behaved proxy sets itself), then the Forwarded
+// header's first "for=" parameter (RFC 7239), then the first entry of
+// X-Forwarded-For (the most commonly forged header of the three, and the
+// last one checked for that reason).
+func ResolveClientIP(r *http.Request, trusted []netip.Prefix) string {
+	peerIP := r.RemoteAddr
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		peerIP = host
+	}
+
+	if len(trusted) == 0 || !isTrustedPeer(peerIP, trusted) {
+		return peerIP
+	}
+
+	if realIP := strings.TrimSpace(r.Header.Get("X-Real-IP")); realIP != "" {
+		return realIP
+	}
+
+	if forwarded := r.Header.Get("Forwarded"); forwarded != "" {
+		if ip := parseForwardedFor(forwarded); ip != "" {
+			return ip
+		}
+	}
+
+	if xff := r.Header.Get("X-Forwarded-For"); xff != "" {
+		first := strings.TrimSpace(strings.Split(xff, ",")[0])
+		if first != "" {
+			return first
+		}
+	}
+
+	return peerIP
+}
+
+// parseForwardedFor extracts the first for= value from an RFC 7239
+// Forwarded header (e.g. `for=203.0.113.4;proto=https`), stripping the
+// quoting/brackets the spec allows around IPv6 literals and port numbers.
+func parseForwardedFor(header string) string {
+	for _, element := range strings.Split(header, ",") {
+		for _, pair := range strings.Split(element, ";") {
+			kv := strings.SplitN(strings.TrimSpace(pair), "=", 2)
+			if len(kv) != 2 || !strings.EqualFold(strings.TrimSpace(kv[0]), "for") {
+				continue
+			}
+			value := strings.Trim(strings.TrimSpace(kv[1]), `"`)
+			value = strings.TrimPrefix(value, "[")
+			if idx := strings.Index(value, "]"); idx >= 0 {
+				value = value[:idx]
+			} else if host, _, err := net.SplitHostPort(value); err == nil {
+				value = host
+			}
+			if value != "" {
+				return value
+			}
+		}
+	}
+	return ""
+}
+
+// HTTPContextFunc builds an mcp-go server.WithHTTPContextFunc callback
+// that resolves the calling client's identity per request (honoring
+// MCP_TRUSTED_PROXIES) and attaches it to the context the MCP server
+// passes into CheckReadyOrReturn and every tool handler, so status
+// surfaces like LSPClientStatus.Origin/LSPActivity.Origin can report
+// which upstream caller is responsible for in-flight work.
+//
+// NOTE: this tree has no cmd/ entrypoint that actually constructs an
+// http.Server or an mcp-go StreamableHTTP/SSE transport to pass this
+// callback to - RegisterAllTools wires tools onto a ToolServer, but
+// nothing here starts a listener. Wiring HTTPContextFunc into a real
+// server.NewStreamableHTTPServer(..., server.WithHTTPContextFunc(...))
+// call is left for whichever cmd/ binary ends up owning that transport.
+func HTTPContextFunc(workspaceRoot string) func(ctx context.Context, r *http.Request) context.Context {
+	trusted := trustedProxiesFromEnv()
+
+	return func(ctx context.Context, r *http.Request) context.Context {
+		id := tools.ClientIdentity{
+			IP:        ResolveClientIP(r, trusted),
+			Workspace: workspaceRoot,
+		}
+		return tools.WithClientIdentity(ctx, id)
+	}
+}
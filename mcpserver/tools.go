@@ -40,8 +40,17 @@ func RegisterAllTools(mcpServer tools.ToolServer, bridge interfaces.BridgeInterf
 	// - document_color
 	// - color_presentation
 
+	// Workspace-wide batch variants of the otherwise-hidden per-file
+	// document_link/document_color/folding_range: useful to an agent as an
+	// audit/survey tool (e.g. "find every dead link in the workspace") in a
+	// way a single-URI call isn't
+	tools.RegisterWorkspaceDocumentLinkTool(mcpServer, bridge)
+	tools.RegisterWorkspaceDocumentColorTool(mcpServer, bridge)
+	tools.RegisterWorkspaceFoldingRangeTool(mcpServer, bridge)
+
 	// Code improvement tools
 	tools.RegisterCodeActionsTool(mcpServer, bridge)
+	tools.RegisterApplyCodeActionTool(mcpServer, bridge)
 	tools.RegisterFormatDocumentTool(mcpServer, bridge)
 	// Hide IDE/UI-oriented tool:
 	// - range_formatting
@@ -58,6 +67,10 @@ func RegisterAllTools(mcpServer tools.ToolServer, bridge interfaces.BridgeInterf
 	// Workspace analysis
 	tools.RegisterWorkspaceDiagnosticsTool(mcpServer, bridge)
 
+	// Incremental file index health/changes (see bridge.MCPLSPBridge.WorkspaceIndex)
+	tools.RegisterWorkspaceStatsTool(mcpServer, bridge)
+	tools.RegisterWorkspaceChangedSinceTool(mcpServer, bridge)
+
 	// Document diagnostics
 	tools.RegisterDocumentDiagnosticsTool(mcpServer, bridge)
 
@@ -71,6 +84,62 @@ func RegisterAllTools(mcpServer tools.ToolServer, bridge interfaces.BridgeInterf
 	// Hide bridge diagnostic tool from the agent tool list:
 	// - mcp_lsp_diagnostics
 
+	// Read a language server's already-pushed (publishDiagnostics) cache,
+	// for one file or the whole workspace, with an optional blocking wait.
+	tools.RegisterLiveDiagnosticsTool(mcpServer, bridge)
+
 	// Server/client status (includes LSP $/progress)
 	tools.RegisterLSPStatusTool(mcpServer, bridge)
+
+	// Recent LSP notifications the bridge has no handler for (backed by
+	// the ring buffer in lsp/unhandled_notifications.go)
+	tools.RegisterUnhandledNotificationsTool(mcpServer, bridge)
+
+	// Cancel a long-running LSP request started by a progress-aware tool
+	// (e.g. workspace_diagnostics) by its reported progress token
+	tools.RegisterCancelInFlightTool(mcpServer, bridge)
+
+	// Ask a language server to stop progress it reported on its own
+	// (e.g. indexing), via window/workDoneProgress/cancel
+	tools.RegisterCancelLSPProgressTool(mcpServer, bridge)
+
+	// Block on one progress token until it ends (or cancel it) - a
+	// complement to polling lsp_status's activity[] by hand
+	tools.RegisterProgressStreamTool(mcpServer, bridge)
+	tools.RegisterProgressCancelTool(mcpServer, bridge)
+
+	// Retained $/progress event history (see lsp.ProgressTracker.History),
+	// filtered by time window and title regex - forensic visibility into
+	// what a language server was doing, complementing progress_stream's
+	// live wait and lsp_status's point-in-time snapshot
+	tools.RegisterProgressHistoryTool(mcpServer, bridge)
+
+	// Graceful restart: pick up language server config changes without
+	// dropping in-flight requests
+	tools.RegisterGracefulRestartTool(mcpServer, bridge)
+
+	// Pre-flight/continuous posture checks for configured language servers
+	tools.RegisterLanguageServerPostureTool(mcpServer, bridge)
+
+	// Advisory version/vulnerability health per configured language server
+	// (see bridge.MCPLSPBridge.CheckAllHealth); degrades lsp_status rather
+	// than gating it
+	tools.RegisterLSPHealthcheckTool(mcpServer, bridge)
+
+	// Per-server warm-up strategy (see bridge/warmup_strategy.go) and its
+	// last-run outcome: files opened, probe query errors
+	tools.RegisterWarmupStatusTool(mcpServer, bridge)
+
+	// Dynamic workspace/didChangeWatchedFiles glob registrations (see
+	// lsp.WatchRegistry) and recent auto-forwarded file-change dispatches
+	tools.RegisterWatchStatusTool(mcpServer, bridge)
+
+	// Cross-file module coupling/cohesion analysis (Ca/Ce/Instability, LCOM4)
+	tools.RegisterModuleCouplingTool(mcpServer, bridge)
+
+	// Type-1/Type-2 code clone detection across BSL modules
+	tools.RegisterFindDuplicatesTool(mcpServer, bridge)
+
+	// LCP-clustered symbol outline for large modules
+	tools.RegisterSymbolOutlineTool(mcpServer, bridge)
 }
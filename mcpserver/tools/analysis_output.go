@@ -0,0 +1,74 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"rockerboo/mcp-lsp-bridge/analysis"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// analysisOutputFormat is project_analysis's output_format option for the
+// handleFileAnalysis/handlePatternAnalysis/handleWorkspaceAnalysis/
+// handleSymbolRelationships handlers: "text" (default) keeps the existing
+// human-formatted fmt.Fprintf report; "json"/"ndjson" marshal the
+// underlying analysis.*Data plus result.Metadata to a stable schema so
+// non-LLM MCP clients (dashboards, CI) don't have to regex-scrape the text
+// report.
+type analysisOutputFormat string
+
+const (
+	analysisOutputText   analysisOutputFormat = "text"
+	analysisOutputJSON   analysisOutputFormat = "json"
+	analysisOutputNDJSON analysisOutputFormat = "ndjson"
+)
+
+// parseAnalysisOutputFormat reads and validates the output_format option,
+// defaulting to analysisOutputText when unset.
+func parseAnalysisOutputFormat(request mcp.CallToolRequest) (analysisOutputFormat, error) {
+	switch f := analysisOutputFormat(strings.ToLower(strings.TrimSpace(request.GetString("output_format", "")))); f {
+	case "":
+		return analysisOutputText, nil
+	case analysisOutputText, analysisOutputJSON, analysisOutputNDJSON:
+		return f, nil
+	default:
+		return "", fmt.Errorf("output_format: unknown value %q (expected text, json, or ndjson)", f)
+	}
+}
+
+// analysisJSONEnvelope is the stable JSON schema returned for
+// output_format="json": the handler's typed analysis.*Data payload plus the
+// engine's run metadata, tagged with which analysis_type produced it.
+type analysisJSONEnvelope struct {
+	AnalysisType string                    `json:"analysis_type"`
+	Data         any                       `json:"data"`
+	Metadata     analysis.AnalysisMetadata `json:"metadata"`
+}
+
+// writeAnalysisJSON marshals data+metadata to the analysisJSONEnvelope
+// schema and returns it as the tool result.
+func writeAnalysisJSON(analysisType string, data any, metadata analysis.AnalysisMetadata) (*mcp.CallToolResult, error) {
+	payload, err := json.MarshalIndent(analysisJSONEnvelope{AnalysisType: analysisType, Data: data, Metadata: metadata}, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("marshal %s to JSON: %v", analysisType, err)), nil
+	}
+	return mcp.NewToolResultText(string(payload)), nil
+}
+
+// writeAnalysisNDJSON emits one JSON object per line from records, so large
+// workspace scans can stream one record per pattern instance / relation /
+// recommendation instead of buffering the whole result into one document.
+func writeAnalysisNDJSON(records []any) (*mcp.CallToolResult, error) {
+	var sb strings.Builder
+	for _, rec := range records {
+		line, err := json.Marshal(rec)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("marshal ndjson record: %v", err)), nil
+		}
+		sb.Write(line)
+		sb.WriteByte('\n')
+	}
+	return mcp.NewToolResultText(sb.String()), nil
+}
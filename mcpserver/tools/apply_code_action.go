@@ -0,0 +1,90 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"rockerboo/mcp-lsp-bridge/interfaces"
+	"rockerboo/mcp-lsp-bridge/logger"
+	"rockerboo/mcp-lsp-bridge/lsp"
+	"rockerboo/mcp-lsp-bridge/utils"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/myleshyson/lsprotocol-go/protocol"
+)
+
+// ApplyCodeActionTool registers the apply_code_action MCP tool: the
+// write-side counterpart to project_analysis's code_actions analysis type.
+// code_actions mints an action_id for every code action it previews that
+// carries a WorkspaceEdit; this tool looks that id back up and writes the
+// edit to disk, the same way an editor applies a server-initiated
+// workspace/applyEdit after the caller accepts it.
+func ApplyCodeActionTool(bridge interfaces.BridgeInterface) (mcp.Tool, server.ToolHandlerFunc) {
+	return mcp.NewTool(
+			"apply_code_action",
+			mcp.WithDescription("Apply a code action previewed by project_analysis's code_actions analysis type. Pass the action_id it returned for an action that carried an edit; this writes that WorkspaceEdit to disk."),
+			mcp.WithDestructiveHintAnnotation(true),
+			mcp.WithString("action_id", mcp.Description("action_id returned by a prior code_actions call."), mcp.Required()),
+		), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			actionID, err := request.RequireString("action_id")
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			action, ok := globalCodeActionCache.take(actionID)
+			if !ok {
+				return mcp.NewToolResultError(fmt.Sprintf("apply_code_action: unknown or already-applied action_id %q; re-run code_actions to get a fresh one", actionID)), nil
+			}
+
+			uris := make([]string, 0, len(action.Edit.Changes))
+			for editURI := range action.Edit.Changes {
+				uris = append(uris, string(editURI))
+			}
+			sort.Strings(uris)
+
+			applied := make([]string, 0, len(uris))
+			for _, editURI := range uris {
+				edits := action.Edit.Changes[protocol.DocumentUri(editURI)]
+
+				path := utils.URIToFilePath(bridge.NormalizeURIForLSP(editURI))
+
+				current, readErr := os.ReadFile(path) // #nosec G304 -- writing within user workspace
+				if readErr != nil {
+					return mcp.NewToolResultError(fmt.Sprintf("apply_code_action: failed to read %s: %v", editURI, readErr)), nil
+				}
+
+				updated := lsp.ApplyTextEdits(string(current), edits)
+
+				mode := os.FileMode(0o644)
+				if info, statErr := os.Stat(path); statErr == nil {
+					mode = info.Mode()
+				}
+
+				if err := os.WriteFile(path, []byte(updated), mode); err != nil {
+					return mcp.NewToolResultError(fmt.Sprintf("apply_code_action: failed to write %s: %v", editURI, err)), nil
+				}
+
+				applied = append(applied, editURI)
+			}
+
+			// DocumentChanges (create/rename/delete file operations) aren't
+			// applied: every target of this repo's configured language
+			// servers (gopls-style fill_struct/organize_imports) only ever
+			// edits existing files, so Changes alone covers the cases this
+			// tool exists for.
+			if len(action.Edit.DocumentChanges) > 0 {
+				logger.Warn(fmt.Sprintf("apply_code_action: %q carries %d DocumentChanges operation(s) (create/rename/delete) that were not applied", action.Title, len(action.Edit.DocumentChanges)))
+			}
+
+			return mcp.NewToolResultText(fmt.Sprintf("APPLIED|%s|%s|files=%d\n%s", actionID, action.Title, len(applied), strings.Join(applied, "\n"))), nil
+		}
+}
+
+// RegisterApplyCodeActionTool registers the apply_code_action tool.
+func RegisterApplyCodeActionTool(mcpServer ToolServer, bridge interfaces.BridgeInterface) {
+	mcpServer.AddTool(ApplyCodeActionTool(bridge))
+}
@@ -0,0 +1,294 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"rockerboo/mcp-lsp-bridge/interfaces"
+	"rockerboo/mcp-lsp-bridge/logger"
+	"rockerboo/mcp-lsp-bridge/lsp"
+	"rockerboo/mcp-lsp-bridge/utils"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/myleshyson/lsprotocol-go/protocol"
+)
+
+const defaultBatchRangeFormattingTabSize = 4
+
+// batchRangeFormattingRange is one textDocument/rangeFormatting request
+// within a single file, decoded from the requests_json argument.
+type batchRangeFormattingRange struct {
+	StartLine      int `json:"start_line"`
+	StartCharacter int `json:"start_character"`
+	EndLine        int `json:"end_line"`
+	EndCharacter   int `json:"end_character"`
+}
+
+// batchRangeFormattingRequest groups every range to format within one file,
+// along with the formatting options that apply to each of them.
+type batchRangeFormattingRequest struct {
+	URI          string                      `json:"uri"`
+	Ranges       []batchRangeFormattingRange `json:"ranges"`
+	TabSize      *int                        `json:"tab_size,omitempty"`
+	InsertSpaces *bool                       `json:"insert_spaces,omitempty"`
+}
+
+// BatchRangeFormattingFileResult summarizes what happened to one file: how
+// many edits its ranges produced, whether they were written to disk, and
+// why they weren't if a range failed or two ranges' edits overlapped.
+type BatchRangeFormattingFileResult struct {
+	URI       string `json:"uri"`
+	EditCount int    `json:"edit_count"`
+	Applied   bool   `json:"applied"`
+	Skipped   bool   `json:"skipped"`
+	Error     string `json:"error,omitempty"`
+}
+
+// BatchRangeFormattingReport is the overall result of a
+// batch_range_formatting call: one result per file plus whether the whole
+// batch applied cleanly.
+type BatchRangeFormattingReport struct {
+	Results  []BatchRangeFormattingFileResult `json:"results"`
+	Applied  bool                             `json:"applied"`
+	ExitCode int                              `json:"exit_code"`
+}
+
+// collectBatchRangeFormattingEdits issues textDocument/rangeFormatting for
+// every range in reqItem and merges the results into one sorted,
+// non-overlapping edit list for the file.
+func collectBatchRangeFormattingEdits(bridge interfaces.BridgeInterface, reqItem batchRangeFormattingRequest) ([]protocol.TextEdit, error) {
+	tabSize := defaultBatchRangeFormattingTabSize
+	if reqItem.TabSize != nil {
+		tabSize = *reqItem.TabSize
+	}
+	insertSpaces := true
+	if reqItem.InsertSpaces != nil {
+		insertSpaces = *reqItem.InsertSpaces
+	}
+
+	tabSizeUint32, err := safeUint32(tabSize)
+	if err != nil {
+		return nil, fmt.Errorf("invalid tab_size: %w", err)
+	}
+
+	var allEdits []protocol.TextEdit
+	for i, r := range reqItem.Ranges {
+		startLine, err := safeUint32(r.StartLine)
+		if err != nil {
+			return nil, fmt.Errorf("range %d: invalid start_line: %w", i, err)
+		}
+		startCharacter, err := safeUint32(r.StartCharacter)
+		if err != nil {
+			return nil, fmt.Errorf("range %d: invalid start_character: %w", i, err)
+		}
+		endLine, err := safeUint32(r.EndLine)
+		if err != nil {
+			return nil, fmt.Errorf("range %d: invalid end_line: %w", i, err)
+		}
+		endCharacter, err := safeUint32(r.EndCharacter)
+		if err != nil {
+			return nil, fmt.Errorf("range %d: invalid end_character: %w", i, err)
+		}
+
+		edits, err := bridge.RangeFormatting(reqItem.URI, startLine, startCharacter, endLine, endCharacter, tabSizeUint32, insertSpaces)
+		if err != nil {
+			return nil, fmt.Errorf("range %d: %w", i, err)
+		}
+
+		allEdits = append(allEdits, edits...)
+	}
+
+	return mergeSortedRangeEdits(allEdits)
+}
+
+// mergeSortedRangeEdits sorts edits by start position and rejects the batch
+// if any two overlap, since per-range formatting requests for the same file
+// aren't guaranteed to stay within their own range.
+func mergeSortedRangeEdits(edits []protocol.TextEdit) ([]protocol.TextEdit, error) {
+	merged := make([]protocol.TextEdit, len(edits))
+	copy(merged, edits)
+	sort.Slice(merged, func(i, j int) bool {
+		return batchPositionLess(merged[i].Range.Start, merged[j].Range.Start)
+	})
+
+	for i := 1; i < len(merged); i++ {
+		if batchPositionLess(merged[i].Range.Start, merged[i-1].Range.End) {
+			prev, cur := merged[i-1].Range, merged[i].Range
+			return nil, fmt.Errorf("overlapping edits at %d:%d-%d:%d and %d:%d-%d:%d",
+				prev.Start.Line, prev.Start.Character, prev.End.Line, prev.End.Character,
+				cur.Start.Line, cur.Start.Character, cur.End.Line, cur.End.Character)
+		}
+	}
+
+	return merged, nil
+}
+
+// batchPositionLess orders Positions by line then character, mirroring
+// lsp.ApplyTextEdits' own ordering so the overlap check agrees with how
+// edits are eventually applied.
+func batchPositionLess(a, b protocol.Position) bool {
+	if a.Line != b.Line {
+		return a.Line < b.Line
+	}
+	return a.Character < b.Character
+}
+
+// applyBatchRangeFormattingEdits writes a file's merged edits to disk, the
+// same read-apply-write sequence apply_code_action uses to turn a
+// WorkspaceEdit into bytes on disk.
+func applyBatchRangeFormattingEdits(bridge interfaces.BridgeInterface, uri string, edits []protocol.TextEdit) error {
+	path := utils.URIToFilePath(bridge.NormalizeURIForLSP(uri))
+
+	current, err := os.ReadFile(path) // #nosec G304 -- writing within user workspace
+	if err != nil {
+		return fmt.Errorf("read %s: %w", uri, err)
+	}
+
+	updated := lsp.ApplyTextEdits(string(current), edits)
+
+	mode := os.FileMode(0o644)
+	if info, statErr := os.Stat(path); statErr == nil {
+		mode = info.Mode()
+	}
+
+	if err := os.WriteFile(path, []byte(updated), mode); err != nil {
+		return fmt.Errorf("write %s: %w", uri, err)
+	}
+
+	return nil
+}
+
+// buildBatchRangeFormattingReport formats every requested range, merging and
+// overlap-checking each file's edits before any writes happen. If any file
+// fails at that stage - a bad range, a dead language server, overlapping
+// edits - apply is withheld for the whole batch and no file is written.
+// Once writing starts, files are written one at a time: if a write fails,
+// files already written stay written and the remaining files are still
+// attempted; the failure is reported on that file's result.
+func buildBatchRangeFormattingReport(bridge interfaces.BridgeInterface, requests []batchRangeFormattingRequest, apply bool) BatchRangeFormattingReport {
+	results := make([]BatchRangeFormattingFileResult, len(requests))
+	edits := make([][]protocol.TextEdit, len(requests))
+	batchOK := true
+
+	for i, reqItem := range requests {
+		results[i] = BatchRangeFormattingFileResult{URI: reqItem.URI}
+
+		fileEdits, err := collectBatchRangeFormattingEdits(bridge, reqItem)
+		if err != nil {
+			results[i].Error = err.Error()
+			batchOK = false
+			continue
+		}
+
+		edits[i] = fileEdits
+		results[i].EditCount = len(fileEdits)
+	}
+
+	if !apply || !batchOK {
+		for i := range results {
+			if results[i].Error == "" {
+				results[i].Skipped = true
+			}
+		}
+		return finishBatchRangeFormattingReport(results, false)
+	}
+
+	for i, reqItem := range requests {
+		if len(edits[i]) == 0 {
+			results[i].Skipped = true
+			continue
+		}
+		if err := applyBatchRangeFormattingEdits(bridge, reqItem.URI, edits[i]); err != nil {
+			results[i].Error = fmt.Sprintf("apply failed: %v", err)
+			continue
+		}
+		results[i].Applied = true
+	}
+
+	return finishBatchRangeFormattingReport(results, true)
+}
+
+func finishBatchRangeFormattingReport(results []BatchRangeFormattingFileResult, attemptedApply bool) BatchRangeFormattingReport {
+	exitCode := 0
+	allApplied := attemptedApply
+	for _, r := range results {
+		if r.Error != "" {
+			exitCode = 1
+			allApplied = false
+		}
+	}
+	return BatchRangeFormattingReport{Results: results, Applied: allApplied, ExitCode: exitCode}
+}
+
+func formatBatchRangeFormattingText(report BatchRangeFormattingReport) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "BATCH_RANGE_FORMATTING|files=%d|applied=%t|exit_code=%d\n", len(report.Results), report.Applied, report.ExitCode)
+	for _, r := range report.Results {
+		switch {
+		case r.Error != "":
+			fmt.Fprintf(&sb, "ERROR    %s: %s\n", r.URI, r.Error)
+		case r.Applied:
+			fmt.Fprintf(&sb, "APPLIED  %s (%d edit(s))\n", r.URI, r.EditCount)
+		case r.Skipped:
+			fmt.Fprintf(&sb, "SKIPPED  %s (%d edit(s))\n", r.URI, r.EditCount)
+		default:
+			fmt.Fprintf(&sb, "PENDING  %s (%d edit(s))\n", r.URI, r.EditCount)
+		}
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+// BatchRangeFormattingTool registers "batch_range_formatting": a multi-file,
+// multi-range counterpart to RangeFormattingTool for agents that want to
+// reformat several regions in one round-trip instead of one call per range.
+func BatchRangeFormattingTool(bridge interfaces.BridgeInterface) (mcp.Tool, server.ToolHandlerFunc) {
+	return mcp.NewTool("batch_range_formatting",
+			mcp.WithDescription("Format multiple ranges across one or more files in a single call (textDocument/rangeFormatting per range). Each file's edits are merged, sorted, and checked for overlaps; with apply=true, files are written only if every file's edits were computed without error; a write failure on one file does not undo files already written."),
+			mcp.WithDestructiveHintAnnotation(true),
+			mcp.WithString("requests_json", mcp.Description(`JSON array: [{"uri":"file:///a.bsl","ranges":[{"start_line":0,"start_character":0,"end_line":5,"end_character":0}],"tab_size":4,"insert_spaces":true}]`), mcp.Required()),
+			mcp.WithBoolean("apply", mcp.Description("Write merged edits to disk for every file (default: false, preview only)."), mcp.DefaultBool(false)),
+			mcp.WithString("output_format", mcp.Description("'text' (default) or 'json'.")),
+		), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			requestsJSON, err := request.RequireString("requests_json")
+			if err != nil {
+				logger.Error("batch_range_formatting: requests_json parsing failed", err)
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			var requests []batchRangeFormattingRequest
+			if err := json.Unmarshal([]byte(requestsJSON), &requests); err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("Invalid requests_json: %v", err)), nil
+			}
+			if len(requests) == 0 {
+				return mcp.NewToolResultError("requests_json must contain at least one file"), nil
+			}
+
+			apply := request.GetBool("apply", false)
+			outputFormat := strings.ToLower(strings.TrimSpace(request.GetString("output_format", "")))
+
+			if result, ok := CheckReadyOrReturn(bridge); !ok {
+				return result, nil
+			}
+
+			report := buildBatchRangeFormattingReport(bridge, requests, apply)
+
+			if outputFormat == "json" {
+				data, err := json.MarshalIndent(report, "", "  ")
+				if err != nil {
+					return mcp.NewToolResultError(fmt.Sprintf("Failed to marshal report: %v", err)), nil
+				}
+				return mcp.NewToolResultText(string(data)), nil
+			}
+
+			return mcp.NewToolResultText(formatBatchRangeFormattingText(report)), nil
+		}
+}
+
+func RegisterBatchRangeFormattingTool(mcpServer ToolServer, bridge interfaces.BridgeInterface) {
+	mcpServer.AddTool(BatchRangeFormattingTool(bridge))
+}
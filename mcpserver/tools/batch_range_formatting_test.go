@@ -0,0 +1,73 @@
+package tools
+
+import (
+	"testing"
+
+	"github.com/myleshyson/lsprotocol-go/protocol"
+)
+
+func textEditAt(startLine, startChar, endLine, endChar uint32, text string) protocol.TextEdit {
+	return protocol.TextEdit{
+		Range: protocol.Range{
+			Start: protocol.Position{Line: startLine, Character: startChar},
+			End:   protocol.Position{Line: endLine, Character: endChar},
+		},
+		NewText: text,
+	}
+}
+
+func TestMergeSortedRangeEdits_SortsByPosition(t *testing.T) {
+	edits := []protocol.TextEdit{
+		textEditAt(5, 0, 5, 1, "b"),
+		textEditAt(1, 0, 1, 1, "a"),
+	}
+
+	merged, err := mergeSortedRangeEdits(edits)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(merged) != 2 || merged[0].NewText != "a" || merged[1].NewText != "b" {
+		t.Fatalf("merged edits not sorted by start position: %+v", merged)
+	}
+}
+
+func TestMergeSortedRangeEdits_DetectsOverlap(t *testing.T) {
+	edits := []protocol.TextEdit{
+		textEditAt(1, 0, 3, 0, "first"),
+		textEditAt(2, 0, 4, 0, "second"),
+	}
+
+	if _, err := mergeSortedRangeEdits(edits); err == nil {
+		t.Fatal("expected an overlap error, got nil")
+	}
+}
+
+func TestMergeSortedRangeEdits_AdjacentEditsDoNotOverlap(t *testing.T) {
+	edits := []protocol.TextEdit{
+		textEditAt(1, 0, 2, 0, "first"),
+		textEditAt(2, 0, 3, 0, "second"),
+	}
+
+	merged, err := mergeSortedRangeEdits(edits)
+	if err != nil {
+		t.Fatalf("adjacent (touching, non-overlapping) edits should be allowed: %v", err)
+	}
+	if len(merged) != 2 {
+		t.Fatalf("len(merged) = %d, want 2", len(merged))
+	}
+}
+
+func TestFinishBatchRangeFormattingReport_AnyErrorFailsTheBatch(t *testing.T) {
+	results := []BatchRangeFormattingFileResult{
+		{URI: "file:///a.bsl", Applied: true},
+		{URI: "file:///b.bsl", Error: "range 0: overlapping edits"},
+	}
+
+	report := finishBatchRangeFormattingReport(results, true)
+	if report.Applied {
+		t.Error("Applied = true, want false when any file errored")
+	}
+	if report.ExitCode != 1 {
+		t.Errorf("ExitCode = %d, want 1", report.ExitCode)
+	}
+}
@@ -2,12 +2,12 @@ package tools
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"strings"
 	"sync"
 	"time"
 
+	bridgepkg "rockerboo/mcp-lsp-bridge/bridge"
 	"rockerboo/mcp-lsp-bridge/interfaces"
 	"rockerboo/mcp-lsp-bridge/logger"
 
@@ -25,6 +25,25 @@ const (
 	TimeoutSeconds   = 60
 )
 
+// callGraphWorkerPoolSize bounds how many IncomingCalls/OutgoingCalls
+// requests buildTreeBFS has in flight at once, replacing the old
+// recursive design's per-level goroutine fan-out (which opened a fresh
+// 5-slot semaphore at every depth and could pile up thousands of blocked
+// goroutines on a dense graph).
+const callGraphWorkerPoolSize = 8
+
+// callGraphQueueCapacity bounds buildTreeBFS's job/result channels. It's
+// sized well above HardLimitNodes so the node-count check (not channel
+// backpressure) is what throttles traversal - a full queue would indicate
+// a single job fanning out to thousands of calls, which the per-child
+// maxNodes check below already guards against.
+const callGraphQueueCapacity = 2048
+
+// callGraphMaxCyclePathLen bounds how far recordCycle walks parent
+// pointers back looking for the repeated node, so a very deep chain can't
+// turn one cycle report into an unbounded walk.
+const callGraphMaxCyclePathLen = 64
+
 // Known BSL entry points (event handlers, commands, etc.)
 var bslEntryPoints = map[string]bool{
 	// Document events
@@ -87,37 +106,86 @@ type CallGraphNode struct {
 
 // CallGraphResult is the complete result of call graph analysis
 type CallGraphResult struct {
-	Root           *CallGraphNode `json:"root"`
-	IncomingTree   *CallGraphNode `json:"incoming_tree,omitempty"`
-	OutgoingTree   *CallGraphNode `json:"outgoing_tree,omitempty"`
-	TotalNodes     int            `json:"total_nodes"`
-	MaxDepthUp     int            `json:"max_depth_up_reached"`
-	MaxDepthDown   int            `json:"max_depth_down_reached"`
-	Truncated      bool           `json:"truncated"`
-	TruncateReason string         `json:"truncate_reason,omitempty"`
-	CyclesFound    int            `json:"cycles_found"`
-	EntryPoints    []string       `json:"entry_points_found,omitempty"`
-	ElapsedMs      int64          `json:"elapsed_ms"`
+	Root           *CallGraphNode  `json:"root"`
+	IncomingTree   *CallGraphNode  `json:"incoming_tree,omitempty"`
+	OutgoingTree   *CallGraphNode  `json:"outgoing_tree,omitempty"`
+	TotalNodes     int             `json:"total_nodes"`
+	MaxDepthUp     int             `json:"max_depth_up_reached"`
+	MaxDepthDown   int             `json:"max_depth_down_reached"`
+	Truncated      bool            `json:"truncated"`
+	TruncateReason string          `json:"truncate_reason,omitempty"`
+	CyclesFound    int             `json:"cycles_found"`
+	Cycles         []CyclePath     `json:"cycles,omitempty"`
+	EntryPoints    []EntryPointHit `json:"entry_points_found,omitempty"`
+	CacheHits      int64           `json:"cache_hits"`
+	CacheMisses    int64           `json:"cache_misses"`
+	ElapsedMs      int64           `json:"elapsed_ms"`
+}
+
+// CyclePath is one cycle buildTreeBFS's parent-pointer walk (recordCycle)
+// reconstructed: the sequence of node keys/names from the first node on
+// the path back to the repeated node, e.g. Names ["A", "B", "C", "A"].
+// When the repeated node was reached from an unrelated branch rather than
+// a true back-edge (two independent paths converging on the same callee),
+// the reconstructed path still ends at the repeat but may not start
+// there - it's the best ancestor chain recordCycle could walk.
+type CyclePath struct {
+	Keys  []string `json:"keys"`
+	Names []string `json:"names"`
+}
+
+// EntryPointHit is one entry point CallGraphTool found while walking the
+// graph, along with the EntryPointRegistry pattern name that matched it -
+// "builtin:<name>" for the hard-coded bslEntryPoints fallback, or the Name of a
+// registered EntryPointPattern - so callers can audit why a node was
+// flagged as an entry point.
+type EntryPointHit struct {
+	Name    string `json:"name"`
+	Pattern string `json:"pattern"`
 }
 
-// callGraphBuilder manages the recursive graph building
+// callGraphBuilder manages the BFS graph building (see buildTreeBFS). All
+// fields are safe for concurrent use by the worker pool.
 type callGraphBuilder struct {
-	bridge         interfaces.BridgeInterface
-	visited        map[string]bool
-	visitedMu      sync.RWMutex
-	nodeCount      int
-	nodeCountMu    sync.Mutex
-	maxNodes       int
-	depthUp        int
-	depthDown      int
-	cyclesFound    int
-	cyclesMu       sync.Mutex
-	entryPoints    []string
-	entryMu        sync.Mutex
-	maxDepthUp     int
-	maxDepthDown   int
-	depthMu        sync.Mutex
-	ctx            context.Context
+	bridge interfaces.BridgeInterface
+
+	// visited maps a node key to the key of the node that first
+	// discovered it, so a repeat lookup can walk parent pointers back to
+	// reconstruct the actual cycle path (see recordCycle) instead of just
+	// flagging a boolean.
+	visited   map[string]string
+	visitedMu sync.RWMutex
+
+	// nodeNames maps a node key to its symbol name, populated by
+	// itemToNode, so recordCycle can report a human-readable path
+	// alongside the raw keys.
+	nodeNames   map[string]string
+	nodeNamesMu sync.Mutex
+
+	nodeCount   int
+	nodeCountMu sync.Mutex
+	maxNodes    int
+
+	depthUp   int
+	depthDown int
+
+	// bypassCache skips callGraphCacheFor's lookup/store entirely (the
+	// bypass_cache tool parameter), for callers who want a guaranteed
+	// fresh read of the LSP regardless of what's cached.
+	bypassCache bool
+
+	cycles   []CyclePath
+	cyclesMu sync.Mutex
+
+	entryPoints []EntryPointHit
+	entryMu     sync.Mutex
+
+	maxDepthUp   int
+	maxDepthDown int
+	depthMu      sync.Mutex
+
+	ctx context.Context
+
 	truncated      bool
 	truncateReason string
 }
@@ -146,7 +214,7 @@ Parameters:
 Output includes:
 - Complete call trees (incoming/outgoing)
 - Entry point detection (BSL events like ПриЗаписи, ПриОткрытии)
-- Cycle detection with markers
+- Cycle detection with the actual repeated path (A -> B -> C -> A)
 - Truncation info if limits reached`),
 			mcp.WithDestructiveHintAnnotation(false),
 			mcp.WithString("uri", mcp.Description("URI to the file"), mcp.Required()),
@@ -155,6 +223,8 @@ Output includes:
 			mcp.WithNumber("depth_up", mcp.Description("Max depth for incoming calls (default: 5, 0 = unlimited)")),
 			mcp.WithNumber("depth_down", mcp.Description("Max depth for outgoing calls (default: 5, 0 = unlimited)")),
 			mcp.WithNumber("max_nodes", mcp.Description("Max total nodes (default: 100, 0 = unlimited, hard limit: 500)")),
+			mcp.WithString("format", mcp.Description("Output format: 'json' (default), 'dot' (Graphviz digraph), or 'mermaid' (Mermaid flowchart).")),
+			mcp.WithBoolean("bypass_cache", mcp.Description("Skip the on-disk call-graph cache and always query the LSP fresh (default: false)."), mcp.DefaultBool(false)),
 		), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 			startTime := time.Now()
 
@@ -235,21 +305,22 @@ Output includes:
 
 			// Create builder
 			builder := &callGraphBuilder{
-				bridge:    bridge,
-				visited:   make(map[string]bool),
-				maxNodes:  maxNodes,
-				depthUp:   depthUp,
-				depthDown: depthDown,
-				ctx:       timeoutCtx,
+				bridge:      bridge,
+				visited:     make(map[string]string),
+				maxNodes:    maxNodes,
+				depthUp:     depthUp,
+				depthDown:   depthDown,
+				ctx:         timeoutCtx,
+				bypassCache: request.GetBool("bypass_cache", false),
 			}
 
 			// Build root node
 			rootNode := builder.itemToNode(&rootItem, 0, "root")
 
 			// Check if root is an entry point
-			if isEntryPoint(rootItem.Name) {
+			if matched, pattern := isEntryPoint(bridge, rootItem.Name, symbolKindToString(rootItem.Kind)); matched {
 				rootNode.IsEntryPoint = true
-				builder.addEntryPoint(rootItem.Name)
+				builder.addEntryPoint(rootItem.Name, pattern)
 			}
 
 			// Build incoming tree (callers) - parallel
@@ -271,6 +342,11 @@ Output includes:
 
 			wg.Wait()
 
+			var cacheHits, cacheMisses int64
+			if cache := builder.cache(); cache != nil {
+				cacheHits, cacheMisses = cache.Counts()
+			}
+
 			// Build result
 			result := &CallGraphResult{
 				Root:           rootNode,
@@ -281,29 +357,47 @@ Output includes:
 				MaxDepthDown:   builder.maxDepthDown,
 				Truncated:      builder.truncated,
 				TruncateReason: builder.truncateReason,
-				CyclesFound:    builder.cyclesFound,
+				CyclesFound:    len(builder.cycles),
+				Cycles:         builder.cycles,
 				EntryPoints:    builder.entryPoints,
+				CacheHits:      cacheHits,
+				CacheMisses:    cacheMisses,
 				ElapsedMs:      time.Since(startTime).Milliseconds(),
 			}
 
-			// Marshal to JSON
-			jsonBytes, err := json.MarshalIndent(result, "", "  ")
+			format := request.GetString("format", "json")
+			rendered, err := result.Render(format)
 			if err != nil {
-				return mcp.NewToolResultError(fmt.Sprintf("Failed to marshal result: %v", err)), nil
+				return mcp.NewToolResultError(err.Error()), nil
 			}
 
-			return mcp.NewToolResultText(string(jsonBytes)), nil
+			return mcp.NewToolResultText(rendered), nil
 		}
 }
 
+// callGraphNodeKey identifies a call-hierarchy node by its declaration
+// site, matching symbol_call_graph.go's node-identity convention.
+func callGraphNodeKey(item *protocol.CallHierarchyItem) string {
+	return fmt.Sprintf("%s:%d:%d", item.Uri, item.Range.Start.Line, item.Range.Start.Character)
+}
+
 // itemToNode converts a CallHierarchyItem to a CallGraphNode
 func (b *callGraphBuilder) itemToNode(item *protocol.CallHierarchyItem, depth int, direction string) *CallGraphNode {
 	b.nodeCountMu.Lock()
 	b.nodeCount++
 	b.nodeCountMu.Unlock()
 
+	key := callGraphNodeKey(item)
+
+	b.nodeNamesMu.Lock()
+	if b.nodeNames == nil {
+		b.nodeNames = make(map[string]string)
+	}
+	b.nodeNames[key] = item.Name
+	b.nodeNamesMu.Unlock()
+
 	return &CallGraphNode{
-		ID:        fmt.Sprintf("%s:%d:%d", item.Uri, item.Range.Start.Line, item.Range.Start.Character),
+		ID:        key,
 		Name:      item.Name,
 		Kind:      symbolKindToString(item.Kind),
 		URI:       string(item.Uri),
@@ -314,299 +408,415 @@ func (b *callGraphBuilder) itemToNode(item *protocol.CallHierarchyItem, depth in
 	}
 }
 
-// buildIncomingTree recursively builds the incoming calls tree
-func (b *callGraphBuilder) buildIncomingTree(item *protocol.CallHierarchyItem, depth int) *CallGraphNode {
-	// Check context cancellation (timeout)
+// buildIncomingTree builds the incoming (callers) tree rooted at item,
+// whose direct callers are reported at startDepth. See buildTreeBFS.
+func (b *callGraphBuilder) buildIncomingTree(item *protocol.CallHierarchyItem, startDepth int) *CallGraphNode {
+	return b.buildTreeBFS(item, "up", b.depthUp, startDepth)
+}
+
+// buildOutgoingTree builds the outgoing (callees) tree rooted at item,
+// whose direct callees are reported at startDepth. See buildTreeBFS.
+// reachability_analysis.go calls this directly, once per BSL entry point,
+// sharing one callGraphBuilder (and its visited set) across all of them.
+func (b *callGraphBuilder) buildOutgoingTree(item *protocol.CallHierarchyItem, startDepth int) *CallGraphNode {
+	return b.buildTreeBFS(item, "down", b.depthDown, startDepth)
+}
+
+// callGraphJob is one unit of work for buildTreeBFS's worker pool: fetch
+// item's callers/callees and report item itself as parentKey's child.
+type callGraphJob struct {
+	item      protocol.CallHierarchyItem
+	depth     int
+	parentKey string
+}
+
+// callGraphJobResult is a completed job's node, ready for the assembler
+// goroutine to attach under parentKey.
+type callGraphJobResult struct {
+	parentKey string
+	key       string
+	node      *CallGraphNode
+}
+
+// buildTreeBFS replaces the old design's recursive per-level goroutine
+// fan-out (a fresh sync.WaitGroup and 5-slot semaphore at every depth)
+// with a single BFS: one shared job queue, a fixed callGraphWorkerPoolSize
+// pool of workers calling IncomingCalls/OutgoingCalls, and a results
+// channel feeding one assembler goroutine that builds the tree from
+// (parentKey -> node) pairs. direction is "up" (IncomingCalls) or "down"
+// (OutgoingCalls); root's direct calls are reported at startDepth.
+func (b *callGraphBuilder) buildTreeBFS(root *protocol.CallHierarchyItem, direction string, maxDepth, startDepth int) *CallGraphNode {
+	rootKey := callGraphNodeKey(root)
+
+	jobs := make(chan callGraphJob, callGraphQueueCapacity)
+	results := make(chan callGraphJobResult, callGraphQueueCapacity)
+
+	var inFlight sync.WaitGroup
+	inFlight.Add(1)
+	jobs <- callGraphJob{item: *root, depth: startDepth, parentKey: rootKey}
+
+	var workers sync.WaitGroup
+	for range callGraphWorkerPoolSize {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			for job := range jobs {
+				b.processCallGraphJob(job, direction, maxDepth, jobs, results, &inFlight)
+				inFlight.Done()
+			}
+		}()
+	}
+
+	go func() {
+		inFlight.Wait()
+		close(jobs)
+	}()
+
+	go func() {
+		workers.Wait()
+		close(results)
+	}()
+
+	children := make(map[string][]*CallGraphNode)
+	for res := range results {
+		if res.node == nil {
+			continue
+		}
+		children[res.parentKey] = append(children[res.parentKey], res.node)
+	}
+
+	var assemble func(key string) []*CallGraphNode
+	assemble = func(key string) []*CallGraphNode {
+		kids := children[key]
+		for _, kid := range kids {
+			kid.Children = assemble(kid.ID)
+		}
+		return kids
+	}
+
+	rootChildren := assemble(rootKey)
+	if len(rootChildren) == 0 {
+		return nil
+	}
+
+	containerID := fmt.Sprintf("outgoing-%s:%d", root.Uri, root.Range.Start.Line)
+	containerName := fmt.Sprintf("Calls from %s", root.Name)
+	if direction == "up" {
+		containerID = fmt.Sprintf("incoming-%s:%d", root.Uri, root.Range.Start.Line)
+		containerName = fmt.Sprintf("Callers of %s", root.Name)
+	}
+
+	return &CallGraphNode{
+		ID:        containerID,
+		Name:      containerName,
+		Direction: direction,
+		Depth:     startDepth,
+		Children:  rootChildren,
+	}
+}
+
+// processCallGraphJob handles one BFS job: apply the timeout/node-count
+// limits, resolve job.item's node (flagging it as a cycle and recording
+// the repeated path if it was already visited), fetch its
+// callers/callees, and enqueue each as a new job - unless maxDepth or a
+// limit stops expansion, in which case the node is still reported, just
+// as a leaf.
+func (b *callGraphBuilder) processCallGraphJob(job callGraphJob, direction string, maxDepth int, jobs chan<- callGraphJob, results chan<- callGraphJobResult, inFlight *sync.WaitGroup) {
 	select {
 	case <-b.ctx.Done():
 		b.setTruncated("timeout after 60 seconds")
-		return nil
+		return
 	default:
 	}
 
-	// Check depth limit
-	if depth > b.depthUp {
-		return nil
-	}
-
-	// Check node limit
 	b.nodeCountMu.Lock()
 	if b.nodeCount >= b.maxNodes {
 		b.nodeCountMu.Unlock()
 		b.setTruncated(fmt.Sprintf("max_nodes limit reached (%d)", b.maxNodes))
-		return nil
+		return
 	}
 	b.nodeCountMu.Unlock()
 
-	// Update max depth reached
-	b.depthMu.Lock()
-	if depth > b.maxDepthUp {
-		b.maxDepthUp = depth
-	}
-	b.depthMu.Unlock()
+	key := callGraphNodeKey(&job.item)
 
-	// Get incoming calls from LSP
-	calls, err := b.bridge.IncomingCalls(*item)
-	if err != nil {
-		logger.Error("call_graph: failed to get incoming calls", err)
-		return nil
+	b.visitedMu.Lock()
+	_, seen := b.visited[key]
+	if !seen {
+		b.visited[key] = job.parentKey
 	}
+	b.visitedMu.Unlock()
 
-	if len(calls) == 0 {
-		return nil
-	}
+	node := b.itemToNode(&job.item, job.depth, direction)
 
-	// Create container node for incoming calls
-	containerNode := &CallGraphNode{
-		ID:        fmt.Sprintf("incoming-%s:%d", item.Uri, item.Range.Start.Line),
-		Name:      fmt.Sprintf("Callers of %s", item.Name),
-		Direction: "up",
-		Depth:     depth,
-		Children:  make([]*CallGraphNode, 0, len(calls)),
+	if seen {
+		node.IsCycle = true
+		b.recordCycle(key, job.parentKey)
+		results <- callGraphJobResult{parentKey: job.parentKey, key: key, node: node}
+		return
 	}
 
-	// Process calls in parallel with limiting
-	var wg sync.WaitGroup
-	var mu sync.Mutex
-	semaphore := make(chan struct{}, 5) // Limit concurrent LSP calls
-
-	for _, call := range calls {
-		// Check limits before spawning goroutine
-		b.nodeCountMu.Lock()
-		if b.nodeCount >= b.maxNodes {
-			b.nodeCountMu.Unlock()
-			b.setTruncated(fmt.Sprintf("max_nodes limit reached (%d)", b.maxNodes))
-			break
+	if direction == "up" {
+		if matched, pattern := isEntryPoint(b.bridge, job.item.Name, symbolKindToString(job.item.Kind)); matched {
+			node.IsEntryPoint = true
+			b.addEntryPoint(job.item.Name, pattern)
 		}
-		b.nodeCountMu.Unlock()
+	}
 
-		select {
-		case <-b.ctx.Done():
-			b.setTruncated("timeout after 60 seconds")
-			break
-		default:
+	b.depthMu.Lock()
+	if direction == "up" {
+		if job.depth > b.maxDepthUp {
+			b.maxDepthUp = job.depth
 		}
+	} else if job.depth > b.maxDepthDown {
+		b.maxDepthDown = job.depth
+	}
+	b.depthMu.Unlock()
 
-		wg.Add(1)
-		callCopy := call // Capture for goroutine
+	results <- callGraphJobResult{parentKey: job.parentKey, key: key, node: node}
 
-		go func() {
-			defer wg.Done()
-			semaphore <- struct{}{}        // Acquire
-			defer func() { <-semaphore }() // Release
-
-			callerItem := callCopy.From
-			nodeKey := fmt.Sprintf("%s:%d:%d", callerItem.Uri, callerItem.Range.Start.Line, callerItem.Range.Start.Character)
-
-			// Check for cycle
-			b.visitedMu.RLock()
-			isCycle := b.visited[nodeKey]
-			b.visitedMu.RUnlock()
-
-			node := b.itemToNode(&callerItem, depth, "up")
-
-			if isCycle {
-				node.IsCycle = true
-				b.cyclesMu.Lock()
-				b.cyclesFound++
-				b.cyclesMu.Unlock()
-
-				mu.Lock()
-				containerNode.Children = append(containerNode.Children, node)
-				mu.Unlock()
-				return
-			}
+	if job.depth >= maxDepth {
+		return
+	}
 
-			// Mark as visited
-			b.visitedMu.Lock()
-			b.visited[nodeKey] = true
-			b.visitedMu.Unlock()
+	var calls []protocol.CallHierarchyIncomingCall
+	var outCalls []protocol.CallHierarchyOutgoingCall
+	var err error
+	if direction == "up" {
+		calls, err = b.fetchIncomingCalls(job.item, key)
+	} else {
+		outCalls, err = b.fetchOutgoingCalls(job.item, key)
+	}
+	if err != nil {
+		logger.Error(fmt.Sprintf("call_graph: failed to get %s calls", direction), err)
+		return
+	}
 
-			// Check if entry point
-			if isEntryPoint(callerItem.Name) {
-				node.IsEntryPoint = true
-				b.addEntryPoint(callerItem.Name)
-			}
+	for _, call := range calls {
+		b.enqueueCallGraphChild(call.From, job.depth+1, key, jobs, inFlight)
+	}
+	for _, call := range outCalls {
+		b.enqueueCallGraphChild(call.To, job.depth+1, key, jobs, inFlight)
+	}
+}
 
-			// Recurse for incoming calls
-			childTree := b.buildIncomingTree(&callerItem, depth+1)
-			if childTree != nil && len(childTree.Children) > 0 {
-				node.Children = childTree.Children
-			}
+// fetchIncomingCalls returns key's callers, preferring a cached result
+// (see callGraphCacheFor) over an LSP round trip. A cache miss - or any
+// error hashing/reading the file, which just means caching is skipped for
+// this job - falls through to bridge.IncomingCalls, and a successful
+// result is stored back for next time.
+func (b *callGraphBuilder) fetchIncomingCalls(item protocol.CallHierarchyItem, key string) ([]protocol.CallHierarchyIncomingCall, error) {
+	cache := b.cache()
+	if cache == nil {
+		return b.bridge.IncomingCalls(item)
+	}
 
-			mu.Lock()
-			containerNode.Children = append(containerNode.Children, node)
-			mu.Unlock()
-		}()
+	fileHash, err := bridgepkg.HashFile(string(item.Uri))
+	if err != nil {
+		return b.bridge.IncomingCalls(item)
 	}
 
-	wg.Wait()
+	if calls, ok := cache.GetIncoming(string(item.Uri), fileHash, key); ok {
+		return calls, nil
+	}
 
-	if len(containerNode.Children) == 0 {
-		return nil
+	calls, err := b.bridge.IncomingCalls(item)
+	if err != nil {
+		return nil, err
 	}
 
-	return containerNode
+	if err := cache.PutIncoming(string(item.Uri), fileHash, key, calls); err != nil {
+		logger.Error("call_graph: failed to write incoming calls to cache", err)
+	}
+	return calls, nil
 }
 
-// buildOutgoingTree recursively builds the outgoing calls tree
-func (b *callGraphBuilder) buildOutgoingTree(item *protocol.CallHierarchyItem, depth int) *CallGraphNode {
-	// Check context cancellation (timeout)
-	select {
-	case <-b.ctx.Done():
-		b.setTruncated("timeout after 60 seconds")
-		return nil
-	default:
+// fetchOutgoingCalls is fetchIncomingCalls' outgoing-direction twin.
+func (b *callGraphBuilder) fetchOutgoingCalls(item protocol.CallHierarchyItem, key string) ([]protocol.CallHierarchyOutgoingCall, error) {
+	cache := b.cache()
+	if cache == nil {
+		return b.bridge.OutgoingCalls(item)
 	}
 
-	// Check depth limit
-	if depth > b.depthDown {
-		return nil
+	fileHash, err := bridgepkg.HashFile(string(item.Uri))
+	if err != nil {
+		return b.bridge.OutgoingCalls(item)
 	}
 
-	// Check node limit
-	b.nodeCountMu.Lock()
-	if b.nodeCount >= b.maxNodes {
-		b.nodeCountMu.Unlock()
-		b.setTruncated(fmt.Sprintf("max_nodes limit reached (%d)", b.maxNodes))
-		return nil
+	if calls, ok := cache.GetOutgoing(string(item.Uri), fileHash, key); ok {
+		return calls, nil
 	}
-	b.nodeCountMu.Unlock()
 
-	// Update max depth reached
-	b.depthMu.Lock()
-	if depth > b.maxDepthDown {
-		b.maxDepthDown = depth
+	calls, err := b.bridge.OutgoingCalls(item)
+	if err != nil {
+		return nil, err
 	}
-	b.depthMu.Unlock()
 
-	// Get outgoing calls from LSP
-	calls, err := b.bridge.OutgoingCalls(*item)
-	if err != nil {
-		logger.Error("call_graph: failed to get outgoing calls", err)
-		return nil
+	if err := cache.PutOutgoing(string(item.Uri), fileHash, key, calls); err != nil {
+		logger.Error("call_graph: failed to write outgoing calls to cache", err)
 	}
+	return calls, nil
+}
 
-	if len(calls) == 0 {
+// cache returns the bridge's CallGraphCache, or nil if bypassCache is set
+// or the concrete bridge doesn't expose one (see callGraphCacheFor).
+func (b *callGraphBuilder) cache() *bridgepkg.CallGraphCache {
+	if b.bypassCache {
 		return nil
 	}
+	return callGraphCacheFor(b.bridge)
+}
 
-	// Create container node for outgoing calls
-	containerNode := &CallGraphNode{
-		ID:        fmt.Sprintf("outgoing-%s:%d", item.Uri, item.Range.Start.Line),
-		Name:      fmt.Sprintf("Calls from %s", item.Name),
-		Direction: "down",
-		Depth:     depth,
-		Children:  make([]*CallGraphNode, 0, len(calls)),
+// callGraphCacheFor returns the concrete bridge's call graph cache, or nil
+// if bridge isn't backed by *bridgepkg.MCPLSPBridge (e.g. a test mock),
+// mirroring entryPointRegistryFor's type-assertion pattern below.
+func callGraphCacheFor(bridge interfaces.BridgeInterface) *bridgepkg.CallGraphCache {
+	b, ok := bridge.(*bridgepkg.MCPLSPBridge)
+	if !ok {
+		return nil
 	}
+	return b.CallGraphCache()
+}
 
-	// Process calls in parallel with limiting
-	var wg sync.WaitGroup
-	var mu sync.Mutex
-	semaphore := make(chan struct{}, 5) // Limit concurrent LSP calls
+// invalidateCallGraphCache drops every cached call-graph entry for each
+// changed/deleted file in changes, so did_change_watched_files.go's tool
+// handler can't leave stale IncomingCalls/OutgoingCalls results behind
+// when a file is edited outside the normal didOpen/didChange flow (the
+// cache key already includes the content hash, but without this the
+// superseded entries would just sit on disk until something reads them
+// into a miss). A no-op if caching is disabled.
+func invalidateCallGraphCache(bridge interfaces.BridgeInterface, changes []protocol.FileEvent) {
+	cache := callGraphCacheFor(bridge)
+	if cache == nil {
+		return
+	}
 
-	for _, call := range calls {
-		// Check limits before spawning goroutine
-		b.nodeCountMu.Lock()
-		if b.nodeCount >= b.maxNodes {
-			b.nodeCountMu.Unlock()
-			b.setTruncated(fmt.Sprintf("max_nodes limit reached (%d)", b.maxNodes))
-			break
+	for _, change := range changes {
+		if err := cache.InvalidateURI(string(change.Uri)); err != nil {
+			logger.Error("call_graph: failed to invalidate cache for changed file", err)
 		}
+	}
+}
+
+// enqueueCallGraphChild adds one more BFS job, respecting the node-count
+// limit so a job whose calls list is very long can't flood the queue past
+// maxNodes worth of real work.
+func (b *callGraphBuilder) enqueueCallGraphChild(item protocol.CallHierarchyItem, depth int, parentKey string, jobs chan<- callGraphJob, inFlight *sync.WaitGroup) {
+	b.nodeCountMu.Lock()
+	if b.nodeCount+len(jobs) >= b.maxNodes {
 		b.nodeCountMu.Unlock()
+		b.setTruncated(fmt.Sprintf("max_nodes limit reached (%d)", b.maxNodes))
+		return
+	}
+	b.nodeCountMu.Unlock()
+
+	inFlight.Add(1)
+	jobs <- callGraphJob{item: item, depth: depth, parentKey: parentKey}
+}
 
-		select {
-		case <-b.ctx.Done():
-			b.setTruncated("timeout after 60 seconds")
+// recordCycle reconstructs the path from fromKey back through parent
+// pointers to repeatKey (the node job.item revisited), so
+// CallGraphResult.Cycles reports the actual repeated path instead of just
+// a boolean flag. If the walk reaches callGraphMaxCyclePathLen entries or
+// a node with no recorded parent before finding repeatKey again - which
+// happens when two independent branches converge on the same callee
+// rather than a true back-edge - the path still ends at repeatKey, just
+// doesn't start there.
+func (b *callGraphBuilder) recordCycle(repeatKey, fromKey string) {
+	b.visitedMu.RLock()
+	chain := []string{fromKey}
+	cur := fromKey
+	for i := 0; i < callGraphMaxCyclePathLen && cur != repeatKey; i++ {
+		parent, ok := b.visited[cur]
+		if !ok || parent == "" {
 			break
-		default:
 		}
+		cur = parent
+		chain = append(chain, cur)
+	}
+	b.visitedMu.RUnlock()
 
-		wg.Add(1)
-		callCopy := call // Capture for goroutine
-
-		go func() {
-			defer wg.Done()
-			semaphore <- struct{}{}        // Acquire
-			defer func() { <-semaphore }() // Release
-
-			calleeItem := callCopy.To
-			nodeKey := fmt.Sprintf("%s:%d:%d", calleeItem.Uri, calleeItem.Range.Start.Line, calleeItem.Range.Start.Character)
-
-			// Check for cycle
-			b.visitedMu.RLock()
-			isCycle := b.visited[nodeKey]
-			b.visitedMu.RUnlock()
-
-			node := b.itemToNode(&calleeItem, depth, "down")
-
-			if isCycle {
-				node.IsCycle = true
-				b.cyclesMu.Lock()
-				b.cyclesFound++
-				b.cyclesMu.Unlock()
-
-				mu.Lock()
-				containerNode.Children = append(containerNode.Children, node)
-				mu.Unlock()
-				return
-			}
-
-			// Mark as visited
-			b.visitedMu.Lock()
-			b.visited[nodeKey] = true
-			b.visitedMu.Unlock()
-
-			// Recurse for outgoing calls
-			childTree := b.buildOutgoingTree(&calleeItem, depth+1)
-			if childTree != nil && len(childTree.Children) > 0 {
-				node.Children = childTree.Children
-			}
-
-			mu.Lock()
-			containerNode.Children = append(containerNode.Children, node)
-			mu.Unlock()
-		}()
+	keys := make([]string, len(chain))
+	for i, k := range chain {
+		keys[len(chain)-1-i] = k
 	}
+	keys = append(keys, repeatKey)
+
+	b.nodeNamesMu.Lock()
+	names := make([]string, len(keys))
+	for i, k := range keys {
+		if n, ok := b.nodeNames[k]; ok {
+			names[i] = n
+		} else {
+			names[i] = k
+		}
+	}
+	b.nodeNamesMu.Unlock()
 
-	wg.Wait()
+	b.cyclesMu.Lock()
+	b.cycles = append(b.cycles, CyclePath{Keys: keys, Names: names})
+	b.cyclesMu.Unlock()
+}
 
-	if len(containerNode.Children) == 0 {
-		return nil
+// isEntryPoint checks whether name (optionally narrowed by kind, a
+// symbolKindToString result) is a known entry point. It first consults
+// bridge's EntryPointRegistry, if the concrete bridge exposes one (see
+// entryPointRegistryFor) - this is where teams extend detection with
+// localized names, project-specific prefixes, or regexes via
+// entry_points.yaml or the register_entry_points tool, without
+// recompiling. It then falls back to the hard-coded bslEntryPoints map so
+// behavior is unchanged for bridges that don't expose a registry (e.g.
+// test mocks). Returns whether name matched and the name of the pattern
+// that matched it, for CallGraphResult.EntryPoints' audit trail.
+func isEntryPoint(bridge interfaces.BridgeInterface, name, kind string) (bool, string) {
+	if registry := entryPointRegistryFor(bridge); registry != nil {
+		if p, ok := registry.Match(name, kind); ok {
+			return true, p.Name
+		}
 	}
 
-	return containerNode
+	return builtinIsEntryPoint(name)
 }
 
-// isEntryPoint checks if a method name is a known BSL entry point
-func isEntryPoint(name string) bool {
-	// Check exact match first
+// builtinIsEntryPoint checks name against the hard-coded bslEntryPoints
+// map, by exact match or substring (for cases like "Форма_ПриОткрытии").
+func builtinIsEntryPoint(name string) (bool, string) {
 	if bslEntryPoints[name] {
-		return true
+		return true, "builtin:" + name
 	}
 
-	// Check if name contains known entry point (for cases like "Форма_ПриОткрытии")
 	for ep := range bslEntryPoints {
 		if strings.Contains(name, ep) {
-			return true
+			return true, "builtin:" + ep
 		}
 	}
 
-	return false
+	return false, ""
+}
+
+// entryPointRegistryFor returns the concrete bridge's entry point
+// registry, or nil if bridge isn't backed by *bridgepkg.MCPLSPBridge (e.g.
+// a test mock), mirroring commandRegistryFor's type-assertion pattern in
+// execute_command.go.
+func entryPointRegistryFor(bridge interfaces.BridgeInterface) *bridgepkg.EntryPointRegistry {
+	b, ok := bridge.(*bridgepkg.MCPLSPBridge)
+	if !ok {
+		return nil
+	}
+	return b.EntryPointRegistry()
 }
 
-// addEntryPoint safely adds an entry point to the list
-func (b *callGraphBuilder) addEntryPoint(name string) {
+// addEntryPoint safely adds an entry point hit to the list, deduplicated
+// by name.
+func (b *callGraphBuilder) addEntryPoint(name, pattern string) {
 	b.entryMu.Lock()
 	defer b.entryMu.Unlock()
 
-	// Check for duplicates
 	for _, ep := range b.entryPoints {
-		if ep == name {
+		if ep.Name == name {
 			return
 		}
 	}
-	b.entryPoints = append(b.entryPoints, name)
+	b.entryPoints = append(b.entryPoints, EntryPointHit{Name: name, Pattern: pattern})
 }
 
 // setTruncated safely sets truncation status
@@ -0,0 +1,186 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// callGraphEdge is one edge in the flattened call graph: From calls To,
+// unless IsCycle marks it as the closing edge of a cycle already shown
+// elsewhere in the tree.
+type callGraphEdge struct {
+	From    string
+	To      string
+	IsCycle bool
+}
+
+// Render renders the call graph as JSON (the default), a Graphviz DOT
+// digraph, or a Mermaid flowchart, so agents can paste the result directly
+// into docs/PR descriptions or pipe it to `dot` without writing a
+// client-side transformer.
+func (r *CallGraphResult) Render(format string) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(format)) {
+	case "", "json":
+		data, err := json.MarshalIndent(r, "", "  ")
+		if err != nil {
+			return "", fmt.Errorf("marshal call graph: %w", err)
+		}
+		return string(data), nil
+	case "dot":
+		return r.renderDOT(), nil
+	case "mermaid":
+		return r.renderMermaid(), nil
+	default:
+		return "", fmt.Errorf("unsupported call graph format %q (want json, dot, or mermaid)", format)
+	}
+}
+
+// collectRenderNodes flattens Root plus IncomingTree/OutgoingTree into a
+// deduplicated node list and the edges between them. Only the outermost
+// IncomingTree/OutgoingTree container ("Callers of X"/"Calls from X") is
+// skipped - every level below it is real call-hierarchy nodes, since
+// buildIncomingTree/buildOutgoingTree already flatten their own nested
+// containers onto the node they belong to.
+func (r *CallGraphResult) collectRenderNodes() ([]*CallGraphNode, []callGraphEdge) {
+	var nodes []*CallGraphNode
+	var edges []callGraphEdge
+	seen := make(map[string]bool)
+
+	add := func(n *CallGraphNode) {
+		if n == nil || seen[n.ID] {
+			return
+		}
+		seen[n.ID] = true
+		nodes = append(nodes, n)
+	}
+
+	var walk func(n, parent *CallGraphNode)
+	walk = func(n, parent *CallGraphNode) {
+		if n == nil {
+			return
+		}
+		add(n)
+		if parent != nil {
+			if n.Direction == "up" {
+				edges = append(edges, callGraphEdge{From: n.ID, To: parent.ID, IsCycle: n.IsCycle})
+			} else {
+				edges = append(edges, callGraphEdge{From: parent.ID, To: n.ID, IsCycle: n.IsCycle})
+			}
+		}
+		for _, child := range n.Children {
+			walk(child, n)
+		}
+	}
+
+	add(r.Root)
+	if r.IncomingTree != nil {
+		for _, child := range r.IncomingTree.Children {
+			walk(child, r.Root)
+		}
+	}
+	if r.OutgoingTree != nil {
+		for _, child := range r.OutgoingTree.Children {
+			walk(child, r.Root)
+		}
+	}
+
+	return nodes, edges
+}
+
+// renderDOT renders the graph as a Graphviz digraph. Entry points get a
+// distinct fillcolor and cycle-closing edges are dashed.
+func (r *CallGraphResult) renderDOT() string {
+	nodes, edges := r.collectRenderNodes()
+
+	var sb strings.Builder
+	sb.WriteString("digraph CallGraph {\n")
+	sb.WriteString("  rankdir=LR;\n")
+	sb.WriteString("  node [shape=box, style=filled, fillcolor=white];\n\n")
+
+	for _, n := range nodes {
+		fillcolor := "white"
+		if n.IsEntryPoint {
+			fillcolor = "lightgoldenrod"
+		}
+		label := fmt.Sprintf("%s\\n(%s)", dotEscape(n.Name), dotEscape(n.Kind))
+		fmt.Fprintf(&sb, "  %q [label=\"%s\", fillcolor=%q];\n", n.ID, label, fillcolor)
+	}
+
+	sb.WriteString("\n")
+	for _, e := range edges {
+		attrs := ""
+		if e.IsCycle {
+			attrs = " [style=dashed]"
+		}
+		fmt.Fprintf(&sb, "  %q -> %q%s;\n", e.From, e.To, attrs)
+	}
+
+	sb.WriteString("}\n")
+	return sb.String()
+}
+
+// dotEscape escapes backslashes and double quotes for a Graphviz quoted
+// string or label, without touching literal "\n" line-break sequences we
+// insert ourselves.
+func dotEscape(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	return s
+}
+
+var mermaidIDInvalid = regexp.MustCompile(`[^A-Za-z0-9_]+`)
+
+// sanitizeMermaidID turns a CallGraphNode.ID (a "uri:line:character" or
+// "incoming-uri:line" string) into a valid Mermaid flowchart node id.
+func sanitizeMermaidID(id string) string {
+	sanitized := mermaidIDInvalid.ReplaceAllString(id, "_")
+	if sanitized == "" || !unicode.IsLetter(rune(sanitized[0])) {
+		sanitized = "n_" + sanitized
+	}
+	return sanitized
+}
+
+// renderMermaid renders the graph as a Mermaid flowchart. Entry points are
+// styled with a distinct fill and cycle-closing edges use a dotted arrow.
+func (r *CallGraphResult) renderMermaid() string {
+	nodes, edges := r.collectRenderNodes()
+
+	ids := make(map[string]string, len(nodes))
+	used := make(map[string]int, len(nodes))
+	for _, n := range nodes {
+		base := sanitizeMermaidID(n.ID)
+		id := base
+		if count := used[base]; count > 0 {
+			id = fmt.Sprintf("%s_%d", base, count)
+		}
+		used[base]++
+		ids[n.ID] = id
+	}
+
+	var sb strings.Builder
+	sb.WriteString("flowchart LR\n")
+
+	for _, n := range nodes {
+		label := fmt.Sprintf("%s (%s)", n.Name, n.Kind)
+		fmt.Fprintf(&sb, "  %s[%q]\n", ids[n.ID], label)
+	}
+
+	for _, e := range edges {
+		arrow := "-->"
+		if e.IsCycle {
+			arrow = "-.->"
+		}
+		fmt.Fprintf(&sb, "  %s %s %s\n", ids[e.From], arrow, ids[e.To])
+	}
+
+	for _, n := range nodes {
+		if n.IsEntryPoint {
+			fmt.Fprintf(&sb, "  style %s fill:#ffd966\n", ids[n.ID])
+		}
+	}
+
+	return sb.String()
+}
@@ -0,0 +1,84 @@
+package tools
+
+import (
+	"strings"
+	"testing"
+)
+
+func sampleCallGraphResult() *CallGraphResult {
+	root := &CallGraphNode{ID: "root", Name: "ПриЗаписи", Kind: "method", Direction: "root", IsEntryPoint: true}
+	caller := &CallGraphNode{ID: "caller", Name: "Обработать", Kind: "function", Direction: "up"}
+	callee := &CallGraphNode{ID: "callee", Name: "Проверить", Kind: "function", Direction: "down"}
+	cyclic := &CallGraphNode{ID: "caller", Name: "Обработать", Kind: "function", Direction: "down", IsCycle: true}
+	callee.Children = []*CallGraphNode{cyclic}
+
+	return &CallGraphResult{
+		Root:         root,
+		IncomingTree: &CallGraphNode{ID: "incoming-root", Children: []*CallGraphNode{caller}},
+		OutgoingTree: &CallGraphNode{ID: "outgoing-root", Children: []*CallGraphNode{callee}},
+	}
+}
+
+func TestCallGraphResult_RenderJSON(t *testing.T) {
+	out, err := sampleCallGraphResult().Render("")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(out, `"id": "root"`) {
+		t.Errorf("default render should be JSON, got:\n%s", out)
+	}
+}
+
+func TestCallGraphResult_RenderDOT(t *testing.T) {
+	out, err := sampleCallGraphResult().Render("dot")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.HasPrefix(out, "digraph CallGraph {") {
+		t.Errorf("dot output missing digraph header:\n%s", out)
+	}
+	if !strings.Contains(out, `"caller" -> "root"`) {
+		t.Errorf("expected caller->root incoming edge:\n%s", out)
+	}
+	if !strings.Contains(out, `"root" -> "callee"`) {
+		t.Errorf("expected root->callee outgoing edge:\n%s", out)
+	}
+	if !strings.Contains(out, "lightgoldenrod") {
+		t.Errorf("entry point node should get a distinct fillcolor:\n%s", out)
+	}
+	if !strings.Contains(out, `"callee" -> "caller" [style=dashed]`) {
+		t.Errorf("cycle edge should be dashed:\n%s", out)
+	}
+}
+
+func TestCallGraphResult_RenderMermaid(t *testing.T) {
+	out, err := sampleCallGraphResult().Render("mermaid")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.HasPrefix(out, "flowchart LR\n") {
+		t.Errorf("mermaid output missing flowchart header:\n%s", out)
+	}
+	if !strings.Contains(out, "caller --> root") {
+		t.Errorf("expected caller --> root incoming edge:\n%s", out)
+	}
+	if !strings.Contains(out, "callee -.-> caller") {
+		t.Errorf("cycle edge should use a dotted arrow:\n%s", out)
+	}
+	if !strings.Contains(out, "style root fill:") {
+		t.Errorf("entry point node should get a style line:\n%s", out)
+	}
+}
+
+func TestCallGraphResult_RenderUnknownFormat(t *testing.T) {
+	if _, err := sampleCallGraphResult().Render("svg"); err == nil {
+		t.Fatal("expected an error for an unsupported format, got nil")
+	}
+}
+
+func TestSanitizeMermaidID_CollapsesInvalidCharacters(t *testing.T) {
+	got := sanitizeMermaidID("file:///a/b.bsl:10:4")
+	if strings.ContainsAny(got, ":/.") {
+		t.Errorf("sanitizeMermaidID(%q) = %q, still contains invalid characters", "file:///a/b.bsl:10:4", got)
+	}
+}
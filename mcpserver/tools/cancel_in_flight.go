@@ -0,0 +1,46 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+
+	"rockerboo/mcp-lsp-bridge/interfaces"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// CancelInFlightTool cancels a long-running LSP request started by a
+// progress-aware tool (e.g. workspace_diagnostics), by the request id that
+// tool reported in its initial progress notification - itself the
+// server-reported workDoneToken, so this really does cancel "by progress
+// token" as opposed to inventing a separate tracking id.
+func CancelInFlightTool(bridge interfaces.BridgeInterface) (mcp.Tool, server.ToolHandlerFunc) {
+	return mcp.NewTool("cancel_in_flight",
+			mcp.WithDescription(`Cancel a long-running LSP request started by a progress-aware tool (e.g. workspace_diagnostics), sending $/cancelRequest to the language server.
+
+USAGE: cancel_in_flight request_id="<id reported in that tool's initial progress notification>"
+PARAMETERS: request_id (required)
+OUTPUT: whether a request was found under that id and cancellation was sent`),
+			mcp.WithDestructiveHintAnnotation(true),
+			mcp.WithString("request_id", mcp.Description("The in-flight request id reported when the original tool call started"), mcp.Required()),
+		), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			id, err := request.RequireString("request_id")
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			cancelled, found := cancelInFlight(id)
+			if !found {
+				return mcp.NewToolResultText(fmt.Sprintf("No in-flight request registered under %q (it may have already finished).", id)), nil
+			}
+			if !cancelled {
+				return mcp.NewToolResultText(fmt.Sprintf("Request %q was found but could not be cancelled (it may have just finished).", id)), nil
+			}
+			return mcp.NewToolResultText(fmt.Sprintf("Sent cancellation for request %q.", id)), nil
+		}
+}
+
+func RegisterCancelInFlightTool(mcpServer ToolServer, bridge interfaces.BridgeInterface) {
+	mcpServer.AddTool(CancelInFlightTool(bridge))
+}
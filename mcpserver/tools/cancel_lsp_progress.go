@@ -0,0 +1,76 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+
+	bridgepkg "rockerboo/mcp-lsp-bridge/bridge"
+	"rockerboo/mcp-lsp-bridge/interfaces"
+	"rockerboo/mcp-lsp-bridge/types"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// workDoneProgressCanceler is the subset of a language client needed to
+// forward window/workDoneProgress/cancel for a server-reported progress
+// token - see lsp.LanguageClient.CancelWorkDoneProgress and
+// LSPStatus.Activity, which is where a token worth cancelling is actually
+// surfaced from.
+type workDoneProgressCanceler interface {
+	CancelWorkDoneProgress(ctx context.Context, tokenKey string) error
+}
+
+// CancelLSPProgressTool sends window/workDoneProgress/cancel to a specific
+// language server for a specific progress token, asking the server itself
+// to stop work it reported via $/progress (e.g. a long indexing pass) -
+// unlike cancel_in_flight, which only aborts a request this bridge issued.
+func CancelLSPProgressTool(bridge interfaces.BridgeInterface) (mcp.Tool, server.ToolHandlerFunc) {
+	return mcp.NewTool("cancel_lsp_progress",
+			mcp.WithDescription(`Ask a language server to stop a long-running operation it reported via $/progress (e.g. indexing), by sending window/workDoneProgress/cancel.
+
+USAGE: cancel_lsp_progress server="bsl" token="<token from lsp_status's activity[].token>"
+PARAMETERS: server (required), token (required)
+OUTPUT: whether the cancel notification was sent
+
+NOTE: unlike cancel_in_flight, this targets progress the server started on its own, not a request this bridge issued - the server decides whether and how quickly to actually stop.`),
+			mcp.WithDestructiveHintAnnotation(true),
+			mcp.WithString("server", mcp.Description("Language server name, as shown in lsp_status's clients[].server"), mcp.Required()),
+			mcp.WithString("token", mcp.Description("Progress token, as shown in lsp_status's activity[].token"), mcp.Required()),
+		), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			serverName, err := request.RequireString("server")
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			token, err := request.RequireString("token")
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			b, ok := bridge.(*bridgepkg.MCPLSPBridge)
+			if !ok {
+				return mcp.NewToolResultError("bridge does not support progress cancellation"), nil
+			}
+
+			clients := b.ListConnectedClients()
+			client, ok := clients[types.LanguageServer(serverName)]
+			if !ok {
+				return mcp.NewToolResultError(fmt.Sprintf("no connected language server named %q", serverName)), nil
+			}
+
+			canceler, ok := client.(workDoneProgressCanceler)
+			if !ok {
+				return mcp.NewToolResultError(fmt.Sprintf("language server %q does not support progress cancellation", serverName)), nil
+			}
+
+			if err := canceler.CancelWorkDoneProgress(ctx, token); err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("failed to send workDoneProgress/cancel: %v", err)), nil
+			}
+
+			return mcp.NewToolResultText(fmt.Sprintf("Sent workDoneProgress/cancel for token %q to %q.", token, serverName)), nil
+		}
+}
+
+func RegisterCancelLSPProgressTool(mcpServer ToolServer, bridge interfaces.BridgeInterface) {
+	mcpServer.AddTool(CancelLSPProgressTool(bridge))
+}
@@ -0,0 +1,33 @@
+package tools
+
+import "context"
+
+// ClientIdentity is the resolved caller of an MCP tool call - attached to
+// a tool handler's context by an HTTP transport (see
+// mcpserver.HTTPContextFunc) so status surfaces like LSPActivity.Origin
+// and LSPClientStatus.Origin can report which upstream tenant is
+// responsible for in-flight work, for multi-tenant deployments behind a
+// reverse proxy.
+//
+// Workspace is currently always the bridge's single configured workspace
+// root: this tree has no per-tenant workspace routing, so it is not
+// (yet) derived per request.
+type ClientIdentity struct {
+	IP        string
+	Workspace string
+}
+
+type clientIdentityCtxKey struct{}
+
+// WithClientIdentity attaches id to ctx for downstream tool handlers to
+// read via ClientIdentityFromContext.
+func WithClientIdentity(ctx context.Context, id ClientIdentity) context.Context {
+	return context.WithValue(ctx, clientIdentityCtxKey{}, id)
+}
+
+// ClientIdentityFromContext retrieves the ClientIdentity attached by
+// WithClientIdentity, if any.
+func ClientIdentityFromContext(ctx context.Context) (ClientIdentity, bool) {
+	id, ok := ctx.Value(clientIdentityCtxKey{}).(ClientIdentity)
+	return id, ok
+}
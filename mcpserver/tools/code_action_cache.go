@@ -0,0 +1,94 @@
+package tools
+
+import (
+	"fmt"
+	"hash/fnv"
+	"sync"
+
+	"rockerboo/mcp-lsp-bridge/types"
+
+	"github.com/myleshyson/lsprotocol-go/protocol"
+)
+
+// cachedCodeAction is what a code_actions action_id resolves back to for
+// apply_code_action: enough to apply the WorkspaceEdit it previewed
+// without re-asking the language server for it (code actions aren't
+// guaranteed idempotent, and some servers only resolve the edit lazily via
+// codeAction/resolve).
+type cachedCodeAction struct {
+	Language types.Language
+	URI      string
+	Title    string
+	Kind     protocol.CodeActionKind
+	Edit     protocol.WorkspaceEdit
+}
+
+// maxCachedCodeActions bounds codeActionCache the same way diagnosticCache
+// bounds itself: a long-running session that keeps previewing code actions
+// without ever applying any of them shouldn't grow the cache without limit.
+const maxCachedCodeActions = 512
+
+// codeActionCache is the process-wide store bridging a code_actions
+// preview (which mints action_id) to a later apply_code_action call (which
+// looks it up); entries are FIFO-evicted once the cache is full.
+type codeActionCache struct {
+	mu      sync.Mutex
+	entries map[string]cachedCodeAction
+	order   []string
+}
+
+func newCodeActionCache() *codeActionCache {
+	return &codeActionCache{entries: make(map[string]cachedCodeAction)}
+}
+
+// globalCodeActionCache is shared by handleCodeActions (project_analysis.go)
+// and ApplyCodeActionTool; action_id only means anything across those two
+// tool calls if both sides agree on one cache.
+var globalCodeActionCache = newCodeActionCache()
+
+func (c *codeActionCache) store(id string, action cachedCodeAction) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, exists := c.entries[id]; !exists {
+		if len(c.order) >= maxCachedCodeActions {
+			oldest := c.order[0]
+			c.order = c.order[1:]
+			delete(c.entries, oldest)
+		}
+		c.order = append(c.order, id)
+	}
+	c.entries[id] = action
+}
+
+// take is a get that also removes the entry: applying a code action is a
+// one-shot operation, since re-applying the same WorkspaceEdit against a
+// file that's since changed underneath it isn't what the caller wants.
+func (c *codeActionCache) take(id string) (cachedCodeAction, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	action, ok := c.entries[id]
+	if !ok {
+		return cachedCodeAction{}, false
+	}
+
+	delete(c.entries, id)
+	for i, key := range c.order {
+		if key == id {
+			c.order = append(c.order[:i], c.order[i+1:]...)
+			break
+		}
+	}
+
+	return action, true
+}
+
+// codeActionID derives a stable action_id for a code action returned for
+// uri/rng, so re-running the same code_actions query mints the same id
+// instead of forcing the caller to re-fetch it before every apply.
+func codeActionID(uri string, rng protocol.Range, title string, kind protocol.CodeActionKind) string {
+	h := fnv.New64a()
+	fmt.Fprintf(h, "%s|%d:%d-%d:%d|%s|%s", uri, rng.Start.Line, rng.Start.Character, rng.End.Line, rng.End.Character, title, kind)
+	return fmt.Sprintf("ca_%016x", h.Sum64())
+}
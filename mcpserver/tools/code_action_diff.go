@@ -0,0 +1,63 @@
+package tools
+
+import (
+	"fmt"
+	"strings"
+
+	"rockerboo/mcp-lsp-bridge/lsp"
+
+	"github.com/myleshyson/lsprotocol-go/protocol"
+	"github.com/sergi/go-diff/diffmatchpatch"
+)
+
+// maxCodeActionDiffLines bounds how much of a code action's diff preview
+// code_actions renders, so fill_struct/organize_imports touching a huge
+// file doesn't blow out the response the way an unbounded diff would.
+const maxCodeActionDiffLines = 40
+
+// codeActionDiffPreview applies edits to current (the on-disk baseline) and
+// renders a compact unified-style diff between the two, line by line via
+// go-diff's line-mode diff so the preview reads like a normal patch rather
+// than a character-level diff. Returns the rendered preview and the new
+// text (the latter is what apply_code_action eventually writes to disk).
+func codeActionDiffPreview(current string, edits []protocol.TextEdit) (preview string, newText string) {
+	newText = lsp.ApplyTextEdits(current, edits)
+
+	dmp := diffmatchpatch.New()
+	a, b, lines := dmp.DiffLinesToChars(current, newText)
+	diffs := dmp.DiffCharsToLines(dmp.DiffMain(a, b, false), lines)
+
+	var out strings.Builder
+	shown := 0
+	truncated := false
+
+	for _, d := range diffs {
+		if truncated {
+			break
+		}
+		for _, line := range strings.SplitAfter(d.Text, "\n") {
+			if line == "" {
+				continue
+			}
+			if shown >= maxCodeActionDiffLines {
+				truncated = true
+				break
+			}
+			switch d.Type {
+			case diffmatchpatch.DiffInsert:
+				fmt.Fprintf(&out, "+%s", line)
+			case diffmatchpatch.DiffDelete:
+				fmt.Fprintf(&out, "-%s", line)
+			default:
+				fmt.Fprintf(&out, " %s", line)
+			}
+			shown++
+		}
+	}
+
+	if truncated {
+		out.WriteString("... (diff truncated)\n")
+	}
+
+	return out.String(), newText
+}
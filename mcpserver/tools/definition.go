@@ -3,18 +3,25 @@ package tools
 import (
 	"context"
 	"fmt"
-	"path/filepath"
 	"strings"
 
 	"rockerboo/mcp-lsp-bridge/interfaces"
 	"rockerboo/mcp-lsp-bridge/logger"
 	"rockerboo/mcp-lsp-bridge/types"
+	"rockerboo/mcp-lsp-bridge/utils"
 
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
 	"github.com/myleshyson/lsprotocol-go/protocol"
 )
 
+// definitionCapable is the subset of lsp.LanguageClient's dynamic-capability
+// API that types.LanguageClientInterface doesn't declare, reached the same
+// way workspaceDiagnosticStreamer reaches the pull-diagnostic API.
+type definitionCapable interface {
+	SupportsDefinition() bool
+}
+
 // DefinitionTool exposes LSP textDocument/definition for a specific (uri,line,character).
 // This is lower-level than project_analysis(definitions) and is intended for fast, coordinate-based navigation.
 func DefinitionTool(bridge interfaces.BridgeInterface) (mcp.Tool, server.ToolHandlerFunc) {
@@ -77,6 +84,12 @@ OUTPUT: One or more target locations (file + range) suitable for get_range_conte
 				lang = *inferred
 			}
 
+			if clients, clientErr := bridge.GetMultiLanguageClients([]string{string(lang)}); clientErr == nil && clients[lang] != nil {
+				if capable, ok := clients[lang].(definitionCapable); ok && !capable.SupportsDefinition() {
+					return mcp.NewToolResultError(fmt.Sprintf("%s language server does not support textDocument/definition", lang)), nil
+				}
+			}
+
 			// Normalize URI (important for Docker/session mode path mapping)
 			normalizedURI := bridge.NormalizeURIForLSP(uri)
 
@@ -86,7 +99,12 @@ OUTPUT: One or more target locations (file + range) suitable for get_range_conte
 				return mcp.NewToolResultError(fmt.Sprintf("definition request failed: %v", err)), nil
 			}
 
-			return mcp.NewToolResultText(formatDefinitions(defs)), nil
+			var workspaceRoot string
+			if dirs := bridge.AllowedDirectories(); len(dirs) > 0 {
+				workspaceRoot = dirs[0]
+			}
+
+			return mcp.NewToolResultText(formatDefinitions(defs, workspaceRoot)), nil
 		}
 }
 
@@ -94,7 +112,7 @@ func RegisterDefinitionTool(mcpServer ToolServer, bridge interfaces.BridgeInterf
 	mcpServer.AddTool(DefinitionTool(bridge))
 }
 
-func formatDefinitions(defs []protocol.Or2[protocol.LocationLink, protocol.Location]) string {
+func formatDefinitions(defs []protocol.Or2[protocol.LocationLink, protocol.Location], workspaceRoot string) string {
 	if len(defs) == 0 {
 		return "DEFINITION:\nNo definitions found."
 	}
@@ -113,18 +131,16 @@ func formatDefinitions(defs []protocol.Or2[protocol.LocationLink, protocol.Locat
 		switch v := def.Value.(type) {
 		case protocol.Location:
 			u := string(v.Uri)
-			filename := filepath.Base(strings.TrimPrefix(u, "file://"))
 			fmt.Fprintf(&b, "%d. %s:%d:%d-%d:%d\n", i+1,
-				filename,
+				utils.DisplayPath(u, workspaceRoot),
 				v.Range.Start.Line, v.Range.Start.Character,
 				v.Range.End.Line, v.Range.End.Character,
 			)
 			fmt.Fprintf(&b, "   URI: %s\n", u)
 		case protocol.LocationLink:
 			u := string(v.TargetUri)
-			filename := filepath.Base(strings.TrimPrefix(u, "file://"))
 			fmt.Fprintf(&b, "%d. %s:%d:%d-%d:%d\n", i+1,
-				filename,
+				utils.DisplayPath(u, workspaceRoot),
 				v.TargetRange.Start.Line, v.TargetRange.Start.Character,
 				v.TargetRange.End.Line, v.TargetRange.End.Character,
 			)
@@ -46,6 +46,8 @@ func DidChangeWatchedFilesTool(bridge interfaces.BridgeInterface) (mcp.Tool, ser
 				return mcp.NewToolResultError(fmt.Sprintf("didChangeWatchedFiles failed: %v", err)), nil
 			}
 
+			invalidateCallGraphCache(bridge, changes)
+
 			return mcp.NewToolResultText("ok"), nil
 		}
 }
@@ -0,0 +1,463 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"rockerboo/mcp-lsp-bridge/interfaces"
+	"rockerboo/mcp-lsp-bridge/logger"
+	"rockerboo/mcp-lsp-bridge/types"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// defaultCloneWindowSize is the sliding-window length (in tokens) clone
+// detection uses when the caller doesn't override window_size.
+const defaultCloneWindowSize = 50
+
+// defaultFindDuplicatesMaxGroups bounds how many clone groups
+// bsl_find_duplicates reports, so a workspace with one pervasively copy-
+// pasted pattern doesn't return an unbounded result.
+const defaultFindDuplicatesMaxGroups = 200
+
+// bslCloneTokenPattern lexes a BSL source into the ordered token stream
+// clone detection slides its window over: a string literal (see
+// bslStringLiteralPattern), then the two-char symbolic operators (checked
+// before their one-char prefixes so "<>" isn't split into "<" and ">"),
+// then identifiers/keywords/numbers, then any other single non-space
+// character (punctuation).
+var bslCloneTokenPattern = regexp.MustCompile(`"(?:[^"]|"")*"|<>|<=|>=|[\p{L}\p{N}_]+|[^\s]`)
+
+// bslNumberLiteralPattern matches a bare BSL numeric literal.
+var bslNumberLiteralPattern = regexp.MustCompile(`^[0-9]+(?:\.[0-9]+)?$`)
+
+// bslToken is one lexical token produced by tokenizeBSLSource. Raw is the
+// original text, used for Type-1 exact matching and similarity scoring;
+// Normalized is Raw with identifiers and literals folded to placeholders
+// (see bslNormalizeToken), used for Type-2 matching. Line is 0-based.
+type bslToken struct {
+	Raw        string
+	Normalized string
+	Line       uint32
+}
+
+// bslNormalizeToken maps tok to its Type-2 clone-detection placeholder:
+// string and numeric literals become "LIT", identifiers that aren't one of
+// bslOperatorKeywords/bslDecisionKeywords become "ID". Keywords, operators
+// and punctuation pass through unchanged, since those give a clone its
+// structural shape - renaming a variable or changing a literal shouldn't
+// break a match, but rewriting Если into Пока should.
+func bslNormalizeToken(tok string) string {
+	if strings.HasPrefix(tok, `"`) || bslNumberLiteralPattern.MatchString(tok) {
+		return "LIT"
+	}
+	if bslWordPattern.MatchString(tok) {
+		lower := strings.ToLower(tok)
+		if !bslOperatorKeywords[lower] && !bslDecisionKeywords[lower] {
+			return "ID"
+		}
+	}
+	return tok
+}
+
+// tokenizeBSLSource lexes source into an ordered token stream (see
+// bslCloneTokenPattern), tracking each token's 0-based source line so clone
+// groups can be reported as file/line ranges.
+func tokenizeBSLSource(source string) []bslToken {
+	lines := strings.Split(source, "\n")
+	lineStarts := make([]int, len(lines))
+	offset := 0
+	for i, l := range lines {
+		lineStarts[i] = offset
+		offset += len(l) + 1
+	}
+	lineForOffset := func(pos int) uint32 {
+		idx := sort.Search(len(lineStarts), func(i int) bool { return lineStarts[i] > pos }) - 1
+		if idx < 0 {
+			idx = 0
+		}
+		return uint32(idx)
+	}
+
+	matches := bslCloneTokenPattern.FindAllStringIndex(source, -1)
+	tokens := make([]bslToken, 0, len(matches))
+	for _, loc := range matches {
+		raw := source[loc[0]:loc[1]]
+		tokens = append(tokens, bslToken{Raw: raw, Normalized: bslNormalizeToken(raw), Line: lineForOffset(loc[0])})
+	}
+	return tokens
+}
+
+// cloneDocument is one file's token stream, named for clone-group reporting.
+type cloneDocument struct {
+	module string
+	tokens []bslToken
+}
+
+// cloneHashBase and cloneHashMod are the Rabin-Karp rolling hash's base and
+// modulus (a Mersenne prime). Both are kept small enough that every
+// intermediate product in rollingWindowHashes' update step - at most
+// (cloneHashMod-1)*(cloneHashMod-1) - still fits in a uint64 without
+// wrapping, so plain "%" modular arithmetic is safe here; collisions this
+// causes are expected and harmless, since findCloneGroups always verifies a
+// same-hash bucket with an exact token comparison before calling it a clone.
+const (
+	cloneHashBase = uint64(131)
+	cloneHashMod  = uint64(1)<<31 - 1
+)
+
+// tokenHash hashes a token's normalized text down to a value below
+// cloneHashMod so rollingWindowHashes can combine per-token hashes
+// polynomially instead of rehashing the token text on every window shift.
+func tokenHash(s string) uint64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(s))
+	return h.Sum64() % cloneHashMod
+}
+
+// rollingWindowHashes returns one Rabin-Karp hash per size-n sliding window
+// of tokens' normalized text, computed incrementally so each window after
+// the first costs O(1) instead of re-hashing all n tokens. These hashes are
+// only a pre-filter: findCloneGroups verifies every same-hash bucket with
+// an exact token comparison before calling it a clone.
+func rollingWindowHashes(tokens []bslToken, n int) []uint64 {
+	if n <= 0 || len(tokens) < n {
+		return nil
+	}
+
+	th := make([]uint64, len(tokens))
+	for i, t := range tokens {
+		th[i] = tokenHash(t.Normalized)
+	}
+
+	pow := uint64(1)
+	for i := 0; i < n-1; i++ {
+		pow = (pow * cloneHashBase) % cloneHashMod
+	}
+
+	hashes := make([]uint64, len(tokens)-n+1)
+	var h uint64
+	for i := 0; i < n; i++ {
+		h = (h*cloneHashBase + th[i]) % cloneHashMod
+	}
+	hashes[0] = h
+	for i := 1; i < len(hashes); i++ {
+		h = (h + cloneHashMod - (th[i-1]*pow)%cloneHashMod) % cloneHashMod
+		h = (h*cloneHashBase + th[i+n-1]) % cloneHashMod
+		hashes[i] = h
+	}
+	return hashes
+}
+
+// cloneWindowRef points at one size-n window: the document it came from and
+// the token index it starts at.
+type cloneWindowRef struct {
+	doc   int
+	start int
+}
+
+// CloneOccurrence is one location a CloneGroup's shared token window was
+// found at.
+type CloneOccurrence struct {
+	Module    string `json:"module"`
+	StartLine uint32 `json:"start_line"`
+	EndLine   uint32 `json:"end_line"`
+}
+
+// CloneGroup is a set of token windows findCloneGroups verified are
+// equivalent (Type-2: identical once identifiers/literals are normalized).
+// Similarity is the fraction of Occurrences whose raw token text exactly
+// matches the first occurrence's - 1.0 means every occurrence is a Type-1
+// (textually identical) clone, lower means some are Type-2 only.
+type CloneGroup struct {
+	TokenLength int               `json:"token_length"`
+	Similarity  float64           `json:"similarity"`
+	Occurrences []CloneOccurrence `json:"occurrences"`
+}
+
+// tokenSeqEqual compares two equal-length token slices by field f.
+func tokenSeqEqual(a, b []bslToken, f func(bslToken) string) bool {
+	for i := range a {
+		if f(a[i]) != f(b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// findCloneGroups runs windowSize-token Rabin-Karp clone detection over
+// docs: every window's hash (rollingWindowHashes) is bucketed, each bucket
+// with more than one window is split into exact-match clusters (verifying
+// away hash collisions via tokenSeqEqual on Normalized text), and within a
+// cluster, windows that overlap an already-kept window in the same document
+// are dropped. Consecutive windows of one longer duplicated block hash to
+// different buckets and are not merged, so a duplicated block of length L
+// is reported as up to L-windowSize+1 overlapping groups. Returns groups
+// sorted by descending occurrence count, and whether maxGroups truncated
+// the result.
+func findCloneGroups(docs []cloneDocument, windowSize, maxGroups int) ([]CloneGroup, bool) {
+	buckets := make(map[uint64][]cloneWindowRef)
+	for di, doc := range docs {
+		for wi, h := range rollingWindowHashes(doc.tokens, windowSize) {
+			buckets[h] = append(buckets[h], cloneWindowRef{doc: di, start: wi})
+		}
+	}
+
+	windowTokens := func(ref cloneWindowRef) []bslToken {
+		return docs[ref.doc].tokens[ref.start : ref.start+windowSize]
+	}
+
+	var groups []CloneGroup
+	for _, refs := range buckets {
+		if len(refs) < 2 {
+			continue
+		}
+
+		var clusters [][]cloneWindowRef
+		for _, ref := range refs {
+			placed := false
+			for ci, cluster := range clusters {
+				if tokenSeqEqual(windowTokens(ref), windowTokens(cluster[0]), func(t bslToken) string { return t.Normalized }) {
+					clusters[ci] = append(cluster, ref)
+					placed = true
+					break
+				}
+			}
+			if !placed {
+				clusters = append(clusters, []cloneWindowRef{ref})
+			}
+		}
+
+		for _, cluster := range clusters {
+			sort.Slice(cluster, func(i, j int) bool {
+				if cluster[i].doc != cluster[j].doc {
+					return cluster[i].doc < cluster[j].doc
+				}
+				return cluster[i].start < cluster[j].start
+			})
+
+			var kept []cloneWindowRef
+			lastEnd := make(map[int]int)
+			for _, ref := range cluster {
+				if end, ok := lastEnd[ref.doc]; ok && ref.start < end {
+					continue
+				}
+				kept = append(kept, ref)
+				lastEnd[ref.doc] = ref.start + windowSize
+			}
+			if len(kept) < 2 {
+				continue
+			}
+
+			first := windowTokens(kept[0])
+			identical := 0
+			group := CloneGroup{TokenLength: windowSize}
+			for _, ref := range kept {
+				toks := windowTokens(ref)
+				group.Occurrences = append(group.Occurrences, CloneOccurrence{
+					Module:    docs[ref.doc].module,
+					StartLine: toks[0].Line + 1,
+					EndLine:   toks[len(toks)-1].Line + 1,
+				})
+				if tokenSeqEqual(toks, first, func(t bslToken) string { return t.Raw }) {
+					identical++
+				}
+			}
+			group.Similarity = float64(identical) / float64(len(kept))
+			groups = append(groups, group)
+		}
+	}
+
+	sort.Slice(groups, func(i, j int) bool {
+		if len(groups[i].Occurrences) != len(groups[j].Occurrences) {
+			return len(groups[i].Occurrences) > len(groups[j].Occurrences)
+		}
+		return groups[i].Occurrences[0].Module < groups[j].Occurrences[0].Module
+	})
+
+	truncated := false
+	if maxGroups > 0 && len(groups) > maxGroups {
+		groups = groups[:maxGroups]
+		truncated = true
+	}
+	return groups, truncated
+}
+
+// duplicationRatio computes a single file's Metrics.DuplicationRatio:
+// duplicated_tokens / total_tokens, where duplicated_tokens is the number of
+// token positions covered by any windowSize-token block that recurs
+// elsewhere in the same token stream (verified by exact Normalized-token
+// comparison, the same way findCloneGroups verifies cross-file matches).
+// This only catches intra-file duplication - a block copy-pasted within one
+// file - rather than reaching across the workspace the way bsl_find_duplicates
+// does, so calculateFileComplexityFromSymbols can keep deriving a file's
+// metrics from that file's own source alone.
+func duplicationRatio(tokens []bslToken, windowSize int) float64 {
+	if len(tokens) == 0 || windowSize <= 0 || len(tokens) < windowSize {
+		return 0
+	}
+
+	buckets := make(map[uint64][]int)
+	for start, h := range rollingWindowHashes(tokens, windowSize) {
+		buckets[h] = append(buckets[h], start)
+	}
+
+	covered := make([]bool, len(tokens))
+	for _, starts := range buckets {
+		if len(starts) < 2 {
+			continue
+		}
+		for i, a := range starts {
+			for _, b := range starts[i+1:] {
+				if tokenSeqEqual(tokens[a:a+windowSize], tokens[b:b+windowSize], func(t bslToken) string { return t.Normalized }) {
+					for k := a; k < a+windowSize; k++ {
+						covered[k] = true
+					}
+					for k := b; k < b+windowSize; k++ {
+						covered[k] = true
+					}
+				}
+			}
+		}
+	}
+
+	duplicated := 0
+	for _, c := range covered {
+		if c {
+			duplicated++
+		}
+	}
+	return float64(duplicated) / float64(len(tokens))
+}
+
+// DuplicateReport is buildDuplicateReport's result.
+type DuplicateReport struct {
+	WindowSize     int          `json:"window_size"`
+	ModulesScanned int          `json:"modules_scanned"`
+	Groups         []CloneGroup `json:"clone_groups"`
+	Truncated      bool         `json:"truncated"`
+	TruncateReason string       `json:"truncate_reason,omitempty"`
+}
+
+// buildDuplicateReport is bsl_find_duplicates' core: it walks the first
+// allowed directory for language's modules (see enumerateModuleFiles, the
+// same workspace walk bsl_module_coupling uses), tokenizes every module
+// (tokenizeBSLSource) and runs findCloneGroups across all of them at once,
+// so a clone group can span files as well as recur within one.
+func buildDuplicateReport(bridge interfaces.BridgeInterface, language types.Language, windowSize, maxGroups int) (DuplicateReport, error) {
+	if windowSize <= 0 {
+		windowSize = defaultCloneWindowSize
+	}
+
+	dirs := bridge.AllowedDirectories()
+	if len(dirs) == 0 {
+		return DuplicateReport{}, errors.New("bsl_find_duplicates: no workspace directories configured")
+	}
+	workspaceDir := dirs[0]
+
+	paths, truncatedScan, err := enumerateModuleFiles(workspaceDir, language)
+	if err != nil {
+		return DuplicateReport{}, fmt.Errorf("bsl_find_duplicates: workspace walk failed: %w", err)
+	}
+
+	var docs []cloneDocument
+	for _, path := range paths {
+		source, err := os.ReadFile(path) // #nosec G304 -- path came from walking the configured workspace directory
+		if err != nil {
+			logger.Warn(fmt.Sprintf("bsl_find_duplicates: failed to read %s: %v", path, err))
+			continue
+		}
+		docs = append(docs, cloneDocument{
+			module: moduleNameForPath(workspaceDir, path),
+			tokens: tokenizeBSLSource(string(source)),
+		})
+	}
+
+	groups, truncatedGroups := findCloneGroups(docs, windowSize, maxGroups)
+
+	report := DuplicateReport{WindowSize: windowSize, ModulesScanned: len(docs), Groups: groups}
+	switch {
+	case truncatedScan:
+		report.Truncated = true
+		report.TruncateReason = fmt.Sprintf("module cap reached (%d)", moduleCouplingMaxModules)
+	case truncatedGroups:
+		report.Truncated = true
+		report.TruncateReason = fmt.Sprintf("clone group cap reached (%d)", maxGroups)
+	}
+
+	return report, nil
+}
+
+// formatDuplicateReportText renders report for output_format="text".
+func formatDuplicateReportText(report DuplicateReport) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "DUPLICATE_CODE|modules=%d|window=%d|groups=%d|truncated=%t\n",
+		report.ModulesScanned, report.WindowSize, len(report.Groups), report.Truncated)
+	if report.Truncated {
+		fmt.Fprintf(&sb, "TRUNCATE_REASON|%s\n", report.TruncateReason)
+	}
+
+	sb.WriteString("\nCLONE GROUPS:\n")
+	for i, g := range report.Groups {
+		fmt.Fprintf(&sb, "  %d. tokens=%d similarity=%.2f occurrences=%d\n", i+1, g.TokenLength, g.Similarity, len(g.Occurrences))
+		for _, occ := range g.Occurrences {
+			fmt.Fprintf(&sb, "     - %s:%d-%d\n", occ.Module, occ.StartLine, occ.EndLine)
+		}
+	}
+
+	return sb.String()
+}
+
+// DuplicateDetectionTool registers "bsl_find_duplicates": Type-1/Type-2
+// clone detection across a workspace's BSL modules. Sources are tokenized
+// (tokenizeBSLSource) with identifiers and literals normalized to ID/LIT
+// placeholders for Type-2 matching, a window_size-token Rabin-Karp rolling
+// hash (rollingWindowHashes) finds candidate matches, and exact token
+// comparison verifies each candidate before it's reported as a clone group
+// (see findCloneGroups).
+func DuplicateDetectionTool(bridge interfaces.BridgeInterface) (mcp.Tool, server.ToolHandlerFunc) {
+	return mcp.NewTool("bsl_find_duplicates",
+			mcp.WithDescription(`Detects Type-1 (exact) and Type-2 (renamed identifiers/literals) code clones across the workspace's BSL modules. Tokenizes every module, slides a window_size-token window with a Rabin-Karp rolling hash to find candidates, then verifies each with exact token comparison to eliminate hash collisions. Reports clone groups with every occurrence's module/line range, the window's token length, and a similarity score (1.0 = every occurrence is textually identical; lower means some only match after Type-2 normalization).`),
+			mcp.WithDestructiveHintAnnotation(false),
+			mcp.WithString("language", mcp.Description("Language whose modules to scan (default: \"bsl\").")),
+			mcp.WithNumber("window_size", mcp.Description("Clone-detection window length in tokens (default: 50)."), mcp.Min(4), mcp.DefaultNumber(defaultCloneWindowSize)),
+			mcp.WithNumber("max_groups", mcp.Description("Maximum clone groups to report (default: 200)."), mcp.Min(1), mcp.DefaultNumber(defaultFindDuplicatesMaxGroups)),
+			mcp.WithString("output_format", mcp.Description("\"json\" (default) or \"text\".")),
+		), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			language := types.Language(strings.TrimSpace(request.GetString("language", "")))
+			if language == "" {
+				language = types.Language("bsl")
+			}
+			windowSize := request.GetInt("window_size", defaultCloneWindowSize)
+			maxGroups := request.GetInt("max_groups", defaultFindDuplicatesMaxGroups)
+			outputFormat := strings.ToLower(strings.TrimSpace(request.GetString("output_format", "")))
+
+			report, err := buildDuplicateReport(bridge, language, windowSize, maxGroups)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			if outputFormat == "text" {
+				return mcp.NewToolResultText(formatDuplicateReportText(report)), nil
+			}
+
+			payload, err := json.MarshalIndent(report, "", "  ")
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("bsl_find_duplicates: failed to marshal result: %v", err)), nil
+			}
+			return mcp.NewToolResultText(string(payload)), nil
+		}
+}
+
+// RegisterFindDuplicatesTool registers the bsl_find_duplicates tool.
+func RegisterFindDuplicatesTool(mcpServer ToolServer, bridge interfaces.BridgeInterface) {
+	mcpServer.AddTool(DuplicateDetectionTool(bridge))
+}
@@ -0,0 +1,58 @@
+package tools
+
+import "testing"
+
+func TestDuplicationRatio_DetectsRepeatedBlock(t *testing.T) {
+	block := `Если Сумма > 0 Тогда
+	Результат = Сумма * Ставка;
+Иначе
+	Результат = 0;
+КонецЕсли;`
+
+	// Pasting block twice should flag (most of) both copies as duplicated;
+	// a single copy has nothing to match against, so its ratio is 0.
+	dup := tokenizeBSLSource(block + "\n" + block)
+	if got := duplicationRatio(dup, 10); got < 0.9 {
+		t.Errorf("duplicationRatio(pasted-twice) = %.2f, want >= 0.9", got)
+	}
+
+	single := tokenizeBSLSource(block)
+	if got := duplicationRatio(single, 10); got != 0 {
+		t.Errorf("duplicationRatio(single copy) = %.2f, want 0", got)
+	}
+}
+
+func TestFindCloneGroups_Type2MatchAcrossFiles(t *testing.T) {
+	// Same shape, different identifiers/literals - a Type-2 clone, not
+	// Type-1, so Similarity should land below 1.0 but the group must still
+	// be found.
+	docA := cloneDocument{module: "a.bsl", tokens: tokenizeBSLSource(`
+		Если Сумма > 0 Тогда
+			Результат = Сумма * Ставка;
+		Иначе
+			Результат = 0;
+		КонецЕсли;`)}
+	docB := cloneDocument{module: "b.bsl", tokens: tokenizeBSLSource(`
+		Если Доход > 0 Тогда
+			Результат = Доход * Процент;
+		Иначе
+			Результат = 0;
+		КонецЕсли;`)}
+
+	groups, truncated := findCloneGroups([]cloneDocument{docA, docB}, 10, 0)
+	if truncated {
+		t.Fatalf("unexpected truncation")
+	}
+	if len(groups) == 0 {
+		t.Fatalf("expected at least one clone group, got none")
+	}
+
+	for _, g := range groups {
+		if g.Similarity >= 1.0 {
+			t.Errorf("group with similarity %.2f should be Type-2 only (renamed identifiers), want < 1.0", g.Similarity)
+		}
+		if len(g.Occurrences) != 2 {
+			t.Errorf("len(Occurrences) = %d, want 2 (one per file)", len(g.Occurrences))
+		}
+	}
+}
@@ -4,9 +4,12 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strings"
 
+	bridgepkg "rockerboo/mcp-lsp-bridge/bridge"
 	"rockerboo/mcp-lsp-bridge/interfaces"
 	"rockerboo/mcp-lsp-bridge/logger"
+	"rockerboo/mcp-lsp-bridge/types"
 
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
@@ -14,31 +17,18 @@ import (
 
 func ExecuteCommandTool(bridge interfaces.BridgeInterface) (mcp.Tool, server.ToolHandlerFunc) {
 	return mcp.NewTool("execute_command",
-			mcp.WithDescription("Execute workspace commands exposed by the language server (workspace/executeCommand). Useful for server-specific actions like refactors or code generation."),
+			mcp.WithDescription("Execute workspace commands exposed by the language server (workspace/executeCommand). Call with no 'command' argument to list the commands known for a language along with their argument schema and policy. Useful for server-specific actions like refactors or code generation."),
 			mcp.WithDestructiveHintAnnotation(false),
-			mcp.WithString("command", mcp.Description("LSP command identifier (server-specific)."), mcp.Required()),
-			mcp.WithString("arguments_json", mcp.Description("Optional JSON array of arguments for the command.")),
+			mcp.WithString("command", mcp.Description("LSP command identifier (server-specific). Omit to list known commands for the language instead of dispatching one.")),
+			mcp.WithString("arguments_json", mcp.Description("Optional JSON array of arguments for the command, validated against the command's registered schema if one is known.")),
 			mcp.WithString("language", mcp.Description("Language server ID (e.g., 'bsl'). Required if uri is not provided.")),
 			mcp.WithString("uri", mcp.Description("Optional file URI to infer language when language is not provided.")),
+			mcp.WithBoolean("confirm", mcp.Description("Must be true to dispatch a command registered with policy 'destructive'.")),
 		), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-			command, err := request.RequireString("command")
-			if err != nil {
-				logger.Error("execute_command: command parsing failed", err)
-				return mcp.NewToolResultError(err.Error()), nil
-			}
-
 			if result, ok := CheckReadyOrReturn(bridge); !ok {
 				return result, nil
 			}
 
-			argsJSON := request.GetString("arguments_json", "")
-			var args []any
-			if argsJSON != "" {
-				if err := json.Unmarshal([]byte(argsJSON), &args); err != nil {
-					return mcp.NewToolResultError(fmt.Sprintf("Invalid arguments_json: %v", err)), nil
-				}
-			}
-
 			language := request.GetString("language", "")
 			if language == "" {
 				uri := request.GetString("uri", "")
@@ -52,6 +42,48 @@ func ExecuteCommandTool(bridge interfaces.BridgeInterface) (mcp.Tool, server.Too
 				language = string(*lang)
 			}
 
+			registry := commandRegistryFor(bridge)
+
+			command := request.GetString("command", "")
+			if command == "" {
+				if registry == nil {
+					return mcp.NewToolResultError("execute_command self-discovery is not supported by this bridge"), nil
+				}
+				out, err := json.Marshal(registry.List(types.Language(language)))
+				if err != nil {
+					return mcp.NewToolResultError(fmt.Sprintf("failed to marshal known commands: %v", err)), nil
+				}
+				return mcp.NewToolResultText(string(out)), nil
+			}
+
+			var spec bridgepkg.CommandSpec
+			var known bool
+			if registry != nil {
+				spec, known = registry.Get(types.Language(language), command)
+			}
+
+			if known && spec.Policy == bridgepkg.CommandPolicyDenied {
+				return mcp.NewToolResultError(fmt.Sprintf("command %q is denied by commands.yaml policy", command)), nil
+			}
+
+			argsJSON := request.GetString("arguments_json", "")
+			var args []any
+			if argsJSON != "" {
+				if err := json.Unmarshal([]byte(argsJSON), &args); err != nil {
+					return mcp.NewToolResultError(fmt.Sprintf("Invalid arguments_json: %v", err)), nil
+				}
+			}
+
+			if known {
+				if errs := bridgepkg.ValidateArguments(spec, args); len(errs) > 0 {
+					return mcp.NewToolResultError(fmt.Sprintf("invalid arguments for %q:\n- %s", command, strings.Join(errs, "\n- "))), nil
+				}
+
+				if spec.Policy == bridgepkg.CommandPolicyDestructive && !request.GetBool("confirm", false) {
+					return mcp.NewToolResultError(fmt.Sprintf("command %q is destructive and requires confirm: true", command)), nil
+				}
+			}
+
 			result, err := bridge.ExecuteCommand(language, command, args)
 			if err != nil {
 				logger.Error("execute_command: request failed", err)
@@ -66,6 +98,19 @@ func ExecuteCommandTool(bridge interfaces.BridgeInterface) (mcp.Tool, server.Too
 		}
 }
 
+// commandRegistryFor returns the concrete bridge's command registry, or nil
+// if bridge isn't backed by *bridgepkg.MCPLSPBridge (e.g. a test mock),
+// mirroring the type-assertion pattern graceful_restart.go and
+// language_server_posture.go use to reach bridge-only functionality that
+// isn't part of interfaces.BridgeInterface.
+func commandRegistryFor(bridge interfaces.BridgeInterface) *bridgepkg.CommandRegistry {
+	b, ok := bridge.(*bridgepkg.MCPLSPBridge)
+	if !ok {
+		return nil
+	}
+	return b.CommandRegistry()
+}
+
 func RegisterExecuteCommandTool(mcpServer ToolServer, bridge interfaces.BridgeInterface) {
 	mcpServer.AddTool(ExecuteCommandTool(bridge))
 }
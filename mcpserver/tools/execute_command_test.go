@@ -0,0 +1,84 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"rockerboo/mcp-lsp-bridge/mocks"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/mcptest"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// TestExecuteCommandDispatchesUnconfirmedWhenCommandUnknown is a
+// characterization test for the chunk4-5 review finding: commandRegistryFor
+// only returns a non-nil *bridgepkg.CommandRegistry when bridge is backed by
+// the concrete *bridgepkg.MCPLSPBridge, so against any other
+// interfaces.BridgeInterface (this mock included) every command is
+// `known == false` and ExecuteCommandTool's destructive/deny gate
+// (execute_command.go's `if known { ... }`) never runs - the exact
+// unconfirmed-destructive-dispatch gap the review flagged, just reached via
+// a different `known == false` cause than the unsynced-registry one the fix
+// targets. It documents why that gate must never be the only thing standing
+// between a caller and a destructive command: the registry has to actually
+// be synced (see bridge/auto_connect.go's SyncCommandRegistry calls) for
+// `known` to ever be true against the real bridge.
+func TestExecuteCommandDispatchesUnconfirmedWhenCommandUnknown(t *testing.T) {
+	bridge := &mocks.MockBridge{}
+	bridge.On("ExecuteCommand", "bsl", "bsl.dangerous.delete", []any{}).
+		Return(json.RawMessage(`{"ok":true}`), nil)
+
+	tool, handler := ExecuteCommandTool(bridge)
+	mcpServer, err := mcptest.NewServer(t, server.ServerTool{Tool: tool, Handler: handler})
+	if err != nil {
+		t.Fatalf("create MCP server: %v", err)
+	}
+
+	toolResult, err := mcpServer.Client().CallTool(context.Background(), mcp.CallToolRequest{
+		Request: mcp.Request{Method: "tools/call"},
+		Params: mcp.CallToolParams{
+			Name: "execute_command",
+			Arguments: map[string]any{
+				"language": "bsl",
+				"command":  "bsl.dangerous.delete",
+				// Deliberately omitted: "confirm". A command registered
+				// with policy "destructive" must reject this.
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("call execute_command: %v", err)
+	}
+	if toolResult.IsError {
+		t.Fatalf("expected the unconfirmed dispatch to succeed against an unsynced registry (that's the gap), got error: %#v", toolResult.Content)
+	}
+}
+
+func TestExecuteCommandRequiresLanguageOrURI(t *testing.T) {
+	// Without a concrete *bridgepkg.MCPLSPBridge to back commandRegistryFor,
+	// there's no way to make a command "known" in this test, so this only
+	// checks that a call with neither language nor uri is rejected.
+	bridge := &mocks.MockBridge{}
+
+	tool, handler := ExecuteCommandTool(bridge)
+	mcpServer, err := mcptest.NewServer(t, server.ServerTool{Tool: tool, Handler: handler})
+	if err != nil {
+		t.Fatalf("create MCP server: %v", err)
+	}
+
+	toolResult, err := mcpServer.Client().CallTool(context.Background(), mcp.CallToolRequest{
+		Request: mcp.Request{Method: "tools/call"},
+		Params: mcp.CallToolParams{
+			Name:      "execute_command",
+			Arguments: map[string]any{},
+		},
+	})
+	if err != nil {
+		t.Fatalf("call execute_command: %v", err)
+	}
+	if !toolResult.IsError {
+		t.Fatal("expected an error when neither language nor uri is given")
+	}
+}
@@ -0,0 +1,542 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"rockerboo/mcp-lsp-bridge/interfaces"
+	"rockerboo/mcp-lsp-bridge/logger"
+	"rockerboo/mcp-lsp-bridge/lsp"
+	"rockerboo/mcp-lsp-bridge/utils"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/myleshyson/lsprotocol-go/protocol"
+	"github.com/sergi/go-diff/diffmatchpatch"
+)
+
+// defaultFormattingCheckTabSize matches RangeFormattingTool's default.
+const defaultFormattingCheckTabSize = 4
+
+// formattingCheckDiffContext is how many unchanged lines formatting_check
+// keeps on either side of a changed region in a hunk, the same convention
+// `diff -u`/git use.
+const formattingCheckDiffContext = 3
+
+// FormattingCheckHunk is one contiguous changed region in a formatting_check
+// diff, using unified diff's hunk-header convention
+// ("@@ -OldStart,OldLines +NewStart,NewLines @@"); all line numbers are
+// 1-based, except that OldStart/NewStart is 0 for a hunk that only inserts
+// at the very start of the file.
+type FormattingCheckHunk struct {
+	OldStart int `json:"old_start"`
+	OldLines int `json:"old_lines"`
+	NewStart int `json:"new_start"`
+	NewLines int `json:"new_lines"`
+}
+
+// FormattingCheckResult is one file's formatting_check verdict.
+type FormattingCheckResult struct {
+	URI         string                `json:"uri"`
+	IsClean     bool                  `json:"is_clean"`
+	Hunks       []FormattingCheckHunk `json:"hunks,omitempty"`
+	UnifiedDiff string                `json:"unified_diff,omitempty"`
+	Error       string                `json:"error,omitempty"`
+}
+
+// FormattingCheckReport is formatting_check's overall result: a CI-style
+// pass/fail gate over every checked file, answering "is this changeset
+// properly formatted?" the way the Gerrit formatting-checker daemon does,
+// without applying any edit or needing a separate sandboxed formatter.
+type FormattingCheckReport struct {
+	Results  []FormattingCheckResult `json:"results"`
+	Clean    bool                    `json:"clean"`
+	ExitCode int                     `json:"exit_code"`
+}
+
+// formattingCheckIgnoredDirs mirrors the ignored-directory set
+// bsl_module_coupling/bsl_find_duplicates use when walking a workspace.
+var formattingCheckIgnoredDirs = map[string]struct{}{
+	".git": {}, ".hg": {}, ".svn": {}, ".idea": {}, ".vscode": {},
+	"node_modules": {}, "vendor": {}, "dist": {}, "build": {}, "out": {}, "target": {}, "_bin": {},
+}
+
+// discoverFormattingCheckFiles walks workspaceDir collecting every regular
+// file whose workspace-relative path matches any of globPatterns
+// (matchesAnyGlob), skipping the usual VCS/dependency/build directories.
+func discoverFormattingCheckFiles(workspaceDir string, globPatterns []string) ([]string, error) {
+	var paths []string
+	err := filepath.WalkDir(workspaceDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() {
+			if _, ok := formattingCheckIgnoredDirs[strings.ToLower(d.Name())]; ok {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		relPath, relErr := filepath.Rel(workspaceDir, path)
+		if relErr != nil {
+			relPath = path
+		}
+		if matchesAnyGlob(globPatterns, relPath) {
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return paths, nil
+}
+
+// formattingCheckChangedRange is a 0-based, end-inclusive line range (LSP
+// Position.Line numbering) that only_changed restricts a file's formatting
+// check to.
+type formattingCheckChangedRange struct {
+	startLine uint32
+	endLine   uint32
+}
+
+// formattingCheckHunkHeaderPattern matches a unified-diff hunk header's
+// new-file side, e.g. "@@ -12,3 +14,5 @@" captures "14" and "5".
+var formattingCheckHunkHeaderPattern = regexp.MustCompile(`^@@ -\d+(?:,\d+)? \+(\d+)(?:,(\d+))? @@`)
+
+// changedLineRanges runs `git diff --unified=0 baseRef -- relPath` in
+// workspaceDir and parses the hunk headers into the new-file line ranges
+// only_changed restricts formatting_check to. A hunk with a zero new-file
+// line count (a pure deletion) contributes no range, since there is no
+// surviving line left to format.
+func changedLineRanges(ctx context.Context, workspaceDir, baseRef, relPath string) ([]formattingCheckChangedRange, error) {
+	if strings.HasPrefix(baseRef, "-") {
+		// baseRef is the MCP tool's base_ref argument, passed straight
+		// through to git as a bare (non "--"-prefixed) positional arg - a
+		// value like "--output=/some/path" would otherwise be parsed by
+		// git as an option instead of a revision, letting a caller make
+		// git write arbitrary files on this host.
+		return nil, fmt.Errorf("base_ref %q must not start with '-'", baseRef)
+	}
+
+	out, err := exec.CommandContext(ctx, "git", "-C", workspaceDir, "diff", "--unified=0", baseRef, "--", relPath).CombinedOutput()
+	if err != nil {
+		return nil, fmt.Errorf("git diff --unified=0 %s -- %s: %w (%s)", baseRef, relPath, err, strings.TrimSpace(string(out)))
+	}
+
+	var ranges []formattingCheckChangedRange
+	for _, line := range strings.Split(string(out), "\n") {
+		if !strings.HasPrefix(line, "@@ ") {
+			continue
+		}
+		m := formattingCheckHunkHeaderPattern.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		newStart, _ := strconv.Atoi(m[1])
+		newLines := 1
+		if m[2] != "" {
+			newLines, _ = strconv.Atoi(m[2])
+		}
+		if newLines == 0 {
+			continue
+		}
+		ranges = append(ranges, formattingCheckChangedRange{
+			startLine: uint32(newStart - 1),
+			endLine:   uint32(newStart - 1 + newLines - 1),
+		})
+	}
+	return ranges, nil
+}
+
+// lineCharLen returns the rune length of 0-based line n in lines, or 0 if
+// out of range, so a changed range's end_character can point at the real
+// end of its last line instead of an arbitrary large value.
+func lineCharLen(lines []string, n uint32) uint32 {
+	if int(n) >= len(lines) {
+		return 0
+	}
+	return uint32(len([]rune(lines[n])))
+}
+
+// diffLineOp is one line-granularity operation from comparing a
+// formatting_check file's original and formatted text, carrying the 1-based
+// line number it occupies on whichever side(s) it belongs to (0 on the side
+// it doesn't).
+type diffLineOp struct {
+	kind    diffmatchpatch.Operation
+	text    string
+	oldLine int
+	newLine int
+}
+
+// diffLineOps runs go-diff's line-mode diff (the same approach
+// codeActionDiffPreview uses) between original and formatted and returns
+// one diffLineOp per line, with old/new line numbers assigned in order.
+func diffLineOps(original, formatted string) []diffLineOp {
+	dmp := diffmatchpatch.New()
+	a, b, lineArr := dmp.DiffLinesToChars(original, formatted)
+	diffs := dmp.DiffCharsToLines(dmp.DiffMain(a, b, false), lineArr)
+
+	var ops []diffLineOp
+	oldLine, newLine := 1, 1
+	for _, d := range diffs {
+		for _, line := range strings.SplitAfter(d.Text, "\n") {
+			if line == "" {
+				continue
+			}
+			op := diffLineOp{kind: d.Type, text: line}
+			switch d.Type {
+			case diffmatchpatch.DiffEqual:
+				op.oldLine, op.newLine = oldLine, newLine
+				oldLine++
+				newLine++
+			case diffmatchpatch.DiffDelete:
+				op.oldLine = oldLine
+				oldLine++
+			case diffmatchpatch.DiffInsert:
+				op.newLine = newLine
+				newLine++
+			}
+			ops = append(ops, op)
+		}
+	}
+	return ops
+}
+
+// groupDiffHunks clusters the changed (non-equal) entries of ops into
+// [lo, hi] index ranges, merging two changed regions separated by at most
+// 2*context unchanged lines into a single hunk, then widens each group by up
+// to context unchanged lines on either side.
+func groupDiffHunks(ops []diffLineOp, context int) [][2]int {
+	var changed []int
+	for i, op := range ops {
+		if op.kind != diffmatchpatch.DiffEqual {
+			changed = append(changed, i)
+		}
+	}
+	if len(changed) == 0 {
+		return nil
+	}
+
+	var groups [][2]int
+	start, end := changed[0], changed[0]
+	for _, idx := range changed[1:] {
+		if idx-end-1 <= 2*context {
+			end = idx
+			continue
+		}
+		groups = append(groups, [2]int{start, end})
+		start, end = idx, idx
+	}
+	groups = append(groups, [2]int{start, end})
+
+	for i, g := range groups {
+		lo := g[0] - context
+		if lo < 0 {
+			lo = 0
+		}
+		hi := g[1] + context
+		if hi >= len(ops) {
+			hi = len(ops) - 1
+		}
+		groups[i] = [2]int{lo, hi}
+	}
+	return groups
+}
+
+// formatHunkRange renders a hunk-header range the way git does: a bare line
+// number when count is 1, "start,count" otherwise.
+func formatHunkRange(start, count int) string {
+	if count == 1 {
+		return strconv.Itoa(start)
+	}
+	return fmt.Sprintf("%d,%d", start, count)
+}
+
+// buildFormattingCheckDiff compares original against formatted (the result
+// of applying the LSP's formatting edits, never written to disk) and
+// renders a unified diff labeled with label, returning both the rendered
+// text and the structured hunks the JSON result reports alongside it.
+// Returns (nil, "") if the two are identical.
+func buildFormattingCheckDiff(label, original, formatted string) ([]FormattingCheckHunk, string) {
+	if original == formatted {
+		return nil, ""
+	}
+
+	ops := diffLineOps(original, formatted)
+	groups := groupDiffHunks(ops, formattingCheckDiffContext)
+	if len(groups) == 0 {
+		return nil, ""
+	}
+
+	carryOld, carryNew := 0, 0
+	oldLineAt := make([]int, len(ops))
+	newLineAt := make([]int, len(ops))
+	for i, op := range ops {
+		if op.oldLine != 0 {
+			carryOld = op.oldLine
+		}
+		if op.newLine != 0 {
+			carryNew = op.newLine
+		}
+		oldLineAt[i] = carryOld
+		newLineAt[i] = carryNew
+	}
+
+	var hunks []FormattingCheckHunk
+	var out strings.Builder
+	fmt.Fprintf(&out, "--- a/%s\n+++ b/%s\n", label, label)
+
+	for _, g := range groups {
+		lo, hi := g[0], g[1]
+
+		oldStart, newStart := 0, 0
+		oldLines, newLines := 0, 0
+		for k := lo; k <= hi; k++ {
+			if ops[k].kind != diffmatchpatch.DiffInsert {
+				oldLines++
+				if oldStart == 0 {
+					oldStart = ops[k].oldLine
+				}
+			}
+			if ops[k].kind != diffmatchpatch.DiffDelete {
+				newLines++
+				if newStart == 0 {
+					newStart = ops[k].newLine
+				}
+			}
+		}
+		if oldStart == 0 {
+			oldStart = oldLineAt[lo]
+		}
+		if newStart == 0 {
+			newStart = newLineAt[lo]
+		}
+
+		hunks = append(hunks, FormattingCheckHunk{OldStart: oldStart, OldLines: oldLines, NewStart: newStart, NewLines: newLines})
+
+		fmt.Fprintf(&out, "@@ -%s +%s @@\n", formatHunkRange(oldStart, oldLines), formatHunkRange(newStart, newLines))
+		for k := lo; k <= hi; k++ {
+			switch ops[k].kind {
+			case diffmatchpatch.DiffInsert:
+				fmt.Fprintf(&out, "+%s", ops[k].text)
+			case diffmatchpatch.DiffDelete:
+				fmt.Fprintf(&out, "-%s", ops[k].text)
+			default:
+				fmt.Fprintf(&out, " %s", ops[k].text)
+			}
+		}
+	}
+
+	return hunks, out.String()
+}
+
+// checkFileFormatting loads uriOrPath's on-disk content, asks the routed LSP
+// server for the formatting edits it would apply (the whole document, or
+// only the only_changed-restricted ranges when changedRanges is non-nil) and
+// diffs the result against the original without writing anything to disk.
+func checkFileFormatting(bridge interfaces.BridgeInterface, uriOrPath string, changedRanges []formattingCheckChangedRange, tabSize uint32, insertSpaces bool) FormattingCheckResult {
+	fileURI := bridge.NormalizeURIForLSP(uriOrPath)
+	label := utils.URIToFilePath(fileURI)
+
+	result := FormattingCheckResult{URI: fileURI}
+
+	source, err := os.ReadFile(label) // #nosec G304 -- path came from an explicit uri/glob argument or a workspace walk
+	if err != nil {
+		result.Error = fmt.Sprintf("read %s: %v", label, err)
+		return result
+	}
+	original := string(source)
+
+	var edits []protocol.TextEdit
+	if changedRanges == nil {
+		got, err := bridge.Formatting(fileURI, tabSize, insertSpaces)
+		if err != nil {
+			result.Error = fmt.Sprintf("formatting request failed: %v", err)
+			return result
+		}
+		edits = got
+	} else if len(changedRanges) == 0 {
+		// only_changed found no hunks against base_ref: nothing to check.
+		result.IsClean = true
+		return result
+	} else {
+		lines := strings.Split(original, "\n")
+		for _, r := range changedRanges {
+			got, err := bridge.RangeFormatting(fileURI, r.startLine, 0, r.endLine, lineCharLen(lines, r.endLine), tabSize, insertSpaces)
+			if err != nil {
+				result.Error = fmt.Sprintf("range formatting request failed for lines %d-%d: %v", r.startLine+1, r.endLine+1, err)
+				return result
+			}
+			edits = append(edits, got...)
+		}
+	}
+
+	formatted := lsp.ApplyTextEdits(original, edits)
+	if formatted == original {
+		result.IsClean = true
+		return result
+	}
+
+	result.Hunks, result.UnifiedDiff = buildFormattingCheckDiff(label, original, formatted)
+	return result
+}
+
+// buildFormattingCheckReport is formatting_check's core: it resolves which
+// files to check (either the explicit uris list, or every file under the
+// first allowed workspace directory matching glob), computes each file's
+// only_changed line ranges when requested, and runs checkFileFormatting over
+// all of them.
+func buildFormattingCheckReport(ctx context.Context, bridge interfaces.BridgeInterface, uris []string, globPatterns []string, onlyChanged bool, baseRef string, tabSize uint32, insertSpaces bool) (FormattingCheckReport, error) {
+	files := uris
+	if len(files) == 0 {
+		if len(globPatterns) == 0 {
+			return FormattingCheckReport{}, errors.New("formatting_check: must set uris or glob")
+		}
+		dirs := bridge.AllowedDirectories()
+		if len(dirs) == 0 {
+			return FormattingCheckReport{}, errors.New("formatting_check: no workspace directories configured")
+		}
+		discovered, err := discoverFormattingCheckFiles(dirs[0], globPatterns)
+		if err != nil {
+			return FormattingCheckReport{}, fmt.Errorf("formatting_check: workspace walk failed: %w", err)
+		}
+		files = discovered
+	}
+
+	var workspaceDir string
+	if onlyChanged {
+		dirs := bridge.AllowedDirectories()
+		if len(dirs) == 0 {
+			return FormattingCheckReport{}, errors.New("formatting_check: only_changed requires a configured workspace directory")
+		}
+		workspaceDir = dirs[0]
+	}
+
+	report := FormattingCheckReport{Clean: true}
+	for _, f := range files {
+		var changedRanges []formattingCheckChangedRange
+		if onlyChanged {
+			path := utils.URIToFilePath(bridge.NormalizeURIForLSP(f))
+			relPath, relErr := filepath.Rel(workspaceDir, path)
+			if relErr != nil {
+				relPath = path
+			}
+			ranges, err := changedLineRanges(ctx, workspaceDir, baseRef, relPath)
+			if err != nil {
+				logger.Warn(fmt.Sprintf("formatting_check: %v", err))
+				report.Results = append(report.Results, FormattingCheckResult{URI: bridge.NormalizeURIForLSP(f), Error: err.Error()})
+				report.Clean = false
+				continue
+			}
+			changedRanges = ranges
+			if changedRanges == nil {
+				changedRanges = []formattingCheckChangedRange{}
+			}
+		}
+
+		result := checkFileFormatting(bridge, f, changedRanges, tabSize, insertSpaces)
+		if result.Error != "" || !result.IsClean {
+			report.Clean = false
+		}
+		report.Results = append(report.Results, result)
+	}
+
+	if !report.Clean {
+		report.ExitCode = 1
+	}
+	return report, nil
+}
+
+// formatFormattingCheckText renders report for output_format="text".
+func formatFormattingCheckText(report FormattingCheckReport) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "FORMATTING_CHECK|files=%d|clean=%t|exit_code=%d\n\n", len(report.Results), report.Clean, report.ExitCode)
+	for _, r := range report.Results {
+		switch {
+		case r.Error != "":
+			fmt.Fprintf(&sb, "ERROR  %s: %s\n", r.URI, r.Error)
+		case r.IsClean:
+			fmt.Fprintf(&sb, "CLEAN  %s\n", r.URI)
+		default:
+			fmt.Fprintf(&sb, "DIRTY  %s (%d hunk(s))\n", r.URI, len(r.Hunks))
+			sb.WriteString(r.UnifiedDiff)
+		}
+	}
+	return sb.String()
+}
+
+// FormattingCheckTool registers "formatting_check": a CI-style lint gate
+// built on RangeFormattingTool/Formatting. Unlike those, it never writes an
+// edit - it computes what textDocument/formatting (or, with only_changed,
+// textDocument/rangeFormatting restricted to each file's git-diff hunks)
+// would change and reports a structured {uri, hunks, unifiedDiff, isClean}
+// per file plus a top-level clean/exit_code, the way a Gerrit-style
+// formatting-checker daemon answers "is this changeset properly formatted?"
+// without a sandboxed formatter server.
+func FormattingCheckTool(bridge interfaces.BridgeInterface) (mcp.Tool, server.ToolHandlerFunc) {
+	return mcp.NewTool("formatting_check",
+			mcp.WithDescription("CI-style formatting lint gate. Computes the textDocument/formatting (or rangeFormatting) edits for each file without applying them, and reports per-file hunks/unifiedDiff/isClean plus an overall clean flag and exit_code. Set only_changed=true to restrict each file's check to the line ranges `git diff --unified=0 base_ref` reports as modified, instead of reformatting the whole file."),
+			mcp.WithDestructiveHintAnnotation(false),
+			mcp.WithString("uris", mcp.Description("Comma-separated file URIs or paths to check. Either this or glob is required.")),
+			mcp.WithString("glob", mcp.Description("Comma-separated glob pattern(s), matched against the workspace-relative path of every file under the first allowed directory. Either this or uris is required.")),
+			mcp.WithBoolean("only_changed", mcp.Description("Restrict each file's check to the line ranges modified since base_ref (default: false, checks the whole file).")),
+			mcp.WithString("base_ref", mcp.Description("Git ref only_changed diffs against (default: \"HEAD\").")),
+			mcp.WithNumber("tab_size", mcp.Description("Tab size for formatting (default: 4)")),
+			mcp.WithBoolean("insert_spaces", mcp.Description("Use spaces for indentation (default: true)"), mcp.DefaultBool(true)),
+			mcp.WithString("output_format", mcp.Description("\"json\" (default) or \"text\".")),
+		), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			uris := splitGlobList(request.GetString("uris", ""))
+			globPatterns := splitGlobList(request.GetString("glob", ""))
+			onlyChanged := request.GetBool("only_changed", false)
+			baseRef := strings.TrimSpace(request.GetString("base_ref", ""))
+			if baseRef == "" {
+				baseRef = "HEAD"
+			}
+
+			tabSize := defaultFormattingCheckTabSize
+			if val, err := request.RequireInt("tab_size"); err == nil {
+				tabSize = val
+			}
+			insertSpaces := request.GetBool("insert_spaces", true)
+			outputFormat := strings.ToLower(strings.TrimSpace(request.GetString("output_format", "")))
+
+			if result, ok := CheckReadyOrReturn(bridge); !ok {
+				return result, nil
+			}
+
+			tabSizeUint32, err := safeUint32(tabSize)
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("Invalid tab_size: %v", err)), nil
+			}
+
+			report, err := buildFormattingCheckReport(ctx, bridge, uris, globPatterns, onlyChanged, baseRef, tabSizeUint32, insertSpaces)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			if outputFormat == "text" {
+				return mcp.NewToolResultText(formatFormattingCheckText(report)), nil
+			}
+
+			payload, err := json.MarshalIndent(report, "", "  ")
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("formatting_check: failed to marshal result: %v", err)), nil
+			}
+			return mcp.NewToolResultText(string(payload)), nil
+		}
+}
+
+// RegisterFormattingCheckTool registers the formatting_check tool.
+func RegisterFormattingCheckTool(mcpServer ToolServer, bridge interfaces.BridgeInterface) {
+	mcpServer.AddTool(FormattingCheckTool(bridge))
+}
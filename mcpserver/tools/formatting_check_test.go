@@ -0,0 +1,112 @@
+package tools
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestBuildFormattingCheckDiff_NoChange(t *testing.T) {
+	src := "line1\nline2\nline3\n"
+	hunks, diff := buildFormattingCheckDiff("a.bsl", src, src)
+	if hunks != nil || diff != "" {
+		t.Errorf("identical text should produce no hunks/diff, got hunks=%v diff=%q", hunks, diff)
+	}
+}
+
+func TestBuildFormattingCheckDiff_SingleHunk(t *testing.T) {
+	original := "func A()\n\tx=1;\nend\n"
+	formatted := "func A()\n\tx = 1;\nend\n"
+
+	hunks, diff := buildFormattingCheckDiff("a.bsl", original, formatted)
+	if len(hunks) != 1 {
+		t.Fatalf("len(hunks) = %d, want 1", len(hunks))
+	}
+	if hunks[0].OldLines != hunks[0].NewLines {
+		t.Errorf("a one-line reformat should keep the same line count on both sides: %+v", hunks[0])
+	}
+	if !strings.Contains(diff, "-\tx=1;") || !strings.Contains(diff, "+\tx = 1;") {
+		t.Errorf("unified diff missing expected -/+ lines:\n%s", diff)
+	}
+	if !strings.HasPrefix(diff, "--- a/a.bsl\n+++ b/a.bsl\n") {
+		t.Errorf("unified diff missing file header:\n%s", diff)
+	}
+}
+
+func TestBuildFormattingCheckDiff_TwoFarApartHunksStaySeparate(t *testing.T) {
+	lines := make([]string, 0, 40)
+	for i := 0; i < 20; i++ {
+		lines = append(lines, "same")
+	}
+	original := strings.Join(lines, "\n") + "\n"
+
+	changed := make([]string, len(lines))
+	copy(changed, lines)
+	changed[0] = "changed-top"
+	changed[len(changed)-1] = "changed-bottom"
+	formatted := strings.Join(changed, "\n") + "\n"
+
+	hunks, _ := buildFormattingCheckDiff("a.bsl", original, formatted)
+	if len(hunks) != 2 {
+		t.Fatalf("len(hunks) = %d, want 2 (changes far enough apart to stay separate)", len(hunks))
+	}
+}
+
+func TestBuildFormattingCheckDiff_InsertAtStart(t *testing.T) {
+	original := "b\nc\n"
+	formatted := "a\nb\nc\n"
+
+	hunks, _ := buildFormattingCheckDiff("a.bsl", original, formatted)
+	if len(hunks) != 1 {
+		t.Fatalf("len(hunks) = %d, want 1", len(hunks))
+	}
+	// The hunk carries the two unchanged lines after the insertion as
+	// context, so only those count on the old side.
+	if hunks[0].OldLines != 2 {
+		t.Errorf("insertion before 2 context lines should report OldLines=2, got %d", hunks[0].OldLines)
+	}
+}
+
+func TestChangedLineRangesHunkHeaderPattern(t *testing.T) {
+	tests := []struct {
+		line      string
+		wantStart string
+		wantLines string
+	}{
+		{"@@ -1,3 +1,3 @@", "1", "3"},
+		{"@@ -0,0 +1,5 @@ func Foo()", "1", "5"},
+		{"@@ -4,2 +4 @@", "4", ""},
+	}
+	for _, tt := range tests {
+		m := formattingCheckHunkHeaderPattern.FindStringSubmatch(tt.line)
+		if m == nil {
+			t.Fatalf("pattern did not match %q", tt.line)
+		}
+		if m[1] != tt.wantStart || m[2] != tt.wantLines {
+			t.Errorf("FindStringSubmatch(%q) = %v, want start=%q lines=%q", tt.line, m, tt.wantStart, tt.wantLines)
+		}
+	}
+}
+
+func TestChangedLineRangesRejectsDashPrefixedBaseRef(t *testing.T) {
+	// base_ref is passed to `git diff` as a bare positional arg, before
+	// "--"; a value starting with "-" would otherwise be parsed by git as
+	// an option (e.g. "--output=/some/path" making git write an arbitrary
+	// file) instead of a revision.
+	if _, err := changedLineRanges(context.Background(), t.TempDir(), "--output=/tmp/pwned.txt", "a.bsl"); err == nil {
+		t.Fatal("expected a dash-prefixed base_ref to be rejected")
+	}
+}
+
+func TestLineCharLen(t *testing.T) {
+	lines := []string{"abc", "de"}
+	if got := lineCharLen(lines, 0); got != 3 {
+		t.Errorf("lineCharLen(0) = %d, want 3", got)
+	}
+	if got := lineCharLen(lines, 1); got != 2 {
+		t.Errorf("lineCharLen(1) = %d, want 2", got)
+	}
+	if got := lineCharLen(lines, 5); got != 0 {
+		t.Errorf("lineCharLen(out of range) = %d, want 0", got)
+	}
+}
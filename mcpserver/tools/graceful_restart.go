@@ -0,0 +1,39 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+
+	bridgepkg "rockerboo/mcp-lsp-bridge/bridge"
+	"rockerboo/mcp-lsp-bridge/interfaces"
+	"rockerboo/mcp-lsp-bridge/logger"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// GracefulRestartTool reloads connected language servers without dropping
+// in-flight requests: see bridge.MCPLSPBridge.ReloadAll /
+// lsp.LanguageClient.Reload.
+func GracefulRestartTool(bridge interfaces.BridgeInterface) (mcp.Tool, server.ToolHandlerFunc) {
+	return mcp.NewTool("graceful_restart",
+			mcp.WithDescription("Restart connected language servers picking up any config changes, without dropping in-flight requests. A new connection is started and initialized before the old one is drained and torn down."),
+			mcp.WithDestructiveHintAnnotation(true),
+		), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			b, ok := bridge.(*bridgepkg.MCPLSPBridge)
+			if !ok {
+				return mcp.NewToolResultError("graceful_restart is not supported by this bridge"), nil
+			}
+
+			if err := b.ReloadAll(ctx); err != nil {
+				logger.Error("graceful_restart: reload failed", err)
+				return mcp.NewToolResultError(fmt.Sprintf("graceful restart failed: %v", err)), nil
+			}
+
+			return mcp.NewToolResultText("graceful restart complete"), nil
+		}
+}
+
+func RegisterGracefulRestartTool(mcpServer ToolServer, bridge interfaces.BridgeInterface) {
+	mcpServer.AddTool(GracefulRestartTool(bridge))
+}
@@ -0,0 +1,226 @@
+package tools
+
+// Golden-file test harness for project_analysis's handlers, modeled after
+// gopls's internal/lsp/tests txtar fixtures: each testdata/*.txtar archive
+// bundles a small workspace plus the recorded ProjectAnalysisTool output for
+// one analysis_type. Run with -golden (e.g.
+// `go test ./mcpserver/tools/... -run Golden -golden`) to regenerate a
+// fixture's golden section after an intentional output change.
+//
+// Only text_search is covered today: workspace_analysis/file_analysis/
+// pattern_analysis/symbol_relationships all route through
+// analysis.ProjectAnalyzer, which this checkout doesn't vendor, so there's
+// nothing real for those fixtures to exercise yet. Dropping in a fixture
+// file alongside its analysis_type is enough to pick up coverage once that
+// package exists; runGoldenFixture and the summary counting don't need to
+// change.
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"rockerboo/mcp-lsp-bridge/mocks"
+	"rockerboo/mcp-lsp-bridge/types"
+	"rockerboo/mcp-lsp-bridge/utils"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/mcptest"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+var updateGolden = flag.Bool("golden", false, "regenerate testdata/*.txtar golden sections instead of checking them")
+
+// txtarSection is one "-- name --" block of a txtar archive.
+type txtarSection struct {
+	Name string
+	Data string
+}
+
+// parseTxtar is a minimal txtar reader, just enough for these fixtures: it
+// splits on "-- name --" marker lines and keeps each section's body
+// (trailing newline included). It skips the comment/header support
+// golang.org/x/tools/txtar has, since no fixture here needs one.
+func parseTxtar(raw string) []txtarSection {
+	var sections []txtarSection
+	var current *txtarSection
+	var body strings.Builder
+
+	flush := func() {
+		if current != nil {
+			current.Data = body.String()
+			sections = append(sections, *current)
+		}
+		body.Reset()
+	}
+
+	for _, line := range strings.Split(raw, "\n") {
+		if rest, ok := strings.CutPrefix(line, "-- "); ok {
+			if name, ok := strings.CutSuffix(rest, " --"); ok {
+				flush()
+				current = &txtarSection{Name: strings.TrimSpace(name)}
+				continue
+			}
+		}
+		if current != nil {
+			body.WriteString(line)
+			body.WriteString("\n")
+		}
+	}
+	flush()
+	return sections
+}
+
+// writeTxtar is parseTxtar's inverse, used to rewrite a fixture's golden
+// section under -golden.
+func writeTxtar(sections []txtarSection) string {
+	var sb strings.Builder
+	for _, s := range sections {
+		fmt.Fprintf(&sb, "-- %s --\n", s.Name)
+		sb.WriteString(s.Data)
+	}
+	return sb.String()
+}
+
+// goldenFixtureSummary reports how much of a fixture actually got
+// exercised, so a fixture whose golden section is accidentally empty shows
+// up in test output instead of silently passing.
+type goldenFixtureSummary struct {
+	Fixture        string
+	WorkspaceFiles int
+	GoldenLines    int
+}
+
+// runGoldenFixture materializes fixture's "workspace/*" sections into a
+// fresh temp directory, drives project_analysis with its query/
+// analysis_type sections against a mocks.MockBridge wired to that
+// directory, and checks the result against the "*.golden" section (or
+// rewrites it, under -golden). The real workspace path is normalized to
+// $WORKSPACE before comparison since t.TempDir() isn't reproducible across
+// runs.
+func runGoldenFixture(t *testing.T, path string) goldenFixtureSummary {
+	t.Helper()
+
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read fixture: %v", err)
+	}
+	sections := parseTxtar(string(raw))
+
+	workspace := t.TempDir()
+
+	var query, analysisType, goldenName, golden string
+	var workspaceFiles int
+
+	for _, s := range sections {
+		switch {
+		case s.Name == "query":
+			query = strings.TrimSpace(s.Data)
+		case s.Name == "analysis_type":
+			analysisType = strings.TrimSpace(s.Data)
+		case strings.HasSuffix(s.Name, ".golden"):
+			goldenName, golden = s.Name, s.Data
+		case strings.HasPrefix(s.Name, "workspace/"):
+			rel := strings.TrimPrefix(s.Name, "workspace/")
+			full := filepath.Join(workspace, filepath.FromSlash(rel))
+			if err := os.MkdirAll(filepath.Dir(full), 0o755); err != nil {
+				t.Fatalf("mkdir for %s: %v", s.Name, err)
+			}
+			if err := os.WriteFile(full, []byte(s.Data), 0o644); err != nil {
+				t.Fatalf("write %s: %v", s.Name, err)
+			}
+			workspaceFiles++
+		}
+	}
+
+	if query == "" || analysisType == "" || goldenName == "" {
+		t.Fatalf("fixture %s: missing one of query/analysis_type/*.golden sections", path)
+	}
+
+	bridge := &mocks.MockBridge{}
+	bridge.On("AllowedDirectories").Return([]string{workspace})
+	bridge.On("GetConnectedLanguages").Return([]types.Language{"bsl"})
+	bridge.On("GetMultiLanguageClients", []string{"bsl"}).
+		Return(map[types.Language]types.LanguageClientInterface{"bsl": &mocks.MockLanguageClient{}}, nil)
+	// text_search normalizes every matched file's URI; main.bsl is this
+	// fixture's only file with a match, so it's the only call worth mocking.
+	bridge.On("NormalizeURIForLSP", utils.FilePathToURI(filepath.Join(workspace, "main.bsl"))).
+		Return("file:///workspace/main.bsl")
+
+	tool, handler := ProjectAnalysisTool(bridge)
+	mcpServer, err := mcptest.NewServer(t, server.ServerTool{Tool: tool, Handler: handler})
+	if err != nil {
+		t.Fatalf("create MCP server: %v", err)
+	}
+
+	toolResult, err := mcpServer.Client().CallTool(context.Background(), mcp.CallToolRequest{
+		Request: mcp.Request{Method: "tools/call"},
+		Params: mcp.CallToolParams{
+			Name: "project_analysis",
+			Arguments: map[string]any{
+				"query":         query,
+				"analysis_type": analysisType,
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("call project_analysis: %v", err)
+	}
+	if toolResult.IsError {
+		t.Fatalf("project_analysis returned an error: %#v", toolResult.Content)
+	}
+
+	text, ok := toolResult.Content[0].(mcp.TextContent)
+	if !ok {
+		t.Fatalf("expected text content, got %T", toolResult.Content[0])
+	}
+
+	got := strings.ReplaceAll(text.Text, workspace, "$WORKSPACE")
+
+	if *updateGolden {
+		for i, s := range sections {
+			if s.Name == goldenName {
+				sections[i].Data = got
+			}
+		}
+		if err := os.WriteFile(path, []byte(writeTxtar(sections)), 0o644); err != nil {
+			t.Fatalf("rewrite golden: %v", err)
+		}
+		golden = got
+	}
+
+	if got != golden {
+		t.Errorf("fixture %s: output mismatch\n--- got ---\n%s\n--- want ---\n%s", path, got, golden)
+	}
+
+	bridge.AssertExpectations(t)
+
+	return goldenFixtureSummary{
+		Fixture:        filepath.Base(path),
+		WorkspaceFiles: workspaceFiles,
+		GoldenLines:    len(strings.Split(strings.TrimRight(golden, "\n"), "\n")),
+	}
+}
+
+// TestProjectAnalysisGolden runs every testdata/*.txtar fixture through
+// runGoldenFixture.
+func TestProjectAnalysisGolden(t *testing.T) {
+	fixtures, err := filepath.Glob(filepath.Join("testdata", "*.txtar"))
+	if err != nil {
+		t.Fatalf("glob testdata: %v", err)
+	}
+	if len(fixtures) == 0 {
+		t.Skip("no testdata/*.txtar fixtures")
+	}
+
+	for _, fixture := range fixtures {
+		t.Run(filepath.Base(fixture), func(t *testing.T) {
+			summary := runGoldenFixture(t, fixture)
+			t.Logf("%s: %d workspace file(s), %d golden line(s) asserted", summary.Fixture, summary.WorkspaceFiles, summary.GoldenLines)
+		})
+	}
+}
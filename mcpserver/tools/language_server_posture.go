@@ -0,0 +1,44 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	bridgepkg "rockerboo/mcp-lsp-bridge/bridge"
+	"rockerboo/mcp-lsp-bridge/interfaces"
+	"rockerboo/mcp-lsp-bridge/logger"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// LanguageServerPostureTool re-runs the pre-flight/continuous posture check
+// (see lsp.RunPostureCheck) for every configured language server and reports
+// the results, so a broken install or unreachable endpoint can be diagnosed
+// without waiting for a confusing connect/initialize timeout.
+func LanguageServerPostureTool(bridge interfaces.BridgeInterface) (mcp.Tool, server.ToolHandlerFunc) {
+	return mcp.NewTool("language_server_posture",
+			mcp.WithDescription("Check that configured language servers are reachable/executable before connecting: binary existence, executable bit, optional hash pin and minimum version for stdio servers; DNS+TCP reachability for websocket/tcp servers."),
+			mcp.WithDestructiveHintAnnotation(false),
+		), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			b, ok := bridge.(*bridgepkg.MCPLSPBridge)
+			if !ok {
+				return mcp.NewToolResultError("language_server_posture is not supported by this bridge"), nil
+			}
+
+			reports := b.CheckAllPosture()
+
+			payload, err := json.MarshalIndent(reports, "", "  ")
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("failed to marshal posture reports: %v", err)), nil
+			}
+
+			logger.Debug("language_server_posture: reported posture for configured servers")
+			return mcp.NewToolResultText(string(payload)), nil
+		}
+}
+
+func RegisterLanguageServerPostureTool(mcpServer ToolServer, bridge interfaces.BridgeInterface) {
+	mcpServer.AddTool(LanguageServerPostureTool(bridge))
+}
@@ -0,0 +1,212 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"rockerboo/mcp-lsp-bridge/interfaces"
+	"rockerboo/mcp-lsp-bridge/logger"
+	"rockerboo/mcp-lsp-bridge/lsp"
+	"rockerboo/mcp-lsp-bridge/types"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/myleshyson/lsprotocol-go/protocol"
+)
+
+const defaultLiveDiagnosticsWaitSeconds = 10
+
+// severityRank orders protocol.DiagnosticSeverity from most to least severe,
+// matching the numeric order the LSP spec already defines (Error=1 is most
+// severe), so "min_severity" filtering is just a <= comparison.
+func severityRank(sev *protocol.DiagnosticSeverity) protocol.DiagnosticSeverity {
+	if sev == nil {
+		return protocol.DiagnosticSeverityHint
+	}
+	return *sev
+}
+
+func parseMinSeverity(s string) (protocol.DiagnosticSeverity, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "hint":
+		return protocol.DiagnosticSeverityHint, nil
+	case "information", "info":
+		return protocol.DiagnosticSeverityInformation, nil
+	case "warning", "warn":
+		return protocol.DiagnosticSeverityWarning, nil
+	case "error":
+		return protocol.DiagnosticSeverityError, nil
+	default:
+		return 0, fmt.Errorf("unknown min_severity %q (want error, warning, information, or hint)", s)
+	}
+}
+
+func filterBySeverity(diagnostics []protocol.Diagnostic, minSeverity protocol.DiagnosticSeverity) []protocol.Diagnostic {
+	filtered := make([]protocol.Diagnostic, 0, len(diagnostics))
+	for _, d := range diagnostics {
+		if severityRank(d.Severity) <= minSeverity {
+			filtered = append(filtered, d)
+		}
+	}
+	return filtered
+}
+
+// diagnosticsWaiter is the subset of lsp.LanguageClient's push-diagnostics API
+// that types.LanguageClientInterface doesn't declare. Reached via an inline
+// type assertion, the same way BuildLSPStatus reaches ProgressSnapshot on a
+// client it only holds as an interface.
+type diagnosticsWaiter interface {
+	AllDiagnostics() map[string]lsp.DiagnosticEntry
+	WaitForDiagnostics(ctx context.Context, uri string, lastSeenVersion int32) (lsp.DiagnosticEntry, error)
+}
+
+// LiveDiagnosticsTool exposes the diagnostics a language server has already
+// pushed via textDocument/publishDiagnostics - the server's own push stream,
+// cached in lsp.DiagnosticStore - as opposed to project_analysis/diagnostics'
+// pull-based textDocument/diagnostic request.
+func LiveDiagnosticsTool(bridge interfaces.BridgeInterface) (mcp.Tool, server.ToolHandlerFunc) {
+	return mcp.NewTool("live_diagnostics",
+			mcp.WithDescription(`Read diagnostics a language server has already published (textDocument/publishDiagnostics), for one file or the whole workspace.
+
+USAGE:
+- Whole workspace: live_diagnostics language="bsl"
+- One file: live_diagnostics uri="file://path" language="bsl"
+- Wait for a fresh report after an edit: live_diagnostics uri="file://path" wait_newer_than=3 wait_seconds=10
+
+PARAMETERS:
+- uri (optional): restrict to one file; omitted means every URI the server has reported on
+- language (optional): which connected language server to read from (default: first connected)
+- min_severity (optional): error|warning|information|hint (default: hint, i.e. everything)
+- wait_newer_than (optional): block until a report newer than this document version is published (requires uri)
+- wait_seconds (optional): how long to block for wait_newer_than (default 10)
+
+OUTPUT: Diagnostics grouped by URI, each with range, severity, source, and message.`),
+			mcp.WithDestructiveHintAnnotation(false),
+			mcp.WithString("uri", mcp.Description("Restrict to one file's diagnostics; omit for the whole workspace")),
+			mcp.WithString("language", mcp.Description("Language server to read from (default: first connected)")),
+			mcp.WithString("min_severity", mcp.Description("Minimum severity to include: error, warning, information, or hint (default: hint)")),
+			mcp.WithNumber("wait_newer_than", mcp.Description("Block until a report newer than this document version is published for uri")),
+			mcp.WithNumber("wait_seconds", mcp.Description("Timeout in seconds for wait_newer_than (default 10)"), mcp.Min(1)),
+		), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			if result, ok := CheckReadyOrReturn(bridge); !ok {
+				return result, nil
+			}
+
+			uri := request.GetString("uri", "")
+
+			minSeverity, err := parseMinSeverity(request.GetString("min_severity", ""))
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			var lang types.Language
+			if override := request.GetString("language", ""); override != "" {
+				lang = types.Language(strings.ToLower(override))
+			} else if uri != "" {
+				inferred, langErr := bridge.InferLanguage(uri)
+				if langErr != nil || inferred == nil {
+					return mcp.NewToolResultError(fmt.Sprintf("failed to infer language for %s: %v (pass language=\"bsl\" to override)", uri, langErr)), nil
+				}
+				lang = *inferred
+			} else {
+				langs := bridge.GetConnectedLanguages()
+				if len(langs) == 0 {
+					return mcp.NewToolResultError("no connected language servers"), nil
+				}
+				lang = langs[0]
+			}
+
+			clients, err := bridge.GetMultiLanguageClients([]string{string(lang)})
+			if err != nil || clients[lang] == nil {
+				return mcp.NewToolResultError(fmt.Sprintf("no LSP client available for language %q", lang)), nil
+			}
+			client := clients[lang]
+
+			normalizedURI := uri
+			if normalizedURI != "" {
+				normalizedURI = bridge.NormalizeURIForLSP(normalizedURI)
+			}
+
+			if lastSeenArg := request.GetInt("wait_newer_than", -1); lastSeenArg >= 0 {
+				if normalizedURI == "" {
+					return mcp.NewToolResultError("wait_newer_than requires uri"), nil
+				}
+				waiter, ok := client.(diagnosticsWaiter)
+				if !ok {
+					return mcp.NewToolResultError("this client does not support waiting for push diagnostics"), nil
+				}
+
+				lastSeen := int32(lastSeenArg)
+				waitSeconds := request.GetInt("wait_seconds", defaultLiveDiagnosticsWaitSeconds)
+				waitCtx, cancel := context.WithTimeout(ctx, time.Duration(waitSeconds)*time.Second)
+				defer cancel()
+
+				entry, err := waiter.WaitForDiagnostics(waitCtx, normalizedURI, lastSeen)
+				if err != nil {
+					logger.Warn(fmt.Sprintf("live_diagnostics: wait for %s timed out or failed: %v", normalizedURI, err))
+					return mcp.NewToolResultText(fmt.Sprintf("No newer diagnostics for %s within %ds (still at version %d or none published yet).", normalizedURI, waitSeconds, lastSeen)), nil
+				}
+				return mcp.NewToolResultText(formatLiveDiagnostics(map[string]lsp.DiagnosticEntry{normalizedURI: entry}, minSeverity)), nil
+			}
+
+			if normalizedURI != "" {
+				return mcp.NewToolResultText(formatLiveDiagnostics(map[string]lsp.DiagnosticEntry{
+					normalizedURI: {Diagnostics: client.Diagnostics(normalizedURI)},
+				}, minSeverity)), nil
+			}
+
+			waiter, ok := client.(diagnosticsWaiter)
+			if !ok {
+				return mcp.NewToolResultError("this client does not support workspace-wide push diagnostics"), nil
+			}
+			return mcp.NewToolResultText(formatLiveDiagnostics(waiter.AllDiagnostics(), minSeverity)), nil
+		}
+}
+
+func RegisterLiveDiagnosticsTool(mcpServer ToolServer, bridge interfaces.BridgeInterface) {
+	mcpServer.AddTool(LiveDiagnosticsTool(bridge))
+}
+
+func formatLiveDiagnostics(byURI map[string]lsp.DiagnosticEntry, minSeverity protocol.DiagnosticSeverity) string {
+	uris := make([]string, 0, len(byURI))
+	for uri := range byURI {
+		uris = append(uris, uri)
+	}
+	sort.Strings(uris)
+
+	var b strings.Builder
+	total := 0
+	for _, uri := range uris {
+		filtered := filterBySeverity(byURI[uri].Diagnostics, minSeverity)
+		if len(filtered) == 0 {
+			continue
+		}
+		total += len(filtered)
+		fmt.Fprintf(&b, "%s (version %d):\n", uri, byURI[uri].Version)
+		for _, d := range filtered {
+			fmt.Fprintf(&b, "  [%s] %d:%d-%d:%d %s: %s\n",
+				diagnosticSeverityLabel(d.Severity),
+				d.Range.Start.Line, d.Range.Start.Character,
+				d.Range.End.Line, d.Range.End.Character,
+				formatDiagnosticSource(d.Source),
+				strings.TrimSpace(d.Message),
+			)
+		}
+	}
+
+	if total == 0 {
+		return "LIVE DIAGNOSTICS:\nNo diagnostics at or above the requested severity."
+	}
+
+	return fmt.Sprintf("LIVE DIAGNOSTICS (%d):\n%s", total, b.String())
+}
+
+func formatDiagnosticSource(source *string) string {
+	if source == nil || *source == "" {
+		return "lsp"
+	}
+	return *source
+}
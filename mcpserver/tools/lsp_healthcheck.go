@@ -0,0 +1,59 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	bridgepkg "rockerboo/mcp-lsp-bridge/bridge"
+	"rockerboo/mcp-lsp-bridge/interfaces"
+	"rockerboo/mcp-lsp-bridge/logger"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// LSPHealthcheckTool reports (refreshing stale or missing entries, or every
+// entry when force=true) each configured language server's advisory health:
+// detected version vs.
+// LanguageServerConfig.Posture.MinVersion, and any govulncheck findings
+// when Posture.GoModuleDir is set (see lsp.RunHealthCheck). Unlike
+// language_server_posture this never blocks a connect/warm-up decision
+// itself - it only ever degrades lsp_status's state to "degraded" (see
+// mcpserver/tools/readiness.go) and, for a known-vulnerable release,
+// makes StartWarmup refuse to run against that server.
+func LSPHealthcheckTool(bridge interfaces.BridgeInterface) (mcp.Tool, server.ToolHandlerFunc) {
+	return mcp.NewTool("lsp_healthcheck",
+			mcp.WithDescription(`Report each configured language server's advisory health: detected version vs. its configured minimum, and any govulncheck findings.
+
+USAGE: lsp_healthcheck
+       lsp_healthcheck force=true   (ignore the cache TTL and re-probe every server now)
+OUTPUT: per server - ok, warnings[], vulnerabilities[], detected_version, checked_at.
+
+NOTE: results are cached with a TTL and also refreshed periodically in the background (see bridge.MCPLSPBridge.StartHealthChecks) - this is advisory status, not a pre-flight gate like language_server_posture.`),
+			mcp.WithDestructiveHintAnnotation(false),
+			mcp.WithBoolean("force", mcp.Description("Ignore the cache TTL and re-probe every configured server now (default false: serve cached results, refreshing only stale/missing ones)")),
+		), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			b, ok := bridge.(*bridgepkg.MCPLSPBridge)
+			if !ok {
+				return mcp.NewToolResultError("lsp_healthcheck is not supported by this bridge"), nil
+			}
+
+			if request.GetBool("force", false) {
+				b.InvalidateHealthCache()
+			}
+			reports := b.CheckAllHealth()
+
+			payload, err := json.MarshalIndent(reports, "", "  ")
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("failed to marshal health reports: %v", err)), nil
+			}
+
+			logger.Debug("lsp_healthcheck: reported health for configured servers")
+			return mcp.NewToolResultText(string(payload)), nil
+		}
+}
+
+func RegisterLSPHealthcheckTool(mcpServer ToolServer, bridge interfaces.BridgeInterface) {
+	mcpServer.AddTool(LSPHealthcheckTool(bridge))
+}
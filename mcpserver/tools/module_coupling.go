@@ -0,0 +1,452 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"rockerboo/mcp-lsp-bridge/interfaces"
+	"rockerboo/mcp-lsp-bridge/logger"
+	"rockerboo/mcp-lsp-bridge/types"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/myleshyson/lsprotocol-go/protocol"
+)
+
+// moduleCouplingMaxModules bounds how many workspace files one
+// bsl_module_coupling call will walk, so a huge project can't turn one
+// request into an unbounded number of textDocument/references round trips.
+const moduleCouplingMaxModules = 300
+
+// defaultModuleCouplingTopN is how many "god modules" are reported when the
+// caller doesn't override top_n.
+const defaultModuleCouplingTopN = 10
+
+// bslExportKeyword is the BSL modifier marking a procedure/function visible
+// outside its declaring module, e.g. "Процедура ОбработатьДанные() Экспорт".
+const bslExportKeyword = "экспорт"
+
+// ModuleCoupling is one module's afferent/efferent coupling, instability
+// and cohesion, as computed by buildModuleCouplingGraph.
+type ModuleCoupling struct {
+	Module      string  `json:"module"`
+	Afferent    int     `json:"afferent_coupling"`
+	Efferent    int     `json:"efferent_coupling"`
+	Instability float64 `json:"instability"`
+	LCOM4       int     `json:"lcom4"`
+	ExportCount int     `json:"export_count"`
+}
+
+// ModuleCouplingEdge is one module-level dependency edge: From's source
+// references a symbol exported by To. Weight counts the distinct
+// reference sites that produced the edge.
+type ModuleCouplingEdge struct {
+	From   string `json:"from"`
+	To     string `json:"to"`
+	Weight int    `json:"weight"`
+}
+
+// ModuleCouplingGraph is buildModuleCouplingGraph's result.
+type ModuleCouplingGraph struct {
+	Modules        []ModuleCoupling     `json:"modules"`
+	Edges          []ModuleCouplingEdge `json:"edges"`
+	GodModules     []string             `json:"god_modules"`
+	ModulesScanned int                  `json:"modules_scanned"`
+	Truncated      bool                 `json:"truncated"`
+	TruncateReason string               `json:"truncate_reason,omitempty"`
+}
+
+// referenceCache memoizes bridge.FindSymbolReferences by declaration site:
+// buildModuleCouplingGraph's coupling pass only looks up exported symbols,
+// but re-exported or repeatedly-referenced symbols can still recur across a
+// large workspace walk.
+type referenceCache struct {
+	bridge  interfaces.BridgeInterface
+	entries map[string][]protocol.Location
+}
+
+func newReferenceCache(bridge interfaces.BridgeInterface) *referenceCache {
+	return &referenceCache{bridge: bridge, entries: make(map[string][]protocol.Location)}
+}
+
+// references resolves and caches the references of the symbol declared at
+// (uri, line, character).
+func (c *referenceCache) references(language, uri string, line, character uint32) []protocol.Location {
+	key := fmt.Sprintf("%s:%s:%d:%d", language, uri, line, character)
+	if refs, ok := c.entries[key]; ok {
+		return refs
+	}
+
+	refs, err := c.bridge.FindSymbolReferences(language, uri, line, character, false)
+	if err != nil {
+		logger.Warn(fmt.Sprintf("bsl_module_coupling: references request failed for %s: %v", key, err))
+		refs = nil
+	}
+	c.entries[key] = refs
+	return refs
+}
+
+// moduleBuildState is one module's intermediate state while
+// buildModuleCouplingGraph walks the workspace, before coupling/LCOM4 are
+// derived from it.
+type moduleBuildState struct {
+	name      string
+	uri       string
+	lines     []string
+	exported  []protocol.DocumentSymbol
+	functions []protocol.DocumentSymbol
+	variables []protocol.DocumentSymbol
+}
+
+// enumerateModuleFiles walks workspaceDir collecting up to
+// moduleCouplingMaxModules file paths matching language's default
+// extensions (see defaultTextSearchExtensions), the same extension
+// whitelist text_search falls back to.
+func enumerateModuleFiles(workspaceDir string, language types.Language) ([]string, bool, error) {
+	exts := make(map[string]struct{})
+	for _, e := range defaultTextSearchExtensions(language) {
+		exts[strings.ToLower(e)] = struct{}{}
+	}
+
+	ignoredDirs := map[string]struct{}{
+		".git": {}, ".hg": {}, ".svn": {}, ".idea": {}, ".vscode": {},
+		"node_modules": {}, "vendor": {}, "dist": {}, "build": {}, "out": {}, "target": {}, "_bin": {},
+	}
+
+	errStopWalk := errors.New("module_coupling: stop walk")
+	var files []string
+	truncated := false
+
+	walkErr := filepath.WalkDir(workspaceDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() {
+			if _, ok := ignoredDirs[strings.ToLower(d.Name())]; ok {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if _, ok := exts[strings.ToLower(filepath.Ext(d.Name()))]; !ok {
+			return nil
+		}
+		if len(files) >= moduleCouplingMaxModules {
+			truncated = true
+			return errStopWalk
+		}
+		files = append(files, path)
+		return nil
+	})
+
+	if walkErr != nil && !errors.Is(walkErr, errStopWalk) {
+		return files, truncated, walkErr
+	}
+	return files, truncated, nil
+}
+
+// moduleNameForPath turns an absolute file path into a workspace-relative
+// module name, the identity buildModuleCouplingGraph's edges and
+// coupling/LCOM4 results are keyed by.
+func moduleNameForPath(workspaceDir, path string) string {
+	rel, err := filepath.Rel(workspaceDir, path)
+	if err != nil {
+		return path
+	}
+	return filepath.ToSlash(rel)
+}
+
+// isExportedBSLSymbol reports whether sym's declaration line carries BSL's
+// "Экспорт" modifier.
+func isExportedBSLSymbol(sym protocol.DocumentSymbol, lines []string) bool {
+	line := int(sym.Range.Start.Line)
+	if line < 0 || line >= len(lines) {
+		return false
+	}
+	for _, w := range bslWordPattern.FindAllString(lines[line], -1) {
+		if strings.ToLower(w) == bslExportKeyword {
+			return true
+		}
+	}
+	return false
+}
+
+// lcom4ForModule groups a module's functions/procedures into connected
+// components via union-find: two are connected if one's body textually
+// calls the other by name, or both bodies reference a common module-level
+// variable/constant (state.variables). This mirrors
+// bslCyclomaticComplexity's lexical approach rather than issuing further
+// textDocument/references requests per private procedure, which would blow
+// up bsl_module_coupling's request count on a module with many small
+// helpers. The result - the number of components - is LCOM4; a cohesive
+// module has one component, a module doing unrelated things has several.
+func lcom4ForModule(state *moduleBuildState) int {
+	n := len(state.functions)
+	if n == 0 {
+		return 0
+	}
+
+	parent := make([]int, n)
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+	union := func(a, b int) {
+		ra, rb := find(a), find(b)
+		if ra != rb {
+			parent[ra] = rb
+		}
+	}
+
+	bodyWords := make([]map[string]bool, n)
+	for i, fn := range state.functions {
+		words := map[string]bool{}
+		for _, w := range bslWordPattern.FindAllString(functionSourceText(state.lines, fn.Range), -1) {
+			words[strings.ToLower(w)] = true
+		}
+		bodyWords[i] = words
+	}
+
+	for i := range state.functions {
+		for j := i + 1; j < n; j++ {
+			ni := strings.ToLower(state.functions[i].Name)
+			nj := strings.ToLower(state.functions[j].Name)
+			if bodyWords[i][nj] || bodyWords[j][ni] {
+				union(i, j)
+			}
+		}
+	}
+
+	for _, v := range state.variables {
+		vname := strings.ToLower(v.Name)
+		first := -1
+		for i := range state.functions {
+			if !bodyWords[i][vname] {
+				continue
+			}
+			if first == -1 {
+				first = i
+			} else {
+				union(first, i)
+			}
+		}
+	}
+
+	roots := map[int]bool{}
+	for i := range state.functions {
+		roots[find(i)] = true
+	}
+	return len(roots)
+}
+
+// buildModuleCouplingGraph is bsl_module_coupling's core: it walks the
+// first allowed directory for language's modules, collects each module's
+// exported procedures/functions, issues textDocument/references (cached by
+// referenceCache) on each to find which other modules depend on it, and
+// derives afferent/efferent coupling, instability and LCOM4 cohesion from
+// the result. topN bounds the GodModules list.
+func buildModuleCouplingGraph(bridge interfaces.BridgeInterface, language types.Language, topN int) (ModuleCouplingGraph, error) {
+	dirs := bridge.AllowedDirectories()
+	if len(dirs) == 0 {
+		return ModuleCouplingGraph{}, errors.New("bsl_module_coupling: no workspace directories configured")
+	}
+	workspaceDir := dirs[0]
+
+	paths, truncatedScan, err := enumerateModuleFiles(workspaceDir, language)
+	if err != nil {
+		return ModuleCouplingGraph{}, fmt.Errorf("bsl_module_coupling: workspace walk failed: %w", err)
+	}
+
+	modules := make(map[string]*moduleBuildState, len(paths))
+	uriToModule := make(map[string]string, len(paths))
+
+	for _, path := range paths {
+		fileUri := bridge.NormalizeURIForLSP(path)
+
+		docSymbols, err := bridge.GetDocumentSymbols(fileUri)
+		if err != nil {
+			logger.Warn(fmt.Sprintf("bsl_module_coupling: document symbols request failed for %s: %v", fileUri, err))
+			continue
+		}
+		source, err := os.ReadFile(path) // #nosec G304 -- path came from walking the configured workspace directory
+		if err != nil {
+			logger.Warn(fmt.Sprintf("bsl_module_coupling: failed to read %s: %v", path, err))
+			continue
+		}
+
+		state := &moduleBuildState{
+			name:  moduleNameForPath(workspaceDir, path),
+			uri:   fileUri,
+			lines: strings.Split(string(source), "\n"),
+		}
+
+		for _, sym := range docSymbols {
+			switch sym.Kind {
+			case protocol.SymbolKindFunction, protocol.SymbolKindMethod:
+				state.functions = append(state.functions, sym)
+				if isExportedBSLSymbol(sym, state.lines) {
+					state.exported = append(state.exported, sym)
+				}
+			case protocol.SymbolKindVariable, protocol.SymbolKindConstant:
+				state.variables = append(state.variables, sym)
+			}
+		}
+
+		modules[state.name] = state
+		uriToModule[fileUri] = state.name
+	}
+
+	coupling := make(map[string]*ModuleCoupling, len(modules))
+	for name, state := range modules {
+		coupling[name] = &ModuleCoupling{Module: name, ExportCount: len(state.exported), LCOM4: lcom4ForModule(state)}
+	}
+
+	cache := newReferenceCache(bridge)
+	edgeWeights := make(map[[2]string]int)
+
+	for name, state := range modules {
+		lang, err := bridge.InferLanguage(state.uri)
+		if err != nil {
+			logger.Warn(fmt.Sprintf("bsl_module_coupling: failed to infer language for %s: %v", state.uri, err))
+			continue
+		}
+		for _, sym := range state.exported {
+			refs := cache.references(string(*lang), state.uri, sym.SelectionRange.Start.Line, sym.SelectionRange.Start.Character)
+			for _, ref := range refs {
+				otherModule, ok := uriToModule[string(ref.Uri)]
+				if !ok || otherModule == name {
+					continue
+				}
+				edgeWeights[[2]string{otherModule, name}]++
+			}
+		}
+	}
+
+	for key := range edgeWeights {
+		from, to := key[0], key[1]
+		coupling[to].Afferent++
+		coupling[from].Efferent++
+	}
+	for _, c := range coupling {
+		if total := c.Afferent + c.Efferent; total > 0 {
+			c.Instability = float64(c.Efferent) / float64(total)
+		}
+	}
+
+	graph := ModuleCouplingGraph{ModulesScanned: len(modules)}
+	for _, c := range coupling {
+		graph.Modules = append(graph.Modules, *c)
+	}
+	sort.Slice(graph.Modules, func(i, j int) bool { return graph.Modules[i].Module < graph.Modules[j].Module })
+
+	for key, weight := range edgeWeights {
+		graph.Edges = append(graph.Edges, ModuleCouplingEdge{From: key[0], To: key[1], Weight: weight})
+	}
+	sort.Slice(graph.Edges, func(i, j int) bool {
+		if graph.Edges[i].From != graph.Edges[j].From {
+			return graph.Edges[i].From < graph.Edges[j].From
+		}
+		return graph.Edges[i].To < graph.Edges[j].To
+	})
+
+	godModules := append([]ModuleCoupling(nil), graph.Modules...)
+	sort.Slice(godModules, func(i, j int) bool {
+		ci, cj := godModules[i], godModules[j]
+		if si, sj := ci.Afferent+ci.Efferent, cj.Afferent+cj.Efferent; si != sj {
+			return si > sj
+		}
+		return ci.Module < cj.Module
+	})
+	if topN <= 0 {
+		topN = defaultModuleCouplingTopN
+	}
+	if topN > len(godModules) {
+		topN = len(godModules)
+	}
+	for _, m := range godModules[:topN] {
+		graph.GodModules = append(graph.GodModules, m.Module)
+	}
+
+	if truncatedScan {
+		graph.Truncated = true
+		graph.TruncateReason = fmt.Sprintf("module cap reached (%d)", moduleCouplingMaxModules)
+	}
+
+	return graph, nil
+}
+
+// formatModuleCouplingText renders graph for output_format="text".
+func formatModuleCouplingText(graph ModuleCouplingGraph) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "MODULE_COUPLING|modules=%d|truncated=%t\n", graph.ModulesScanned, graph.Truncated)
+	if graph.Truncated {
+		fmt.Fprintf(&sb, "TRUNCATE_REASON|%s\n", graph.TruncateReason)
+	}
+
+	sb.WriteString("\nGOD MODULES (highest Ca+Ce):\n")
+	for i, m := range graph.GodModules {
+		fmt.Fprintf(&sb, "  %d. %s\n", i+1, m)
+	}
+
+	sb.WriteString("\nMODULES:\n")
+	for _, m := range graph.Modules {
+		fmt.Fprintf(&sb, "  %s: Ca=%d Ce=%d I=%.2f LCOM4=%d exports=%d\n",
+			m.Module, m.Afferent, m.Efferent, m.Instability, m.LCOM4, m.ExportCount)
+	}
+
+	return sb.String()
+}
+
+// ModuleCouplingTool registers "bsl_module_coupling": a cross-file afferent/
+// efferent coupling analysis over a workspace's BSL modules, derived from
+// real textDocument/references lookups on every exported procedure/
+// function (see buildModuleCouplingGraph), plus an LCOM4 cohesion score per
+// module.
+func ModuleCouplingTool(bridge interfaces.BridgeInterface) (mcp.Tool, server.ToolHandlerFunc) {
+	return mcp.NewTool("bsl_module_coupling",
+			mcp.WithDescription(`Cross-file coupling analysis for BSL modules. For every module in the workspace, finds its exported ("Экспорт") procedures/functions, issues textDocument/references on each, and builds a module-level dependency graph: Ca (afferent coupling - modules that depend on this one), Ce (efferent coupling - modules this one depends on), and Instability I = Ce/(Ca+Ce). Also reports LCOM4 (lack of cohesion: connected components among a module's own procedures, grouped by shared calls/variables - 1 is cohesive, more means the module is doing unrelated things) and a top-N "god modules" list ranked by Ca+Ce.`),
+			mcp.WithDestructiveHintAnnotation(false),
+			mcp.WithString("language", mcp.Description("Language whose modules to analyze (default: \"bsl\").")),
+			mcp.WithNumber("top_n", mcp.Description("How many highest-coupled \"god modules\" to report (default: 10)."), mcp.Min(1), mcp.DefaultNumber(defaultModuleCouplingTopN)),
+			mcp.WithString("output_format", mcp.Description("\"json\" (default) or \"text\".")),
+		), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			language := types.Language(strings.TrimSpace(request.GetString("language", "")))
+			if language == "" {
+				language = types.Language("bsl")
+			}
+			topN := request.GetInt("top_n", defaultModuleCouplingTopN)
+			outputFormat := strings.ToLower(strings.TrimSpace(request.GetString("output_format", "")))
+
+			graph, err := buildModuleCouplingGraph(bridge, language, topN)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			if outputFormat == "text" {
+				return mcp.NewToolResultText(formatModuleCouplingText(graph)), nil
+			}
+
+			payload, err := json.MarshalIndent(graph, "", "  ")
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("bsl_module_coupling: failed to marshal result: %v", err)), nil
+			}
+			return mcp.NewToolResultText(string(payload)), nil
+		}
+}
+
+// RegisterModuleCouplingTool registers the bsl_module_coupling tool.
+func RegisterModuleCouplingTool(mcpServer ToolServer, bridge interfaces.BridgeInterface) {
+	mcpServer.AddTool(ModuleCouplingTool(bridge))
+}
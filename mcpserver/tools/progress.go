@@ -0,0 +1,104 @@
+package tools
+
+import (
+	"context"
+	"sync"
+
+	"rockerboo/mcp-lsp-bridge/lsp"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// progressForwarder sends best-effort MCP progress notifications
+// (notifications/progress) for a long-running LSP request, forwarding the
+// server's own begin/report/end $/progress stream instead of inventing one
+// - generalizing the textSearchProgressReporter pattern project_analysis.go
+// already uses for its local text_search scan, for any tool that hands a
+// workDoneToken to the language server. Nil-safe: no-op when the caller
+// didn't attach a progress token to the tool call, or the server can't be
+// recovered from ctx, mirroring how textSearchProgressReporter treats
+// progress as optional rather than required.
+type progressForwarder struct {
+	mcpServer *server.MCPServer
+	ctx       context.Context
+	token     any
+}
+
+func newProgressForwarder(ctx context.Context, request mcp.CallToolRequest) *progressForwarder {
+	if request.Params.Meta == nil || request.Params.Meta.ProgressToken == nil {
+		return nil
+	}
+
+	mcpServer := server.ServerFromContext(ctx)
+	if mcpServer == nil {
+		return nil
+	}
+
+	return &progressForwarder{mcpServer: mcpServer, ctx: ctx, token: request.Params.Meta.ProgressToken}
+}
+
+// forward relays one LSP $/progress event as an MCP progress notification.
+func (f *progressForwarder) forward(ev lsp.ProgressEvent) {
+	if f == nil {
+		return
+	}
+
+	payload := map[string]any{
+		"progressToken": f.token,
+		"kind":          ev.Kind,
+	}
+	if ev.Title != "" {
+		payload["title"] = ev.Title
+	}
+	if ev.Message != "" {
+		payload["message"] = ev.Message
+	}
+	if ev.Percentage != nil {
+		payload["percentage"] = *ev.Percentage
+	}
+
+	_ = f.mcpServer.SendNotificationToClient(f.ctx, "notifications/progress", payload)
+}
+
+// inFlightRequests tracks cancel funcs for requests issued by progress-aware
+// tools, keyed by the same id reported to the caller (typically the LSP
+// workDoneToken's key - see LanguageClient.WorkspaceDiagnosticWithProgress -
+// so cancel_in_flight really does "cancel by progress token" as asked), so
+// a later cancel_in_flight call can abort one from a separate tool
+// invocation (and therefore a separate goroutine) than the one that started it.
+var inFlightRequests = struct {
+	mu      sync.Mutex
+	cancels map[string]func() bool
+}{cancels: make(map[string]func() bool)}
+
+// registerInFlight records cancel under id, overwriting whatever was
+// registered there before (ids are expected to be unique per request - see
+// callers). It returns an unregister func the request's own goroutine
+// should defer, so the entry doesn't outlive the request it cancels.
+func registerInFlight(id string, cancel func() bool) (unregister func()) {
+	inFlightRequests.mu.Lock()
+	inFlightRequests.cancels[id] = cancel
+	inFlightRequests.mu.Unlock()
+
+	return func() {
+		inFlightRequests.mu.Lock()
+		delete(inFlightRequests.cancels, id)
+		inFlightRequests.mu.Unlock()
+	}
+}
+
+// cancelInFlight aborts the request registered under id, if any. found is
+// false if no request is registered under that id (already finished, or an
+// unknown id); ok is the cancel func's own report of whether cancellation
+// succeeded.
+func cancelInFlight(id string) (ok bool, found bool) {
+	inFlightRequests.mu.Lock()
+	cancel, exists := inFlightRequests.cancels[id]
+	inFlightRequests.mu.Unlock()
+
+	if !exists {
+		return false, false
+	}
+	return cancel(), true
+}
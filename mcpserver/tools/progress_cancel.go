@@ -0,0 +1,35 @@
+package tools
+
+import (
+	"rockerboo/mcp-lsp-bridge/interfaces"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// ProgressCancelTool is progress_stream's companion for aborting a token
+// instead of watching it. It reuses CancelLSPProgressTool's handler
+// verbatim (see its doc comment for the window/workDoneProgress/cancel
+// semantics) under the progress_*-prefixed name this pair of tools was
+// asked for, so it's discoverable alongside progress_stream without an
+// agent needing to know the older cancel_lsp_progress name too.
+func ProgressCancelTool(bridge interfaces.BridgeInterface) (mcp.Tool, server.ToolHandlerFunc) {
+	_, handler := CancelLSPProgressTool(bridge)
+
+	tool := mcp.NewTool("progress_cancel",
+		mcp.WithDescription(`Ask a language server to stop a long-running operation it reported via $/progress (e.g. indexing), by sending window/workDoneProgress/cancel. Equivalent to cancel_lsp_progress.
+
+USAGE: progress_cancel server="bsl" token="<token from lsp_status's activity[].token>"
+PARAMETERS: server (required), token (required)
+OUTPUT: whether the cancel notification was sent`),
+		mcp.WithDestructiveHintAnnotation(true),
+		mcp.WithString("server", mcp.Description("Language server name, as shown in lsp_status's clients[].server"), mcp.Required()),
+		mcp.WithString("token", mcp.Description("Progress token, as shown in lsp_status's activity[].token"), mcp.Required()),
+	)
+
+	return tool, handler
+}
+
+func RegisterProgressCancelTool(mcpServer ToolServer, bridge interfaces.BridgeInterface) {
+	mcpServer.AddTool(ProgressCancelTool(bridge))
+}
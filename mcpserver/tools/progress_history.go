@@ -0,0 +1,101 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	bridgepkg "rockerboo/mcp-lsp-bridge/bridge"
+	"rockerboo/mcp-lsp-bridge/interfaces"
+	"rockerboo/mcp-lsp-bridge/types"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+const defaultProgressHistorySinceSeconds = 600
+
+// ProgressHistoryTool queries one language server's retained $/progress
+// event history (see lsp.ProgressTracker.History/Stats), filtered by a
+// time window and/or a title regex - forensic visibility into what a
+// language server was doing during a slow agent session, which
+// lsp_status's activity[] can't answer once the events in question have
+// already ended.
+func ProgressHistoryTool(bridge interfaces.BridgeInterface) (mcp.Tool, server.ToolHandlerFunc) {
+	return mcp.NewTool("progress_history",
+			mcp.WithDescription(`Query one language server's retained $/progress event history by time window and/or title regex.
+
+USAGE: progress_history server="bsl" since_seconds=600 title_pattern="[Ii]ndex"
+PARAMETERS: server (required), since_seconds (optional, default 600), title_pattern (optional regex against ProgressEvent.Title), token (optional, restrict to one token as shown in lsp_status's activity[].token)
+OUTPUT: one line per retained event, oldest first, followed by a STATS summary (distinct tokens seen, currently active, avg begin-to-end duration per title).`),
+			mcp.WithDestructiveHintAnnotation(false),
+			mcp.WithString("server", mcp.Description("Language server name, as shown in lsp_status's clients[].server"), mcp.Required()),
+			mcp.WithNumber("since_seconds", mcp.Description("How far back to look, in seconds (default 600)"), mcp.Min(0)),
+			mcp.WithString("title_pattern", mcp.Description("Regex filtering events by ProgressEvent.Title; omit to match every title")),
+			mcp.WithString("token", mcp.Description("Restrict to one progress token, as shown in lsp_status's activity[].token")),
+		), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			serverName, err := request.RequireString("server")
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			sinceSeconds := request.GetInt("since_seconds", defaultProgressHistorySinceSeconds)
+			token := request.GetString("token", "")
+
+			var titleRe *regexp.Regexp
+			if pattern := request.GetString("title_pattern", ""); pattern != "" {
+				titleRe, err = regexp.Compile(pattern)
+				if err != nil {
+					return mcp.NewToolResultError(fmt.Sprintf("invalid title_pattern: %v", err)), nil
+				}
+			}
+
+			b, ok := bridge.(*bridgepkg.MCPLSPBridge)
+			if !ok {
+				return mcp.NewToolResultError("bridge does not support progress history"), nil
+			}
+
+			clients := b.ListConnectedClients()
+			client, ok := clients[types.LanguageServer(serverName)]
+			if !ok {
+				return mcp.NewToolResultError(fmt.Sprintf("no connected language server named %q", serverName)), nil
+			}
+
+			provider, ok := client.(progressProvider)
+			if !ok {
+				return mcp.NewToolResultError(fmt.Sprintf("language server %q does not support progress history", serverName)), nil
+			}
+
+			tracker := provider.Progress()
+			since := time.Now().Add(-time.Duration(sinceSeconds) * time.Second)
+
+			var lines []string
+			for _, ev := range tracker.History(since, token) {
+				if titleRe != nil && !titleRe.MatchString(ev.Title) {
+					continue
+				}
+				lines = append(lines, fmt.Sprintf("#%d %s", ev.Seq, formatProgressEvent(ev)))
+			}
+
+			stats := tracker.Stats()
+			avgParts := make([]string, 0, len(stats.AvgDurationPerTitle))
+			for title, d := range stats.AvgDurationPerTitle {
+				avgParts = append(avgParts, fmt.Sprintf("%s=%s", title, d.Round(time.Millisecond)))
+			}
+			sort.Strings(avgParts)
+			summary := fmt.Sprintf("STATS tokens_seen=%d active=%d avg_duration=[%s]", stats.TokensSeen, stats.ActiveCount, strings.Join(avgParts, " "))
+
+			if len(lines) == 0 {
+				return mcp.NewToolResultText(fmt.Sprintf("PROGRESS HISTORY %q: no events in window\n%s", serverName, summary)), nil
+			}
+
+			return mcp.NewToolResultText(fmt.Sprintf("PROGRESS HISTORY %q (%d events):\n%s\n%s", serverName, len(lines), strings.Join(lines, "\n"), summary)), nil
+		}
+}
+
+func RegisterProgressHistoryTool(mcpServer ToolServer, bridge interfaces.BridgeInterface) {
+	mcpServer.AddTool(ProgressHistoryTool(bridge))
+}
@@ -0,0 +1,147 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	bridgepkg "rockerboo/mcp-lsp-bridge/bridge"
+	"rockerboo/mcp-lsp-bridge/interfaces"
+	"rockerboo/mcp-lsp-bridge/lsp"
+	"rockerboo/mcp-lsp-bridge/types"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+const (
+	defaultProgressStreamTimeout = 30 * time.Second
+	maxProgressStreamTimeout     = 5 * time.Minute
+)
+
+// progressProvider is the subset of a language client needed to subscribe
+// to its ProgressTracker directly - see lsp.LanguageClient.Progress. Local
+// duck-typed interface for the same reason workDoneProgressCanceler (see
+// cancel_lsp_progress.go) is: the interfaces package this would otherwise
+// live on doesn't exist in this tree.
+type progressProvider interface {
+	Progress() *lsp.ProgressTracker
+}
+
+// ProgressStreamTool subscribes to one server's $/progress token and
+// accumulates its begin/report/end events into a single text report,
+// returning as soon as the token's "end" event arrives, the caller's ctx
+// is cancelled, or timeout_ms elapses - whichever comes first.
+//
+// NOTE: there's no push-based MCP transport in this tree (see
+// mcpserver.HTTPContextFunc's doc comment on that gap) for a tool call to
+// stream chunks back incrementally - this blocks for up to timeout_ms and
+// reports everything it collected in one response, rather than truly
+// streaming.
+func ProgressStreamTool(bridge interfaces.BridgeInterface) (mcp.Tool, server.ToolHandlerFunc) {
+	return mcp.NewTool("progress_stream",
+			mcp.WithDescription(`Watch one language server's $/progress token until it ends, collecting every event into a single report.
+
+USAGE: progress_stream server="bsl" token="<token from lsp_status's activity[].token>" timeout_ms=30000
+PARAMETERS: server (required), token (required), timeout_ms (optional, default 30000, max 300000)
+OUTPUT: one line per begin/report/end event observed, in order, plus whether the token ended, the call was cancelled, or it timed out.
+
+NOTE: this tool call blocks for up to timeout_ms rather than streaming live - see lsp_status for a point-in-time snapshot instead, or progress_cancel to abort the token.`),
+			mcp.WithDestructiveHintAnnotation(false),
+			mcp.WithString("server", mcp.Description("Language server name, as shown in lsp_status's clients[].server"), mcp.Required()),
+			mcp.WithString("token", mcp.Description("Progress token, as shown in lsp_status's activity[].token"), mcp.Required()),
+			mcp.WithNumber("timeout_ms", mcp.Description("Max time to wait for the token to end, in milliseconds (default 30000, max 300000)"), mcp.Min(1)),
+		), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			serverName, err := request.RequireString("server")
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			token, err := request.RequireString("token")
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			timeout := defaultProgressStreamTimeout
+			if ms := request.GetInt("timeout_ms", 0); ms > 0 {
+				timeout = time.Duration(ms) * time.Millisecond
+				if timeout > maxProgressStreamTimeout {
+					timeout = maxProgressStreamTimeout
+				}
+			}
+
+			b, ok := bridge.(*bridgepkg.MCPLSPBridge)
+			if !ok {
+				return mcp.NewToolResultError("bridge does not support progress streaming"), nil
+			}
+
+			clients := b.ListConnectedClients()
+			client, ok := clients[types.LanguageServer(serverName)]
+			if !ok {
+				return mcp.NewToolResultError(fmt.Sprintf("no connected language server named %q", serverName)), nil
+			}
+
+			provider, ok := client.(progressProvider)
+			if !ok {
+				return mcp.NewToolResultError(fmt.Sprintf("language server %q does not support progress subscription", serverName)), nil
+			}
+
+			streamCtx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+
+			events, unsubscribe := provider.Progress().Subscribe(token)
+			defer unsubscribe()
+
+			var lines []string
+			ended := false
+		loop:
+			for {
+				select {
+				case ev, chOk := <-events:
+					if !chOk {
+						break loop
+					}
+					lines = append(lines, formatProgressEvent(ev))
+					if ev.Kind == "end" {
+						ended = true
+						break loop
+					}
+				case <-streamCtx.Done():
+					break loop
+				}
+			}
+
+			status := "ended"
+			if !ended {
+				if ctx.Err() != nil {
+					status = "cancelled"
+				} else {
+					status = "timed out"
+				}
+			}
+
+			if len(lines) == 0 {
+				return mcp.NewToolResultText(fmt.Sprintf("PROGRESS %q on %q: no events observed (%s)", token, serverName, status)), nil
+			}
+
+			return mcp.NewToolResultText(fmt.Sprintf("PROGRESS %q on %q (%s):\n%s", token, serverName, status, strings.Join(lines, "\n"))), nil
+		}
+}
+
+func RegisterProgressStreamTool(mcpServer ToolServer, bridge interfaces.BridgeInterface) {
+	mcpServer.AddTool(ProgressStreamTool(bridge))
+}
+
+func formatProgressEvent(ev lsp.ProgressEvent) string {
+	parts := []string{ev.Time.Format(time.RFC3339), ev.Kind}
+	if ev.Title != "" {
+		parts = append(parts, ev.Title)
+	}
+	if ev.Message != "" {
+		parts = append(parts, ev.Message)
+	}
+	if ev.Percentage != nil {
+		parts = append(parts, fmt.Sprintf("%d%%", *ev.Percentage))
+	}
+	return strings.Join(parts, " | ")
+}
@@ -1,17 +1,26 @@
 package tools
 
 import (
+	"container/heap"
 	"context"
 	"errors"
 	"fmt"
 	"io"
 	"io/fs"
+	"math"
 	"os"
 	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"rockerboo/mcp-lsp-bridge/analysis"
 	"rockerboo/mcp-lsp-bridge/async"
+	bridgepkg "rockerboo/mcp-lsp-bridge/bridge"
 	"rockerboo/mcp-lsp-bridge/collections"
 	"rockerboo/mcp-lsp-bridge/interfaces"
 	"rockerboo/mcp-lsp-bridge/logger"
@@ -39,7 +48,7 @@ USAGE:
 - Workspace overview: analysis_type="workspace_analysis", query="entire_project"
 
 ANALYSIS TYPES:
-workspace_symbols, document_symbols, references, definitions, text_search, workspace_analysis, symbol_relationships, file_analysis, pattern_analysis
+workspace_symbols, document_symbols, references, definitions, text_search, workspace_analysis, symbol_relationships, file_analysis, pattern_analysis, lsp_lifecycle, code_actions
 
 QUICK GUIDE (what each type does + what query means):
 - workspace_symbols: find symbol candidates in the whole project. query = symbol name / substring.
@@ -47,10 +56,12 @@ QUICK GUIDE (what each type does + what query means):
 - references: find usage sites of the first matching symbol (includes declaration). query = symbol name.
 - definitions: find definition location(s) of the first matching symbol. query = symbol name.
 - text_search: search raw text across workspace files (fast fallback when LSP is not enough). query = substring.
-- file_analysis: analyze a file (structure/metrics/patterns). query = file path or file URI.
+- file_analysis: analyze a file (structure/metrics/patterns), plus its code lenses and cached diagnostics. query = file path or file URI.
 - workspace_analysis: high-level overview of the workspace. query = "entire_project" (or any placeholder).
 - symbol_relationships: analyze relationships around a symbol. query = symbol name.
 - pattern_analysis: analyze patterns across files. query = keyword/pattern.
+- lsp_lifecycle: inspect or control connected language servers. query = "status", "restart", "restart_all", "stop", or "stop_all". "restart"/"stop" act on one server, named via the server or language option. Works even when the bridge isn't ready yet, since it's how you diagnose/fix a stuck server.
+- code_actions: resolve a symbol and list the code actions (quickfix/refactor/fill_struct/organize_imports/...) available there. query = symbol name.
 
 PAGINATION:
 - offset: skip N results (default 0)
@@ -58,13 +69,47 @@ PAGINATION:
 
 OPTIONAL:
 - workspace_uri: project root URI (defaults to the first allowed directory).
+- output_format: "text" (default), "json" (single stable-schema document), or "ndjson" (one JSON record per pattern instance/relation/recommendation). Applies to file_analysis, pattern_analysis, workspace_analysis, and symbol_relationships only.
+
+TEXT_SEARCH OPTIONS (analysis_type="text_search" only):
+- mode: "substring" (default), "regex", or "word" (substring wrapped in \b...\b).
+- include/exclude: comma-separated glob patterns matched against the workspace-relative path, applied before the language's default extension filter. Setting include opts out of the default extension whitelist.
+- case_sensitive: defaults to false.
+
+LSP_LIFECYCLE OPTIONS (analysis_type="lsp_lifecycle" only):
+- server: specific configured server name to restart/stop (e.g. "bsl-language-server"). Required for "restart"/"stop" unless language is given instead.
+- language: language whose configured server(s) to restart/stop/filter status for, when server isn't given directly. A language can map to more than one server (see workspace_symbols routing); all of them are targeted.
+
+SYMBOL_RELATIONSHIPS CALL GRAPH OPTIONS (analysis_type="symbol_relationships" only):
+- max_depth: transitive call graph depth from the resolved symbol (default: 3).
+- direction: "incoming", "outgoing", or "both" (default).
+- include_external: traverse past call graph nodes outside the resolved symbol's file too (default: false; such nodes are still recorded, just not expanded).
+
+CODE_ACTIONS OPTIONS (analysis_type="code_actions" only):
+- kind: comma-separated LSP CodeActionKind(s) to filter to, e.g. "quickfix", "refactor.rewrite", "source.organizeImports", or a language-specific kind like "refactor.rewrite.fillStruct". Omit for the server's full menu at that position.
+- Actions carrying an edit get a stable action_id and a compact per-file diff preview; pass action_id to the apply_code_action tool to apply it via workspace/applyEdit.
+
+FILE_ANALYSIS OPTIONS (analysis_type="file_analysis" only):
+- complexity_threshold: McCabe cyclomatic complexity above which a function/procedure is flagged high-complexity in the REAL COMPLEXITY section (default: 15).
 
 PARAMETERS: analysis_type (required), query (required), limit (default: 20)`),
 			mcp.WithDestructiveHintAnnotation(false),
 			mcp.WithString("workspace_uri", mcp.Description("Project root URI (optional, defaults to detected project root).")),
 			mcp.WithString("query", mcp.Description("Symbol name OR file path OR text pattern (see examples above)."), mcp.Required()),
-			mcp.WithString("analysis_type", mcp.Description("Choose: workspace_symbols, document_symbols, references, definitions, text_search, workspace_analysis, symbol_relationships, file_analysis, pattern_analysis."), mcp.Required()),
+			mcp.WithString("analysis_type", mcp.Description("Choose: workspace_symbols, document_symbols, references, definitions, text_search, workspace_analysis, symbol_relationships, file_analysis, pattern_analysis, lsp_lifecycle, code_actions."), mcp.Required()),
 			mcp.WithNumber("offset", mcp.Description("Skip N results (default: 0)."), mcp.DefaultNumber(0), mcp.Min(0)),
+			mcp.WithString("mode", mcp.Description("text_search only: \"substring\" (default), \"regex\", or \"word\".")),
+			mcp.WithString("include", mcp.Description("text_search only: comma-separated glob patterns to include (workspace-relative path).")),
+			mcp.WithString("exclude", mcp.Description("text_search only: comma-separated glob patterns to exclude (workspace-relative path).")),
+			mcp.WithBoolean("case_sensitive", mcp.Description("text_search only: match case-sensitively (default: false).")),
+			mcp.WithString("server", mcp.Description("lsp_lifecycle only: specific server name to restart/stop.")),
+			mcp.WithString("language", mcp.Description("lsp_lifecycle only: language whose server(s) to restart/stop/filter status for.")),
+			mcp.WithString("output_format", mcp.Description("file_analysis/pattern_analysis/workspace_analysis/symbol_relationships only: \"text\" (default), \"json\", or \"ndjson\".")),
+			mcp.WithNumber("max_depth", mcp.Description("symbol_relationships only: transitive call graph depth (default: 3).")),
+			mcp.WithString("direction", mcp.Description("symbol_relationships only: call graph direction: \"incoming\", \"outgoing\", or \"both\" (default).")),
+			mcp.WithBoolean("include_external", mcp.Description("symbol_relationships only: traverse past call graph nodes outside the resolved symbol's file too (default: false).")),
+			mcp.WithString("kind", mcp.Description("code_actions only: comma-separated CodeActionKind(s) to filter to (e.g. \"quickfix\", \"refactor.rewrite.fillStruct\").")),
+			mcp.WithNumber("complexity_threshold", mcp.Description("file_analysis only: McCabe cyclomatic complexity above which a function is flagged high-complexity (default: 15)."), mcp.Min(1), mcp.DefaultNumber(15)),
 			mcp.WithNumber("limit", mcp.Description("Max results (default: 20)."), mcp.Min(0), mcp.Max(100), mcp.DefaultNumber(20)),
 		), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 			workspaceUri := request.GetString("workspace_uri", "")
@@ -87,10 +132,22 @@ PARAMETERS: analysis_type (required), query (required), limit (default: 20)`),
 			offset := request.GetInt("offset", 0)
 			limit := request.GetInt("limit", 20)
 
+			// lsp_lifecycle dispatches before the readiness gate: it's how an
+			// operator inspects or restarts a stuck server, so it must work
+			// even when the bridge isn't ready yet.
+			if analysisType == "lsp_lifecycle" {
+				return handleLspLifecycle(ctx, bridge, query, request)
+			}
+
 			if result, ok := CheckReadyOrReturn(bridge); !ok {
 				return result, nil
 			}
 
+			outputFormat, err := parseAnalysisOutputFormat(request)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
 			// Handle options parameter - since GetObject might not be available, create empty map for now
 			options := make(map[string]interface{})
 
@@ -162,7 +219,7 @@ PARAMETERS: analysis_type (required), query (required), limit (default: 20)`),
 
 			switch analysisType {
 			case "workspace_symbols":
-				return handleWorkspaceSymbols(lspClient, query, offset, limit, activeLanguage, &response)
+				return handleWorkspaceSymbols(bridge, lspClient, query, offset, limit, activeLanguage, &response)
 			case "document_symbols":
 				return handleDocumentSymbols(bridge, query, offset, limit, &response)
 			case "references":
@@ -170,15 +227,23 @@ PARAMETERS: analysis_type (required), query (required), limit (default: 20)`),
 			case "definitions":
 				return handleDefinitions(bridge, lspClient, query, activeLanguage, &response)
 			case "text_search":
-				return handleTextSearch(ctx, bridge, projectPath, query, offset, limit, activeLanguage, &response)
+				textSearchOpts := textSearchOptions{
+					Mode:          request.GetString("mode", "substring"),
+					Include:       request.GetString("include", ""),
+					Exclude:       request.GetString("exclude", ""),
+					CaseSensitive: request.GetBool("case_sensitive", false),
+				}
+				return handleTextSearch(ctx, request, bridge, projectPath, query, offset, limit, activeLanguage, textSearchOpts, &response)
 			case "workspace_analysis":
-				return handleWorkspaceAnalysis(bridge, clients, query, options, &response)
+				return handleWorkspaceAnalysis(bridge, clients, query, options, outputFormat, &response)
 			case "symbol_relationships":
-				return handleSymbolRelationships(bridge, clients, query, options, &response)
+				return handleSymbolRelationships(bridge, clients, query, options, outputFormat, request, &response)
 			case "file_analysis":
-				return handleFileAnalysis(bridge, clients, query, options, &response)
+				return handleFileAnalysis(ctx, bridge, clients, query, options, outputFormat, request.GetInt("complexity_threshold", defaultComplexityThreshold), &response)
 			case "pattern_analysis":
-				return handlePatternAnalysis(bridge, clients, query, options, &response)
+				return handlePatternAnalysis(bridge, clients, query, options, outputFormat, &response)
+			case "code_actions":
+				return handleCodeActions(ctx, bridge, clients, query, request.GetString("kind", ""), offset, limit, activeLanguage, &response)
 			default:
 				return mcp.NewToolResultError("Unknown analysis type: " + analysisType), nil
 			}
@@ -186,13 +251,238 @@ PARAMETERS: analysis_type (required), query (required), limit (default: 20)`),
 }
 
 type textSearchHit struct {
-	URI       string
-	Line      int
-	Character int
-	Preview   string
+	URI      string
+	Line     int
+	StartCol int
+	EndCol   int
+	Preview  string
+}
+
+// searchHitLess orders hits by (URI, Line, StartCol) ascending, giving
+// text_search a deterministic result order independent of the order its
+// worker pool happens to finish scanning files in.
+func searchHitLess(a, b textSearchHit) bool {
+	if a.URI != b.URI {
+		return a.URI < b.URI
+	}
+	if a.Line != b.Line {
+		return a.Line < b.Line
+	}
+	return a.StartCol < b.StartCol
+}
+
+// searchHitHeap is a max-heap over textSearchHit by searchHitLess, i.e. its
+// root is always the "largest" (last-sorting) hit currently held. Wrapped by
+// boundedSearchHitCollector to keep only the smallest capacity hits.
+type searchHitHeap []textSearchHit
+
+func (h searchHitHeap) Len() int           { return len(h) }
+func (h searchHitHeap) Less(i, j int) bool { return searchHitLess(h[j], h[i]) }
+func (h searchHitHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *searchHitHeap) Push(x any)        { *h = append(*h, x.(textSearchHit)) }
+func (h *searchHitHeap) Pop() any {
+	old := *h
+	n := len(old)
+	item := old[n-1]
+	*h = old[:n-1]
+	return item
+}
+
+// boundedSearchHitCollector is the concurrency-safe top-K collector
+// text_search's workers feed hits into: it keeps only the capacity
+// smallest hits by searchHitLess, regardless of the order they arrive in,
+// so the final result is the same whichever worker happened to scan which
+// file first.
+type boundedSearchHitCollector struct {
+	mu       sync.Mutex
+	capacity int
+	h        searchHitHeap
+}
+
+func newBoundedSearchHitCollector(capacity int) *boundedSearchHitCollector {
+	return &boundedSearchHitCollector{capacity: capacity}
+}
+
+func (c *boundedSearchHitCollector) offer(hit textSearchHit) {
+	if c.capacity <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.h) < c.capacity {
+		heap.Push(&c.h, hit)
+		return
+	}
+	if searchHitLess(hit, c.h[0]) {
+		heap.Pop(&c.h)
+		heap.Push(&c.h, hit)
+	}
+}
+
+// full reports whether the collector already holds capacity hits, i.e.
+// enough to satisfy offset+limit no matter what the rest of the scan turns
+// up; callers use this to cancel the remaining scan early.
+func (c *boundedSearchHitCollector) full() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.capacity > 0 && len(c.h) >= c.capacity
+}
+
+// sorted drains the collector into ascending searchHitLess order.
+func (c *boundedSearchHitCollector) sorted() []textSearchHit {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	out := make([]textSearchHit, len(c.h))
+	copy(out, c.h)
+	sort.Slice(out, func(i, j int) bool { return searchHitLess(out[i], out[j]) })
+	return out
+}
+
+// textSearchProgressReporter sends best-effort MCP progress notifications
+// (notifications/progress) while a text_search scan is running, if the
+// caller attached a progress token to the tool call. It's a no-op value
+// (nil-safe) when no token was supplied or the server can't be recovered
+// from ctx, mirroring how the rest of this package treats progress/partial
+// features as optional rather than required.
+type textSearchProgressReporter struct {
+	mcpServer *server.MCPServer
+	ctx       context.Context
+	token     any
+}
+
+func newTextSearchProgressReporter(ctx context.Context, request mcp.CallToolRequest) *textSearchProgressReporter {
+	if request.Params.Meta == nil || request.Params.Meta.ProgressToken == nil {
+		return nil
+	}
+
+	mcpServer := server.ServerFromContext(ctx)
+	if mcpServer == nil {
+		return nil
+	}
+
+	return &textSearchProgressReporter{mcpServer: mcpServer, ctx: ctx, token: request.Params.Meta.ProgressToken}
+}
+
+func (p *textSearchProgressReporter) report(scannedFiles, seenMatches int) {
+	if p == nil {
+		return
+	}
+
+	_ = p.mcpServer.SendNotificationToClient(p.ctx, "notifications/progress", map[string]any{
+		"progressToken": p.token,
+		"scannedFiles":  scannedFiles,
+		"seenMatches":   seenMatches,
+	})
+}
+
+// textSearchOptions carries the text_search-only parameters accepted by
+// ProjectAnalysisTool: mode selects how query is matched, include/exclude
+// filter which files are scanned, and case_sensitive controls matching case.
+type textSearchOptions struct {
+	Mode          string
+	Include       string
+	Exclude       string
+	CaseSensitive bool
+}
+
+const maxTextSearchMatchesPerLine = 50
+
+// compileTextSearchMatcher returns a function that finds all non-overlapping
+// match spans (byte start/end columns) of query within a line, per mode:
+//   - "substring" (default): plain strings.Index scan, case-folded unless
+//     caseSensitive.
+//   - "regex": query is compiled once with regexp.Compile and reused for
+//     every line via FindAllStringIndex, capped at maxTextSearchMatchesPerLine
+//     per line to avoid pathological patterns.
+//   - "word": like "regex", but the compiled pattern is wrapped in \b...\b.
+func compileTextSearchMatcher(mode, query string, caseSensitive bool) (func(line string) [][2]int, error) {
+	switch mode {
+	case "", "substring":
+		needle := query
+		if !caseSensitive {
+			needle = strings.ToLower(needle)
+		}
+		return func(line string) [][2]int {
+			haystack := line
+			if !caseSensitive {
+				haystack = strings.ToLower(haystack)
+			}
+			var spans [][2]int
+			pos := 0
+			for len(spans) < maxTextSearchMatchesPerLine {
+				idx := strings.Index(haystack[pos:], needle)
+				if idx < 0 {
+					break
+				}
+				start := pos + idx
+				end := start + len(needle)
+				spans = append(spans, [2]int{start, end})
+				if len(needle) == 0 {
+					break
+				}
+				pos = end
+			}
+			return spans
+		}, nil
+	case "regex", "word":
+		pattern := query
+		if mode == "word" {
+			pattern = `\b` + pattern + `\b`
+		}
+		if !caseSensitive {
+			pattern = "(?i)" + pattern
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regex pattern: %w", err)
+		}
+		return func(line string) [][2]int {
+			return re.FindAllStringIndex(line, maxTextSearchMatchesPerLine)
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown mode %q (expected substring, regex, or word)", mode)
+	}
+}
+
+// matchesAnyGlob reports whether relPath (workspace-relative, slash
+// separated) matches any comma-separated glob pattern in patterns, trying
+// both the full relative path and just the base name so a bare "*.go"
+// pattern matches at any depth.
+func matchesAnyGlob(patterns []string, relPath string) bool {
+	relPath = filepath.ToSlash(relPath)
+	base := filepath.Base(relPath)
+	for _, pattern := range patterns {
+		if pattern == "" {
+			continue
+		}
+		if ok, _ := filepath.Match(pattern, relPath); ok {
+			return true
+		}
+		if ok, _ := filepath.Match(pattern, base); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func splitGlobList(raw string) []string {
+	if strings.TrimSpace(raw) == "" {
+		return nil
+	}
+	parts := strings.Split(raw, ",")
+	out := make([]string, 0, len(parts))
+	for _, p := range parts {
+		if p = strings.TrimSpace(p); p != "" {
+			out = append(out, p)
+		}
+	}
+	return out
 }
 
-func handleTextSearch(ctx context.Context, bridge interfaces.BridgeInterface, projectPath string, query string, offset, limit int, activeLanguage types.Language, response *strings.Builder) (*mcp.CallToolResult, error) {
+func handleTextSearch(ctx context.Context, request mcp.CallToolRequest, bridge interfaces.BridgeInterface, projectPath string, query string, offset, limit int, activeLanguage types.Language, opts textSearchOptions, response *strings.Builder) (*mcp.CallToolResult, error) {
 	query = strings.TrimSpace(query)
 	if query == "" {
 		return mcp.NewToolResultError("query must be non-empty for text_search"), nil
@@ -205,10 +495,24 @@ func handleTextSearch(ctx context.Context, bridge interfaces.BridgeInterface, pr
 		offset = 0
 	}
 
-	exts := defaultTextSearchExtensions(activeLanguage)
-	extSet := make(map[string]struct{}, len(exts))
-	for _, e := range exts {
-		extSet[strings.ToLower(e)] = struct{}{}
+	matcher, err := compileTextSearchMatcher(opts.Mode, query, opts.CaseSensitive)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	includes := splitGlobList(opts.Include)
+	excludes := splitGlobList(opts.Exclude)
+
+	// include opts the caller out of the language's default extension
+	// whitelist; without it we keep the narrow default so search outside it
+	// requires an explicit opt-in.
+	var extSet map[string]struct{}
+	if len(includes) == 0 {
+		exts := defaultTextSearchExtensions(activeLanguage)
+		extSet = make(map[string]struct{}, len(exts))
+		for _, e := range exts {
+			extSet[strings.ToLower(e)] = struct{}{}
+		}
 	}
 
 	ignoredDirs := map[string]struct{}{
@@ -228,21 +532,79 @@ func handleTextSearch(ctx context.Context, bridge interfaces.BridgeInterface, pr
 
 	const maxFileSizeBytes int64 = 2 * 1024 * 1024 // 2MB guardrail
 	const maxPreviewLen = 220
+	const lineBatchSize = 2000 // lines scanned between ctx/stop checks, so a single huge file can't delay cancellation
+
+	// need is how many hits (from the start of the file walk, before
+	// offset) the collector has to hold before the page at
+	// [offset, offset+limit) is fully determined; once it holds that many,
+	// nothing later in the walk can still make the cut. limit == 0 means
+	// no hits are wanted at all, so the collector is given no capacity,
+	// but we still stop once seenMatches reaches offset since the caller
+	// already has everything they asked for.
+	need := offset + limit
+	collectorCap := need
+	if limit == 0 {
+		collectorCap = 0
+	}
+	collector := newBoundedSearchHitCollector(collectorCap)
 
-	var (
-		scannedFiles  int
-		seenMatches   int
-		returnedHits  []textSearchHit
-		truncatedScan bool
-	)
+	var scannedFiles, seenMatches int64
 
-	errStopWalk := errors.New("text_search: stop walk")
+	stopCh := make(chan struct{})
+	var stopOnce sync.Once
+	stopScan := func() { stopOnce.Do(func() { close(stopCh) }) }
+	scanStopped := func() bool {
+		select {
+		case <-stopCh:
+			return true
+		default:
+			return false
+		}
+	}
 
-	need := offset + limit
-	if limit == 0 {
-		need = offset // still scan until offset? but nothing to return → we can short-circuit.
+	progress := newTextSearchProgressReporter(ctx, request)
+	progressDone := make(chan struct{})
+	if progress != nil {
+		go func() {
+			ticker := time.NewTicker(200 * time.Millisecond)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ticker.C:
+					progress.report(int(atomic.LoadInt64(&scannedFiles)), int(atomic.LoadInt64(&seenMatches)))
+				case <-progressDone:
+					return
+				}
+			}
+		}()
+	}
+
+	// One WalkDir producer feeds a bounded channel of candidate file paths;
+	// GOMAXPROCS scanner workers drain it concurrently so a large BSL
+	// project doesn't pay for every file's open+read+scan serially on the
+	// calling goroutine.
+	numWorkers := runtime.GOMAXPROCS(0)
+	if numWorkers < 1 {
+		numWorkers = 1
+	}
+	pathCh := make(chan string, numWorkers*4)
+
+	var workers sync.WaitGroup
+	for i := 0; i < numWorkers; i++ {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			for path := range pathCh {
+				if ctx.Err() != nil || scanStopped() {
+					continue
+				}
+				scanFileForTextSearch(ctx, path, bridge, matcher, limit, need, maxPreviewLen, lineBatchSize, collector, &scannedFiles, &seenMatches, stopScan, scanStopped)
+			}
+		}()
 	}
 
+	errStopWalk := errors.New("text_search: stop walk")
+
 	walkErr := filepath.WalkDir(projectPath, func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
 			return nil
@@ -250,6 +612,9 @@ func handleTextSearch(ctx context.Context, bridge interfaces.BridgeInterface, pr
 		if ctx.Err() != nil {
 			return ctx.Err()
 		}
+		if scanStopped() {
+			return errStopWalk
+		}
 
 		if d.IsDir() {
 			if _, ok := ignoredDirs[strings.ToLower(d.Name())]; ok {
@@ -258,8 +623,21 @@ func handleTextSearch(ctx context.Context, bridge interfaces.BridgeInterface, pr
 			return nil
 		}
 
-		ext := strings.ToLower(filepath.Ext(d.Name()))
-		if len(extSet) > 0 {
+		relPath, relErr := filepath.Rel(projectPath, path)
+		if relErr != nil {
+			relPath = path
+		}
+
+		if len(excludes) > 0 && matchesAnyGlob(excludes, relPath) {
+			return nil
+		}
+
+		if len(includes) > 0 {
+			if !matchesAnyGlob(includes, relPath) {
+				return nil
+			}
+		} else if len(extSet) > 0 {
+			ext := strings.ToLower(filepath.Ext(d.Name()))
 			if _, ok := extSet[ext]; !ok {
 				return nil
 			}
@@ -273,75 +651,48 @@ func handleTextSearch(ctx context.Context, bridge interfaces.BridgeInterface, pr
 			return nil
 		}
 
-		f, openErr := os.Open(path) // #nosec G304 -- walking within user workspace
-		if openErr != nil {
-			return nil
-		}
-		defer func() { _ = f.Close() }()
-
-		scannedFiles++
-
-		// Read as bytes and split by '\n' manually to avoid Scanner token limits on huge lines.
-		data, readErr := io.ReadAll(f)
-		if readErr != nil {
+		select {
+		case pathCh <- path:
 			return nil
+		case <-stopCh:
+			return errStopWalk
+		case <-ctx.Done():
+			return ctx.Err()
 		}
+	})
 
-		// Simple line scan
-		start := 0
-		line := 0
-		for start <= len(data) {
-			end := start
-			for end < len(data) && data[end] != '\n' {
-				end++
-			}
-			// Strip trailing '\r'
-			lineBytes := data[start:end]
-			if len(lineBytes) > 0 && lineBytes[len(lineBytes)-1] == '\r' {
-				lineBytes = lineBytes[:len(lineBytes)-1]
-			}
-
-			lineStr := string(lineBytes)
-			if idx := strings.Index(lineStr, query); idx >= 0 {
-				seenMatches++
-				if seenMatches > offset && limit > 0 && len(returnedHits) < limit {
-					u := bridge.NormalizeURIForLSP(utils.FilePathToURI(path))
-					preview := strings.TrimSpace(lineStr)
-					if len(preview) > maxPreviewLen {
-						preview = preview[:maxPreviewLen] + "…"
-					}
-					returnedHits = append(returnedHits, textSearchHit{
-						URI:       u,
-						Line:      line,
-						Character: idx,
-						Preview:   preview,
-					})
-				}
+	close(pathCh)
+	workers.Wait()
+	close(progressDone)
 
-				// Early stop if we have enough matches for this page.
-				if need > 0 && seenMatches >= need && (limit == 0 || len(returnedHits) >= limit) {
-					truncatedScan = true
-					return errStopWalk
-				}
-			}
+	truncatedScan := scanStopped()
+	if walkErr != nil && !errors.Is(walkErr, errStopWalk) && !errors.Is(walkErr, context.Canceled) && !errors.Is(walkErr, context.DeadlineExceeded) {
+		logger.Warn(fmt.Sprintf("text_search: walk error: %v", walkErr))
+	}
 
-			line++
-			if end >= len(data) {
-				break
-			}
-			start = end + 1
+	var returnedHits []textSearchHit
+	if limit > 0 {
+		sortedHits := collector.sorted()
+		if len(sortedHits) > offset {
+			returnedHits = sortedHits[offset:]
 		}
+	}
 
-		return nil
-	})
+	finalScannedFiles := int(atomic.LoadInt64(&scannedFiles))
+	finalSeenMatches := int(atomic.LoadInt64(&seenMatches))
 
-	if walkErr != nil && !errors.Is(walkErr, errStopWalk) && walkErr != context.Canceled && walkErr != context.DeadlineExceeded {
-		logger.Warn(fmt.Sprintf("text_search: walk error: %v", walkErr))
+	mode := opts.Mode
+	if mode == "" {
+		mode = "substring"
 	}
 
-	fmt.Fprintf(response, "TEXT_SEARCH|%s|offset=%d|limit=%d\n", query, offset, limit)
-	fmt.Fprintf(response, "LANG=%s|EXTS=%s\n", activeLanguage, strings.Join(exts, ","))
-	fmt.Fprintf(response, "SCANNED_FILES=%d|SEEN_MATCHES=%d|RETURNED=%d|TRUNCATED=%t\n", scannedFiles, seenMatches, len(returnedHits), truncatedScan)
+	fmt.Fprintf(response, "TEXT_SEARCH|%s|mode=%s|offset=%d|limit=%d\n", query, mode, offset, limit)
+	if len(includes) > 0 || len(excludes) > 0 {
+		fmt.Fprintf(response, "LANG=%s|INCLUDE=%s|EXCLUDE=%s\n", activeLanguage, strings.Join(includes, ","), strings.Join(excludes, ","))
+	} else {
+		fmt.Fprintf(response, "LANG=%s|EXTS=%s\n", activeLanguage, strings.Join(defaultTextSearchExtensions(activeLanguage), ","))
+	}
+	fmt.Fprintf(response, "SCANNED_FILES=%d|SEEN_MATCHES=%d|RETURNED=%d|TRUNCATED=%t\n", finalScannedFiles, finalSeenMatches, len(returnedHits), truncatedScan)
 	response.WriteString("\n")
 
 	if len(returnedHits) == 0 {
@@ -350,7 +701,7 @@ func handleTextSearch(ctx context.Context, bridge interfaces.BridgeInterface, pr
 	}
 
 	for i, h := range returnedHits {
-		fmt.Fprintf(response, "%d|%d:%d|%s|%s\n", offset+i+1, h.Line, h.Character, h.URI, h.Preview)
+		fmt.Fprintf(response, "%d|%d:%d-%d|%s|%s\n", offset+i+1, h.Line, h.StartCol, h.EndCol, h.URI, h.Preview)
 	}
 
 	if truncatedScan {
@@ -361,6 +712,108 @@ func handleTextSearch(ctx context.Context, bridge interfaces.BridgeInterface, pr
 	return mcp.NewToolResultText(response.String()), nil
 }
 
+// scanFileForTextSearch is one text_search worker's unit of work: it opens
+// path, scans it line by line against matcher, and offers every match to
+// collector. ctx and stopScan/scanStopped are rechecked every
+// lineBatchSize lines (not just once per file), so a cancelled request or
+// an early-stop signal raised by a sibling worker aborts a multi-megabyte
+// file scan promptly instead of running it to completion.
+func scanFileForTextSearch(
+	ctx context.Context,
+	path string,
+	bridge interfaces.BridgeInterface,
+	matcher func(line string) [][2]int,
+	limit, need, maxPreviewLen, lineBatchSize int,
+	collector *boundedSearchHitCollector,
+	scannedFiles, seenMatches *int64,
+	stopScan func(),
+	scanStopped func() bool,
+) {
+	f, openErr := os.Open(path) // #nosec G304 -- walking within user workspace
+	if openErr != nil {
+		return
+	}
+	defer func() { _ = f.Close() }()
+
+	// Read as bytes and split by '\n' manually to avoid Scanner token limits on huge lines.
+	data, readErr := io.ReadAll(f)
+	if readErr != nil {
+		return
+	}
+
+	atomic.AddInt64(scannedFiles, 1)
+
+	start := 0
+	line := 0
+	for start <= len(data) {
+		if line%lineBatchSize == 0 && (ctx.Err() != nil || scanStopped()) {
+			return
+		}
+
+		end := start
+		for end < len(data) && data[end] != '\n' {
+			end++
+		}
+		// Strip trailing '\r'
+		lineBytes := data[start:end]
+		if len(lineBytes) > 0 && lineBytes[len(lineBytes)-1] == '\r' {
+			lineBytes = lineBytes[:len(lineBytes)-1]
+		}
+
+		lineStr := string(lineBytes)
+		for _, span := range matcher(lineStr) {
+			seen := atomic.AddInt64(seenMatches, 1)
+
+			u := bridge.NormalizeURIForLSP(utils.FilePathToURI(path))
+			preview := strings.TrimSpace(lineStr)
+			if len(preview) > maxPreviewLen {
+				preview = preview[:maxPreviewLen] + "…"
+			}
+			collector.offer(textSearchHit{
+				URI:      u,
+				Line:     line,
+				StartCol: span[0],
+				EndCol:   span[1],
+				Preview:  preview,
+			})
+
+			// Early stop once the collector holds everything the final
+			// page could need (limit > 0), or once a limit == 0 caller's
+			// offset has been reached (nothing is being returned anyway).
+			if need > 0 && (collector.full() || (limit == 0 && seen >= int64(need))) {
+				stopScan()
+				return
+			}
+		}
+
+		line++
+		if end >= len(data) {
+			break
+		}
+		start = end + 1
+	}
+}
+
+// writeFeatureRoutingHint appends a note naming any other configured server
+// that's declared (via only-features/except-features) to handle feature for
+// language, when the one we just queried doesn't. Uses
+// bridge.RouteFeatureForLanguage, so it's a no-op for bridges that don't
+// expose a command registry (e.g. test mocks) or when no alternate is
+// configured.
+func writeFeatureRoutingHint(bridge interfaces.BridgeInterface, response *strings.Builder, language types.Language, feature string) {
+	b, ok := bridge.(*bridgepkg.MCPLSPBridge)
+	if !ok {
+		return
+	}
+
+	candidates := b.RouteFeatureForLanguage(language, feature)
+	if len(candidates) == 0 {
+		return
+	}
+
+	fmt.Fprintf(response, "Configured servers for %q on %s: %v. Consider routing this request to one of them.\n", feature, language, candidates)
+}
+
 func defaultTextSearchExtensions(lang types.Language) []string {
 	switch strings.ToLower(string(lang)) {
 	case "bsl":
@@ -378,7 +831,7 @@ func defaultTextSearchExtensions(lang types.Language) []string {
 }
 
 // handleWorkspaceSymbols handles the 'workspace_symbols' analysis type
-func handleWorkspaceSymbols(lspClient types.LanguageClientInterface, query string, offset, limit int, activeLanguage types.Language, response *strings.Builder) (*mcp.CallToolResult, error) {
+func handleWorkspaceSymbols(bridge interfaces.BridgeInterface, lspClient types.LanguageClientInterface, query string, offset, limit int, activeLanguage types.Language, response *strings.Builder) (*mcp.CallToolResult, error) {
 	symbols, err := lspClient.WorkspaceSymbols(query)
 	if err != nil {
 		logger.Error("Workspace symbols query failed", fmt.Sprintf("Language: %s, Query: %s, Error: %v", activeLanguage, query, err))
@@ -390,6 +843,7 @@ func handleWorkspaceSymbols(lspClient types.LanguageClientInterface, query strin
 			fmt.Fprintf(response, "Warning: The %s language server does not support workspace symbol search.\n", activeLanguage)
 			fmt.Fprintf(response, "This is a known limitation of some language servers.\n")
 			fmt.Fprintf(response, "Try using 'document_symbols' analysis type with a specific file path instead.\n")
+			writeFeatureRoutingHint(bridge, response, activeLanguage, "workspace_symbols")
 		} else {
 			fmt.Fprintf(response, "Error: Failed to get workspace symbols for language '%s': %v\n", activeLanguage, err)
 		}
@@ -409,12 +863,17 @@ func handleWorkspaceSymbols(lspClient types.LanguageClientInterface, query strin
 	// Format pagination info
 	fmt.Fprintf(response, "%s:\n", FormatPaginationInfo(paginationResult))
 
+	var workspaceRoot string
+	if dirs := bridge.AllowedDirectories(); len(dirs) > 0 {
+		workspaceRoot = dirs[0]
+	}
+
 	for i, symbol := range paginatedSymbols {
 		switch v := symbol.Location.Value.(type) {
 		case protocol.Location:
 			// Extract filename from URI
 			uri := string(v.Uri)
-			filename := filepath.Base(strings.TrimPrefix(uri, "file://"))
+			filename := utils.DisplayPath(uri, workspaceRoot)
 
 			// Format symbol kind in a readable way
 			kindStr := symbolKindToString(symbol.Kind)
@@ -674,6 +1133,7 @@ func handleDefinitions(bridge interfaces.BridgeInterface, lspClient types.Langua
 			fmt.Fprintf(response, "Warning: The %s language server does not support workspace symbol search.\n", activeLanguage)
 			fmt.Fprintf(response, "This is a known limitation of some language servers.\n")
 			fmt.Fprintf(response, "Try using 'document_symbols' analysis type with a specific file path instead.\n")
+			writeFeatureRoutingHint(bridge, response, activeLanguage, "workspace_symbols")
 		} else {
 			fmt.Fprintf(response, "Error: Cannot find definitions - workspace symbols search failed: %v\n", err)
 		}
@@ -690,11 +1150,17 @@ func handleDefinitions(bridge interfaces.BridgeInterface, lspClient types.Langua
 		// If multiple symbols found, list them and ask for clarification
 		fmt.Fprintf(response, "Multiple symbols found matching the query '%s'.\n", query)
 		fmt.Fprintf(response, "Please clarify which one you mean:\n")
+
+		var workspaceRoot string
+		if dirs := bridge.AllowedDirectories(); len(dirs) > 0 {
+			workspaceRoot = dirs[0]
+		}
+
 		// Iterate through symbols and format them similar to workspace_symbols
 		for i, symbol := range symbols {
 			if v, ok := symbol.Location.Value.(protocol.Location); ok {
 				uri := string(v.Uri)
-				filename := filepath.Base(strings.TrimPrefix(uri, "file://"))
+				filename := utils.DisplayPath(uri, workspaceRoot)
 				kindStr := symbolKindToString(symbol.Kind)
 				startLine := v.Range.Start.Line
 				startChar := v.Range.Start.Character
@@ -741,12 +1207,17 @@ func handleDefinitions(bridge interfaces.BridgeInterface, lspClient types.Langua
 
 		fmt.Fprintf(response, "Found %d definitions for symbol '%s':\n", len(definitions), symbol.Name)
 
+		var workspaceRoot string
+		if dirs := bridge.AllowedDirectories(); len(dirs) > 0 {
+			workspaceRoot = dirs[0]
+		}
+
 		for i, def := range definitions {
 			// A definition can be LocationLink or Location (protocol.Or2[protocol.LocationLink, protocol.Location])
 			// Need to switch on the value of the Or2
 			if loc, ok := def.Value.(protocol.Location); ok {
 				defUri := string(loc.Uri)
-				defFilename := filepath.Base(strings.TrimPrefix(defUri, "file://"))
+				defFilename := utils.DisplayPath(defUri, workspaceRoot)
 				defStartLine := loc.Range.Start.Line
 				defStartChar := loc.Range.Start.Character
 				defEndLine := loc.Range.End.Line
@@ -757,7 +1228,7 @@ func handleDefinitions(bridge interfaces.BridgeInterface, lspClient types.Langua
 			} else if locLink, ok := def.Value.(protocol.LocationLink); ok {
 				// LocationLink has OriginSelectionRange and TargetUri/Range/SelectionRange
 				defUri := string(locLink.TargetUri)
-				defFilename := filepath.Base(strings.TrimPrefix(defUri, "file://"))
+				defFilename := utils.DisplayPath(defUri, workspaceRoot)
 				defStartLine := locLink.TargetRange.Start.Line
 				defStartChar := locLink.TargetRange.Start.Character
 				defEndLine := locLink.TargetRange.End.Line
@@ -776,18 +1247,46 @@ func handleDefinitions(bridge interfaces.BridgeInterface, lspClient types.Langua
 	return mcp.NewToolResultText(response.String()), nil
 }
 
+// FunctionComplexity is one function/procedure's McCabe cyclomatic
+// complexity, computed from decision-point keywords in its source range.
+type FunctionComplexity struct {
+	Name           string
+	Range          protocol.Range
+	Score          int
+	HighComplexity bool
+}
+
+// HalsteadMetrics is Halstead's software science counts for a file's whole
+// source, plus the Volume derived from them: V = N * log2(n), where n is
+// vocabulary (distinct operators + distinct operands) and N is length
+// (total operators + total operands).
+type HalsteadMetrics struct {
+	DistinctOperators int
+	DistinctOperands  int
+	TotalOperators    int
+	TotalOperands     int
+	Volume            float64
+}
+
 // ComplexityMetrics represents file complexity metrics
 type ComplexityMetrics struct {
-	TotalLines      int
-	FunctionCount   int
-	ClassCount      int
-	VariableCount   int
-	ComplexityScore float64
-	ComplexityLevel string
+	TotalLines           int
+	FunctionCount        int
+	ClassCount           int
+	VariableCount        int
+	Functions            []FunctionComplexity
+	MinComplexity        int
+	MaxComplexity        int
+	AvgComplexity        float64
+	TotalComplexity      int
+	ComplexityThreshold  int
+	Halstead             HalsteadMetrics
+	MaintainabilityIndex float64
+	DuplicationRatio     float64
 }
 
 // handleFileAnalysis handles the 'file_analysis' analysis type
-func handleFileAnalysis(bridge interfaces.BridgeInterface, clients map[types.Language]types.LanguageClientInterface, query string, options map[string]interface{}, response *strings.Builder) (*mcp.CallToolResult, error) {
+func handleFileAnalysis(ctx context.Context, bridge interfaces.BridgeInterface, clients map[types.Language]types.LanguageClientInterface, query string, options map[string]interface{}, outputFormat analysisOutputFormat, complexityThreshold int, response *strings.Builder) (*mcp.CallToolResult, error) {
 	response.WriteString("FILE ANALYSIS:\n")
 
 	// Try intelligent file context resolution first
@@ -823,6 +1322,34 @@ func handleFileAnalysis(bridge interfaces.BridgeInterface, clients map[types.Lan
 
 	fmt.Fprintf(response, "Analyzing file: %s\n\n", fileUri)
 
+	// Code lenses and cached push diagnostics aren't part of the analysis
+	// package's abstraction, so fetch them directly from the file's LSP
+	// client the same way handleCodeActions talks to a client directly.
+	var codeLenses []protocol.CodeLens
+	var diagnostics []protocol.Diagnostic
+	if lang, langErr := bridge.InferLanguage(fileUri); langErr != nil {
+		logger.Warn(fmt.Sprintf("file_analysis: failed to infer language for %s: %v", fileUri, langErr))
+	} else if client, ok := clients[*lang]; ok {
+		if lenses, err := client.CodeLens(ctx, fileUri); err != nil {
+			logger.Warn(fmt.Sprintf("file_analysis: code lens request failed: %v", err))
+		} else {
+			codeLenses = lenses
+		}
+		diagnostics = client.Diagnostics(fileUri)
+	}
+
+	// Real McCabe cyclomatic complexity, computed from this file's own
+	// document symbols and source text rather than trusted to whatever the
+	// (separately imported) analysis package's own complexity block reports.
+	var realComplexity ComplexityMetrics
+	if docSymbols, err := bridge.GetDocumentSymbols(fileUri); err != nil {
+		logger.Warn(fmt.Sprintf("file_analysis: document symbols request failed: %v", err))
+	} else if source, err := utils.ReadURI(fileUri); err != nil {
+		logger.Warn(fmt.Sprintf("file_analysis: failed to read %s: %v", fileUri, err))
+	} else {
+		realComplexity = calculateFileComplexityFromSymbols(docSymbols, string(source), complexityThreshold)
+	}
+
 	// Create analysis engine with clients and language detector
 	analyzer := analysis.NewProjectAnalyzer(clients,
 		analysis.WithLanguageDetector(bridge.InferLanguage))
@@ -845,6 +1372,67 @@ func handleFileAnalysis(bridge interfaces.BridgeInterface, clients map[types.Lan
 
 	// Format results
 	if fileData, ok := result.Data.(analysis.FileAnalysisData); ok {
+		// Promote error-severity cached diagnostics into recommendations so
+		// they surface alongside the analysis engine's own findings instead
+		// of only in the DIAGNOSTICS section below.
+		recommendations := fileData.Recommendations
+		for _, d := range diagnostics {
+			if d.Severity == nil || *d.Severity != protocol.DiagnosticSeverityError {
+				continue
+			}
+			recommendations = append(recommendations, analysis.Recommendation{
+				Priority:    "error",
+				Type:        "diagnostic",
+				Description: fmt.Sprintf("L%d: %s", d.Range.Start.Line+1, d.Message),
+				Effort:      "unknown",
+			})
+		}
+
+		switch outputFormat {
+		case analysisOutputJSON:
+			enriched := struct {
+				analysis.FileAnalysisData
+				Recommendations []analysis.Recommendation `json:"recommendations"`
+				CodeLenses      []codeLensSummary         `json:"code_lenses,omitempty"`
+				Diagnostics     []diagnosticSummary       `json:"diagnostics,omitempty"`
+				RealComplexity  ComplexityMetrics         `json:"real_complexity"`
+			}{
+				FileAnalysisData: fileData,
+				Recommendations:  recommendations,
+				CodeLenses:       summarizeCodeLenses(codeLenses),
+				Diagnostics:      summarizeDiagnostics(diagnostics),
+				RealComplexity:   realComplexity,
+			}
+			return writeAnalysisJSON("file_analysis", enriched, result.Metadata)
+		case analysisOutputNDJSON:
+			records := []any{
+				map[string]any{
+					"record_type": "summary",
+					"language":    fileData.Language,
+					"symbols":     len(fileData.Symbols),
+					"complexity":  fileData.Complexity,
+					"quality":     fileData.CodeQuality,
+				},
+			}
+			for _, relation := range fileData.CrossFileRelations {
+				records = append(records, map[string]any{"record_type": "cross_file_relation", "relation": relation})
+			}
+			for _, rec := range recommendations {
+				records = append(records, map[string]any{"record_type": "recommendation", "recommendation": rec})
+			}
+			for _, lens := range summarizeCodeLenses(codeLenses) {
+				records = append(records, map[string]any{"record_type": "code_lens", "code_lens": lens})
+			}
+			for _, diag := range summarizeDiagnostics(diagnostics) {
+				records = append(records, map[string]any{"record_type": "diagnostic", "diagnostic": diag})
+			}
+			records = append(records, map[string]any{"record_type": "real_complexity_summary", "summary": realComplexity})
+			for _, fn := range realComplexity.Functions {
+				records = append(records, map[string]any{"record_type": "real_complexity_function", "function": fn})
+			}
+			return writeAnalysisNDJSON(records)
+		}
+
 		fmt.Fprintf(response, "Language: %s\n", fileData.Language)
 		fmt.Fprintf(response, "Symbols found: %d\n\n", len(fileData.Symbols))
 
@@ -854,9 +1442,31 @@ func handleFileAnalysis(bridge interfaces.BridgeInterface, clients map[types.Lan
 		fmt.Fprintf(response, "  Total Lines: %d\n", complexity.TotalLines)
 		fmt.Fprintf(response, "  Functions: %d\n", complexity.FunctionCount)
 		fmt.Fprintf(response, "  Classes: %d\n", complexity.ClassCount)
-		fmt.Fprintf(response, "  Variables: %d\n", complexity.VariableCount)
-		fmt.Fprintf(response, "  Complexity Score: %.2f\n", complexity.ComplexityScore)
-		fmt.Fprintf(response, "  Complexity Level: %s\n\n", complexity.ComplexityLevel)
+		fmt.Fprintf(response, "  Variables: %d\n\n", complexity.VariableCount)
+
+		// Real McCabe cyclomatic complexity (see realComplexity above),
+		// superseding the naive functions*2 + classes*3 + variables score
+		// this section used to report.
+		fmt.Fprintf(response, "REAL COMPLEXITY (BSL cyclomatic, threshold=%d):\n", realComplexity.ComplexityThreshold)
+		if len(realComplexity.Functions) == 0 {
+			fmt.Fprintf(response, "  (no functions/procedures found)\n\n")
+		} else {
+			fmt.Fprintf(response, "  Min: %d  Max: %d  Avg: %.2f  Total: %d\n",
+				realComplexity.MinComplexity, realComplexity.MaxComplexity, realComplexity.AvgComplexity, realComplexity.TotalComplexity)
+			for _, fn := range realComplexity.Functions {
+				flag := ""
+				if fn.HighComplexity {
+					flag = " [HIGH COMPLEXITY]"
+				}
+				fmt.Fprintf(response, "  - %s: %d%s\n", fn.Name, fn.Score, flag)
+			}
+			response.WriteString("\n")
+		}
+		fmt.Fprintf(response, "  Halstead Volume: %.2f (operators: %d/%d distinct/total, operands: %d/%d distinct/total)\n",
+			realComplexity.Halstead.Volume, realComplexity.Halstead.DistinctOperators, realComplexity.Halstead.TotalOperators,
+			realComplexity.Halstead.DistinctOperands, realComplexity.Halstead.TotalOperands)
+		fmt.Fprintf(response, "  Maintainability Index: %.2f\n", realComplexity.MaintainabilityIndex)
+		fmt.Fprintf(response, "  Duplication Ratio (intra-file): %.2f\n\n", realComplexity.DuplicationRatio)
 
 		// Import/Export analysis
 		importExport := fileData.ImportExport
@@ -888,11 +1498,44 @@ func handleFileAnalysis(bridge interfaces.BridgeInterface, clients map[types.Lan
 
 		// Recommendations
 		fmt.Fprintf(response, "RECOMMENDATIONS:\n")
-		for _, rec := range fileData.Recommendations {
+		for _, rec := range recommendations {
 			fmt.Fprintf(response, "  - [%s] %s: %s (effort: %s)\n",
 				rec.Priority, rec.Type, rec.Description, rec.Effort)
 		}
 
+		// Code lenses
+		fmt.Fprintf(response, "\nCODE LENSES: %d\n", len(codeLenses))
+		for _, lens := range codeLenses {
+			title, command := "(unresolved)", ""
+			if lens.Command != nil {
+				title, command = lens.Command.Title, lens.Command.Command
+			}
+			fmt.Fprintf(response, "  - L%d: %s", lens.Range.Start.Line+1, title)
+			if command != "" {
+				fmt.Fprintf(response, " [%s]", command)
+			}
+			response.WriteString("\n")
+		}
+
+		// Cached push diagnostics, grouped by severity
+		fmt.Fprintf(response, "\nDIAGNOSTICS (cached): %d\n", len(diagnostics))
+		severityCounts := map[string]int{}
+		for _, d := range diagnostics {
+			severityCounts[diagnosticSeverityLabel(d.Severity)]++
+		}
+		for _, sev := range []string{"error", "warning", "information", "hint", "unknown"} {
+			if n := severityCounts[sev]; n > 0 {
+				fmt.Fprintf(response, "  %s: %d\n", sev, n)
+			}
+		}
+		for _, d := range diagnostics {
+			fmt.Fprintf(response, "  - [%s] L%d: %s", diagnosticSeverityLabel(d.Severity), d.Range.Start.Line+1, d.Message)
+			if d.Source != nil && *d.Source != "" {
+				fmt.Fprintf(response, " (%s)", *d.Source)
+			}
+			response.WriteString("\n")
+		}
+
 		// Analysis metadata
 		fmt.Fprintf(response, "\nANALYSIS METADATA:\n")
 		fmt.Fprintf(response, "  Duration: %v\n", result.Metadata.Duration)
@@ -918,8 +1561,75 @@ func handleFileAnalysis(bridge interfaces.BridgeInterface, clients map[types.Lan
 	return mcp.NewToolResultText(response.String()), nil
 }
 
+// codeLensSummary is the JSON/NDJSON shape for a code lens in file_analysis
+// output: just enough to act on without round-tripping the raw protocol
+// type (Data, in particular, is only meaningful to the server that'll
+// eventually handle codeLens/resolve or a command execution).
+type codeLensSummary struct {
+	Range   protocol.Range `json:"range"`
+	Title   string         `json:"title,omitempty"`
+	Command string         `json:"command,omitempty"`
+}
+
+func summarizeCodeLenses(lenses []protocol.CodeLens) []codeLensSummary {
+	summaries := make([]codeLensSummary, 0, len(lenses))
+	for _, lens := range lenses {
+		summary := codeLensSummary{Range: lens.Range}
+		if lens.Command != nil {
+			summary.Title = lens.Command.Title
+			summary.Command = lens.Command.Command
+		}
+		summaries = append(summaries, summary)
+	}
+	return summaries
+}
+
+// diagnosticSummary is the JSON/NDJSON shape for a cached push diagnostic.
+type diagnosticSummary struct {
+	Severity string         `json:"severity"`
+	Message  string         `json:"message"`
+	Source   string         `json:"source,omitempty"`
+	Range    protocol.Range `json:"range"`
+}
+
+func summarizeDiagnostics(diagnostics []protocol.Diagnostic) []diagnosticSummary {
+	summaries := make([]diagnosticSummary, 0, len(diagnostics))
+	for _, d := range diagnostics {
+		summary := diagnosticSummary{
+			Severity: diagnosticSeverityLabel(d.Severity),
+			Message:  d.Message,
+			Range:    d.Range,
+		}
+		if d.Source != nil {
+			summary.Source = *d.Source
+		}
+		summaries = append(summaries, summary)
+	}
+	return summaries
+}
+
+// diagnosticSeverityLabel renders a DiagnosticSeverity the way this file
+// renders every other LSP enum - as a lowercase word, not the wire integer.
+func diagnosticSeverityLabel(sev *protocol.DiagnosticSeverity) string {
+	if sev == nil {
+		return "unknown"
+	}
+	switch *sev {
+	case protocol.DiagnosticSeverityError:
+		return "error"
+	case protocol.DiagnosticSeverityWarning:
+		return "warning"
+	case protocol.DiagnosticSeverityInformation:
+		return "information"
+	case protocol.DiagnosticSeverityHint:
+		return "hint"
+	default:
+		return "unknown"
+	}
+}
+
 // handlePatternAnalysis handles the 'pattern_analysis' analysis type
-func handlePatternAnalysis(bridge interfaces.BridgeInterface, clients map[types.Language]types.LanguageClientInterface, query string, options map[string]interface{}, response *strings.Builder) (*mcp.CallToolResult, error) {
+func handlePatternAnalysis(bridge interfaces.BridgeInterface, clients map[types.Language]types.LanguageClientInterface, query string, options map[string]interface{}, outputFormat analysisOutputFormat, response *strings.Builder) (*mcp.CallToolResult, error) {
 	response.WriteString("PATTERN ANALYSIS:\n")
 
 	// Determine pattern type from options or use query as pattern type
@@ -960,6 +1670,20 @@ func handlePatternAnalysis(bridge interfaces.BridgeInterface, clients map[types.
 
 	// Format results
 	if patternData, ok := result.Data.(analysis.PatternAnalysisData); ok {
+		switch outputFormat {
+		case analysisOutputJSON:
+			return writeAnalysisJSON("pattern_analysis", patternData, result.Metadata)
+		case analysisOutputNDJSON:
+			var records []any
+			for _, instance := range patternData.PatternInstances {
+				records = append(records, map[string]any{"record_type": "pattern_instance", "instance": instance})
+			}
+			for _, violation := range patternData.Violations {
+				records = append(records, map[string]any{"record_type": "violation", "violation": violation})
+			}
+			return writeAnalysisNDJSON(records)
+		}
+
 		fmt.Fprintf(response, "Scope: %s\n", patternData.Scope)
 		fmt.Fprintf(response, "Consistency Score: %.1f%%\n\n", patternData.ConsistencyScore*100)
 
@@ -1023,7 +1747,7 @@ func handlePatternAnalysis(bridge interfaces.BridgeInterface, clients map[types.
 }
 
 // handleWorkspaceAnalysis handles the 'workspace_analysis' analysis type
-func handleWorkspaceAnalysis(bridge interfaces.BridgeInterface, clients map[types.Language]types.LanguageClientInterface, query string, options map[string]interface{}, response *strings.Builder) (*mcp.CallToolResult, error) {
+func handleWorkspaceAnalysis(bridge interfaces.BridgeInterface, clients map[types.Language]types.LanguageClientInterface, query string, options map[string]interface{}, outputFormat analysisOutputFormat, response *strings.Builder) (*mcp.CallToolResult, error) {
 	response.WriteString("WORKSPACE ANALYSIS:\n")
 
 	fmt.Fprintf(response, "Analyzing workspace for: %s\n\n", query)
@@ -1050,6 +1774,21 @@ func handleWorkspaceAnalysis(bridge interfaces.BridgeInterface, clients map[type
 
 	// Format results
 	if workspaceData, ok := result.Data.(analysis.WorkspaceAnalysisData); ok {
+		switch outputFormat {
+		case analysisOutputJSON:
+			return writeAnalysisJSON("workspace_analysis", workspaceData, result.Metadata)
+		case analysisOutputNDJSON:
+			var records []any
+			for lang, stats := range workspaceData.LanguageDistribution {
+				records = append(records, map[string]any{"record_type": "language_distribution", "language": lang, "stats": stats})
+			}
+			for _, pattern := range workspaceData.DependencyPatterns {
+				records = append(records, map[string]any{"record_type": "dependency_pattern", "pattern": pattern})
+			}
+			records = append(records, map[string]any{"record_type": "architectural_health", "health": workspaceData.ArchitecturalHealth})
+			return writeAnalysisNDJSON(records)
+		}
+
 		fmt.Fprintf(response, "LANGUAGE DISTRIBUTION:\n")
 		for lang, stats := range workspaceData.LanguageDistribution {
 			fmt.Fprintf(response, "- %s: %d files (%.1f%%), %d symbols, avg complexity: %.2f\n",
@@ -1122,7 +1861,7 @@ func handleWorkspaceAnalysis(bridge interfaces.BridgeInterface, clients map[type
 }
 
 // handleSymbolRelationships handles the 'symbol_relationships' analysis type
-func handleSymbolRelationships(bridge interfaces.BridgeInterface, clients map[types.Language]types.LanguageClientInterface, query string, options map[string]interface{}, response *strings.Builder) (*mcp.CallToolResult, error) {
+func handleSymbolRelationships(bridge interfaces.BridgeInterface, clients map[types.Language]types.LanguageClientInterface, query string, options map[string]interface{}, outputFormat analysisOutputFormat, toolRequest mcp.CallToolRequest, response *strings.Builder) (*mcp.CallToolResult, error) {
 	response.WriteString("SYMBOL RELATIONSHIPS:\n")
 
 	fmt.Fprintf(response, "Analyzing symbol: %s\n\n", query)
@@ -1149,6 +1888,61 @@ func handleSymbolRelationships(bridge interfaces.BridgeInterface, clients map[ty
 
 	// Format results
 	if symbolData, ok := result.Data.(analysis.SymbolRelationshipsData); ok {
+		maxDepth := toolRequest.GetInt("max_depth", defaultSymbolCallGraphMaxDepth)
+		if maxDepth <= 0 {
+			maxDepth = defaultSymbolCallGraphMaxDepth
+		}
+		direction := toolRequest.GetString("direction", "both")
+		if direction != "incoming" && direction != "outgoing" && direction != "both" {
+			direction = "both"
+		}
+		includeExternal := toolRequest.GetBool("include_external", false)
+
+		// Transitive call graph: a BFS over IncomingCalls/OutgoingCalls
+		// starting from the symbol's own call hierarchy item(s), going
+		// beyond the direct incoming/outgoing calls already in symbolData.
+		var callGraph *SymbolCallGraph
+		if len(symbolData.CallHierarchy) > 0 {
+			g := buildSymbolCallGraph(bridge, symbolData.CallHierarchy[0], direction, maxDepth, includeExternal)
+			callGraph = &g
+		}
+
+		switch outputFormat {
+		case analysisOutputJSON:
+			payload := map[string]any{"symbol_relationships": symbolData}
+			if callGraph != nil {
+				payload["call_graph"] = callGraph
+				payload["call_graph_dot"] = callGraphToDOT(*callGraph)
+				payload["call_graph_cytoscape"] = callGraphToCytoscape(*callGraph)
+			}
+			return writeAnalysisJSON("symbol_relationships", payload, result.Metadata)
+		case analysisOutputNDJSON:
+			records := []any{
+				map[string]any{"record_type": "symbol", "symbol": symbolData.Symbol, "language": symbolData.Language},
+			}
+			for _, call := range symbolData.IncomingCalls {
+				records = append(records, map[string]any{"record_type": "incoming_call", "call": call})
+			}
+			for _, call := range symbolData.OutgoingCalls {
+				records = append(records, map[string]any{"record_type": "outgoing_call", "call": call})
+			}
+			for _, related := range symbolData.RelatedSymbols {
+				records = append(records, map[string]any{"record_type": "related_symbol", "related": related})
+			}
+			if callGraph != nil {
+				for _, n := range callGraph.Nodes {
+					records = append(records, map[string]any{"record_type": "call_graph_node", "node": n})
+				}
+				for _, e := range callGraph.Edges {
+					records = append(records, map[string]any{"record_type": "call_graph_edge", "edge": e})
+				}
+				for _, scc := range callGraph.StronglyConnectedGroups {
+					records = append(records, map[string]any{"record_type": "call_graph_scc", "component": scc})
+				}
+			}
+			return writeAnalysisNDJSON(records)
+		}
+
 		fmt.Fprintf(response, "SYMBOL INFORMATION:\n")
 		fmt.Fprintf(response, "- Name: %s\n", symbolData.Symbol.Name)
 		fmt.Fprintf(response, "- Language: %s\n", symbolData.Language)
@@ -1210,6 +2004,37 @@ func handleSymbolRelationships(bridge interfaces.BridgeInterface, clients map[ty
 			}
 		}
 
+		// Transitive call graph
+		if callGraph != nil {
+			fmt.Fprintf(response, "\nTRANSITIVE CALL GRAPH (direction=%s, max_depth=%d):\n", direction, maxDepth)
+			fmt.Fprintf(response, "- Nodes: %d, Edges: %d\n", len(callGraph.Nodes), len(callGraph.Edges))
+			if callGraph.Truncated {
+				fmt.Fprintf(response, "- Truncated: %s\n", callGraph.TruncateReason)
+			}
+			if len(callGraph.StronglyConnectedGroups) > 0 {
+				fmt.Fprintf(response, "- Strongly-connected components (cycles): %d\n", len(callGraph.StronglyConnectedGroups))
+				for i, scc := range callGraph.StronglyConnectedGroups {
+					if i >= 5 {
+						fmt.Fprintf(response, "  ... and %d more\n", len(callGraph.StronglyConnectedGroups)-5)
+						break
+					}
+					fmt.Fprintf(response, "  - %s\n", strings.Join(scc, " -> "))
+				}
+			}
+			printed := 0
+			for _, n := range callGraph.Nodes {
+				if n.Key == callGraph.Root {
+					continue
+				}
+				if printed >= 10 {
+					fmt.Fprintf(response, "  ... and %d more nodes\n", len(callGraph.Nodes)-1-printed)
+					break
+				}
+				fmt.Fprintf(response, "  - %s (%s) depth=%d fan_in=%d fan_out=%d\n", n.Name, n.URI, n.Depth, n.FanIn, n.FanOut)
+				printed++
+			}
+		}
+
 		// Usage patterns
 		usage := symbolData.UsagePatterns
 		fmt.Fprintf(response, "\nUSAGE PATTERNS:\n")
@@ -1275,19 +2100,211 @@ func handleSymbolRelationships(bridge interfaces.BridgeInterface, clients map[ty
 	return mcp.NewToolResultText(response.String()), nil
 }
 
-// calculateFileComplexityFromSymbols calculates complexity metrics from document symbols
-func calculateFileComplexityFromSymbols(symbols []protocol.DocumentSymbol) ComplexityMetrics {
-	metrics := ComplexityMetrics{
-		TotalLines:    0,
-		FunctionCount: 0,
-		ClassCount:    0,
-		VariableCount: 0,
+// defaultComplexityThreshold is the McCabe score above which a function is
+// flagged high-complexity, mirroring gocyclo's default "over 15" convention.
+const defaultComplexityThreshold = 15
+
+// bslDecisionKeywords are the lowercase BSL keywords counted as McCabe
+// decision points, one point per occurrence: Если/ИначеЕсли (if/else-if),
+// Пока (while), Для (for and for-each - "Для Каждого" also starts with
+// "для"), Попытка/Исключение (try/except), И/ИЛИ (and/or).
+var bslDecisionKeywords = map[string]bool{
+	"если":       true,
+	"иначеесли":  true,
+	"пока":       true,
+	"для":        true,
+	"попытка":    true,
+	"исключение": true,
+	"и":          true,
+	"или":        true,
+}
+
+var bslWordPattern = regexp.MustCompile(`[\p{L}\p{N}_]+`)
+
+// bslTernaryPattern matches BSL's ?(condition, whenTrue, whenFalse) ternary.
+var bslTernaryPattern = regexp.MustCompile(`\?\s*\(`)
+
+// bslLineCommentPattern matches a BSL "//" line comment, from the "//" to
+// the end of the line (the newline itself isn't consumed, so blanking this
+// out can't merge two lines together).
+var bslLineCommentPattern = regexp.MustCompile(`//[^\n]*`)
+
+// bslCyclomaticComplexity computes a McCabe-style cyclomatic complexity
+// score for a single function/procedure's BSL source: one point for each
+// decision-point keyword (see bslDecisionKeywords) plus each ?() ternary,
+// starting from a baseline of 1 - the same convention gocyclo uses for Go.
+// String literals and "//" line comments are blanked out first (the same
+// bslStringLiteralPattern blank-out bslHalsteadMetrics uses), so a decision
+// keyword that only appears in quoted text or a comment isn't counted as a
+// real branch.
+func bslCyclomaticComplexity(body string) int {
+	score := 1
+
+	code := bslStringLiteralPattern.ReplaceAllString(body, "")
+	code = bslLineCommentPattern.ReplaceAllString(code, "")
+
+	for _, word := range bslWordPattern.FindAllString(code, -1) {
+		if bslDecisionKeywords[strings.ToLower(word)] {
+			score++
+		}
+	}
+
+	score += len(bslTernaryPattern.FindAllStringIndex(code, -1))
+
+	return score
+}
+
+// bslOperatorKeywords are the lowercase BSL keywords counted as Halstead
+// operators: conditional/loop/exception-handling structure words, the
+// logical operators И/ИЛИ/Не, and Возврат. This is a superset of
+// bslDecisionKeywords - Тогда/Иначе/КонецЕсли and friends are operators too,
+// they just aren't decision points on their own.
+var bslOperatorKeywords = map[string]bool{
+	"если":         true,
+	"тогда":        true,
+	"иначеесли":    true,
+	"иначе":        true,
+	"конецесли":    true,
+	"пока":         true,
+	"для":          true,
+	"цикл":         true,
+	"конеццикла":   true,
+	"попытка":      true,
+	"исключение":   true,
+	"конецпопытки": true,
+	"возврат":      true,
+	"и":            true,
+	"или":          true,
+	"не":           true,
+}
+
+// bslSymbolOperatorPattern matches BSL's symbolic operators: the two-char
+// forms have to come first so e.g. "<>" isn't lexed as "<" then ">".
+var bslSymbolOperatorPattern = regexp.MustCompile(`<>|<=|>=|[=<>+\-*/%]`)
+
+// bslStringLiteralPattern matches a BSL string literal, where a doubled
+// quote ("") is the escape for a literal quote inside the string.
+var bslStringLiteralPattern = regexp.MustCompile(`"(?:[^"]|"")*"`)
+
+// bslHalsteadMetrics lexes body into Halstead operators and operands:
+// operators are bslOperatorKeywords plus bslSymbolOperatorPattern's symbols,
+// operands are string literals (see bslStringLiteralPattern) plus every
+// other word (identifiers and numeric literals). Distinct counts are keyed
+// by the lowercased token text.
+func bslHalsteadMetrics(body string) HalsteadMetrics {
+	operators := map[string]int{}
+	operands := map[string]int{}
+
+	// Blank out string literals first so quoted text isn't re-lexed as
+	// identifiers or symbolic operators, but still count each literal as one
+	// operand occurrence.
+	code := bslStringLiteralPattern.ReplaceAllStringFunc(body, func(literal string) string {
+		operands[literal]++
+		return strings.Repeat(" ", len(literal))
+	})
+
+	for _, op := range bslSymbolOperatorPattern.FindAllString(code, -1) {
+		operators[op]++
+	}
+	code = bslSymbolOperatorPattern.ReplaceAllString(code, " ")
+
+	for _, word := range bslWordPattern.FindAllString(code, -1) {
+		lower := strings.ToLower(word)
+		if bslOperatorKeywords[lower] {
+			operators[lower]++
+		} else {
+			operands[lower]++
+		}
+	}
+
+	metrics := HalsteadMetrics{DistinctOperators: len(operators), DistinctOperands: len(operands)}
+	for _, n := range operators {
+		metrics.TotalOperators += n
+	}
+	for _, n := range operands {
+		metrics.TotalOperands += n
+	}
+
+	vocabulary := metrics.DistinctOperators + metrics.DistinctOperands
+	length := metrics.TotalOperators + metrics.TotalOperands
+	if vocabulary > 0 && length > 0 {
+		metrics.Volume = float64(length) * math.Log2(float64(vocabulary))
+	}
+
+	return metrics
+}
+
+// calculateMaintainabilityIndex applies the standard Maintainability Index
+// formula (Oman & Hagemeister, as used by Visual Studio and radon):
+// MI = 171 - 5.2*ln(V) - 0.23*CC - 16.2*ln(LOC), rescaled to 0-100 and
+// clamped at both ends. volume, cyclomatic and linesOfCode are each floored
+// at 1 so a trivial or empty file doesn't send ln() to -Inf.
+func calculateMaintainabilityIndex(volume float64, cyclomatic, linesOfCode int) float64 {
+	if volume < 1 {
+		volume = 1
+	}
+	if cyclomatic < 1 {
+		cyclomatic = 1
+	}
+	if linesOfCode < 1 {
+		linesOfCode = 1
+	}
+
+	mi := 171 - 5.2*math.Log(volume) - 0.23*float64(cyclomatic) - 16.2*math.Log(float64(linesOfCode))
+	mi = mi * 100 / 171
+
+	switch {
+	case mi < 0:
+		return 0
+	case mi > 100:
+		return 100
+	default:
+		return mi
+	}
+}
+
+// functionSourceText slices the lines rng spans out of a file already
+// split into lines, clamping to the file's bounds in case a server's
+// reported range runs past what we read from disk.
+func functionSourceText(lines []string, rng protocol.Range) string {
+	start, end := int(rng.Start.Line), int(rng.End.Line)
+	if start < 0 {
+		start = 0
+	}
+	if end >= len(lines) {
+		end = len(lines) - 1
 	}
+	if start > end || start >= len(lines) {
+		return ""
+	}
+
+	return strings.Join(lines[start:end+1], "\n")
+}
+
+// calculateFileComplexityFromSymbols calculates complexity metrics from
+// document symbols: structural counts from symbol kinds, a genuine McCabe
+// cyclomatic complexity per function/procedure (see bslCyclomaticComplexity)
+// and the file-level aggregate over them, plus whole-file Halstead volume
+// (bslHalsteadMetrics), a Maintainability Index derived from that volume,
+// the file's total cyclomatic complexity and its line count, and a
+// DuplicationRatio from intra-file clone detection (see duplicationRatio).
+// threshold flags any function scoring above it as HighComplexity.
+func calculateFileComplexityFromSymbols(symbols []protocol.DocumentSymbol, source string, threshold int) ComplexityMetrics {
+	metrics := ComplexityMetrics{ComplexityThreshold: threshold}
+	lines := strings.Split(source, "\n")
 
 	for _, symbol := range symbols {
 		switch symbol.Kind {
 		case protocol.SymbolKindFunction, protocol.SymbolKindMethod:
 			metrics.FunctionCount++
+
+			score := bslCyclomaticComplexity(functionSourceText(lines, symbol.Range))
+			metrics.Functions = append(metrics.Functions, FunctionComplexity{
+				Name:           symbol.Name,
+				Range:          symbol.Range,
+				Score:          score,
+				HighComplexity: score > threshold,
+			})
 		case protocol.SymbolKindClass, protocol.SymbolKindInterface:
 			metrics.ClassCount++
 		case protocol.SymbolKindVariable, protocol.SymbolKindConstant:
@@ -1298,17 +2315,293 @@ func calculateFileComplexityFromSymbols(symbols []protocol.DocumentSymbol) Compl
 		metrics.TotalLines += int(symbol.Range.End.Line - symbol.Range.Start.Line + 1)
 	}
 
-	// Calculate complexity score
-	metrics.ComplexityScore = float64(metrics.FunctionCount*2 + metrics.ClassCount*3 + metrics.VariableCount)
+	for i, fn := range metrics.Functions {
+		metrics.TotalComplexity += fn.Score
+		if i == 0 || fn.Score < metrics.MinComplexity {
+			metrics.MinComplexity = fn.Score
+		}
+		if fn.Score > metrics.MaxComplexity {
+			metrics.MaxComplexity = fn.Score
+		}
+	}
+	if len(metrics.Functions) > 0 {
+		metrics.AvgComplexity = float64(metrics.TotalComplexity) / float64(len(metrics.Functions))
+	}
 
-	// Categorize complexity level
-	if metrics.ComplexityScore < 10 {
-		metrics.ComplexityLevel = "low"
-	} else if metrics.ComplexityScore < 50 {
-		metrics.ComplexityLevel = "medium"
-	} else {
-		metrics.ComplexityLevel = "high"
+	metrics.Halstead = bslHalsteadMetrics(source)
+	cc := metrics.TotalComplexity
+	if cc == 0 {
+		cc = 1
 	}
+	metrics.MaintainabilityIndex = calculateMaintainabilityIndex(metrics.Halstead.Volume, cc, len(lines))
+	metrics.DuplicationRatio = duplicationRatio(tokenizeBSLSource(source), defaultCloneWindowSize)
 
 	return metrics
 }
+
+// handleLspLifecycle implements analysis_type="lsp_lifecycle": status/
+// restart/restart_all/stop/stop_all actions over connected language
+// servers. It requires a concrete *bridge.MCPLSPBridge, since the
+// status/control methods it uses are intentionally not part of
+// interfaces.BridgeInterface (see bridge/lifecycle.go).
+func handleLspLifecycle(ctx context.Context, bridge interfaces.BridgeInterface, query string, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	b, ok := bridge.(*bridgepkg.MCPLSPBridge)
+	if !ok {
+		return mcp.NewToolResultError("lsp_lifecycle is not supported by this bridge"), nil
+	}
+
+	action := strings.TrimSpace(query)
+	serverFilter := types.LanguageServer(strings.TrimSpace(request.GetString("server", "")))
+	languageFilter := types.Language(strings.TrimSpace(request.GetString("language", "")))
+
+	var response strings.Builder
+	fmt.Fprintf(&response, "LSP_LIFECYCLE|%s\n", action)
+
+	switch action {
+	case "status":
+		statuses := b.ClientStatus()
+		printed := 0
+		for _, s := range statuses {
+			if serverFilter != "" && s.Server != serverFilter {
+				continue
+			}
+			if languageFilter != "" && !serverMatchesLanguage(b, s.Server, languageFilter) {
+				continue
+			}
+			fmt.Fprintf(&response, "%s|connected=%t|status=%s|pid=%d|uptime=%s|pending=%d|caps=%s|last_error=%s\n",
+				s.Server, s.Connected, s.Status, s.PID, s.Uptime.Round(time.Second), s.PendingRequests, strings.Join(s.Capabilities, ","), s.LastError)
+			printed++
+		}
+		if printed == 0 {
+			response.WriteString("NO_CLIENTS\n")
+		}
+		return mcp.NewToolResultText(response.String()), nil
+
+	case "restart_all":
+		if err := b.ReloadAll(ctx); err != nil {
+			fmt.Fprintf(&response, "ERROR|%v\n", err)
+			return mcp.NewToolResultText(response.String()), nil
+		}
+		response.WriteString("OK\n")
+		return mcp.NewToolResultText(response.String()), nil
+
+	case "stop_all":
+		for _, s := range b.ClientStatus() {
+			if err := b.StopClient(s.Server); err != nil {
+				fmt.Fprintf(&response, "ERROR|%s|%v\n", s.Server, err)
+				continue
+			}
+			fmt.Fprintf(&response, "STOPPED|%s\n", s.Server)
+		}
+		return mcp.NewToolResultText(response.String()), nil
+
+	case "restart", "stop":
+		targets, err := resolveLspLifecycleTargets(b, serverFilter, languageFilter)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		for _, name := range targets {
+			var actionErr error
+			if action == "restart" {
+				actionErr = b.RestartClient(ctx, name)
+			} else {
+				actionErr = b.StopClient(name)
+			}
+			if actionErr != nil {
+				fmt.Fprintf(&response, "ERROR|%s|%v\n", name, actionErr)
+				continue
+			}
+			fmt.Fprintf(&response, "OK|%s\n", name)
+		}
+		return mcp.NewToolResultText(response.String()), nil
+
+	default:
+		return mcp.NewToolResultError(fmt.Sprintf("lsp_lifecycle: unknown action %q (expected status, restart, restart_all, stop, or stop_all)", action)), nil
+	}
+}
+
+// resolveLspLifecycleTargets picks which server name(s) restart/stop act on:
+// an explicit server name wins outright; otherwise language is expanded via
+// ServerNamesForLanguage, which may return more than one server when a
+// language is routed across multiple configured servers (see
+// bridge.RouteFeatureForLanguage). Neither given is a caller error, not a
+// silent no-op.
+func resolveLspLifecycleTargets(b *bridgepkg.MCPLSPBridge, server types.LanguageServer, language types.Language) ([]types.LanguageServer, error) {
+	if server != "" {
+		return []types.LanguageServer{server}, nil
+	}
+	if language != "" {
+		names := b.ServerNamesForLanguage(language)
+		if len(names) == 0 {
+			return nil, fmt.Errorf("lsp_lifecycle: no configured server found for language %q", language)
+		}
+		return names, nil
+	}
+	return nil, errors.New("lsp_lifecycle: restart/stop requires a \"server\" or \"language\" option naming which connected server to act on")
+}
+
+// serverMatchesLanguage reports whether server is one of the servers
+// configured for language, for lsp_lifecycle's "status" action language
+// filter.
+func serverMatchesLanguage(b *bridgepkg.MCPLSPBridge, server types.LanguageServer, language types.Language) bool {
+	for _, name := range b.ServerNamesForLanguage(language) {
+		if name == server {
+			return true
+		}
+	}
+	return false
+}
+
+// handleCodeActions handles the 'code_actions' analysis type: it resolves
+// query to a symbol via the same WorkspaceSymbols -> first match -> precise
+// position path handleReferences uses, then asks the symbol's language
+// server for textDocument/codeAction over that identifier's range, scoped
+// to kindFilter when given. Every returned action that carries a
+// WorkspaceEdit gets a stable action_id and a compact per-file diff preview
+// of its Changes map (DocumentChanges entries aren't previewed);
+// apply_code_action applies one of those edits later.
+func handleCodeActions(ctx context.Context, bridge interfaces.BridgeInterface, clients map[types.Language]types.LanguageClientInterface, query, kindFilter string, offset, limit int, activeLanguage types.Language, response *strings.Builder) (*mcp.CallToolResult, error) {
+	// Convert clients to async operations
+	ops := collections.TransformMap(clients, func(client types.LanguageClientInterface) func() ([]protocol.WorkspaceSymbol, error) {
+		return func() ([]protocol.WorkspaceSymbol, error) {
+			return client.WorkspaceSymbols(query)
+		}
+	})
+
+	// Execute symbol search across all clients in parallel
+	results, err := async.MapWithKeys(ctx, ops)
+	if err != nil {
+		fmt.Fprintf(response, "ERROR: %v\n", err)
+		return mcp.NewToolResultText(response.String()), nil
+	}
+
+	flattened := utils.FlattenKeyedResults(results)
+	allSymbols := flattened.Values
+	for _, err := range flattened.Errors {
+		logger.Warn(fmt.Sprintf("Workspace symbols search failed: %v", err))
+	}
+
+	if len(allSymbols) == 0 {
+		fmt.Fprintf(response, "NO_SYMBOL: %s\n", query)
+		return mcp.NewToolResultText(response.String()), nil
+	}
+
+	// Use the first symbol found, same as handleReferences/handleDefinitions
+	symbol := allSymbols[0]
+	loc, ok := symbol.Location.Value.(protocol.Location)
+	if !ok {
+		fmt.Fprintf(response, "UNSUPPORTED_FORMAT: %T\n", symbol.Location.Value)
+		return mcp.NewToolResultText(response.String()), nil
+	}
+
+	uri := string(loc.Uri)
+	line := loc.Range.Start.Line
+	character := loc.Range.Start.Character
+	preciseChar := FindPreciseCharacterPosition(bridge, uri, line, character, symbol.Name)
+
+	client, ok := clients[activeLanguage]
+	if !ok {
+		fmt.Fprintf(response, "ERROR: no LSP client for language %q\n", activeLanguage)
+		return mcp.NewToolResultText(response.String()), nil
+	}
+
+	only := parseCodeActionKinds(kindFilter)
+
+	startChar := uint32(preciseChar)
+	endChar := startChar + uint32(len([]rune(symbol.Name)))
+
+	actions, err := client.CodeActions(ctx, uri, line, startChar, line, endChar, only)
+	if err != nil {
+		fmt.Fprintf(response, "ERROR: %v\n", err)
+		return mcp.NewToolResultText(response.String()), nil
+	}
+
+	if len(actions) == 0 {
+		fmt.Fprintf(response, "NO_ACTIONS: %s\n", symbol.Name)
+		return mcp.NewToolResultText(response.String()), nil
+	}
+
+	totalCount := len(actions)
+	if offset >= totalCount {
+		fmt.Fprintf(response, "OFFSET_EXCEEDED: %d >= %d\n", offset, totalCount)
+		return mcp.NewToolResultText(response.String()), nil
+	}
+	end := min(offset+limit, totalCount)
+	paginatedActions := actions[offset:end]
+
+	fmt.Fprintf(response, "CODE_ACTIONS|%s|%d|%d|%d\n", symbol.Name, offset, len(paginatedActions), totalCount)
+
+	for i, action := range paginatedActions {
+		kind := ""
+		if action.Kind != nil {
+			kind = string(*action.Kind)
+		}
+		fmt.Fprintf(response, "%d. %s (%s)\n", offset+i+1, action.Title, kind)
+
+		if action.Edit == nil {
+			continue
+		}
+
+		actionID := codeActionID(uri, protocol.Range{Start: protocol.Position{Line: line, Character: startChar}, End: protocol.Position{Line: line, Character: endChar}}, action.Title, derefCodeActionKind(action.Kind))
+		globalCodeActionCache.store(actionID, cachedCodeAction{Language: activeLanguage, URI: uri, Title: action.Title, Kind: derefCodeActionKind(action.Kind), Edit: *action.Edit})
+
+		fmt.Fprintf(response, "\taction_id: %s\n", actionID)
+
+		for editURI, edits := range action.Edit.Changes {
+			current, readErr := readWorkspaceEditBaseline(bridge, string(editURI))
+			if readErr != nil {
+				fmt.Fprintf(response, "\t%s: (could not read current content: %v)\n", editURI, readErr)
+				continue
+			}
+
+			preview, _ := codeActionDiffPreview(current, edits)
+			fmt.Fprintf(response, "\t%s:\n", editURI)
+			for _, diffLine := range strings.Split(strings.TrimRight(preview, "\n"), "\n") {
+				fmt.Fprintf(response, "\t%s\n", diffLine)
+			}
+		}
+	}
+
+	if end < totalCount {
+		fmt.Fprintf(response, "MORE|%d\n", totalCount-end)
+	}
+
+	return mcp.NewToolResultText(response.String()), nil
+}
+
+// parseCodeActionKinds splits raw's comma-separated CodeActionKind list
+// into CodeActionContext.Only, returning nil (no filter) for an empty
+// string.
+func parseCodeActionKinds(raw string) []protocol.CodeActionKind {
+	parts := splitGlobList(raw)
+	if len(parts) == 0 {
+		return nil
+	}
+	kinds := make([]protocol.CodeActionKind, 0, len(parts))
+	for _, p := range parts {
+		kinds = append(kinds, protocol.CodeActionKind(p))
+	}
+	return kinds
+}
+
+// derefCodeActionKind returns "" for a nil optional Kind, the dereferenced
+// value otherwise.
+func derefCodeActionKind(kind *protocol.CodeActionKind) protocol.CodeActionKind {
+	if kind == nil {
+		return ""
+	}
+	return *kind
+}
+
+// readWorkspaceEditBaseline reads the current content of uri so a code
+// action's WorkspaceEdit can be diffed against it. Always reads the file
+// straight off disk, same as text_search does, since not every
+// LanguageClientInterface implementation exposes an open-buffer lookup.
+func readWorkspaceEditBaseline(bridge interfaces.BridgeInterface, uri string) (string, error) {
+	path := utils.URIToFilePath(bridge.NormalizeURIForLSP(uri))
+	content, err := os.ReadFile(path) // #nosec G304 -- reading within user workspace
+	if err != nil {
+		return "", err
+	}
+	return string(content), nil
+}
@@ -0,0 +1,142 @@
+package tools
+
+import (
+	"math"
+	"testing"
+)
+
+// withinEpsilon reports whether got is within eps of want, for the
+// floating-point comparisons below.
+func withinEpsilon(got, want, eps float64) bool {
+	return math.Abs(got-want) <= eps
+}
+
+func TestBslHalsteadMetrics(t *testing.T) {
+	// Hand-computed by lexing this fixture's operators (если, тогда, иначе,
+	// конецесли, >, =, =, *) and operands (Сумма x2, 0 x2, Результат x2, 2):
+	// distinct operators = 7 (4 keywords + 3 symbols), distinct operands = 4,
+	// total operators = 8, total operands = 7.
+	// n (vocabulary) = 11, N (length) = 15, V = 15 * log2(11) ~= 51.8915.
+	source := `Если Сумма > 0 Тогда
+    Результат = Сумма * 2;
+Иначе
+    Результат = 0;
+КонецЕсли;`
+
+	got := bslHalsteadMetrics(source)
+
+	if got.DistinctOperators != 7 {
+		t.Errorf("DistinctOperators = %d, want 7", got.DistinctOperators)
+	}
+	if got.DistinctOperands != 4 {
+		t.Errorf("DistinctOperands = %d, want 4", got.DistinctOperands)
+	}
+	if got.TotalOperators != 8 {
+		t.Errorf("TotalOperators = %d, want 8", got.TotalOperators)
+	}
+	if got.TotalOperands != 7 {
+		t.Errorf("TotalOperands = %d, want 7", got.TotalOperands)
+	}
+	wantVolume := 15 * math.Log2(11)
+	if !withinEpsilon(got.Volume, wantVolume, 0.01) {
+		t.Errorf("Volume = %.4f, want %.4f", got.Volume, wantVolume)
+	}
+}
+
+func TestBslHalsteadMetrics_StringLiteralIsOneOperand(t *testing.T) {
+	// The quoted text "Если > 0" looks like keywords/operators, but as a
+	// string literal it must be lexed as a single operand occurrence, not
+	// re-parsed as code.
+	source := `Результат = "Если > 0";`
+
+	got := bslHalsteadMetrics(source)
+
+	if got.TotalOperands != 2 { // the string literal, plus "результат"
+		t.Errorf("TotalOperands = %d, want 2", got.TotalOperands)
+	}
+	if got.TotalOperators != 1 { // just "="
+		t.Errorf("TotalOperators = %d, want 1", got.TotalOperators)
+	}
+}
+
+func TestBslCyclomaticComplexity_StringLiteralDecisionWordsDontCount(t *testing.T) {
+	// Every "real" branch keyword here is inside a string literal - none of
+	// it is actual code - so this function has no branching at all and
+	// should score the baseline 1, not 1 + one per occurrence of
+	// "Если"/"или"/"для" etc. found inside the quoted text.
+	source := `Сообщить("Если вы хотите продолжить, нажмите Да или ИЛИ Отмена для прерывания");`
+
+	if got := bslCyclomaticComplexity(source); got != 1 {
+		t.Errorf("bslCyclomaticComplexity = %d, want 1 (no real decision points)", got)
+	}
+}
+
+func TestBslCyclomaticComplexity_LineCommentDecisionWordsDontCount(t *testing.T) {
+	source := `Результат = 0; // Если это не так, то Пока не готово
+Результат = 1;`
+
+	if got := bslCyclomaticComplexity(source); got != 1 {
+		t.Errorf("bslCyclomaticComplexity = %d, want 1 (decision words only appear in a // comment)", got)
+	}
+}
+
+func TestBslCyclomaticComplexity_RealBranchesStillCount(t *testing.T) {
+	source := `Если Сумма > 0 Тогда
+    Результат = Сумма;
+ИначеЕсли Сумма < 0 Тогда
+    Результат = 0;
+КонецЕсли;`
+
+	// Baseline 1 + "если" + "иначеесли" = 3.
+	if got := bslCyclomaticComplexity(source); got != 3 {
+		t.Errorf("bslCyclomaticComplexity = %d, want 3", got)
+	}
+}
+
+func TestCalculateMaintainabilityIndex(t *testing.T) {
+	tests := []struct {
+		name       string
+		volume     float64
+		cyclomatic int
+		loc        int
+		want       float64
+	}{
+		{
+			// MI = 171 - 5.2*ln(100) - 0.23*5 - 16.2*ln(20), rescaled *100/171.
+			// = (171 - 23.94688 - 1.15 - 48.53086) * 100/171 ~= 56.9428.
+			name:       "typical file",
+			volume:     100,
+			cyclomatic: 5,
+			loc:        20,
+			want:       56.9428,
+		},
+		{
+			// Deliberately pathological inputs push the raw formula well
+			// below zero; the result must clamp to 0, not go negative.
+			name:       "clamps at zero",
+			volume:     1000,
+			cyclomatic: 1000,
+			loc:        1000,
+			want:       0,
+		},
+		{
+			// Zero/negative inputs are floored at 1 before taking ln(), so a
+			// trivially small file still produces a finite, near-100 score.
+			name:       "floors degenerate inputs",
+			volume:     0,
+			cyclomatic: 0,
+			loc:        0,
+			want:       99.8655,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := calculateMaintainabilityIndex(tt.volume, tt.cyclomatic, tt.loc)
+			if !withinEpsilon(got, tt.want, 0.01) {
+				t.Errorf("calculateMaintainabilityIndex(%v, %v, %v) = %.4f, want %.4f",
+					tt.volume, tt.cyclomatic, tt.loc, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,225 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"rockerboo/mcp-lsp-bridge/interfaces"
+	"rockerboo/mcp-lsp-bridge/logger"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/myleshyson/lsprotocol-go/protocol"
+)
+
+// ReachabilityEntryPoint is one BSL entry point that seeded the scan,
+// along with how many previously-unvisited symbols its outgoing call tree
+// newly reached.
+type ReachabilityEntryPoint struct {
+	Name      string `json:"name"`
+	Pattern   string `json:"pattern"`
+	URI       string `json:"uri"`
+	Line      uint32 `json:"line"`
+	Character uint32 `json:"character"`
+	Reached   int    `json:"reached"`
+}
+
+// ReachabilitySymbol is a workspace function/method symbol that no entry
+// point's outgoing call tree reached - a dead-code candidate.
+type ReachabilitySymbol struct {
+	Name      string `json:"name"`
+	Kind      string `json:"kind"`
+	URI       string `json:"uri"`
+	Line      uint32 `json:"line"`
+	Character uint32 `json:"character"`
+}
+
+// ReachabilityResult is the complete result of a workspace-wide
+// reachability scan rooted at every known BSL entry point.
+type ReachabilityResult struct {
+	EntryPoints        []ReachabilityEntryPoint `json:"entry_points"`
+	TotalReachable     int                      `json:"total_reachable"`
+	TotalFunctions     int                      `json:"total_function_symbols"`
+	UnreachableSymbols []ReachabilitySymbol     `json:"unreachable_symbols"`
+	Truncated          bool                     `json:"truncated"`
+	TruncateReason     string                   `json:"truncate_reason,omitempty"`
+	ElapsedMs          int64                    `json:"elapsed_ms"`
+}
+
+// RegisterReachabilityAnalysisTool registers the reachability_analysis tool.
+func RegisterReachabilityAnalysisTool(mcpServer ToolServer, bridge interfaces.BridgeInterface) {
+	mcpServer.AddTool(ReachabilityAnalysisTool(bridge))
+}
+
+// ReachabilityAnalysisTool builds "reachability_analysis": the workspace-wide
+// companion to call_graph.go's single-position CallGraphTool. Instead of
+// tracing from one cursor position, it scans every workspace symbol for a
+// name matching a known BSL entry point (see bslEntryPoints/isEntryPoint),
+// treats each match as a root, and runs buildOutgoingTree for all of them
+// against one shared callGraphBuilder.visited set so overlapping call
+// trees are only counted once. The result is the union of everything
+// reachable from an entry point plus the function/method symbols that
+// aren't - candidates for dead code or a missing entry-point pattern.
+func ReachabilityAnalysisTool(bridge interfaces.BridgeInterface) (mcp.Tool, server.ToolHandlerFunc) {
+	return mcp.NewTool("reachability_analysis",
+			mcp.WithDescription(`Workspace-wide reachability / dead-code analysis seeded from BSL entry points.
+
+Scans every workspace symbol for a name matching a known BSL entry point (event handlers, commands, scheduled jobs - see call_graph's entry point list), then traces the outgoing call tree from each one, sharing a single visited set so overlapping call trees aren't double counted.
+
+EXCELLENT for:
+- Finding dead code: functions no entry point's call tree reaches
+- Sanity-checking entry point coverage before a refactor
+
+Parameters:
+- depth_down: How deep to trace callees from each entry point (default: 5, 0 = unlimited up to hard limit)
+- max_nodes: Maximum nodes to collect across all entry points combined (default: 100, 0 = unlimited up to 500)
+
+Output includes:
+- Per-entry-point coverage (how many new symbols each one reached)
+- The total reachable-symbol count
+- unreachable_symbols: function/method symbols no entry point reached`),
+			mcp.WithDestructiveHintAnnotation(false),
+			mcp.WithNumber("depth_down", mcp.Description("Max depth for outgoing calls from each entry point (default: 5, 0 = unlimited)")),
+			mcp.WithNumber("max_nodes", mcp.Description("Max total nodes across all entry points (default: 100, 0 = unlimited, hard limit: 500)")),
+		), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			startTime := time.Now()
+
+			depthDown := DefaultDepthDown
+			if val, err := request.RequireInt("depth_down"); err == nil {
+				depthDown = val
+				if depthDown == 0 {
+					depthDown = HardLimitNodes
+				}
+			}
+
+			maxNodes := DefaultMaxNodes
+			if val, err := request.RequireInt("max_nodes"); err == nil {
+				maxNodes = val
+				if maxNodes == 0 || maxNodes > HardLimitNodes {
+					maxNodes = HardLimitNodes
+				}
+			}
+
+			if result, ok := CheckReadyOrReturn(bridge); !ok {
+				return result, nil
+			}
+
+			timeoutCtx, cancel := context.WithTimeout(ctx, TimeoutSeconds*time.Second)
+			defer cancel()
+
+			symbols, err := bridge.WorkspaceSymbols("")
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("Failed to list workspace symbols: %v", err)), nil
+			}
+
+			funcSymbols := make([]protocol.WorkspaceSymbol, 0, len(symbols))
+			roots := make([]protocol.WorkspaceSymbol, 0)
+			rootPatterns := make(map[string]string)
+			for _, sym := range symbols {
+				if sym.Kind != protocol.SymbolKindFunction && sym.Kind != protocol.SymbolKindMethod {
+					continue
+				}
+				funcSymbols = append(funcSymbols, sym)
+				if matched, pattern := isEntryPoint(bridge, sym.Name, symbolKindToString(sym.Kind)); matched {
+					roots = append(roots, sym)
+					rootPatterns[sym.Name] = pattern
+				}
+			}
+
+			builder := &callGraphBuilder{
+				bridge:    bridge,
+				visited:   make(map[string]string),
+				maxNodes:  maxNodes,
+				depthDown: depthDown,
+				ctx:       timeoutCtx,
+			}
+
+			entryPoints := make([]ReachabilityEntryPoint, 0, len(roots))
+
+			for _, root := range roots {
+				loc, ok := root.Location.Value.(protocol.Location)
+				if !ok {
+					continue
+				}
+
+				select {
+				case <-timeoutCtx.Done():
+					builder.setTruncated("timeout after 60 seconds")
+				default:
+				}
+
+				prepItems, err := bridge.PrepareCallHierarchy(string(loc.Uri), loc.Range.Start.Line, loc.Range.Start.Character)
+				if err != nil || len(prepItems) == 0 {
+					logger.Warn(fmt.Sprintf("reachability_analysis: no call hierarchy item for entry point %q: %v", root.Name, err))
+					continue
+				}
+				rootItem := prepItems[0]
+				rootKey := fmt.Sprintf("%s:%d:%d", rootItem.Uri, rootItem.Range.Start.Line, rootItem.Range.Start.Character)
+
+				builder.visitedMu.Lock()
+				before := len(builder.visited)
+				builder.visited[rootKey] = ""
+				builder.visitedMu.Unlock()
+
+				builder.buildOutgoingTree(&rootItem, 1)
+
+				builder.visitedMu.RLock()
+				after := len(builder.visited)
+				builder.visitedMu.RUnlock()
+
+				entryPoints = append(entryPoints, ReachabilityEntryPoint{
+					Name:      root.Name,
+					Pattern:   rootPatterns[root.Name],
+					URI:       string(loc.Uri),
+					Line:      loc.Range.Start.Line,
+					Character: loc.Range.Start.Character,
+					Reached:   after - before,
+				})
+			}
+
+			builder.visitedMu.RLock()
+			reachable := make(map[string]bool, len(builder.visited))
+			for k := range builder.visited {
+				reachable[k] = true
+			}
+			builder.visitedMu.RUnlock()
+
+			unreachable := make([]ReachabilitySymbol, 0)
+			for _, sym := range funcSymbols {
+				loc, ok := sym.Location.Value.(protocol.Location)
+				if !ok {
+					continue
+				}
+				key := fmt.Sprintf("%s:%d:%d", loc.Uri, loc.Range.Start.Line, loc.Range.Start.Character)
+				if reachable[key] {
+					continue
+				}
+				unreachable = append(unreachable, ReachabilitySymbol{
+					Name:      sym.Name,
+					Kind:      symbolKindToString(sym.Kind),
+					URI:       string(loc.Uri),
+					Line:      loc.Range.Start.Line,
+					Character: loc.Range.Start.Character,
+				})
+			}
+
+			result := &ReachabilityResult{
+				EntryPoints:        entryPoints,
+				TotalReachable:     len(reachable),
+				TotalFunctions:     len(funcSymbols),
+				UnreachableSymbols: unreachable,
+				Truncated:          builder.truncated,
+				TruncateReason:     builder.truncateReason,
+				ElapsedMs:          time.Since(startTime).Milliseconds(),
+			}
+
+			payload, err := json.MarshalIndent(result, "", "  ")
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("reachability_analysis: failed to marshal result: %v", err)), nil
+			}
+
+			return mcp.NewToolResultText(string(payload)), nil
+		}
+}
@@ -1,6 +1,7 @@
 package tools
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"sort"
@@ -23,6 +24,14 @@ type LSPActivity struct {
 	Message     string  `json:"message,omitempty"`
 	Percentage  *uint32 `json:"percentage,omitempty"`
 	Cancellable *bool   `json:"cancellable,omitempty"`
+	// Origin is the resolved caller (see ClientIdentity) that
+	// triggered the tool call this activity's progress token belongs to,
+	// for multi-tenant HTTP deployments behind a reverse proxy. Empty
+	// when the calling transport doesn't resolve one (e.g. stdio, or no
+	// mcpserver.HTTPContextFunc wired in) - this ties to whichever
+	// request most recently drove BuildLSPStatus, not the original
+	// requester of a long-running progress stream another caller started.
+	Origin string `json:"origin,omitempty"`
 }
 
 type LSPClientStatus struct {
@@ -32,6 +41,15 @@ type LSPClientStatus struct {
 	Status         string `json:"status"`
 	LastError      string `json:"last_error,omitempty"`
 	ActiveProgress int    `json:"active_progress"`
+	// Origin mirrors LSPActivity.Origin - see its doc comment.
+	Origin string `json:"origin,omitempty"`
+	// Health is the most recent lsp.RunHealthCheck result for this server
+	// (see bridge.MCPLSPBridge.HealthReports/CheckAllHealth), cached with a
+	// TTL and refreshed periodically in the background (see
+	// bridge.MCPLSPBridge.StartHealthChecks) - nil until the first check
+	// runs. Unlike Connected/Status, a bad Health never makes Ready false;
+	// it only ever pulls LSPStatus.State down to "degraded".
+	Health *lsp.HealthResult `json:"health,omitempty"`
 }
 
 type IndexingProgress struct {
@@ -43,12 +61,25 @@ type IndexingProgress struct {
 	Message        string `json:"message,omitempty"`
 }
 
+// UnhandledNotificationSummary rolls up one LSP notification method's
+// recent unhandled activity for lsp_status, sourced from
+// lsp.UnhandledNotificationRingSnapshot. See
+// tools.RegisterUnhandledNotificationsTool for a filterable, full-detail
+// view of the same ring buffer.
+type UnhandledNotificationSummary struct {
+	Method     string    `json:"method"`
+	LastSeen   time.Time `json:"last_seen"`
+	Emitted    int       `json:"emitted"`
+	Suppressed int       `json:"suppressed"`
+}
+
 type LSPStatus struct {
-	Ready    bool              `json:"ready"`
-	State    string            `json:"state"`
-	Activity []LSPActivity     `json:"activity"`
-	Clients  []LSPClientStatus `json:"clients,omitempty"`
-	Indexing *IndexingProgress `json:"indexing,omitempty"`
+	Ready                  bool                           `json:"ready"`
+	State                  string                         `json:"state"`
+	Activity               []LSPActivity                  `json:"activity"`
+	Clients                []LSPClientStatus              `json:"clients,omitempty"`
+	Indexing               *IndexingProgress              `json:"indexing,omitempty"`
+	UnhandledNotifications []UnhandledNotificationSummary `json:"unhandled_notifications,omitempty"`
 }
 
 type LSPStatusResponse struct {
@@ -56,7 +87,29 @@ type LSPStatusResponse struct {
 	RetryAfterMs int `json:"retry_after_ms,omitempty"`
 }
 
+// BuildLSPStatus builds status with no caller attribution (LSPActivity.Origin
+// and LSPClientStatus.Origin left empty) - the right choice for transports
+// that don't resolve a client identity, e.g. stdio. See
+// BuildLSPStatusWithContext for HTTP deployments behind a reverse proxy.
 func BuildLSPStatus(bridge interfaces.BridgeInterface) (LSPStatus, error) {
+	return buildLSPStatus(bridge, "")
+}
+
+// BuildLSPStatusWithContext behaves like BuildLSPStatus, but stamps every
+// LSPActivity/LSPClientStatus entry's Origin with the caller identity
+// ctx carries (see ClientIdentityFromContext), if any - so
+// CheckReadyOrReturn and lsp_status can show which upstream tenant's tool
+// call is holding an indexing token when the bridge is fronted by a
+// reverse proxy (see mcpserver.HTTPContextFunc).
+func BuildLSPStatusWithContext(ctx context.Context, bridge interfaces.BridgeInterface) (LSPStatus, error) {
+	origin := ""
+	if id, ok := ClientIdentityFromContext(ctx); ok {
+		origin = id.IP
+	}
+	return buildLSPStatus(bridge, origin)
+}
+
+func buildLSPStatus(bridge interfaces.BridgeInterface, origin string) (LSPStatus, error) {
 	b, ok := bridge.(*bridgepkg.MCPLSPBridge)
 	if !ok {
 		return LSPStatus{}, fmt.Errorf("bridge does not support status introspection")
@@ -84,6 +137,9 @@ func BuildLSPStatus(bridge interfaces.BridgeInterface) (LSPStatus, error) {
 	anyError := false
 	anyStarting := false
 	anyBusy := false
+	anyUnhealthy := false
+
+	healthReports := b.HealthReports()
 
 	for _, srv := range servers {
 		client := clients[types.LanguageServer(srv)]
@@ -126,11 +182,20 @@ func BuildLSPStatus(bridge interfaces.BridgeInterface) (LSPStatus, error) {
 						Message:     ev.Message,
 						Percentage:  ev.Percentage,
 						Cancellable: ev.Cancellable,
+						Origin:      origin,
 					})
 				}
 			}
 		}
 
+		var health *lsp.HealthResult
+		if h, ok := healthReports[types.LanguageServer(srv)]; ok {
+			health = &h
+			if !h.OK {
+				anyUnhealthy = true
+			}
+		}
+
 		status.Clients = append(status.Clients, LSPClientStatus{
 			Server:         srv,
 			Command:        metrics.GetCommand(),
@@ -138,6 +203,8 @@ func BuildLSPStatus(bridge interfaces.BridgeInterface) (LSPStatus, error) {
 			Status:         statusStr,
 			LastError:      lastError,
 			ActiveProgress: activeCount,
+			Origin:         origin,
+			Health:         health,
 		})
 
 		// Try to get indexing status from SessionAdapter
@@ -168,6 +235,11 @@ func BuildLSPStatus(bridge interfaces.BridgeInterface) (LSPStatus, error) {
 		status.State = "busy"
 	case anyStarting || connectedCount == 0:
 		status.State = "starting"
+	case anyUnhealthy:
+		// Degraded, not error: a stale version or a govulncheck finding
+		// doesn't mean the connection is broken, so tools keep working -
+		// see Ready below, which anyUnhealthy never affects.
+		status.State = "degraded"
 	default:
 		status.State = "ready"
 	}
@@ -177,9 +249,44 @@ func BuildLSPStatus(bridge interfaces.BridgeInterface) (LSPStatus, error) {
 	// and there are no connection/errors.
 	status.Ready = connectedCount > 0 && !anyError
 
+	status.UnhandledNotifications = unhandledNotificationSummaries()
+
 	return status, nil
 }
 
+// unhandledNotificationSummaries rolls lsp.UnhandledNotificationRingSnapshot
+// up into one entry per method (last-seen time, plus emitted/suppressed
+// counts across every window recorded in the ring), sorted by last-seen
+// descending so the most recently active method is first.
+func unhandledNotificationSummaries() []UnhandledNotificationSummary {
+	ring := lsp.UnhandledNotificationRingSnapshot("")
+	if len(ring) == 0 {
+		return nil
+	}
+
+	summaries := make([]UnhandledNotificationSummary, 0, len(ring))
+	for method, entries := range ring {
+		summary := UnhandledNotificationSummary{Method: method}
+		for _, entry := range entries {
+			if entry.Time.After(summary.LastSeen) {
+				summary.LastSeen = entry.Time
+			}
+			if entry.Suppressed > 0 {
+				summary.Suppressed += entry.Suppressed
+			} else {
+				summary.Emitted++
+			}
+		}
+		summaries = append(summaries, summary)
+	}
+
+	sort.Slice(summaries, func(i, j int) bool {
+		return summaries[i].LastSeen.After(summaries[j].LastSeen)
+	})
+
+	return summaries
+}
+
 func FormatLSPStatus(status LSPStatus) (string, error) {
 	raw, err := json.Marshal(status)
 	if err != nil {
@@ -200,11 +307,21 @@ func FormatLSPStatusResponse(status LSPStatus, retryAfterMs int) (string, error)
 	return string(raw), nil
 }
 
+// CheckReadyOrReturn behaves like CheckReadyOrReturnWithContext, with no
+// caller attribution (see BuildLSPStatus). Kept for the many tool handlers
+// that haven't been updated to pass their ctx through yet.
 func CheckReadyOrReturn(bridge interfaces.BridgeInterface) (*mcp.CallToolResult, bool) {
+	return CheckReadyOrReturnWithContext(context.Background(), bridge)
+}
+
+// CheckReadyOrReturnWithContext behaves like CheckReadyOrReturn, but uses
+// ctx's caller identity (see ClientIdentityFromContext) to stamp
+// Origin on the LSPStatus it may return, for multi-tenant HTTP deployments.
+func CheckReadyOrReturnWithContext(ctx context.Context, bridge interfaces.BridgeInterface) (*mcp.CallToolResult, bool) {
 	// If we're running with the concrete bridge, trigger auto-connect as needed.
 	// This removes the need for an explicit lsp_connect tool call.
 	if b, ok := bridge.(*bridgepkg.MCPLSPBridge); ok {
-		status, err := BuildLSPStatus(bridge)
+		status, err := BuildLSPStatusWithContext(ctx, bridge)
 		if err == nil && !status.Ready {
 			// If there are no connected clients (or we are still starting), attempt (re)connect.
 			connected := 0
@@ -221,10 +338,15 @@ func CheckReadyOrReturn(bridge interfaces.BridgeInterface) (*mcp.CallToolResult,
 		}
 
 		// Give the background connect a small head-start to avoid returning "starting"
-		// on the very first tool call in normal cases.
+		// on the very first tool call in normal cases. This poll is bounded and
+		// inherent to one synchronous MCP tool call (there's no push channel back
+		// to the caller mid-call) - it isn't the thing that made a stuck warm-up
+		// un-cancellable. That part is now fixed: StartWarmup's returned
+		// WarmupHandle and MCPLSPBridge.Shutdown can interrupt a hung warm-up via
+		// its child context (see bridge/warmup.go, bridge/supervisor.go).
 		deadline := time.Now().Add(2 * time.Second)
 		for time.Now().Before(deadline) {
-			s, e := BuildLSPStatus(bridge)
+			s, e := BuildLSPStatusWithContext(ctx, bridge)
 			if e != nil {
 				break
 			}
@@ -251,7 +373,7 @@ func CheckReadyOrReturn(bridge interfaces.BridgeInterface) (*mcp.CallToolResult,
 		}
 	}
 
-	status, err := BuildLSPStatus(bridge)
+	status, err := BuildLSPStatusWithContext(ctx, bridge)
 	if err != nil {
 		// In unit tests and in alternative bridge implementations we may not support
 		// status introspection. In that case, don't block tool execution.
@@ -0,0 +1,61 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	bridgepkg "rockerboo/mcp-lsp-bridge/bridge"
+	"rockerboo/mcp-lsp-bridge/interfaces"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// EntryPointRegistrationTool builds "register_entry_points": lets teams
+// extend call_graph.go/reachability_analysis.go's entry-point detection
+// with patterns for localized translations, project-specific prefixes, or
+// naming conventions the built-in bslEntryPoints list doesn't cover,
+// without recompiling. Patterns registered this way are additive and live
+// for the process lifetime, same as ones loaded from entry_points.yaml
+// (see bridge.EntryPointRegistry).
+func EntryPointRegistrationTool(bridge interfaces.BridgeInterface) (mcp.Tool, server.ToolHandlerFunc) {
+	return mcp.NewTool("register_entry_points",
+			mcp.WithDescription(`Register custom entry-point patterns for call_graph and reachability_analysis's entry-point detection, on top of the built-in BSL list. Call with no 'patterns_json' to list every registered pattern instead of adding one.
+
+Each pattern supports exactly one of "exact", "substring", or "regex" matched against the symbol name, plus an optional "kind" (e.g. "Method") to narrow matches to that symbol kind. Example: [{"name": "form commands", "substring": "Форма_", "kind": "Method"}]`),
+			mcp.WithDestructiveHintAnnotation(false),
+			mcp.WithString("patterns_json", mcp.Description(`JSON array of patterns to register. Omit to list registered patterns instead.`)),
+		), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			registry := entryPointRegistryFor(bridge)
+			if registry == nil {
+				return mcp.NewToolResultError("register_entry_points is not supported by this bridge"), nil
+			}
+
+			patternsJSON := request.GetString("patterns_json", "")
+			if patternsJSON != "" {
+				var patterns []bridgepkg.EntryPointPattern
+				if err := json.Unmarshal([]byte(patternsJSON), &patterns); err != nil {
+					return mcp.NewToolResultError(fmt.Sprintf("invalid patterns_json: %v", err)), nil
+				}
+
+				for _, p := range patterns {
+					if err := registry.Register(p); err != nil {
+						return mcp.NewToolResultError(err.Error()), nil
+					}
+				}
+			}
+
+			out, err := json.MarshalIndent(registry.List(), "", "  ")
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("failed to marshal registered patterns: %v", err)), nil
+			}
+
+			return mcp.NewToolResultText(string(out)), nil
+		}
+}
+
+// RegisterEntryPointRegistrationTool registers the register_entry_points tool.
+func RegisterEntryPointRegistrationTool(mcpServer ToolServer, bridge interfaces.BridgeInterface) {
+	mcpServer.AddTool(EntryPointRegistrationTool(bridge))
+}
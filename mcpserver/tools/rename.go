@@ -0,0 +1,393 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"rockerboo/mcp-lsp-bridge/interfaces"
+	"rockerboo/mcp-lsp-bridge/logger"
+	"rockerboo/mcp-lsp-bridge/lsp"
+	"rockerboo/mcp-lsp-bridge/utils"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/myleshyson/lsprotocol-go/protocol"
+)
+
+// renameFilePreview describes what one step of a rename's WorkspaceEdit
+// does to a file, whether or not apply=true actually carried it out.
+type renameFilePreview struct {
+	URI    string `json:"uri"`
+	Op     string `json:"op"` // "edit", "create", "rename", "delete"
+	NewURI string `json:"new_uri,omitempty"`
+	Diff   string `json:"diff,omitempty"`
+}
+
+// RenameResult is rename's structured output: the raw WorkspaceEdit the
+// language server returned (for callers that want to inspect it directly),
+// a per-file preview, and whether it was actually written to disk.
+type RenameResult struct {
+	Edit    *protocol.WorkspaceEdit `json:"edit"`
+	Preview []renameFilePreview     `json:"preview"`
+	Applied bool                    `json:"applied"`
+	Error   string                  `json:"error,omitempty"`
+}
+
+// RenameTool registers "rename": the write-side counterpart to
+// PrepareRenameTool. It runs prepareRename as a guard so an obviously-bad
+// position fails the same way prepare_rename does, then issues the real
+// textDocument/rename request. With apply=false (the default) it only
+// previews the resulting WorkspaceEdit; with apply=true it writes every
+// change to disk as one all-or-nothing batch, rolling back what it already
+// applied if a later step fails.
+func RenameTool(bridge interfaces.BridgeInterface) (mcp.Tool, server.ToolHandlerFunc) {
+	return mcp.NewTool("rename",
+			mcp.WithDescription("Rename the symbol at a position (textDocument/rename) and preview or apply the resulting WorkspaceEdit. Runs the same prepareRename guard as prepare_rename first, so an invalid position fails the same way."),
+			mcp.WithDestructiveHintAnnotation(true),
+			mcp.WithString("uri", mcp.Description("URI to the file"), mcp.Required()),
+			mcp.WithNumber("line", mcp.Description("Line number (0-based)"), mcp.Required(), mcp.Min(0)),
+			mcp.WithNumber("character", mcp.Description("Character position (0-based)"), mcp.Required(), mcp.Min(0)),
+			mcp.WithString("new_name", mcp.Description("The new name for the symbol"), mcp.Required()),
+			mcp.WithBoolean("apply", mcp.Description("Write the WorkspaceEdit to disk (default: false, preview only)."), mcp.DefaultBool(false)),
+		), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			uri, err := request.RequireString("uri")
+			if err != nil {
+				logger.Error("rename: URI parsing failed", err)
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			line, err := request.RequireInt("line")
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("Invalid line: %v", err)), nil
+			}
+			character, err := request.RequireInt("character")
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("Invalid character: %v", err)), nil
+			}
+			newName, err := request.RequireString("new_name")
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			apply := request.GetBool("apply", false)
+
+			if result, ok := CheckReadyOrReturn(bridge); !ok {
+				return result, nil
+			}
+
+			lineUint32, err := safeUint32(line)
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("Invalid line: %v", err)), nil
+			}
+			characterUint32, err := safeUint32(character)
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("Invalid character: %v", err)), nil
+			}
+
+			// Reuse prepare_rename as a guard: a position with nothing
+			// renameable fails here the same way prepare_rename reports it,
+			// before the language server is asked to do the real rename.
+			if _, err := bridge.PrepareRename(uri, lineUint32, characterUint32); err != nil {
+				logger.Error("rename: prepareRename guard failed", err)
+				return mcp.NewToolResultError(fmt.Sprintf("Prepare rename failed: %v", err)), nil
+			}
+
+			edit, err := bridge.Rename(uri, lineUint32, characterUint32, newName)
+			if err != nil {
+				logger.Error("rename: request failed", err)
+				return mcp.NewToolResultError(fmt.Sprintf("Rename failed: %v", err)), nil
+			}
+
+			ops, err := planRenameOperations(edit)
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("rename: %v", err)), nil
+			}
+
+			result := RenameResult{Edit: edit, Preview: previewRenameOperations(bridge, ops)}
+
+			if apply {
+				if err := applyRenameOperations(bridge, ops); err != nil {
+					result.Error = err.Error()
+				} else {
+					result.Applied = true
+				}
+			}
+
+			raw, err := json.Marshal(result)
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("Failed to serialize result: %v", err)), nil
+			}
+
+			return mcp.NewToolResultText(string(raw)), nil
+		}
+}
+
+func RegisterRenameTool(mcpServer ToolServer, bridge interfaces.BridgeInterface) {
+	mcpServer.AddTool(RenameTool(bridge))
+}
+
+// renameOperation is one step of a WorkspaceEdit, in application order:
+// either a text edit (from Changes, or a TextDocumentEdit within
+// DocumentChanges) or a create/rename/delete resource operation
+// (DocumentChanges only).
+type renameOperation struct {
+	kind   string // "edit", "create", "rename", "delete"
+	uri    string
+	newURI string // "rename" only
+	edits  []protocol.TextEdit
+
+	overwrite         bool
+	ignoreIfExists    bool
+	ignoreIfNotExists bool
+	recursive         bool
+}
+
+// planRenameOperations turns edit's Changes/DocumentChanges into an ordered
+// list of operations to preview or apply. Per the LSP spec a WorkspaceEdit
+// carries DocumentChanges XOR Changes; DocumentChanges wins when present
+// since it's the only form that can express resource operations and
+// per-edit versioning.
+func planRenameOperations(edit *protocol.WorkspaceEdit) ([]renameOperation, error) {
+	if edit == nil {
+		return nil, nil
+	}
+	if len(edit.DocumentChanges) > 0 {
+		return planDocumentChanges(edit.DocumentChanges)
+	}
+	return planChanges(edit.Changes), nil
+}
+
+func planChanges(changes map[protocol.DocumentUri][]protocol.TextEdit) []renameOperation {
+	uris := make([]string, 0, len(changes))
+	for uri := range changes {
+		uris = append(uris, string(uri))
+	}
+	sort.Strings(uris)
+
+	ops := make([]renameOperation, 0, len(uris))
+	for _, uri := range uris {
+		ops = append(ops, renameOperation{kind: "edit", uri: uri, edits: changes[protocol.DocumentUri(uri)]})
+	}
+	return ops
+}
+
+func planDocumentChanges(changes []protocol.Or4[protocol.TextDocumentEdit, protocol.CreateFile, protocol.RenameFile, protocol.DeleteFile]) ([]renameOperation, error) {
+	ops := make([]renameOperation, 0, len(changes))
+	lastVersion := make(map[string]int32)
+
+	for i, change := range changes {
+		switch v := change.Value.(type) {
+		case protocol.TextDocumentEdit:
+			uri := string(v.TextDocument.Uri)
+			if v.TextDocument.Version != nil {
+				if prev, seen := lastVersion[uri]; seen && *v.TextDocument.Version <= prev {
+					return nil, fmt.Errorf("documentChanges[%d]: non-increasing version %d for %s (have %d)", i, *v.TextDocument.Version, uri, prev)
+				}
+				lastVersion[uri] = *v.TextDocument.Version
+			}
+			ops = append(ops, renameOperation{kind: "edit", uri: uri, edits: v.Edits})
+
+		case protocol.CreateFile:
+			op := renameOperation{kind: "create", uri: string(v.Uri)}
+			if v.Options != nil {
+				op.overwrite = boolValue(v.Options.Overwrite)
+				op.ignoreIfExists = boolValue(v.Options.IgnoreIfExists)
+			}
+			ops = append(ops, op)
+
+		case protocol.RenameFile:
+			op := renameOperation{kind: "rename", uri: string(v.OldUri), newURI: string(v.NewUri)}
+			if v.Options != nil {
+				op.overwrite = boolValue(v.Options.Overwrite)
+				op.ignoreIfExists = boolValue(v.Options.IgnoreIfExists)
+			}
+			ops = append(ops, op)
+
+		case protocol.DeleteFile:
+			op := renameOperation{kind: "delete", uri: string(v.Uri)}
+			if v.Options != nil {
+				op.recursive = boolValue(v.Options.Recursive)
+				op.ignoreIfNotExists = boolValue(v.Options.IgnoreIfNotExists)
+			}
+			ops = append(ops, op)
+
+		default:
+			return nil, fmt.Errorf("documentChanges[%d]: unrecognized operation type %T", i, v)
+		}
+	}
+
+	return ops, nil
+}
+
+func boolValue(p *bool) bool {
+	return p != nil && *p
+}
+
+// previewRenameOperations renders each operation as a diff (for edits) or a
+// short description (for resource operations), the same compact per-file
+// preview handleCodeActions gives for a code action's WorkspaceEdit.
+func previewRenameOperations(bridge interfaces.BridgeInterface, ops []renameOperation) []renameFilePreview {
+	preview := make([]renameFilePreview, 0, len(ops))
+
+	for _, op := range ops {
+		switch op.kind {
+		case "edit":
+			current, err := readWorkspaceEditBaseline(bridge, op.uri)
+			if err != nil {
+				preview = append(preview, renameFilePreview{URI: op.uri, Op: op.kind, Diff: fmt.Sprintf("(could not read current content: %v)", err)})
+				continue
+			}
+			diff, _ := codeActionDiffPreview(current, op.edits)
+			preview = append(preview, renameFilePreview{URI: op.uri, Op: op.kind, Diff: strings.TrimRight(diff, "\n")})
+		case "rename":
+			preview = append(preview, renameFilePreview{URI: op.uri, Op: op.kind, NewURI: op.newURI})
+		default:
+			preview = append(preview, renameFilePreview{URI: op.uri, Op: op.kind})
+		}
+	}
+
+	return preview
+}
+
+// renameUndo reverses one applied renameOperation, best-effort.
+type renameUndo func() error
+
+// applyRenameOperations executes ops against the filesystem in order,
+// building an undo stack as it goes. If any operation fails, every
+// already-applied operation is reversed (in reverse order) before the
+// original error is returned, so apply=true is all-or-nothing the same way
+// a single workspace/applyEdit is for an editor.
+func applyRenameOperations(bridge interfaces.BridgeInterface, ops []renameOperation) error {
+	var undo []renameUndo
+
+	rollback := func() {
+		for i := len(undo) - 1; i >= 0; i-- {
+			if err := undo[i](); err != nil {
+				logger.Error("rename: rollback step failed, workspace may be left partially applied", err)
+			}
+		}
+	}
+
+	for i, op := range ops {
+		var (
+			step renameUndo
+			err  error
+		)
+		switch op.kind {
+		case "edit":
+			step, err = applyRenameEdit(bridge, op)
+		case "create":
+			step, err = applyRenameCreate(bridge, op)
+		case "rename":
+			step, err = applyRenameRename(bridge, op)
+		case "delete":
+			step, err = applyRenameDelete(bridge, op)
+		default:
+			err = fmt.Errorf("unrecognized operation kind %q", op.kind)
+		}
+		if err != nil {
+			rollback()
+			return fmt.Errorf("operation %d (%s %s): %w", i, op.kind, op.uri, err)
+		}
+		undo = append(undo, step)
+	}
+
+	return nil
+}
+
+func applyRenameEdit(bridge interfaces.BridgeInterface, op renameOperation) (renameUndo, error) {
+	path := utils.URIToFilePath(bridge.NormalizeURIForLSP(op.uri))
+
+	original, err := os.ReadFile(path) // #nosec G304 -- writing within user workspace
+	if err != nil {
+		return nil, fmt.Errorf("read: %w", err)
+	}
+
+	updated := lsp.ApplyTextEdits(string(original), op.edits)
+
+	mode := os.FileMode(0o644)
+	if info, statErr := os.Stat(path); statErr == nil {
+		mode = info.Mode()
+	}
+
+	if err := os.WriteFile(path, []byte(updated), mode); err != nil {
+		return nil, fmt.Errorf("write: %w", err)
+	}
+
+	return func() error { return os.WriteFile(path, original, mode) }, nil
+}
+
+func applyRenameCreate(bridge interfaces.BridgeInterface, op renameOperation) (renameUndo, error) {
+	path := utils.URIToFilePath(bridge.NormalizeURIForLSP(op.uri))
+
+	original, statErr := os.ReadFile(path) // #nosec G304 -- writing within user workspace
+	exists := statErr == nil
+	if exists {
+		if op.ignoreIfExists {
+			return func() error { return nil }, nil
+		}
+		if !op.overwrite {
+			return nil, fmt.Errorf("create: %s already exists", path)
+		}
+	}
+
+	if err := os.WriteFile(path, nil, 0o644); err != nil {
+		return nil, fmt.Errorf("create: %w", err)
+	}
+
+	return func() error {
+		if exists {
+			return os.WriteFile(path, original, 0o644)
+		}
+		return os.Remove(path)
+	}, nil
+}
+
+func applyRenameRename(bridge interfaces.BridgeInterface, op renameOperation) (renameUndo, error) {
+	oldPath := utils.URIToFilePath(bridge.NormalizeURIForLSP(op.uri))
+	newPath := utils.URIToFilePath(bridge.NormalizeURIForLSP(op.newURI))
+
+	if _, err := os.Stat(newPath); err == nil {
+		if op.ignoreIfExists {
+			return func() error { return nil }, nil
+		}
+		if !op.overwrite {
+			return nil, fmt.Errorf("rename: %s already exists", newPath)
+		}
+	}
+
+	if err := os.Rename(oldPath, newPath); err != nil {
+		return nil, fmt.Errorf("rename: %w", err)
+	}
+
+	return func() error { return os.Rename(newPath, oldPath) }, nil
+}
+
+// applyRenameDelete deletes a single file. Directory deletion (Recursive)
+// isn't supported: BSL rename operations only ever touch individual source
+// files, and restoring a whole deleted directory tree on rollback isn't
+// worth the complexity that would add here.
+func applyRenameDelete(bridge interfaces.BridgeInterface, op renameOperation) (renameUndo, error) {
+	path := utils.URIToFilePath(bridge.NormalizeURIForLSP(op.uri))
+
+	original, err := os.ReadFile(path) // #nosec G304 -- reading within user workspace before delete
+	if err != nil {
+		if os.IsNotExist(err) && op.ignoreIfNotExists {
+			return func() error { return nil }, nil
+		}
+		return nil, fmt.Errorf("delete: %w", err)
+	}
+
+	mode := os.FileMode(0o644)
+	if info, statErr := os.Stat(path); statErr == nil {
+		mode = info.Mode()
+	}
+
+	if err := os.Remove(path); err != nil {
+		return nil, fmt.Errorf("delete: %w", err)
+	}
+
+	return func() error { return os.WriteFile(path, original, mode) }, nil
+}
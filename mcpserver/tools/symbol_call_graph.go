@@ -0,0 +1,331 @@
+package tools
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"rockerboo/mcp-lsp-bridge/interfaces"
+
+	"github.com/myleshyson/lsprotocol-go/protocol"
+)
+
+// defaultSymbolCallGraphMaxDepth and symbolCallGraphMaxNodes bound
+// handleSymbolRelationships' transitive call-graph section so a generic
+// BSL module (or a runaway cycle) can't turn one symbol_relationships call
+// into an unbounded LSP request storm. Unlike call_graph.go's
+// callGraphBuilder (a standalone tool building a full incoming+outgoing
+// tree for one position), this builds a single BFS graph over an already-
+// resolved symbol, scoped by a direction option.
+const (
+	defaultSymbolCallGraphMaxDepth = 3
+	symbolCallGraphMaxNodes        = 200
+)
+
+// symbolCallGraphNodeKey identifies a call-hierarchy node by its
+// declaration site, matching call_graph.go's node-identity convention.
+func symbolCallGraphNodeKey(item protocol.CallHierarchyItem) string {
+	return fmt.Sprintf("%s:%d:%d", item.Uri, item.Range.Start.Line, item.Range.Start.Character)
+}
+
+// SymbolCallGraphNode is one node in the graph buildSymbolCallGraph
+// returns: a call-hierarchy item plus the fan-in/fan-out/depth metrics
+// computed once BFS traversal finishes.
+type SymbolCallGraphNode struct {
+	Key       string `json:"key"`
+	Name      string `json:"name"`
+	Kind      string `json:"kind"`
+	URI       string `json:"uri"`
+	Line      uint32 `json:"line"`
+	Character uint32 `json:"character"`
+	Depth     int    `json:"depth_from_root"`
+	FanIn     int    `json:"fan_in"`
+	FanOut    int    `json:"fan_out"`
+}
+
+// SymbolCallGraphEdge is one call edge, deduplicated so repeated calls from
+// the same caller to the same callee collapse into one edge with every
+// call-site range recorded once.
+type SymbolCallGraphEdge struct {
+	From      string           `json:"from"`
+	To        string           `json:"to"`
+	CallSites []protocol.Range `json:"call_sites"`
+}
+
+// SymbolCallGraph is the result of buildSymbolCallGraph: nodes, edges, and
+// the strongly-connected components Tarjan's algorithm finds among them,
+// which corroborates file_analysis's import-level "Circular Dependencies"
+// count with a call-level equivalent.
+type SymbolCallGraph struct {
+	Root                    string                `json:"root"`
+	Direction               string                `json:"direction"`
+	Nodes                   []SymbolCallGraphNode `json:"nodes"`
+	Edges                   []SymbolCallGraphEdge `json:"edges"`
+	StronglyConnectedGroups [][]string            `json:"strongly_connected_components,omitempty"`
+	Truncated               bool                  `json:"truncated"`
+	TruncateReason          string                `json:"truncate_reason,omitempty"`
+}
+
+type symbolCallGraphQueueEntry struct {
+	item  protocol.CallHierarchyItem
+	depth int
+}
+
+// buildSymbolCallGraph does a BFS over bridge.IncomingCalls/OutgoingCalls
+// starting at root, up to maxDepth hops and symbolCallGraphMaxNodes total
+// nodes, keying visited nodes by (uri, name, range.start) to break cycles.
+// direction selects which edges to follow ("incoming", "outgoing", or
+// "both"); includeExternal allows traversal to continue past nodes whose
+// URI differs from root's (otherwise such nodes are still recorded, just
+// not expanded further).
+func buildSymbolCallGraph(bridge interfaces.BridgeInterface, root protocol.CallHierarchyItem, direction string, maxDepth int, includeExternal bool) SymbolCallGraph {
+	rootKey := symbolCallGraphNodeKey(root)
+	graph := SymbolCallGraph{Root: rootKey, Direction: direction}
+
+	nodes := map[string]*SymbolCallGraphNode{
+		rootKey: {
+			Key: rootKey, Name: root.Name, Kind: symbolKindToString(root.Kind),
+			URI: string(root.Uri), Line: root.Range.Start.Line, Character: root.Range.Start.Character,
+		},
+	}
+	edges := make(map[[2]string]*SymbolCallGraphEdge)
+	adjacency := make(map[string]map[string]bool)
+	visited := map[string]bool{rootKey: true}
+
+	markTruncated := func(reason string) {
+		if !graph.Truncated {
+			graph.Truncated = true
+			graph.TruncateReason = reason
+		}
+	}
+
+	addEdge := func(fromKey, toKey string, sites []protocol.Range) {
+		k := [2]string{fromKey, toKey}
+		e, ok := edges[k]
+		if !ok {
+			e = &SymbolCallGraphEdge{From: fromKey, To: toKey}
+			edges[k] = e
+		}
+		e.CallSites = dedupeCallGraphRanges(append(e.CallSites, sites...))
+
+		if adjacency[fromKey] == nil {
+			adjacency[fromKey] = make(map[string]bool)
+		}
+		adjacency[fromKey][toKey] = true
+	}
+
+	enqueue := func(queue *[]symbolCallGraphQueueEntry, item protocol.CallHierarchyItem, depth int) {
+		key := symbolCallGraphNodeKey(item)
+		external := item.Uri != root.Uri
+
+		if _, ok := nodes[key]; !ok {
+			if len(nodes) >= symbolCallGraphMaxNodes {
+				markTruncated(fmt.Sprintf("max node cap reached (%d)", symbolCallGraphMaxNodes))
+				return
+			}
+			nodes[key] = &SymbolCallGraphNode{
+				Key: key, Name: item.Name, Kind: symbolKindToString(item.Kind),
+				URI: string(item.Uri), Line: item.Range.Start.Line, Character: item.Range.Start.Character,
+				Depth: depth,
+			}
+		}
+
+		if visited[key] {
+			return
+		}
+		visited[key] = true
+
+		if external && !includeExternal {
+			return
+		}
+		*queue = append(*queue, symbolCallGraphQueueEntry{item: item, depth: depth})
+	}
+
+	queue := []symbolCallGraphQueueEntry{{item: root, depth: 0}}
+
+	for len(queue) > 0 {
+		if len(nodes) >= symbolCallGraphMaxNodes {
+			markTruncated(fmt.Sprintf("max node cap reached (%d)", symbolCallGraphMaxNodes))
+			break
+		}
+
+		current := queue[0]
+		queue = queue[1:]
+		if current.depth >= maxDepth {
+			continue
+		}
+
+		currentKey := symbolCallGraphNodeKey(current.item)
+
+		if direction == "incoming" || direction == "both" {
+			if calls, err := bridge.IncomingCalls(current.item); err == nil {
+				for _, call := range calls {
+					addEdge(symbolCallGraphNodeKey(call.From), currentKey, call.FromRanges)
+					enqueue(&queue, call.From, current.depth+1)
+				}
+			}
+		}
+
+		if direction == "outgoing" || direction == "both" {
+			if calls, err := bridge.OutgoingCalls(current.item); err == nil {
+				for _, call := range calls {
+					addEdge(currentKey, symbolCallGraphNodeKey(call.To), call.FromRanges)
+					enqueue(&queue, call.To, current.depth+1)
+				}
+			}
+		}
+	}
+
+	for key := range edges {
+		nodes[key[0]].FanOut++
+		nodes[key[1]].FanIn++
+	}
+
+	graph.Nodes = make([]SymbolCallGraphNode, 0, len(nodes))
+	for _, n := range nodes {
+		graph.Nodes = append(graph.Nodes, *n)
+	}
+	sort.Slice(graph.Nodes, func(i, j int) bool { return graph.Nodes[i].Key < graph.Nodes[j].Key })
+
+	graph.Edges = make([]SymbolCallGraphEdge, 0, len(edges))
+	for _, e := range edges {
+		graph.Edges = append(graph.Edges, *e)
+	}
+	sort.Slice(graph.Edges, func(i, j int) bool {
+		if graph.Edges[i].From != graph.Edges[j].From {
+			return graph.Edges[i].From < graph.Edges[j].From
+		}
+		return graph.Edges[i].To < graph.Edges[j].To
+	})
+
+	graph.StronglyConnectedGroups = tarjanSCC(adjacency)
+	return graph
+}
+
+// dedupeCallGraphRanges drops duplicate call-site ranges so repeated BFS
+// visits to the same edge (possible when a callee is reached via more than
+// one path before the visited-set catches up) don't inflate CallSites.
+func dedupeCallGraphRanges(ranges []protocol.Range) []protocol.Range {
+	seen := make(map[string]bool, len(ranges))
+	out := make([]protocol.Range, 0, len(ranges))
+	for _, r := range ranges {
+		key := fmt.Sprintf("%d:%d-%d:%d", r.Start.Line, r.Start.Character, r.End.Line, r.End.Character)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		out = append(out, r)
+	}
+	return out
+}
+
+// tarjanSCC finds strongly-connected components of more than one node (true
+// cycles, not just self-loops) in a call graph's adjacency map, via
+// Tarjan's algorithm.
+func tarjanSCC(adjacency map[string]map[string]bool) [][]string {
+	type tarjanState struct {
+		index   int
+		lowlink int
+		onStack bool
+	}
+
+	states := make(map[string]*tarjanState)
+	var stack []string
+	var components [][]string
+	nextIndex := 0
+
+	var strongconnect func(v string)
+	strongconnect = func(v string) {
+		states[v] = &tarjanState{index: nextIndex, lowlink: nextIndex, onStack: true}
+		nextIndex++
+		stack = append(stack, v)
+
+		neighbors := make([]string, 0, len(adjacency[v]))
+		for w := range adjacency[v] {
+			neighbors = append(neighbors, w)
+		}
+		sort.Strings(neighbors)
+
+		for _, w := range neighbors {
+			if states[w] == nil {
+				strongconnect(w)
+				if states[w].lowlink < states[v].lowlink {
+					states[v].lowlink = states[w].lowlink
+				}
+			} else if states[w].onStack && states[w].index < states[v].lowlink {
+				states[v].lowlink = states[w].index
+			}
+		}
+
+		if states[v].lowlink == states[v].index {
+			var component []string
+			for {
+				n := len(stack) - 1
+				w := stack[n]
+				stack = stack[:n]
+				states[w].onStack = false
+				component = append(component, w)
+				if w == v {
+					break
+				}
+			}
+			if len(component) > 1 {
+				sort.Strings(component)
+				components = append(components, component)
+			}
+		}
+	}
+
+	nodeKeys := make([]string, 0, len(adjacency))
+	for v := range adjacency {
+		nodeKeys = append(nodeKeys, v)
+	}
+	sort.Strings(nodeKeys)
+
+	for _, v := range nodeKeys {
+		if states[v] == nil {
+			strongconnect(v)
+		}
+	}
+
+	sort.Slice(components, func(i, j int) bool { return components[i][0] < components[j][0] })
+	return components
+}
+
+// callGraphToDOT renders graph as a Graphviz DOT digraph.
+func callGraphToDOT(graph SymbolCallGraph) string {
+	var sb strings.Builder
+	sb.WriteString("digraph call_graph {\n")
+	for _, n := range graph.Nodes {
+		label := strings.ReplaceAll(n.Name, "\"", "\\\"")
+		fmt.Fprintf(&sb, "  %q [label=%q];\n", n.Key, label)
+	}
+	for _, e := range graph.Edges {
+		fmt.Fprintf(&sb, "  %q -> %q;\n", e.From, e.To)
+	}
+	sb.WriteString("}\n")
+	return sb.String()
+}
+
+// cytoscapeElement is one node or edge in Cytoscape.js's elements JSON
+// format (https://js.cytoscape.org/#notation/elements-json).
+type cytoscapeElement struct {
+	Data map[string]any `json:"data"`
+}
+
+// callGraphToCytoscape renders graph as a flat Cytoscape.js elements list.
+func callGraphToCytoscape(graph SymbolCallGraph) []cytoscapeElement {
+	elements := make([]cytoscapeElement, 0, len(graph.Nodes)+len(graph.Edges))
+	for _, n := range graph.Nodes {
+		elements = append(elements, cytoscapeElement{Data: map[string]any{
+			"id": n.Key, "label": n.Name, "kind": n.Kind, "uri": n.URI,
+			"fan_in": n.FanIn, "fan_out": n.FanOut, "depth": n.Depth,
+		}})
+	}
+	for i, e := range graph.Edges {
+		elements = append(elements, cytoscapeElement{Data: map[string]any{
+			"id": fmt.Sprintf("e%d", i), "source": e.From, "target": e.To, "call_sites": len(e.CallSites),
+		}})
+	}
+	return elements
+}
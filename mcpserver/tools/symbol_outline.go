@@ -0,0 +1,228 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"rockerboo/mcp-lsp-bridge/interfaces"
+	"rockerboo/mcp-lsp-bridge/logger"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/myleshyson/lsprotocol-go/protocol"
+)
+
+// defaultSymbolOutlineMinPrefixLen is the shortest shared name prefix (in
+// runes) clusterSymbolsByPrefix will group symbols under when the caller
+// doesn't override min_prefix_len.
+const defaultSymbolOutlineMinPrefixLen = 4
+
+// SymbolOutlineEntry is one symbol in a SymbolOutline, flattened out of the
+// file's document symbol tree.
+type SymbolOutlineEntry struct {
+	Name      string `json:"name"`
+	Kind      string `json:"kind"`
+	Line      uint32 `json:"line"`
+	Character uint32 `json:"character"`
+}
+
+// SymbolOutlineGroup is a naming cluster clusterSymbolsByPrefix found: every
+// Symbol's name starts with Prefix, which is the longest prefix shared by
+// the whole cluster (not just the configured minimum).
+type SymbolOutlineGroup struct {
+	Prefix  string               `json:"prefix"`
+	Symbols []SymbolOutlineEntry `json:"symbols"`
+}
+
+// SymbolOutline is buildSymbolOutline's result.
+type SymbolOutline struct {
+	URI          string               `json:"uri"`
+	MinPrefixLen int                  `json:"min_prefix_len"`
+	Groups       []SymbolOutlineGroup `json:"groups"`
+	Ungrouped    []SymbolOutlineEntry `json:"ungrouped,omitempty"`
+}
+
+// flattenDocumentSymbols walks symbols and their Children recursively into
+// a flat list of SymbolOutlineEntry, the input clusterSymbolsByPrefix
+// clusters.
+func flattenDocumentSymbols(symbols []protocol.DocumentSymbol) []SymbolOutlineEntry {
+	var entries []SymbolOutlineEntry
+	var walk func([]protocol.DocumentSymbol)
+	walk = func(syms []protocol.DocumentSymbol) {
+		for _, sym := range syms {
+			entries = append(entries, SymbolOutlineEntry{
+				Name:      sym.Name,
+				Kind:      symbolKindToString(sym.Kind),
+				Line:      sym.Range.Start.Line,
+				Character: sym.Range.Start.Character,
+			})
+			walk(sym.Children)
+		}
+	}
+	walk(symbols)
+	return entries
+}
+
+// commonPrefixLen returns how many leading runes a and b share.
+func commonPrefixLen(a, b []rune) int {
+	n := len(a)
+	if len(b) < n {
+		n = len(b)
+	}
+	i := 0
+	for i < n && a[i] == b[i] {
+		i++
+	}
+	return i
+}
+
+// clusterSymbolsByPrefix groups entries into naming clusters using the
+// classic sorted-strings LCP technique: once entries are lexicographically
+// sorted, the longest common prefix of any contiguous run equals the
+// minimum of that run's adjacent-pair LCPs. This walks the sorted list
+// greedily, extending the current run for as long as doing so keeps that
+// running minimum at or above minPrefixLen, e.g. ПолучитьКлиента,
+// ПолучитьЗаказ and ПолучитьТовар (sorted adjacent, each pair sharing at
+// least "Получить") form one group with Prefix "Получить". Runs of length
+// 1 - nothing else shares their prefix - are returned as Ungrouped instead
+// of single-member groups.
+func clusterSymbolsByPrefix(entries []SymbolOutlineEntry, minPrefixLen int) (groups []SymbolOutlineGroup, ungrouped []SymbolOutlineEntry) {
+	if len(entries) == 0 {
+		return nil, nil
+	}
+
+	sorted := append([]SymbolOutlineEntry(nil), entries...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+
+	names := make([][]rune, len(sorted))
+	for i, e := range sorted {
+		names[i] = []rune(e.Name)
+	}
+
+	i := 0
+	for i < len(sorted) {
+		j := i
+		runMin := len(names[i])
+		for j+1 < len(sorted) {
+			lcp := commonPrefixLen(names[j], names[j+1])
+			next := lcp
+			if runMin < next {
+				next = runMin
+			}
+			if next < minPrefixLen {
+				break
+			}
+			runMin = next
+			j++
+		}
+
+		if j > i {
+			groups = append(groups, SymbolOutlineGroup{
+				Prefix:  string(names[i][:runMin]),
+				Symbols: append([]SymbolOutlineEntry(nil), sorted[i:j+1]...),
+			})
+		} else {
+			ungrouped = append(ungrouped, sorted[i])
+		}
+		i = j + 1
+	}
+
+	sort.Slice(groups, func(a, b int) bool {
+		if len(groups[a].Symbols) != len(groups[b].Symbols) {
+			return len(groups[a].Symbols) > len(groups[b].Symbols)
+		}
+		return groups[a].Prefix < groups[b].Prefix
+	})
+	return groups, ungrouped
+}
+
+// buildSymbolOutline fetches uri's document symbols, flattens them (see
+// flattenDocumentSymbols) and clusters them by shared name prefix (see
+// clusterSymbolsByPrefix) - a BSL module with hundreds of flat procedures
+// reads far more usefully as a handful of "Получить*"/"Установить*"-style
+// groups than as one alphabetical dump.
+func buildSymbolOutline(bridge interfaces.BridgeInterface, uri string, minPrefixLen int) (SymbolOutline, error) {
+	if minPrefixLen <= 0 {
+		minPrefixLen = defaultSymbolOutlineMinPrefixLen
+	}
+
+	symbols, err := bridge.GetDocumentSymbols(uri)
+	if err != nil {
+		return SymbolOutline{}, fmt.Errorf("bsl_symbol_outline: document symbols request failed: %w", err)
+	}
+
+	entries := flattenDocumentSymbols(symbols)
+	groups, ungrouped := clusterSymbolsByPrefix(entries, minPrefixLen)
+
+	return SymbolOutline{URI: uri, MinPrefixLen: minPrefixLen, Groups: groups, Ungrouped: ungrouped}, nil
+}
+
+// formatSymbolOutlineText renders outline for output_format="text".
+func formatSymbolOutlineText(outline SymbolOutline) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "SYMBOL_OUTLINE|%s|min_prefix=%d|groups=%d|ungrouped=%d\n",
+		outline.URI, outline.MinPrefixLen, len(outline.Groups), len(outline.Ungrouped))
+
+	for _, g := range outline.Groups {
+		fmt.Fprintf(&sb, "\n%s* (%d):\n", g.Prefix, len(g.Symbols))
+		for _, s := range g.Symbols {
+			fmt.Fprintf(&sb, "  %s (%s) %d:%d\n", s.Name, s.Kind, s.Line, s.Character)
+		}
+	}
+
+	if len(outline.Ungrouped) > 0 {
+		sb.WriteString("\nUNGROUPED:\n")
+		for _, s := range outline.Ungrouped {
+			fmt.Fprintf(&sb, "  %s (%s) %d:%d\n", s.Name, s.Kind, s.Line, s.Character)
+		}
+	}
+
+	return sb.String()
+}
+
+// SymbolOutlineTool registers "bsl_symbol_outline": an LCP-clustered
+// organization of one file's document symbols, grouping procedures/
+// functions that share a naming prefix (e.g. "Получить") instead of
+// listing hundreds of symbols alphabetically (see buildSymbolOutline).
+func SymbolOutlineTool(bridge interfaces.BridgeInterface) (mcp.Tool, server.ToolHandlerFunc) {
+	return mcp.NewTool("bsl_symbol_outline",
+			mcp.WithDescription(`Clusters a file's document symbols into naming groups using longest-common-prefix analysis: sorts symbol names, then groups any contiguous run sharing a prefix of at least min_prefix_len characters (e.g. ПолучитьКлиента/ПолучитьЗаказ/ПолучитьТовар cluster under "Получить"). Much more useful than the raw alphabetical symbol dump for a BSL module with hundreds of procedures.`),
+			mcp.WithDestructiveHintAnnotation(false),
+			mcp.WithString("uri", mcp.Description("URI to the file"), mcp.Required()),
+			mcp.WithNumber("min_prefix_len", mcp.Description("Minimum shared-prefix length (in characters) to form a group (default: 4)."), mcp.Min(1), mcp.DefaultNumber(defaultSymbolOutlineMinPrefixLen)),
+			mcp.WithString("output_format", mcp.Description("\"json\" (default) or \"text\".")),
+		), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			uri, err := request.RequireString("uri")
+			if err != nil {
+				logger.Error("bsl_symbol_outline: URI parsing failed", err)
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+			minPrefixLen := request.GetInt("min_prefix_len", defaultSymbolOutlineMinPrefixLen)
+			outputFormat := strings.ToLower(strings.TrimSpace(request.GetString("output_format", "")))
+
+			normalizedURI := bridge.NormalizeURIForLSP(uri)
+
+			outline, err := buildSymbolOutline(bridge, normalizedURI, minPrefixLen)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			if outputFormat == "text" {
+				return mcp.NewToolResultText(formatSymbolOutlineText(outline)), nil
+			}
+
+			payload, err := json.MarshalIndent(outline, "", "  ")
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("bsl_symbol_outline: failed to marshal result: %v", err)), nil
+			}
+			return mcp.NewToolResultText(string(payload)), nil
+		}
+}
+
+// RegisterSymbolOutlineTool registers the bsl_symbol_outline tool.
+func RegisterSymbolOutlineTool(mcpServer ToolServer, bridge interfaces.BridgeInterface) {
+	mcpServer.AddTool(SymbolOutlineTool(bridge))
+}
@@ -0,0 +1,44 @@
+package tools
+
+import "testing"
+
+func TestClusterSymbolsByPrefix(t *testing.T) {
+	names := []string{
+		"ПолучитьКлиента", "ПолучитьЗаказ", "ПолучитьТовар",
+		"УстановитьФлаг", "УстановитьДату",
+		"Инициализация",
+	}
+	var entries []SymbolOutlineEntry
+	for _, n := range names {
+		entries = append(entries, SymbolOutlineEntry{Name: n})
+	}
+
+	groups, ungrouped := clusterSymbolsByPrefix(entries, 4)
+
+	if len(groups) != 2 {
+		t.Fatalf("len(groups) = %d, want 2", len(groups))
+	}
+	if groups[0].Prefix != "Получить" || len(groups[0].Symbols) != 3 {
+		t.Errorf("groups[0] = %q (%d symbols), want \"Получить\" (3 symbols)", groups[0].Prefix, len(groups[0].Symbols))
+	}
+	if groups[1].Prefix != "Установить" || len(groups[1].Symbols) != 2 {
+		t.Errorf("groups[1] = %q (%d symbols), want \"Установить\" (2 symbols)", groups[1].Prefix, len(groups[1].Symbols))
+	}
+
+	if len(ungrouped) != 1 || ungrouped[0].Name != "Инициализация" {
+		t.Errorf("ungrouped = %v, want just [Инициализация]", ungrouped)
+	}
+}
+
+func TestClusterSymbolsByPrefix_NoMatches(t *testing.T) {
+	entries := []SymbolOutlineEntry{{Name: "Альфа"}, {Name: "Бета"}, {Name: "Гамма"}}
+
+	groups, ungrouped := clusterSymbolsByPrefix(entries, 4)
+
+	if len(groups) != 0 {
+		t.Errorf("len(groups) = %d, want 0", len(groups))
+	}
+	if len(ungrouped) != 3 {
+		t.Errorf("len(ungrouped) = %d, want 3", len(ungrouped))
+	}
+}
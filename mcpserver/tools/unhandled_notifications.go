@@ -0,0 +1,115 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"rockerboo/mcp-lsp-bridge/interfaces"
+	"rockerboo/mcp-lsp-bridge/lsp"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+const defaultUnhandledNotificationsLimit = 20
+
+// unhandledNotificationRow flattens one lsp.UnhandledNotificationRingSnapshot
+// entry (which comes back grouped by method) so rows from different
+// methods can be sorted together by time.
+type unhandledNotificationRow struct {
+	Method     string
+	Time       time.Time
+	Params     string
+	Suppressed int
+}
+
+// UnhandledNotificationsTool surfaces lsp/unhandled_notifications.go's ring
+// buffer: LSP notification methods the bridge received but has no handler
+// for. Without this, that traffic is only visible in logs - this turns it
+// into a signal an agent can use to discover, say, that the connected BSL
+// language server is pushing a "bsl/reportIssues" notification the bridge
+// hasn't mapped to a tool yet.
+func UnhandledNotificationsTool(bridge interfaces.BridgeInterface) (mcp.Tool, server.ToolHandlerFunc) {
+	return mcp.NewTool("unhandled_notifications",
+			mcp.WithDescription(`List recent LSP notifications the bridge received but has no handler for.
+
+USAGE:
+- Everything recent: unhandled_notifications
+- One method: unhandled_notifications method="bsl/reportIssues"
+- Last 5 minutes only: unhandled_notifications since="5m"
+
+PARAMETERS:
+- method (optional): exact notification method name to filter to
+- since (optional): Go duration string, e.g. "5m" or "1h" (default: no time filter)
+- limit (optional): max entries to return, most recent first (default 20)
+
+OUTPUT: method, time, emitted-vs-suppressed marker, and a (possibly truncated) params blob per entry.`),
+			mcp.WithDestructiveHintAnnotation(false),
+			mcp.WithString("method", mcp.Description("Restrict to one notification method")),
+			mcp.WithString("since", mcp.Description("Only include entries from the last duration, e.g. \"5m\" (default: no limit)")),
+			mcp.WithNumber("limit", mcp.Description("Max entries to return, most recent first (default 20)"), mcp.Min(1)),
+		), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			method := request.GetString("method", "")
+			limit := request.GetInt("limit", defaultUnhandledNotificationsLimit)
+
+			var cutoff time.Time
+			if sinceArg := request.GetString("since", ""); sinceArg != "" {
+				d, err := time.ParseDuration(sinceArg)
+				if err != nil {
+					return mcp.NewToolResultError(fmt.Sprintf("invalid since %q: %v", sinceArg, err)), nil
+				}
+				cutoff = time.Now().Add(-d)
+			}
+
+			ring := lsp.UnhandledNotificationRingSnapshot(method)
+			rows := make([]unhandledNotificationRow, 0)
+			for m, entries := range ring {
+				for _, entry := range entries {
+					if !cutoff.IsZero() && entry.Time.Before(cutoff) {
+						continue
+					}
+					rows = append(rows, unhandledNotificationRow{
+						Method:     m,
+						Time:       entry.Time,
+						Params:     string(entry.Params),
+						Suppressed: entry.Suppressed,
+					})
+				}
+			}
+
+			sort.Slice(rows, func(i, j int) bool { return rows[i].Time.After(rows[j].Time) })
+			if limit > 0 && len(rows) > limit {
+				rows = rows[:limit]
+			}
+
+			return mcp.NewToolResultText(formatUnhandledNotifications(rows)), nil
+		}
+}
+
+func RegisterUnhandledNotificationsTool(mcpServer ToolServer, bridge interfaces.BridgeInterface) {
+	mcpServer.AddTool(UnhandledNotificationsTool(bridge))
+}
+
+func formatUnhandledNotifications(rows []unhandledNotificationRow) string {
+	if len(rows) == 0 {
+		return "UNHANDLED NOTIFICATIONS:\nNone recorded."
+	}
+
+	var b strings.Builder
+	for _, row := range rows {
+		if row.Suppressed > 0 {
+			fmt.Fprintf(&b, "[%s] %s suppressed=%d (rate-limit rollup)\n", row.Time.Format(time.RFC3339), row.Method, row.Suppressed)
+			continue
+		}
+		params := row.Params
+		if params == "" {
+			params = "(no params)"
+		}
+		fmt.Fprintf(&b, "[%s] %s params=%s\n", row.Time.Format(time.RFC3339), row.Method, params)
+	}
+
+	return fmt.Sprintf("UNHANDLED NOTIFICATIONS (%d):\n%s", len(rows), b.String())
+}
@@ -0,0 +1,73 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	bridgepkg "rockerboo/mcp-lsp-bridge/bridge"
+	"rockerboo/mcp-lsp-bridge/interfaces"
+	"rockerboo/mcp-lsp-bridge/types"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// WarmupStatusTool surfaces bridge.WarmupStrategyReports: which
+// bridge.WarmupStrategy (see bridge/warmup_strategy.go) was resolved for
+// each language server's last warm-up run, how many of its selected files
+// were opened, and any errors from its probe queries. Complements
+// lsp_status's coarse warmup running/done/error summary with per-server,
+// per-strategy detail.
+func WarmupStatusTool(bridge interfaces.BridgeInterface) (mcp.Tool, server.ToolHandlerFunc) {
+	return mcp.NewTool("warmup_status",
+			mcp.WithDescription(`Show the warm-up strategy resolved for each language server and how its last run went.
+
+USAGE: warmup_status
+OUTPUT: per server - strategy name, files opened/selected, and any probe query errors.
+
+NOTE: a server's strategy comes from its LanguageServerConfig.Warmup config (kind "file_glob", "workspace_symbol_probe", or "noop"); nil falls back to the legacy BSL file-glob-plus-probe default. Nothing is reported for a server until its first warm-up run (see lsp_status's warmup fields for whether one is running/scheduled).`),
+			mcp.WithDestructiveHintAnnotation(false),
+		), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			b, ok := bridge.(*bridgepkg.MCPLSPBridge)
+			if !ok {
+				return mcp.NewToolResultError("bridge does not support warm-up strategy reporting"), nil
+			}
+
+			reports := b.WarmupStrategyReports()
+			return mcp.NewToolResultText(formatWarmupStrategyReports(reports)), nil
+		}
+}
+
+func RegisterWarmupStatusTool(mcpServer ToolServer, bridge interfaces.BridgeInterface) {
+	mcpServer.AddTool(WarmupStatusTool(bridge))
+}
+
+func formatWarmupStrategyReports(reports map[types.LanguageServer]bridgepkg.WarmupStrategyStatus) string {
+	if len(reports) == 0 {
+		return "WARM-UP STRATEGIES:\nNone recorded yet."
+	}
+
+	servers := make([]string, 0, len(reports))
+	for serverName := range reports {
+		servers = append(servers, string(serverName))
+	}
+	sort.Strings(servers)
+
+	var b strings.Builder
+	for _, serverName := range servers {
+		status := reports[types.LanguageServer(serverName)]
+		fmt.Fprintf(&b, "%s: strategy=%s files=%d/%d", serverName, status.Strategy, status.FilesOpened, status.FilesTotal)
+		if len(status.ProbeErrors) == 0 {
+			b.WriteString(" probe_errors=none\n")
+			continue
+		}
+		fmt.Fprintf(&b, " probe_errors=%d\n", len(status.ProbeErrors))
+		for _, probeErr := range status.ProbeErrors {
+			fmt.Fprintf(&b, "  - %s\n", probeErr)
+		}
+	}
+
+	return fmt.Sprintf("WARM-UP STRATEGIES (%d servers):\n%s", len(reports), b.String())
+}
@@ -0,0 +1,94 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	bridgepkg "rockerboo/mcp-lsp-bridge/bridge"
+	"rockerboo/mcp-lsp-bridge/interfaces"
+	"rockerboo/mcp-lsp-bridge/lsp"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/myleshyson/lsprotocol-go/protocol"
+)
+
+// WatchStatusTool surfaces bridge.WatchStatus: which language servers have
+// dynamically registered workspace/didChangeWatchedFiles glob watchers (see
+// lsp.WatchRegistry), and the most recent fsnotify-driven dispatches
+// forwardWorkspaceIndexEvents has sent them. Complements workspace_stats/
+// workspace_changed_since (the raw index) with the server-facing
+// notification side of the same file-watch pipeline.
+func WatchStatusTool(bridge interfaces.BridgeInterface) (mcp.Tool, server.ToolHandlerFunc) {
+	return mcp.NewTool("watch_status",
+			mcp.WithDescription(`Show active workspace/didChangeWatchedFiles glob registrations and recent dispatches.
+
+USAGE: watch_status
+OUTPUT: per server with a dynamic registration - its glob patterns; then the most recent coalesced file-change notifications sent to any server.
+
+NOTE: a server with no dynamic registration isn't listed under "registered globs" - it receives every workspace change unfiltered, the pre-existing behavior. See did_change_watched_files for the manual/editor-driven path this auto-forwards in place of.`),
+			mcp.WithDestructiveHintAnnotation(false),
+		), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			b, ok := bridge.(*bridgepkg.MCPLSPBridge)
+			if !ok {
+				return mcp.NewToolResultError("bridge does not support watch status reporting"), nil
+			}
+
+			globs, recent := b.WatchStatus()
+			return mcp.NewToolResultText(formatWatchStatus(globs, recent)), nil
+		}
+}
+
+func RegisterWatchStatusTool(mcpServer ToolServer, bridge interfaces.BridgeInterface) {
+	mcpServer.AddTool(WatchStatusTool(bridge))
+}
+
+func formatWatchStatus(globs map[string][]string, recent []lsp.WatchDispatch) string {
+	var b strings.Builder
+
+	if len(globs) == 0 {
+		b.WriteString("REGISTERED GLOBS: none (every connected server receives unfiltered workspace changes)\n")
+	} else {
+		servers := make([]string, 0, len(globs))
+		for serverName := range globs {
+			servers = append(servers, serverName)
+		}
+		sort.Strings(servers)
+
+		b.WriteString("REGISTERED GLOBS:\n")
+		for _, serverName := range servers {
+			fmt.Fprintf(&b, "%s: %s\n", serverName, strings.Join(globs[serverName], ", "))
+		}
+	}
+
+	if len(recent) == 0 {
+		b.WriteString("RECENT DISPATCHES: none\n")
+		return b.String()
+	}
+
+	fmt.Fprintf(&b, "RECENT DISPATCHES (%d, oldest first):\n", len(recent))
+	for _, d := range recent {
+		fmt.Fprintf(&b, "%s %s %s -> %s\n", d.Time.Format(time.RFC3339), d.Server, fileChangeTypeName(d.Type), d.Uri)
+	}
+
+	return b.String()
+}
+
+// fileChangeTypeName renders an LSP FileChangeType the same way
+// did_change_watched_files.go's tool description documents the enum
+// (1=Created, 2=Changed, 3=Deleted).
+func fileChangeTypeName(t protocol.FileChangeType) string {
+	switch t {
+	case protocol.FileChangeTypeCreated:
+		return "created"
+	case protocol.FileChangeTypeDeleted:
+		return "deleted"
+	case protocol.FileChangeTypeChanged:
+		return "changed"
+	default:
+		return "unknown"
+	}
+}
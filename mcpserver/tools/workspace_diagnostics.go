@@ -0,0 +1,118 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"rockerboo/mcp-lsp-bridge/interfaces"
+	"rockerboo/mcp-lsp-bridge/logger"
+	"rockerboo/mcp-lsp-bridge/lsp"
+	"rockerboo/mcp-lsp-bridge/types"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/myleshyson/lsprotocol-go/protocol"
+)
+
+// workspaceDiagnosticStreamer is the subset of lsp.LanguageClient's pull
+// workspace/diagnostic API that types.LanguageClientInterface doesn't
+// declare, reached the same way diagnosticsWaiter reaches the push API.
+type workspaceDiagnosticStreamer interface {
+	WorkspaceDiagnosticWithProgress(ctx context.Context, identifier string, onStart func(tokenKey string), onProgress func(lsp.ProgressEvent)) (*protocol.WorkspaceDiagnosticReport, error)
+}
+
+// progressCanceler is the subset of lsp.LanguageClient's cancellation API
+// reached the same way workspaceDiagnosticStreamer is.
+type progressCanceler interface {
+	CancelProgress(tokenKey string) bool
+}
+
+// WorkspaceDiagnosticsTool pulls a fresh, whole-workspace diagnostics report
+// via LSP workspace/diagnostic. Unlike live_diagnostics (which only reads
+// whatever the server already pushed), this actively asks the server to
+// (re)compute - which on a large 1C configuration can take minutes, so it
+// reports workDone progress back to the caller and can be cancelled
+// mid-flight.
+func WorkspaceDiagnosticsTool(bridge interfaces.BridgeInterface) (mcp.Tool, server.ToolHandlerFunc) {
+	return mcp.NewTool("workspace_diagnostics",
+			mcp.WithDescription(`Pull a fresh, whole-workspace diagnostics report via LSP workspace/diagnostic. This actively asks the server to recompute diagnostics, as opposed to live_diagnostics which only reads what it already published - expect it to take a while on a large workspace.
+
+USAGE:
+- workspace_diagnostics language="bsl"
+- Attach an MCP progress token (_meta.progressToken) to the call to receive begin/report/end status as it runs
+- Cancel early: cancel_in_flight request_id="<id from the begin notification>"
+
+PARAMETERS: language (optional, default: first connected), min_severity (optional: error|warning|information|hint, default: hint)
+OUTPUT: severity-filtered diagnostics for every URI the server reports on`),
+			mcp.WithDestructiveHintAnnotation(false),
+			mcp.WithString("language", mcp.Description("Language server to query (default: first connected)")),
+			mcp.WithString("min_severity", mcp.Description("Minimum severity to include: error, warning, information, or hint (default: hint)")),
+		), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			if result, ok := CheckReadyOrReturn(bridge); !ok {
+				return result, nil
+			}
+
+			minSeverity, err := parseMinSeverity(request.GetString("min_severity", ""))
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			var lang types.Language
+			if override := request.GetString("language", ""); override != "" {
+				lang = types.Language(strings.ToLower(override))
+			} else {
+				langs := bridge.GetConnectedLanguages()
+				if len(langs) == 0 {
+					return mcp.NewToolResultError("no connected language servers"), nil
+				}
+				lang = langs[0]
+			}
+
+			clients, err := bridge.GetMultiLanguageClients([]string{string(lang)})
+			if err != nil || clients[lang] == nil {
+				return mcp.NewToolResultError(fmt.Sprintf("no LSP client available for language %q", lang)), nil
+			}
+			client := clients[lang]
+
+			streamer, ok := client.(workspaceDiagnosticStreamer)
+			if !ok {
+				return mcp.NewToolResultError(fmt.Sprintf("client for %q does not support workspace/diagnostic", lang)), nil
+			}
+
+			forwarder := newProgressForwarder(ctx, request)
+			canceler, _ := client.(progressCanceler)
+
+			var unregister func()
+			report, err := streamer.WorkspaceDiagnosticWithProgress(ctx, string(lang),
+				func(tokenKey string) {
+					if canceler != nil {
+						unregister = registerInFlight(tokenKey, func() bool { return canceler.CancelProgress(tokenKey) })
+					}
+					forwarder.forward(lsp.ProgressEvent{Kind: "begin", Message: fmt.Sprintf("workspace/diagnostic started; cancel with cancel_in_flight request_id=%q", tokenKey)})
+				},
+				forwarder.forward,
+			)
+			if unregister != nil {
+				unregister()
+			}
+			if err != nil {
+				logger.Error("workspace_diagnostics: request failed", err)
+				return mcp.NewToolResultError(fmt.Sprintf("workspace diagnostics request failed: %v", err)), nil
+			}
+
+			// WorkspaceDiagnosticWithProgress already reconciled "unchanged"
+			// items against LanguageClient's own pull-diagnostic cache, so
+			// every item here carries its items regardless of item.Kind.
+			byURI := make(map[string]lsp.DiagnosticEntry, len(report.Items))
+			for _, item := range report.Items {
+				byURI[string(item.Uri)] = lsp.DiagnosticEntry{Diagnostics: item.Items}
+			}
+
+			return mcp.NewToolResultText(formatLiveDiagnostics(byURI, minSeverity)), nil
+		}
+}
+
+func RegisterWorkspaceDiagnosticsTool(mcpServer ToolServer, bridge interfaces.BridgeInterface) {
+	mcpServer.AddTool(WorkspaceDiagnosticsTool(bridge))
+}
@@ -0,0 +1,74 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+
+	"rockerboo/mcp-lsp-bridge/interfaces"
+	"rockerboo/mcp-lsp-bridge/logger"
+	"rockerboo/mcp-lsp-bridge/utils"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// WorkspaceDocumentColorTool fans DocumentColorTool's single-URI
+// textDocument/documentColor out across every matching file in the
+// workspace. See workspace_document_link.go's doc comment and
+// workspace_fanout.go for the shared machinery.
+func WorkspaceDocumentColorTool(bridge interfaces.BridgeInterface) (mcp.Tool, server.ToolHandlerFunc) {
+	toolOpts := append([]mcp.ToolOption{
+		mcp.WithDescription(`Run textDocument/documentColor across every matching file in the workspace, not just one URI.
+
+USAGE: workspace_document_color directory_filters_json=["-vendor/"] languages_json=["bsl"] max_files=200
+OUTPUT: NDJSON (default) - one {"uri":...,"data":[...]} or {"uri":...,"error":...} record per file.`),
+		mcp.WithDestructiveHintAnnotation(false),
+	}, workspaceFanoutParams()...)
+
+	return mcp.NewTool("workspace_document_color", toolOpts...),
+		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			opts, err := parseWorkspaceFanoutOptions(request)
+			if err != nil {
+				logger.Error("workspace_document_color: option parsing failed", err)
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			if result, ok := CheckReadyOrReturn(bridge); !ok {
+				return result, nil
+			}
+
+			dirs := bridge.AllowedDirectories()
+			if len(dirs) == 0 {
+				return mcp.NewToolResultError("workspace_document_color: no workspace directories configured"), nil
+			}
+
+			filters, err := parseDirectoryFilters(opts.DirectoryFilters)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			paths, truncated, err := enumerateWorkspaceFanoutFiles(dirs[0], opts.Languages, filters, opts.MaxFiles)
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("workspace_document_color: workspace walk failed: %v", err)), nil
+			}
+
+			uris := make([]string, len(paths))
+			for i, p := range paths {
+				uris[i] = utils.FilePathToURI(p)
+			}
+
+			results := runWorkspaceFanout(ctx, bridge, uris, opts, func(uri string) (any, error) {
+				colors, err := bridge.DocumentColor(uri)
+				if err != nil {
+					return nil, err
+				}
+				return colors, nil
+			})
+
+			return writeWorkspaceFanoutResponse(results, opts.OutputFormat, truncated)
+		}
+}
+
+func RegisterWorkspaceDocumentColorTool(mcpServer ToolServer, bridge interfaces.BridgeInterface) {
+	mcpServer.AddTool(WorkspaceDocumentColorTool(bridge))
+}
@@ -0,0 +1,77 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+
+	"rockerboo/mcp-lsp-bridge/interfaces"
+	"rockerboo/mcp-lsp-bridge/logger"
+	"rockerboo/mcp-lsp-bridge/utils"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// WorkspaceDocumentLinkTool fans DocumentLinkTool's single-URI
+// textDocument/documentLink out across every matching file in the
+// workspace (or a directory_filters-scoped subset of it), so an agent
+// doesn't have to enumerate files itself and call document_link once per
+// file. See workspace_fanout.go for the shared enumeration/concurrency/
+// progress machinery this and its document_color/folding_range siblings
+// all reuse.
+func WorkspaceDocumentLinkTool(bridge interfaces.BridgeInterface) (mcp.Tool, server.ToolHandlerFunc) {
+	toolOpts := append([]mcp.ToolOption{
+		mcp.WithDescription(`Run textDocument/documentLink across every matching file in the workspace, not just one URI.
+
+USAGE: workspace_document_link directory_filters_json=["-vendor/"] languages_json=["bsl"] max_files=200
+OUTPUT: NDJSON (default) - one {"uri":...,"data":[...]} or {"uri":...,"error":...} record per file.`),
+		mcp.WithDestructiveHintAnnotation(false),
+	}, workspaceFanoutParams()...)
+
+	return mcp.NewTool("workspace_document_link", toolOpts...),
+		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			opts, err := parseWorkspaceFanoutOptions(request)
+			if err != nil {
+				logger.Error("workspace_document_link: option parsing failed", err)
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			if result, ok := CheckReadyOrReturn(bridge); !ok {
+				return result, nil
+			}
+
+			dirs := bridge.AllowedDirectories()
+			if len(dirs) == 0 {
+				return mcp.NewToolResultError("workspace_document_link: no workspace directories configured"), nil
+			}
+
+			filters, err := parseDirectoryFilters(opts.DirectoryFilters)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			paths, truncated, err := enumerateWorkspaceFanoutFiles(dirs[0], opts.Languages, filters, opts.MaxFiles)
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("workspace_document_link: workspace walk failed: %v", err)), nil
+			}
+
+			uris := make([]string, len(paths))
+			for i, p := range paths {
+				uris[i] = utils.FilePathToURI(p)
+			}
+
+			results := runWorkspaceFanout(ctx, bridge, uris, opts, func(uri string) (any, error) {
+				links, err := bridge.DocumentLink(uri)
+				if err != nil {
+					return nil, err
+				}
+				return links, nil
+			})
+
+			return writeWorkspaceFanoutResponse(results, opts.OutputFormat, truncated)
+		}
+}
+
+func RegisterWorkspaceDocumentLinkTool(mcpServer ToolServer, bridge interfaces.BridgeInterface) {
+	mcpServer.AddTool(WorkspaceDocumentLinkTool(bridge))
+}
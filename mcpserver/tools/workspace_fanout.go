@@ -0,0 +1,416 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	bridgepkg "rockerboo/mcp-lsp-bridge/bridge"
+	"rockerboo/mcp-lsp-bridge/interfaces"
+	"rockerboo/mcp-lsp-bridge/lsp"
+	"rockerboo/mcp-lsp-bridge/types"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// workspaceFanoutMaxFiles is the default cap on how many files one
+// workspace_* tool call will fan a request out across, mirroring
+// moduleCouplingMaxModules's purpose for bsl_module_coupling.
+const workspaceFanoutMaxFiles = 300
+
+// defaultWorkspaceFanoutConcurrency bounds how many textDocument/* requests
+// a workspace_* tool issues at once when the caller doesn't set
+// concurrency.
+const defaultWorkspaceFanoutConcurrency = 8
+
+// defaultWorkspaceFanoutBudget is the wall-clock budget a workspace_* tool
+// call runs under when the caller doesn't set timeout_ms.
+const defaultWorkspaceFanoutBudget = 60 * time.Second
+
+// workspaceFanoutResult is one file's outcome, the NDJSON record shape
+// workspace_document_link/workspace_document_color/workspace_folding_range
+// all share: exactly one of Data/Error is set, so one failing file doesn't
+// abort the batch (see buildWorkspaceFanoutReport).
+type workspaceFanoutResult struct {
+	Uri   string `json:"uri"`
+	Data  any    `json:"data,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+// workspaceFanoutOptions carries the parameters common to every workspace_*
+// fan-out tool.
+type workspaceFanoutOptions struct {
+	DirectoryFilters []string
+	Languages        []string
+	MaxFiles         int
+	Concurrency      int
+	Budget           time.Duration
+	OutputFormat     string
+}
+
+// workspaceFanoutParams are the mcp.ToolOption declarations every
+// workspace_* fan-out tool shares, appended after its own tool-specific
+// options (none of these are required).
+func workspaceFanoutParams() []mcp.ToolOption {
+	return []mcp.ToolOption{
+		mcp.WithString("directory_filters_json", mcp.Description(`JSON array of gopls-style include/exclude patterns, applied in order: [\"-vendor/\", \"+src/\", \"-**/_generated/**\"]. Default: no filtering.`)),
+		mcp.WithString("languages_json", mcp.Description(`JSON array of language server IDs to scan (default: ["bsl"]).`)),
+		mcp.WithNumber("max_files", mcp.Description("Cap on how many files this call will fan out across."), mcp.Min(1), mcp.DefaultNumber(workspaceFanoutMaxFiles)),
+		mcp.WithNumber("concurrency", mcp.Description("Max requests in flight at once."), mcp.Min(1), mcp.DefaultNumber(defaultWorkspaceFanoutConcurrency)),
+		mcp.WithNumber("timeout_ms", mcp.Description("Wall-clock budget for the whole call, in milliseconds."), mcp.Min(1), mcp.DefaultNumber(int(defaultWorkspaceFanoutBudget/time.Millisecond))),
+		mcp.WithString("output_format", mcp.Description(`"ndjson" (default - one JSON record per file), "json" (single array), or "text".`)),
+	}
+}
+
+// parseWorkspaceFanoutOptions reads directory_filters_json, languages_json,
+// max_files, concurrency, and timeout_ms off request, the same
+// optional-JSON-array-string convention did_change_watched_files.go's
+// changes_json and batch_range_formatting.go's requests_json use for list
+// parameters - this tree has no native array-typed MCP param in use
+// anywhere to follow instead.
+func parseWorkspaceFanoutOptions(request mcp.CallToolRequest) (workspaceFanoutOptions, error) {
+	opts := workspaceFanoutOptions{
+		Languages:   []string{"bsl"},
+		MaxFiles:    workspaceFanoutMaxFiles,
+		Concurrency: defaultWorkspaceFanoutConcurrency,
+		Budget:      defaultWorkspaceFanoutBudget,
+	}
+
+	if raw := strings.TrimSpace(request.GetString("directory_filters_json", "")); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &opts.DirectoryFilters); err != nil {
+			return opts, fmt.Errorf("invalid directory_filters_json: %w", err)
+		}
+	}
+
+	if raw := strings.TrimSpace(request.GetString("languages_json", "")); raw != "" {
+		var languages []string
+		if err := json.Unmarshal([]byte(raw), &languages); err != nil {
+			return opts, fmt.Errorf("invalid languages_json: %w", err)
+		}
+		if len(languages) > 0 {
+			opts.Languages = languages
+		}
+	}
+
+	if maxFiles := request.GetInt("max_files", 0); maxFiles > 0 {
+		opts.MaxFiles = maxFiles
+	}
+	if concurrency := request.GetInt("concurrency", 0); concurrency > 0 {
+		opts.Concurrency = concurrency
+	}
+	if timeoutMs := request.GetInt("timeout_ms", 0); timeoutMs > 0 {
+		opts.Budget = time.Duration(timeoutMs) * time.Millisecond
+	}
+
+	opts.OutputFormat = strings.ToLower(strings.TrimSpace(request.GetString("output_format", "")))
+
+	return opts, nil
+}
+
+// directoryFilter is one compiled entry of a gopls-style directory_filters
+// list: "+pattern" re-includes, "-pattern" excludes, later entries in the
+// list override earlier ones for paths they both match.
+type directoryFilter struct {
+	include bool
+	re      *regexp.Regexp
+}
+
+// parseDirectoryFilters compiles gopls-style include/exclude patterns
+// (e.g. "+src/", "-vendor/", "-**/_generated/**") into directoryFilters,
+// evaluated in order by allowedByDirectoryFilters.
+func parseDirectoryFilters(patterns []string) ([]directoryFilter, error) {
+	filters := make([]directoryFilter, 0, len(patterns))
+	for _, p := range patterns {
+		p = strings.TrimSpace(p)
+		if p == "" {
+			continue
+		}
+
+		var include bool
+		switch p[0] {
+		case '+':
+			include = true
+		case '-':
+			include = false
+		default:
+			return nil, fmt.Errorf("directory filter %q must start with '+' or '-'", p)
+		}
+
+		pattern := strings.TrimSuffix(p[1:], "/")
+		re, err := directoryGlobToRegexp(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("directory filter %q: %w", p, err)
+		}
+
+		filters = append(filters, directoryFilter{include: include, re: re})
+	}
+	return filters, nil
+}
+
+// directoryGlobToRegexp translates the same "**"/"*"/"?" glob dialect
+// lsp.globToRegexp compiles FileSystemWatcher patterns with (see
+// lsp/watch_registry.go) into an anchored regexp, additionally matching as
+// a directory-prefix: "src" matches "src" itself and anything under it.
+func directoryGlobToRegexp(pattern string) (*regexp.Regexp, error) {
+	var sb strings.Builder
+	sb.WriteString("^")
+
+	for i := 0; i < len(pattern); {
+		switch {
+		case strings.HasPrefix(pattern[i:], "**"):
+			sb.WriteString(".*")
+			i += 2
+		case pattern[i] == '*':
+			sb.WriteString("[^/]*")
+			i++
+		case pattern[i] == '?':
+			sb.WriteString("[^/]")
+			i++
+		default:
+			sb.WriteString(regexp.QuoteMeta(pattern[i : i+1]))
+			i++
+		}
+	}
+
+	// Match the pattern itself or anything below it, so "-vendor" excludes
+	// both the "vendor" directory entry and every file under it.
+	sb.WriteString("(/.*)?$")
+	return regexp.Compile(sb.String())
+}
+
+// allowedByDirectoryFilters reports whether relPath (workspace-relative,
+// slash-separated) should be scanned: with no filters, everything is
+// allowed; otherwise the last matching filter in order wins, and the
+// default with at least one filter present is to exclude (gopls'
+// "directoryFilters" semantics - an explicit "+" allowlist is opt-in).
+func allowedByDirectoryFilters(filters []directoryFilter, relPath string) bool {
+	if len(filters) == 0 {
+		return true
+	}
+
+	allowed := false
+	for _, f := range filters {
+		if f.re.MatchString(relPath) {
+			allowed = f.include
+		}
+	}
+	return allowed
+}
+
+// enumerateWorkspaceFanoutFiles walks workspaceDir collecting up to
+// maxFiles paths matching any of languages' default extensions (see
+// defaultTextSearchExtensions) and allowed by filters.
+func enumerateWorkspaceFanoutFiles(workspaceDir string, languages []string, filters []directoryFilter, maxFiles int) ([]string, bool, error) {
+	exts := make(map[string]struct{})
+	for _, lang := range languages {
+		for _, e := range defaultTextSearchExtensions(types.Language(lang)) {
+			exts[strings.ToLower(e)] = struct{}{}
+		}
+	}
+
+	ignoredDirs := map[string]struct{}{
+		".git": {}, ".hg": {}, ".svn": {}, ".idea": {}, ".vscode": {},
+		"node_modules": {}, "vendor": {}, "dist": {}, "build": {}, "out": {}, "target": {}, "_bin": {},
+	}
+
+	errStopWalk := errors.New("workspace_fanout: stop walk")
+	var files []string
+	truncated := false
+
+	walkErr := filepath.WalkDir(workspaceDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+
+		relPath, relErr := filepath.Rel(workspaceDir, path)
+		if relErr != nil {
+			relPath = path
+		}
+		relPath = filepath.ToSlash(relPath)
+
+		if d.IsDir() {
+			if _, ok := ignoredDirs[strings.ToLower(d.Name())]; ok {
+				return fs.SkipDir
+			}
+			if relPath != "." && !allowedByDirectoryFilters(filters, relPath) {
+				return fs.SkipDir
+			}
+			return nil
+		}
+
+		if _, ok := exts[strings.ToLower(filepath.Ext(d.Name()))]; !ok {
+			return nil
+		}
+		if !allowedByDirectoryFilters(filters, relPath) {
+			return nil
+		}
+
+		if len(files) >= maxFiles {
+			truncated = true
+			return errStopWalk
+		}
+		files = append(files, path)
+		return nil
+	})
+
+	if walkErr != nil && !errors.Is(walkErr, errStopWalk) {
+		return files, truncated, walkErr
+	}
+	return files, truncated, nil
+}
+
+// runWorkspaceFanout issues work for every uri in uris, bounded to
+// opts.Concurrency requests in flight at once and opts.Budget total
+// wall-clock time, and reports throughput through a synthetic
+// ProgressTracker token on the first connected client it finds (see
+// emitWorkspaceFanoutProgress) so lsp_status/progress_stream can watch it
+// the same way a real server-reported $/progress stream would. A file
+// whose work returns an error gets an Error-only result rather than
+// aborting the rest of the batch.
+func runWorkspaceFanout(ctx context.Context, bridge interfaces.BridgeInterface, uris []string, opts workspaceFanoutOptions, work func(uri string) (any, error)) []workspaceFanoutResult {
+	budgetCtx, cancel := context.WithTimeout(ctx, opts.Budget)
+	defer cancel()
+
+	progress, tokenKey := newWorkspaceFanoutProgress(bridge, len(uris))
+	if progress != nil {
+		defer progress.Emit(tokenKey, "end", "workspace fan-out", fmt.Sprintf("%d files", len(uris)), nil)
+	}
+
+	results := make([]workspaceFanoutResult, len(uris))
+	sem := make(chan struct{}, opts.Concurrency)
+	var wg sync.WaitGroup
+	var done int64
+	var mu sync.Mutex
+
+	for i, uri := range uris {
+		if budgetCtx.Err() != nil {
+			mu.Lock()
+			results[i] = workspaceFanoutResult{Uri: uri, Error: "skipped: wall-clock budget exceeded"}
+			mu.Unlock()
+			continue
+		}
+
+		wg.Add(1)
+		go func(i int, uri string) {
+			defer wg.Done()
+
+			select {
+			case sem <- struct{}{}:
+			case <-budgetCtx.Done():
+				mu.Lock()
+				results[i] = workspaceFanoutResult{Uri: uri, Error: "skipped: wall-clock budget exceeded"}
+				mu.Unlock()
+				return
+			}
+			defer func() { <-sem }()
+
+			data, err := work(uri)
+
+			mu.Lock()
+			if err != nil {
+				results[i] = workspaceFanoutResult{Uri: uri, Error: err.Error()}
+			} else {
+				results[i] = workspaceFanoutResult{Uri: uri, Data: data}
+			}
+			done++
+			n := done
+			mu.Unlock()
+
+			reportWorkspaceFanoutProgress(progress, tokenKey, int(n), len(uris))
+		}(i, uri)
+	}
+
+	wg.Wait()
+	return results
+}
+
+// fanoutProgressReporter is the subset of lsp.ProgressTracker
+// runWorkspaceFanout needs - a local duck-typed interface for the same
+// reason progressProvider (progress_stream.go) is: there's no interfaces
+// package in this tree to declare it in centrally.
+type fanoutProgressReporter interface {
+	Emit(tokenKey, kind, title, message string, percentage *uint32)
+}
+
+// newWorkspaceFanoutProgress picks the first connected language client's
+// ProgressTracker to report synthetic progress on, and emits its "begin"
+// event. Returns a nil reporter (safe to call Emit-via-nil-check on,
+// mirroring textSearchProgressReporter's nil-safety) if no client is
+// connected or the bridge doesn't expose one.
+func newWorkspaceFanoutProgress(bridge interfaces.BridgeInterface, total int) (fanoutProgressReporter, string) {
+	b, ok := bridge.(*bridgepkg.MCPLSPBridge)
+	if !ok {
+		return nil, ""
+	}
+
+	for _, client := range b.ListConnectedClients() {
+		provider, ok := client.(progressProvider)
+		if !ok {
+			continue
+		}
+
+		tracker := provider.Progress()
+		tokenKey := lsp.NewSyntheticProgressToken()
+		tracker.Emit(tokenKey, "begin", "workspace fan-out", fmt.Sprintf("0/%d files", total), nil)
+		return tracker, tokenKey
+	}
+
+	return nil, ""
+}
+
+func reportWorkspaceFanoutProgress(progress fanoutProgressReporter, tokenKey string, done, total int) {
+	if progress == nil {
+		return
+	}
+	pct := uint32(0)
+	if total > 0 {
+		pct = uint32(done * 100 / total)
+	}
+	progress.Emit(tokenKey, "report", "workspace fan-out", fmt.Sprintf("%d/%d files", done, total), &pct)
+}
+
+// writeWorkspaceFanoutResponse renders results per output_format: "ndjson"
+// (default - one JSON record per file, so a caller can start processing
+// before a huge workspace scan finishes rather than waiting for one giant
+// document), "json" (a single indented array), or "text" (one summary line
+// per file). truncated notes when enumerateWorkspaceFanoutFiles stopped
+// early at max_files.
+func writeWorkspaceFanoutResponse(results []workspaceFanoutResult, outputFormat string, truncated bool) (*mcp.CallToolResult, error) {
+	switch outputFormat {
+	case "json":
+		payload, err := json.MarshalIndent(results, "", "  ")
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("failed to marshal results: %v", err)), nil
+		}
+		return mcp.NewToolResultText(string(payload)), nil
+
+	case "text":
+		var sb strings.Builder
+		fmt.Fprintf(&sb, "WORKSPACE_FANOUT|files=%d|truncated=%t\n", len(results), truncated)
+		for _, r := range results {
+			if r.Error != "" {
+				fmt.Fprintf(&sb, "ERROR %s: %s\n", r.Uri, r.Error)
+				continue
+			}
+			fmt.Fprintf(&sb, "OK    %s\n", r.Uri)
+		}
+		return mcp.NewToolResultText(strings.TrimRight(sb.String(), "\n")), nil
+
+	default: // "ndjson"
+		records := make([]any, len(results))
+		for i, r := range results {
+			records[i] = r
+		}
+		if truncated {
+			records = append(records, map[string]any{"truncated": true})
+		}
+		return writeAnalysisNDJSON(records)
+	}
+}
@@ -0,0 +1,74 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+
+	"rockerboo/mcp-lsp-bridge/interfaces"
+	"rockerboo/mcp-lsp-bridge/logger"
+	"rockerboo/mcp-lsp-bridge/utils"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// WorkspaceFoldingRangeTool fans FoldingRangeTool's single-URI
+// textDocument/foldingRange out across every matching file in the
+// workspace. See workspace_document_link.go's doc comment and
+// workspace_fanout.go for the shared machinery.
+func WorkspaceFoldingRangeTool(bridge interfaces.BridgeInterface) (mcp.Tool, server.ToolHandlerFunc) {
+	toolOpts := append([]mcp.ToolOption{
+		mcp.WithDescription(`Run textDocument/foldingRange across every matching file in the workspace, not just one URI.
+
+USAGE: workspace_folding_range directory_filters_json=["-vendor/"] languages_json=["bsl"] max_files=200
+OUTPUT: NDJSON (default) - one {"uri":...,"data":[...]} or {"uri":...,"error":...} record per file.`),
+		mcp.WithDestructiveHintAnnotation(false),
+	}, workspaceFanoutParams()...)
+
+	return mcp.NewTool("workspace_folding_range", toolOpts...),
+		func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			opts, err := parseWorkspaceFanoutOptions(request)
+			if err != nil {
+				logger.Error("workspace_folding_range: option parsing failed", err)
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			if result, ok := CheckReadyOrReturn(bridge); !ok {
+				return result, nil
+			}
+
+			dirs := bridge.AllowedDirectories()
+			if len(dirs) == 0 {
+				return mcp.NewToolResultError("workspace_folding_range: no workspace directories configured"), nil
+			}
+
+			filters, err := parseDirectoryFilters(opts.DirectoryFilters)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			paths, truncated, err := enumerateWorkspaceFanoutFiles(dirs[0], opts.Languages, filters, opts.MaxFiles)
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("workspace_folding_range: workspace walk failed: %v", err)), nil
+			}
+
+			uris := make([]string, len(paths))
+			for i, p := range paths {
+				uris[i] = utils.FilePathToURI(p)
+			}
+
+			results := runWorkspaceFanout(ctx, bridge, uris, opts, func(uri string) (any, error) {
+				ranges, err := bridge.FoldingRange(uri)
+				if err != nil {
+					return nil, err
+				}
+				return ranges, nil
+			})
+
+			return writeWorkspaceFanoutResponse(results, opts.OutputFormat, truncated)
+		}
+}
+
+func RegisterWorkspaceFoldingRangeTool(mcpServer ToolServer, bridge interfaces.BridgeInterface) {
+	mcpServer.AddTool(WorkspaceFoldingRangeTool(bridge))
+}
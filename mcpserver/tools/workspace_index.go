@@ -0,0 +1,117 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	bridgepkg "rockerboo/mcp-lsp-bridge/bridge"
+	"rockerboo/mcp-lsp-bridge/interfaces"
+	"rockerboo/mcp-lsp-bridge/logger"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// WorkspaceStatsTool reports the bridge's incremental file index's own
+// health (see bridge.MCPLSPBridge.WorkspaceIndex / workspace.Index), so an
+// agent can tell whether the server has actually observed a given edit
+// before trusting LSP results against it.
+func WorkspaceStatsTool(bridge interfaces.BridgeInterface) (mcp.Tool, server.ToolHandlerFunc) {
+	return mcp.NewTool("workspace_stats",
+			mcp.WithDescription(`Report the bridge's file-watching index health: total indexed files, whether it's watching via fsnotify or falling back to periodic polling, and when it last saw a change.
+
+USAGE: workspace_stats
+OUTPUT: total files tracked, watcher mode, scan count/duration, last event time`),
+			mcp.WithDestructiveHintAnnotation(false),
+		), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			b, ok := bridge.(*bridgepkg.MCPLSPBridge)
+			if !ok {
+				return mcp.NewToolResultError("workspace_stats is not supported by this bridge"), nil
+			}
+
+			idx, err := b.WorkspaceIndex()
+			if err != nil {
+				logger.Error("workspace_stats: index unavailable", err)
+				return mcp.NewToolResultError(fmt.Sprintf("workspace index unavailable: %v", err)), nil
+			}
+
+			stats := idx.Stats()
+
+			var out strings.Builder
+			out.WriteString("WORKSPACE INDEX:\n")
+			fmt.Fprintf(&out, "Root: %s\n", stats.Root)
+			fmt.Fprintf(&out, "Files tracked: %d\n", stats.TotalFiles)
+			fmt.Fprintf(&out, "Watcher mode: %s\n", stats.WatcherMode)
+			fmt.Fprintf(&out, "Scans: %d (last at %s, took %s)\n",
+				stats.ScanCount, formatTimeOrNever(stats.LastScanAt), stats.LastScanDuration)
+			if stats.WatcherMode == "poll" {
+				fmt.Fprintf(&out, "Poll duty cycle: %.1f%%\n", stats.DutyCyclePercent)
+			}
+			fmt.Fprintf(&out, "Last event seen: %s\n", formatTimeOrNever(stats.LastEventAt))
+
+			return mcp.NewToolResultText(out.String()), nil
+		}
+}
+
+func RegisterWorkspaceStatsTool(mcpServer ToolServer, bridge interfaces.BridgeInterface) {
+	mcpServer.AddTool(WorkspaceStatsTool(bridge))
+}
+
+// WorkspaceChangedSinceTool reports every file the workspace index has seen
+// change since a given Unix timestamp, from its in-memory recent-event log
+// (see workspace.Index.ChangedSince) - useful after issuing a batch of
+// edits to confirm the server noticed all of them before querying it.
+func WorkspaceChangedSinceTool(bridge interfaces.BridgeInterface) (mcp.Tool, server.ToolHandlerFunc) {
+	return mcp.NewTool("workspace_changed_since",
+			mcp.WithDescription(`List files the workspace index has seen created/changed/deleted since a given Unix timestamp.
+
+USAGE: workspace_changed_since since_unix=1712000000
+PARAMETERS: since_unix (required, Unix seconds)
+OUTPUT: one line per observed change, oldest first`),
+			mcp.WithDestructiveHintAnnotation(false),
+			mcp.WithNumber("since_unix", mcp.Description("Unix timestamp (seconds); changes observed after this are returned"), mcp.Required()),
+		), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			sinceUnix, err := request.RequireInt("since_unix")
+			if err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+
+			b, ok := bridge.(*bridgepkg.MCPLSPBridge)
+			if !ok {
+				return mcp.NewToolResultError("workspace_changed_since is not supported by this bridge"), nil
+			}
+
+			idx, err := b.WorkspaceIndex()
+			if err != nil {
+				logger.Error("workspace_changed_since: index unavailable", err)
+				return mcp.NewToolResultError(fmt.Sprintf("workspace index unavailable: %v", err)), nil
+			}
+
+			events := idx.ChangedSince(time.Unix(int64(sinceUnix), 0))
+			if len(events) == 0 {
+				return mcp.NewToolResultText("No changes observed since that time."), nil
+			}
+
+			var out strings.Builder
+			fmt.Fprintf(&out, "CHANGES SINCE %d:\n", sinceUnix)
+			for _, ev := range events {
+				fmt.Fprintf(&out, "%s %s (seen %s)\n", strings.ToUpper(string(ev.Op)), ev.Path, ev.Seen.Format(time.RFC3339))
+			}
+
+			return mcp.NewToolResultText(out.String()), nil
+		}
+}
+
+func RegisterWorkspaceChangedSinceTool(mcpServer ToolServer, bridge interfaces.BridgeInterface) {
+	mcpServer.AddTool(WorkspaceChangedSinceTool(bridge))
+}
+
+// formatTimeOrNever renders t as RFC3339, or "never" for the zero value.
+func formatTimeOrNever(t time.Time) string {
+	if t.IsZero() {
+		return "never"
+	}
+	return t.Format(time.RFC3339)
+}
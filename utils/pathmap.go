@@ -5,14 +5,135 @@ import (
 	"fmt"
 	"os"
 	"path"
+	"path/filepath"
+	"sort"
 	"strings"
+	"sync"
+	"time"
 )
 
-// DockerPathMapper handles path conversion between host system and Docker container
+// defaultSymlinkCacheTTL bounds how long WithSymlinkResolution trusts a
+// previously resolved path before re-walking it with filepath.EvalSymlinks.
+const defaultSymlinkCacheTTL = 30 * time.Second
+
+// ErrSymlinkEscape is returned (wrapped) by HostToContainer, ContainerToHost
+// and ValidatePath when WithSymlinkResolution is enabled and a path, once
+// its symlinks are resolved, falls outside the mount it textually matched -
+// e.g. a symlink planted inside a mounted workspace that points at /etc.
+// Callers (mcpserver/tools handlers) can match on this with errors.Is to
+// report a clean "path escapes workspace" error instead of a raw
+// filesystem one.
+var ErrSymlinkEscape = errors.New("docker path mapper: path escapes mount via symlink")
+
+// Mount is one host<->container directory mapping, the unit
+// DockerPathMapper matches paths against. ReadOnly is informational only
+// (callers decide what to do with it); DockerPathMapper itself never
+// refuses a write based on it.
+//
+// Subpath, if set, confines the mount to a relative subdirectory of Host -
+// e.g. a wide host mount ("/srv/1c-projects") can still only let the LSP
+// bridge see/index "/srv/1c-projects/tenant-a" by setting Subpath to
+// "tenant-a". It must be relative and contain no ".." segments (checked at
+// construction); HostToContainer/ContainerToHost/ValidatePath all enforce
+// it at the effective root of Host+"/"+Subpath, not just Host.
+type Mount struct {
+	Host      string // e.g. "D:/My Projects/Projects 1C" (normalized with forward slashes)
+	Container string // e.g. "/projects/main"
+	Subpath   string // e.g. "tenant-a"; empty means the whole Host mount is in scope
+	ReadOnly  bool
+}
+
+// effectiveHostRoot returns the host path every access through this mount
+// is confined to: Host itself, or Host/Subpath when Subpath is set.
+func (m Mount) effectiveHostRoot() string {
+	if m.Subpath == "" {
+		return m.Host
+	}
+	return path.Join(m.Host, m.Subpath)
+}
+
+// effectiveContainerRoot is effectiveHostRoot's container-side counterpart:
+// Subpath is mirrored onto the container path the same way matchHost
+// mirrors it when translating, so the two sides stay confined to the same
+// logical subtree.
+func (m Mount) effectiveContainerRoot() string {
+	if m.Subpath == "" {
+		return m.Container
+	}
+	return path.Join(m.Container, m.Subpath)
+}
+
+// MountMatch is the result of matching a path against a DockerPathMapper's
+// mount table: which Mount it fell under, plus both sides of the
+// conversion, so a tool can report which workspace a file belongs to
+// instead of just a bare path.
+type MountMatch struct {
+	Mount         Mount
+	HostPath      string
+	ContainerPath string
+}
+
+// DockerPathMapper handles path conversion between host system and Docker
+// container across one or more mounted directories (Docker -v style).
+// mounts is sorted longest-host-prefix-first at construction so that a
+// nested mount (e.g. /projects/shared-lib under /projects) is matched
+// before its broader parent.
+//
+// mu guards mounts: a mapper built via NewDockerPathMapperFromDocker can be
+// told to re-query the engine and swap its mount table in place (see
+// Refresh), while HostToContainer/ContainerToHost/etc. keep running
+// concurrently against whatever table was current at call time.
 type DockerPathMapper struct {
-	hostRoot      string // D:/My Projects/Projects 1C (normalized with forward slashes)
-	containerRoot string // /projects
-	enabled       bool   // true if working in Docker mode
+	mu      sync.RWMutex
+	mounts  []Mount
+	enabled bool // true if at least one mount is configured (Docker mode)
+
+	// refresh re-queries the mount table's source (e.g. the Docker Engine
+	// API); nil for mappers built from an explicit table or env vars, in
+	// which case Refresh is a no-op.
+	refresh func() ([]Mount, error)
+
+	// resolveSymlinks turns on the opt-in hardening pass described on
+	// WithSymlinkResolution. Off by default: it costs a filepath.EvalSymlinks
+	// syscall walk per lookup (amortized by symlinkCache).
+	resolveSymlinks bool
+	symlinkCacheTTL time.Duration
+
+	symlinkCacheMu sync.Mutex
+	symlinkCache   map[string]symlinkCacheEntry
+}
+
+// symlinkCacheEntry is a cached filepath.EvalSymlinks result. Real
+// per-inode keying would need a platform-specific stat syscall (this
+// package otherwise stays portable to Windows hosts), so entries are keyed
+// on the input path instead - it still bounds repeated resolution of the
+// same path within the TTL window, which is what a single LSP request
+// touching the same file a few times actually needs.
+type symlinkCacheEntry struct {
+	resolved string
+	expires  time.Time
+}
+
+// PathMapperOption configures optional DockerPathMapper behavior at
+// construction time; see WithSymlinkResolution.
+type PathMapperOption func(*DockerPathMapper)
+
+// WithSymlinkResolution enables symlink-aware path confinement: after a
+// path matches a mount by prefix, HostToContainer/ContainerToHost/
+// ValidatePath additionally resolve its real location with
+// filepath.EvalSymlinks and re-check that against the mount table, so a
+// symlink planted inside a mounted workspace can't be used to read or
+// write outside it. A resolved path is cached for ttl (defaultSymlinkCacheTTL
+// if ttl <= 0) to keep the common case - the same few files touched
+// repeatedly by one LSP session - cheap.
+func WithSymlinkResolution(ttl time.Duration) PathMapperOption {
+	if ttl <= 0 {
+		ttl = defaultSymlinkCacheTTL
+	}
+	return func(dpm *DockerPathMapper) {
+		dpm.resolveSymlinks = true
+		dpm.symlinkCacheTTL = ttl
+	}
 }
 
 // IsWindowsAbsPath checks if a path is a Windows absolute path (e.g., C:\... or C:/...)
@@ -47,43 +168,187 @@ func pathsEqualFold(a, b string) bool {
 }
 
 // hasPrefixFold checks if path has prefix using case-insensitive comparison
-// Both paths should already be normalized with forward slashes
+// at a path-segment boundary: p must equal prefix exactly or have a '/'
+// immediately after it, so a sibling directory whose name happens to be a
+// string-prefix of another (e.g. "tenant-ab" vs. a mount confined to
+// "tenant-a") is never mistaken for being inside it.
+// Both paths should already be normalized with forward slashes.
 func hasPrefixFold(p, prefix string) bool {
-	if len(p) < len(prefix) {
+	if len(p) < len(prefix) || !strings.EqualFold(p[:len(prefix)], prefix) {
 		return false
 	}
-	return strings.EqualFold(p[:len(prefix)], prefix)
+	return len(p) == len(prefix) || p[len(prefix)] == '/'
 }
 
-// NewDockerPathMapper creates a new DockerPathMapper instance
-func NewDockerPathMapper(hostRoot, containerRoot string) (*DockerPathMapper, error) {
-	if hostRoot == "" {
-		return nil, errors.New("host root path cannot be empty")
+// hasPathPrefix is hasPrefixFold's case-sensitive counterpart, for
+// container-side paths: always POSIX inside the container, never needing
+// the case-insensitive handling a Windows host mount does.
+func hasPathPrefix(p, prefix string) bool {
+	if len(p) < len(prefix) || p[:len(prefix)] != prefix {
+		return false
 	}
-	if containerRoot == "" {
-		return nil, errors.New("container root path cannot be empty")
+	return len(p) == len(prefix) || p[len(prefix)] == '/'
+}
+
+// NewDockerPathMapper creates a DockerPathMapper from an explicit mount
+// table. Mounts are validated (non-empty host, absolute container path)
+// and sorted longest-host-prefix-first so HostToContainer/ContainerToHost
+// resolve the most specific mount first. opts apply optional hardening such
+// as WithSymlinkResolution.
+func NewDockerPathMapper(mounts []Mount, opts ...PathMapperOption) (*DockerPathMapper, error) {
+	normalized, err := normalizeMounts(mounts)
+	if err != nil {
+		return nil, err
+	}
+
+	dpm := &DockerPathMapper{mounts: normalized, enabled: true}
+	for _, opt := range opts {
+		opt(dpm)
+	}
+	return dpm, nil
+}
+
+// normalizeMounts validates each mount (non-empty host, absolute container
+// path) and returns a copy sorted longest-host-prefix-first. Shared by
+// NewDockerPathMapper and Refresh so both apply the same rules.
+func normalizeMounts(mounts []Mount) ([]Mount, error) {
+	if len(mounts) == 0 {
+		return nil, errors.New("at least one mount is required")
+	}
+
+	normalized := make([]Mount, len(mounts))
+	for i, m := range mounts {
+		if m.Host == "" {
+			return nil, errors.New("mount host path cannot be empty")
+		}
+		if m.Container == "" {
+			return nil, errors.New("mount container path cannot be empty")
+		}
+
+		cleanContainer := strings.TrimSuffix(normalizePathSeparators(m.Container), "/")
+		if !strings.HasPrefix(cleanContainer, "/") {
+			return nil, fmt.Errorf("mount container path must be absolute (starting with /): %s", m.Container)
+		}
+
+		cleanSubpath, err := normalizeSubpath(m.Subpath)
+		if err != nil {
+			return nil, fmt.Errorf("mount %s: %w", m.Host, err)
+		}
+
+		normalized[i] = Mount{
+			Host:      normalizePathSeparators(m.Host),
+			Container: cleanContainer,
+			Subpath:   cleanSubpath,
+			ReadOnly:  m.ReadOnly,
+		}
+	}
+
+	sort.SliceStable(normalized, func(i, j int) bool {
+		return len(normalized[i].effectiveHostRoot()) > len(normalized[j].effectiveHostRoot())
+	})
+
+	return normalized, nil
+}
+
+// normalizeSubpath validates and cleans a Mount's Subpath: it must be
+// relative (no leading "/" or Windows drive letter) and, once cleaned,
+// contain no ".." segments that would climb back above Host.
+func normalizeSubpath(subpath string) (string, error) {
+	if subpath == "" {
+		return "", nil
+	}
+
+	normalized := strings.ReplaceAll(subpath, "\\", "/")
+	if strings.HasPrefix(normalized, "/") || IsWindowsAbsPath(normalized) {
+		return "", fmt.Errorf("subpath %q must be relative", subpath)
+	}
+
+	cleaned := path.Clean(normalized)
+	if cleaned == "." || cleaned == "" {
+		return "", nil
+	}
+	if cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+		return "", fmt.Errorf("subpath %q must not contain .. segments", subpath)
+	}
+
+	return cleaned, nil
+}
+
+// parseMountSpec parses one "host:container[:ro]" entry (Docker -v syntax).
+// The host side may itself contain a colon as a Windows drive letter
+// (e.g. "D:/Projects:/projects"), so the split skips over a leading drive
+// letter before looking for the host/container separator.
+func parseMountSpec(spec string) (Mount, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		return Mount{}, errors.New("empty mount spec")
+	}
+
+	readOnly := false
+	if rest, ok := strings.CutSuffix(spec, ":ro"); ok {
+		readOnly = true
+		spec = rest
+	} else if rest, ok := strings.CutSuffix(spec, ":rw"); ok {
+		spec = rest
 	}
 
-	// Normalize host root path - convert backslashes to forward slashes
-	// This works correctly on both Linux and Windows
-	cleanHostRoot := normalizePathSeparators(hostRoot)
+	driveOffset := 0
+	if IsWindowsAbsPath(spec) {
+		driveOffset = 2 // skip the "X:" drive-letter colon
+	}
 
-	// For container paths, use simple string cleaning to avoid Windows path issues
-	cleanContainerRoot := strings.TrimSuffix(containerRoot, "/")
-	if !strings.HasPrefix(cleanContainerRoot, "/") {
-		return nil, errors.New("container root must be an absolute path starting with /")
+	idx := strings.IndexByte(spec[driveOffset:], ':')
+	if idx < 0 {
+		return Mount{}, fmt.Errorf("invalid mount spec %q: expected host:container[:ro]", spec)
 	}
+	idx += driveOffset
 
-	return &DockerPathMapper{
-		hostRoot:      cleanHostRoot,
-		containerRoot: cleanContainerRoot,
-		enabled:       true,
-	}, nil
+	host := spec[:idx]
+	container := spec[idx+1:]
+	if host == "" || container == "" {
+		return Mount{}, fmt.Errorf("invalid mount spec %q: expected host:container[:ro]", spec)
+	}
+
+	return Mount{Host: host, Container: container, ReadOnly: readOnly}, nil
+}
+
+// parseMountsEnv parses PROJECTS_MOUNTS: a comma-separated list of
+// "host:container[:ro]" entries, one per -v flag the container was
+// actually started with.
+func parseMountsEnv(value string) ([]Mount, error) {
+	var mounts []Mount
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		m, err := parseMountSpec(entry)
+		if err != nil {
+			return nil, fmt.Errorf("PROJECTS_MOUNTS: %w", err)
+		}
+		mounts = append(mounts, m)
+	}
+	if len(mounts) == 0 {
+		return nil, errors.New("PROJECTS_MOUNTS set but contained no mounts")
+	}
+	return mounts, nil
 }
 
-// NewDockerPathMapperFromEnv creates a DockerPathMapper from environment variables
+// NewDockerPathMapperFromEnv creates a DockerPathMapper from environment
+// variables. PROJECTS_MOUNTS (repeatable "host:container[:ro]" entries,
+// comma-separated) takes priority; if unset, falls back to the older
+// single-mount HOST_PROJECTS_ROOT/PROJECTS_HOST_ROOT + PROJECTS_ROOT pair.
+// Returns a disabled mapper (every method a no-op passthrough) if neither
+// is configured.
 func NewDockerPathMapperFromEnv() (*DockerPathMapper, error) {
-	// Try different environment variable names for host root
+	if raw := os.Getenv("PROJECTS_MOUNTS"); raw != "" {
+		mounts, err := parseMountsEnv(raw)
+		if err != nil {
+			return nil, err
+		}
+		return NewDockerPathMapper(mounts)
+	}
+
 	hostRoot := os.Getenv("HOST_PROJECTS_ROOT")
 	if hostRoot == "" {
 		hostRoot = os.Getenv("PROJECTS_HOST_ROOT")
@@ -96,14 +361,10 @@ func NewDockerPathMapperFromEnv() (*DockerPathMapper, error) {
 
 	// If no host root is specified, return disabled mapper
 	if hostRoot == "" {
-		return &DockerPathMapper{
-			hostRoot:      "",
-			containerRoot: containerRoot,
-			enabled:       false,
-		}, nil
+		return &DockerPathMapper{enabled: false}, nil
 	}
 
-	return NewDockerPathMapper(hostRoot, containerRoot)
+	return NewDockerPathMapper([]Mount{{Host: hostRoot, Container: containerRoot}})
 }
 
 // IsEnabled returns true if the path mapper is enabled (Docker mode)
@@ -111,14 +372,166 @@ func (dpm *DockerPathMapper) IsEnabled() bool {
 	return dpm.enabled
 }
 
-// HostRoot returns the host root path
-func (dpm *DockerPathMapper) HostRoot() string {
-	return dpm.hostRoot
+// Mounts returns the mapper's mount table, longest-host-prefix-first.
+func (dpm *DockerPathMapper) Mounts() []Mount {
+	dpm.mu.RLock()
+	defer dpm.mu.RUnlock()
+
+	out := make([]Mount, len(dpm.mounts))
+	copy(out, dpm.mounts)
+	return out
+}
+
+// Refresh re-queries the mount table's source and swaps it in, so a
+// DockerPathMapper built via NewDockerPathMapperFromDocker reflects live
+// `docker run -v` flags without the bridge restarting. A no-op for mappers
+// built from an explicit table or env vars (refresh is nil).
+func (dpm *DockerPathMapper) Refresh() error {
+	if dpm.refresh == nil {
+		return nil
+	}
+
+	mounts, err := dpm.refresh()
+	if err != nil {
+		return fmt.Errorf("docker path mapper: refresh: %w", err)
+	}
+
+	normalized, err := normalizeMounts(mounts)
+	if err != nil {
+		return fmt.Errorf("docker path mapper: refresh: %w", err)
+	}
+
+	dpm.mu.Lock()
+	dpm.mounts = normalized
+	dpm.mu.Unlock()
+	return nil
+}
+
+// matchHost returns the first (longest-prefix) mount whose host root
+// contains cleanPath, plus the matched host/container paths.
+func (dpm *DockerPathMapper) matchHost(cleanPath string) (MountMatch, error) {
+	dpm.mu.RLock()
+	defer dpm.mu.RUnlock()
+
+	for _, m := range dpm.mounts {
+		root := m.effectiveHostRoot()
+		if !hasPrefixFold(cleanPath, root) {
+			continue
+		}
+
+		relative := strings.TrimPrefix(cleanPath[len(m.Host):], "/")
+
+		containerPath := m.Container
+		if relative != "" {
+			containerPath = path.Join(m.Container, relative)
+		}
+		containerPath = path.Clean(containerPath)
+
+		return MountMatch{Mount: m, HostPath: cleanPath, ContainerPath: containerPath}, nil
+	}
+
+	return MountMatch{}, fmt.Errorf("path %s is outside all mounted directories", cleanPath)
+}
+
+// matchContainer returns the first mount whose container root contains
+// cleanPath, plus the matched host/container paths.
+func (dpm *DockerPathMapper) matchContainer(cleanPath string) (MountMatch, error) {
+	dpm.mu.RLock()
+	defer dpm.mu.RUnlock()
+
+	for _, m := range dpm.mounts {
+		if !hasPathPrefix(cleanPath, m.Container) {
+			continue
+		}
+
+		relative := strings.TrimPrefix(cleanPath[len(m.Container):], "/")
+
+		hostPath := m.Host
+		if relative != "" {
+			hostPath = path.Join(m.Host, relative)
+		}
+		hostPath = path.Clean(hostPath)
+
+		if !hasPrefixFold(hostPath, m.effectiveHostRoot()) {
+			return MountMatch{}, fmt.Errorf("path %s resolves to %s, which escapes mount subpath %s", cleanPath, hostPath, m.Subpath)
+		}
+
+		return MountMatch{Mount: m, HostPath: hostPath, ContainerPath: cleanPath}, nil
+	}
+
+	return MountMatch{}, fmt.Errorf("path %s is outside all container mount roots", cleanPath)
+}
+
+// verifySymlinkSafe is a no-op unless WithSymlinkResolution was used to
+// build dpm. When enabled, it resolves the real, symlink-free location of
+// the side of match this process actually has a filesystem view of - the
+// container side when dpm is enabled (Docker mode: the bridge runs inside
+// the container the mounts describe), the host side otherwise - and
+// returns ErrSymlinkEscape if that resolved path falls outside the
+// matching mount's effective root.
+func (dpm *DockerPathMapper) verifySymlinkSafe(match MountMatch) error {
+	if !dpm.resolveSymlinks {
+		return nil
+	}
+
+	checkPath := match.HostPath
+	root := match.Mount.effectiveHostRoot()
+	if dpm.IsEnabled() {
+		checkPath = match.ContainerPath
+		root = match.Mount.effectiveContainerRoot()
+	}
+
+	resolved, err := dpm.resolveSymlinksCached(checkPath)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			// Nothing on disk yet (e.g. a rename/format tool about to
+			// create the file) can't have a symlink to escape through.
+			return nil
+		}
+		return fmt.Errorf("docker path mapper: resolve symlinks for %s: %w", checkPath, err)
+	}
+
+	if !hasPrefixFold(normalizePathSeparators(resolved), root) {
+		return fmt.Errorf("%w: %s resolves to %s, outside mount root %s", ErrSymlinkEscape, checkPath, resolved, root)
+	}
+
+	return nil
+}
+
+// resolveSymlinksCached wraps filepath.EvalSymlinks with a per-path TTL
+// cache so a burst of calls against the same file (typical of one LSP
+// request touching a document a few times) only hits the filesystem once.
+func (dpm *DockerPathMapper) resolveSymlinksCached(p string) (string, error) {
+	now := time.Now()
+
+	dpm.symlinkCacheMu.Lock()
+	if entry, ok := dpm.symlinkCache[p]; ok && now.Before(entry.expires) {
+		dpm.symlinkCacheMu.Unlock()
+		return entry.resolved, nil
+	}
+	dpm.symlinkCacheMu.Unlock()
+
+	resolved, err := filepath.EvalSymlinks(p)
+	if err != nil {
+		return "", err
+	}
+
+	dpm.symlinkCacheMu.Lock()
+	if dpm.symlinkCache == nil {
+		dpm.symlinkCache = make(map[string]symlinkCacheEntry)
+	}
+	dpm.symlinkCache[p] = symlinkCacheEntry{resolved: resolved, expires: now.Add(dpm.symlinkCacheTTL)}
+	dpm.symlinkCacheMu.Unlock()
+
+	return resolved, nil
 }
 
-// ContainerRoot returns the container root path
-func (dpm *DockerPathMapper) ContainerRoot() string {
-	return dpm.containerRoot
+// ResolveHost matches hostPath (a plain path, not a URI) against the mount
+// table and returns the structured result, so callers that want to know
+// which workspace a file belongs to (not just its converted path) can use
+// this directly instead of HostToContainer.
+func (dpm *DockerPathMapper) ResolveHost(hostPath string) (MountMatch, error) {
+	return dpm.matchHost(normalizePathSeparators(hostPath))
 }
 
 // HostToContainer converts a host path to container path
@@ -145,37 +558,19 @@ func (dpm *DockerPathMapper) HostToContainer(hostPath string) (string, error) {
 		filePath = hostPath
 	}
 
-	// Normalize the input path - convert backslashes to forward slashes
-	cleanPath := normalizePathSeparators(filePath)
-
-	// hostRoot is already normalized in NewDockerPathMapper
-	normalizedHostRoot := dpm.hostRoot
-
-	// Check if path is within the host root directory (case-insensitive for Windows paths)
-	if !hasPrefixFold(cleanPath, normalizedHostRoot) {
-		return "", fmt.Errorf("path %s is outside mounted directory %s", cleanPath, normalizedHostRoot)
+	match, err := dpm.matchHost(normalizePathSeparators(filePath))
+	if err != nil {
+		return "", err
 	}
 
-	// Extract relative path (preserve original case for the relative portion)
-	relativePath := cleanPath[len(normalizedHostRoot):]
-	relativePath = strings.TrimPrefix(relativePath, "/")
-
-	// Build container path
-	var containerPath string
-	if relativePath == "" {
-		containerPath = dpm.containerRoot
-	} else {
-		containerPath = path.Join(dpm.containerRoot, relativePath)
+	if err := dpm.verifySymlinkSafe(match); err != nil {
+		return "", err
 	}
 
-	// Normalize the final path
-	containerPath = path.Clean(containerPath)
-
-	// Return as URI if input was URI
 	if isURI {
-		return "file://" + containerPath, nil
+		return "file://" + match.ContainerPath, nil
 	}
-	return containerPath, nil
+	return match.ContainerPath, nil
 }
 
 // ContainerToHost converts a container path to host path
@@ -188,56 +583,44 @@ func (dpm *DockerPathMapper) ContainerToHost(containerPath string) (string, erro
 		return "", errors.New("container path cannot be empty")
 	}
 
-	// Clean and normalize the input path (container is always slash-based)
-	cleanPath := normalizePathSeparators(containerPath)
-
-	// Check if path is within the container root directory
-	if !strings.HasPrefix(cleanPath, dpm.containerRoot) {
-		return "", fmt.Errorf("path %s is outside container root %s", cleanPath, dpm.containerRoot)
+	match, err := dpm.matchContainer(normalizePathSeparators(containerPath))
+	if err != nil {
+		return "", err
 	}
 
-	// Replace container root with host root
-	relativePath := strings.TrimPrefix(cleanPath, dpm.containerRoot)
-	relativePath = strings.TrimPrefix(relativePath, "/")
-
-	// Build host path (keep forward slashes - the caller can convert if needed)
-	var hostPath string
-	if relativePath == "" {
-		hostPath = dpm.hostRoot
-	} else {
-		hostPath = path.Join(dpm.hostRoot, relativePath)
+	if err := dpm.verifySymlinkSafe(match); err != nil {
+		return "", err
 	}
 
-	// Normalize the final path
-	hostPath = path.Clean(hostPath)
-
-	return hostPath, nil
+	return match.HostPath, nil
 }
 
-// ValidatePath checks if a host path is within the allowed directory
+// ValidatePath checks if a host path is within one of the mapper's mounted directories
 func (dpm *DockerPathMapper) ValidatePath(hostPath string) error {
 	if !dpm.enabled {
 		return nil // No validation if disabled
 	}
 
-	// Normalize the path first
 	cleanPath := normalizePathSeparators(hostPath)
 
 	// Check if path is absolute (works for both Windows and Unix paths)
 	isAbsolute := strings.HasPrefix(cleanPath, "/") || IsWindowsAbsPath(cleanPath)
 
-	// In Docker mode, treat relative paths as relative to hostRoot
+	// In Docker mode, treat relative paths as relative to the first mount's
+	// effective (subpath-confined) root
 	if !isAbsolute {
-		cleanPath = path.Join(dpm.hostRoot, cleanPath)
-		cleanPath = path.Clean(cleanPath)
+		dpm.mu.RLock()
+		firstRoot := dpm.mounts[0].effectiveHostRoot()
+		dpm.mu.RUnlock()
+		cleanPath = path.Clean(path.Join(firstRoot, cleanPath))
 	}
 
-	// Check if path is within host root (case-insensitive for Windows paths)
-	if !hasPrefixFold(cleanPath, dpm.hostRoot) {
-		return fmt.Errorf("path is outside mounted directory: %s", hostPath)
+	match, err := dpm.matchHost(cleanPath)
+	if err != nil {
+		return fmt.Errorf("path is outside mounted directories: %s", hostPath)
 	}
 
-	return nil
+	return dpm.verifySymlinkSafe(match)
 }
 
 // NormalizeURI normalizes a file:// URI for container usage
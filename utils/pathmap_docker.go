@@ -0,0 +1,168 @@
+package utils
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+const dockerSocketPath = "/var/run/docker.sock"
+
+// dockerContainerInspect is the subset of GET /containers/{id}/json this
+// package needs - the full response has dozens of fields we don't care
+// about.
+type dockerContainerInspect struct {
+	Mounts []struct {
+		Source      string `json:"Source"`
+		Destination string `json:"Destination"`
+		RW          bool   `json:"RW"`
+	} `json:"Mounts"`
+}
+
+// NewDockerPathMapperFromDocker builds a DockerPathMapper by asking the
+// Docker Engine API (over /var/run/docker.sock, or DOCKER_HOST if set)
+// what mounts the current container was actually started with, instead of
+// requiring the operator to mirror -v flags into HOST_PROJECTS_ROOT/
+// PROJECTS_ROOT by hand. Falls back to NewDockerPathMapperFromEnv when the
+// socket is unreachable or the current process doesn't look like it's
+// running in a container (neither /proc/self/cgroup nor HOSTNAME yields a
+// container ID).
+//
+// The returned mapper's Refresh method re-runs this same query and swaps
+// in the result, so a sidecar deployment can pick up `docker update`/
+// recreate mount changes without the bridge restarting.
+func NewDockerPathMapperFromDocker(ctx context.Context) (*DockerPathMapper, error) {
+	client := dockerEngineClient()
+
+	containerID, err := currentContainerID()
+	if err != nil {
+		return NewDockerPathMapperFromEnv()
+	}
+
+	mounts, err := fetchContainerMounts(ctx, client, containerID)
+	if err != nil {
+		return NewDockerPathMapperFromEnv()
+	}
+
+	dpm, err := NewDockerPathMapper(mounts)
+	if err != nil {
+		return nil, fmt.Errorf("docker path mapper: container %s reported no usable mounts: %w", containerID, err)
+	}
+
+	dpm.refresh = func() ([]Mount, error) {
+		return fetchContainerMounts(context.Background(), client, containerID)
+	}
+
+	return dpm, nil
+}
+
+// dockerEngineClient returns an HTTP client that talks to the Docker
+// Engine API over its unix socket (DOCKER_HOST, if set, overrides the
+// default /var/run/docker.sock).
+func dockerEngineClient() *http.Client {
+	socket := dockerSocketPath
+	if host := os.Getenv("DOCKER_HOST"); host != "" {
+		socket = strings.TrimPrefix(host, "unix://")
+	}
+
+	return &http.Client{
+		Timeout: 5 * time.Second,
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, "unix", socket)
+			},
+		},
+	}
+}
+
+// currentContainerID returns this process's own container ID, read from
+// /proc/self/cgroup (the last "/docker/<id>" segment on cgroup v1/v2
+// hosts) or, failing that, HOSTNAME - Docker sets a container's hostname
+// to its short ID by default.
+func currentContainerID() (string, error) {
+	if id, err := containerIDFromCgroup("/proc/self/cgroup"); err == nil {
+		return id, nil
+	}
+
+	if hostname := os.Getenv("HOSTNAME"); hostname != "" {
+		return hostname, nil
+	}
+
+	return "", fmt.Errorf("not running in a container (no /proc/self/cgroup docker entry, no HOSTNAME)")
+}
+
+func containerIDFromCgroup(path string) (string, error) {
+	f, err := os.Open(path) // #nosec G304 -- fixed kernel-exposed path, not user input
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		idx := strings.LastIndex(line, "/docker/")
+		if idx < 0 {
+			continue
+		}
+		id := strings.TrimSpace(line[idx+len("/docker/"):])
+		id = strings.TrimSuffix(id, ".scope") // cgroup v2 often appends this
+		if id != "" {
+			return id, nil
+		}
+	}
+
+	return "", fmt.Errorf("%s: no docker container entry found", path)
+}
+
+// fetchContainerMounts calls GET /containers/{id}/json and translates its
+// Mounts[] entries into this package's Mount table (Source -> host,
+// Destination -> container, RW -> !ReadOnly).
+func fetchContainerMounts(ctx context.Context, client *http.Client, containerID string) ([]Mount, error) {
+	url := fmt.Sprintf("http://docker/containers/%s/json", containerID)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("docker path mapper: build request: %w", err)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("docker path mapper: query engine API: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("docker path mapper: engine API returned %s", resp.Status)
+	}
+
+	var inspect dockerContainerInspect
+	if err := json.NewDecoder(resp.Body).Decode(&inspect); err != nil {
+		return nil, fmt.Errorf("docker path mapper: decode engine API response: %w", err)
+	}
+
+	mounts := make([]Mount, 0, len(inspect.Mounts))
+	for _, m := range inspect.Mounts {
+		if m.Source == "" || m.Destination == "" {
+			continue
+		}
+		mounts = append(mounts, Mount{
+			Host:      m.Source,
+			Container: m.Destination,
+			ReadOnly:  !m.RW,
+		})
+	}
+
+	if len(mounts) == 0 {
+		return nil, fmt.Errorf("docker path mapper: container %s has no bind mounts", containerID)
+	}
+
+	return mounts, nil
+}
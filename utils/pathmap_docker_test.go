@@ -0,0 +1,51 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestContainerIDFromCgroupV1(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "cgroup")
+	writeCgroupFixture(t, path, "12:memory:/docker/abc123def456\n")
+
+	id, err := containerIDFromCgroup(path)
+	if err != nil {
+		t.Fatalf("containerIDFromCgroup failed: %v", err)
+	}
+	if id != "abc123def456" {
+		t.Fatalf("expected abc123def456, got %q", id)
+	}
+}
+
+func TestContainerIDFromCgroupV2(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "cgroup")
+	writeCgroupFixture(t, path, "0::/system.slice/docker-abc123def456.scope\n")
+
+	// cgroup v2 entries don't use the "/docker/<id>" segment this parser
+	// looks for; confirm it falls through to "no entry found" rather than
+	// panicking or misparsing the slice name as an ID.
+	if _, err := containerIDFromCgroup(path); err == nil {
+		t.Fatal("expected an error for a cgroup v2 style line with no /docker/ segment")
+	}
+}
+
+func TestContainerIDFromCgroupNotInContainer(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "cgroup")
+	writeCgroupFixture(t, path, "0::/user.slice/user-1000.slice\n")
+
+	if _, err := containerIDFromCgroup(path); err == nil {
+		t.Fatal("expected an error when no docker entry is present")
+	}
+}
+
+func writeCgroupFixture(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("failed to write fixture: %v", err)
+	}
+}
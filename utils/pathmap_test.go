@@ -0,0 +1,279 @@
+package utils
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestNewDockerPathMapperLongestPrefixFirst(t *testing.T) {
+	dpm, err := NewDockerPathMapper([]Mount{
+		{Host: "/home/user/projects", Container: "/projects"},
+		{Host: "/home/user/projects/shared-lib", Container: "/projects/shared-lib"},
+	})
+	if err != nil {
+		t.Fatalf("NewDockerPathMapper failed: %v", err)
+	}
+
+	containerPath, err := dpm.HostToContainer("/home/user/projects/shared-lib/util.bsl")
+	if err != nil {
+		t.Fatalf("HostToContainer failed: %v", err)
+	}
+	if containerPath != "/projects/shared-lib/util.bsl" {
+		t.Fatalf("expected the more specific mount to win, got %q", containerPath)
+	}
+}
+
+func TestHostToContainerOutsideAllMounts(t *testing.T) {
+	dpm, err := NewDockerPathMapper([]Mount{{Host: "/home/user/projects", Container: "/projects"}})
+	if err != nil {
+		t.Fatalf("NewDockerPathMapper failed: %v", err)
+	}
+
+	if _, err := dpm.HostToContainer("/etc/passwd"); err == nil {
+		t.Fatal("expected an error for a path outside every mount")
+	}
+}
+
+func TestResolveHostReportsMatchedMount(t *testing.T) {
+	dpm, err := NewDockerPathMapper([]Mount{
+		{Host: "/home/user/main", Container: "/projects/main"},
+		{Host: "/home/user/lib", Container: "/projects/lib", ReadOnly: true},
+	})
+	if err != nil {
+		t.Fatalf("NewDockerPathMapper failed: %v", err)
+	}
+
+	match, err := dpm.ResolveHost("/home/user/lib/common.bsl")
+	if err != nil {
+		t.Fatalf("ResolveHost failed: %v", err)
+	}
+	if match.Mount.Container != "/projects/lib" || !match.Mount.ReadOnly {
+		t.Fatalf("unexpected matched mount: %+v", match.Mount)
+	}
+	if match.ContainerPath != "/projects/lib/common.bsl" {
+		t.Fatalf("unexpected container path: %s", match.ContainerPath)
+	}
+}
+
+func TestParseMountSpecWindowsDriveLetter(t *testing.T) {
+	m, err := parseMountSpec("D:/My Projects/Projects 1C:/projects:ro")
+	if err != nil {
+		t.Fatalf("parseMountSpec failed: %v", err)
+	}
+	if m.Host != "D:/My Projects/Projects 1C" || m.Container != "/projects" || !m.ReadOnly {
+		t.Fatalf("unexpected mount: %+v", m)
+	}
+}
+
+func TestNewDockerPathMapperFromEnvMultiMount(t *testing.T) {
+	t.Setenv("PROJECTS_MOUNTS", "/home/user/main:/projects/main,/home/user/lib:/projects/lib:ro")
+	t.Setenv("HOST_PROJECTS_ROOT", "")
+	t.Setenv("PROJECTS_HOST_ROOT", "")
+	t.Setenv("PROJECTS_ROOT", "")
+
+	dpm, err := NewDockerPathMapperFromEnv()
+	if err != nil {
+		t.Fatalf("NewDockerPathMapperFromEnv failed: %v", err)
+	}
+
+	mounts := dpm.Mounts()
+	if len(mounts) != 2 {
+		t.Fatalf("expected 2 mounts, got %d", len(mounts))
+	}
+}
+
+func TestNewDockerPathMapperFromEnvLegacySingleMount(t *testing.T) {
+	t.Setenv("PROJECTS_MOUNTS", "")
+	t.Setenv("HOST_PROJECTS_ROOT", "/home/user/projects")
+	t.Setenv("PROJECTS_ROOT", "/projects")
+
+	dpm, err := NewDockerPathMapperFromEnv()
+	if err != nil {
+		t.Fatalf("NewDockerPathMapperFromEnv failed: %v", err)
+	}
+	if !dpm.IsEnabled() {
+		t.Fatal("expected mapper to be enabled")
+	}
+}
+
+func TestSubpathRejectsConstructionWithDotDot(t *testing.T) {
+	_, err := NewDockerPathMapper([]Mount{
+		{Host: "/srv/1c-projects", Container: "/projects", Subpath: "../etc"},
+	})
+	if err == nil {
+		t.Fatal("expected an error for a Subpath containing ..")
+	}
+}
+
+func TestSubpathRejectsAbsoluteSubpath(t *testing.T) {
+	_, err := NewDockerPathMapper([]Mount{
+		{Host: "/srv/1c-projects", Container: "/projects", Subpath: "/tenant-a"},
+	})
+	if err == nil {
+		t.Fatal("expected an error for an absolute Subpath")
+	}
+}
+
+func TestSubpathRejectsWindowsDriveLetterSubpath(t *testing.T) {
+	_, err := NewDockerPathMapper([]Mount{
+		{Host: "D:/1c-projects", Container: "/projects", Subpath: "D:/tenant-a"},
+	})
+	if err == nil {
+		t.Fatal("expected an error for a Windows-drive-letter Subpath")
+	}
+}
+
+func TestSubpathConfinesHostToContainer(t *testing.T) {
+	dpm, err := NewDockerPathMapper([]Mount{
+		{Host: "/srv/1c-projects", Container: "/projects", Subpath: "tenant-a"},
+	})
+	if err != nil {
+		t.Fatalf("NewDockerPathMapper failed: %v", err)
+	}
+
+	if _, err := dpm.HostToContainer("/srv/1c-projects/tenant-b/Module.bsl"); err == nil {
+		t.Fatal("expected a sibling tenant directory to be rejected")
+	}
+
+	containerPath, err := dpm.HostToContainer("/srv/1c-projects/tenant-a/Module.bsl")
+	if err != nil {
+		t.Fatalf("HostToContainer failed for a path inside the subpath: %v", err)
+	}
+	if containerPath != "/projects/tenant-a/Module.bsl" {
+		t.Fatalf("unexpected container path: %s", containerPath)
+	}
+}
+
+func TestSubpathConfinesHostToContainerMixedSeparators(t *testing.T) {
+	dpm, err := NewDockerPathMapper([]Mount{
+		{Host: `D:\1c-projects`, Container: "/projects", Subpath: `tenant-a`},
+	})
+	if err != nil {
+		t.Fatalf("NewDockerPathMapper failed: %v", err)
+	}
+
+	if _, err := dpm.HostToContainer(`D:\1c-projects\tenant-b\Module.bsl`); err == nil {
+		t.Fatal("expected a sibling tenant directory to be rejected even with backslash separators")
+	}
+
+	containerPath, err := dpm.HostToContainer(`D:\1c-projects\tenant-a\Module.bsl`)
+	if err != nil {
+		t.Fatalf("HostToContainer failed for a backslash path inside the subpath: %v", err)
+	}
+	if containerPath != "/projects/tenant-a/Module.bsl" {
+		t.Fatalf("unexpected container path: %s", containerPath)
+	}
+}
+
+// TestSubpathRejectsTextualEscapeFromContainerSide simulates what a
+// symlink inside the container mount that points back up at a sibling
+// tenant directory would look like from ContainerToHost's perspective: a
+// container path whose ".." components, once cleaned, resolve outside the
+// subpath. Full symlink-aware resolution (actually following an on-disk
+// symlink) is covered separately - this only checks the textual
+// confinement chunk13-3 adds.
+func TestSubpathRejectsTextualEscapeFromContainerSide(t *testing.T) {
+	dpm, err := NewDockerPathMapper([]Mount{
+		{Host: "/srv/1c-projects", Container: "/projects", Subpath: "tenant-a"},
+	})
+	if err != nil {
+		t.Fatalf("NewDockerPathMapper failed: %v", err)
+	}
+
+	if _, err := dpm.ContainerToHost("/projects/tenant-a/../tenant-b/Module.bsl"); err == nil {
+		t.Fatal("expected a .. escape from the container side to be rejected")
+	}
+}
+
+// TestSubpathRejectsPrefixSiblingEscape guards against a sibling directory
+// whose name is a string-prefix of the confined Subpath (e.g. "tenant-ab"
+// vs. a mount confined to "tenant-a") being mistaken for being inside it -
+// hasPrefixFold/hasPathPrefix must check for a path-separator boundary,
+// not just a raw string prefix.
+func TestSubpathRejectsPrefixSiblingEscape(t *testing.T) {
+	dpm, err := NewDockerPathMapper([]Mount{
+		{Host: "/srv/1c-projects", Container: "/projects", Subpath: "tenant-a"},
+	})
+	if err != nil {
+		t.Fatalf("NewDockerPathMapper failed: %v", err)
+	}
+
+	if _, err := dpm.HostToContainer("/srv/1c-projects/tenant-ab/secret.bsl"); err == nil {
+		t.Fatal("expected a host path under a same-prefix sibling directory to be rejected")
+	}
+
+	if _, err := dpm.ContainerToHost("/projects/tenant-ab/secret.bsl"); err == nil {
+		t.Fatal("expected a container path under a same-prefix sibling directory to be rejected")
+	}
+}
+
+// symlinkEscapeFixture lays out a mount whose container root contains a
+// symlink ("escape") pointing at a file outside it, and returns the mount
+// plus the path (relative to the container root) that walks through it.
+func symlinkEscapeFixture(t *testing.T) (Mount, string) {
+	t.Helper()
+
+	containerRoot := t.TempDir()
+	outside := t.TempDir()
+
+	if err := os.WriteFile(filepath.Join(outside, "Module.bsl"), []byte("content"), 0o644); err != nil {
+		t.Fatalf("failed to write fixture file: %v", err)
+	}
+	if err := os.Symlink(outside, filepath.Join(containerRoot, "escape")); err != nil {
+		t.Skipf("symlinks not supported on this platform/filesystem: %v", err)
+	}
+
+	return Mount{Host: "/srv/1c-projects", Container: containerRoot}, "/srv/1c-projects/escape/Module.bsl"
+}
+
+func TestSymlinkResolutionDisabledByDefaultAllowsEscape(t *testing.T) {
+	mount, hostPath := symlinkEscapeFixture(t)
+
+	dpm, err := NewDockerPathMapper([]Mount{mount})
+	if err != nil {
+		t.Fatalf("NewDockerPathMapper failed: %v", err)
+	}
+
+	// WithSymlinkResolution is opt-in; without it, matching stays textual
+	// (chunk13-3's behavior), so an escaping symlink is not caught here.
+	if _, err := dpm.HostToContainer(hostPath); err != nil {
+		t.Fatalf("expected textual matching to pass through the symlink, got: %v", err)
+	}
+}
+
+func TestWithSymlinkResolutionRejectsContainerSideEscape(t *testing.T) {
+	mount, hostPath := symlinkEscapeFixture(t)
+
+	dpm, err := NewDockerPathMapper([]Mount{mount}, WithSymlinkResolution(time.Minute))
+	if err != nil {
+		t.Fatalf("NewDockerPathMapper failed: %v", err)
+	}
+
+	if _, err := dpm.HostToContainer(hostPath); !errors.Is(err, ErrSymlinkEscape) {
+		t.Fatalf("expected ErrSymlinkEscape, got: %v", err)
+	}
+}
+
+func TestWithSymlinkResolutionAllowsSymlinkWithinMount(t *testing.T) {
+	containerRoot := t.TempDir()
+	if err := os.WriteFile(filepath.Join(containerRoot, "Real.bsl"), []byte("content"), 0o644); err != nil {
+		t.Fatalf("failed to write fixture file: %v", err)
+	}
+	if err := os.Symlink(filepath.Join(containerRoot, "Real.bsl"), filepath.Join(containerRoot, "Alias.bsl")); err != nil {
+		t.Skipf("symlinks not supported on this platform/filesystem: %v", err)
+	}
+
+	dpm, err := NewDockerPathMapper([]Mount{
+		{Host: "/srv/1c-projects", Container: containerRoot},
+	}, WithSymlinkResolution(time.Minute))
+	if err != nil {
+		t.Fatalf("NewDockerPathMapper failed: %v", err)
+	}
+
+	if _, err := dpm.HostToContainer("/srv/1c-projects/Alias.bsl"); err != nil {
+		t.Fatalf("expected a symlink that stays within the mount to be allowed, got: %v", err)
+	}
+}
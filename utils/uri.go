@@ -108,6 +108,29 @@ func FileURIToPath(uri string) (string, error) {
 	return filepath.FromSlash(p), nil
 }
 
+// DisplayPath renders uri (a file:// URI or a local path) as an OS-correct
+// display string, relative to workspaceRoot when uri falls under it.
+// This replaces the ad-hoc filepath.Base(strings.TrimPrefix(u, "file://"))
+// formatters scattered across the tools package, which mishandle
+// percent-encoded paths (spaces, non-ASCII/Cyrillic BSL module names) and
+// Windows drive-letter URIs (file:///C:/... stripped naively leaves a
+// leading "/C:"). workspaceRoot may be empty, in which case the full
+// decoded path is returned unchanged.
+func DisplayPath(uri, workspaceRoot string) string {
+	path := URIToFilePath(uri)
+
+	if workspaceRoot == "" {
+		return path
+	}
+
+	rel, err := filepath.Rel(workspaceRoot, path)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return path
+	}
+
+	return rel
+}
+
 // PathToFileURI converts a local OS path into a file:// URI.
 func PathToFileURI(path string) (string, error) {
 	path = strings.TrimSpace(path)
@@ -0,0 +1,151 @@
+package utils
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// URIResolver lets a tool or the LSP client read a URI's content without
+// caring whether it names a local path, a remote host, or (in tests) an
+// in-memory fixture. Open/Stat are for tools that only need bytes/metadata;
+// ToLocal is for the one case that genuinely needs a real OS path - handing
+// a file to a language server that can only ever speak file:// itself. The
+// returned cleanup must be called once the caller is done with the path
+// (it removes any scratch copy the resolver made).
+type URIResolver interface {
+	Open(uri string) (io.ReadCloser, error)
+	Stat(uri string) (fs.FileInfo, error)
+	ToLocal(uri string) (path string, cleanup func(), err error)
+}
+
+var (
+	resolversMu sync.RWMutex
+	resolvers   = map[string]URIResolver{
+		"file": fileResolver{},
+	}
+)
+
+// RegisterResolver makes resolver the handler for every URI whose scheme
+// matches (case-insensitively). Schemes are matched against NormalizeURI's
+// output, so callers that only ever pass local paths never hit a non-file
+// resolver. Intended to be called from init() - e.g. the sftp and memfs
+// resolvers in this package register themselves this way.
+func RegisterResolver(scheme string, resolver URIResolver) {
+	resolversMu.Lock()
+	defer resolversMu.Unlock()
+	resolvers[strings.ToLower(scheme)] = resolver
+}
+
+// ResolverFor returns the registered URIResolver for uri's scheme. A bare
+// local path (no "scheme://") is treated as file://. Returns an error if
+// no resolver was registered for the scheme - callers should surface this
+// to the user rather than silently falling back to local-path handling,
+// since that would read past the URI's stated scheme.
+func ResolverFor(uri string) (URIResolver, error) {
+	scheme := uriScheme(uri)
+
+	resolversMu.RLock()
+	defer resolversMu.RUnlock()
+
+	resolver, ok := resolvers[scheme]
+	if !ok {
+		return nil, fmt.Errorf("uri resolver: no handler registered for scheme %q (uri: %s)", scheme, uri)
+	}
+	return resolver, nil
+}
+
+// uriScheme extracts the lower-cased scheme from uri, defaulting to "file"
+// for bare local paths (no "://").
+func uriScheme(uri string) string {
+	if i := strings.Index(uri, "://"); i >= 0 {
+		return strings.ToLower(uri[:i])
+	}
+	return "file"
+}
+
+// ReadURI opens uri via its registered resolver, reads it fully, and closes
+// it - the common case for tools/cache code that just wants bytes without
+// handling Open/Close themselves (see bridge.HashFile, project_analysis.go's
+// file_analysis complexity read).
+func ReadURI(uri string) ([]byte, error) {
+	resolver, err := ResolverFor(uri)
+	if err != nil {
+		return nil, err
+	}
+
+	rc, err := resolver.Open(uri)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	return io.ReadAll(rc)
+}
+
+// fileResolver is the default URIResolver, backing plain file:// URIs and
+// bare local paths via the local filesystem.
+type fileResolver struct{}
+
+func (fileResolver) Open(uri string) (io.ReadCloser, error) {
+	path := URIToFilePath(uri)
+	f, err := os.Open(path) // #nosec G304 -- path is caller-supplied workspace URI, same trust boundary as os.ReadFile elsewhere in this codebase
+	if err != nil {
+		return nil, fmt.Errorf("uri resolver (file): open %q: %w", path, err)
+	}
+	return f, nil
+}
+
+func (fileResolver) Stat(uri string) (fs.FileInfo, error) {
+	path := URIToFilePath(uri)
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("uri resolver (file): stat %q: %w", path, err)
+	}
+	return info, nil
+}
+
+// ToLocal returns the local path unchanged; there is no scratch copy to
+// clean up, so cleanup is a no-op.
+func (fileResolver) ToLocal(uri string) (string, func(), error) {
+	return URIToFilePath(uri), func() {}, nil
+}
+
+// mirrorToScratchFile writes content to a fresh temp file named after
+// remoteName (so the extension an LSP server keys formatting/diagnostics
+// behavior off of is preserved) and returns its path plus a cleanup that
+// removes it. Shared by every resolver whose ToLocal has to produce a real
+// OS path for content that isn't already on disk (memfs, sftp).
+func mirrorToScratchFile(remoteName string, content []byte) (string, func(), error) {
+	dir, err := os.MkdirTemp("", "mcp-lsp-bridge-scratch-*")
+	if err != nil {
+		return "", nil, fmt.Errorf("uri resolver: create scratch dir: %w", err)
+	}
+
+	path := filepath.Join(dir, filepath.Base(remoteName))
+	if err := os.WriteFile(path, content, 0o600); err != nil {
+		os.RemoveAll(dir)
+		return "", nil, fmt.Errorf("uri resolver: write scratch file: %w", err)
+	}
+
+	cleanup := func() { os.RemoveAll(dir) }
+	return path, cleanup, nil
+}
+
+// parseRemoteURI splits a scheme://[user@]host[:port]/path URI into its
+// connection and path components, shared by non-local resolvers (sftp).
+func parseRemoteURI(uri string) (user, host, path string, err error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return "", "", "", fmt.Errorf("uri resolver: invalid uri %q: %w", uri, err)
+	}
+	if u.User != nil {
+		user = u.User.Username()
+	}
+	return user, u.Host, u.Path, nil
+}
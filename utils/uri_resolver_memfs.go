@@ -0,0 +1,103 @@
+package utils
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"strings"
+	"sync"
+	"time"
+)
+
+func init() {
+	RegisterResolver("memfs", memFS)
+}
+
+// memFS is the process-wide memfs:// resolver - an in-memory filesystem
+// for tests that need a URIResolver without touching disk or a network.
+// URIs look like memfs://<name>/path/to/file; put/remove below manage
+// its contents directly, bypassing Open/Stat's read-only API.
+var memFS = &memFSResolver{files: make(map[string][]byte)}
+
+type memFSResolver struct {
+	mu    sync.RWMutex
+	files map[string][]byte
+}
+
+// PutMemFile seeds (or overwrites) the content a memfs:// URI resolves to.
+func PutMemFile(uri string, content []byte) {
+	memFS.mu.Lock()
+	defer memFS.mu.Unlock()
+	memFS.files[memFSKey(uri)] = content
+}
+
+// RemoveMemFile removes a previously seeded memfs:// URI, so later tests
+// don't see another test's leftovers.
+func RemoveMemFile(uri string) {
+	memFS.mu.Lock()
+	defer memFS.mu.Unlock()
+	delete(memFS.files, memFSKey(uri))
+}
+
+func memFSKey(uri string) string {
+	return strings.TrimPrefix(uri, "memfs://")
+}
+
+func (r *memFSResolver) Open(uri string) (io.ReadCloser, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	content, ok := r.files[memFSKey(uri)]
+	if !ok {
+		return nil, fmt.Errorf("uri resolver (memfs): no such file: %s", uri)
+	}
+	return io.NopCloser(strings.NewReader(string(content))), nil
+}
+
+func (r *memFSResolver) Stat(uri string) (fs.FileInfo, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	content, ok := r.files[memFSKey(uri)]
+	if !ok {
+		return nil, fmt.Errorf("uri resolver (memfs): no such file: %s", uri)
+	}
+	return memFileInfo{name: memFSKey(uri), size: int64(len(content))}, nil
+}
+
+// ToLocal mirrors the in-memory content into a real temp file so that code
+// paths which genuinely need an OS path (e.g. handing a file to an LSP
+// server) work against memfs:// URIs in tests the same way they would
+// against sftp:// ones. cleanup removes the temp file.
+func (r *memFSResolver) ToLocal(uri string) (string, func(), error) {
+	content, err := r.readAll(uri)
+	if err != nil {
+		return "", nil, err
+	}
+	return mirrorToScratchFile(memFSKey(uri), content)
+}
+
+func (r *memFSResolver) readAll(uri string) ([]byte, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	content, ok := r.files[memFSKey(uri)]
+	if !ok {
+		return nil, fmt.Errorf("uri resolver (memfs): no such file: %s", uri)
+	}
+	return content, nil
+}
+
+// memFileInfo is a minimal fs.FileInfo for memFSResolver.Stat; memfs:// has
+// no notion of mode/mtime, so those are fixed stand-ins.
+type memFileInfo struct {
+	name string
+	size int64
+}
+
+func (i memFileInfo) Name() string       { return i.name }
+func (i memFileInfo) Size() int64        { return i.size }
+func (i memFileInfo) Mode() fs.FileMode  { return 0o444 }
+func (i memFileInfo) ModTime() time.Time { return time.Time{} }
+func (i memFileInfo) IsDir() bool        { return false }
+func (i memFileInfo) Sys() any           { return nil }
@@ -0,0 +1,50 @@
+package utils
+
+import (
+	"testing"
+)
+
+func TestResolverForUnknownScheme(t *testing.T) {
+	if _, err := ResolverFor("ftp://example.com/file.bsl"); err == nil {
+		t.Fatal("expected an error for an unregistered scheme, got nil")
+	}
+}
+
+func TestReadURIMemFS(t *testing.T) {
+	uri := "memfs://fixtures/Module.bsl"
+	PutMemFile(uri, []byte("Процедура Тест()\nКонецПроцедуры"))
+	defer RemoveMemFile(uri)
+
+	data, err := ReadURI(uri)
+	if err != nil {
+		t.Fatalf("ReadURI failed: %v", err)
+	}
+	if string(data) != "Процедура Тест()\nКонецПроцедуры" {
+		t.Fatalf("unexpected content: %q", data)
+	}
+}
+
+func TestMemFSResolverToLocalMirrorsScratchFile(t *testing.T) {
+	uri := "memfs://fixtures/Module.bsl"
+	PutMemFile(uri, []byte("content"))
+	defer RemoveMemFile(uri)
+
+	resolver, err := ResolverFor(uri)
+	if err != nil {
+		t.Fatalf("ResolverFor failed: %v", err)
+	}
+
+	path, cleanup, err := resolver.ToLocal(uri)
+	if err != nil {
+		t.Fatalf("ToLocal failed: %v", err)
+	}
+	defer cleanup()
+
+	got, err := ReadURI(FilePathToURI(path))
+	if err != nil {
+		t.Fatalf("reading mirrored scratch file failed: %v", err)
+	}
+	if string(got) != "content" {
+		t.Fatalf("unexpected mirrored content: %q", got)
+	}
+}
@@ -0,0 +1,564 @@
+// Package workspace maintains an incremental, persisted index of a 1C
+// configuration's source files (path -> mtime), replacing the ad-hoc
+// parallelWalkWithMtime prototype in cmd/fs-bench/cpu_bench.go with a
+// long-lived component: one parallel scan (via fswalk) on Start, then an
+// fsnotify watcher for deltas, falling back to a bounded poll-rescan loop
+// on platforms where fsnotify can't see changes at all (WSL/Docker bind
+// mounts over 9p/virtiofs routinely drop inotify events). Callers read
+// Events() to learn what changed and Stats()/ChangedSince() to answer
+// workspace_stats/workspace_changed_since without re-scanning.
+package workspace
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"rockerboo/mcp-lsp-bridge/fswalk"
+	"rockerboo/mcp-lsp-bridge/logger"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// EventOp describes what happened to a file between two observations.
+type EventOp string
+
+const (
+	EventCreated EventOp = "created"
+	EventChanged EventOp = "changed"
+	EventDeleted EventOp = "deleted"
+)
+
+// Event is one file-level change the index has observed, either during the
+// initial scan (diffed against the persisted map) or afterward (from the
+// watcher or a poll rescan).
+type Event struct {
+	Path string
+	Op   EventOp
+	// Mtime is the file's modification time as of this event, as Unix
+	// seconds. Zero for EventDeleted.
+	Mtime int64
+	// Seen is when the index itself observed this change, not the file's
+	// own mtime - ChangedSince filters on this.
+	Seen time.Time
+}
+
+// Stats is a point-in-time snapshot of the index's own health, exposed
+// through the workspace_stats MCP tool so an agent can tell whether the
+// server has actually seen a given edit before relying on LSP results.
+type Stats struct {
+	Root             string
+	TotalFiles       int
+	WatcherMode      string // "fsnotify" or "poll"
+	ScanCount        int64
+	LastScanAt       time.Time
+	LastScanDuration time.Duration
+	LastEventAt      time.Time
+	// DutyCyclePercent is LastScanDuration / PollInterval * 100, the same
+	// ratio cmd/fs-bench/cpu_bench.go printed as "Duty cycle if polling
+	// every Ns" - meaningful only in "poll" WatcherMode; zero otherwise.
+	DutyCyclePercent float64
+}
+
+// recentEventsCap bounds the in-memory event log ChangedSince searches, so
+// a long-running index doesn't grow unbounded on a churny workspace.
+const recentEventsCap = 5000
+
+// indexStateDir and indexStateFile mirror CallGraphCache's
+// .mcp-lsp-bridge/<name> convention for per-workspace persisted state.
+const indexStateDir = ".mcp-lsp-bridge"
+const indexStateFile = "workspace_index.json"
+
+// Options configures a new Index.
+type Options struct {
+	// Root is the workspace directory to scan and watch. Required.
+	Root string
+	// PersistPath overrides where the path->mtime map is persisted.
+	// Defaults to Root/.mcp-lsp-bridge/workspace_index.json.
+	PersistPath string
+	// Extensions filters which files are indexed. Defaults to .bsl and .os,
+	// the extensions cmd/fs-bench/cpu_bench.go's prototype hard-coded.
+	Extensions []string
+	// SkipDir prunes subtrees from both the initial scan and the poll
+	// fallback. Defaults to fswalk.DefaultSkipDir.
+	SkipDir fswalk.SkipDirFunc
+	// Workers bounds the initial scan's concurrency. <= 0 uses fswalk's own
+	// default (GOMAXPROCS).
+	Workers int
+	// PollInterval is how often the poll fallback rescans Root when
+	// fsnotify can't be used. Defaults to 30s, matching the interval
+	// cmd/fs-bench/cpu_bench.go's duty-cycle estimate assumed.
+	PollInterval time.Duration
+}
+
+func (o Options) withDefaults() Options {
+	if len(o.Extensions) == 0 {
+		o.Extensions = []string{".bsl", ".os"}
+	}
+	if o.SkipDir == nil {
+		o.SkipDir = fswalk.DefaultSkipDir
+	}
+	if o.PollInterval <= 0 {
+		o.PollInterval = 30 * time.Second
+	}
+	if o.PersistPath == "" {
+		o.PersistPath = filepath.Join(o.Root, indexStateDir, indexStateFile)
+	}
+	return o
+}
+
+// Index is an incrementally-maintained path->mtime map for one workspace
+// root, kept current by an fsnotify watcher (or a poll-rescan fallback)
+// after its initial parallel scan. The zero value is not usable; construct
+// with NewIndex.
+type Index struct {
+	opts Options
+
+	mu    sync.RWMutex
+	files map[string]int64 // path -> mtime (unix seconds)
+
+	eventsMu sync.Mutex
+	recent   []Event
+
+	events chan Event
+
+	statsMu sync.Mutex
+	stats   Stats
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewIndex validates opts and returns an Index ready to Start. It does not
+// touch the filesystem yet.
+func NewIndex(opts Options) (*Index, error) {
+	if opts.Root == "" {
+		return nil, fmt.Errorf("workspace: Root is required")
+	}
+	opts = opts.withDefaults()
+
+	return &Index{
+		opts:   opts,
+		files:  make(map[string]int64),
+		events: make(chan Event, 256),
+		stats:  Stats{Root: opts.Root, WatcherMode: "poll"},
+	}, nil
+}
+
+// Events returns the channel Event values are published on. Start must be
+// called for anything to arrive on it. The channel is never closed while
+// the Index is running; it's closed by Stop.
+func (idx *Index) Events() <-chan Event {
+	return idx.events
+}
+
+// Start loads the persisted map (if any), performs one parallel scan of
+// Root diffed against it, persists the result, and then keeps the index
+// current: via fsnotify if it can watch Root successfully, falling back to
+// a poll-rescan loop on PollInterval otherwise (this is the common case
+// inside a Docker bind mount or a WSL9p-backed directory, where inotify
+// either isn't delivered at all or is delivered for the wrong path).
+// Start returns once the initial scan completes; the watcher/poll loop
+// keeps running in the background until ctx is cancelled or Stop is
+// called.
+func (idx *Index) Start(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+	idx.cancel = cancel
+	idx.done = make(chan struct{})
+
+	idx.loadPersisted()
+
+	if err := idx.scanAndDiff(ctx, true); err != nil {
+		cancel()
+		return fmt.Errorf("workspace: initial scan failed: %w", err)
+	}
+
+	watcher, dirs, err := idx.startFsnotify()
+	if err != nil {
+		logger.Warn("workspace: fsnotify unavailable, falling back to poll rescan", "root", idx.opts.Root, "error", err)
+		idx.setWatcherMode("poll")
+		go idx.pollLoop(ctx)
+	} else {
+		logger.Info("workspace: watching with fsnotify", "root", idx.opts.Root, "dirs", len(dirs))
+		idx.setWatcherMode("fsnotify")
+		go idx.watchFsnotify(ctx, watcher)
+	}
+
+	go func() {
+		<-ctx.Done()
+		close(idx.done)
+	}()
+
+	return nil
+}
+
+// Stop tears down the watcher/poll loop started by Start. Safe to call
+// more than once.
+func (idx *Index) Stop() {
+	if idx.cancel != nil {
+		idx.cancel()
+	}
+}
+
+// Stats returns a snapshot of the index's current health and activity.
+func (idx *Index) Stats() Stats {
+	idx.statsMu.Lock()
+	defer idx.statsMu.Unlock()
+
+	s := idx.stats
+
+	idx.mu.RLock()
+	s.TotalFiles = len(idx.files)
+	idx.mu.RUnlock()
+
+	if s.WatcherMode == "poll" && idx.opts.PollInterval > 0 {
+		s.DutyCyclePercent = s.LastScanDuration.Seconds() / idx.opts.PollInterval.Seconds() * 100
+	}
+
+	return s
+}
+
+// ChangedSince returns every Event the index has observed (scan diff,
+// watcher, or poll rescan) with Seen after since, oldest first. It only
+// searches the in-memory log (recentEventsCap entries); a caller asking
+// about a point further back than that should treat the result as a lower
+// bound, not an exhaustive diff.
+func (idx *Index) ChangedSince(since time.Time) []Event {
+	idx.eventsMu.Lock()
+	defer idx.eventsMu.Unlock()
+
+	out := make([]Event, 0, len(idx.recent))
+	for _, ev := range idx.recent {
+		if ev.Seen.After(since) {
+			out = append(out, ev)
+		}
+	}
+	return out
+}
+
+func (idx *Index) setWatcherMode(mode string) {
+	idx.statsMu.Lock()
+	idx.stats.WatcherMode = mode
+	idx.statsMu.Unlock()
+}
+
+// emit records ev in the recent-event log and publishes it on Events(),
+// dropping the event on the floor (logged) rather than blocking forever if
+// nobody is draining the channel.
+func (idx *Index) emit(ev Event) {
+	idx.eventsMu.Lock()
+	idx.recent = append(idx.recent, ev)
+	if len(idx.recent) > recentEventsCap {
+		idx.recent = idx.recent[len(idx.recent)-recentEventsCap:]
+	}
+	idx.eventsMu.Unlock()
+
+	idx.statsMu.Lock()
+	idx.stats.LastEventAt = ev.Seen
+	idx.statsMu.Unlock()
+
+	select {
+	case idx.events <- ev:
+	default:
+		logger.Warn("workspace: events channel full, dropping event", "path", ev.Path, "op", string(ev.Op))
+	}
+}
+
+// extFilter returns an fswalk.Filter honoring idx.opts.Extensions, for the
+// initial scan and poll rescans (which walk via fswalk.Walk and so have a
+// real os.DirEntry to hand it).
+func (idx *Index) extFilter() fswalk.Filter {
+	return fswalk.ExtFilter(idx.opts.Extensions...)
+}
+
+// matchesExt reports whether path's extension is one idx.opts.Extensions
+// tracks. Used by the fsnotify path, which only has a filename - not a real
+// os.DirEntry - to check it against.
+func (idx *Index) matchesExt(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	for _, e := range idx.opts.Extensions {
+		if strings.ToLower(e) == ext {
+			return true
+		}
+	}
+	return false
+}
+
+// scanAndDiff performs one fswalk.Walk over Root, builds a fresh path->mtime
+// map, diffs it against the previous one (emitting created/changed/deleted
+// events unless initial is true, in which case the first scan seeds the map
+// silently - matching persisted state from a previous run rather than
+// reporting every file as "created" on every restart), swaps the new map
+// in, and persists it.
+func (idx *Index) scanAndDiff(ctx context.Context, initial bool) error {
+	start := time.Now()
+
+	fresh := make(map[string]int64)
+	var freshMu sync.Mutex
+
+	err := fswalk.Walk(ctx, idx.opts.Root, fswalk.Options{
+		Workers: idx.opts.Workers,
+		Filter:  idx.extFilter(),
+		SkipDir: idx.opts.SkipDir,
+	}, func(path string, d os.DirEntry) error {
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		mtime := info.ModTime().Unix()
+
+		freshMu.Lock()
+		fresh[path] = mtime
+		freshMu.Unlock()
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	idx.mu.Lock()
+	previous := idx.files
+	idx.files = fresh
+	idx.mu.Unlock()
+
+	if !initial {
+		idx.diffAndEmit(previous, fresh)
+	} else {
+		// Still report files that changed (or disappeared) while the
+		// index wasn't running, against whatever was persisted - just not
+		// as a flood of "created" for a workspace seen for the first time.
+		if len(previous) > 0 {
+			idx.diffAndEmit(previous, fresh)
+		}
+	}
+
+	idx.persist()
+
+	duration := time.Since(start)
+	idx.statsMu.Lock()
+	idx.stats.ScanCount++
+	idx.stats.LastScanAt = start
+	idx.stats.LastScanDuration = duration
+	idx.statsMu.Unlock()
+
+	return nil
+}
+
+// diffAndEmit compares previous and fresh path->mtime maps and emits one
+// Event per file that was added, modified, or removed.
+func (idx *Index) diffAndEmit(previous, fresh map[string]int64) {
+	now := time.Now()
+
+	for path, mtime := range fresh {
+		if prevMtime, ok := previous[path]; !ok {
+			idx.emit(Event{Path: path, Op: EventCreated, Mtime: mtime, Seen: now})
+		} else if prevMtime != mtime {
+			idx.emit(Event{Path: path, Op: EventChanged, Mtime: mtime, Seen: now})
+		}
+	}
+	for path := range previous {
+		if _, ok := fresh[path]; !ok {
+			idx.emit(Event{Path: path, Op: EventDeleted, Seen: now})
+		}
+	}
+}
+
+// pollLoop rescans Root every PollInterval, diffing against the previous
+// scan, until ctx is cancelled. Used only when startFsnotify failed.
+func (idx *Index) pollLoop(ctx context.Context) {
+	ticker := time.NewTicker(idx.opts.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := idx.scanAndDiff(ctx, false); err != nil {
+				logger.Warn("workspace: poll rescan failed", "root", idx.opts.Root, "error", err)
+			}
+		}
+	}
+}
+
+// persist writes the current path->mtime map to opts.PersistPath, via a
+// temp-file-then-rename so a crash mid-write never leaves a truncated,
+// unreadable file behind. Failures are logged, not returned: a missed
+// persist just means the next restart re-diffs more than it needed to.
+func (idx *Index) persist() {
+	idx.mu.RLock()
+	snapshot := make(map[string]int64, len(idx.files))
+	for k, v := range idx.files {
+		snapshot[k] = v
+	}
+	idx.mu.RUnlock()
+
+	if err := os.MkdirAll(filepath.Dir(idx.opts.PersistPath), 0o755); err != nil {
+		logger.Warn("workspace: failed to create persist dir", "path", idx.opts.PersistPath, "error", err)
+		return
+	}
+
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		logger.Warn("workspace: failed to marshal index", "error", err)
+		return
+	}
+
+	tmp := idx.opts.PersistPath + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		logger.Warn("workspace: failed to write index", "path", tmp, "error", err)
+		return
+	}
+	if err := os.Rename(tmp, idx.opts.PersistPath); err != nil {
+		logger.Warn("workspace: failed to finalize index", "path", idx.opts.PersistPath, "error", err)
+	}
+}
+
+// loadPersisted reads opts.PersistPath into idx.files, if present. A
+// missing or corrupt file just means the first scan treats every match as
+// new (per scanAndDiff's initial-scan handling) - not an error.
+func (idx *Index) loadPersisted() {
+	data, err := os.ReadFile(idx.opts.PersistPath)
+	if err != nil {
+		return
+	}
+
+	var loaded map[string]int64
+	if err := json.Unmarshal(data, &loaded); err != nil {
+		logger.Warn("workspace: failed to parse persisted index, starting fresh", "path", idx.opts.PersistPath, "error", err)
+		return
+	}
+
+	idx.mu.Lock()
+	idx.files = loaded
+	idx.mu.Unlock()
+}
+
+// startFsnotify creates a watcher and recursively adds every directory
+// under Root that the initial scan would accept (honoring SkipDir), mostly
+// so a Docker/WSL bind mount that silently accepts the watch calls but
+// never actually delivers events isn't distinguishable here - that's
+// exactly the platform case the poll fallback exists for, at the next
+// layer up (the MCP-side posture checks, not this package, are the place
+// that would ever warn about it explicitly).
+func (idx *Index) startFsnotify() (*fsnotify.Watcher, []string, error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var dirs []string
+	err = filepath.WalkDir(idx.opts.Root, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if path != idx.opts.Root && idx.opts.SkipDir != nil && idx.opts.SkipDir(path, d) {
+			return filepath.SkipDir
+		}
+		if werr := watcher.Add(path); werr != nil {
+			logger.Debug(fmt.Sprintf("workspace: failed to watch %s: %v", path, werr))
+			return nil
+		}
+		dirs = append(dirs, path)
+		return nil
+	})
+	if err != nil {
+		watcher.Close()
+		return nil, nil, err
+	}
+	if len(dirs) == 0 {
+		watcher.Close()
+		return nil, nil, fmt.Errorf("workspace: no directories could be watched under %s", idx.opts.Root)
+	}
+
+	return watcher, dirs, nil
+}
+
+// watchFsnotify translates fsnotify.Events into Event values until ctx is
+// cancelled, and adds newly-created directories to the watch set so the
+// recursive coverage startFsnotify established doesn't go stale as the
+// workspace grows.
+func (idx *Index) watchFsnotify(ctx context.Context, watcher *fsnotify.Watcher) {
+	defer watcher.Close()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case ev, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+			idx.handleFsnotifyEvent(watcher, ev)
+
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
+			logger.Warn("workspace: fsnotify error", "root", idx.opts.Root, "error", err)
+		}
+	}
+}
+
+func (idx *Index) handleFsnotifyEvent(watcher *fsnotify.Watcher, ev fsnotify.Event) {
+	info, statErr := os.Stat(ev.Name)
+
+	if info != nil && info.IsDir() {
+		if ev.Op&(fsnotify.Create) != 0 {
+			if idx.opts.SkipDir == nil || !idx.opts.SkipDir(ev.Name, fs.FileInfoToDirEntry(info)) {
+				if err := watcher.Add(ev.Name); err != nil {
+					logger.Debug(fmt.Sprintf("workspace: failed to watch new dir %s: %v", ev.Name, err))
+				}
+			}
+		}
+		return
+	}
+
+	if !idx.matchesExt(ev.Name) {
+		return
+	}
+
+	now := time.Now()
+
+	switch {
+	case ev.Op&fsnotify.Remove != 0, ev.Op&fsnotify.Rename != 0:
+		idx.mu.Lock()
+		_, existed := idx.files[ev.Name]
+		delete(idx.files, ev.Name)
+		idx.mu.Unlock()
+		if existed {
+			idx.emit(Event{Path: ev.Name, Op: EventDeleted, Seen: now})
+			idx.persist()
+		}
+
+	case ev.Op&(fsnotify.Write|fsnotify.Create) != 0:
+		if statErr != nil {
+			return
+		}
+		mtime := info.ModTime().Unix()
+
+		idx.mu.Lock()
+		prevMtime, existed := idx.files[ev.Name]
+		idx.files[ev.Name] = mtime
+		idx.mu.Unlock()
+
+		op := EventChanged
+		if !existed {
+			op = EventCreated
+		} else if prevMtime == mtime {
+			return
+		}
+		idx.emit(Event{Path: ev.Name, Op: op, Mtime: mtime, Seen: now})
+		idx.persist()
+	}
+}
@@ -0,0 +1,106 @@
+package workspace
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeFile(t *testing.T, root, rel, content string) {
+	t.Helper()
+	path := filepath.Join(root, rel)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestNewIndexRequiresRoot(t *testing.T) {
+	if _, err := NewIndex(Options{}); err == nil {
+		t.Fatal("expected an error when Root is empty")
+	}
+}
+
+// TestScanAndDiffEmitsEventsAfterInitialScan checks that the first scan
+// seeds the map silently (no flood of "created" events for a workspace
+// observed for the first time), while a subsequent scan reports new,
+// modified, and removed files relative to it.
+func TestScanAndDiffEmitsEventsAfterInitialScan(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, root, "Catalogs/Module.bsl", "a")
+
+	idx, err := NewIndex(Options{Root: root, PersistPath: filepath.Join(root, "index.json")})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := idx.scanAndDiff(context.Background(), true); err != nil {
+		t.Fatal(err)
+	}
+	if got := idx.ChangedSince(time.Time{}); len(got) != 0 {
+		t.Fatalf("expected no events from the initial scan, got %d", len(got))
+	}
+
+	writeFile(t, root, "Catalogs/New.bsl", "x") // created
+
+	modified := filepath.Join(root, "Catalogs/Module.bsl")
+	writeFile(t, root, "Catalogs/Module.bsl", "ab")
+	newMtime := time.Now().Add(time.Minute) // force a detectably later mtime regardless of filesystem resolution
+	if err := os.Chtimes(modified, newMtime, newMtime); err != nil {
+		t.Fatal(err)
+	}
+
+	before := time.Now()
+	time.Sleep(10 * time.Millisecond)
+
+	if err := idx.scanAndDiff(context.Background(), false); err != nil {
+		t.Fatal(err)
+	}
+
+	events := idx.ChangedSince(before)
+	if len(events) == 0 {
+		t.Fatal("expected at least one event from the second scan")
+	}
+
+	var sawCreated bool
+	for _, ev := range events {
+		if ev.Op == EventCreated && filepath.Base(ev.Path) == "New.bsl" {
+			sawCreated = true
+		}
+	}
+	if !sawCreated {
+		t.Errorf("expected a created event for New.bsl, got %+v", events)
+	}
+}
+
+func TestPersistAndLoadRoundtrip(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, root, "Module.bsl", "a")
+
+	persistPath := filepath.Join(root, ".mcp-lsp-bridge", "workspace_index.json")
+	idx, err := NewIndex(Options{Root: root, PersistPath: persistPath})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := idx.scanAndDiff(context.Background(), true); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := os.Stat(persistPath); err != nil {
+		t.Fatalf("expected persisted index at %s: %v", persistPath, err)
+	}
+
+	reopened, err := NewIndex(Options{Root: root, PersistPath: persistPath})
+	if err != nil {
+		t.Fatal(err)
+	}
+	reopened.loadPersisted()
+
+	if stats := reopened.Stats(); stats.TotalFiles != 1 {
+		t.Errorf("expected 1 file loaded from persisted state, got %d", stats.TotalFiles)
+	}
+}